@@ -2,23 +2,40 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
 
 	"github.com/dejo1307/archmcp/internal/config"
 	"github.com/dejo1307/archmcp/internal/engine"
 	"github.com/dejo1307/archmcp/internal/facts"
 	"github.com/dejo1307/archmcp/internal/explainers/cycles"
+	"github.com/dejo1307/archmcp/internal/explainers/hotspots"
 	"github.com/dejo1307/archmcp/internal/explainers/layers"
+	"github.com/dejo1307/archmcp/internal/extractors/cppextractor"
+	"github.com/dejo1307/archmcp/internal/extractors/dockerextractor"
+	"github.com/dejo1307/archmcp/internal/extractors/elixirextractor"
 	"github.com/dejo1307/archmcp/internal/extractors/goextractor"
 	"github.com/dejo1307/archmcp/internal/extractors/kotlinextractor"
 	"github.com/dejo1307/archmcp/internal/extractors/openapiextractor"
+	"github.com/dejo1307/archmcp/internal/extractors/protoextractor"
 	"github.com/dejo1307/archmcp/internal/extractors/pythonextractor"
 	"github.com/dejo1307/archmcp/internal/extractors/rubyextractor"
+	"github.com/dejo1307/archmcp/internal/extractors/scalaextractor"
+	"github.com/dejo1307/archmcp/internal/extractors/sqlextractor"
 	"github.com/dejo1307/archmcp/internal/extractors/swiftextractor"
 	"github.com/dejo1307/archmcp/internal/extractors/tsextractor"
+	"github.com/dejo1307/archmcp/internal/renderers"
+	"github.com/dejo1307/archmcp/internal/renderers/insightsmd"
+	"github.com/dejo1307/archmcp/internal/renderers/jsongraph"
 	"github.com/dejo1307/archmcp/internal/renderers/llmcontext"
 	"github.com/dejo1307/archmcp/internal/server"
 )
@@ -29,32 +46,94 @@ func main() {
 
 	ctx := context.Background()
 
-	// Check for --generate flag
+	// Check for --generate, --watch, --stdout, --format, --facts and --profile flags
 	generateMode := false
+	watchMode := false
+	stdoutMode := false
+	format := "text"
+	factsPath := ""
+	profileDir := ""
 	cfgPath := "mcp-arch.yaml"
-	for _, arg := range os.Args[1:] {
-		if arg == "--generate" {
+	args := os.Args[1:]
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--generate":
 			generateMode = true
-		} else {
+		case arg == "--watch":
+			watchMode = true
+		case arg == "--stdout":
+			stdoutMode = true
+		case arg == "--format":
+			// Never fall through to the config path when the value is
+			// missing; an unknown value is rejected below.
+			if i+1 == len(args) || strings.HasPrefix(args[i+1], "--") {
+				log.Fatalf("--format requires a value: text or json")
+			}
+			i++
+			format = args[i]
+		case strings.HasPrefix(arg, "--format="):
+			format = strings.TrimPrefix(arg, "--format=")
+		case arg == "--facts" && i+1 < len(args):
+			i++
+			factsPath = args[i]
+		case strings.HasPrefix(arg, "--facts="):
+			factsPath = strings.TrimPrefix(arg, "--facts=")
+		case arg == "--profile" && i+1 < len(args):
+			i++
+			profileDir = args[i]
+		case strings.HasPrefix(arg, "--profile="):
+			profileDir = strings.TrimPrefix(arg, "--profile=")
+		default:
 			cfgPath = arg
 		}
 	}
+	if format == "" {
+		log.Fatalf("--format requires a value: text or json")
+	}
+	if format != "text" && format != "json" {
+		log.Fatalf("unknown --format %q: must be text or json", format)
+	}
+	if stdoutMode && !generateMode {
+		log.Fatalf("--stdout prints the llm_context of a --generate run and requires --generate")
+	}
+	if stdoutMode && format == "json" {
+		log.Fatalf("--stdout prints the llm_context of a --generate run and cannot be combined with --format json")
+	}
+	if factsPath != "" && (generateMode || watchMode) {
+		log.Fatalf("--facts serves a prebuilt snapshot and cannot be combined with --generate or --watch")
+	}
+	if profileDir != "" && !generateMode {
+		log.Fatalf("--profile profiles a --generate run and requires --generate")
+	}
 
 	// If the config path is relative, resolve it first against the current
 	// working directory, then (as a fallback) against the directory containing
 	// the binary itself. This ensures the config is found when Cursor starts
 	// the MCP server from a different working directory.
+	// An invalid ARCHMCP_* variable is fatal as is, without the "using
+	// defaults" warning: the defaults would fail on it too.
+	var envErr *config.EnvError
 	cfg, err := config.Load(cfgPath)
-	if err != nil && !filepath.IsAbs(cfgPath) {
+	if err != nil && !errors.As(err, &envErr) && !filepath.IsAbs(cfgPath) {
 		if exePath, exErr := os.Executable(); exErr == nil {
 			exeDir := filepath.Dir(exePath)
 			cfg, err = config.Load(filepath.Join(exeDir, cfgPath))
 		}
 	}
+	if errors.As(err, &envErr) {
+		log.Fatalf("invalid config: %v", err)
+	}
 	if err != nil {
 		// If config file doesn't exist, use defaults
-		fmt.Fprintf(os.Stderr, "warning: %v, using defaults\n", err)
 		cfg = config.Default()
+		if envErr := cfg.ApplyEnv(); envErr != nil {
+			log.Fatalf("invalid config: %v", envErr)
+		}
+		fmt.Fprintf(os.Stderr, "warning: %v, using defaults\n", err)
+	}
+	if watchMode {
+		cfg.Watch.Enabled = true
 	}
 
 	eng, err := engine.New(cfg)
@@ -63,29 +142,65 @@ func main() {
 	}
 
 	// Register extractors
-	eng.RegisterExtractor(goextractor.New())
+	eng.RegisterExtractor(goextractor.NewWithOptions(goextractor.Options{
+		DocComments:     cfg.Go.DocComments,
+		CoverageProfile: cfg.Go.CoverageProfile,
+		Tests:           cfg.Go.Tests,
+		GOOS:            cfg.Go.GOOS,
+		GOARCH:          cfg.Go.GOARCH,
+		BuildTags:       cfg.Go.BuildTags,
+	}))
 	eng.RegisterExtractor(kotlinextractor.New())
 	eng.RegisterExtractor(openapiextractor.New())
 	eng.RegisterExtractor(pythonextractor.New())
 	eng.RegisterExtractor(tsextractor.New())
 	eng.RegisterExtractor(swiftextractor.New())
 	eng.RegisterExtractor(rubyextractor.New())
+	eng.RegisterExtractor(sqlextractor.New())
+	eng.RegisterExtractor(scalaextractor.New())
+	eng.RegisterExtractor(elixirextractor.New())
+	eng.RegisterExtractor(dockerextractor.New())
+	eng.RegisterExtractor(cppextractor.New())
+	eng.RegisterExtractor(protoextractor.New())
 
 	// Register explainers
-	eng.RegisterExplainer(cycles.New())
+	eng.RegisterExplainer(cycles.NewWithGranularity(cfg.Cycles.Granularity))
 	eng.RegisterExplainer(layers.New())
+	eng.RegisterExplainer(hotspots.New(cfg.Hotspots.MaxSymbols, cfg.Hotspots.MaxCoupling, cfg.Hotspots.MaxMethods))
 
-	// Register renderers
-	eng.RegisterRenderer(llmcontext.New(cfg.Output.MaxContextTokens))
+	// Register renderers. Every renderer is registered; the renderers (or
+	// output.renderers) config list decides which ones run.
+	for _, rnd := range []renderers.Renderer{
+		llmcontext.New(cfg.Output.MaxContextTokens),
+		jsongraph.New(cfg.JSONGraph.MaxNodes, renderers.LabelTrim{
+			StripPrefix: cfg.Output.LabelTrim.StripPrefix,
+			Segments:    cfg.Output.LabelTrim.Segments,
+		}),
+		insightsmd.New(),
+	} {
+		eng.RegisterRenderer(rnd)
+	}
 
 	// One-shot generation mode
 	if generateMode {
-		repoPath, err := filepath.Abs(cfg.Repo)
+		repoPath, err := cfg.RepoPath()
 		if err != nil {
 			log.Fatalf("failed to resolve repo path: %v", err)
 		}
 
+		var stopProfile func() error
+		if profileDir != "" {
+			if stopProfile, err = startProfile(profileDir); err != nil {
+				log.Fatalf("failed to start profiling: %v", err)
+			}
+		}
 		snapshot, err := eng.GenerateSnapshot(ctx, repoPath, false)
+		if stopProfile != nil {
+			if err := stopProfile(); err != nil {
+				log.Fatalf("failed to write profiles: %v", err)
+			}
+			log.Printf("[main] wrote cpu.pprof and mem.pprof to %s", profileDir)
+		}
 		if err != nil {
 			log.Fatalf("snapshot generation failed: %v", err)
 		}
@@ -94,21 +209,47 @@ func main() {
 			log.Fatalf("failed to write artifacts: %v", err)
 		}
 
+		outDir := filepath.Join(repoPath, cfg.Output.Dir)
+		if stdoutMode {
+			if err := writeLLMContext(ctx, os.Stdout, eng, snapshot); err != nil {
+				log.Fatalf("failed to write llm_context: %v", err)
+			}
+			os.Exit(0)
+		}
+		if format == "json" {
+			if err := writeGenerateReport(os.Stdout, snapshot, outDir); err != nil {
+				log.Fatalf("failed to write report: %v", err)
+			}
+			os.Exit(0)
+		}
+
 		fmt.Fprintf(os.Stderr, "\nSnapshot complete:\n")
 		fmt.Fprintf(os.Stderr, "  Repository:  %s\n", snapshot.Meta.RepoPath)
+		fmt.Fprintf(os.Stderr, "  Parsed:      %d/%d files, %d errors\n", snapshot.Meta.FilesParsed(), snapshot.Meta.FileCount, len(snapshot.Meta.Errors))
 		fmt.Fprintf(os.Stderr, "  Facts:       %d\n", snapshot.Meta.FactCount)
 		fmt.Fprintf(os.Stderr, "  Insights:    %d\n", snapshot.Meta.InsightCount)
 		fmt.Fprintf(os.Stderr, "  Artifacts:   %d\n", len(snapshot.Artifacts))
 		fmt.Fprintf(os.Stderr, "  Duration:    %s\n", snapshot.Meta.Duration)
-		fmt.Fprintf(os.Stderr, "  Output:      %s\n", filepath.Join(repoPath, cfg.Output.Dir))
+		for _, st := range snapshot.Meta.ExtractorStats {
+			fmt.Fprintf(os.Stderr, "    %-12s %s (%d facts, %d files)\n", st.Name+":", st.Duration, st.Facts, st.Files)
+		}
+		fmt.Fprintf(os.Stderr, "  Output:      %s\n", outDir)
 		os.Exit(0)
 	}
 
-	// Auto-load existing snapshot if available (so queries work immediately
-	// without requiring a generate_snapshot call first).
-	if repoPath, err := filepath.Abs(cfg.Repo); err == nil {
-		factsPath := filepath.Join(repoPath, cfg.Output.Dir, "facts.jsonl")
-		if _, err := os.Stat(factsPath); err == nil {
+	// Load a prebuilt facts file when one is given, or else an existing
+	// snapshot of the repo, so queries work immediately without requiring a
+	// generate_snapshot call first.
+	if factsPath != "" {
+		if _, err := eng.LoadFactsFile(factsPath); err != nil {
+			log.Fatalf("failed to load facts: %v", err)
+		}
+		if cfg.Watch.Enabled {
+			log.Printf("[main] watch mode disabled: serving prebuilt facts from %s", factsPath)
+			cfg.Watch.Enabled = false
+		}
+	} else if repoPath, err := cfg.RepoPath(); err == nil {
+		if factsPath := engine.FactsFile(filepath.Join(repoPath, cfg.Output.Dir), cfg.Output.Compress); factsPath != "" {
 			log.Printf("[main] loading existing snapshot from %s", factsPath)
 			if err := eng.Store().ReadJSONLFile(factsPath); err != nil {
 				log.Printf("[main] warning: failed to load existing facts: %v", err)
@@ -130,7 +271,109 @@ func main() {
 		log.Fatalf("failed to create server: %v", err)
 	}
 
+	// Keep the snapshot fresh in the background when watch mode is enabled.
+	if cfg.Watch.Enabled {
+		go func() {
+			debounce := time.Duration(cfg.Watch.DebounceMs) * time.Millisecond
+			repoPath, err := cfg.RepoPath()
+			if err == nil {
+				err = eng.Watch(ctx, repoPath, debounce)
+			}
+			if err != nil {
+				log.Printf("[main] watch mode stopped: %v", err)
+			}
+		}()
+	}
+
 	if err := srv.Run(ctx); err != nil {
 		log.Fatalf("server error: %v", err)
 	}
 }
+
+// writeLLMContext writes the llm_context.md of snapshot, rendering it when
+// the llm_context renderer is not enabled.
+func writeLLMContext(ctx context.Context, w io.Writer, eng *engine.Engine, snapshot *facts.Snapshot) error {
+	for _, a := range snapshot.Artifacts {
+		if a.Name == "llm_context.md" {
+			_, err := w.Write(a.Content)
+			return err
+		}
+	}
+	content, err := eng.RenderArtifact(ctx, "llm_context", "llm_context.md", 0)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// startProfile starts writing a CPU profile to dir/cpu.pprof, creating dir
+// if needed. The returned function stops it and writes a heap profile of
+// the live objects to dir/mem.pprof.
+func startProfile(dir string) (func() error, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	cpu, err := os.Create(filepath.Join(dir, "cpu.pprof"))
+	if err != nil {
+		return nil, err
+	}
+	if err := pprof.StartCPUProfile(cpu); err != nil {
+		cpu.Close()
+		return nil, err
+	}
+	return func() error {
+		pprof.StopCPUProfile()
+		if err := cpu.Close(); err != nil {
+			return err
+		}
+		mem, err := os.Create(filepath.Join(dir, "mem.pprof"))
+		if err != nil {
+			return err
+		}
+		runtime.GC() // up-to-date statistics for the heap profile
+		if err := pprof.WriteHeapProfile(mem); err != nil {
+			mem.Close()
+			return err
+		}
+		return mem.Close()
+	}, nil
+}
+
+// generateReport is the --format json summary of a --generate run.
+type generateReport struct {
+	Meta        facts.SnapshotMeta `json:"meta"`
+	FilesParsed int                `json:"files_parsed"`
+	OutputDir   string             `json:"output_dir"`
+	Artifacts   []artifactInfo     `json:"artifacts"`
+}
+
+type artifactInfo struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Type string `json:"type"`
+	Size int    `json:"size"`
+}
+
+// writeGenerateReport writes the snapshot meta and the artifact list as
+// JSON. File hashes are left out; snapshot.meta.json has them.
+func writeGenerateReport(w io.Writer, snapshot *facts.Snapshot, outDir string) error {
+	report := generateReport{
+		Meta:        snapshot.Meta,
+		FilesParsed: snapshot.Meta.FilesParsed(),
+		OutputDir:   outDir,
+		Artifacts:   []artifactInfo{},
+	}
+	report.Meta.FileHashes = nil
+	for _, a := range snapshot.Artifacts {
+		report.Artifacts = append(report.Artifacts, artifactInfo{
+			Name: a.Name,
+			Path: filepath.Join(outDir, a.Name),
+			Type: a.Type,
+			Size: len(a.Content),
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
@@ -3,6 +3,7 @@ module github.com/dejo1307/archmcp
 go 1.25.1
 
 require (
+	github.com/fsnotify/fsnotify v1.8.0
 	github.com/modelcontextprotocol/go-sdk v1.3.0
 	github.com/tree-sitter/go-tree-sitter v0.24.0
 	github.com/tree-sitter/tree-sitter-typescript v0.23.2
@@ -14,4 +15,5 @@ require (
 	github.com/mattn/go-pointer v0.0.1 // indirect
 	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
 	golang.org/x/oauth2 v0.30.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
 )
@@ -3,24 +3,206 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/dejo1307/archmcp/internal/facts"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the mcp-arch.yaml configuration.
 type Config struct {
-	Repo       string       `yaml:"repo"`
-	Ignore     []string     `yaml:"ignore"`
-	Extractors []string     `yaml:"extractors"`
-	Explainers []string     `yaml:"explainers"`
-	Renderers  []string     `yaml:"renderers"`
-	Output     OutputConfig `yaml:"output"`
+	Repo       string          `yaml:"repo" json:"repo"`
+	Ignore     []string        `yaml:"ignore" json:"ignore"`
+	Extractors []string        `yaml:"extractors" json:"extractors"`
+	Explainers []string        `yaml:"explainers" json:"explainers"`
+	Renderers  []string        `yaml:"renderers" json:"renderers"`
+	Output     OutputConfig    `yaml:"output" json:"output"`
+	Watch      WatchConfig     `yaml:"watch" json:"watch"`
+	Walk       WalkConfig      `yaml:"walk" json:"walk"`
+	Generate   GenerateConfig  `yaml:"generate" json:"generate"`
+	Hotspots   HotspotsConfig  `yaml:"hotspots" json:"hotspots"`
+	Go         GoConfig        `yaml:"go" json:"go"`
+	JSONGraph  JSONGraphConfig `yaml:"json_graph" json:"json_graph"`
+	Cycles     CyclesConfig    `yaml:"cycles" json:"cycles"`
+	Graph      GraphConfig     `yaml:"graph" json:"graph"`
+	// SourceCache bounds the in-memory cache of source files read by
+	// show_symbol and grep_source.
+	SourceCache SourceCacheConfig `yaml:"source_cache" json:"source_cache"`
+	// FeatureFlags configures the detection of feature flag reads.
+	FeatureFlags FeatureFlagsConfig `yaml:"feature_flags" json:"feature_flags"`
+	// CaseInsensitivePaths makes file filters and path normalization ignore
+	// case, for repositories checked out on case-insensitive filesystems
+	// (macOS, Windows). Stored fact paths keep their case.
+	CaseInsensitivePaths bool `yaml:"case_insensitive_paths" json:"case_insensitive_paths,omitempty"`
+	// RepoRoot is the base directory relative repository paths given to
+	// tools resolve against (see ResolvePath). Unset, they resolve against
+	// the server's working directory.
+	RepoRoot string `yaml:"repo_root" json:"repo_root,omitempty"`
+
+	// Source is the path the config was loaded from, or "" when defaults are in use.
+	Source string `yaml:"-" json:"source"`
+	// EnvOverrides lists the environment variables that overrode file or
+	// default values (see ApplyEnv).
+	EnvOverrides []string `yaml:"-" json:"env_overrides,omitempty"`
 }
 
 // OutputConfig controls where and how output artifacts are generated.
 type OutputConfig struct {
-	Dir              string `yaml:"dir"`
-	MaxContextTokens int    `yaml:"max_context_tokens"`
+	Dir              string `yaml:"dir" json:"dir"`
+	MaxContextTokens int    `yaml:"max_context_tokens" json:"max_context_tokens"`
+	// Renderers, when set, selects the renderers to run and takes precedence
+	// over the top-level renderers list.
+	Renderers []string `yaml:"renderers" json:"renderers,omitempty"`
+	// LabelTrim shortens node labels in graph renderers; node IDs keep the
+	// full fact names.
+	LabelTrim LabelTrimConfig `yaml:"label_trim" json:"label_trim"`
+	// Compress writes the facts as gzip-compressed facts.jsonl.gz instead
+	// of facts.jsonl.
+	Compress bool `yaml:"compress" json:"compress"`
+}
+
+// LabelTrimConfig controls how graph renderers shorten node labels. When
+// neither field is set, labels show the last path segment of the name.
+type LabelTrimConfig struct {
+	// StripPrefix is removed from the start of module and symbol names
+	// (e.g. "internal" turns "internal/server.New" into "server.New").
+	StripPrefix string `yaml:"strip_prefix" json:"strip_prefix,omitempty"`
+	// Segments, when positive, keeps only the last N path segments.
+	Segments int `yaml:"segments" json:"segments,omitempty"`
+}
+
+// WatchConfig controls background regeneration on file changes.
+type WatchConfig struct {
+	Enabled    bool `yaml:"enabled" json:"enabled"`
+	DebounceMs int  `yaml:"debounce_ms" json:"debounce_ms"`
+}
+
+// WalkConfig controls how the repository tree is walked.
+type WalkConfig struct {
+	// FollowSymlinks walks into symlinked directories. Symlink cycles are
+	// broken, and files are recorded under their real path when it lies
+	// inside the repository.
+	FollowSymlinks bool `yaml:"follow_symlinks" json:"follow_symlinks"`
+}
+
+// GenerateConfig bounds a single snapshot generation so a runaway extractor
+// or an unexpectedly large tree cannot hang the server.
+type GenerateConfig struct {
+	// Timeout is the deadline for a whole generation, as a Go duration
+	// ("90s", "10m"). Empty means no limit.
+	Timeout string `yaml:"timeout" json:"timeout,omitempty"`
+	// MaxFiles aborts generation when the walk finds more files than this.
+	// Zero means no limit.
+	MaxFiles int `yaml:"max_files" json:"max_files,omitempty"`
+	// MaxFacts caps the facts one generation may extract. Zero means no
+	// limit. What happens above the cap depends on MaxFactsStrategy.
+	MaxFacts int `yaml:"max_facts" json:"max_facts,omitempty"`
+	// MaxFactsStrategy is "error" (default), which fails the generation,
+	// or "sample", which keeps every non-symbol fact and caps the symbols
+	// kept per module so the total fits.
+	MaxFactsStrategy string `yaml:"max_facts_strategy" json:"max_facts_strategy,omitempty"`
+	// MaxSymbolsPerModule, in sample mode, additionally caps the symbols
+	// kept per module. Zero derives the cap from MaxFacts alone.
+	MaxSymbolsPerModule int `yaml:"max_symbols_per_module" json:"max_symbols_per_module,omitempty"`
+}
+
+// Strategies for GenerateConfig.MaxFactsStrategy.
+const (
+	MaxFactsError  = "error"
+	MaxFactsSample = "sample"
+)
+
+// TimeoutDuration returns the parsed Timeout, or 0 when it is unset or
+// invalid. Load rejects invalid values.
+func (g GenerateConfig) TimeoutDuration() time.Duration {
+	if g.Timeout == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(g.Timeout)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// HotspotsConfig holds the thresholds used by the hotspots explainer.
+// A zero value disables the corresponding check.
+type HotspotsConfig struct {
+	MaxSymbols  int `yaml:"max_symbols" json:"max_symbols"`   // symbols declared in one module
+	MaxCoupling int `yaml:"max_coupling" json:"max_coupling"` // fan-in + fan-out of one module
+	MaxMethods  int `yaml:"max_methods" json:"max_methods"`   // methods defined on one type
+}
+
+// CyclesConfig controls the cycles explainer.
+type CyclesConfig struct {
+	// Granularity is the node set cycles are detected on: "module" (default),
+	// "file", or "symbol".
+	Granularity string `yaml:"granularity" json:"granularity"`
+}
+
+// GraphConfig controls the relation graph the traversal tools run on.
+type GraphConfig struct {
+	// RelationKinds lists the relation kinds ingested as graph edges
+	// ("imports", "implements", ...). Empty ingests all of them. Tools
+	// following the other relations (traverse, find_path, hierarchy, ...)
+	// then see fewer results; query_facts still returns every relation.
+	RelationKinds []string `yaml:"relation_kinds" json:"relation_kinds,omitempty"`
+}
+
+// DefaultFlagMethods are the default feature_flags.methods: the typed flag
+// evaluation methods of the LaunchDarkly SDKs for Go, JavaScript/TypeScript
+// and Java/Kotlin. Methods are matched whatever the receiver, so generic
+// names (variation, isEnabled, is_enabled, getVariant) are left out: they
+// would turn logger.isEnabled("DEBUG") into a flag. Unleash and in-house
+// clients are configured explicitly.
+var DefaultFlagMethods = []string{
+	"BoolVariation", "StringVariation", "IntVariation", "Float64Variation", "JSONVariation",
+	"BoolVariationDetail", "StringVariationDetail", "IntVariationDetail", "Float64VariationDetail", "JSONVariationDetail",
+	"boolVariation", "stringVariation", "numberVariation", "intVariation", "doubleVariation", "jsonVariation", "jsonValueVariation",
+	"boolVariationDetail", "stringVariationDetail", "numberVariationDetail", "intVariationDetail", "doubleVariationDetail", "jsonVariationDetail",
+}
+
+// FeatureFlagsConfig controls feature flag detection.
+type FeatureFlagsConfig struct {
+	// Methods are the flag client methods whose string literal first
+	// argument is a flag key, matched by name whatever the receiver
+	// ("BoolVariation", "isEnabled"). Empty disables detection.
+	Methods []string `yaml:"methods" json:"methods"`
+}
+
+// SourceCacheConfig bounds the server's LRU cache of source file contents.
+// A zero value for either limit disables the cache.
+type SourceCacheConfig struct {
+	MaxFiles int   `yaml:"max_files" json:"max_files"`
+	MaxBytes int64 `yaml:"max_bytes" json:"max_bytes"`
+}
+
+// JSONGraphConfig controls the json_graph renderer.
+type JSONGraphConfig struct {
+	MaxNodes int `yaml:"max_nodes" json:"max_nodes"` // cap on nodes written to graph.cyto.json
+}
+
+// GoConfig holds Go extractor options.
+type GoConfig struct {
+	// DocComments controls how doc comments are recorded in Props["doc"]:
+	// "first_sentence" (default), "full", or "off".
+	DocComments string `yaml:"doc_comments" json:"doc_comments"`
+	// CoverageProfile is a `go test -coverprofile` output file, relative to
+	// the repo root. When set, functions get covered/coverage_pct props.
+	CoverageProfile string `yaml:"coverage_profile" json:"coverage_profile,omitempty"`
+	// Tests reads _test.go files, even though the default ignore patterns
+	// skip them, to link production symbols to the tests that reference them.
+	Tests bool `yaml:"tests" json:"tests"`
+	// GOOS, GOARCH and BuildTags scope extraction to a target platform,
+	// skipping files whose build constraints or _GOOS/_GOARCH file name
+	// suffixes exclude it. Unset, every file is extracted.
+	GOOS      string   `yaml:"goos" json:"goos,omitempty"`
+	GOARCH    string   `yaml:"goarch" json:"goarch,omitempty"`
+	BuildTags []string `yaml:"build_tags" json:"build_tags,omitempty"`
 }
 
 // Default returns a Config with sensible defaults.
@@ -40,13 +222,37 @@ func Default() *Config {
 			"**/*_test.rb",
 			".archmcp/**",
 		},
-		Extractors: []string{"go", "kotlin", "openapi", "python", "typescript", "swift", "ruby"},
-		Explainers: []string{"cycles", "layers"},
+		Extractors: []string{"go", "kotlin", "openapi", "python", "typescript", "swift", "ruby", "sql", "scala", "elixir", "docker", "cpp", "proto"},
+		Explainers: []string{"cycles", "layers", "hotspots"},
 		Renderers:  []string{"llm_context"},
 		Output: OutputConfig{
 			Dir:              ".archmcp",
 			MaxContextTokens: 16000,
 		},
+		Watch: WatchConfig{
+			DebounceMs: 500,
+		},
+		Hotspots: HotspotsConfig{
+			MaxSymbols:  50,
+			MaxCoupling: 30,
+			MaxMethods:  20,
+		},
+		Go: GoConfig{
+			DocComments: "first_sentence",
+		},
+		Cycles: CyclesConfig{
+			Granularity: "module",
+		},
+		JSONGraph: JSONGraphConfig{
+			MaxNodes: 2000,
+		},
+		SourceCache: SourceCacheConfig{
+			MaxFiles: 256,
+			MaxBytes: 32 << 20,
+		},
+		FeatureFlags: FeatureFlagsConfig{
+			Methods: slices.Clone(DefaultFlagMethods),
+		},
 	}
 }
 
@@ -62,6 +268,13 @@ func Load(path string) (*Config, error) {
 	if err := yaml.Unmarshal(data, cfg); err != nil {
 		return nil, fmt.Errorf("parsing config %s: %w", path, err)
 	}
+	if err := cfg.ApplyEnv(); err != nil {
+		return nil, err
+	}
+	cfg.Source = path
+	if abs, err := filepath.Abs(path); err == nil {
+		cfg.Source = abs
+	}
 
 	// Ensure required defaults
 	if cfg.Output.Dir == "" {
@@ -70,10 +283,158 @@ func Load(path string) (*Config, error) {
 	if cfg.Output.MaxContextTokens == 0 {
 		cfg.Output.MaxContextTokens = 16000
 	}
+	if cfg.Watch.DebounceMs <= 0 {
+		cfg.Watch.DebounceMs = 500
+	}
+	if len(cfg.Output.Renderers) > 0 {
+		cfg.Renderers = cfg.Output.Renderers
+	}
+	if cfg.Generate.Timeout != "" {
+		if d, err := time.ParseDuration(cfg.Generate.Timeout); err != nil || d < 0 {
+			return nil, fmt.Errorf("parsing config %s: generate.timeout %q is not a valid duration (e.g. \"90s\", \"10m\")", path, cfg.Generate.Timeout)
+		}
+	}
+	if cfg.Generate.MaxFiles < 0 {
+		return nil, fmt.Errorf("parsing config %s: generate.max_files must not be negative", path)
+	}
+	if cfg.Generate.MaxFacts < 0 || cfg.Generate.MaxSymbolsPerModule < 0 {
+		return nil, fmt.Errorf("parsing config %s: generate.max_facts and generate.max_symbols_per_module must not be negative", path)
+	}
+	switch cfg.Generate.MaxFactsStrategy {
+	case "", MaxFactsError, MaxFactsSample:
+	default:
+		return nil, fmt.Errorf("parsing config %s: generate.max_facts_strategy %q must be %q or %q", path, cfg.Generate.MaxFactsStrategy, MaxFactsError, MaxFactsSample)
+	}
+	for _, kind := range cfg.Graph.RelationKinds {
+		if !slices.Contains(facts.RelationKinds, kind) {
+			return nil, fmt.Errorf("parsing config %s: graph.relation_kinds entry %q is not a relation kind (one of %s)", path, kind, strings.Join(facts.RelationKinds, ", "))
+		}
+	}
+	if cfg.Output.LabelTrim.Segments < 0 {
+		return nil, fmt.Errorf("parsing config %s: output.label_trim.segments must not be negative", path)
+	}
 
 	return cfg, nil
 }
 
+// envOverrides maps the supported environment variables to the fields they
+// set, in the order they are applied.
+var envOverrides = []struct {
+	name string
+	set  func(c *Config, v string) error
+}{
+	{"ARCHMCP_REPO", func(c *Config, v string) error {
+		c.Repo = v
+		return nil
+	}},
+	{"ARCHMCP_REPO_ROOT", func(c *Config, v string) error {
+		c.RepoRoot = v
+		return nil
+	}},
+	{"ARCHMCP_OUTPUT_DIR", func(c *Config, v string) error {
+		c.Output.Dir = v
+		return nil
+	}},
+	{"ARCHMCP_MAX_CONTEXT_TOKENS", func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("must be a positive integer")
+		}
+		c.Output.MaxContextTokens = n
+		return nil
+	}},
+	{"ARCHMCP_WATCH", func(c *Config, v string) error {
+		on, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("must be true or false")
+		}
+		c.Watch.Enabled = on
+		return nil
+	}},
+	{"ARCHMCP_GENERATE_TIMEOUT", func(c *Config, v string) error {
+		if d, err := time.ParseDuration(v); err != nil || d < 0 {
+			return fmt.Errorf("is not a valid duration (e.g. \"90s\", \"10m\")")
+		}
+		c.Generate.Timeout = v
+		return nil
+	}},
+}
+
+// EnvError reports an ARCHMCP_* environment variable whose value is invalid.
+// Load returns it as is, so callers can tell it from a missing or malformed
+// config file.
+type EnvError struct {
+	Name  string
+	Value string
+	Err   error
+}
+
+func (e *EnvError) Error() string {
+	return fmt.Sprintf("environment variable %s=%q %v", e.Name, e.Value, e.Err)
+}
+
+func (e *EnvError) Unwrap() error { return e.Err }
+
+// ApplyEnv overrides fields with the ARCHMCP_* environment variables that
+// are set and non-empty, so one config works across machines and containers
+// without editing the file. The precedence is env > file > defaults: Load
+// applies it over the file, and callers using Default apply it themselves.
+func (c *Config) ApplyEnv() error {
+	for _, o := range envOverrides {
+		v := os.Getenv(o.name)
+		if v == "" {
+			continue
+		}
+		if err := o.set(c, v); err != nil {
+			return &EnvError{Name: o.name, Value: v, Err: err}
+		}
+		c.EnvOverrides = append(c.EnvOverrides, o.name)
+	}
+	return nil
+}
+
+// ResolvePath returns the absolute form of a repository path given to a
+// tool. A leading ~ expands to the user's home directory, and a relative
+// path is joined to RepoRoot (itself ~-expanded), or to the working
+// directory when RepoRoot is unset.
+func (c *Config) ResolvePath(p string) (string, error) {
+	p, err := expandHome(p)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(p) && c.RepoRoot != "" {
+		root, err := expandHome(c.RepoRoot)
+		if err != nil {
+			return "", err
+		}
+		p = filepath.Join(root, p)
+	}
+	return filepath.Abs(p)
+}
+
+// RepoPath returns the absolute form of Repo, with a leading ~ expanded to
+// the user's home directory as for repo_root.
+func (c *Config) RepoPath() (string, error) {
+	p, err := expandHome(c.Repo)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(p)
+}
+
+// expandHome replaces a leading "~" or "~/" in p with the home directory.
+// Other users' homes ("~bob") are not expanded.
+func expandHome(p string) (string, error) {
+	if p != "~" && !strings.HasPrefix(p, "~/") && !strings.HasPrefix(p, "~"+string(filepath.Separator)) {
+		return p, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("expanding %s: %w", p, err)
+	}
+	return filepath.Join(home, p[1:]), nil
+}
+
 // IsExtractorEnabled returns true if the named extractor is enabled.
 func (c *Config) IsExtractorEnabled(name string) bool {
 	return contains(c.Extractors, name)
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes a config file with the given content and returns its path.
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mcp-arch.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_RelationKinds(t *testing.T) {
+	cfg, err := Load(writeConfig(t, "graph:\n  relation_kinds: [imports, implements]\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if want := []string{"imports", "implements"}; !reflect.DeepEqual(cfg.Graph.RelationKinds, want) {
+		t.Errorf("relation kinds = %v, want %v", cfg.Graph.RelationKinds, want)
+	}
+
+	_, err = Load(writeConfig(t, "graph:\n  relation_kinds: [imports, import]\n"))
+	if err == nil || !strings.Contains(err.Error(), `graph.relation_kinds entry "import"`) {
+		t.Errorf("err = %v, want the unknown relation kind reported", err)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	file := "repo: from-file\noutput:\n  max_context_tokens: 8000\nwatch:\n  enabled: true\ngenerate:\n  timeout: 30s\n"
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+		check   func(t *testing.T, cfg *Config)
+	}{
+		{
+			name: "env over file",
+			env:  map[string]string{"ARCHMCP_REPO": "from-env", "ARCHMCP_MAX_CONTEXT_TOKENS": "4000", "ARCHMCP_WATCH": "false", "ARCHMCP_GENERATE_TIMEOUT": "2m"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Repo != "from-env" || cfg.Output.MaxContextTokens != 4000 || cfg.Watch.Enabled || cfg.Generate.Timeout != "2m" {
+					t.Errorf("cfg = repo %q, tokens %d, watch %v, timeout %q; want the env values", cfg.Repo, cfg.Output.MaxContextTokens, cfg.Watch.Enabled, cfg.Generate.Timeout)
+				}
+				want := []string{"ARCHMCP_REPO", "ARCHMCP_MAX_CONTEXT_TOKENS", "ARCHMCP_WATCH", "ARCHMCP_GENERATE_TIMEOUT"}
+				if !reflect.DeepEqual(cfg.EnvOverrides, want) {
+					t.Errorf("env overrides = %v, want %v", cfg.EnvOverrides, want)
+				}
+			},
+		},
+		{
+			name: "unset keeps the file value",
+			env:  map[string]string{"ARCHMCP_REPO": ""},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Repo != "from-file" || cfg.Output.MaxContextTokens != 8000 || !cfg.Watch.Enabled || cfg.Generate.Timeout != "30s" {
+					t.Errorf("cfg = repo %q, tokens %d, watch %v, timeout %q; want the file values", cfg.Repo, cfg.Output.MaxContextTokens, cfg.Watch.Enabled, cfg.Generate.Timeout)
+				}
+				if len(cfg.EnvOverrides) != 0 {
+					t.Errorf("env overrides = %v, want none", cfg.EnvOverrides)
+				}
+			},
+		},
+		{name: "invalid int", env: map[string]string{"ARCHMCP_MAX_CONTEXT_TOKENS": "lots"}, wantErr: `ARCHMCP_MAX_CONTEXT_TOKENS="lots" must be a positive integer`},
+		{name: "non-positive int", env: map[string]string{"ARCHMCP_MAX_CONTEXT_TOKENS": "0"}, wantErr: `ARCHMCP_MAX_CONTEXT_TOKENS="0" must be a positive integer`},
+		{name: "invalid bool", env: map[string]string{"ARCHMCP_WATCH": "maybe"}, wantErr: `ARCHMCP_WATCH="maybe" must be true or false`},
+		{name: "invalid duration", env: map[string]string{"ARCHMCP_GENERATE_TIMEOUT": "soon"}, wantErr: `ARCHMCP_GENERATE_TIMEOUT="soon" is not a valid duration`},
+		{name: "negative duration", env: map[string]string{"ARCHMCP_GENERATE_TIMEOUT": "-1s"}, wantErr: `ARCHMCP_GENERATE_TIMEOUT="-1s" is not a valid duration`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, o := range envOverrides {
+				t.Setenv(o.name, tt.env[o.name])
+			}
+			cfg, err := Load(writeConfig(t, file))
+			if tt.wantErr != "" {
+				var envErr *EnvError
+				if !errors.As(err, &envErr) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want an EnvError containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestRepoPath_ExpandsHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for repo, want := range map[string]string{
+		"~":          home,
+		"~/src/app":  filepath.Join(home, "src", "app"),
+		"/srv/app":   "/srv/app",
+		"~other/app": "",
+	} {
+		got, err := (&Config{Repo: repo}).RepoPath()
+		if err != nil {
+			t.Fatalf("RepoPath(%q): %v", repo, err)
+		}
+		if want == "" {
+			want, _ = filepath.Abs(repo)
+		}
+		if got != want {
+			t.Errorf("RepoPath(%q) = %q, want %q", repo, got, want)
+		}
+	}
+}
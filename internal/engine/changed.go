@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// gitChangedFiles returns the repo-relative paths of files that differ from
+// ref, as reported by `git diff --name-only`. Paths are reported relative to
+// repoPath even when it is a subdirectory of the git work tree.
+func gitChangedFiles(ctx context.Context, repoPath, ref string) ([]string, error) {
+	if strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("invalid git ref %q", ref)
+	}
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "diff", "--name-only", "--relative", ref, "--")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("git diff %s: %s", ref, msg)
+	}
+
+	var files []string
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			files = append(files, filepath.FromSlash(line))
+		}
+	}
+	return files, nil
+}
+
+// changeScope describes which part of the repo is re-extracted during a
+// changed_since run.
+type changeScope struct {
+	files   []string            // walked files to re-extract
+	changed map[string]struct{} // files reported by git, including deletions
+	dirs    map[string]struct{} // directories containing a changed file
+}
+
+// newChangeScope restricts walked to the changed files and their siblings.
+// Siblings are included so extractors that work per package or directory
+// (e.g. module facts) see the complete picture for every touched directory.
+func newChangeScope(changed, walked []string) *changeScope {
+	sc := &changeScope{
+		changed: make(map[string]struct{}, len(changed)),
+		dirs:    make(map[string]struct{}),
+	}
+	for _, f := range changed {
+		sc.changed[f] = struct{}{}
+		sc.dirs[filepath.Dir(f)] = struct{}{}
+	}
+	for _, f := range walked {
+		if _, ok := sc.dirs[filepath.Dir(f)]; ok {
+			sc.files = append(sc.files, f)
+		}
+	}
+	return sc
+}
+
+// touched reports whether facts for path must come from the fresh extraction:
+// the path was changed, sits in a touched directory, or is itself a touched
+// directory (module facts use the directory as their File).
+func (sc *changeScope) touched(path string) bool {
+	if _, ok := sc.changed[path]; ok {
+		return true
+	}
+	if _, ok := sc.dirs[path]; ok {
+		return true
+	}
+	_, ok := sc.dirs[filepath.Dir(path)]
+	return ok
+}
+
+// carryOver removes from prev the facts for paths inside the change scope,
+// which the run re-extracts. Config facts gather reads from many files, so
+// they are removed too and returned with just the reads in unchanged files;
+// combine merges them with the fresh reads.
+func (sc *changeScope) carryOver(prev *facts.Store) []facts.Fact {
+	var configs []facts.Fact
+	for _, f := range prev.ByKind(facts.KindConfig) {
+		reads := configReads(f)
+		if reads == nil {
+			continue
+		}
+		var unchanged []string
+		for _, r := range reads {
+			if file, _ := splitRead(r); !sc.touched(file) {
+				unchanged = append(unchanged, r)
+			}
+		}
+		if len(unchanged) > 0 {
+			configs = append(configs, withConfigReads(f, unchanged))
+		}
+	}
+	prev.RemoveFunc(func(f facts.Fact) bool {
+		return (f.Kind == facts.KindConfig && configReads(f) != nil) || sc.touched(f.File)
+	})
+	return configs
+}
+
+// dropReextracted removes from prev the facts for files the fresh extraction
+// emitted facts for, which covers extractors that scan the repo on their own
+// and ignore the restricted file list.
+func dropReextracted(prev *facts.Store, fresh []facts.Fact) {
+	freshFiles := make(map[string]struct{}, len(fresh))
+	for _, f := range fresh {
+		freshFiles[f.File] = struct{}{}
+	}
+	prev.RemoveFunc(func(f facts.Fact) bool {
+		_, ok := freshFiles[f.File]
+		return ok
+	})
+}
+
+// combine returns the facts a changed_since run adds: the fresh facts
+// followed by the carried-over config facts. A config fact read both in re-extracted and in
+// unchanged files comes out as one fact with all its reads, located at the
+// first, as a full run has it.
+func combine(fresh, carried []facts.Fact) []facts.Fact {
+	freshConfig := make(map[string]bool)
+	for _, f := range fresh {
+		if f.Kind == facts.KindConfig {
+			freshConfig[f.Name] = true
+		}
+	}
+	both := make(map[string]bool)
+	for _, f := range carried {
+		if f.Kind == facts.KindConfig && freshConfig[f.Name] {
+			both[f.Name] = true
+		}
+	}
+	if len(both) == 0 {
+		return append(slices.Clone(fresh), carried...)
+	}
+	ff := mergeConfigFacts(append(slices.Clone(fresh), carried...))
+	for i, f := range ff {
+		if f.Kind == facts.KindConfig && both[f.Name] {
+			ff[i] = withConfigReads(f, configReads(f))
+		}
+	}
+	return ff
+}
+
+// previousSnapshot returns the facts and file hashes of the last snapshot for
+// repoPath in a store the caller may change, or a nil store when there is
+// none. The in-memory snapshot is preferred, copied with its graph;
+// otherwise facts.jsonl (or facts.jsonl.gz) and snapshot.meta.json in the
+// output directory are loaded. Multi-repo stores are not reused because
+// their file paths carry repo prefixes.
+func (e *Engine) previousSnapshot(repoPath string) (*facts.Store, []facts.FileHash) {
+	if e.snapshot != nil && e.snapshot.Meta.RepoPath == repoPath && len(e.repoPaths) == 0 {
+		return e.store.Clone(), e.snapshot.Meta.FileHashes
+	}
+
+	outDir := filepath.Join(repoPath, e.cfg.Output.Dir)
+	prev := e.newStore()
+	factsPath := FactsFile(outDir, e.cfg.Output.Compress)
+	if factsPath == "" || prev.ReadJSONLFile(factsPath) != nil {
+		log.Printf("[engine] changed_since: no previous snapshot found, only changed files will be included")
+		return nil, nil
+	}
+	var meta facts.SnapshotMeta
+	if data, err := os.ReadFile(filepath.Join(outDir, "snapshot.meta.json")); err == nil {
+		if err := json.Unmarshal(data, &meta); err != nil {
+			log.Printf("[engine] changed_since: could not parse previous snapshot meta: %v", err)
+		}
+	}
+	return prev, meta.FileHashes
+}
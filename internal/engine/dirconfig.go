@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// dirConfigName is the per-directory config file. It scopes ignore/include
+// patterns and the extractor selection to the subtree it sits in.
+const dirConfigName = ".archmcp.yaml"
+
+// dirConfigFile is the YAML layout of a .archmcp.yaml file.
+type dirConfigFile struct {
+	Ignore     []string `yaml:"ignore"`
+	Include    []string `yaml:"include"`
+	Extractors []string `yaml:"extractors"`
+}
+
+// dirConfig is a compiled .archmcp.yaml. Its patterns use .archmcpignore
+// syntax and are matched against paths relative to dir.
+type dirConfig struct {
+	dir        string // slash-separated, relative to the repo root ("." for the root)
+	ignore     []ignoreRule
+	include    []ignoreRule // when set, only matching files in the subtree are walked
+	extractors []string     // when set, only these extractors see the subtree's files
+}
+
+// dirConfigs discovers and caches the .archmcp.yaml files of one repository.
+// Each directory is read at most once per snapshot, the first time a path
+// below it is checked. Safe for concurrent use.
+type dirConfigs struct {
+	root    string
+	mu      sync.Mutex
+	configs map[string]*dirConfig // relative dir -> config, nil when the dir has none
+}
+
+func newDirConfigs(root string) *dirConfigs {
+	return &dirConfigs{root: root, configs: make(map[string]*dirConfig)}
+}
+
+// nearest returns the config of the closest directory above relPath that has
+// one, or nil. A directory's own config governs its contents, not the
+// directory itself. Nearest wins: configs further up are not consulted.
+func (dc *dirConfigs) nearest(relPath string) *dirConfig {
+	relPath = filepath.ToSlash(relPath)
+	if dc == nil || relPath == "." {
+		return nil
+	}
+	dir := path.Dir(relPath)
+	for {
+		if c := dc.load(dir); c != nil {
+			return c
+		}
+		if dir == "." {
+			return nil
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+// load returns the config in dir, reading it on first use.
+func (dc *dirConfigs) load(dir string) *dirConfig {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if c, ok := dc.configs[dir]; ok {
+		return c
+	}
+	c := readDirConfig(dc.root, dir)
+	dc.configs[dir] = c
+	return c
+}
+
+// readDirConfig parses dir's .archmcp.yaml. A missing or malformed file
+// yields nil, so the next config up applies.
+func readDirConfig(root, dir string) *dirConfig {
+	file := path.Join(dir, dirConfigName)
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[engine] warning: reading %s: %v", file, err)
+		}
+		return nil
+	}
+	var raw dirConfigFile
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		log.Printf("[engine] warning: skipping %s: %v", file, err)
+		return nil
+	}
+
+	c := &dirConfig{dir: dir, extractors: raw.Extractors}
+	for _, p := range raw.Ignore {
+		if rule, ok := parseIgnorePattern(p, file); ok {
+			c.ignore = append(c.ignore, rule)
+		}
+	}
+	for _, p := range raw.Include {
+		if rule, ok := parseIgnorePattern(p, file); ok {
+			c.include = append(c.include, rule)
+		}
+	}
+	log.Printf("[engine] loaded %s (%d ignore, %d include patterns)", file, len(c.ignore), len(c.include))
+	return c
+}
+
+// rel returns relPath relative to the config's directory.
+func (c *dirConfig) rel(relPath string) string {
+	relPath = filepath.ToSlash(relPath)
+	if c.dir == "." {
+		return relPath
+	}
+	return strings.TrimPrefix(relPath, c.dir+"/")
+}
+
+// match returns how the config excludes relPath, or nil: it matches an
+// ignore pattern, or it is a file missing from a non-empty include list.
+func (c *dirConfig) match(relPath string, isDir bool) *IgnoreMatch {
+	sub := c.rel(relPath)
+	if rule, ok := findIgnoreRule(c.ignore, sub, isDir); ok {
+		return &IgnoreMatch{Path: filepath.ToSlash(relPath), Source: rule.source, Pattern: rule.pattern}
+	}
+	if !isDir && len(c.include) > 0 && !matchIgnoreRules(c.include, sub, false) {
+		return &IgnoreMatch{Path: filepath.ToSlash(relPath), Source: path.Join(c.dir, dirConfigName), NotIncluded: true}
+	}
+	return nil
+}
+
+// allowsExtractor reports whether the named extractor may process files
+// under the config.
+func (c *dirConfig) allowsExtractor(name string) bool {
+	if c == nil || len(c.extractors) == 0 {
+		return true
+	}
+	for _, n := range c.extractors {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// filesForExtractor drops the files whose nearest .archmcp.yaml does not
+// select the named extractor.
+func (e *Engine) filesForExtractor(name string, files []string) []string {
+	if e.dirConfigs == nil {
+		return files
+	}
+	var out []string
+	for _, f := range files {
+		if e.dirConfigs.nearest(f).allowsExtractor(name) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -6,11 +6,15 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -24,26 +28,40 @@ import (
 
 // Engine orchestrates the snapshot generation pipeline.
 type Engine struct {
-	mu         sync.Mutex // serializes GenerateSnapshot calls
+	mu         sync.Mutex   // serializes GenerateSnapshot calls
+	cfgMu      sync.RWMutex // guards cfg.Extractors for readers that don't hold mu
 	cfg        *config.Config
 	extractors *extractors.Registry
 	explainers *explainers.Registry
 	renderers  *renderers.Registry
 	store      *facts.Store
 	snapshot   *facts.Snapshot
-	repoPaths  map[string]string // repo label -> absolute path (populated in append mode)
+	repoPaths  map[string]string     // repo label -> absolute path (populated in append mode)
+	hashCache  map[string]cachedHash // absolute path -> last computed hash
+	repoIgnore []ignoreRule          // .archmcpignore rules of the repo being walked (guarded by mu)
+	dirConfigs *dirConfigs           // .archmcp.yaml files of the repo being walked (guarded by mu)
 }
 
 // New creates a new Engine with the given config.
 // Extractors, explainers, and renderers must be registered after creation.
 func New(cfg *config.Config) (*Engine, error) {
-	return &Engine{
+	e := &Engine{
 		cfg:        cfg,
 		extractors: extractors.NewRegistry(),
 		explainers: explainers.NewRegistry(),
 		renderers:  renderers.NewRegistry(),
-		store:      facts.NewStore(),
-	}, nil
+	}
+	e.store = e.newStore()
+	return e, nil
+}
+
+// newStore returns an empty fact store with the path and graph settings of
+// the config.
+func (e *Engine) newStore() *facts.Store {
+	store := facts.NewStore()
+	store.SetCaseInsensitivePaths(e.cfg.CaseInsensitivePaths)
+	store.SetGraphRelationKinds(e.cfg.Graph.RelationKinds)
+	return store
 }
 
 // RegisterExtractor adds an extractor to the engine.
@@ -76,6 +94,49 @@ func (e *Engine) Config() *config.Config {
 	return e.cfg
 }
 
+// ExtractorNames returns the names of the registered extractors, in
+// registration order.
+func (e *Engine) ExtractorNames() []string {
+	names := make([]string, 0, len(e.extractors.All()))
+	for _, ext := range e.extractors.All() {
+		names = append(names, ext.Name())
+	}
+	return names
+}
+
+// SetExtractors replaces the enabled extractors of the config with names,
+// which must all be registered, and returns the effective list: names with
+// duplicates dropped. The change applies from the next snapshot generation;
+// one in progress finishes first.
+func (e *Engine) SetExtractors(names []string) ([]string, error) {
+	var enabled []string
+	for _, name := range names {
+		if e.extractors.Get(name) == nil {
+			return nil, fmt.Errorf("unknown extractor %q; registered extractors: %s", name, strings.Join(e.ExtractorNames(), ", "))
+		}
+		if !slices.Contains(enabled, name) {
+			enabled = append(enabled, name)
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cfgMu.Lock()
+	e.cfg.Extractors = enabled
+	e.cfgMu.Unlock()
+	return enabled, nil
+}
+
+// ConfigCopy returns a copy of the engine config with its own extractors
+// list. Unlike reading through Config, it is safe while SetExtractors runs.
+func (e *Engine) ConfigCopy() *config.Config {
+	e.cfgMu.RLock()
+	defer e.cfgMu.RUnlock()
+	cfg := *e.cfg
+	cfg.Extractors = slices.Clone(e.cfg.Extractors)
+	return &cfg
+}
+
 // SetRepoPaths sets the repo label -> absolute path mapping (used in tests).
 func (e *Engine) SetRepoPaths(paths map[string]string) {
 	e.repoPaths = paths
@@ -123,113 +184,206 @@ func (e *Engine) ResolveFactFile(f *facts.Fact) string {
 // When appendMode is true the existing store is preserved and new facts are
 // added with file paths prefixed by the repo basename, enabling multi-repo queries.
 func (e *Engine) GenerateSnapshot(ctx context.Context, repoPath string, appendMode bool) (*facts.Snapshot, error) {
+	return e.generateSnapshot(ctx, repoPath, appendMode, "")
+}
+
+// GenerateSnapshotSince regenerates the snapshot, re-extracting only files
+// changed since the given git ref (plus the other files in their directories,
+// so package-level context stays complete). Facts for unchanged files are
+// carried over from the previous snapshot, either in memory or from the
+// facts.jsonl in the output directory. It is not supported in append mode.
+func (e *Engine) GenerateSnapshotSince(ctx context.Context, repoPath, ref string) (*facts.Snapshot, error) {
+	if ref == "" {
+		return nil, fmt.Errorf("changed_since ref is required")
+	}
+	return e.generateSnapshot(ctx, repoPath, false, ref)
+}
+
+func (e *Engine) generateSnapshot(ctx context.Context, repoPath string, appendMode bool, changedSince string) (*facts.Snapshot, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	if timeout := e.cfg.Generate.TimeoutDuration(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	start := time.Now()
 
+	var absRepo string
+	var err error
 	if repoPath == "" {
-		repoPath = e.cfg.Repo
+		absRepo, err = e.cfg.RepoPath()
+	} else {
+		absRepo, err = filepath.Abs(repoPath)
 	}
-
-	absRepo, err := filepath.Abs(repoPath)
 	if err != nil {
 		return nil, fmt.Errorf("resolving repo path: %w", err)
 	}
 
 	repoLabel := filepath.Base(absRepo)
 
+	// 1. Walk repository and collect files, honoring the repo's .archmcpignore
+	// and per-directory .archmcp.yaml files on top of the configured ignore
+	// patterns. This runs before the store is touched, so a repo over
+	// generate.max_files leaves the current snapshot in place.
+	e.repoIgnore = loadIgnoreFile(absRepo)
+	e.dirConfigs = newDirConfigs(absRepo)
+	files, err := e.walkRepo(absRepo)
+	if err != nil {
+		return nil, fmt.Errorf("walking repo: %w", err)
+	}
+	log.Printf("[engine] found %d files in %s", len(files), absRepo)
+	if limit := e.cfg.Generate.MaxFiles; limit > 0 && len(files) > limit {
+		return nil, fmt.Errorf("%s has %d files, more than generate.max_files (%d); add ignore patterns or raise the limit", absRepo, len(files), limit)
+	}
+
+	// The run builds its facts in a scratch store and repo list, swapped in
+	// only once it succeeds, so a run that fails or times out leaves the
+	// current snapshot, facts and graph in place.
+	var store *facts.Store
+	var repoPaths map[string]string
+	var prevHashes []facts.FileHash
 	if appendMode {
+		store = e.store.Clone()
+
 		// Track repo label -> absolute path for multi-repo resolution.
-		if e.repoPaths == nil {
-			e.repoPaths = make(map[string]string)
+		repoPaths = e.RepoPaths()
+		if repoPaths == nil {
+			repoPaths = make(map[string]string)
 		}
-		e.repoPaths[repoLabel] = absRepo
+		repoPaths[repoLabel] = absRepo
 
 		// Retroactively tag facts from a prior single-repo snapshot so they
 		// are filterable by repo alongside the newly appended facts.
-		if e.snapshot != nil && e.store.Count() > 0 {
+		if e.snapshot != nil && store.Count() > 0 {
 			prevLabel := filepath.Base(e.snapshot.Meta.RepoPath)
-			if _, alreadyTracked := e.repoPaths[prevLabel]; !alreadyTracked {
-				tagged := e.store.TagUntagged(prevLabel, prevLabel+"/")
+			if _, alreadyTracked := repoPaths[prevLabel]; !alreadyTracked {
+				tagged := store.TagUntagged(prevLabel, prevLabel+"/")
 				if tagged > 0 {
-					e.repoPaths[prevLabel] = e.snapshot.Meta.RepoPath
+					repoPaths[prevLabel] = e.snapshot.Meta.RepoPath
 					log.Printf("[engine] retroactively tagged %d existing facts with repo label %q", tagged, prevLabel)
 				}
 			}
 		}
-	} else {
-		// Clear previous state (default single-repo behaviour).
-		e.store.Clear()
-		e.repoPaths = nil
+
+		// Appending a repo again replaces its earlier facts.
+		if n := store.RemoveFunc(func(f facts.Fact) bool { return f.Repo == repoLabel }); n > 0 {
+			log.Printf("[engine] replacing %d facts of repo %q", n, repoLabel)
+		}
+	} else if changedSince != "" {
+		// A changed_since run starts from the previous facts, and replaces
+		// those of the re-extracted files below.
+		store, prevHashes = e.previousSnapshot(absRepo)
+	}
+	if store == nil {
+		// Start from an empty store (default single-repo behaviour).
+		store = e.newStore()
 	}
 
-	// 1. Walk repository and collect files
-	files, err := e.walkRepo(absRepo)
-	if err != nil {
-		return nil, fmt.Errorf("walking repo: %w", err)
+	var scope *changeScope
+	var configs []facts.Fact // config facts carried over by changed_since
+	carried := 0
+	if changedSince != "" {
+		changed, err := gitChangedFiles(ctx, absRepo, changedSince)
+		if err != nil {
+			return nil, fmt.Errorf("changed_since: %w", err)
+		}
+		scope = newChangeScope(changed, files)
+		files = scope.files
+		log.Printf("[engine] changed_since %s: %d changed files, extracting %d files", changedSince, len(changed), len(files))
+		configs = scope.carryOver(store)
+		carried = store.Count() + len(configs)
 	}
-	log.Printf("[engine] found %d files in %s", len(files), absRepo)
 
 	// 2. Compute file hashes (for snapshot metadata)
 	currentHashes := e.computeFileHashes(absRepo, files)
 
 	// 3. Detect and run extractors
-	preCount := e.store.Count()
-	usedExtractors, err := e.runExtractors(ctx, absRepo, files)
+	extracted, usedExtractors, extractErrs, extractorStats, sampling, err := e.runExtractors(ctx, absRepo, files, carried)
 	if err != nil {
 		return nil, fmt.Errorf("extraction: %w", err)
 	}
-	newCount := e.store.Count()
-	log.Printf("[engine] extracted %d facts using %d extractors", newCount, len(usedExtractors))
+	// Extractors report cancellation as an ordinary error and keep going, so
+	// check explicitly rather than publish a partial snapshot.
+	if err := e.generationAborted(ctx); err != nil {
+		return nil, fmt.Errorf("extraction: %w", err)
+	}
+	log.Printf("[engine] extracted %d facts using %d extractors (%d errors)", len(extracted), len(usedExtractors), len(extractErrs))
+
+	frameworks := e.detectFrameworks(absRepo, usedExtractors)
+
+	if scope != nil {
+		// Config facts of the fresh extraction absorb the reads carried over
+		// for them.
+		dropReextracted(store, extracted)
+		extracted = combine(extracted, configs)
+		log.Printf("[engine] carried over %d facts from unchanged files", store.Count()+len(configs))
+		for _, fh := range prevHashes {
+			if _, ok := currentHashes[fh.Path]; !ok && !scope.touched(fh.Path) {
+				currentHashes[fh.Path] = fh
+			}
+		}
+	}
+
+	preCount := store.Count()
+	store.Add(extracted...)
 
 	// Always set Repo on newly extracted facts so the repo filter works
 	// even in single-repo mode.
-	e.store.SetRepoRange(preCount, repoLabel)
+	store.SetRepoRange(preCount, repoLabel)
 
 	// In append mode, additionally prefix file paths so facts from
 	// different repos are distinguishable by file path.
 	if appendMode {
-		e.store.TagRange(preCount, repoLabel, repoLabel+"/")
-		log.Printf("[engine] prefixed %d facts with repo label %q", newCount-preCount, repoLabel)
+		store.TagRange(preCount, repoLabel, repoLabel+"/")
+		log.Printf("[engine] prefixed %d facts with repo label %q", store.Count()-preCount, repoLabel)
 	}
 
-	// 3b. Build graph index for traversal queries
-	e.store.BuildGraph()
-	log.Printf("[engine] built graph index (%d nodes, %d edges)", e.store.Graph().NodeCount(), e.store.Graph().EdgeCount())
+	// 3b. Update the graph index for traversal queries. Facts kept from
+	// before (appended repos, unchanged files) are already indexed, so only
+	// the new ones are added; a cleared store builds it from scratch.
+	store.ExtendGraph(preCount)
+	log.Printf("[engine] built graph index (%d nodes, %d edges)", store.Graph().NodeCount(), store.Graph().EdgeCount())
 
 	// 4. Run explainers
-	allInsights, usedExplainers, err := e.runExplainers(ctx)
+	allInsights, usedExplainers, err := e.runExplainers(ctx, store)
 	if err != nil {
 		return nil, fmt.Errorf("explanation: %w", err)
 	}
+	if err := e.generationAborted(ctx); err != nil {
+		return nil, fmt.Errorf("explanation: %w", err)
+	}
 	log.Printf("[engine] produced %d insights using %d explainers", len(allInsights), len(usedExplainers))
 
 	// 5. Build file hashes for the snapshot meta
 	var fileHashes []facts.FileHash
-	for path, hash := range currentHashes {
-		fileHashes = append(fileHashes, facts.FileHash{
-			Path:    path,
-			Hash:    hash,
-			ModTime: fileModTime(filepath.Join(absRepo, path)),
-		})
+	for _, fh := range currentHashes {
+		fileHashes = append(fileHashes, fh)
 	}
 
 	// 6. Build snapshot
 	duration := time.Since(start)
 	snapshot := &facts.Snapshot{
 		Meta: facts.SnapshotMeta{
-			RepoPath:     absRepo,
-			GeneratedAt:  time.Now().UTC().Format(time.RFC3339),
-			Duration:     duration.String(),
-			Extractors:   usedExtractors,
-			Explainers:   usedExplainers,
-			Renderers:    []string{},
-			FileHashes:   fileHashes,
-			FactCount:    e.store.Count(),
-			InsightCount: len(allInsights),
+			RepoPath:       absRepo,
+			GeneratedAt:    time.Now().UTC().Format(time.RFC3339),
+			Duration:       duration.String(),
+			Extractors:     usedExtractors,
+			Explainers:     usedExplainers,
+			Renderers:      []string{},
+			FileHashes:     fileHashes,
+			FileCount:      len(files),
+			FactCount:      store.Count(),
+			InsightCount:   len(allInsights),
+			ChangedSince:   changedSince,
+			Errors:         extractErrs,
+			Frameworks:     frameworks,
+			Sampling:       sampling,
+			ExtractorStats: extractorStats,
 		},
-		Facts:    e.store.All(),
+		Facts:    store.All(),
 		Insights: allInsights,
 	}
 
@@ -241,20 +395,42 @@ func (e *Engine) GenerateSnapshot(ctx context.Context, repoPath string, appendMo
 	snapshot.Meta.Renderers = usedRenderers
 	log.Printf("[engine] produced %d artifacts using %d renderers", len(snapshot.Artifacts), len(usedRenderers))
 
+	e.store.Replace(store)
+	e.repoPaths = repoPaths
 	e.snapshot = snapshot
 	log.Printf("[engine] snapshot generated in %s", duration)
 	return snapshot, nil
 }
 
-// walkRepo collects all files in the repo, applying ignore patterns.
+// generationAborted returns ctx's error once it is done, naming
+// generate.timeout when that deadline is what expired.
+func (e *Engine) generationAborted(ctx context.Context) error {
+	err := ctx.Err()
+	if errors.Is(err, context.DeadlineExceeded) && e.cfg.Generate.TimeoutDuration() > 0 {
+		return fmt.Errorf("snapshot generation exceeded generate.timeout (%s): %w", e.cfg.Generate.Timeout, err)
+	}
+	return err
+}
+
+// walkRepo collects all files in the repo, applying ignore patterns. A repo
+// path that is itself a symlink is resolved first. Symlinked directories
+// inside the repo are only walked when walk.follow_symlinks is set.
 func (e *Engine) walkRepo(repoPath string) ([]string, error) {
+	root, err := filepath.EvalSymlinks(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	if e.cfg.Walk.FollowSymlinks {
+		return e.walkFollowingSymlinks(root)
+	}
+
 	var files []string
-	err := filepath.WalkDir(repoPath, func(path string, d fs.DirEntry, err error) error {
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		relPath, err := filepath.Rel(repoPath, path)
+		relPath, err := filepath.Rel(root, path)
 		if err != nil {
 			return err
 		}
@@ -275,24 +451,129 @@ func (e *Engine) walkRepo(repoPath string) ([]string, error) {
 	return files, err
 }
 
+// walkFollowingSymlinks walks root like walkRepo and also descends into
+// symlinked directories. Each real directory is walked at most once, which
+// breaks symlink cycles and keeps a directory linked from several places
+// from being extracted twice. Files are recorded under their real path
+// relative to root when it lies inside the repo, and under the path through
+// the link otherwise.
+func (e *Engine) walkFollowingSymlinks(root string) ([]string, error) {
+	var files []string
+	seenFiles := make(map[string]bool)
+	visitedDirs := make(map[string]bool)
+
+	addFile := func(relPath, realPath string) {
+		if realRel, err := filepath.Rel(root, realPath); err == nil && realRel != ".." && !strings.HasPrefix(realRel, ".."+string(filepath.Separator)) {
+			if e.isIgnored(realRel, false) {
+				return
+			}
+			relPath = realRel
+		}
+		if !seenFiles[relPath] {
+			seenFiles[relPath] = true
+			files = append(files, relPath)
+		}
+	}
+
+	// walk walks the real directory dir, whose path through any links from
+	// root is relDir.
+	var walk func(dir, relDir string) error
+	walk = func(dir, relDir string) error {
+		return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			inner, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			relPath := filepath.Join(relDir, inner)
+
+			if d.Type()&fs.ModeSymlink != 0 {
+				realPath, err := filepath.EvalSymlinks(path)
+				if err != nil {
+					log.Printf("[engine] warning: skipping broken symlink %s: %v", relPath, err)
+					return nil
+				}
+				info, err := os.Stat(realPath)
+				if err != nil {
+					return nil
+				}
+				if e.isIgnored(relPath, info.IsDir()) {
+					return nil
+				}
+				if !info.IsDir() {
+					addFile(relPath, realPath)
+					return nil
+				}
+				if visitedDirs[realPath] {
+					log.Printf("[engine] not following symlink %s: %s is already walked", relPath, realPath)
+					return nil
+				}
+				return walk(realPath, relPath)
+			}
+
+			if d.IsDir() {
+				if relPath != "." && e.isIgnored(relPath, true) {
+					return filepath.SkipDir
+				}
+				if visitedDirs[path] {
+					return filepath.SkipDir
+				}
+				visitedDirs[path] = true
+				return nil
+			}
+
+			if !e.isIgnored(relPath, false) {
+				addFile(relPath, path)
+			}
+			return nil
+		})
+	}
+
+	err := walk(root, ".")
+	return files, err
+}
+
 // isIgnored checks whether a path matches any ignore pattern.
 func (e *Engine) isIgnored(relPath string, isDir bool) bool {
+	return matchIgnore(e.cfg.Ignore, e.repoIgnore, e.dirConfigs, relPath, isDir) != nil
+}
+
+// repoIgnored returns a function reporting whether the .archmcpignore and
+// .archmcp.yaml rules of the repo being walked ignore a file. The config
+// patterns are left out: their default test file globs are what the Go
+// extractor's tests option reads past.
+func (e *Engine) repoIgnored() func(relPath string) bool {
+	repoIgnore, dirs := e.repoIgnore, e.dirConfigs
+	return func(relPath string) bool {
+		return matchIgnore(nil, repoIgnore, dirs, relPath, false) != nil
+	}
+}
+
+// matchIgnore returns the first rule that ignores relPath, checking the
+// configured patterns, then the .archmcpignore rules, then the nearest
+// .archmcp.yaml, or nil when the path is kept.
+func matchIgnore(patterns []string, repoIgnore []ignoreRule, dirs *dirConfigs, relPath string, isDir bool) *IgnoreMatch {
 	// Normalize to forward slashes for matching
 	relPath = filepath.ToSlash(relPath)
+	configMatch := func(pattern string) *IgnoreMatch {
+		return &IgnoreMatch{Path: relPath, Source: "config", Pattern: pattern}
+	}
 
-	for _, pattern := range e.cfg.Ignore {
+	for _, pattern := range patterns {
 		// Handle directory-only patterns
 		if strings.HasSuffix(pattern, "/**") {
 			dirPrefix := strings.TrimSuffix(pattern, "/**")
 			if relPath == dirPrefix || strings.HasPrefix(relPath, dirPrefix+"/") {
-				return true
+				return configMatch(pattern)
 			}
 		}
 
 		// Standard glob match
 		matched, err := filepath.Match(pattern, relPath)
 		if err == nil && matched {
-			return true
+			return configMatch(pattern)
 		}
 
 		// Also try matching just the filename for patterns like **/*.go
@@ -300,21 +581,61 @@ func (e *Engine) isIgnored(relPath string, isDir bool) bool {
 			subPattern := strings.TrimPrefix(pattern, "**/")
 			matched, err = filepath.Match(subPattern, filepath.Base(relPath))
 			if err == nil && matched {
-				return true
+				return configMatch(pattern)
 			}
 			// Also try the full relative path
 			matched, err = filepath.Match(subPattern, relPath)
 			if err == nil && matched {
-				return true
+				return configMatch(pattern)
 			}
 		}
 	}
-	return false
+	if rule, ok := findIgnoreRule(repoIgnore, relPath, isDir); ok {
+		return &IgnoreMatch{Path: relPath, Source: rule.source, Pattern: rule.pattern}
+	}
+	if dc := dirs.nearest(relPath); dc != nil {
+		return dc.match(relPath, isDir)
+	}
+	return nil
 }
 
-// runExtractors detects applicable extractors and runs them.
-func (e *Engine) runExtractors(ctx context.Context, repoPath string, files []string) ([]string, error) {
+// detectFrameworks collects the framework versions reported by the extractors
+// that ran, keeping the first version seen for each framework.
+func (e *Engine) detectFrameworks(repoPath string, used []string) []facts.FrameworkInfo {
+	var frameworks []facts.FrameworkInfo
+	seen := make(map[string]bool)
+	for _, name := range used {
+		fd, ok := e.extractors.Get(name).(extractors.FrameworkDetector)
+		if !ok {
+			continue
+		}
+		for _, fw := range fd.DetectFrameworks(repoPath) {
+			if seen[fw.Name] {
+				continue
+			}
+			seen[fw.Name] = true
+			frameworks = append(frameworks, fw)
+		}
+	}
+	return frameworks
+}
+
+// runExtractors detects applicable extractors and runs them. Failures are
+// returned as extract errors rather than aborting the run: an extractor that
+// reports extractors.FileErrors keeps the facts from the files it could
+// parse, while any other error drops that extractor's output. The time,
+// file count and fact count of every extractor that ran are returned as
+// stats, failed ones included. Entry points
+// no extractor classified are marked by name. OpenAPI routes
+// that duplicate a code-derived route are merged into it before the facts
+// are returned. generate.max_facts is applied as each extractor finishes,
+// counting the carried facts a changed_since run keeps.
+func (e *Engine) runExtractors(ctx context.Context, repoPath string, files []string, carried int) ([]facts.Fact, []string, []facts.ExtractError, []facts.ExtractorStat, *facts.Sampling, error) {
 	var usedNames []string
+	var extractErrs []facts.ExtractError
+	var stats []facts.ExtractorStat
+	var allFacts []facts.Fact
+	limit := newFactLimit(e.cfg.Generate, carried)
 
 	for _, ext := range e.extractors.All() {
 		if !e.cfg.IsExtractorEnabled(ext.Name()) {
@@ -324,6 +645,7 @@ func (e *Engine) runExtractors(ctx context.Context, repoPath string, files []str
 		detected, err := ext.Detect(repoPath)
 		if err != nil {
 			log.Printf("[engine] extractor %s detect error: %v", ext.Name(), err)
+			extractErrs = append(extractErrs, facts.ExtractError{Extractor: ext.Name(), Message: "detect: " + err.Error()})
 			continue
 		}
 		if !detected {
@@ -332,22 +654,61 @@ func (e *Engine) runExtractors(ctx context.Context, repoPath string, files []str
 		}
 
 		log.Printf("[engine] running extractor: %s", ext.Name())
-		extracted, err := ext.Extract(ctx, repoPath, files)
+		extFiles := e.filesForExtractor(ext.Name(), files)
+		if ia, ok := ext.(extractors.IgnoreAware); ok {
+			ia.SetIgnored(e.repoIgnored())
+		}
+		extStart := time.Now()
+		extracted, err := ext.Extract(ctx, repoPath, extFiles)
+		elapsed := time.Since(extStart)
+		stat := facts.ExtractorStat{Name: ext.Name(), Duration: elapsed.String(), Files: len(extFiles)}
 		if err != nil {
-			log.Printf("[engine] extractor %s error: %v", ext.Name(), err)
-			continue
+			var fileErrs extractors.FileErrors
+			if !errors.As(err, &fileErrs) {
+				log.Printf("[engine] extractor %s error: %v", ext.Name(), err)
+				extractErrs = append(extractErrs, facts.ExtractError{Extractor: ext.Name(), Message: err.Error()})
+				stats = append(stats, stat)
+				continue
+			}
+			for _, fe := range fileErrs {
+				log.Printf("[engine] extractor %s: %s: %v", ext.Name(), fe.File, fe.Err)
+				extractErrs = append(extractErrs, facts.ExtractError{File: fe.File, Extractor: ext.Name(), Message: fe.Err.Error()})
+			}
 		}
 
-		e.store.Add(extracted...)
 		usedNames = append(usedNames, ext.Name())
-		log.Printf("[engine] extractor %s: emitted %d facts", ext.Name(), len(extracted))
+		stat.Facts = len(extracted)
+		stats = append(stats, stat)
+		log.Printf("[engine] extractor %s: emitted %d facts from %d files in %s", ext.Name(), len(extracted), len(extFiles), elapsed)
+		if allFacts, err = limit.apply(append(allFacts, extracted...)); err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
 	}
 
-	return usedNames, nil
+	if ff := e.scanFeatureFlags(ctx, repoPath, files); len(ff) > 0 {
+		log.Printf("[engine] found %d feature flags", len(ff))
+		var err error
+		if allFacts, err = limit.apply(append(allFacts, ff...)); err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
+	}
+
+	extractors.MarkEntryPoints(allFacts)
+	merged := mergeSpecRoutes(allFacts)
+	if n := len(allFacts) - len(merged); n > 0 {
+		log.Printf("[engine] merged %d OpenAPI routes into code-derived routes", n)
+	}
+	merged = mergeConfigFacts(merged)
+	sampling := limit.sampling(merged)
+	if sampling != nil {
+		log.Printf("[engine] sampled %d symbols from %d modules to fit generate.max_facts (%d); kept at most %d symbols per module",
+			sampling.DroppedSymbols, sampling.SampledModules, sampling.MaxFacts, sampling.SymbolsPerModule)
+	}
+	return merged, usedNames, extractErrs, stats, sampling, nil
 }
 
-// runExplainers runs all enabled explainers.
-func (e *Engine) runExplainers(ctx context.Context) ([]facts.Insight, []string, error) {
+// runExplainers runs all enabled explainers over store.
+func (e *Engine) runExplainers(ctx context.Context, store *facts.Store) ([]facts.Insight, []string, error) {
 	var allInsights []facts.Insight
 	var usedNames []string
 
@@ -357,7 +718,7 @@ func (e *Engine) runExplainers(ctx context.Context) ([]facts.Insight, []string,
 		}
 
 		log.Printf("[engine] running explainer: %s", exp.Name())
-		insights, err := exp.Explain(ctx, e.store)
+		insights, err := exp.Explain(ctx, store)
 		if err != nil {
 			log.Printf("[engine] explainer %s error: %v", exp.Name(), err)
 			continue
@@ -375,6 +736,12 @@ func (e *Engine) runExplainers(ctx context.Context) ([]facts.Insight, []string,
 func (e *Engine) runRenderers(ctx context.Context, snapshot *facts.Snapshot) ([]string, error) {
 	var usedNames []string
 
+	for _, name := range e.cfg.Renderers {
+		if e.renderers.Get(name) == nil {
+			log.Printf("[engine] warning: renderer %q is enabled but not registered", name)
+		}
+	}
+
 	for _, rnd := range e.renderers.All() {
 		if !e.cfg.IsRendererEnabled(rnd.Name()) {
 			continue
@@ -394,14 +761,73 @@ func (e *Engine) runRenderers(ctx context.Context, snapshot *facts.Snapshot) ([]
 	return usedNames, nil
 }
 
+// RenderArtifact re-renders the named renderer from the current snapshot and
+// returns the content of the artifact called artifactName. The renderer does
+// not have to be enabled. A positive maxTokens overrides the configured budget
+// of renderers that implement renderers.Budgeted.
+func (e *Engine) RenderArtifact(ctx context.Context, rendererName, artifactName string, maxTokens int) ([]byte, error) {
+	if e.snapshot == nil {
+		return nil, fmt.Errorf("no snapshot generated")
+	}
+	rnd := e.renderers.Get(rendererName)
+	if rnd == nil {
+		return nil, fmt.Errorf("renderer %q is not registered", rendererName)
+	}
+	if b, ok := rnd.(renderers.Budgeted); ok && maxTokens > 0 {
+		rnd = b.WithMaxTokens(maxTokens)
+	}
+
+	artifacts, err := rnd.Render(ctx, e.snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("renderer %s: %w", rendererName, err)
+	}
+	for _, a := range artifacts {
+		if a.Name == artifactName {
+			return a.Content, nil
+		}
+	}
+	return nil, fmt.Errorf("renderer %s produced no %s", rendererName, artifactName)
+}
+
+// The names of the facts file in an output directory, plain and compressed
+// (output.compress).
+const (
+	factsFileName           = "facts.jsonl"
+	compressedFactsFileName = "facts.jsonl.gz"
+)
+
+// FactsFile returns the path of the facts file in outDir, facts.jsonl or
+// facts.jsonl.gz, trying first the one output.compress selects. It returns
+// "" when there is neither.
+func FactsFile(outDir string, compress bool) string {
+	names := []string{factsFileName, compressedFactsFileName}
+	if compress {
+		names[0], names[1] = names[1], names[0]
+	}
+	for _, name := range names {
+		path := filepath.Join(outDir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
 // WriteArtifacts writes all snapshot artifacts to the output directory,
 // including facts.jsonl, insights.json, and snapshot.meta.json.
 func (e *Engine) WriteArtifacts(repoPath string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 	if e.snapshot == nil {
 		return fmt.Errorf("no snapshot generated")
 	}
 
-	outDir := filepath.Join(repoPath, e.cfg.Output.Dir)
+	return e.writeSnapshotFiles(filepath.Join(repoPath, e.cfg.Output.Dir))
+}
+
+// writeSnapshotFiles writes the renderer artifacts, facts.jsonl,
+// insights.json, and snapshot.meta.json of the current snapshot to outDir.
+func (e *Engine) writeSnapshotFiles(outDir string) error {
 	if err := os.MkdirAll(outDir, 0o755); err != nil {
 		return fmt.Errorf("creating output dir: %w", err)
 	}
@@ -415,12 +841,20 @@ func (e *Engine) WriteArtifacts(repoPath string) error {
 		log.Printf("[engine] wrote %s (%d bytes)", path, len(a.Content))
 	}
 
-	// Write facts.jsonl
-	factsPath := filepath.Join(outDir, "facts.jsonl")
+	// Write facts.jsonl, or facts.jsonl.gz when compressed, and remove the
+	// other one so a stale copy is never loaded in its place.
+	name, stale := factsFileName, compressedFactsFileName
+	if e.cfg.Output.Compress {
+		name, stale = stale, name
+	}
+	factsPath := filepath.Join(outDir, name)
 	if err := e.store.WriteJSONLFile(factsPath); err != nil {
-		return fmt.Errorf("writing facts.jsonl: %w", err)
+		return fmt.Errorf("writing %s: %w", name, err)
 	}
 	log.Printf("[engine] wrote %s", factsPath)
+	if err := os.Remove(filepath.Join(outDir, stale)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing %s: %w", stale, err)
+	}
 
 	// Write insights.json
 	insightsJSON, err := json.MarshalIndent(e.snapshot.Insights, "", "  ")
@@ -474,26 +908,92 @@ func (e *Engine) GetArtifact(name string) ([]byte, error) {
 	}
 }
 
-// computeFileHashes computes SHA-256 hashes for all files (used in snapshot metadata).
-func (e *Engine) computeFileHashes(repoPath string, files []string) map[string]string {
-	hashes := make(map[string]string, len(files))
-	for _, relFile := range files {
+// cachedHash is a file hash remembered between snapshots. It is reused while
+// the file's size and modification time are unchanged.
+type cachedHash struct {
+	size    int64
+	modTime time.Time
+	hash    string
+}
+
+// computeFileHashes computes SHA-256 hashes for all files (used in snapshot
+// metadata), keyed by relative path. Files whose size and modification time
+// match the engine's hash cache are not re-read; the rest are hashed in
+// parallel by a pool of GOMAXPROCS workers. Entries in the cache are keyed by
+// absolute path so appended repositories do not collide.
+func (e *Engine) computeFileHashes(repoPath string, files []string) map[string]facts.FileHash {
+	type hashResult struct {
+		entry cachedHash
+		ok    bool
+	}
+	results := make([]hashResult, len(files))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(files) {
+		workers = len(files)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				entry, ok := hashFile(filepath.Join(repoPath, files[i]), e.hashCache)
+				results[i] = hashResult{entry, ok}
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if e.hashCache == nil {
+		e.hashCache = make(map[string]cachedHash, len(files))
+	}
+	hashes := make(map[string]facts.FileHash, len(files))
+	for i, relFile := range files {
 		absFile := filepath.Join(repoPath, relFile)
-		data, err := os.ReadFile(absFile)
-		if err != nil {
+		if !results[i].ok {
+			delete(e.hashCache, absFile)
 			continue
 		}
-		h := sha256.Sum256(data)
-		hashes[relFile] = hex.EncodeToString(h[:])
+		entry := results[i].entry
+		e.hashCache[absFile] = entry
+		hashes[relFile] = facts.FileHash{
+			Path:    relFile,
+			Hash:    entry.hash,
+			ModTime: entry.modTime.UTC().Format(time.RFC3339),
+		}
 	}
 	return hashes
 }
 
-// fileModTime returns the modification time of a file as an RFC3339 string.
-func fileModTime(path string) string {
-	info, err := os.Stat(path)
+// hashFile stats and hashes a single file, reusing the cached hash when the
+// size and modification time are unchanged. cache is only read.
+func hashFile(absFile string, cache map[string]cachedHash) (cachedHash, bool) {
+	info, err := os.Stat(absFile)
 	if err != nil {
-		return ""
+		return cachedHash{}, false
+	}
+	if prev, ok := cache[absFile]; ok && prev.size == info.Size() && prev.modTime.Equal(info.ModTime()) {
+		return prev, true
+	}
+
+	f, err := os.Open(absFile)
+	if err != nil {
+		return cachedHash{}, false
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return cachedHash{}, false
 	}
-	return info.ModTime().UTC().Format(time.RFC3339)
+	return cachedHash{
+		size:    info.Size(),
+		modTime: info.ModTime(),
+		hash:    hex.EncodeToString(h.Sum(nil)),
+	}, true
 }
@@ -2,12 +2,24 @@ package engine
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/dejo1307/archmcp/internal/config"
+	"github.com/dejo1307/archmcp/internal/extractors"
 	"github.com/dejo1307/archmcp/internal/facts"
+	"github.com/dejo1307/archmcp/internal/renderers/llmcontext"
 )
 
 func TestIsIgnored(t *testing.T) {
@@ -107,6 +119,237 @@ func TestIsIgnored(t *testing.T) {
 	}
 }
 
+func TestMatchIgnoreRules(t *testing.T) {
+	rules := parseIgnoreRules([]byte(`# generated code
+*.pb.go
+/scripts
+docs/
+legacy/**/*.rb
+!legacy/keep/*.rb
+logs/
+!logs/keep.txt
+*.log
+!important.log
+\#literal
+`))
+
+	tests := []struct {
+		relPath string
+		isDir   bool
+		want    bool
+	}{
+		{"api/v1/service.pb.go", false, true},
+		{"api/v1/service.go", false, false},
+		{"scripts", true, true},
+		{"scripts/run.sh", false, true},
+		{"tools/scripts/run.sh", false, false}, // anchored to the root
+		{"docs", true, true},
+		{"pkg/docs/readme.txt", false, true}, // unanchored directory rule
+		{"docs.go", false, false},            // directory-only rule
+		{"legacy/a/b/old.rb", false, true},
+		{"legacy/keep/new.rb", false, false}, // re-included by negation
+		{"logs/keep.txt", false, true},       // parent directory stays excluded
+		{"server/debug.log", false, true},
+		{"important.log", false, false},
+		{"#literal", false, true},
+	}
+	for _, tt := range tests {
+		if got := matchIgnoreRules(rules, tt.relPath, tt.isDir); got != tt.want {
+			t.Errorf("matchIgnoreRules(%q, isDir=%v) = %v, want %v", tt.relPath, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestWalkRepo_FollowSymlinks(t *testing.T) {
+	base := t.TempDir()
+	outside := filepath.Join(base, "outside")
+	dir := filepath.Join(base, "repo")
+	for name, content := range map[string]string{
+		"repo/shared/util.go":       "package shared",
+		"repo/services/a/main.go":   "package main",
+		"repo/vendor/dep/dep.go":    "package dep",
+		"outside/lib/lib.go":        "package lib",
+		"outside/lib/lib_test.go":   "package lib",
+		"repo/services/a/notes.txt": "x",
+	} {
+		path := filepath.Join(base, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"services/a/shared": "../../shared",                // inside the repo
+		"services/a/lib":    filepath.Join(outside, "lib"), // outside the repo
+		"services/a/loop":   "..",                          // cycle back to services/
+		"services/a/dep":    "../../vendor/dep",            // target is ignored
+		"services/a/gone":   "../../missing",               // broken
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+	repoLink := filepath.Join(base, "repo-link")
+	if err := os.Symlink(dir, repoLink); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Default()
+	eng, _ := New(cfg)
+
+	// Without follow_symlinks, linked directories are skipped, but a
+	// symlinked repo root is still walked.
+	files, err := eng.walkRepo(repoLink)
+	if err != nil {
+		t.Fatalf("walkRepo: %v", err)
+	}
+	walked := strings.Join(files, ",")
+	if !strings.Contains(walked, "services/a/main.go") || !strings.Contains(walked, "shared/util.go") || strings.Contains(walked, "lib.go") {
+		t.Errorf("default walk = %s", walked)
+	}
+
+	cfg.Walk.FollowSymlinks = true
+	files, err = eng.walkRepo(dir)
+	if err != nil {
+		t.Fatalf("walkRepo: %v", err)
+	}
+	want := []string{
+		"services/a/lib/lib.go", // outside the repo: path through the link
+		"services/a/main.go",
+		"services/a/notes.txt",
+		"shared/util.go", // inside the repo: real path, recorded once
+	}
+	sort.Strings(files)
+	if strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("follow_symlinks walk = %v, want %v", files, want)
+	}
+}
+
+func TestGenerateSnapshot_HonorsArchmcpIgnore(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"main.go":             "package main",
+		"gen/api.pb.go":       "package gen",
+		"internal/a/a.go":     "package a",
+		"internal/a/a_gen.go": "package a",
+		".archmcpignore":      "gen/\n*_gen.go\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := config.Default()
+	eng, _ := New(cfg)
+	snap, err := eng.GenerateSnapshot(context.Background(), dir, false)
+	if err != nil {
+		t.Fatalf("GenerateSnapshot: %v", err)
+	}
+
+	hashed := make(map[string]bool)
+	for _, fh := range snap.Meta.FileHashes {
+		hashed[fh.Path] = true
+	}
+	for _, ignored := range []string{"gen/api.pb.go", "internal/a/a_gen.go"} {
+		if hashed[ignored] {
+			t.Errorf("%s should be excluded by .archmcpignore", ignored)
+		}
+	}
+	for _, kept := range []string{"main.go", "internal/a/a.go"} {
+		if !hashed[kept] {
+			t.Errorf("%s should be walked", kept)
+		}
+	}
+	if len(cfg.Ignore) != len(config.Default().Ignore) {
+		t.Error(".archmcpignore patterns must not leak into the shared config")
+	}
+}
+
+func TestGenerateSnapshot_DirConfigs(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"main.go":                           "package main",
+		"tools/gen.go":                      "package tools",
+		"frontend/.archmcp.yaml":            "ignore:\n  - storybook/\n  - \"*.stories.tsx\"\nextractors: [typescript]\n",
+		"frontend/app.tsx":                  "export const App = 1",
+		"frontend/app.stories.tsx":          "export default {}",
+		"frontend/storybook/config.ts":      "export {}",
+		"frontend/legacy/.archmcp.yaml":     "include:\n  - src/\n",
+		"frontend/legacy/src/a.js":          "var a",
+		"frontend/legacy/src/a.stories.tsx": "export default {}",
+		"frontend/legacy/build/b.js":        "var b",
+		"services/.archmcp.yaml":            "ignore: [\"*_mock.go\", \"/gen/\"]\n",
+		"services/api/api.go":               "package api",
+		"services/api/api_mock.go":          "package api",
+		"services/gen/client.go":            "package gen",
+		"services/api/gen/keep.go":          "package gen",
+		"services/broken/.archmcp.yaml":     "ignore: [unterminated\n",
+		"services/broken/x_mock.go":         "package broken",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := config.Default()
+	eng, _ := New(cfg)
+	snap, err := eng.GenerateSnapshot(context.Background(), dir, false)
+	if err != nil {
+		t.Fatalf("GenerateSnapshot: %v", err)
+	}
+
+	hashed := make(map[string]bool)
+	for _, fh := range snap.Meta.FileHashes {
+		hashed[fh.Path] = true
+	}
+	for _, ignored := range []string{
+		"frontend/app.stories.tsx",
+		"frontend/storybook/config.ts",
+		"frontend/legacy/build/b.js",
+		"services/api/api_mock.go",
+		"services/gen/client.go",
+		// A malformed config is skipped in favour of the next one up.
+		"services/broken/x_mock.go",
+	} {
+		if hashed[ignored] {
+			t.Errorf("%s should be excluded by its directory config", ignored)
+		}
+	}
+	for _, kept := range []string{
+		"main.go",
+		"tools/gen.go",
+		"frontend/app.tsx",
+		"frontend/legacy/src/a.js",
+		// The nearest config wins, so frontend's patterns do not apply here.
+		"frontend/legacy/src/a.stories.tsx",
+		// Anchored patterns are relative to the config's directory.
+		"services/api/gen/keep.go",
+	} {
+		if !hashed[kept] {
+			t.Errorf("%s should be walked", kept)
+		}
+	}
+
+	files := []string{"main.go", "frontend/app.tsx", "frontend/legacy/src/a.js"}
+	if got := eng.filesForExtractor("go", files); strings.Join(got, ",") != "main.go,frontend/legacy/src/a.js" {
+		t.Errorf("files for go = %v", got)
+	}
+	if got := eng.filesForExtractor("typescript", files); len(got) != len(files) {
+		t.Errorf("files for typescript = %v", got)
+	}
+}
+
 func TestResolveFactFile_SingleRepo(t *testing.T) {
 	cfg := config.Default()
 	eng, _ := New(cfg)
@@ -193,3 +436,1380 @@ func TestGenerateSnapshot_ConcurrentCallsSerialized(t *testing.T) {
 		}
 	}
 }
+
+func TestIsWatchIgnored(t *testing.T) {
+	cfg := config.Default()
+	cfg.Ignore = []string{"vendor/**", "**/*_test.go"}
+	eng, _ := New(cfg)
+	repo := t.TempDir()
+	os.WriteFile(filepath.Join(repo, ".archmcpignore"), []byte("generated/\n"), 0o644)
+	rules := eng.newWatchRules(repo)
+
+	tests := []struct {
+		relPath string
+		want    bool
+	}{
+		{".archmcp", true},
+		{".archmcp/facts.jsonl", true},
+		{"vendor/foo.go", true},
+		{"pkg/foo_test.go", true},
+		{"generated/foo.go", true},
+		{"pkg/foo.go", false},
+		{".archmcpx/foo.go", false},
+	}
+	for _, tt := range tests {
+		if got := rules.ignored(tt.relPath); got != tt.want {
+			t.Errorf("ignored(%q) = %v, want %v", tt.relPath, got, tt.want)
+		}
+	}
+}
+
+// TestWatch_RegeneratesOnChange verifies that a file change triggers a
+// background regeneration that writes artifacts to the output directory.
+func TestWatch_RegeneratesOnChange(t *testing.T) {
+	repo := t.TempDir()
+	cfg := config.Default()
+	eng, _ := New(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- eng.Watch(ctx, repo, 20*time.Millisecond) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Give the watcher a moment to register the repo root.
+	time.Sleep(100 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	metaPath := filepath.Join(repo, cfg.Output.Dir, "snapshot.meta.json")
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(metaPath); err == nil {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("expected %s to be written after a file change", metaPath)
+}
+
+// TestWatch_OwnIgnoreRulesWhileGenerating runs snapshot generations of another
+// repo while the watcher handles events. The other repo's .archmcpignore
+// excludes *.go, so a watcher filtering with the rules generation last loaded
+// would miss the change. Run with -race to check the watcher reads none of
+// generation's state.
+func TestWatch_OwnIgnoreRulesWhileGenerating(t *testing.T) {
+	repo, other := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(other, ".archmcpignore"), []byte("*.go\n"), 0o644)
+	os.WriteFile(filepath.Join(other, "main.go"), []byte("package main\n"), 0o644)
+
+	cfg := config.Default()
+	cfg.Explainers = nil
+	cfg.Renderers = nil
+	eng, _ := New(cfg)
+	if _, err := eng.GenerateSnapshot(context.Background(), other, false); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		eng.Watch(ctx, repo, 20*time.Millisecond)
+	}()
+	go func() {
+		defer wg.Done()
+		for ctx.Err() == nil {
+			eng.GenerateSnapshot(ctx, other, false)
+		}
+	}()
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	// Give the watcher a moment to register the repo root.
+	time.Sleep(100 * time.Millisecond)
+	metaPath := filepath.Join(repo, cfg.Output.Dir, "snapshot.meta.json")
+	deadline := time.Now().Add(5 * time.Second)
+	for i := 0; time.Now().Before(deadline); i++ {
+		if _, err := os.Stat(metaPath); err == nil {
+			return
+		}
+		if i%10 == 0 {
+			os.WriteFile(filepath.Join(repo, "main.go"), []byte(fmt.Sprintf("package main // %d\n", i)), 0o644)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("expected a change to %s to regenerate its snapshot", repo)
+}
+
+// fileExtractor emits one symbol fact per file and records the files it saw.
+type fileExtractor struct {
+	seen []string
+}
+
+func (x *fileExtractor) Name() string                         { return "go" }
+func (x *fileExtractor) Detect(repoPath string) (bool, error) { return true, nil }
+func (x *fileExtractor) Extract(ctx context.Context, repoPath string, files []string) ([]facts.Fact, error) {
+	x.seen = append([]string(nil), files...)
+	var out []facts.Fact
+	for _, f := range files {
+		out = append(out, facts.Fact{Kind: facts.KindSymbol, Name: f, File: f})
+	}
+	return out, nil
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestGenerateSnapshotSince(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(repo, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a/a.go", "package a\n")
+	write("a/a2.go", "package a\n")
+	write("b/b.go", "package b\n")
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-q", "-m", "init")
+
+	cfg := config.Default()
+	cfg.Explainers = nil
+	cfg.Renderers = nil
+	eng, _ := New(cfg)
+	ext := &fileExtractor{}
+	eng.RegisterExtractor(ext)
+
+	if _, err := eng.GenerateSnapshot(context.Background(), repo, false); err != nil {
+		t.Fatal(err)
+	}
+
+	write("a/a.go", "package a\n\nfunc New() {}\n")
+	write("a/new.go", "package a\n")
+	runGit(t, repo, "add", ".")
+	if err := os.Remove(filepath.Join(repo, "b", "b.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	snap, err := eng.GenerateSnapshotSince(context.Background(), repo, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Meta.ChangedSince != "HEAD" {
+		t.Errorf("ChangedSince = %q, want HEAD", snap.Meta.ChangedSince)
+	}
+
+	// Only the touched directory is re-extracted, siblings included.
+	if len(ext.seen) != 3 {
+		t.Errorf("extractor saw %v, want the 3 files in a/", ext.seen)
+	}
+
+	names := make(map[string]bool)
+	for _, f := range snap.Facts {
+		names[f.Name] = true
+	}
+	for _, want := range []string{"a/a.go", "a/a2.go", "a/new.go"} {
+		if !names[want] {
+			t.Errorf("expected fact for %s", want)
+		}
+	}
+	if names["b/b.go"] {
+		t.Error("facts for a deleted file should not be carried over")
+	}
+}
+
+func TestGenerateSnapshotSince_CarriesOverUnchanged(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	for _, rel := range []string{"a/a.go", "b/b.go"} {
+		os.MkdirAll(filepath.Join(repo, filepath.Dir(rel)), 0o755)
+		os.WriteFile(filepath.Join(repo, rel), []byte("package x\n"), 0o644)
+	}
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-q", "-m", "init")
+
+	cfg := config.Default()
+	cfg.Explainers = nil
+	cfg.Renderers = nil
+	eng, _ := New(cfg)
+	ext := &fileExtractor{}
+	eng.RegisterExtractor(ext)
+	if _, err := eng.GenerateSnapshot(context.Background(), repo, false); err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile(filepath.Join(repo, "a", "a.go"), []byte("package x\n// edit\n"), 0o644)
+	snap, err := eng.GenerateSnapshotSince(context.Background(), repo, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ext.seen) != 1 || ext.seen[0] != filepath.Join("a", "a.go") {
+		t.Errorf("extractor saw %v, want only a/a.go", ext.seen)
+	}
+	if snap.Meta.FactCount != 2 {
+		t.Errorf("FactCount = %d, want 2 (b/b.go carried over)", snap.Meta.FactCount)
+	}
+}
+
+func TestGenerateSnapshotSince_MergesConfigReads(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	read := "package x\n\nvar on = ld.BoolVariation(\"new-checkout\", ctx, false)\n"
+	for _, rel := range []string{"a/a.go", "b/b.go", "c/c.go"} {
+		os.MkdirAll(filepath.Join(repo, filepath.Dir(rel)), 0o755)
+		os.WriteFile(filepath.Join(repo, rel), []byte(read), 0o644)
+	}
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-q", "-m", "init")
+
+	cfg := config.Default()
+	cfg.Explainers = nil
+	cfg.Renderers = nil
+	eng, _ := New(cfg)
+	eng.RegisterExtractor(&fileExtractor{})
+	if _, err := eng.GenerateSnapshot(context.Background(), repo, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := eng.WriteArtifacts(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	// b/b.go moves its read down a line; c/c.go stops reading the flag.
+	os.WriteFile(filepath.Join(repo, "b", "b.go"), []byte("package x\n"+read), 0o644)
+	os.WriteFile(filepath.Join(repo, "c", "c.go"), []byte("package x\n"), 0o644)
+
+	check := func(name string, snap *facts.Snapshot) {
+		t.Helper()
+		var flags []facts.Fact
+		for _, f := range snap.Facts {
+			if f.Name == "flag:new-checkout" {
+				flags = append(flags, f)
+			}
+		}
+		if len(flags) != 1 {
+			t.Fatalf("%s: got %d flag:new-checkout facts, want 1: %+v", name, len(flags), flags)
+		}
+		f := flags[0]
+		if reads := configReads(f); strings.Join(reads, ",") != "a/a.go:3,b/b.go:4" {
+			t.Errorf("%s: reads = %v, want a/a.go:3 and b/b.go:4", name, reads)
+		}
+		if f.File != "a/a.go" || f.Line != 3 {
+			t.Errorf("%s: located at %s:%d, want a/a.go:3", name, f.File, f.Line)
+		}
+		var readBy []string
+		for _, r := range f.Relations {
+			if r.Kind == facts.RelReadBy {
+				readBy = append(readBy, r.Target)
+			}
+		}
+		if strings.Join(readBy, ",") != "a,b" {
+			t.Errorf("%s: read_by = %v, want a and b", name, readBy)
+		}
+	}
+
+	// A fresh engine carries over from facts.jsonl, where reads are []any.
+	disk, _ := New(cfg)
+	disk.RegisterExtractor(&fileExtractor{})
+	snap, err := disk.GenerateSnapshotSince(context.Background(), repo, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("facts.jsonl", snap)
+
+	snap, err = eng.GenerateSnapshotSince(context.Background(), repo, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("in-memory snapshot", snap)
+}
+
+// callExtractor emits one symbol per file, calling the symbols named on the
+// file's "calls" lines.
+type callExtractor struct{}
+
+func (callExtractor) Name() string                         { return "go" }
+func (callExtractor) Detect(repoPath string) (bool, error) { return true, nil }
+func (callExtractor) Extract(ctx context.Context, repoPath string, files []string) ([]facts.Fact, error) {
+	var out []facts.Fact
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(repoPath, file))
+		if err != nil {
+			return nil, err
+		}
+		f := facts.Fact{Kind: facts.KindSymbol, Name: filepath.ToSlash(file), File: file}
+		for _, line := range strings.Split(string(data), "\n") {
+			if target, ok := strings.CutPrefix(line, "calls "); ok {
+				f.Relations = append(f.Relations, facts.Relation{Kind: facts.RelCalls, Target: target})
+			}
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// ignoreAwareExtractor records the repo ignore rules it is given.
+type ignoreAwareExtractor struct {
+	callExtractor
+	ignored func(relPath string) bool
+}
+
+func (x *ignoreAwareExtractor) SetIgnored(ignored func(relPath string) bool) { x.ignored = ignored }
+
+func TestGenerateSnapshot_PassesRepoIgnoreRules(t *testing.T) {
+	repo := t.TempDir()
+	os.WriteFile(filepath.Join(repo, "a.go"), nil, 0o644)
+	os.WriteFile(filepath.Join(repo, ".archmcpignore"), []byte("gen_test.go\n"), 0o644)
+
+	cfg := config.Default()
+	cfg.Explainers = nil
+	cfg.Renderers = nil
+	eng, _ := New(cfg)
+	ext := &ignoreAwareExtractor{}
+	eng.RegisterExtractor(ext)
+	if _, err := eng.GenerateSnapshot(context.Background(), repo, false); err != nil {
+		t.Fatal(err)
+	}
+	if ext.ignored == nil {
+		t.Fatal("SetIgnored was not called")
+	}
+	// The .archmcpignore rules apply; the config's test file glob does not.
+	if !ext.ignored("pkg/gen_test.go") || ext.ignored("pkg/foo_test.go") {
+		t.Errorf("ignored(gen_test.go) = %v, ignored(foo_test.go) = %v; want true, false", ext.ignored("pkg/gen_test.go"), ext.ignored("pkg/foo_test.go"))
+	}
+}
+
+func TestGenerateSnapshotSince_UpdatesGraph(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	write := func(rel, content string) {
+		os.MkdirAll(filepath.Join(repo, filepath.Dir(rel)), 0o755)
+		os.WriteFile(filepath.Join(repo, rel), []byte(content), 0o644)
+	}
+	write("a/a.go", "calls b/b.go\n")
+	write("b/b.go", "calls c/c.go\n")
+	write("c/c.go", "")
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-q", "-m", "init")
+
+	cfg := config.Default()
+	cfg.Explainers = nil
+	cfg.Renderers = nil
+	eng, _ := New(cfg)
+	eng.RegisterExtractor(callExtractor{})
+	if _, err := eng.GenerateSnapshot(context.Background(), repo, false); err != nil {
+		t.Fatal(err)
+	}
+
+	write("a/a.go", "calls c/c.go\n")
+	if _, err := eng.GenerateSnapshotSince(context.Background(), repo, "HEAD"); err != nil {
+		t.Fatal(err)
+	}
+	g := eng.Store().Graph()
+	if g.EdgeWeight("a/a.go", "b/b.go") != 0 || g.EdgeWeight("a/a.go", "c/c.go") != 1 {
+		t.Error("expected the edited file's edge to move from b to c")
+	}
+	if g.EdgeWeight("b/b.go", "c/c.go") != 1 {
+		t.Error("expected the unchanged file's edge to be kept")
+	}
+	if g.EdgeCount() != 2 || g.NodeCount() != 3 {
+		t.Errorf("graph has %d nodes and %d edges, want 3 and 2", g.NodeCount(), g.EdgeCount())
+	}
+}
+
+func TestGenerateSnapshot_AppendSameRepoReplacesFacts(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(first, "a.go"), []byte("calls b.go\n"), 0o644)
+	os.WriteFile(filepath.Join(second, "b.go"), []byte("calls a.go\n"), 0o644)
+
+	cfg := config.Default()
+	cfg.Explainers = nil
+	cfg.Renderers = nil
+	eng, _ := New(cfg)
+	eng.RegisterExtractor(callExtractor{})
+	ctx := context.Background()
+	if _, err := eng.GenerateSnapshot(ctx, first, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := eng.GenerateSnapshot(ctx, second, true); err != nil {
+		t.Fatal(err)
+	}
+	count, edges := eng.Store().Count(), eng.Store().Graph().EdgeCount()
+
+	os.WriteFile(filepath.Join(second, "c.go"), []byte(""), 0o644)
+	if _, err := eng.GenerateSnapshot(ctx, second, true); err != nil {
+		t.Fatal(err)
+	}
+	label := filepath.Base(second)
+	if got := len(eng.Store().ByRepo(label)); got != 2 {
+		t.Errorf("repo %s has %d facts after appending it again, want 2", label, got)
+	}
+	if eng.Store().Count() != count+1 || eng.Store().Graph().EdgeCount() != edges {
+		t.Errorf("store has %d facts and %d edges, want %d and %d", eng.Store().Count(), eng.Store().Graph().EdgeCount(), count+1, edges)
+	}
+	if len(eng.Store().ByRepo(filepath.Base(first))) != 1 {
+		t.Error("facts of the first repo should be kept")
+	}
+}
+
+func TestGenerateSnapshotSince_BadRef(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-q")
+
+	eng, _ := New(config.Default())
+	if _, err := eng.GenerateSnapshotSince(context.Background(), repo, "no-such-ref"); err == nil {
+		t.Error("expected an error for an unknown ref")
+	}
+}
+
+// namedRenderer emits a single artifact named after itself.
+type namedRenderer struct{ name string }
+
+func (r namedRenderer) Name() string { return r.name }
+func (r namedRenderer) Render(ctx context.Context, snapshot *facts.Snapshot) ([]facts.Artifact, error) {
+	return []facts.Artifact{{Name: r.name + ".txt", Content: []byte(r.name)}}, nil
+}
+
+func TestRenderers_GatedByConfig(t *testing.T) {
+	repo := t.TempDir()
+	os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0o644)
+
+	cfg := config.Default()
+	cfg.Explainers = nil
+	cfg.Renderers = []string{"mermaid", "llm_context"}
+	eng, _ := New(cfg)
+	eng.RegisterExtractor(&fileExtractor{})
+	for _, name := range []string{"llm_context", "dot", "mermaid"} {
+		eng.RegisterRenderer(namedRenderer{name})
+	}
+
+	snap, err := eng.GenerateSnapshot(context.Background(), repo, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Meta.Renderers) != 2 {
+		t.Errorf("Meta.Renderers = %v, want llm_context and mermaid", snap.Meta.Renderers)
+	}
+	if err := eng.WriteArtifacts(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	outDir := filepath.Join(repo, cfg.Output.Dir)
+	for _, name := range []string{"llm_context.txt", "mermaid.txt"} {
+		if _, err := os.Stat(filepath.Join(outDir, name)); err != nil {
+			t.Errorf("expected %s to be written: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "dot.txt")); err == nil {
+		t.Error("disabled renderer dot should not write artifacts")
+	}
+}
+
+func TestMergeSpecRoutes(t *testing.T) {
+	route := func(name, method string, props map[string]any) facts.Fact {
+		p := map[string]any{"method": method}
+		for k, v := range props {
+			p[k] = v
+		}
+		return facts.Fact{Kind: facts.KindRoute, Name: name, Props: p}
+	}
+	spec := map[string]any{"source": "openapi", "operationId": "getUser", "tags": []string{"users"}, "spec_file": "api/openapi.yaml"}
+	ff := []facts.Fact{
+		route("/users/:id", "GET", map[string]any{"handler": "GetUser", "framework": "gin"}),
+		route("/users/{id}", "GET", spec),
+		route("/users/{id}", "DELETE", map[string]any{"source": "openapi", "operationId": "deleteUser"}),
+		{Kind: facts.KindRoute, Name: "POST /orders", Props: map[string]any{"http_method": "POST", "path": "/orders", "handler": "create_order"}},
+		route("/orders", "POST", map[string]any{"source": "openapi", "operationId": "createOrder", "summary": "Create"}),
+		route("/users/{id}", "GET", map[string]any{"source": "openapi", "role": "client", "operationId": "getUser"}),
+	}
+
+	merged := mergeSpecRoutes(ff)
+	if len(merged) != 4 {
+		t.Fatalf("expected 4 routes after merging, got %d: %+v", len(merged), merged)
+	}
+	get := merged[0]
+	if get.Props["handler"] != "GetUser" || get.Props["operationId"] != "getUser" || get.Props["spec_file"] != "api/openapi.yaml" {
+		t.Errorf("code route should keep its handler and gain spec props, got %v", get.Props)
+	}
+	if get.Props["source"] != nil {
+		t.Errorf("code route must not take the spec's source prop, got %v", get.Props["source"])
+	}
+	if merged[1].Props["operationId"] != "deleteUser" {
+		t.Errorf("spec-only route should be kept, got %+v", merged[1])
+	}
+	if merged[2].Props["operationId"] != "createOrder" || merged[2].Props["summary"] != "Create" {
+		t.Errorf("python route should be matched via http_method/path, got %v", merged[2].Props)
+	}
+	if merged[3].Props["role"] != "client" {
+		t.Errorf("client spec routes should be kept, got %+v", merged[3])
+	}
+}
+
+func TestMergeSpecRoutes_SpecBeforeCode(t *testing.T) {
+	route := func(name, method string, props map[string]any) facts.Fact {
+		p := map[string]any{"method": method}
+		for k, v := range props {
+			p[k] = v
+		}
+		return facts.Fact{Kind: facts.KindRoute, Name: name, Props: p}
+	}
+	// Dropping the first spec route shifts the later facts; the props of
+	// /a must still land on /a, not on the fact shifted into its old slot.
+	ff := []facts.Fact{
+		route("/b", "GET", map[string]any{"source": "openapi", "operationId": "getB"}),
+		route("/a", "GET", map[string]any{"handler": "A"}),
+		route("/b", "GET", map[string]any{"handler": "B"}),
+		route("/a", "GET", map[string]any{"source": "openapi", "operationId": "getA", "summary": "A route"}),
+	}
+
+	merged := mergeSpecRoutes(ff)
+	if len(merged) != 2 {
+		t.Fatalf("expected 2 routes after merging, got %d: %+v", len(merged), merged)
+	}
+	if a := merged[0]; a.Props["handler"] != "A" || a.Props["operationId"] != "getA" || a.Props["summary"] != "A route" {
+		t.Errorf("/a props = %v, want getA and its summary", a.Props)
+	}
+	if b := merged[1]; b.Props["handler"] != "B" || b.Props["operationId"] != "getB" || b.Props["summary"] != nil {
+		t.Errorf("/b props = %v, want getB only", b.Props)
+	}
+}
+
+// failingExtractor emits a fact per file and fails on the files in bad, or
+// returns err outright when it is set.
+type failingExtractor struct {
+	name string
+	bad  map[string]bool
+	err  error
+}
+
+func (x failingExtractor) Name() string                         { return x.name }
+func (x failingExtractor) Detect(repoPath string) (bool, error) { return true, nil }
+func (x failingExtractor) Extract(ctx context.Context, repoPath string, files []string) ([]facts.Fact, error) {
+	if x.err != nil {
+		return nil, x.err
+	}
+	var out []facts.Fact
+	var fileErrs extractors.FileErrors
+	for _, f := range files {
+		if x.bad[f] {
+			fileErrs.Add(f, errors.New("syntax error"))
+			continue
+		}
+		out = append(out, facts.Fact{Kind: facts.KindSymbol, Name: x.name + ":" + f, File: f})
+	}
+	return out, fileErrs.Err()
+}
+
+func TestGenerateSnapshot_RecordsExtractErrors(t *testing.T) {
+	repo := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "bad.go"} {
+		os.WriteFile(filepath.Join(repo, name), []byte("package main\n"), 0o644)
+	}
+
+	cfg := config.Default()
+	cfg.Explainers = nil
+	cfg.Renderers = nil
+	eng, _ := New(cfg)
+	eng.RegisterExtractor(failingExtractor{name: "go", bad: map[string]bool{"bad.go": true}})
+	eng.RegisterExtractor(failingExtractor{name: "python", err: errors.New("interpreter not found")})
+
+	snap, err := eng.GenerateSnapshot(context.Background(), repo, false)
+	if err != nil {
+		t.Fatalf("GenerateSnapshot: %v", err)
+	}
+
+	if snap.Meta.FactCount != 2 {
+		t.Errorf("FactCount = %d, want the 2 facts from files that parsed", snap.Meta.FactCount)
+	}
+	if snap.Meta.FileCount != 3 || snap.Meta.FilesParsed() != 2 {
+		t.Errorf("parsed %d/%d files, want 2/3", snap.Meta.FilesParsed(), snap.Meta.FileCount)
+	}
+	want := []facts.ExtractError{
+		{File: "bad.go", Extractor: "go", Message: "syntax error"},
+		{Extractor: "python", Message: "interpreter not found"},
+	}
+	if len(snap.Meta.Errors) != len(want) {
+		t.Fatalf("Errors = %+v, want %+v", snap.Meta.Errors, want)
+	}
+	for i := range want {
+		if snap.Meta.Errors[i] != want[i] {
+			t.Errorf("Errors[%d] = %+v, want %+v", i, snap.Meta.Errors[i], want[i])
+		}
+	}
+	if len(snap.Meta.Extractors) != 1 || snap.Meta.Extractors[0] != "go" {
+		t.Errorf("Extractors = %v, want only go (python failed outright)", snap.Meta.Extractors)
+	}
+
+	// Both extractors ran, so both are timed, the failed one included.
+	stats := snap.Meta.ExtractorStats
+	if len(stats) != 2 {
+		t.Fatalf("ExtractorStats = %+v, want go and python", stats)
+	}
+	if stats[0].Name != "go" || stats[0].Facts != 2 || stats[0].Files != 3 {
+		t.Errorf("go stat = %+v, want 2 facts from 3 files", stats[0])
+	}
+	if stats[1].Name != "python" || stats[1].Facts != 0 || stats[1].Files != 3 {
+		t.Errorf("python stat = %+v, want no facts from 3 files", stats[1])
+	}
+	for _, st := range stats {
+		if _, err := time.ParseDuration(st.Duration); err != nil {
+			t.Errorf("%s duration %q: %v", st.Name, st.Duration, err)
+		}
+	}
+}
+
+// blockingExtractor waits for its context to end, like an extractor stuck on
+// a pathological file that still checks ctx.Done() in its loop.
+type blockingExtractor struct{}
+
+func (blockingExtractor) Name() string                         { return "go" }
+func (blockingExtractor) Detect(repoPath string) (bool, error) { return true, nil }
+func (blockingExtractor) Extract(ctx context.Context, repoPath string, files []string) ([]facts.Fact, error) {
+	<-ctx.Done()
+	return []facts.Fact{{Kind: facts.KindSymbol, Name: "partial", File: files[0]}}, ctx.Err()
+}
+
+func TestGenerateSnapshot_Timeout(t *testing.T) {
+	repo := t.TempDir()
+	os.WriteFile(filepath.Join(repo, "a.go"), []byte("package main\n"), 0o644)
+
+	cfg := config.Default()
+	cfg.Explainers = nil
+	cfg.Renderers = nil
+	cfg.Generate.Timeout = "50ms"
+	eng, _ := New(cfg)
+	eng.RegisterExtractor(blockingExtractor{})
+
+	start := time.Now()
+	_, err := eng.GenerateSnapshot(context.Background(), repo, false)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "generate.timeout (50ms)") {
+		t.Fatalf("err = %v, want a generate.timeout deadline error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("generation took %s despite the timeout", elapsed)
+	}
+	if eng.Snapshot() != nil {
+		t.Error("a timed-out generation must not publish a snapshot")
+	}
+}
+
+// stallingExtractor is a callExtractor until stall is set, and then blocks
+// like blockingExtractor.
+type stallingExtractor struct{ stall bool }
+
+func (x *stallingExtractor) Name() string                         { return "go" }
+func (x *stallingExtractor) Detect(repoPath string) (bool, error) { return true, nil }
+func (x *stallingExtractor) Extract(ctx context.Context, repoPath string, files []string) ([]facts.Fact, error) {
+	if x.stall {
+		return blockingExtractor{}.Extract(ctx, repoPath, files)
+	}
+	return callExtractor{}.Extract(ctx, repoPath, files)
+}
+
+func TestGenerateSnapshot_TimeoutKeepsPreviousSnapshot(t *testing.T) {
+	repo, other := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(repo, "a.go"), []byte("calls b.go\n"), 0o644)
+	os.WriteFile(filepath.Join(repo, "b.go"), []byte(""), 0o644)
+	os.WriteFile(filepath.Join(other, "c.go"), []byte(""), 0o644)
+
+	cfg := config.Default()
+	cfg.Explainers = nil
+	cfg.Renderers = nil
+	eng, _ := New(cfg)
+	ext := &stallingExtractor{}
+	eng.RegisterExtractor(ext)
+	snap, err := eng.GenerateSnapshot(context.Background(), repo, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ext.stall = true
+	cfg.Generate.Timeout = "50ms"
+	for _, appendMode := range []bool{false, true} {
+		_, err := eng.GenerateSnapshot(context.Background(), other, appendMode)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("append %v: err = %v, want a deadline error", appendMode, err)
+		}
+		if eng.Snapshot() != snap || eng.RepoPaths() != nil {
+			t.Errorf("append %v: the timed-out run replaced the snapshot or repo paths", appendMode)
+		}
+		// The earlier facts stay, untagged and without the partial output.
+		store := eng.Store()
+		if store.Count() != 2 || len(store.ByFile("a.go")) != 1 || len(store.ByName("partial")) != 0 {
+			t.Errorf("append %v: store holds %v", appendMode, store.All())
+		}
+		if g := store.Graph(); g == nil || g.EdgeWeight("a.go", "b.go") != 1 || g.NodeCount() != 2 {
+			t.Errorf("append %v: the previous graph is no longer served", appendMode)
+		}
+	}
+}
+
+func TestGenerateSnapshot_MaxFiles(t *testing.T) {
+	repo := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		os.WriteFile(filepath.Join(repo, name), []byte("package main\n"), 0o644)
+	}
+
+	cfg := config.Default()
+	cfg.Explainers = nil
+	cfg.Renderers = nil
+	eng, _ := New(cfg)
+	eng.RegisterExtractor(&fileExtractor{})
+	if _, err := eng.GenerateSnapshot(context.Background(), repo, false); err != nil {
+		t.Fatalf("GenerateSnapshot: %v", err)
+	}
+
+	cfg.Generate.MaxFiles = 2
+	_, err := eng.GenerateSnapshot(context.Background(), repo, false)
+	if err == nil || !strings.Contains(err.Error(), "3 files, more than generate.max_files (2)") {
+		t.Fatalf("err = %v, want a max_files error", err)
+	}
+	// The check runs before the store is cleared.
+	if eng.Store().Count() != 3 || eng.Snapshot() == nil {
+		t.Errorf("previous snapshot lost: %d facts", eng.Store().Count())
+	}
+}
+
+// factsExtractor returns a fixed fact set.
+type factsExtractor struct{ ff []facts.Fact }
+
+func (x factsExtractor) Name() string                         { return "go" }
+func (x factsExtractor) Detect(repoPath string) (bool, error) { return true, nil }
+func (x factsExtractor) Extract(ctx context.Context, repoPath string, files []string) ([]facts.Fact, error) {
+	return append([]facts.Fact(nil), x.ff...), nil
+}
+
+func TestGenerateSnapshot_MaxFacts(t *testing.T) {
+	repo := t.TempDir()
+	os.WriteFile(filepath.Join(repo, "a.go"), []byte("package main\n"), 0o644)
+
+	// big has 6 symbols, small has 2; 3 non-symbol facts.
+	ff := []facts.Fact{
+		{Kind: facts.KindModule, Name: "big"},
+		{Kind: facts.KindModule, Name: "small"},
+		{Kind: facts.KindRoute, Name: "GET /x", File: "big/routes.go"},
+		{Kind: facts.KindSymbol, Name: "small.A", File: "small/a.go"},
+		{Kind: facts.KindSymbol, Name: "small.B", File: "small/a.go"},
+	}
+	for i := 0; i < 6; i++ {
+		ff = append(ff, facts.Fact{Kind: facts.KindSymbol, Name: fmt.Sprintf("big.s%d", i), File: "big/b.go",
+			Props: map[string]any{"exported": i >= 4}})
+	}
+
+	cfg := config.Default()
+	cfg.Explainers = nil
+	cfg.Renderers = nil
+	cfg.Generate.MaxFacts = 8
+	eng, _ := New(cfg)
+	eng.RegisterExtractor(factsExtractor{ff: ff})
+
+	_, err := eng.GenerateSnapshot(context.Background(), repo, false)
+	if err == nil || !strings.Contains(err.Error(), "extracted 11 facts, more than generate.max_facts (8)") {
+		t.Fatalf("err = %v, want a max_facts error", err)
+	}
+
+	cfg.Generate.MaxFactsStrategy = config.MaxFactsSample
+	snap, err := eng.GenerateSnapshot(context.Background(), repo, false)
+	if err != nil {
+		t.Fatalf("GenerateSnapshot: %v", err)
+	}
+	// A budget of 5 symbols fits a cap of 3: small keeps 2, big keeps 3.
+	want := facts.Sampling{MaxFacts: 8, ExtractedFacts: 11, SymbolsPerModule: 3, SampledModules: 1, DroppedSymbols: 3}
+	if snap.Meta.Sampling == nil || *snap.Meta.Sampling != want {
+		t.Fatalf("Sampling = %+v, want %+v", snap.Meta.Sampling, want)
+	}
+	if snap.Meta.FactCount != 8 {
+		t.Errorf("FactCount = %d, want 8", snap.Meta.FactCount)
+	}
+	var kept []string
+	for _, f := range eng.Store().All() {
+		if strings.HasPrefix(f.Name, "big.") {
+			kept = append(kept, f.Name)
+		}
+	}
+	// Exported symbols first, then extraction order.
+	if fmt.Sprint(kept) != "[big.s0 big.s4 big.s5]" {
+		t.Errorf("kept big symbols %v, want s4, s5 and s0", kept)
+	}
+	if len(eng.Store().ByKind(facts.KindRoute)) != 1 || len(eng.Store().Modules()) != 2 {
+		t.Error("non-symbol facts must all be kept")
+	}
+
+	cfg.Generate.MaxSymbolsPerModule = 1
+	snap, err = eng.GenerateSnapshot(context.Background(), repo, false)
+	if err != nil {
+		t.Fatalf("GenerateSnapshot: %v", err)
+	}
+	if sm := snap.Meta.Sampling; sm == nil || sm.SymbolsPerModule != 1 || sm.DroppedSymbols != 6 || snap.Meta.FactCount != 5 {
+		t.Errorf("with max_symbols_per_module 1: Sampling = %+v, FactCount = %d", sm, snap.Meta.FactCount)
+	}
+}
+
+// recordingExtractor records whether it ran.
+type recordingExtractor struct {
+	name string
+	ran  bool
+}
+
+func (x *recordingExtractor) Name() string                         { return x.name }
+func (x *recordingExtractor) Detect(repoPath string) (bool, error) { return true, nil }
+func (x *recordingExtractor) Extract(ctx context.Context, repoPath string, files []string) ([]facts.Fact, error) {
+	x.ran = true
+	return nil, nil
+}
+
+func TestGenerateSnapshot_MaxFactsStopsAtFirstExtractorOver(t *testing.T) {
+	repo := t.TempDir()
+	os.WriteFile(filepath.Join(repo, "a.go"), []byte("package main\n"), 0o644)
+
+	cfg := config.Default()
+	cfg.Explainers = nil
+	cfg.Renderers = nil
+	cfg.Extractors = []string{"go", "python"}
+	cfg.Generate.MaxFacts = 2
+	eng, _ := New(cfg)
+	eng.RegisterExtractor(factsExtractor{ff: []facts.Fact{
+		{Kind: facts.KindSymbol, Name: "a", File: "a.go"},
+		{Kind: facts.KindSymbol, Name: "b", File: "a.go"},
+		{Kind: facts.KindSymbol, Name: "c", File: "a.go"},
+	}})
+	later := &recordingExtractor{name: "python"}
+	eng.RegisterExtractor(later)
+
+	_, err := eng.GenerateSnapshot(context.Background(), repo, false)
+	if err == nil || !strings.Contains(err.Error(), "extracted 3 facts, more than generate.max_facts (2)") {
+		t.Fatalf("err = %v, want a max_facts error", err)
+	}
+	if later.ran {
+		t.Error("extractors after the one that crossed generate.max_facts should not run")
+	}
+}
+
+func TestGenerateSnapshotSince_MaxFactsCountsCarriedOver(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	for _, rel := range []string{"a/a.go", "b/b.go", "b/b2.go"} {
+		os.MkdirAll(filepath.Join(repo, filepath.Dir(rel)), 0o755)
+		os.WriteFile(filepath.Join(repo, rel), []byte("package x\n"), 0o644)
+	}
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-q", "-m", "init")
+
+	cfg := config.Default()
+	cfg.Explainers = nil
+	cfg.Renderers = nil
+	eng, _ := New(cfg)
+	eng.RegisterExtractor(&fileExtractor{})
+	if _, err := eng.GenerateSnapshot(context.Background(), repo, false); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Generate.MaxFacts = 2
+	os.WriteFile(filepath.Join(repo, "a", "a.go"), []byte("package x\n// edit\n"), 0o644)
+	_, err := eng.GenerateSnapshotSince(context.Background(), repo, "HEAD")
+	want := "extracted 1 facts, more than the 0 facts generate.max_facts (2) leaves after 2 carried over from unchanged files"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("err = %v, want %q", err, want)
+	}
+	if eng.Store().Count() != 3 {
+		t.Errorf("the failed run changed the store: %d facts", eng.Store().Count())
+	}
+}
+
+func TestComputeFileHashes_ReusesCacheForUnchangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{"a.go", "b.go", "sub/c.go"}
+	for _, f := range files {
+		path := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package "+f), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eng, _ := New(config.Default())
+
+	hashes := eng.computeFileHashes(dir, files)
+	if len(hashes) != 3 {
+		t.Fatalf("expected 3 hashes, got %d", len(hashes))
+	}
+	sum := sha256.Sum256([]byte("package a.go"))
+	if got := hashes["a.go"]; got.Hash != hex.EncodeToString(sum[:]) || got.Path != "a.go" || got.ModTime == "" {
+		t.Errorf("a.go hash = %+v", got)
+	}
+
+	// An unchanged file is served from the cache without being re-read.
+	absA := filepath.Join(dir, "a.go")
+	cached := eng.hashCache[absA]
+	cached.hash = "cached"
+	eng.hashCache[absA] = cached
+
+	// A modified file is re-hashed; a deleted one drops out.
+	if err := os.WriteFile(filepath.Join(dir, "b.go"), []byte("package b // edited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "sub/c.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	hashes = eng.computeFileHashes(dir, files)
+	if hashes["a.go"].Hash != "cached" {
+		t.Errorf("unchanged file was re-hashed: %q", hashes["a.go"].Hash)
+	}
+	sum = sha256.Sum256([]byte("package b // edited"))
+	if hashes["b.go"].Hash != hex.EncodeToString(sum[:]) {
+		t.Errorf("modified file hash = %q, want the new content's hash", hashes["b.go"].Hash)
+	}
+	if _, ok := hashes["sub/c.go"]; ok {
+		t.Error("deleted file should have no hash")
+	}
+	if _, ok := eng.hashCache[filepath.Join(dir, "sub/c.go")]; ok {
+		t.Error("deleted file should be evicted from the hash cache")
+	}
+}
+
+func TestRenderArtifact(t *testing.T) {
+	cfg := config.Default()
+	eng, _ := New(cfg)
+	eng.RegisterRenderer(llmcontext.New(16000))
+
+	if _, err := eng.RenderArtifact(context.Background(), "llm_context", "llm_context.md", 0); err == nil {
+		t.Error("expected an error without a snapshot")
+	}
+
+	snap := &facts.Snapshot{Meta: facts.SnapshotMeta{RepoPath: "/repo"}}
+	for i := 0; i < 300; i++ {
+		snap.Facts = append(snap.Facts, facts.Fact{
+			Kind: facts.KindModule, Name: fmt.Sprintf("internal/pkg%03d", i), File: fmt.Sprintf("internal/pkg%03d/pkg.go", i),
+			Props: map[string]any{"language": "go"},
+		})
+	}
+	eng.SetSnapshot(snap)
+
+	full, err := eng.RenderArtifact(context.Background(), "llm_context", "llm_context.md", 0)
+	if err != nil {
+		t.Fatalf("RenderArtifact: %v", err)
+	}
+	short, err := eng.RenderArtifact(context.Background(), "llm_context", "llm_context.md", 1000)
+	if err != nil {
+		t.Fatalf("RenderArtifact with max_tokens: %v", err)
+	}
+	if !strings.HasPrefix(string(short), "# Architecture Snapshot") || len(short) >= len(full) {
+		t.Errorf("a 1000-token summary (%d bytes) should be shorter than the default one (%d bytes)", len(short), len(full))
+	}
+
+	if _, err := eng.RenderArtifact(context.Background(), "nope", "x.md", 0); err == nil {
+		t.Error("expected an error for an unregistered renderer")
+	}
+}
+
+func TestSaveAndLoadSnapshot(t *testing.T) {
+	repo := t.TempDir()
+	write := func(rel string) {
+		path := filepath.Join(repo, rel)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte("package x\n"), 0o644)
+	}
+	write("main/a.go")
+
+	cfg := config.Default()
+	cfg.Explainers = nil
+	cfg.Renderers = []string{"llm_context"}
+	eng, _ := New(cfg)
+	eng.RegisterExtractor(&fileExtractor{})
+	eng.RegisterRenderer(namedRenderer{"llm_context"})
+
+	if _, err := eng.SaveSnapshot("main"); err == nil {
+		t.Error("expected an error saving without a snapshot")
+	}
+	if _, err := eng.GenerateSnapshot(context.Background(), repo, false); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := eng.SaveSnapshot("main")
+	if err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+	if want := filepath.Join(repo, cfg.Output.Dir, "snapshots", "main"); dir != want {
+		t.Errorf("saved to %s, want %s", dir, want)
+	}
+
+	write("feature/b.go")
+	if _, err := eng.GenerateSnapshot(context.Background(), repo, false); err != nil {
+		t.Fatal(err)
+	}
+	if eng.Store().Count() != 2 {
+		t.Fatalf("feature snapshot should have 2 facts, got %d", eng.Store().Count())
+	}
+
+	snap, err := eng.LoadSnapshot("main")
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if eng.Store().Count() != 1 || len(eng.Store().ByName("main/a.go")) != 1 {
+		t.Errorf("expected only the main snapshot's fact after loading, got %v", eng.Store().All())
+	}
+	if eng.Snapshot() != snap || snap.Meta.RepoPath == "" || len(snap.Artifacts) != 1 || snap.Artifacts[0].Name != "llm_context.txt" {
+		t.Errorf("loaded snapshot = %+v", snap)
+	}
+	if eng.Store().Graph() == nil || eng.Store().Graph().NodeCount() == 0 {
+		t.Error("expected the graph to be rebuilt after loading")
+	}
+
+	if _, err := eng.LoadSnapshot("feature"); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("loading an unknown snapshot: err = %v, want ErrSnapshotNotFound", err)
+	}
+	if got := eng.SavedSnapshots(); len(got) != 1 || got[0] != "main" {
+		t.Errorf("SavedSnapshots = %v, want [main]", got)
+	}
+	for _, name := range []string{"", "../main", "feature/x", ".hidden"} {
+		if ValidSnapshotName(name) {
+			t.Errorf("ValidSnapshotName(%q) = true, want false", name)
+		}
+	}
+}
+
+func TestLoadFactsFile(t *testing.T) {
+	repo := t.TempDir()
+	os.WriteFile(filepath.Join(repo, "a.go"), []byte("package x\n"), 0o644)
+
+	cfg := config.Default()
+	cfg.Explainers = nil
+	cfg.Renderers = []string{"llm_context"}
+	gen, _ := New(cfg)
+	gen.RegisterExtractor(&fileExtractor{})
+	gen.RegisterRenderer(namedRenderer{"llm_context"})
+	if _, err := gen.GenerateSnapshot(context.Background(), repo, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := gen.WriteArtifacts(repo); err != nil {
+		t.Fatal(err)
+	}
+	outDir := filepath.Join(repo, cfg.Output.Dir)
+
+	// A server somewhere else loads the output directory's facts file.
+	eng, _ := New(config.Default())
+	snap, err := eng.LoadFactsFile(filepath.Join(outDir, "facts.jsonl"))
+	if err != nil {
+		t.Fatalf("LoadFactsFile: %v", err)
+	}
+	if eng.Store().Count() != 1 || eng.Snapshot() != snap || eng.Store().Graph() == nil {
+		t.Errorf("store has %d facts, snapshot %p vs %p", eng.Store().Count(), eng.Snapshot(), snap)
+	}
+	if snap.Meta.RepoPath != gen.Snapshot().Meta.RepoPath || len(snap.Artifacts) != 1 || snap.Artifacts[0].Name != "llm_context.txt" {
+		t.Errorf("loaded snapshot = %+v", snap)
+	}
+
+	// A facts file shipped on its own has no meta, insights or artifacts.
+	shipped := filepath.Join(t.TempDir(), "ci-facts.jsonl")
+	data, _ := os.ReadFile(filepath.Join(outDir, "facts.jsonl"))
+	os.WriteFile(shipped, data, 0o644)
+	os.WriteFile(filepath.Join(filepath.Dir(shipped), "notes.txt"), []byte("unrelated"), 0o644)
+	snap, err = eng.LoadFactsFile(shipped)
+	if err != nil {
+		t.Fatalf("LoadFactsFile: %v", err)
+	}
+	if eng.Store().Count() != 1 || snap.Meta.FactCount != 1 || len(snap.Artifacts) != 0 || snap.Meta.RepoPath != "" {
+		t.Errorf("loaded snapshot = %+v", snap)
+	}
+
+	if _, err := eng.LoadFactsFile(filepath.Join(t.TempDir(), "missing.jsonl")); err == nil {
+		t.Error("expected an error for a missing facts file")
+	}
+	if eng.Store().Count() != 1 {
+		t.Error("a failed load should leave the store untouched")
+	}
+}
+
+func TestWriteArtifacts_Compress(t *testing.T) {
+	repo := t.TempDir()
+	os.WriteFile(filepath.Join(repo, "a.go"), []byte("package x\n"), 0o644)
+
+	cfg := config.Default()
+	cfg.Explainers = nil
+	cfg.Renderers = nil
+	eng, _ := New(cfg)
+	eng.RegisterExtractor(&fileExtractor{})
+	if _, err := eng.GenerateSnapshot(context.Background(), repo, false); err != nil {
+		t.Fatal(err)
+	}
+	outDir := filepath.Join(repo, cfg.Output.Dir)
+	if err := eng.WriteArtifacts(repo); err != nil {
+		t.Fatal(err)
+	}
+	if got := FactsFile(outDir, true); got != filepath.Join(outDir, "facts.jsonl") {
+		t.Errorf("FactsFile with only facts.jsonl = %q", got)
+	}
+
+	// Turning compression on replaces facts.jsonl with facts.jsonl.gz.
+	cfg.Output.Compress = true
+	if err := eng.WriteArtifacts(repo); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "facts.jsonl")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("stale facts.jsonl should be removed, stat: %v", err)
+	}
+	factsPath := FactsFile(outDir, false)
+	if factsPath != filepath.Join(outDir, "facts.jsonl.gz") {
+		t.Fatalf("FactsFile = %q, want facts.jsonl.gz", factsPath)
+	}
+
+	loader, _ := New(config.Default())
+	snap, err := loader.LoadFactsFile(factsPath)
+	if err != nil {
+		t.Fatalf("LoadFactsFile: %v", err)
+	}
+	if loader.Store().Count() != 1 || snap.Meta.RepoPath != eng.Snapshot().Meta.RepoPath || len(snap.Artifacts) != 0 {
+		t.Errorf("loaded %d facts, snapshot %+v", loader.Store().Count(), snap)
+	}
+
+	if got := FactsFile(t.TempDir(), true); got != "" {
+		t.Errorf("FactsFile of an empty dir = %q, want \"\"", got)
+	}
+}
+
+func TestExplainIgnore(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"main.go":                 "package main",
+		"vendor/lib/lib.go":       "package lib",
+		"gen/api.pb.go":           "package gen",
+		"internal/a/a_gen.go":     "package a",
+		".archmcpignore":          "gen/\n*_gen.go\n!keep_gen.go\n",
+		"internal/a/keep_gen.go":  "package a",
+		"web/.archmcp.yaml":       "ignore: [\"*.stories.tsx\"]\n",
+		"web/app.stories.tsx":     "export default {}",
+		"legacy/.archmcp.yaml":    "include: [src/]\n",
+		"legacy/build/out.js":     "var out",
+		"legacy/src/a.js":         "var a",
+		"node_modules/x/index.js": "module.exports = 1",
+		"internal/a/a_test.go":    "package a",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := config.Default()
+	cfg.Ignore = []string{"vendor/**", "node_modules", "**/*_test.go"}
+	eng, _ := New(cfg)
+
+	tests := []struct {
+		path string
+		want *IgnoreMatch
+	}{
+		{"main.go", nil},
+		{"vendor/lib/lib.go", &IgnoreMatch{Path: "vendor", Source: "config", Pattern: "vendor/**"}},
+		// Only the directory matches, but the walk never enters it.
+		{"node_modules/x/index.js", &IgnoreMatch{Path: "node_modules", Source: "config", Pattern: "node_modules"}},
+		{"internal/a/a_test.go", &IgnoreMatch{Path: "internal/a/a_test.go", Source: "config", Pattern: "**/*_test.go"}},
+		{"gen/api.pb.go", &IgnoreMatch{Path: "gen", Source: ".archmcpignore", Pattern: "gen/"}},
+		{"internal/a/a_gen.go", &IgnoreMatch{Path: "internal/a/a_gen.go", Source: ".archmcpignore", Pattern: "*_gen.go"}},
+		{"internal/a/keep_gen.go", nil},
+		{"web/app.stories.tsx", &IgnoreMatch{Path: "web/app.stories.tsx", Source: "web/.archmcp.yaml", Pattern: "*.stories.tsx"}},
+		{"legacy/build/out.js", &IgnoreMatch{Path: "legacy/build/out.js", Source: "legacy/.archmcp.yaml", NotIncluded: true}},
+		{"legacy/src/a.js", nil},
+		{"gen/missing.go", &IgnoreMatch{Path: "gen", Source: ".archmcpignore", Pattern: "gen/"}},
+		{filepath.Join(dir, "gen", "api.pb.go"), &IgnoreMatch{Path: "gen", Source: ".archmcpignore", Pattern: "gen/"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			exp, err := eng.ExplainIgnore(dir, tt.path)
+			if err != nil {
+				t.Fatalf("ExplainIgnore: %v", err)
+			}
+			if exp.Ignored != (tt.want != nil) {
+				t.Fatalf("ignored = %v, want %v (match %+v)", exp.Ignored, tt.want != nil, exp.Match)
+			}
+			if tt.want != nil && *exp.Match != *tt.want {
+				t.Errorf("match = %+v, want %+v", *exp.Match, *tt.want)
+			}
+		})
+	}
+
+	if exp, _ := eng.ExplainIgnore(dir, "gen/missing.go"); exp.Exists {
+		t.Error("missing path should report exists: false")
+	}
+	if exp, _ := eng.ExplainIgnore(dir, "gen"); !exp.Exists || !exp.IsDir || !exp.Ignored {
+		t.Errorf("gen = %+v, want an existing, ignored directory", exp)
+	}
+	if _, err := eng.ExplainIgnore(dir, "../elsewhere.go"); err == nil {
+		t.Error("expected an error for a path outside the repository")
+	}
+}
+
+func TestSetExtractors(t *testing.T) {
+	repo := t.TempDir()
+	os.WriteFile(filepath.Join(repo, "a.go"), []byte("package main\n"), 0o644)
+
+	cfg := config.Default()
+	cfg.Explainers = nil
+	cfg.Renderers = nil
+	eng, _ := New(cfg)
+	eng.RegisterExtractor(failingExtractor{name: "go"})
+	eng.RegisterExtractor(failingExtractor{name: "typescript"})
+
+	if _, err := eng.SetExtractors([]string{"go", "rust"}); err == nil || !strings.Contains(err.Error(), "go, typescript") {
+		t.Errorf("unknown extractor error = %v, want one listing the registered extractors", err)
+	}
+	if !cfg.IsExtractorEnabled("typescript") {
+		t.Error("a rejected list should leave the config unchanged")
+	}
+
+	enabled, err := eng.SetExtractors([]string{"typescript", "typescript"})
+	if err != nil {
+		t.Fatalf("SetExtractors: %v", err)
+	}
+	if len(enabled) != 1 || enabled[0] != "typescript" {
+		t.Errorf("enabled = %v, want [typescript]", enabled)
+	}
+
+	snap, err := eng.GenerateSnapshot(context.Background(), repo, false)
+	if err != nil {
+		t.Fatalf("GenerateSnapshot: %v", err)
+	}
+	if len(snap.Meta.Extractors) != 1 || snap.Meta.Extractors[0] != "typescript" {
+		t.Errorf("Meta.Extractors = %v, want only typescript", snap.Meta.Extractors)
+	}
+}
+
+func TestMergeConfigFacts(t *testing.T) {
+	env := func(file string, line int, module string) facts.Fact {
+		return facts.Fact{Kind: facts.KindConfig, Name: "env:API_URL", File: file, Line: line,
+			Props:     map[string]any{"source": "env", "variable": "API_URL", "reads": []string{fmt.Sprintf("%s:%d", file, line)}},
+			Relations: []facts.Relation{{Kind: facts.RelReadBy, Target: module}}}
+	}
+	ff := []facts.Fact{
+		env("cmd/api/main.go", 12, "cmd/api"),
+		{Kind: facts.KindModule, Name: "cmd/api"},
+		env("web/src/client.ts", 3, "web/src"),
+	}
+
+	merged := mergeConfigFacts(ff)
+	if len(merged) != 2 {
+		t.Fatalf("merged = %v, want the module and one config fact", merged)
+	}
+	f := merged[0]
+	if f.File != "cmd/api/main.go" || f.Line != 12 {
+		t.Errorf("merged fact at %s:%d, want the first extractor's read", f.File, f.Line)
+	}
+	if reads := f.Props["reads"].([]string); len(reads) != 2 || reads[1] != "web/src/client.ts:3" {
+		t.Errorf("reads = %v, want both reads", reads)
+	}
+	if len(f.Relations) != 2 || f.Relations[1].Target != "web/src" {
+		t.Errorf("relations = %v, want read_by for both modules", f.Relations)
+	}
+}
+
+func TestGenerateSnapshot_FeatureFlags(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"checkout/handler.go": "package checkout\n\nfunc Handle() {\n\tif ld.BoolVariation(\"new-checkout\", user, false) {\n\t}\n\t// ld.BoolVariation(\"commented-out\", user, false)\n}\n",
+		"web/src/cart.ts":     "if (unleash.isEnabled('new-checkout')) {}\nconst v = client.variationDetail(`dark-mode`, false);\nif (logger.isEnabled('DEBUG')) {}\n",
+		"app/cart.rb":         "Flags.is_enabled?(:legacy_cart)\n# ld.variation(\"commented-ruby\")\n",
+		"web/src/flags.ts":    "class Flags {\n  #beta = ld.boolVariation('beta-banner', ctx, false);\n}\n",
+		"docs/flags.md":       "ld.BoolVariation(\"not-source\")\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := config.Default()
+	cfg.Ignore = nil
+	eng, _ := New(cfg)
+	flagFacts := func() map[string]facts.Fact {
+		t.Helper()
+		if _, err := eng.GenerateSnapshot(context.Background(), dir, false); err != nil {
+			t.Fatalf("GenerateSnapshot: %v", err)
+		}
+		flags := make(map[string]facts.Fact)
+		for _, f := range eng.Store().ByKind(facts.KindConfig) {
+			flags[f.Name] = f
+		}
+		return flags
+	}
+
+	// The defaults are LaunchDarkly's typed methods only, so generic names
+	// such as isEnabled are not flags until configured.
+	flags := flagFacts()
+	if len(flags) != 2 {
+		t.Fatalf("config facts = %v, want new-checkout and beta-banner", flags)
+	}
+	if f := flags["flag:new-checkout"]; f.File != "checkout/handler.go" || f.Line != 4 || f.Props["source"] != "feature_flag" || f.Props["flag"] != "new-checkout" {
+		t.Errorf("flag:new-checkout = %+v", f)
+	}
+	if _, ok := flags["flag:beta-banner"]; !ok {
+		t.Error("missing flag:beta-banner read in a TypeScript #private field")
+	}
+
+	cfg.FeatureFlags.Methods = append(slices.Clone(config.DefaultFlagMethods), "isEnabled", "is_enabled?", "variation", "variationDetail")
+	flags = flagFacts()
+	// logger.isEnabled('DEBUG') is a flag too once isEnabled is configured.
+	if len(flags) != 5 {
+		t.Fatalf("config facts = %v, want new-checkout, dark-mode, legacy_cart, beta-banner and DEBUG", flags)
+	}
+	f := flags["flag:new-checkout"]
+	if reads := f.Props["reads"].([]string); len(reads) != 2 || reads[1] != "web/src/cart.ts:1" {
+		t.Errorf("reads = %v, want the Go and TypeScript call sites", reads)
+	}
+	if methods := f.Props["methods"].([]string); len(methods) != 2 || methods[0] != "BoolVariation" || methods[1] != "isEnabled" {
+		t.Errorf("methods = %v", methods)
+	}
+	if len(f.Relations) != 2 || f.Relations[0].Kind != facts.RelReadBy || f.Relations[1].Target != "web/src" {
+		t.Errorf("relations = %v, want read_by for both modules", f.Relations)
+	}
+	if methods := flags["flag:dark-mode"].Props["methods"].([]string); len(methods) != 1 || methods[0] != "variationDetail" {
+		t.Errorf("dark-mode methods = %v, want the longest matching method", methods)
+	}
+	if _, ok := flags["flag:legacy_cart"]; !ok {
+		t.Error("missing flag:legacy_cart read with a Ruby symbol")
+	}
+
+	cfg.FeatureFlags.Methods = nil
+	if flags := flagFacts(); len(flags) != 0 {
+		t.Errorf("%d config facts with no flag methods, want 0", len(flags))
+	}
+}
@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// mergeConfigFacts combines the config facts that several extractors emit
+// for the same variable (a Go service and a TypeScript frontend both reading
+// API_URL) into the first one, taking the union of their reads and read_by
+// relations. The merged fact keeps the location of the first read of the
+// extractor that ran first.
+func mergeConfigFacts(ff []facts.Fact) []facts.Fact {
+	first := make(map[string]int) // name -> index into out
+	out := ff[:0]
+	for _, f := range ff {
+		if f.Kind != facts.KindConfig {
+			out = append(out, f)
+			continue
+		}
+		idx, dup := first[f.Name]
+		if !dup {
+			first[f.Name] = len(out)
+			out = append(out, f)
+			continue
+		}
+		merged := &out[idx]
+		reads := configReads(*merged)
+		for _, r := range configReads(f) {
+			if !slices.Contains(reads, r) {
+				reads = append(reads, r)
+			}
+		}
+		if reads != nil {
+			merged.Props["reads"] = reads
+		}
+		for _, rel := range f.Relations {
+			if !slices.Contains(merged.Relations, rel) {
+				merged.Relations = append(merged.Relations, rel)
+			}
+		}
+	}
+	return out
+}
+
+// configReads returns the reads prop of a config fact ("file:line"), also
+// when it was loaded from facts.jsonl as a []any.
+func configReads(f facts.Fact) []string {
+	switch reads := f.Props["reads"].(type) {
+	case []string:
+		return reads
+	case []any:
+		out := make([]string, 0, len(reads))
+		for _, r := range reads {
+			if s, ok := r.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// withConfigReads returns a copy of the config fact f listing only reads,
+// located at the first of them in file and line order, with a read_by
+// relation per reading module, as the extractors build it.
+func withConfigReads(f facts.Fact, reads []string) facts.Fact {
+	reads = slices.Clone(reads)
+	sort.SliceStable(reads, func(i, j int) bool {
+		fi, li := splitRead(reads[i])
+		fj, lj := splitRead(reads[j])
+		if fi != fj {
+			return fi < fj
+		}
+		return li < lj
+	})
+
+	props := make(map[string]any, len(f.Props))
+	for k, v := range f.Props {
+		props[k] = v
+	}
+	props["reads"] = reads
+	f.Props = props
+	if len(reads) > 0 {
+		f.File, f.Line = splitRead(reads[0])
+	}
+
+	var rels []facts.Relation
+	for _, rel := range f.Relations {
+		if rel.Kind != facts.RelReadBy {
+			rels = append(rels, rel)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, r := range reads {
+		file, _ := splitRead(r)
+		if dir := filepath.Dir(file); !seen[dir] {
+			seen[dir] = true
+			rels = append(rels, facts.Relation{Kind: facts.RelReadBy, Target: dir})
+		}
+	}
+	f.Relations = rels
+	return f
+}
+
+// splitRead splits a "file:line" read into its file and line.
+func splitRead(read string) (string, int) {
+	i := strings.LastIndex(read, ":")
+	if i < 0 {
+		return read, 0
+	}
+	line, err := strconv.Atoi(read[i+1:])
+	if err != nil {
+		return read, 0
+	}
+	return read[:i], line
+}
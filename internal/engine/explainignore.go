@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreMatch describes the rule that keeps a path out of the repository
+// walk.
+type IgnoreMatch struct {
+	// Path is the path the rule matched: the path itself, or the ancestor
+	// directory whose exclusion keeps it out of the walk.
+	Path string `json:"path"`
+	// Source is where the rule is defined: "config" for the ignore list,
+	// ".archmcpignore", or the repo-relative path of a .archmcp.yaml file.
+	Source string `json:"source"`
+	// Pattern is the matching pattern as written. It is empty when the path
+	// was excluded by a .archmcp.yaml include list.
+	Pattern string `json:"pattern,omitempty"`
+	// NotIncluded is set when the path is a file that matches none of the
+	// include patterns of its nearest .archmcp.yaml.
+	NotIncluded bool `json:"not_included,omitempty"`
+}
+
+// IgnoreExplanation reports whether a path would be walked.
+type IgnoreExplanation struct {
+	Path    string       `json:"path"`
+	Exists  bool         `json:"exists"`
+	IsDir   bool         `json:"is_dir"`
+	Ignored bool         `json:"ignored"`
+	Match   *IgnoreMatch `json:"match,omitempty"`
+}
+
+// ExplainIgnore reports whether walking repoPath would skip relPath, and
+// which rule is responsible. The rules are checked as the walk checks them:
+// each ancestor directory first, since an ignored directory is never
+// entered, then the path itself. .archmcpignore and .archmcp.yaml files are
+// read from disk, so edits made since the last snapshot are reflected. A path
+// that does not exist is checked as a file.
+func (e *Engine) ExplainIgnore(repoPath, relPath string) (*IgnoreExplanation, error) {
+	absRepo, err := filepath.Abs(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("resolving repo path: %w", err)
+	}
+	if filepath.IsAbs(relPath) {
+		if relPath, err = filepath.Rel(absRepo, relPath); err != nil {
+			return nil, fmt.Errorf("resolving path: %w", err)
+		}
+	}
+	relPath = filepath.ToSlash(filepath.Clean(relPath))
+	if relPath == ".." || strings.HasPrefix(relPath, "../") {
+		return nil, fmt.Errorf("path %q is outside the repository %s", relPath, absRepo)
+	}
+
+	exp := &IgnoreExplanation{Path: relPath}
+	if info, err := os.Stat(filepath.Join(absRepo, filepath.FromSlash(relPath))); err == nil {
+		exp.Exists = true
+		exp.IsDir = info.IsDir()
+	}
+	if relPath == "." {
+		return exp, nil
+	}
+
+	repoIgnore := loadIgnoreFile(absRepo)
+	dirs := newDirConfigs(absRepo)
+	segments := strings.Split(relPath, "/")
+	for i := range segments {
+		prefix := strings.Join(segments[:i+1], "/")
+		isDir := i < len(segments)-1 || exp.IsDir
+		if m := matchIgnore(e.cfg.Ignore, repoIgnore, dirs, prefix, isDir); m != nil {
+			exp.Ignored = true
+			exp.Match = m
+			break
+		}
+	}
+	return exp, nil
+}
@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/dejo1307/archmcp/internal/config"
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// factLimit enforces generate.max_facts on the facts of one generation. It
+// is applied each time an extractor finishes, so a run over the limit fails,
+// or is sampled down, before the next extractor adds its facts. Facts
+// carried over from unchanged files count toward the limit.
+type factLimit struct {
+	gen     config.GenerateConfig
+	carried int             // facts kept from the previous snapshot
+	sampled map[string]bool // modules that lost symbols
+	dropped int             // symbols dropped so far
+}
+
+func newFactLimit(gen config.GenerateConfig, carried int) *factLimit {
+	return &factLimit{gen: gen, carried: carried, sampled: make(map[string]bool)}
+}
+
+// apply enforces the limit on ff, the facts extracted so far. Under the
+// limit, ff is returned unchanged. Over it, the "error" strategy fails, and
+// the "sample" strategy keeps every non-symbol fact (modules, routes,
+// storage, dependencies) and the first symbols of each module, exported ones
+// first, up to a per-module cap chosen so the total fits.
+func (l *factLimit) apply(ff []facts.Fact) ([]facts.Fact, error) {
+	limit := l.gen.MaxFacts
+	if limit <= 0 || l.carried+len(ff) <= limit {
+		return ff, nil
+	}
+	budget := limit - l.carried
+	if l.gen.MaxFactsStrategy != config.MaxFactsSample {
+		return nil, fmt.Errorf("extracted %d facts, more than %s; add ignore patterns, raise the limit, or set generate.max_facts_strategy to %q",
+			len(ff), l.describe(), config.MaxFactsSample)
+	}
+
+	bySymbolModule := make(map[string][]int) // module -> indices of its symbols
+	nonSymbols := 0
+	for i, f := range ff {
+		if f.Kind != facts.KindSymbol {
+			nonSymbols++
+			continue
+		}
+		mod := symbolModule(f)
+		bySymbolModule[mod] = append(bySymbolModule[mod], i)
+	}
+	if nonSymbols > budget {
+		return nil, fmt.Errorf("extracted %d facts other than symbols, more than %s, so sampling symbols cannot fit the snapshot; raise the limit",
+			nonSymbols, l.describe())
+	}
+
+	counts := make([]int, 0, len(bySymbolModule))
+	for _, idx := range bySymbolModule {
+		counts = append(counts, len(idx))
+	}
+	perModule := symbolCap(counts, budget-nonSymbols)
+	if l.gen.MaxSymbolsPerModule > 0 && l.gen.MaxSymbolsPerModule < perModule {
+		perModule = l.gen.MaxSymbolsPerModule
+	}
+
+	drop := make(map[int]bool)
+	for mod, idx := range bySymbolModule {
+		if len(idx) <= perModule {
+			continue
+		}
+		// Exported symbols are kept first; extraction order breaks ties.
+		sort.SliceStable(idx, func(a, b int) bool {
+			ea, _ := ff[idx[a]].Props["exported"].(bool)
+			eb, _ := ff[idx[b]].Props["exported"].(bool)
+			return ea && !eb
+		})
+		for _, i := range idx[perModule:] {
+			drop[i] = true
+		}
+		l.sampled[mod] = true
+		l.dropped += len(idx) - perModule
+	}
+
+	kept := make([]facts.Fact, 0, len(ff)-len(drop))
+	for i, f := range ff {
+		if !drop[i] {
+			kept = append(kept, f)
+		}
+	}
+	return kept, nil
+}
+
+// describe names the limit in errors, with the share left by carried-over
+// facts when there are any.
+func (l *factLimit) describe() string {
+	if l.carried == 0 {
+		return fmt.Sprintf("generate.max_facts (%d)", l.gen.MaxFacts)
+	}
+	return fmt.Sprintf("the %d facts generate.max_facts (%d) leaves after %d carried over from unchanged files",
+		max(l.gen.MaxFacts-l.carried, 0), l.gen.MaxFacts, l.carried)
+}
+
+// sampling describes what apply dropped, given kept, the facts of the run
+// after the last apply, or returns nil when nothing was dropped.
+func (l *factLimit) sampling(kept []facts.Fact) *facts.Sampling {
+	if l.dropped == 0 {
+		return nil
+	}
+	perModule := make(map[string]int)
+	for _, f := range kept {
+		if mod := symbolModule(f); f.Kind == facts.KindSymbol && l.sampled[mod] {
+			perModule[mod]++
+		}
+	}
+	most := 0
+	for _, n := range perModule {
+		most = max(most, n)
+	}
+	return &facts.Sampling{
+		MaxFacts:         l.gen.MaxFacts,
+		ExtractedFacts:   len(kept) + l.dropped,
+		SymbolsPerModule: most,
+		SampledModules:   len(l.sampled),
+		DroppedSymbols:   l.dropped,
+	}
+}
+
+// symbolModule returns the module a symbol is sampled with: the directory
+// of its file.
+func symbolModule(f facts.Fact) string {
+	return path.Dir(filepath.ToSlash(f.File))
+}
+
+// symbolCap returns the largest per-module cap c for which keeping
+// min(count, c) symbols of every module stays within budget.
+func symbolCap(counts []int, budget int) int {
+	maxCount := 0
+	for _, c := range counts {
+		maxCount = max(maxCount, c)
+	}
+	// sort.Search finds the smallest cap that overshoots the budget.
+	over := sort.Search(maxCount+1, func(c int) bool {
+		total := 0
+		for _, n := range counts {
+			total += min(n, c)
+		}
+		return total > budget
+	})
+	return over - 1
+}
@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/extractors"
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// flagSourceExts maps the extensions of the source files scanned for
+// feature flag reads to the comment rule of their language.
+var flagSourceExts = map[string]func(string) bool{
+	".go": extractors.IsCommentLine, ".ts": extractors.IsCommentLine, ".tsx": extractors.IsCommentLine,
+	".js": extractors.IsCommentLine, ".jsx": extractors.IsCommentLine, ".mjs": extractors.IsCommentLine,
+	".cjs": extractors.IsCommentLine, ".java": extractors.IsCommentLine, ".kt": extractors.IsCommentLine,
+	".kts": extractors.IsCommentLine, ".swift": extractors.IsCommentLine, ".scala": extractors.IsCommentLine,
+	".py": extractors.IsHashCommentLine, ".rb": extractors.IsHashCommentLine,
+}
+
+// scanFeatureFlags returns a config fact per feature flag read in the source
+// files among files, by the methods of feature_flags.methods. Flag SDKs are
+// called the same way in every language, so the scan runs once over all
+// files rather than in each extractor.
+func (e *Engine) scanFeatureFlags(ctx context.Context, repoPath string, files []string) []facts.Fact {
+	flags := extractors.NewFeatureFlags(e.cfg.FeatureFlags.Methods)
+	if flags == nil {
+		return nil
+	}
+	for _, rel := range files {
+		if ctx.Err() != nil {
+			return nil
+		}
+		isComment := flagSourceExts[strings.ToLower(filepath.Ext(rel))]
+		if isComment == nil {
+			continue
+		}
+		src, err := os.ReadFile(filepath.Join(repoPath, rel))
+		if err != nil {
+			continue
+		}
+		flags.Scan(filepath.ToSlash(rel), src, isComment)
+	}
+	return flags.Facts()
+}
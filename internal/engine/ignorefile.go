@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreFileName is the repo-local ignore file, written in gitignore syntax.
+const ignoreFileName = ".archmcpignore"
+
+// ignoreRule is one compiled line of an ignore file.
+type ignoreRule struct {
+	re       *regexp.Regexp
+	negate   bool   // "!pattern" re-includes a previously ignored path
+	dirOnly  bool   // "pattern/" only matches directories
+	anchored bool   // the pattern contains a slash, so it matches from the repo root
+	pattern  string // the line as written
+	source   string // the file the line came from
+}
+
+// loadIgnoreFile reads .archmcpignore from the repo root. A missing file
+// yields no rules.
+func loadIgnoreFile(repoPath string) []ignoreRule {
+	data, err := os.ReadFile(filepath.Join(repoPath, ignoreFileName))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[engine] warning: reading %s: %v", ignoreFileName, err)
+		}
+		return nil
+	}
+	rules := parseIgnoreRules(data)
+	log.Printf("[engine] loaded %d patterns from %s", len(rules), ignoreFileName)
+	return rules
+}
+
+// parseIgnoreRules compiles gitignore-style lines: blank lines and "#"
+// comments are skipped, "!" negates, a trailing "/" restricts to directories,
+// a slash elsewhere anchors the pattern to the root, and "*", "?", "[...]"
+// and "**" behave as in git.
+func parseIgnoreRules(data []byte) []ignoreRule {
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if rule, ok := parseIgnorePattern(line, ignoreFileName); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// parseIgnorePattern compiles a single gitignore-style pattern. source names
+// the file it came from in warnings.
+func parseIgnorePattern(line, source string) (ignoreRule, bool) {
+	rule := ignoreRule{pattern: line, source: source}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	rule.anchored = strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return rule, false
+	}
+
+	re, err := regexp.Compile("^" + globToRegexp(line) + "$")
+	if err != nil {
+		log.Printf("[engine] warning: skipping %s pattern %q: %v", source, line, err)
+		return rule, false
+	}
+	rule.re = re
+	return rule, true
+}
+
+// globToRegexp translates a gitignore glob to a regular expression.
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			sb.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// matchIgnoreRules reports whether relPath is ignored by rules. As in git,
+// the last matching rule wins, and a path inside an ignored directory stays
+// ignored even if a later rule would re-include it.
+func matchIgnoreRules(rules []ignoreRule, relPath string, isDir bool) bool {
+	_, ok := findIgnoreRule(rules, relPath, isDir)
+	return ok
+}
+
+// findIgnoreRule is matchIgnoreRules, also returning the rule that ignored
+// relPath.
+func findIgnoreRule(rules []ignoreRule, relPath string, isDir bool) (ignoreRule, bool) {
+	if len(rules) == 0 {
+		return ignoreRule{}, false
+	}
+	segments := strings.Split(filepath.ToSlash(relPath), "/")
+	for i := range segments {
+		prefix := strings.Join(segments[:i+1], "/")
+		prefixIsDir := i < len(segments)-1 || isDir
+
+		var matched ignoreRule
+		ignored := false
+		for _, r := range rules {
+			if r.dirOnly && !prefixIsDir {
+				continue
+			}
+			target := prefix
+			if !r.anchored {
+				target = segments[i]
+			}
+			if r.re.MatchString(target) {
+				matched, ignored = r, !r.negate
+			}
+		}
+		if ignored {
+			return matched, true
+		}
+	}
+	return ignoreRule{}, false
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// specRouteProps are copied from an OpenAPI route onto the code route it
+// duplicates. Props the code fact already has are left alone.
+var specRouteProps = []string{
+	"operationId",
+	"summary",
+	"description",
+	"tags",
+	"spec_file",
+	"gateway_prefix",
+	"gateway_path",
+	"exposed",
+	"auth_mode",
+}
+
+// mergeSpecRoutes drops OpenAPI route facts that duplicate a code-derived
+// route with the same method and path, copying the spec's operationId, tags
+// and related props onto the code fact. The code fact wins because it carries
+// the handler and its file and line. Spec routes without a code counterpart,
+// and client-role routes on either side, are kept as they are.
+func mergeSpecRoutes(ff []facts.Fact) []facts.Fact {
+	codeRoutes := make(map[string]int) // method + normalized path -> index into ff
+	for i, f := range ff {
+		if f.Kind != facts.KindRoute || isSpecRoute(f) || f.Props["role"] == "client" {
+			continue
+		}
+		if key, ok := routeKey(f); ok {
+			if _, dup := codeRoutes[key]; !dup {
+				codeRoutes[key] = i
+			}
+		}
+	}
+	if len(codeRoutes) == 0 {
+		return ff
+	}
+
+	// A separate output slice, so the indices of code routes stay valid
+	// while spec routes are dropped.
+	out := make([]facts.Fact, 0, len(ff))
+	outIdx := make(map[string]int, len(codeRoutes)) // key -> index into out
+	var specs []facts.Fact                          // spec routes merged into a code route
+	for i, f := range ff {
+		if f.Kind == facts.KindRoute {
+			if key, ok := routeKey(f); ok {
+				idx, found := codeRoutes[key]
+				switch {
+				case found && isSpecRoute(f) && f.Props["role"] != "client":
+					specs = append(specs, f)
+					continue
+				case found && idx == i:
+					outIdx[key] = len(out)
+				}
+			}
+		}
+		out = append(out, f)
+	}
+
+	for _, f := range specs {
+		key, _ := routeKey(f)
+		code := &out[outIdx[key]]
+		if code.Props == nil {
+			code.Props = make(map[string]any)
+		}
+		for _, p := range specRouteProps {
+			if v, ok := f.Props[p]; ok {
+				if _, exists := code.Props[p]; !exists {
+					code.Props[p] = v
+				}
+			}
+		}
+	}
+	return out
+}
+
+func isSpecRoute(f facts.Fact) bool {
+	return f.Props["source"] == "openapi"
+}
+
+// routeKey identifies a route by HTTP method and path, with path parameters
+// normalized so "/users/{id}", "/users/:id", "/users/<id>" and "/users/[id]"
+// match.
+func routeKey(f facts.Fact) (string, bool) {
+	method, _ := f.Props["method"].(string)
+	if method == "" {
+		method, _ = f.Props["http_method"].(string)
+	}
+	method = strings.ToUpper(method)
+	if method == "" || method == "USE" || method == "ANY" {
+		return "", false
+	}
+
+	path, _ := f.Props["path"].(string)
+	if path == "" {
+		path = strings.TrimPrefix(f.Name, method+" ")
+	}
+	return method + " " + normalizeRoutePath(path), true
+}
+
+func normalizeRoutePath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") ||
+			(strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}")) ||
+			(strings.HasPrefix(seg, "<") && strings.HasSuffix(seg, ">")) ||
+			(strings.HasPrefix(seg, "[") && strings.HasSuffix(seg, "]")) {
+			segments[i] = "{}"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
@@ -0,0 +1,255 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// snapshotsDirName is the directory under the output dir that holds named
+// snapshots, one subdirectory per name.
+const snapshotsDirName = "snapshots"
+
+// repoPathsFile records the repo label -> path mapping of a multi-repo
+// snapshot, so file resolution keeps working after it is loaded.
+const repoPathsFile = "repo_paths.json"
+
+// snapshotNamePattern keeps names usable as a single directory name.
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ErrSnapshotNotFound is returned by LoadSnapshot for a name that was never
+// saved.
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// ValidSnapshotName reports whether name can be used with SaveSnapshot and
+// LoadSnapshot: letters, digits, '.', '-' and '_', not starting with a
+// punctuation character. Branch names like "feature/x" need their slash
+// replaced.
+func ValidSnapshotName(name string) bool {
+	return snapshotNamePattern.MatchString(name)
+}
+
+// snapshotsDir returns the directory holding named snapshots: the snapshots
+// directory inside the output dir of the current snapshot's repo, or of the
+// configured repo when nothing has been generated yet.
+func (e *Engine) snapshotsDir() (string, error) {
+	var abs string
+	var err error
+	if e.snapshot != nil && e.snapshot.Meta.RepoPath != "" {
+		abs, err = filepath.Abs(e.snapshot.Meta.RepoPath)
+	} else {
+		abs, err = e.cfg.RepoPath()
+	}
+	if err != nil {
+		return "", fmt.Errorf("resolving repo path: %w", err)
+	}
+	return filepath.Join(abs, e.cfg.Output.Dir, snapshotsDirName), nil
+}
+
+// SaveSnapshot writes the current facts, insights, meta and artifacts to
+// <out_dir>/snapshots/<name>/, replacing an earlier snapshot of that name. It
+// returns the directory written.
+func (e *Engine) SaveSnapshot(name string) (string, error) {
+	if !ValidSnapshotName(name) {
+		return "", fmt.Errorf("invalid snapshot name %q", name)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.snapshot == nil {
+		return "", fmt.Errorf("no snapshot generated")
+	}
+	base, err := e.snapshotsDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, name)
+	if err := os.RemoveAll(dir); err != nil {
+		return "", fmt.Errorf("clearing %s: %w", dir, err)
+	}
+	if err := e.writeSnapshotFiles(dir); err != nil {
+		return "", err
+	}
+	if len(e.repoPaths) > 0 {
+		data, err := json.MarshalIndent(e.repoPaths, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("marshaling repo paths: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, repoPathsFile), data, 0o644); err != nil {
+			return "", fmt.Errorf("writing %s: %w", repoPathsFile, err)
+		}
+	}
+	log.Printf("[engine] saved snapshot %q to %s", name, dir)
+	return dir, nil
+}
+
+// LoadSnapshot replaces the store and current snapshot with the named
+// snapshot saved by SaveSnapshot, and rebuilds the graph index. The current
+// state is left untouched if the saved snapshot cannot be read.
+func (e *Engine) LoadSnapshot(name string) (*facts.Snapshot, error) {
+	if !ValidSnapshotName(name) {
+		return nil, fmt.Errorf("invalid snapshot name %q", name)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	base, err := e.snapshotsDir()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(base, name)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%w: %q", ErrSnapshotNotFound, name)
+	}
+
+	factsPath := FactsFile(dir, e.cfg.Output.Compress)
+	if factsPath == "" {
+		factsPath = filepath.Join(dir, factsFileName) // reported as missing
+	}
+	loaded, snapshot, repoPaths, err := readSnapshotFiles(factsPath, true)
+	if err != nil {
+		return nil, err
+	}
+
+	e.store.Clear()
+	e.store.Add(loaded.All()...)
+	e.store.BuildGraph()
+	snapshot.Facts = e.store.All()
+	e.snapshot = snapshot
+	e.repoPaths = repoPaths
+	log.Printf("[engine] loaded snapshot %q (%d facts) from %s", name, e.store.Count(), dir)
+	return snapshot, nil
+}
+
+// LoadFactsFile replaces the store and current snapshot with the facts in
+// path, a facts.jsonl or facts.jsonl.gz written by an earlier run (for
+// example one generated in CI and shipped to a server that only answers
+// queries). The snapshot meta, insights and artifacts in the same directory
+// are loaded too when present. The analyzed repository does not have to
+// exist on this machine.
+func (e *Engine) LoadFactsFile(path string) (*facts.Snapshot, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	loaded, snapshot, repoPaths, err := readSnapshotFiles(path, false)
+	if err != nil {
+		return nil, err
+	}
+	e.store.Clear()
+	e.store.Add(loaded.All()...)
+	e.store.BuildGraph()
+	snapshot.Facts = e.store.All()
+	if snapshot.Meta.FactCount == 0 {
+		snapshot.Meta.FactCount = e.store.Count()
+	}
+	e.snapshot = snapshot
+	e.repoPaths = repoPaths
+	log.Printf("[engine] loaded %d facts from %s", e.store.Count(), path)
+	return snapshot, nil
+}
+
+// readSnapshotFiles reads the facts file at factsPath and, from its
+// directory, the snapshot meta, insights, repo paths and renderer artifacts.
+// When strict is set, a missing meta or insights file is an error; otherwise
+// they are skipped, and so are the artifacts when there is no meta, since
+// the directory is then not an output directory.
+func readSnapshotFiles(factsPath string, strict bool) (*facts.Store, *facts.Snapshot, map[string]string, error) {
+	dir := filepath.Dir(factsPath)
+	loaded := facts.NewStore()
+	if err := loaded.ReadJSONLFile(factsPath); err != nil {
+		return nil, nil, nil, err
+	}
+	missing := func(err error) bool { return !strict && errors.Is(err, os.ErrNotExist) }
+	snapshot := &facts.Snapshot{}
+	err := readJSONFile(filepath.Join(dir, "snapshot.meta.json"), &snapshot.Meta)
+	if err != nil && !missing(err) {
+		return nil, nil, nil, err
+	}
+	hasMeta := err == nil
+	if err := readJSONFile(filepath.Join(dir, "insights.json"), &snapshot.Insights); err != nil && !missing(err) {
+		return nil, nil, nil, err
+	}
+	var repoPaths map[string]string
+	if err := readJSONFile(filepath.Join(dir, repoPathsFile), &repoPaths); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil, err
+	}
+
+	if !hasMeta {
+		return loaded, snapshot, repoPaths, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("reading %s: %w", dir, err)
+	}
+	for _, entry := range entries {
+		switch entry.Name() {
+		case filepath.Base(factsPath), factsFileName, compressedFactsFileName, "snapshot.meta.json", "insights.json", repoPathsFile:
+			continue
+		}
+		if entry.IsDir() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("reading %s: %w", entry.Name(), err)
+		}
+		snapshot.Artifacts = append(snapshot.Artifacts, facts.Artifact{
+			Name:    entry.Name(),
+			Content: content,
+			Type:    artifactType(entry.Name()),
+		})
+	}
+	return loaded, snapshot, repoPaths, nil
+}
+
+// SavedSnapshots returns the names of the saved snapshots, sorted.
+func (e *Engine) SavedSnapshots() []string {
+	base, err := e.snapshotsDir()
+	if err != nil {
+		return nil
+	}
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && ValidSnapshotName(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decoding %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// artifactType guesses a renderer artifact's MIME type from its file name.
+func artifactType(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md":
+		return "text/markdown"
+	case ".json":
+		return "application/json"
+	}
+	return "text/plain"
+}
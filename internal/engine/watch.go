@@ -0,0 +1,158 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watch monitors repoPath for file changes and regenerates the snapshot once
+// changes have settled for the debounce interval. It blocks until ctx is
+// cancelled. Regeneration goes through GenerateSnapshot, so it is serialized
+// with tool-triggered runs by the engine mutex. Changes are filtered with the
+// ignore rules of repoPath, which Watch loads itself and reloads when an
+// .archmcpignore or .archmcp.yaml file changes.
+func (e *Engine) Watch(ctx context.Context, repoPath string, debounce time.Duration) error {
+	absRepo, err := filepath.Abs(repoPath)
+	if err != nil {
+		return fmt.Errorf("resolving repo path: %w", err)
+	}
+	if debounce <= 0 {
+		debounce = 500 * time.Millisecond
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	rules := e.newWatchRules(absRepo)
+	if err := rules.addDirs(watcher, absRepo); err != nil {
+		return fmt.Errorf("watching %s: %w", absRepo, err)
+	}
+	log.Printf("[watch] watching %s (debounce %s)", absRepo, debounce)
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			relPath, err := filepath.Rel(absRepo, ev.Name)
+			if err != nil || rules.ignored(relPath) {
+				continue
+			}
+			if name := filepath.Base(relPath); name == ignoreFileName || name == dirConfigName {
+				rules = e.newWatchRules(absRepo)
+			}
+			// fsnotify is not recursive: pick up directories created after startup.
+			if ev.Has(fsnotify.Create) {
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+					if err := rules.addDirs(watcher, ev.Name); err != nil {
+						log.Printf("[watch] warning: failed to watch %s: %v", relPath, err)
+					}
+				}
+			}
+			timer.Reset(debounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("[watch] error: %v", err)
+
+		case <-timer.C:
+			e.regenerateFromWatch(ctx, absRepo)
+		}
+	}
+}
+
+// watchRules decides which changes under a watched repo are ignored. It
+// holds its own copy of the repo's ignore rules: the engine's are replaced by
+// every snapshot generation, for whichever repo it walks.
+type watchRules struct {
+	root       string
+	patterns   []string
+	outDir     string
+	repoIgnore []ignoreRule
+	dirs       *dirConfigs
+}
+
+// newWatchRules loads the ignore rules of the repo at root.
+func (e *Engine) newWatchRules(root string) *watchRules {
+	return &watchRules{
+		root:       root,
+		patterns:   e.cfg.Ignore,
+		outDir:     filepath.ToSlash(filepath.Clean(e.cfg.Output.Dir)),
+		repoIgnore: loadIgnoreFile(root),
+		dirs:       newDirConfigs(root),
+	}
+}
+
+// addDirs registers dir and all non-ignored subdirectories with the watcher.
+func (w *watchRules) addDirs(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(w.root, path)
+		if err != nil {
+			return err
+		}
+		if relPath != "." && w.ignored(relPath) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// ignored reports whether a change at relPath should be ignored. The output
+// directory is always skipped so that writing artifacts does not retrigger a
+// regeneration.
+func (w *watchRules) ignored(relPath string) bool {
+	relPath = filepath.ToSlash(relPath)
+	if relPath == w.outDir || strings.HasPrefix(relPath, w.outDir+"/") {
+		return true
+	}
+	return matchIgnore(w.patterns, w.repoIgnore, w.dirs, relPath, false) != nil
+}
+
+// regenerateFromWatch rebuilds the snapshot for repoPath and writes artifacts.
+// Multi-repo stores are left untouched since a single-repo regeneration would
+// discard the other repositories' facts.
+func (e *Engine) regenerateFromWatch(ctx context.Context, repoPath string) {
+	e.mu.Lock()
+	multiRepo := len(e.repoPaths) > 0
+	e.mu.Unlock()
+	if multiRepo {
+		log.Printf("[watch] skipping regeneration: multi-repo snapshot is active")
+		return
+	}
+
+	log.Printf("[watch] change detected, regenerating snapshot for %s", repoPath)
+	if _, err := e.GenerateSnapshot(ctx, repoPath, false); err != nil {
+		log.Printf("[watch] regeneration failed: %v", err)
+		return
+	}
+	if err := e.WriteArtifacts(repoPath); err != nil {
+		log.Printf("[watch] warning: failed to write artifacts: %v", err)
+	}
+}
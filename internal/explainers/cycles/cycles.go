@@ -3,17 +3,40 @@ package cycles
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/dejo1307/archmcp/internal/facts"
 )
 
-// CycleExplainer detects cyclic dependencies between modules using Tarjan's SCC algorithm.
-type CycleExplainer struct{}
+// Granularities accepted by NewWithGranularity.
+const (
+	GranularityModule = "module" // import cycles between modules (default)
+	GranularityFile   = "file"   // reference cycles between individual files
+	GranularitySymbol = "symbol" // call cycles between symbols
+)
+
+// CycleExplainer detects cyclic dependencies using Tarjan's SCC algorithm.
+// By default it works on modules; file and symbol granularities run the same
+// detection over a graph derived from symbol call relations.
+type CycleExplainer struct {
+	granularity string
+}
 
-// New creates a new CycleExplainer.
+// New creates a new CycleExplainer that detects module-level cycles.
 func New() *CycleExplainer {
-	return &CycleExplainer{}
+	return NewWithGranularity(GranularityModule)
+}
+
+// NewWithGranularity creates a CycleExplainer for the given node set.
+// Unknown or empty values fall back to GranularityModule.
+func NewWithGranularity(granularity string) *CycleExplainer {
+	switch granularity {
+	case GranularityFile, GranularitySymbol:
+	default:
+		granularity = GranularityModule
+	}
+	return &CycleExplainer{granularity: granularity}
 }
 
 func (e *CycleExplainer) Name() string {
@@ -22,6 +45,13 @@ func (e *CycleExplainer) Name() string {
 
 // Explain builds a dependency graph from import relations and detects cycles.
 func (e *CycleExplainer) Explain(ctx context.Context, store *facts.Store) ([]facts.Insight, error) {
+	switch e.granularity {
+	case GranularityFile:
+		return explainFineGrained(buildFileGraph(store), "file"), nil
+	case GranularitySymbol:
+		return explainFineGrained(buildSymbolGraph(store), "symbol"), nil
+	}
+
 	// Build adjacency list from dependency facts
 	graph := buildDependencyGraph(store)
 
@@ -60,6 +90,234 @@ func (e *CycleExplainer) Explain(ctx context.Context, store *facts.Store) ([]fac
 	return insights, nil
 }
 
+// explainFineGrained reports each cycle in a file or symbol graph. Unlike
+// module cycles, the description gives an actual loop through the graph so
+// the exact files or symbols involved can be followed in order.
+func explainFineGrained(graph map[string][]string, unit string) []facts.Insight {
+	sccs := tarjanSCC(graph)
+	for _, scc := range sccs {
+		sort.Strings(scc)
+	}
+	// Map iteration makes SCC discovery order random; sort for stable output.
+	sort.Slice(sccs, func(i, j int) bool { return sccs[i][0] < sccs[j][0] })
+
+	var insights []facts.Insight
+	for _, scc := range sccs {
+		if len(scc) <= 1 {
+			continue
+		}
+
+		loop := findLoop(graph, scc)
+		evidence := make([]facts.Evidence, 0, len(scc))
+		for _, node := range scc {
+			ev := facts.Evidence{Detail: fmt.Sprintf("%s %q is part of the cycle", unit, node)}
+			if unit == "file" {
+				ev.File = node
+			} else {
+				ev.Symbol = node
+				ev.Fact = node
+			}
+			evidence = append(evidence, ev)
+		}
+
+		insights = append(insights, facts.Insight{
+			Title:       fmt.Sprintf("Cyclic dependency detected (%d %ss)", len(scc), unit),
+			Description: fmt.Sprintf("The following %ss form a reference cycle: %s. Cycles below the module level complicate initialization order and make the code hard to split.", unit, strings.Join(loop, " -> ")),
+			Confidence:  1.0, // Deterministic
+			Evidence:    evidence,
+			Actions: []string{
+				"Move the shared declarations into one file or a new package",
+				"Break the loop by passing dependencies in instead of referencing them directly",
+			},
+		})
+	}
+	return insights
+}
+
+// findLoop returns a closed path (first node repeated at the end) through
+// nodes of scc, found by BFS from the smallest node back to itself.
+func findLoop(graph map[string][]string, scc []string) []string {
+	in := make(map[string]bool, len(scc))
+	for _, n := range scc {
+		in[n] = true
+	}
+	start := scc[0]
+	prev := map[string]string{}
+	queue := []string{start}
+	for qi := 0; qi < len(queue); qi++ {
+		cur := queue[qi]
+		next := append([]string(nil), graph[cur]...)
+		sort.Strings(next)
+		for _, n := range next {
+			if !in[n] {
+				continue
+			}
+			if n == start {
+				path := []string{start}
+				for c := cur; c != start; c = prev[c] {
+					path = append(path, c)
+				}
+				// path is start, cur, ..., reversed; flip everything after start.
+				for i, j := 1, len(path)-1; i < j; i, j = i+1, j-1 {
+					path[i], path[j] = path[j], path[i]
+				}
+				return append(path, start)
+			}
+			if _, seen := prev[n]; !seen && n != start {
+				prev[n] = cur
+				queue = append(queue, n)
+			}
+		}
+	}
+	// Unreachable for a true SCC; fall back to the member list.
+	return append(append([]string(nil), scc...), start)
+}
+
+// symbolIndex resolves call targets, as written in source, to symbol facts.
+type symbolIndex struct {
+	byName map[string]facts.Fact
+}
+
+func newSymbolIndex(store *facts.Store) *symbolIndex {
+	idx := &symbolIndex{byName: make(map[string]facts.Fact)}
+	for _, f := range store.ByKind(facts.KindSymbol) {
+		if _, exists := idx.byName[f.Name]; !exists {
+			idx.byName[f.Name] = f
+		}
+	}
+	return idx
+}
+
+// resolve maps a call target from caller to a known symbol. It tries the
+// target as-is, then qualified with the caller's package ("helper" →
+// "pkg.helper"), then as a method on the caller's receiver ("s.flush" →
+// "pkg.Server.flush").
+func (idx *symbolIndex) resolve(caller facts.Fact, target string) (facts.Fact, bool) {
+	if f, ok := idx.byName[target]; ok {
+		return f, true
+	}
+	pkg := symbolPackage(caller)
+	if f, ok := idx.byName[pkg+"."+target]; ok {
+		return f, true
+	}
+	if recv, ok := caller.Props["receiver"].(string); ok && recv != "" {
+		if i := strings.LastIndex(target, "."); i >= 0 {
+			if f, ok := idx.byName[pkg+"."+recv+"."+target[i+1:]]; ok {
+				return f, true
+			}
+		}
+	}
+	return facts.Fact{}, false
+}
+
+// symbolPackage returns the module a symbol is declared in.
+func symbolPackage(f facts.Fact) string {
+	for _, r := range f.Relations {
+		if r.Kind == facts.RelDeclares {
+			return r.Target
+		}
+	}
+	return fileDir(f.File)
+}
+
+// buildSymbolGraph links symbols along their resolvable calls relations.
+func buildSymbolGraph(store *facts.Store) map[string][]string {
+	idx := newSymbolIndex(store)
+	graph := make(map[string][]string)
+	for _, f := range idx.byName {
+		seen := make(map[string]bool)
+		for _, r := range f.Relations {
+			if r.Kind != facts.RelCalls {
+				continue
+			}
+			if t, ok := idx.resolve(f, r.Target); ok && t.Name != f.Name && !seen[t.Name] {
+				seen[t.Name] = true
+				graph[f.Name] = append(graph[f.Name], t.Name)
+			}
+		}
+	}
+	return graph
+}
+
+// fileImportExts are the suffixes tried when an import target names a file
+// without its extension, as in TypeScript and JavaScript.
+var fileImportExts = []string{".ts", ".tsx", ".js", ".jsx", "/index.ts", "/index.tsx", "/index.js", ".py", ".rb"}
+
+// buildFileGraph links files that reference each other: a file depends on
+// another when one of its symbols calls a symbol declared there, or when it
+// imports that file directly. Package-level imports (as in Go) are not
+// expanded to every file of the package.
+func buildFileGraph(store *facts.Store) map[string][]string {
+	idx := newSymbolIndex(store)
+	files := make(map[string]bool)
+	for _, f := range store.All() {
+		if f.File != "" {
+			files[f.File] = true
+		}
+	}
+
+	edges := make(map[string]map[string]bool)
+	addEdge := func(from, to string) {
+		if from == "" || to == "" || from == to {
+			return
+		}
+		if edges[from] == nil {
+			edges[from] = make(map[string]bool)
+		}
+		edges[from][to] = true
+	}
+
+	for _, f := range idx.byName {
+		for _, r := range f.Relations {
+			if r.Kind != facts.RelCalls {
+				continue
+			}
+			if t, ok := idx.resolve(f, r.Target); ok {
+				addEdge(f.File, t.File)
+			}
+		}
+	}
+
+	for _, dep := range store.ByKind(facts.KindDependency) {
+		for _, rel := range dep.Relations {
+			if rel.Kind != facts.RelImports {
+				continue
+			}
+			// Targets naming a repo file exactly, like resolved C/C++
+			// includes ("src/util.h"), are internal despite the dot.
+			if files[rel.Target] {
+				addEdge(dep.File, rel.Target)
+				continue
+			}
+			if isExternalImport(rel.Target) {
+				continue
+			}
+			target := rel.Target
+			if strings.HasPrefix(target, ".") {
+				target = resolveRelativeImport(fileDir(dep.File), target)
+			}
+			if files[target] {
+				addEdge(dep.File, target)
+				continue
+			}
+			for _, ext := range fileImportExts {
+				if files[target+ext] {
+					addEdge(dep.File, target+ext)
+					break
+				}
+			}
+		}
+	}
+
+	graph := make(map[string][]string, len(edges))
+	for from, tos := range edges {
+		for to := range tos {
+			graph[from] = append(graph[from], to)
+		}
+	}
+	return graph
+}
+
 // buildDependencyGraph extracts module-level import relationships.
 func buildDependencyGraph(store *facts.Store) map[string][]string {
 	graph := make(map[string][]string)
@@ -86,6 +344,12 @@ func buildDependencyGraph(store *facts.Store) map[string][]string {
 			}
 			target := rel.Target
 
+			// Targets naming a known module exactly are internal.
+			if moduleNames[target] {
+				graph[sourceModule] = append(graph[sourceModule], target)
+				continue
+			}
+
 			// Only track internal dependencies (module-to-module within the repo)
 			// Skip external packages (those with dots like "fmt", "github.com/...", "@types/...")
 			if isExternalImport(target) {
@@ -3,6 +3,7 @@ package cycles
 import (
 	"context"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/dejo1307/archmcp/internal/facts"
@@ -312,3 +313,129 @@ func TestExplain_MultipleCycles(t *testing.T) {
 		t.Errorf("expected 2 cycle insights for 2 disjoint cycles, got %d", len(insights))
 	}
 }
+
+// --- File and symbol granularity tests ---
+
+// makeGoPackage builds a single Go package whose files call each other:
+// a.go:A -> b.go:B -> c.go:C -> a.go:helper, and c.go:Server.Run calls
+// s.flush on its receiver, declared in d.go.
+func makeGoPackage() *facts.Store {
+	s := facts.NewStore()
+	sym := func(name, file string, props map[string]any, calls ...string) facts.Fact {
+		f := facts.Fact{Kind: facts.KindSymbol, Name: "pkg/svc." + name, File: "pkg/svc/" + file, Props: props,
+			Relations: []facts.Relation{{Kind: facts.RelDeclares, Target: "pkg/svc"}}}
+		for _, c := range calls {
+			f.Relations = append(f.Relations, facts.Relation{Kind: facts.RelCalls, Target: c})
+		}
+		return f
+	}
+	s.Add(
+		facts.Fact{Kind: facts.KindModule, Name: "pkg/svc"},
+		sym("A", "a.go", nil, "B", "fmt.Println"),
+		sym("helper", "a.go", nil),
+		sym("B", "b.go", nil, "C"),
+		sym("C", "c.go", nil, "helper"),
+		sym("Server.Run", "c.go", map[string]any{"receiver": "Server"}, "s.flush"),
+		sym("Server.flush", "d.go", map[string]any{"receiver": "Server"}),
+	)
+	return s
+}
+
+func TestNewWithGranularity_Fallback(t *testing.T) {
+	if got := NewWithGranularity("bogus").granularity; got != GranularityModule {
+		t.Errorf("granularity = %q, want %q", got, GranularityModule)
+	}
+}
+
+func TestBuildSymbolGraph_ResolvesPackageAndReceiverCalls(t *testing.T) {
+	graph := buildSymbolGraph(makeGoPackage())
+
+	want := map[string][]string{
+		"pkg/svc.A":          {"pkg/svc.B"},
+		"pkg/svc.B":          {"pkg/svc.C"},
+		"pkg/svc.C":          {"pkg/svc.helper"},
+		"pkg/svc.Server.Run": {"pkg/svc.Server.flush"},
+	}
+	for from, targets := range want {
+		got := sortedSCC(graph[from])
+		exp := sortedSCC(targets)
+		if len(got) != len(exp) {
+			t.Errorf("%s -> %v, want %v", from, got, exp)
+			continue
+		}
+		for i := range exp {
+			if got[i] != exp[i] {
+				t.Errorf("%s -> %v, want %v", from, got, exp)
+				break
+			}
+		}
+	}
+}
+
+func TestExplain_FileGranularity(t *testing.T) {
+	insights, err := NewWithGranularity(GranularityFile).Explain(context.Background(), makeGoPackage())
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	if len(insights) != 1 {
+		t.Fatalf("expected 1 file cycle, got %d: %+v", len(insights), insights)
+	}
+	ins := insights[0]
+	if ins.Title != "Cyclic dependency detected (3 files)" {
+		t.Errorf("title = %q", ins.Title)
+	}
+	wantLoop := "pkg/svc/a.go -> pkg/svc/b.go -> pkg/svc/c.go -> pkg/svc/a.go"
+	if !strings.Contains(ins.Description, wantLoop) {
+		t.Errorf("description %q should contain loop %q", ins.Description, wantLoop)
+	}
+	for _, ev := range ins.Evidence {
+		if ev.File == "pkg/svc/d.go" {
+			t.Error("d.go is not part of the cycle")
+		}
+	}
+}
+
+func TestExplain_FileGranularity_RelativeImports(t *testing.T) {
+	s := facts.NewStore()
+	s.Add(
+		facts.Fact{Kind: facts.KindSymbol, Name: "src/a.x", File: "src/a.ts"},
+		facts.Fact{Kind: facts.KindSymbol, Name: "src/b.y", File: "src/b.ts"},
+		facts.Fact{Kind: facts.KindDependency, File: "src/a.ts", Relations: []facts.Relation{{Kind: facts.RelImports, Target: "./b"}}},
+		facts.Fact{Kind: facts.KindDependency, File: "src/b.ts", Relations: []facts.Relation{{Kind: facts.RelImports, Target: "./a"}}},
+	)
+	insights, _ := NewWithGranularity(GranularityFile).Explain(context.Background(), s)
+	if len(insights) != 1 || len(insights[0].Evidence) != 2 {
+		t.Fatalf("expected one 2-file cycle, got %+v", insights)
+	}
+}
+
+func TestExplain_FileGranularity_HeaderIncludes(t *testing.T) {
+	s := facts.NewStore()
+	s.Add(
+		facts.Fact{Kind: facts.KindDependency, Name: "src/net/conn.h", File: "src/net/conn.h", Relations: []facts.Relation{{Kind: facts.RelImports, Target: "src/net/buffer.h"}}},
+		facts.Fact{Kind: facts.KindDependency, Name: "src/net/buffer.h", File: "src/net/buffer.h", Relations: []facts.Relation{{Kind: facts.RelImports, Target: "src/net/conn.h"}}},
+	)
+	insights, _ := NewWithGranularity(GranularityFile).Explain(context.Background(), s)
+	if len(insights) != 1 || len(insights[0].Evidence) != 2 {
+		t.Fatalf("expected one 2-header include cycle, got %+v", insights)
+	}
+}
+
+func TestExplain_SymbolGranularity(t *testing.T) {
+	s := facts.NewStore()
+	s.Add(
+		facts.Fact{Kind: facts.KindSymbol, Name: "p.even", File: "p/p.go",
+			Relations: []facts.Relation{{Kind: facts.RelDeclares, Target: "p"}, {Kind: facts.RelCalls, Target: "odd"}}},
+		facts.Fact{Kind: facts.KindSymbol, Name: "p.odd", File: "p/p.go",
+			Relations: []facts.Relation{{Kind: facts.RelDeclares, Target: "p"}, {Kind: facts.RelCalls, Target: "even"}}},
+		facts.Fact{Kind: facts.KindSymbol, Name: "p.fact", File: "p/p.go",
+			Relations: []facts.Relation{{Kind: facts.RelDeclares, Target: "p"}, {Kind: facts.RelCalls, Target: "fact"}}},
+	)
+	insights, _ := NewWithGranularity(GranularitySymbol).Explain(context.Background(), s)
+	if len(insights) != 1 {
+		t.Fatalf("expected only the even/odd cycle (self-recursion is not a cycle), got %+v", insights)
+	}
+	if !strings.Contains(insights[0].Description, "p.even -> p.odd -> p.even") {
+		t.Errorf("description = %q", insights[0].Description)
+	}
+}
@@ -0,0 +1,192 @@
+package hotspots
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// HotspotExplainer flags oversized modules ("god modules") and types with an
+// excessive number of methods.
+type HotspotExplainer struct {
+	maxSymbols  int
+	maxCoupling int
+	maxMethods  int
+}
+
+// New creates a new HotspotExplainer. A module is flagged when its symbol
+// count exceeds maxSymbols or its fan-in plus fan-out exceeds maxCoupling; a
+// type is flagged when it has more than maxMethods methods. Non-positive
+// thresholds disable the corresponding check.
+func New(maxSymbols, maxCoupling, maxMethods int) *HotspotExplainer {
+	return &HotspotExplainer{
+		maxSymbols:  maxSymbols,
+		maxCoupling: maxCoupling,
+		maxMethods:  maxMethods,
+	}
+}
+
+func (e *HotspotExplainer) Name() string {
+	return "hotspots"
+}
+
+// Explain computes per-module symbol counts and coupling, and per-type method
+// counts, and reports everything above the configured thresholds. Insights are
+// ordered by severity (largest first) so the worst offenders surface first.
+func (e *HotspotExplainer) Explain(ctx context.Context, store *facts.Store) ([]facts.Insight, error) {
+	all := store.All()
+	coupling := facts.ModuleCoupling(all)
+
+	symbolCount := make(map[string]int)
+	methodCount := make(map[string]int)
+	methodFile := make(map[string]string)
+	for _, f := range all {
+		if f.Kind != facts.KindSymbol {
+			continue
+		}
+		for _, rel := range f.Relations {
+			if rel.Kind == facts.RelDeclares {
+				symbolCount[rel.Target]++
+				break
+			}
+		}
+		if sk, _ := f.Props["symbol_kind"].(string); sk == facts.SymbolMethod {
+			owner := methodOwner(f.Name)
+			if owner == "" {
+				continue
+			}
+			methodCount[owner]++
+			if _, ok := methodFile[owner]; !ok {
+				methodFile[owner] = f.File
+			}
+		}
+	}
+
+	var insights []facts.Insight
+	insights = append(insights, e.moduleInsights(store, symbolCount, coupling)...)
+
+	select {
+	case <-ctx.Done():
+		return insights, ctx.Err()
+	default:
+	}
+
+	insights = append(insights, e.typeInsights(store, methodCount, methodFile)...)
+	return insights, nil
+}
+
+type moduleStat struct {
+	name     string
+	symbols  int
+	coupling facts.Coupling
+	score    int
+}
+
+func (e *HotspotExplainer) moduleInsights(store *facts.Store, symbolCount map[string]int, coupling map[string]facts.Coupling) []facts.Insight {
+	var flagged []moduleStat
+	for _, mod := range store.ByKind(facts.KindModule) {
+		st := moduleStat{name: mod.Name, symbols: symbolCount[mod.Name], coupling: coupling[mod.Name]}
+		tooBig := e.maxSymbols > 0 && st.symbols > e.maxSymbols
+		tooCoupled := e.maxCoupling > 0 && st.coupling.FanIn+st.coupling.FanOut > e.maxCoupling
+		if !tooBig && !tooCoupled {
+			continue
+		}
+		st.score = st.symbols + st.coupling.FanIn + st.coupling.FanOut
+		flagged = append(flagged, st)
+	}
+	sort.Slice(flagged, func(i, j int) bool {
+		if flagged[i].score != flagged[j].score {
+			return flagged[i].score > flagged[j].score
+		}
+		return flagged[i].name < flagged[j].name
+	})
+
+	insights := make([]facts.Insight, 0, len(flagged))
+	for _, st := range flagged {
+		total := st.coupling.FanIn + st.coupling.FanOut
+		var reasons []string
+		if e.maxSymbols > 0 && st.symbols > e.maxSymbols {
+			reasons = append(reasons, fmt.Sprintf("%d symbols (threshold %d)", st.symbols, e.maxSymbols))
+		}
+		if e.maxCoupling > 0 && total > e.maxCoupling {
+			reasons = append(reasons, fmt.Sprintf("coupling %d = fan-in %d + fan-out %d (threshold %d)", total, st.coupling.FanIn, st.coupling.FanOut, e.maxCoupling))
+		}
+		insights = append(insights, facts.Insight{
+			Title:       fmt.Sprintf("God module: %s", st.name),
+			Description: fmt.Sprintf("Module %q has %s. Oversized or highly coupled modules are hard to change safely and tend to attract unrelated responsibilities.", st.name, strings.Join(reasons, " and ")),
+			Confidence:  0.7,
+			Evidence: []facts.Evidence{
+				{Fact: st.name, File: st.name, Detail: fmt.Sprintf("symbols=%d fan_in=%d fan_out=%d", st.symbols, st.coupling.FanIn, st.coupling.FanOut)},
+			},
+			Actions: []string{
+				"Split the module along its distinct responsibilities",
+				"Move rarely used helpers to a dedicated package",
+				"Introduce interfaces to reduce direct dependencies",
+			},
+		})
+	}
+	return insights
+}
+
+func (e *HotspotExplainer) typeInsights(store *facts.Store, methodCount map[string]int, methodFile map[string]string) []facts.Insight {
+	if e.maxMethods <= 0 {
+		return nil
+	}
+
+	type typeStat struct {
+		name    string
+		methods int
+	}
+	var flagged []typeStat
+	for owner, n := range methodCount {
+		if n > e.maxMethods {
+			flagged = append(flagged, typeStat{name: owner, methods: n})
+		}
+	}
+	sort.Slice(flagged, func(i, j int) bool {
+		if flagged[i].methods != flagged[j].methods {
+			return flagged[i].methods > flagged[j].methods
+		}
+		return flagged[i].name < flagged[j].name
+	})
+
+	insights := make([]facts.Insight, 0, len(flagged))
+	for _, st := range flagged {
+		file := methodFile[st.name]
+		line := 0
+		// Prefer the type declaration's location over its first method.
+		if decl := store.LookupByExactName(st.name); len(decl) > 0 {
+			file, line = decl[0].File, decl[0].Line
+		}
+		detail := fmt.Sprintf("%d methods (threshold %d)", st.methods, e.maxMethods)
+		if line > 0 {
+			detail += fmt.Sprintf(", declared at line %d", line)
+		}
+		insights = append(insights, facts.Insight{
+			Title:       fmt.Sprintf("God object: %s", st.name),
+			Description: fmt.Sprintf("Type %q defines %d methods, above the threshold of %d. Large types usually mix several responsibilities.", st.name, st.methods, e.maxMethods),
+			Confidence:  0.7,
+			Evidence: []facts.Evidence{
+				{Symbol: st.name, File: file, Detail: detail},
+			},
+			Actions: []string{
+				"Extract cohesive groups of methods into separate types",
+				"Prefer composition over growing a single type",
+			},
+		})
+	}
+	return insights
+}
+
+// methodOwner returns the owning type of a qualified method name, e.g.
+// "internal/server.Server.Run" → "internal/server.Server".
+func methodOwner(name string) string {
+	i := strings.LastIndex(name, ".")
+	if i <= 0 {
+		return ""
+	}
+	return name[:i]
+}
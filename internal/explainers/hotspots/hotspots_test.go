@@ -0,0 +1,128 @@
+package hotspots
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// --- helpers ---
+
+func addSymbols(s *facts.Store, module string, n int) {
+	for i := 0; i < n; i++ {
+		s.Add(facts.Fact{
+			Kind:      facts.KindSymbol,
+			Name:      fmt.Sprintf("%s.Func%d", module, i),
+			File:      module + "/file.go",
+			Props:     map[string]any{"symbol_kind": facts.SymbolFunc},
+			Relations: []facts.Relation{{Kind: facts.RelDeclares, Target: module}},
+		})
+	}
+}
+
+func findInsight(insights []facts.Insight, title string) *facts.Insight {
+	for i := range insights {
+		if insights[i].Title == title {
+			return &insights[i]
+		}
+	}
+	return nil
+}
+
+// --- tests ---
+
+func TestExplain_GodModuleBySymbols(t *testing.T) {
+	s := facts.NewStore()
+	s.Add(facts.Fact{Kind: facts.KindModule, Name: "internal/big"})
+	s.Add(facts.Fact{Kind: facts.KindModule, Name: "internal/small"})
+	addSymbols(s, "internal/big", 6)
+	addSymbols(s, "internal/small", 2)
+
+	insights, err := New(5, 0, 0).Explain(context.Background(), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(insights) != 1 {
+		t.Fatalf("expected 1 insight, got %d: %+v", len(insights), insights)
+	}
+	ins := findInsight(insights, "God module: internal/big")
+	if ins == nil {
+		t.Fatalf("expected god module insight for internal/big, got %+v", insights)
+	}
+	if !strings.Contains(ins.Description, "6 symbols (threshold 5)") {
+		t.Errorf("description should include concrete numbers, got %q", ins.Description)
+	}
+}
+
+func TestExplain_GodModuleByCoupling(t *testing.T) {
+	s := facts.NewStore()
+	for _, m := range []string{"core", "a", "b", "c"} {
+		s.Add(facts.Fact{Kind: facts.KindModule, Name: m})
+	}
+	for _, src := range []string{"a", "b", "c"} {
+		s.Add(facts.Fact{
+			Kind:      facts.KindDependency,
+			File:      src + "/file.go",
+			Relations: []facts.Relation{{Kind: facts.RelImports, Target: "core"}},
+		})
+	}
+
+	insights, _ := New(0, 2, 0).Explain(context.Background(), s)
+	ins := findInsight(insights, "God module: core")
+	if ins == nil {
+		t.Fatalf("expected god module insight for core, got %+v", insights)
+	}
+	if !strings.Contains(ins.Description, "fan-in 3") {
+		t.Errorf("description should include fan-in, got %q", ins.Description)
+	}
+}
+
+func TestExplain_GodObject(t *testing.T) {
+	s := facts.NewStore()
+	s.Add(facts.Fact{Kind: facts.KindSymbol, Name: "pkg.Server", File: "pkg/server.go", Line: 12,
+		Props: map[string]any{"symbol_kind": facts.SymbolStruct}})
+	for i := 0; i < 4; i++ {
+		s.Add(facts.Fact{Kind: facts.KindSymbol, Name: fmt.Sprintf("pkg.Server.M%d", i), File: "pkg/handlers.go",
+			Props: map[string]any{"symbol_kind": facts.SymbolMethod}})
+	}
+	s.Add(facts.Fact{Kind: facts.KindSymbol, Name: "pkg.Client.Do", File: "pkg/client.go",
+		Props: map[string]any{"symbol_kind": facts.SymbolMethod}})
+
+	insights, _ := New(0, 0, 3).Explain(context.Background(), s)
+	if len(insights) != 1 {
+		t.Fatalf("expected 1 insight, got %d: %+v", len(insights), insights)
+	}
+	ins := findInsight(insights, "God object: pkg.Server")
+	if ins == nil {
+		t.Fatalf("expected god object insight for pkg.Server, got %+v", insights)
+	}
+	if ev := ins.Evidence[0]; ev.File != "pkg/server.go" || !strings.Contains(ev.Detail, "4 methods") {
+		t.Errorf("evidence should point at the type declaration, got %+v", ev)
+	}
+}
+
+func TestExplain_DisabledThresholds(t *testing.T) {
+	s := facts.NewStore()
+	s.Add(facts.Fact{Kind: facts.KindModule, Name: "m"})
+	addSymbols(s, "m", 100)
+
+	insights, _ := New(0, 0, 0).Explain(context.Background(), s)
+	if len(insights) != 0 {
+		t.Errorf("expected no insights with all checks disabled, got %d", len(insights))
+	}
+}
+
+func TestMethodOwner(t *testing.T) {
+	tests := map[string]string{
+		"internal/server.Server.Run": "internal/server.Server",
+		"Run":                        "",
+	}
+	for in, want := range tests {
+		if got := methodOwner(in); got != want {
+			t.Errorf("methodOwner(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
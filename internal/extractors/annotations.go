@@ -0,0 +1,79 @@
+package extractors
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// leadingAnnotationRe matches an annotation or decorator at the start of a
+// line and captures its name. A Kotlin use-site target (@field:) is skipped.
+var leadingAnnotationRe = regexp.MustCompile(`^@(?:\w+:)?([A-Za-z_][\w.]*)`)
+
+// MarkAnnotations records the annotations or decorators of the symbols of
+// ff declared in relFile in Props["annotations"] (see Annotations). lines
+// are the lines of relFile. It serves the line-based extractors and the
+// TypeScript extractor, whose annotations are written just above the
+// declaration or at the start of its line.
+func MarkAnnotations(ff []facts.Fact, relFile string, lines []string) {
+	for i := range ff {
+		f := &ff[i]
+		if f.Kind != facts.KindSymbol || f.File != relFile || f.Line <= 0 || f.Line > len(lines) {
+			continue
+		}
+		if names := Annotations(DeclarationPrelude(lines, f.Line)); len(names) > 0 {
+			f.Props["annotations"] = names
+		}
+	}
+}
+
+// Annotations returns the names of the annotations or decorators in a
+// declaration prelude, in order and without duplicates, as written but
+// without the @ and the arguments: "Transactional", "GetMapping",
+// "app.get", "MainActor". Only those leading a line count, so an @escaping
+// parameter attribute or an @ in a string is not taken for one.
+func Annotations(prelude []string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, line := range prelude {
+		rest := strings.TrimSpace(line)
+		if IsCommentLine(rest) {
+			continue
+		}
+		for {
+			m := leadingAnnotationRe.FindStringSubmatch(rest)
+			if m == nil {
+				break
+			}
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				names = append(names, m[1])
+			}
+			rest = strings.TrimSpace(skipArguments(rest[len(m[0]):]))
+		}
+	}
+	return names
+}
+
+// skipArguments returns s without a leading parenthesized argument list.
+// When the list is not closed on the line, it returns "".
+func skipArguments(s string) string {
+	s = strings.TrimLeft(s, " \t")
+	if !strings.HasPrefix(s, "(") {
+		return s
+	}
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s[i+1:]
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,635 @@
+package cppextractor
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/extractors"
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// buildFiles are the root build files that mark a C/C++ project.
+var buildFiles = []string{"CMakeLists.txt", "Makefile", "makefile", "GNUmakefile"}
+
+// CppExtractor extracts architectural facts from C and C++ source code using
+// line-based regex parsing. The #include graph is the main signal: every
+// source file and header gets a dependency fact whose imports relations point
+// at the repo-relative headers it includes, so includes chain file to file.
+type CppExtractor struct{}
+
+// New creates a new CppExtractor.
+func New() *CppExtractor {
+	return &CppExtractor{}
+}
+
+func (e *CppExtractor) Name() string {
+	return "cpp"
+}
+
+// Detect returns true if the repository has a CMakeLists.txt or Makefile at
+// its root and C/C++ files within the first few directory levels.
+func (e *CppExtractor) Detect(repoPath string) (bool, error) {
+	hasBuild := false
+	for _, name := range buildFiles {
+		if _, err := os.Stat(filepath.Join(repoPath, name)); err == nil {
+			hasBuild = true
+			break
+		}
+	}
+	if !hasBuild {
+		return false, nil
+	}
+
+	found := false
+	_ = filepath.WalkDir(repoPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || found {
+			return filepath.SkipDir
+		}
+		if d.IsDir() {
+			rel, _ := filepath.Rel(repoPath, p)
+			if rel != "." && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" || d.Name() == "vendor" || strings.Count(rel, string(filepath.Separator)) >= 3) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isCppFile(p) {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found, nil
+}
+
+// Extract parses C/C++ files and emits architectural facts.
+func (e *CppExtractor) Extract(ctx context.Context, repoPath string, files []string) ([]facts.Fact, error) {
+	var allFacts []facts.Fact
+	var fileErrs extractors.FileErrors
+
+	var sources []string
+	for _, f := range files {
+		if isCppFile(f) {
+			sources = append(sources, filepath.ToSlash(f))
+		}
+	}
+	resolver := newIncludeResolver(sources)
+
+	modules := make(map[string]bool)
+	moduleDeps := make(map[string]bool) // "from -> to" already emitted
+
+	for _, relFile := range sources {
+		select {
+		case <-ctx.Done():
+			return allFacts, ctx.Err()
+		default:
+		}
+
+		f, err := os.Open(filepath.Join(repoPath, relFile))
+		if err != nil {
+			fileErrs.Add(relFile, err)
+			continue
+		}
+		parsed := parseFile(f, relFile)
+		f.Close()
+
+		dir := path.Dir(relFile)
+		modules[dir] = true
+		allFacts = append(allFacts, parsed.symbols...)
+		allFacts = append(allFacts, includeFacts(relFile, parsed.includes, resolver, moduleDeps)...)
+	}
+
+	var dirs []string
+	for dir := range modules {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		allFacts = append(allFacts, facts.Fact{
+			Kind: facts.KindModule,
+			Name: dir,
+			File: dir,
+			Props: map[string]any{
+				"language": "cpp",
+			},
+		})
+	}
+
+	return allFacts, fileErrs.Err()
+}
+
+var cppExts = map[string]bool{
+	".c": true, ".cc": true, ".cpp": true, ".cxx": true, ".c++": true,
+	".h": true, ".hh": true, ".hpp": true, ".hxx": true, ".h++": true,
+}
+
+func isCppFile(p string) bool {
+	return cppExts[strings.ToLower(filepath.Ext(p))]
+}
+
+func isHeader(p string) bool {
+	return strings.HasPrefix(strings.ToLower(filepath.Ext(p)), ".h")
+}
+
+// --- Include resolution ---
+
+// includeResolver maps #include paths to repo-relative files.
+type includeResolver struct {
+	files    map[string]bool
+	bySuffix map[string][]string // base name -> files with that base name, sorted
+}
+
+func newIncludeResolver(files []string) *includeResolver {
+	r := &includeResolver{files: make(map[string]bool), bySuffix: make(map[string][]string)}
+	for _, f := range files {
+		r.files[f] = true
+		base := path.Base(f)
+		r.bySuffix[base] = append(r.bySuffix[base], f)
+	}
+	for _, list := range r.bySuffix {
+		sort.Strings(list)
+	}
+	return r
+}
+
+// resolve returns the repo-relative file an include refers to, or "". Quoted
+// includes are tried relative to the including file first. Both forms are
+// then tried from the repo root and finally matched against any file whose
+// path ends with the include path, which covers -I include directories. The
+// shortest such path wins.
+func (r *includeResolver) resolve(fromDir, inc string, quoted bool) string {
+	if quoted {
+		if c := path.Clean(path.Join(fromDir, inc)); r.files[c] {
+			return c
+		}
+	}
+	if c := path.Clean(inc); r.files[c] {
+		return c
+	}
+	best := ""
+	for _, f := range r.bySuffix[path.Base(inc)] {
+		if strings.HasSuffix(f, "/"+inc) && (best == "" || len(f) < len(best)) {
+			best = f
+		}
+	}
+	return best
+}
+
+// includeFacts builds the dependency facts for one file's includes: a fact
+// named after the file whose imports relations point at the headers it
+// includes, plus one module-level fact per directory it reaches for the
+// first time, so module-level views (cycles, coupling) see the include graph.
+// Headers always get a file fact, even without includes, so impact analysis
+// can start from any header.
+func includeFacts(relFile string, includes []include, r *includeResolver, moduleDeps map[string]bool) []facts.Fact {
+	if len(includes) == 0 && !isHeader(relFile) {
+		return nil
+	}
+	dir := path.Dir(relFile)
+
+	fileFact := facts.Fact{
+		Kind: facts.KindDependency,
+		Name: relFile,
+		File: relFile,
+		Line: 1,
+		Props: map[string]any{
+			"language": "cpp",
+			"includes": len(includes),
+		},
+	}
+	if len(includes) > 0 {
+		fileFact.Line = includes[0].line
+	}
+	if isHeader(relFile) {
+		fileFact.Props["header"] = true
+	}
+
+	var result []facts.Fact
+	var system, unresolved []string
+	seen := make(map[string]bool)
+	for _, inc := range includes {
+		target := r.resolve(dir, inc.path, inc.quoted)
+		if target == "" {
+			if inc.quoted {
+				unresolved = append(unresolved, inc.path)
+			} else {
+				system = append(system, inc.path)
+			}
+			continue
+		}
+		if target == relFile || seen[target] {
+			continue
+		}
+		seen[target] = true
+		fileFact.Relations = append(fileFact.Relations, facts.Relation{Kind: facts.RelImports, Target: target})
+
+		targetDir := path.Dir(target)
+		key := dir + " -> " + targetDir
+		if targetDir != dir && !moduleDeps[key] {
+			moduleDeps[key] = true
+			result = append(result, facts.Fact{
+				Kind: facts.KindDependency,
+				Name: key,
+				File: relFile,
+				Line: inc.line,
+				Props: map[string]any{
+					"language": "cpp",
+					"source":   "internal",
+				},
+				Relations: []facts.Relation{
+					{Kind: facts.RelImports, Target: targetDir},
+				},
+			})
+		}
+	}
+	if len(system) > 0 {
+		fileFact.Props["system_includes"] = system
+	}
+	if len(unresolved) > 0 {
+		fileFact.Props["unresolved_includes"] = unresolved
+	}
+
+	return append([]facts.Fact{fileFact}, result...)
+}
+
+// --- Parsing ---
+
+var (
+	includeRe = regexp.MustCompile(`^\s*#\s*include\s*([<"])([^>"]+)[>"]`)
+	defineRe  = regexp.MustCompile(`^\s*#\s*define\s+([A-Za-z_]\w*)(\()?`)
+
+	namespaceRe = regexp.MustCompile(`^\s*(?:inline\s+)?namespace(?:\s+[\w:]+)?\s*\{`)
+	externCRe   = regexp.MustCompile(`^\s*extern\s+"C(?:\+\+)?"\s*\{`)
+
+	// class/struct/union/enum declarations with a body or base list.
+	// Captures: typedef (1), keyword (2), name (3), rest of line (4).
+	typeRe = regexp.MustCompile(`^\s*(?:template\s*<[^>]*>\s*)?(typedef\s+)?(class|struct|union|enum(?:\s+class|\s+struct)?)\s+(?:\[\[[^\]]*\]\]\s*)?(?:\w+_API\s+)?([A-Za-z_]\w*)\s*(.*)$`)
+
+	// Anonymous typedef'd aggregate: "typedef struct {".
+	anonTypedefRe = regexp.MustCompile(`^\s*typedef\s+(struct|union|enum)\s*\{`)
+	typedefNameRe = regexp.MustCompile(`^\s*\}\s*([A-Za-z_]\w*)\s*[,;]`)
+
+	// A function definition head: return type, then a (possibly qualified)
+	// name and an opening parenthesis. Captures: prefix (1), name (2).
+	funcRe = regexp.MustCompile(`^\s*((?:[\w:<>,*&~\[\]]+\s+|[\w:<>,]+[*&]+\s*)+?[*&]*)\s*((?:[A-Za-z_]\w*::)*~?[A-Za-z_]\w*|(?:[A-Za-z_]\w*::)*operator\s*\S+?)\s*\(`)
+
+	baseClassRe = regexp.MustCompile(`(?:public|protected|private|virtual)\s+`)
+
+	stringLitRe = regexp.MustCompile(`"(?:\\.|[^"\\])*"|'(?:\\.|[^'\\])+'`)
+)
+
+// notFuncKeywords start statements that look like calls, not definitions.
+var notFuncKeywords = map[string]bool{
+	"return": true, "if": true, "else": true, "while": true, "for": true, "switch": true,
+	"case": true, "do": true, "sizeof": true, "new": true, "delete": true, "throw": true,
+	"using": true, "typedef": true, "goto": true,
+}
+
+// include is one #include directive.
+type include struct {
+	path   string
+	quoted bool
+	line   int
+}
+
+// parsedFile holds what parseFile found in one file.
+type parsedFile struct {
+	symbols  []facts.Fact
+	includes []include
+}
+
+// pendingFunc tracks a function head whose parameter list or body brace
+// continues on the next lines.
+type pendingFunc struct {
+	name       string
+	static     bool
+	line       int
+	parenDepth int
+}
+
+// parseFile scans a C/C++ file for includes, macros, types, and function
+// definitions. Declarations are only recorded at file scope, inside
+// namespaces, or inside extern "C" blocks; class and function bodies are
+// skipped.
+func parseFile(r io.Reader, relFile string) parsedFile {
+	var out parsedFile
+	dir := path.Dir(relFile)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 256*1024), 1024*1024)
+
+	var (
+		lineNum      int
+		depth        int   // current brace depth
+		scopeDepths  []int // brace depths opened by namespaces and extern "C"
+		inComment    bool
+		continuation bool // previous preprocessor line ended with a backslash
+		pending      *pendingFunc
+		anonTypedef  = -1 // depth of an open "typedef struct {", or -1
+		anonKind     string
+		anonLine     int
+	)
+
+	fileScope := func() bool {
+		return depth == len(scopeDepths)
+	}
+
+	for scanner.Scan() {
+		lineNum++
+		raw := scanner.Text()
+
+		if continuation {
+			continuation = strings.HasSuffix(strings.TrimRight(raw, " \t"), "\\")
+			continue
+		}
+
+		line := stripComments(raw, &inComment)
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		// Preprocessor directives.
+		if strings.HasPrefix(trimmed, "#") {
+			continuation = strings.HasSuffix(trimmed, "\\")
+			if m := includeRe.FindStringSubmatch(trimmed); m != nil {
+				out.includes = append(out.includes, include{path: m[2], quoted: m[1] == `"`, line: lineNum})
+			} else if m := defineRe.FindStringSubmatch(trimmed); m != nil && fileScope() {
+				out.symbols = append(out.symbols, macroFact(dir, relFile, lineNum, m[1], m[2] == "("))
+			}
+			continue
+		}
+
+		code := stringLitRe.ReplaceAllString(line, `""`)
+		opens := strings.Count(code, "{")
+		closes := strings.Count(code, "}")
+
+		// Close of an anonymous typedef: "} name;".
+		if anonTypedef >= 0 && depth+opens-closes == anonTypedef {
+			if m := typedefNameRe.FindStringSubmatch(code); m != nil {
+				out.symbols = append(out.symbols, typeFact(dir, relFile, anonLine, anonKind, m[1], ""))
+			}
+			anonTypedef = -1
+		}
+
+		// Continue a function head spanning several lines.
+		if pending != nil {
+			if pending.parenDepth > 0 {
+				pending.parenDepth += strings.Count(code, "(") - strings.Count(code, ")")
+			}
+			if pending.parenDepth <= 0 {
+				switch {
+				case strings.HasPrefix(trimmed, "{") || (opens > 0 && !strings.Contains(code, ";")):
+					out.symbols = append(out.symbols, funcFact(dir, relFile, pending))
+					pending = nil
+				case strings.Contains(code, ";"):
+					pending = nil // a prototype
+				}
+			}
+			depth = applyBraces(depth, opens, closes, &scopeDepths)
+			continue
+		}
+
+		if fileScope() {
+			switch {
+			case namespaceRe.MatchString(code) || externCRe.MatchString(line):
+				depth++
+				scopeDepths = append(scopeDepths, depth)
+				depth = applyBraces(depth, opens-1, closes, &scopeDepths)
+				continue
+
+			case anonTypedefRe.MatchString(code):
+				m := anonTypedefRe.FindStringSubmatch(code)
+				anonTypedef, anonKind, anonLine = depth, m[1], lineNum
+
+			default:
+				if m := typeRe.FindStringSubmatch(code); m != nil && isTypeDefinition(m[4]) {
+					out.symbols = append(out.symbols, typeFact(dir, relFile, lineNum, m[2], m[3], m[4]))
+				} else if pf := matchFuncHead(code, lineNum); pf != nil {
+					switch {
+					case pf.parenDepth > 0:
+						pending = pf
+					case opens > 0:
+						out.symbols = append(out.symbols, funcFact(dir, relFile, pf))
+					case !strings.Contains(code, ";"):
+						pending = pf // body brace on the next line
+					}
+				}
+			}
+		}
+
+		depth = applyBraces(depth, opens, closes, &scopeDepths)
+	}
+
+	return out
+}
+
+// applyBraces updates the brace depth and leaves namespace scopes whose
+// braces have closed.
+func applyBraces(depth, opens, closes int, scopeDepths *[]int) int {
+	depth += opens - closes
+	if depth < 0 {
+		depth = 0
+	}
+	for len(*scopeDepths) > 0 && depth < (*scopeDepths)[len(*scopeDepths)-1] {
+		*scopeDepths = (*scopeDepths)[:len(*scopeDepths)-1]
+	}
+	return depth
+}
+
+// isTypeDefinition reports whether the text after a class/struct name starts
+// a definition ("{", ": public Base", "final") rather than a forward
+// declaration, a variable ("struct foo *p;"), or a return type.
+func isTypeDefinition(rest string) bool {
+	rest = strings.TrimSpace(rest)
+	rest = strings.TrimPrefix(rest, "final")
+	rest = strings.TrimSpace(rest)
+	return rest == "" || strings.HasPrefix(rest, "{") || (strings.HasPrefix(rest, ":") && !strings.HasPrefix(rest, "::"))
+}
+
+// matchFuncHead matches the start of a function definition or declaration
+// at file scope.
+func matchFuncHead(code string, lineNum int) *pendingFunc {
+	m := funcRe.FindStringSubmatch(code)
+	if m == nil {
+		return nil
+	}
+	prefix := strings.Fields(m[1])
+	if len(prefix) == 0 || notFuncKeywords[prefix[0]] || notFuncKeywords[m[2]] {
+		return nil
+	}
+	// "= (" or a call inside an initializer is not a function head.
+	if strings.Contains(code[:strings.Index(code, m[2])], "=") {
+		return nil
+	}
+	after := code[strings.Index(code, m[2])+len(m[2]):]
+	static := false
+	for _, p := range prefix {
+		if p == "static" {
+			static = true
+		}
+	}
+	return &pendingFunc{
+		name:       m[2],
+		static:     static,
+		line:       lineNum,
+		parenDepth: strings.Count(after, "(") - strings.Count(after, ")"),
+	}
+}
+
+// stripComments removes // and /* */ comments from a line, tracking block
+// comments that span lines.
+func stripComments(line string, inComment *bool) string {
+	var sb strings.Builder
+	for i := 0; i < len(line); i++ {
+		if *inComment {
+			if strings.HasPrefix(line[i:], "*/") {
+				*inComment = false
+				i++
+			}
+			continue
+		}
+		if strings.HasPrefix(line[i:], "/*") {
+			*inComment = true
+			i++
+			continue
+		}
+		if strings.HasPrefix(line[i:], "//") {
+			break
+		}
+		sb.WriteByte(line[i])
+	}
+	return sb.String()
+}
+
+// --- Fact builders ---
+
+func macroFact(dir, relFile string, line int, name string, functionLike bool) facts.Fact {
+	f := facts.Fact{
+		Kind: facts.KindSymbol,
+		Name: dir + "." + name,
+		File: relFile,
+		Line: line,
+		Props: map[string]any{
+			"symbol_kind": facts.SymbolConstant,
+			"macro":       true,
+			"exported":    isHeader(relFile),
+			"language":    "cpp",
+		},
+		Relations: []facts.Relation{
+			{Kind: facts.RelDeclares, Target: dir},
+		},
+	}
+	if functionLike {
+		f.Props["function_like"] = true
+	}
+	return f
+}
+
+func typeFact(dir, relFile string, line int, keyword, name, rest string) facts.Fact {
+	symbolKind := facts.SymbolStruct
+	switch {
+	case keyword == "class":
+		symbolKind = facts.SymbolClass
+	case strings.HasPrefix(keyword, "enum"), keyword == "union":
+		symbolKind = facts.SymbolType
+	}
+
+	f := facts.Fact{
+		Kind: facts.KindSymbol,
+		Name: dir + "." + name,
+		File: relFile,
+		Line: line,
+		Props: map[string]any{
+			"symbol_kind": symbolKind,
+			"exported":    true,
+			"language":    "cpp",
+		},
+		Relations: []facts.Relation{
+			{Kind: facts.RelDeclares, Target: dir},
+		},
+	}
+	if keyword == "union" || strings.HasPrefix(keyword, "enum") {
+		f.Props["cpp_kind"] = strings.Fields(keyword)[0]
+	}
+
+	// Base classes: "class Foo : public Bar, private ns::Baz {".
+	rest = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rest), "final"))
+	if strings.HasPrefix(rest, ":") {
+		bases := strings.TrimPrefix(rest, ":")
+		if idx := strings.Index(bases, "{"); idx >= 0 {
+			bases = bases[:idx]
+		}
+		for _, b := range splitBases(bases) {
+			f.Relations = append(f.Relations, facts.Relation{Kind: facts.RelImplements, Target: b})
+		}
+	}
+	return f
+}
+
+// splitBases splits a base-specifier list into simple type names, dropping
+// access specifiers, namespaces, and template arguments.
+func splitBases(list string) []string {
+	var result []string
+	depth, start := 0, 0
+	flush := func(end int) {
+		b := strings.TrimSpace(baseClassRe.ReplaceAllString(list[start:end], ""))
+		if idx := strings.Index(b, "<"); idx >= 0 {
+			b = b[:idx]
+		}
+		if idx := strings.LastIndex(b, "::"); idx >= 0 {
+			b = b[idx+2:]
+		}
+		if b = strings.TrimSpace(b); b != "" {
+			result = append(result, b)
+		}
+	}
+	for i := 0; i < len(list); i++ {
+		switch list[i] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case ',':
+			if depth == 0 {
+				flush(i)
+				start = i + 1
+			}
+		}
+	}
+	flush(len(list))
+	return result
+}
+
+// funcFact builds a function symbol. Out-of-line member definitions
+// ("void Foo::bar()") become methods named after their class.
+func funcFact(dir, relFile string, pf *pendingFunc) facts.Fact {
+	parts := strings.Split(pf.name, "::")
+	name := parts[len(parts)-1]
+	symbolKind := facts.SymbolFunc
+	if len(parts) > 1 {
+		name = parts[len(parts)-2] + "." + name
+		symbolKind = facts.SymbolMethod
+	}
+	return facts.Fact{
+		Kind: facts.KindSymbol,
+		Name: dir + "." + name,
+		File: relFile,
+		Line: pf.line,
+		Props: map[string]any{
+			"symbol_kind": symbolKind,
+			"exported":    !pf.static,
+			"language":    "cpp",
+		},
+		Relations: []facts.Relation{
+			{Kind: facts.RelDeclares, Target: dir},
+		},
+	}
+}
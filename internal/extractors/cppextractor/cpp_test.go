@@ -0,0 +1,221 @@
+package cppextractor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// --- helpers ---
+
+func writeRepo(t *testing.T, files map[string]string) (string, []string) {
+	t.Helper()
+	dir := t.TempDir()
+	var names []string
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	return dir, names
+}
+
+func parseString(src, relFile string) parsedFile {
+	return parseFile(strings.NewReader(src), relFile)
+}
+
+func findFact(ff []facts.Fact, kind, name string) (facts.Fact, bool) {
+	for _, f := range ff {
+		if f.Kind == kind && f.Name == name {
+			return f, true
+		}
+	}
+	return facts.Fact{}, false
+}
+
+func relationTargets(f facts.Fact, relKind string) []string {
+	var out []string
+	for _, r := range f.Relations {
+		if r.Kind == relKind {
+			out = append(out, r.Target)
+		}
+	}
+	return out
+}
+
+// --- tests ---
+
+func TestDetect(t *testing.T) {
+	dir, _ := writeRepo(t, map[string]string{
+		"CMakeLists.txt": "project(demo)\n",
+		"src/main.cpp":   "int main() { return 0; }\n",
+	})
+	if ok, _ := New().Detect(dir); !ok {
+		t.Error("expected CMake project with C++ sources to be detected")
+	}
+
+	noSources, _ := writeRepo(t, map[string]string{"Makefile": "all:\n\techo hi\n"})
+	if ok, _ := New().Detect(noSources); ok {
+		t.Error("a Makefile without C/C++ sources should not be detected")
+	}
+
+	noBuild, _ := writeRepo(t, map[string]string{"main.c": "int main(void) { return 0; }\n"})
+	if ok, _ := New().Detect(noBuild); ok {
+		t.Error("C sources without a root build file should not be detected")
+	}
+}
+
+func TestParseFile_Symbols(t *testing.T) {
+	src := `#include <stdio.h>
+#include "util.h"
+
+#define MAX_SIZE 64
+#define SQUARE(x) ((x) * (x))
+#define LONG_MACRO(a, b) \
+    do { foo(a); \
+    } while (0)
+
+/* a block comment with a fake function: int fake(void) { } */
+typedef struct {
+    int x;
+} Point;
+
+struct node;
+struct node *make_node(int v);
+
+namespace app {
+
+class Shape : public Base, private detail::Counted<Shape> {
+public:
+    virtual double area() const = 0;
+    void helper() { doSomething(); }
+};
+
+struct Config {
+    int port;
+};
+
+enum class Color { Red, Green };
+
+static int clamp(int v,
+                 int lo, int hi)
+{
+    if (v < lo) return lo;
+    return v > hi ? hi : v;
+}
+
+double Shape::scale(double factor) const {
+    return area() * factor;
+}
+
+} // namespace app
+
+extern "C" {
+int c_api(void) { return 1; }
+}
+
+int main(int argc, char **argv) {
+    return clamp(argc, 0, 1);
+}
+`
+	parsed := parseString(src, "src/shape.cpp")
+
+	var names []string
+	for _, f := range parsed.symbols {
+		names = append(names, f.Name)
+	}
+	want := []string{
+		"src.MAX_SIZE", "src.SQUARE", "src.LONG_MACRO",
+		"src.Point", "src.Shape", "src.Config", "src.Color",
+		"src.clamp", "src.Shape.scale", "src.c_api", "src.main",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("symbols = %v\nwant      %v", names, want)
+	}
+
+	square, _ := findFact(parsed.symbols, facts.KindSymbol, "src.SQUARE")
+	if square.Props["macro"] != true || square.Props["function_like"] != true {
+		t.Errorf("SQUARE props = %v", square.Props)
+	}
+
+	shape, _ := findFact(parsed.symbols, facts.KindSymbol, "src.Shape")
+	if shape.Props["symbol_kind"] != facts.SymbolClass || shape.Line != 20 {
+		t.Errorf("Shape = %v line %d", shape.Props, shape.Line)
+	}
+	if got := relationTargets(shape, facts.RelImplements); !reflect.DeepEqual(got, []string{"Base", "Counted"}) {
+		t.Errorf("Shape bases = %v", got)
+	}
+
+	clamp, _ := findFact(parsed.symbols, facts.KindSymbol, "src.clamp")
+	if clamp.Props["exported"] != false || clamp.Line != 32 {
+		t.Errorf("clamp = %v line %d", clamp.Props, clamp.Line)
+	}
+
+	scale, _ := findFact(parsed.symbols, facts.KindSymbol, "src.Shape.scale")
+	if scale.Props["symbol_kind"] != facts.SymbolMethod {
+		t.Errorf("Shape::scale symbol_kind = %v", scale.Props["symbol_kind"])
+	}
+
+	if len(parsed.includes) != 2 || parsed.includes[0].quoted || !parsed.includes[1].quoted {
+		t.Errorf("includes = %+v", parsed.includes)
+	}
+}
+
+func TestExtract_IncludeGraph(t *testing.T) {
+	dir, files := writeRepo(t, map[string]string{
+		"CMakeLists.txt":          "project(demo)\n",
+		"src/main.c":              "#include <stdlib.h>\n#include \"net/conn.h\"\n#include \"missing.h\"\nint main(void) { return 0; }\n",
+		"src/net/conn.h":          "#include \"buffer.h\"\n#include <core/log.h>\n",
+		"src/net/buffer.h":        "#include \"conn.h\"\n",
+		"include/core/log.h":      "#define LOG(x) x\n",
+		"vendor/other/core/log.h": "",
+	})
+
+	ff, err := New().Extract(context.Background(), dir, files)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+
+	main, ok := findFact(ff, facts.KindDependency, "src/main.c")
+	if !ok {
+		t.Fatal("expected a dependency fact for src/main.c")
+	}
+	if got := relationTargets(main, facts.RelImports); !reflect.DeepEqual(got, []string{"src/net/conn.h"}) {
+		t.Errorf("main.c imports = %v", got)
+	}
+	if !reflect.DeepEqual(main.Props["system_includes"], []string{"stdlib.h"}) ||
+		!reflect.DeepEqual(main.Props["unresolved_includes"], []string{"missing.h"}) {
+		t.Errorf("main.c props = %v", main.Props)
+	}
+
+	conn, _ := findFact(ff, facts.KindDependency, "src/net/conn.h")
+	if got := relationTargets(conn, facts.RelImports); !reflect.DeepEqual(got, []string{"src/net/buffer.h", "include/core/log.h"}) {
+		t.Errorf("conn.h imports = %v (angle include should resolve to the shortest matching path)", got)
+	}
+
+	log, ok := findFact(ff, facts.KindDependency, "include/core/log.h")
+	if !ok || log.Props["header"] != true || len(log.Relations) != 0 {
+		t.Errorf("expected an include-free header fact for log.h, got %+v", log)
+	}
+
+	modDep, ok := findFact(ff, facts.KindDependency, "src -> src/net")
+	if !ok || !reflect.DeepEqual(relationTargets(modDep, facts.RelImports), []string{"src/net"}) {
+		t.Errorf("expected module dependency src -> src/net, got %+v", modDep)
+	}
+	if _, ok := findFact(ff, facts.KindModule, "src/net"); !ok {
+		t.Error("expected module fact for src/net")
+	}
+	if _, ok := findFact(ff, facts.KindSymbol, "include/core.LOG"); !ok {
+		t.Error("expected macro symbol include/core.LOG")
+	}
+}
@@ -0,0 +1,80 @@
+package extractors
+
+import (
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// SetDeprecated marks a symbol as deprecated: Props["deprecated"] is set to
+// true, and Props["deprecation"] to the deprecation message when there is one.
+func SetDeprecated(props map[string]any, message string) {
+	props["deprecated"] = true
+	if message = strings.TrimSpace(message); message != "" {
+		props["deprecation"] = message
+	}
+}
+
+// MarkDeprecated marks the symbols of ff declared in relFile as deprecated
+// when detect reports a deprecation in their prelude (see
+// DeclarationPrelude). lines are the lines of relFile. It serves the
+// line-based extractors, whose deprecations are annotations or doc comments
+// written just above the declaration.
+func MarkDeprecated(ff []facts.Fact, relFile string, lines []string, detect func(prelude []string) (string, bool)) {
+	for i := range ff {
+		f := &ff[i]
+		if f.Kind != facts.KindSymbol || f.File != relFile || f.Line <= 0 || f.Line > len(lines) {
+			continue
+		}
+		if message, ok := detect(DeclarationPrelude(lines, f.Line)); ok {
+			SetDeprecated(f.Props, message)
+		}
+	}
+}
+
+// DeclarationPrelude returns the comment and annotation lines directly above
+// the declaration on line (1-based), followed by the declaration line
+// itself. Annotation arguments spanning several lines are included. The
+// prelude ends at the first line above that is blank or is none of these.
+func DeclarationPrelude(lines []string, line int) []string {
+	start := line - 1
+	depth := 0 // parentheses closed below but not yet opened, walking up
+	for i := line - 2; i >= 0 && line-1-i <= 50; i-- {
+		t := strings.TrimSpace(lines[i])
+		if t == "" {
+			break
+		}
+		d := depth + strings.Count(t, ")") - strings.Count(t, "(")
+		if !IsCommentLine(t) && !strings.HasPrefix(t, "@") && d <= 0 && depth <= 0 {
+			break
+		}
+		depth = d
+		start = i
+	}
+	return lines[start:line]
+}
+
+// IsCommentLine reports whether a trimmed line is a comment line in a
+// C-family language: a // comment or a line of a /* */ block.
+func IsCommentLine(trimmed string) bool {
+	return strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*") ||
+		strings.HasPrefix(trimmed, "*") || strings.HasSuffix(trimmed, "*/")
+}
+
+// IsHashCommentLine reports whether a trimmed source line is a # comment,
+// as in Ruby and Python.
+func IsHashCommentLine(trimmed string) bool {
+	return strings.HasPrefix(trimmed, "#")
+}
+
+// CodeText joins the lines of a prelude that are not comments, for matching
+// annotations that may span lines.
+func CodeText(prelude []string) string {
+	var parts []string
+	for _, l := range prelude {
+		if t := strings.TrimSpace(l); !IsCommentLine(t) {
+			parts = append(parts, t)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,414 @@
+package dockerextractor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/extractors"
+	"github.com/dejo1307/archmcp/internal/facts"
+	"gopkg.in/yaml.v3"
+)
+
+// composeFiles are the root compose file names, in the order docker compose
+// looks for them, followed by their override files.
+var composeFiles = []string{
+	"compose.yaml",
+	"compose.yml",
+	"docker-compose.yaml",
+	"docker-compose.yml",
+	"compose.override.yaml",
+	"compose.override.yml",
+	"docker-compose.override.yaml",
+	"docker-compose.override.yml",
+}
+
+// DockerExtractor extracts deployment topology from the repository's root
+// docker compose file: one module fact per service, enriched from the
+// Dockerfile it builds. Like the OpenAPI extractor it reads the compose files
+// directly, because YAML files are typically excluded by the global ignore
+// patterns.
+type DockerExtractor struct{}
+
+// New creates a new DockerExtractor.
+func New() *DockerExtractor {
+	return &DockerExtractor{}
+}
+
+func (e *DockerExtractor) Name() string {
+	return "docker"
+}
+
+// Detect returns true if the repository has a compose file at its root.
+func (e *DockerExtractor) Detect(repoPath string) (bool, error) {
+	return len(findComposeFiles(repoPath)) > 0, nil
+}
+
+// Extract parses the root compose files and emits a KindModule fact per
+// service. Services defined in an override file are merged into the base
+// definition.
+func (e *DockerExtractor) Extract(ctx context.Context, repoPath string, _ []string) ([]facts.Fact, error) {
+	var fileErrs extractors.FileErrors
+	services := make(map[string]*service)
+	var order []string
+
+	for _, relFile := range findComposeFiles(repoPath) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		parsed, err := parseComposeFile(filepath.Join(repoPath, relFile), relFile)
+		if err != nil {
+			fileErrs.Add(relFile, err)
+			continue
+		}
+		for _, svc := range parsed {
+			if existing, ok := services[svc.name]; ok {
+				existing.merge(svc)
+				continue
+			}
+			services[svc.name] = svc
+			order = append(order, svc.name)
+		}
+	}
+
+	var allFacts []facts.Fact
+	for _, name := range order {
+		svc := services[name]
+		if svc.dockerfile != "" {
+			if err := svc.readDockerfile(repoPath); err != nil {
+				fileErrs.Add(svc.dockerfile, err)
+			}
+		}
+		allFacts = append(allFacts, svc.fact())
+	}
+
+	return allFacts, fileErrs.Err()
+}
+
+// findComposeFiles returns the compose files present at the repo root.
+func findComposeFiles(repoPath string) []string {
+	var found []string
+	for _, name := range composeFiles {
+		if info, err := os.Stat(filepath.Join(repoPath, name)); err == nil && !info.IsDir() {
+			found = append(found, name)
+		}
+	}
+	return found
+}
+
+// serviceName returns the fact name for a compose service. The prefix keeps
+// services apart from code modules named after a directory of the same name.
+func serviceName(name string) string {
+	return "service:" + name
+}
+
+// service is a compose service collected across the compose files.
+type service struct {
+	name        string
+	file        string
+	line        int
+	image       string
+	baseImage   string
+	build       string
+	dockerfile  string
+	ports       []string
+	expose      []string
+	dependsOn   []string
+	networkMode string
+}
+
+// merge applies an override file's definition on top of s.
+func (s *service) merge(o *service) {
+	if o.image != "" {
+		s.image = o.image
+	}
+	if o.build != "" {
+		s.build, s.dockerfile = o.build, o.dockerfile
+	}
+	if o.networkMode != "" {
+		s.networkMode = o.networkMode
+	}
+	s.ports = appendUnique(s.ports, o.ports...)
+	s.expose = appendUnique(s.expose, o.expose...)
+	s.dependsOn = appendUnique(s.dependsOn, o.dependsOn...)
+}
+
+// readDockerfile records the final stage's base image and EXPOSE ports.
+func (s *service) readDockerfile(repoPath string) error {
+	f, err := os.Open(filepath.Join(repoPath, s.dockerfile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	base, exposed := parseDockerfile(f)
+	s.baseImage = base
+	s.expose = appendUnique(s.expose, exposed...)
+	return nil
+}
+
+func (s *service) fact() facts.Fact {
+	props := map[string]any{
+		"language":     "docker",
+		"framework":    "docker-compose",
+		"service":      s.name,
+		"compose_file": s.file,
+	}
+	if s.image != "" {
+		props["image"] = s.image
+	}
+	if s.build != "" {
+		props["build"] = s.build
+	}
+	if s.dockerfile != "" {
+		props["dockerfile"] = s.dockerfile
+	}
+	if s.baseImage != "" {
+		props["base_image"] = s.baseImage
+	}
+	if len(s.ports) > 0 {
+		props["ports"] = s.ports
+	}
+	if len(s.expose) > 0 {
+		props["expose"] = s.expose
+	}
+
+	var rels []facts.Relation
+	for _, dep := range s.dependsOn {
+		rels = append(rels, facts.Relation{Kind: facts.RelDependsOn, Target: serviceName(dep)})
+	}
+	// network_mode: "service:vpn" shares the other service's network stack.
+	if target, ok := strings.CutPrefix(s.networkMode, "service:"); ok && !containsString(s.dependsOn, target) {
+		rels = append(rels, facts.Relation{Kind: facts.RelDependsOn, Target: serviceName(target)})
+	}
+
+	return facts.Fact{
+		Kind:      facts.KindModule,
+		Name:      serviceName(s.name),
+		File:      s.file,
+		Line:      s.line,
+		Props:     props,
+		Relations: rels,
+	}
+}
+
+// composeService is the subset of a compose service definition that matters
+// for topology. Fields with several accepted syntaxes are decoded from nodes.
+type composeService struct {
+	Image       string    `yaml:"image"`
+	Build       yaml.Node `yaml:"build"`
+	Ports       []any     `yaml:"ports"`
+	Expose      []any     `yaml:"expose"`
+	DependsOn   yaml.Node `yaml:"depends_on"`
+	Links       []string  `yaml:"links"`
+	NetworkMode string    `yaml:"network_mode"`
+}
+
+// parseComposeFile decodes the services of one compose file, in file order.
+func parseComposeFile(absPath, relFile string) ([]*service, error) {
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Services yaml.Node `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Services.Kind == 0 {
+		return nil, nil
+	}
+	if doc.Services.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: services must be a mapping", doc.Services.Line)
+	}
+
+	var out []*service
+	for i := 0; i+1 < len(doc.Services.Content); i += 2 {
+		key, value := doc.Services.Content[i], doc.Services.Content[i+1]
+		var cs composeService
+		if err := value.Decode(&cs); err != nil {
+			return nil, fmt.Errorf("service %s: %w", key.Value, err)
+		}
+
+		svc := &service{
+			name:        key.Value,
+			file:        relFile,
+			line:        key.Line,
+			image:       cs.Image,
+			networkMode: cs.NetworkMode,
+		}
+		svc.build, svc.dockerfile = buildContext(&cs.Build)
+		for _, p := range cs.Ports {
+			if port := formatPort(p); port != "" {
+				svc.ports = append(svc.ports, port)
+			}
+		}
+		for _, p := range cs.Expose {
+			if port := formatPort(p); port != "" {
+				svc.expose = append(svc.expose, port)
+			}
+		}
+		svc.dependsOn = dependsOnNames(&cs.DependsOn)
+		for _, link := range cs.Links {
+			// "db:database" links the db service under the alias database.
+			name, _, _ := strings.Cut(link, ":")
+			svc.dependsOn = appendUnique(svc.dependsOn, name)
+		}
+		out = append(out, svc)
+	}
+	return out, nil
+}
+
+// buildContext returns the build context directory and the Dockerfile path,
+// both relative to the repo root, for the short ("build: ./web") and long
+// ("build: {context, dockerfile}") syntax. Remote contexts are ignored.
+func buildContext(node *yaml.Node) (string, string) {
+	var dir, dockerfile string
+	switch node.Kind {
+	case yaml.ScalarNode:
+		dir = node.Value
+	case yaml.MappingNode:
+		var b struct {
+			Context    string `yaml:"context"`
+			Dockerfile string `yaml:"dockerfile"`
+		}
+		if node.Decode(&b) != nil {
+			return "", ""
+		}
+		dir, dockerfile = b.Context, b.Dockerfile
+		if dir == "" {
+			dir = "."
+		}
+	default:
+		return "", ""
+	}
+	if strings.Contains(dir, "://") || strings.HasPrefix(dir, "git@") {
+		return dir, ""
+	}
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	dir = filepath.ToSlash(filepath.Clean(dir))
+	return dir, filepath.ToSlash(filepath.Join(dir, dockerfile))
+}
+
+// dependsOnNames accepts both the list ("depends_on: [db]") and the mapping
+// ("depends_on: {db: {condition: ...}}") syntax.
+func dependsOnNames(node *yaml.Node) []string {
+	var names []string
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, n := range node.Content {
+			if n.Kind == yaml.ScalarNode {
+				names = append(names, n.Value)
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content); i += 2 {
+			names = append(names, node.Content[i].Value)
+		}
+	}
+	return names
+}
+
+// formatPort normalizes a port entry from the short syntax ("8080:80", 80)
+// or the long syntax ({published: 8080, target: 80}) to "published:target"
+// or "target".
+func formatPort(p any) string {
+	switch v := p.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case map[string]any:
+		target := fmt.Sprint(v["target"])
+		if v["target"] == nil {
+			return ""
+		}
+		if published, ok := v["published"]; ok && published != nil {
+			return fmt.Sprintf("%v:%s", published, target)
+		}
+		return target
+	}
+	return ""
+}
+
+// parseDockerfile returns the image of the last FROM instruction (the stage
+// that is shipped) and the ports of every EXPOSE instruction.
+func parseDockerfile(r io.Reader) (string, []string) {
+	var base string
+	var exposed []string
+	stages := make(map[string]string) // stage name -> image, for FROM <stage>
+
+	scanner := bufio.NewScanner(r)
+	var line string
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(text, "#") {
+			continue
+		}
+		// Join continuation lines before parsing the instruction.
+		if strings.HasSuffix(text, "\\") {
+			line += strings.TrimSuffix(text, "\\") + " "
+			continue
+		}
+		line += text
+		fields := strings.Fields(line)
+		line = ""
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "FROM":
+			args := fields[1:]
+			for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+				args = args[1:]
+			}
+			if len(args) == 0 {
+				continue
+			}
+			image := args[0]
+			if parent, ok := stages[strings.ToLower(image)]; ok {
+				image = parent
+			}
+			if len(args) >= 3 && strings.EqualFold(args[1], "AS") {
+				stages[strings.ToLower(args[2])] = image
+			}
+			base = image
+		case "EXPOSE":
+			exposed = appendUnique(exposed, fields[1:]...)
+		}
+	}
+	return base, exposed
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		if !containsString(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
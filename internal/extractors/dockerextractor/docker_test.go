@@ -0,0 +1,152 @@
+package dockerextractor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+func writeRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func findFact(ff []facts.Fact, name string) (facts.Fact, bool) {
+	for _, f := range ff {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return facts.Fact{}, false
+}
+
+func dependsOn(f facts.Fact) []string {
+	var out []string
+	for _, r := range f.Relations {
+		if r.Kind == facts.RelDependsOn {
+			out = append(out, r.Target)
+		}
+	}
+	return out
+}
+
+const compose = `services:
+  web:
+    build: ./web
+    ports:
+      - "8080:80"
+      - target: 443
+        published: 8443
+    depends_on:
+      - api
+  api:
+    build:
+      context: services/api
+      dockerfile: Dockerfile.prod
+    depends_on:
+      db:
+        condition: service_healthy
+      cache:
+        condition: service_started
+    links:
+      - "db:database"
+  db:
+    image: postgres:16
+    expose:
+      - 5432
+  cache:
+    image: redis:7
+`
+
+func TestExtract_ComposeServices(t *testing.T) {
+	dir := writeRepo(t, map[string]string{
+		"docker-compose.yml": compose,
+		"web/Dockerfile":     "FROM node:20 AS build\nRUN npm ci\nFROM nginx:1.25\nEXPOSE 80 \\\n  443\n",
+		"services/api/Dockerfile.prod": "# syntax=docker/dockerfile:1\n" +
+			"FROM --platform=linux/amd64 golang:1.22 AS builder\nFROM builder AS final\nEXPOSE 9000\n",
+	})
+
+	e := New()
+	if ok, _ := e.Detect(dir); !ok {
+		t.Fatal("expected docker-compose.yml to be detected")
+	}
+	ff, err := e.Extract(context.Background(), dir, nil)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if len(ff) != 4 {
+		t.Fatalf("expected 4 service facts, got %d: %v", len(ff), ff)
+	}
+
+	web, ok := findFact(ff, "service:web")
+	if !ok {
+		t.Fatal("expected fact service:web")
+	}
+	if web.Kind != facts.KindModule || web.File != "docker-compose.yml" || web.Line != 2 {
+		t.Errorf("web = %s %s:%d", web.Kind, web.File, web.Line)
+	}
+	if got := web.Props["ports"]; !reflect.DeepEqual(got, []string{"8080:80", "8443:443"}) {
+		t.Errorf("web ports = %v", got)
+	}
+	if web.Props["base_image"] != "nginx:1.25" || !reflect.DeepEqual(web.Props["expose"], []string{"80", "443"}) {
+		t.Errorf("web Dockerfile props = %v", web.Props)
+	}
+	if got := dependsOn(web); !reflect.DeepEqual(got, []string{"service:api"}) {
+		t.Errorf("web depends_on = %v", got)
+	}
+
+	api, _ := findFact(ff, "service:api")
+	if api.Props["dockerfile"] != "services/api/Dockerfile.prod" || api.Props["base_image"] != "golang:1.22" {
+		t.Errorf("api props = %v", api.Props)
+	}
+	if got := dependsOn(api); !reflect.DeepEqual(got, []string{"service:db", "service:cache"}) {
+		t.Errorf("api depends_on = %v, want db and cache (links deduplicated)", got)
+	}
+
+	db, _ := findFact(ff, "service:db")
+	if db.Props["image"] != "postgres:16" || !reflect.DeepEqual(db.Props["expose"], []string{"5432"}) {
+		t.Errorf("db props = %v", db.Props)
+	}
+}
+
+func TestExtract_OverrideAndErrors(t *testing.T) {
+	dir := writeRepo(t, map[string]string{
+		"compose.yaml":          "services:\n  web:\n    image: web:latest\n",
+		"compose.override.yaml": "services:\n  web:\n    ports: [\"3000:3000\"]\n    depends_on: [db]\n  db:\n    image: mysql:8\n",
+	})
+	ff, err := New().Extract(context.Background(), dir, nil)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	web, _ := findFact(ff, "service:web")
+	if web.Props["image"] != "web:latest" || !reflect.DeepEqual(web.Props["ports"], []string{"3000:3000"}) {
+		t.Errorf("merged web props = %v", web.Props)
+	}
+	if got := dependsOn(web); !reflect.DeepEqual(got, []string{"service:db"}) {
+		t.Errorf("merged web depends_on = %v", got)
+	}
+
+	bad := writeRepo(t, map[string]string{"docker-compose.yml": "services: [web\n"})
+	if _, err := New().Extract(context.Background(), bad, nil); err == nil || !strings.Contains(err.Error(), "docker-compose.yml") {
+		t.Errorf("expected a file error for the malformed compose file, got %v", err)
+	}
+
+	if ok, _ := New().Detect(t.TempDir()); ok {
+		t.Error("a repo without a compose file should not be detected")
+	}
+}
@@ -0,0 +1,612 @@
+package elixirextractor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/extractors"
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// ElixirExtractor extracts architectural facts from Elixir source code using line-based regex parsing.
+type ElixirExtractor struct{}
+
+// New creates a new ElixirExtractor.
+func New() *ElixirExtractor {
+	return &ElixirExtractor{}
+}
+
+func (e *ElixirExtractor) Name() string {
+	return "elixir"
+}
+
+// Detect returns true if the repository is a Mix project (has a mix.exs).
+func (e *ElixirExtractor) Detect(repoPath string) (bool, error) {
+	if _, err := os.Stat(filepath.Join(repoPath, "mix.exs")); err == nil {
+		return true, nil
+	}
+	return false, nil
+}
+
+// Extract parses Elixir files and emits architectural facts.
+func (e *ElixirExtractor) Extract(ctx context.Context, repoPath string, files []string) ([]facts.Fact, error) {
+	var allFacts []facts.Fact
+	var fileErrs extractors.FileErrors
+
+	isPhoenix := detectPhoenixProject(repoPath)
+	modules := make(map[string]bool)
+
+	for _, relFile := range files {
+		select {
+		case <-ctx.Done():
+			return allFacts, ctx.Err()
+		default:
+		}
+
+		if !isElixirFile(relFile) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(repoPath, relFile))
+		if err != nil {
+			fileErrs.Add(relFile, err)
+			continue
+		}
+		lines := strings.Split(string(data), "\n")
+		codes := lexLines(lines)
+
+		allFacts = append(allFacts, extractFile(relFile, lines, codes, isPhoenix)...)
+		if isRouterFile(codes) {
+			allFacts = append(allFacts, extractRoutes(relFile, lines, codes)...)
+		}
+
+		modules[filepath.Dir(relFile)] = true
+	}
+
+	allFacts = resolveDependencies(allFacts)
+
+	for dir := range modules {
+		props := map[string]any{
+			"language": "elixir",
+		}
+		if isPhoenix {
+			props["framework"] = "phoenix"
+		}
+		allFacts = append(allFacts, facts.Fact{
+			Kind:  facts.KindModule,
+			Name:  dir,
+			File:  dir,
+			Props: props,
+		})
+	}
+
+	return allFacts, fileErrs.Err()
+}
+
+// detectPhoenixProject reports whether mix.exs depends on Phoenix.
+func detectPhoenixProject(repoPath string) bool {
+	data, err := os.ReadFile(filepath.Join(repoPath, "mix.exs"))
+	return err == nil && phoenixDepRe.Match(data)
+}
+
+// --- Regex patterns ---
+
+var (
+	phoenixDepRe = regexp.MustCompile(`\{\s*:phoenix\s*,`)
+
+	// A module name as written: Foo.Bar, or __MODULE__ and names under it.
+	moduleName = `((?:__MODULE__|[A-Z]\w*)(?:\.[A-Z]\w*)*)`
+
+	defmoduleRe = regexp.MustCompile(`^\s*defmodule\s+` + moduleName)
+
+	// Function and macro definitions. Captures: keyword (group 1), name
+	// (group 2), and the rest of the line (group 3).
+	defRe = regexp.MustCompile(`^\s*(def|defp|defmacro|defmacrop)\s+([a-z_]\w*[?!]?)(.*)$`)
+
+	// use / import / alias / require. Captures: directive (group 1), module
+	// (group 2), and the rest of the line (group 3).
+	directiveRe = regexp.MustCompile(`^\s*(use|import|alias|require)\s+` + moduleName + `(.*)$`)
+	aliasAsRe   = regexp.MustCompile(`^\s*,\s*as:\s*([A-Z]\w*)`)
+
+	behaviourRe = regexp.MustCompile(`^\s*@behaviour\s+` + moduleName)
+	schemaRe    = regexp.MustCompile(`^\s*schema\s+"([^"]+)"`)
+)
+
+// elixirStdlib holds the top-level modules of Elixir, OTP wrappers and the
+// Mix and ExUnit tooling; directives naming them get source "stdlib".
+var elixirStdlib = map[string]bool{
+	"Access": true, "Agent": true, "Application": true, "Base": true, "Bitwise": true,
+	"Code": true, "Config": true, "Date": true, "DateTime": true, "DynamicSupervisor": true,
+	"Enum": true, "ExUnit": true, "File": true, "Float": true, "GenServer": true,
+	"IO": true, "Integer": true, "Kernel": true, "Keyword": true, "List": true,
+	"Logger": true, "Macro": true, "Map": true, "MapSet": true, "Mix": true,
+	"Module": true, "NaiveDateTime": true, "Path": true, "Process": true, "Protocol": true,
+	"Record": true, "Regex": true, "Registry": true, "Stream": true, "String": true,
+	"Supervisor": true, "System": true, "Task": true, "Time": true, "Tuple": true,
+	"URI": true, "Version": true,
+}
+
+// moduleScope is an enclosing defmodule whose body starts at depth. Aliases
+// map the short names in effect in the body to full module names.
+type moduleScope struct {
+	name    string
+	depth   int
+	aliases map[string]string
+	defined map[string]bool // name/arity of the functions already recorded
+}
+
+// resolve expands the aliases and __MODULE__ in a module name written in
+// the scope.
+func (s *moduleScope) resolve(name string) string {
+	if s == nil {
+		return name
+	}
+	head, rest, _ := strings.Cut(name, ".")
+	if head == "__MODULE__" {
+		head = s.name
+	} else if full, ok := s.aliases[head]; ok {
+		head = full
+	}
+	if rest == "" {
+		return head
+	}
+	return head + "." + rest
+}
+
+// extractFile parses a single Elixir file and returns facts. codes are the
+// lines of relFile as returned by lexLines.
+func extractFile(relFile string, lines, codes []string, isPhoenix bool) []facts.Fact {
+	var result []facts.Fact
+	dir := filepath.Dir(relFile)
+
+	var scopes []*moduleScope
+	depth := 0
+	for i, code := range codes {
+		lineNum := i + 1
+		depthBefore := depth
+		opens, closes := blockDelta(code)
+		depth += opens - closes
+
+		// Leave modules whose bodies have closed.
+		for len(scopes) > 0 && depthBefore < scopes[len(scopes)-1].depth {
+			scopes = scopes[:len(scopes)-1]
+		}
+		var owner *moduleScope
+		if len(scopes) > 0 {
+			owner = scopes[len(scopes)-1]
+		}
+
+		// Declarations are only recorded at the top level or directly in a
+		// module body, never inside function bodies or quote blocks.
+		if (owner == nil && depthBefore != 0) || (owner != nil && depthBefore != owner.depth) {
+			continue
+		}
+
+		if m := defmoduleRe.FindStringSubmatch(code); m != nil {
+			name := m[1]
+			if owner != nil {
+				if strings.HasPrefix(name, "__MODULE__") {
+					name = owner.resolve(name)
+				} else {
+					// A nested module is named after its parent and can be
+					// referred to by its short name in the parent.
+					name = owner.name + "." + name
+					if !strings.Contains(m[1], ".") {
+						owner.aliases[m[1]] = name
+					}
+				}
+			}
+
+			result = append(result, facts.Fact{
+				Kind: facts.KindSymbol,
+				Name: name,
+				File: relFile,
+				Line: lineNum,
+				Props: map[string]any{
+					"symbol_kind": facts.SymbolClass,
+					"exported":    true,
+					"language":    "elixir",
+				},
+				Relations: []facts.Relation{
+					{Kind: facts.RelDeclares, Target: dir},
+				},
+			})
+			if opens > closes {
+				scope := &moduleScope{name: name, depth: depthBefore + 1, aliases: make(map[string]string), defined: make(map[string]bool)}
+				if owner != nil {
+					for k, v := range owner.aliases {
+						scope.aliases[k] = v
+					}
+				}
+				scopes = append(scopes, scope)
+			}
+			continue
+		}
+
+		if m := directiveRe.FindStringSubmatch(code); m != nil {
+			directive, rest := m[1], m[3]
+			var targets []string
+			if directive == "alias" && strings.HasPrefix(rest, ".{") {
+				// Multi-alias: alias MyApp.{Accounts, Billing.Invoice}, possibly
+				// spanning several lines.
+				group := rest
+				for j := i + 1; !strings.Contains(group, "}") && j < len(codes) && j < i+50; j++ {
+					group += " " + codes[j]
+				}
+				group = strings.TrimPrefix(group, ".{")
+				if end := strings.Index(group, "}"); end >= 0 {
+					group = group[:end]
+				}
+				base := owner.resolve(m[2])
+				for _, part := range strings.Split(group, ",") {
+					if part = strings.TrimSpace(part); part != "" {
+						targets = append(targets, base+"."+part)
+					}
+				}
+			} else {
+				targets = []string{owner.resolve(m[2])}
+			}
+
+			for _, target := range targets {
+				if directive == "alias" && owner != nil {
+					short := target[strings.LastIndex(target, ".")+1:]
+					if as := aliasAsRe.FindStringSubmatch(rest); as != nil && len(targets) == 1 {
+						short = as[1]
+					}
+					owner.aliases[short] = target
+				}
+				result = append(result, facts.Fact{
+					Kind: facts.KindDependency,
+					Name: dir + " -> " + target,
+					File: relFile,
+					Line: lineNum,
+					Props: map[string]any{
+						"language":    "elixir",
+						"import_kind": directive,
+						"module":      target,
+					},
+					Relations: []facts.Relation{
+						{Kind: facts.RelImports, Target: target},
+					},
+				})
+			}
+			continue
+		}
+
+		if owner == nil {
+			continue
+		}
+
+		if m := behaviourRe.FindStringSubmatch(code); m != nil {
+			if mod := moduleFact(result, owner.name); mod != nil {
+				mod.Relations = append(mod.Relations, facts.Relation{Kind: facts.RelImplements, Target: owner.resolve(m[1])})
+			}
+			continue
+		}
+
+		if m := schemaRe.FindStringSubmatch(lines[i]); m != nil && opens > closes {
+			result = append(result, ectoSchema(owner, relFile, m[1], lines, codes, i))
+			continue
+		}
+
+		if m := defRe.FindStringSubmatch(code); m != nil {
+			keyword, name := m[1], m[2]
+			if name == "unquote" {
+				continue // def unquote(name)(...) generated by a macro
+			}
+			arity := arityOf(joinContinued(codes, i, m[3]))
+			key := name + "/" + strconv.Itoa(arity)
+			if owner.defined[key] {
+				continue // a further clause of the same function
+			}
+			owner.defined[key] = true
+
+			exported := keyword == "def" || keyword == "defmacro"
+			props := map[string]any{
+				"symbol_kind": facts.SymbolFunc,
+				"exported":    exported,
+				"language":    "elixir",
+				"arity":       arity,
+			}
+			if strings.HasPrefix(keyword, "defmacro") {
+				props["macro"] = true
+			}
+			if isPhoenix {
+				props["framework"] = "phoenix"
+				// Public two-argument functions of a controller are its actions.
+				if exported && arity == 2 && strings.HasSuffix(owner.name, "Controller") {
+					props["entry_point"] = facts.EntryControllerAction
+				}
+			}
+
+			result = append(result, facts.Fact{
+				Kind:  facts.KindSymbol,
+				Name:  owner.name + "." + key,
+				File:  relFile,
+				Line:  lineNum,
+				Props: props,
+				Relations: []facts.Relation{
+					{Kind: facts.RelDeclares, Target: dir},
+				},
+			})
+		}
+	}
+
+	return result
+}
+
+// moduleFact returns the symbol fact of the module named name in ff.
+func moduleFact(ff []facts.Fact, name string) *facts.Fact {
+	for i := len(ff) - 1; i >= 0; i-- {
+		if ff[i].Kind == facts.KindSymbol && ff[i].Name == name {
+			return &ff[i]
+		}
+	}
+	return nil
+}
+
+// resolveDependencies resolves the directives recorded by extractFile once
+// every file is parsed. A module defined in the repo is replaced by the
+// directory of the file defining it (or of the longest defined module
+// prefix), so the module graph links directory to directory; directives
+// within a directory are dropped. Other modules are kept by name, with
+// source "stdlib" for Elixir's own and "external" for the rest.
+func resolveDependencies(ff []facts.Fact) []facts.Fact {
+	moduleDirs := make(map[string]string)
+	for _, f := range ff {
+		if f.Kind == facts.KindSymbol && f.Props["language"] == "elixir" && f.Props["symbol_kind"] == facts.SymbolClass {
+			if _, ok := moduleDirs[f.Name]; !ok {
+				moduleDirs[f.Name] = filepath.ToSlash(filepath.Dir(f.File))
+			}
+		}
+	}
+
+	kept := ff[:0]
+	for _, f := range ff {
+		module, ok := f.Props["module"].(string)
+		if f.Kind != facts.KindDependency || f.Props["language"] != "elixir" || !ok {
+			kept = append(kept, f)
+			continue
+		}
+		dir := filepath.ToSlash(filepath.Dir(f.File))
+		target, source := module, "external"
+		for prefix := module; prefix != ""; {
+			if d, ok := moduleDirs[prefix]; ok {
+				target, source = d, "internal"
+				break
+			}
+			i := strings.LastIndex(prefix, ".")
+			if i < 0 {
+				break
+			}
+			prefix = prefix[:i]
+		}
+		if source == "internal" && target == dir {
+			continue
+		}
+		if head, _, _ := strings.Cut(module, "."); source == "external" && elixirStdlib[head] {
+			source = "stdlib"
+		}
+		f.Name = dir + " -> " + target
+		f.Props["source"] = source
+		f.Relations = []facts.Relation{{Kind: facts.RelImports, Target: target}}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// --- Lexing helpers ---
+
+// lexLines returns the code of each line: string and charlist contents,
+// sigils and comments removed, and heredocs (including ~H""" templates)
+// blanked, so keywords and brackets can be counted without false matches.
+// Quotes are kept, so "get "/x"" reads as get "".
+func lexLines(lines []string) []string {
+	codes := make([]string, len(lines))
+	heredoc := "" // terminator of the open heredoc
+	for i, line := range lines {
+		start := 0
+		if heredoc != "" {
+			end := strings.Index(line, heredoc)
+			if end < 0 {
+				continue
+			}
+			start = end + len(heredoc)
+			heredoc = ""
+		}
+		codes[i], heredoc = lexLine(line[start:])
+	}
+	return codes
+}
+
+// lexLine returns the code of a line starting outside any string, and the
+// terminator of a heredoc the line opens but does not close.
+func lexLine(line string) (string, string) {
+	var sb strings.Builder
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '#':
+			return sb.String(), ""
+		case c == '?' && i+1 < len(line) && (i == 0 || !isIdentByte(line[i-1])):
+			// Character literal: ?a, ?", ?#.
+			i++
+			if line[i] == '\\' && i+1 < len(line) {
+				i++
+			}
+		case c == '~' && i+2 < len(line) && isLetter(line[i+1]):
+			// Sigil: ~r/.../, ~w(...), ~H""" heredoc.
+			j := i + 2
+			if strings.HasPrefix(line[j:], `"""`) || strings.HasPrefix(line[j:], `'''`) {
+				sb.WriteString(`""`)
+				return sb.String(), line[j : j+3]
+			}
+			end := closingDelimiter(line, j)
+			sb.WriteString(`""`)
+			i = end
+			for i+1 < len(line) && isLetter(line[i+1]) {
+				i++ // modifiers such as ~r/x/i
+			}
+		case c == '"' || c == '\'':
+			if strings.HasPrefix(line[i:], `"""`) || strings.HasPrefix(line[i:], `'''`) {
+				sb.WriteString(`""`)
+				return sb.String(), line[i : i+3]
+			}
+			sb.WriteString(`""`)
+			i = skipString(line, i)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), ""
+}
+
+// skipString returns the index of the quote closing the string opened at
+// line[open], skipping escapes and #{} interpolations, or the last index
+// when the string is not closed on the line.
+func skipString(line string, open int) int {
+	quote := line[open]
+	for i := open + 1; i < len(line); i++ {
+		switch {
+		case line[i] == '\\':
+			i++
+		case line[i] == '#' && i+1 < len(line) && line[i+1] == '{':
+			depth := 0
+			for i++; i < len(line); i++ {
+				if line[i] == '{' {
+					depth++
+				} else if line[i] == '}' {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+		case line[i] == quote:
+			return i
+		}
+	}
+	return len(line) - 1
+}
+
+// closingDelimiter returns the index of the delimiter closing a sigil whose
+// opening delimiter is at line[open], or the last index when there is none.
+func closingDelimiter(line string, open int) int {
+	closer := line[open]
+	switch closer {
+	case '(':
+		closer = ')'
+	case '[':
+		closer = ']'
+	case '{':
+		closer = '}'
+	case '<':
+		closer = '>'
+	}
+	for i := open + 1; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+		} else if line[i] == closer {
+			return i
+		}
+	}
+	return len(line) - 1
+}
+
+// blockDelta returns the number of do and fn blocks a line of code opens
+// and the number of ends it has. A do block opens when do is the last word
+// of the line; do: and end: are keyword list keys, and :do, :end atoms.
+func blockDelta(code string) (opens, closes int) {
+	trimmed := strings.TrimSpace(code)
+	for i := 0; i < len(trimmed); {
+		if !isIdentByte(trimmed[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(trimmed) && (isIdentByte(trimmed[j]) || trimmed[j] == '?' || trimmed[j] == '!') {
+			j++
+		}
+		word := trimmed[i:j]
+		prev := byte(' ')
+		if i > 0 {
+			prev = trimmed[i-1]
+		}
+		isKey := j < len(trimmed) && trimmed[j] == ':'
+		if prev != ':' && prev != '.' && prev != '@' && !isKey {
+			switch word {
+			case "do":
+				if j == len(trimmed) {
+					opens++
+				}
+			case "fn":
+				opens++
+			case "end":
+				closes++
+			}
+		}
+		i = j
+	}
+	return opens, closes
+}
+
+// joinContinued returns rest followed by the code of the lines after
+// line i, up to the line closing the parenthesis rest opens, so the
+// parameters of a multi-line definition can be counted.
+func joinContinued(codes []string, i int, rest string) string {
+	depth := strings.Count(rest, "(") - strings.Count(rest, ")")
+	for j := i + 1; depth > 0 && j < len(codes) && j < i+50; j++ {
+		rest += " " + codes[j]
+		depth += strings.Count(codes[j], "(") - strings.Count(codes[j], ")")
+	}
+	return rest
+}
+
+// arityOf returns the number of parameters of a definition from the text
+// after its name: "(conn, %{"id" => id})" has two. Parameters with
+// defaults count once, so the maximum arity is recorded.
+func arityOf(rest string) int {
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, "(") {
+		return 0
+	}
+	depth, args, empty := 0, 1, true
+	for i := 0; i < len(rest); i++ {
+		switch c := rest[i]; c {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				if empty {
+					return 0
+				}
+				return args
+			}
+		case ',':
+			if depth == 1 {
+				args++
+			}
+		case ' ', '\t':
+		default:
+			empty = false
+		}
+	}
+	return args
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || isLetter(c) || (c >= '0' && c <= '9')
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isElixirFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".ex" || ext == ".exs"
+}
@@ -0,0 +1,477 @@
+package elixirextractor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// --- helpers ---
+
+// setupRepo writes the given files (relative path -> content) into a temp dir.
+func setupRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for rel, content := range files {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func extractAll(t *testing.T, files map[string]string) []facts.Fact {
+	t.Helper()
+	dir := setupRepo(t, files)
+	var rel []string
+	for f := range files {
+		rel = append(rel, f)
+	}
+	slices.Sort(rel)
+	ff, err := New().Extract(context.Background(), dir, rel)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	return ff
+}
+
+func extractSource(src string) []facts.Fact {
+	lines := strings.Split(src, "\n")
+	return extractFile("lib/my_app/test.ex", lines, lexLines(lines), false)
+}
+
+func findFact(ff []facts.Fact, kind, name string) (facts.Fact, bool) {
+	for _, f := range ff {
+		if f.Kind == kind && f.Name == name {
+			return f, true
+		}
+	}
+	return facts.Fact{}, false
+}
+
+func findRoute(ff []facts.Fact, method, path string) (facts.Fact, bool) {
+	for _, f := range ff {
+		if f.Kind == facts.KindRoute && f.Name == path && f.Props["method"] == method {
+			return f, true
+		}
+	}
+	return facts.Fact{}, false
+}
+
+func hasRel(f facts.Fact, kind, target string) bool {
+	for _, r := range f.Relations {
+		if r.Kind == kind && r.Target == target {
+			return true
+		}
+	}
+	return false
+}
+
+// --- Unit tests for lexing helpers ---
+
+func TestLexLines(t *testing.T) {
+	lines := []string{
+		`  @moduledoc """`,
+		`  def inside_doc(a), do: a`,
+		`  """`,
+		`  x = "end # not a comment" # a comment with do`,
+		`  render(~H"""`,
+		`  <div>end</div>`,
+		`  """)`,
+		`  c = ?#`,
+		`  r = ~r/do$/`,
+	}
+	want := []string{
+		`  @moduledoc ""`,
+		``,
+		``,
+		`  x = "" `,
+		`  render(""`,
+		``,
+		`)`,
+		`  c = `,
+		`  r = ""`,
+	}
+	if got := lexLines(lines); !slices.Equal(got, want) {
+		t.Errorf("lexLines =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestBlockDelta(t *testing.T) {
+	tests := []struct {
+		code          string
+		opens, closes int
+	}{
+		{"defmodule MyApp do", 1, 0},
+		{"def run(x), do: x", 0, 0},
+		{"Enum.map(xs, fn x -> x end)", 1, 1},
+		{"Enum.each(xs, fn x ->", 1, 0},
+		{"end)", 0, 1},
+		{"%{start: 1, end: 2}", 0, 0},
+		{"opts[:do]", 0, 0},
+		{"with {:ok, x} <- f() do", 1, 0},
+	}
+	for _, tt := range tests {
+		if opens, closes := blockDelta(tt.code); opens != tt.opens || closes != tt.closes {
+			t.Errorf("blockDelta(%q) = %d, %d, want %d, %d", tt.code, opens, closes, tt.opens, tt.closes)
+		}
+	}
+}
+
+func TestArityOf(t *testing.T) {
+	tests := []struct {
+		rest string
+		want int
+	}{
+		{"", 0},
+		{", do: :ok", 0},
+		{"()", 0},
+		{"(conn, %{\"id\" => id}) do", 2},
+		{"(a, [b, c], {d, e}) when is_list(b) do", 3},
+		{"(name, opts \\\\ [])", 2},
+	}
+	for _, tt := range tests {
+		if got := arityOf(tt.rest); got != tt.want {
+			t.Errorf("arityOf(%q) = %d, want %d", tt.rest, got, tt.want)
+		}
+	}
+}
+
+// --- extractFile ---
+
+func TestExtractFile_ModulesAndFunctions(t *testing.T) {
+	ff := extractSource(`defmodule MyApp.Accounts do
+  @moduledoc """
+  def not_a_function(x), do: x
+  """
+  @behaviour MyApp.Context
+
+  def get_user(id), do: Repo.get(User, id)
+
+  def list_users(opts \\ []) do
+    Enum.map(opts, fn o ->
+      o
+    end)
+  end
+
+  def fetch(%{id: id}), do: id
+  def fetch(id) when is_integer(id), do: id
+
+  defp normalize(
+         name,
+         opts
+       ) do
+    name
+  end
+
+  defmacro __using__(_opts) do
+    quote do
+      def injected, do: nil
+    end
+  end
+
+  defmodule Helpers do
+    def ok?, do: true
+  end
+end
+`)
+
+	mod, ok := findFact(ff, facts.KindSymbol, "MyApp.Accounts")
+	if !ok {
+		t.Fatal("expected module symbol MyApp.Accounts")
+	}
+	if mod.Props["symbol_kind"] != facts.SymbolClass || mod.Line != 1 || !hasRel(mod, facts.RelImplements, "MyApp.Context") {
+		t.Errorf("module = %+v", mod)
+	}
+
+	for name, exported := range map[string]bool{
+		"MyApp.Accounts.get_user/1":         true,
+		"MyApp.Accounts.list_users/1":       true,
+		"MyApp.Accounts.fetch/1":            true,
+		"MyApp.Accounts.normalize/2":        false,
+		"MyApp.Accounts.__using__/1":        true,
+		"MyApp.Accounts.Helpers.ok?/0":      true,
+		"MyApp.Accounts.not_a_function/1":   false,
+		"MyApp.Accounts.injected/0":         false,
+		"MyApp.Accounts.Helpers.injected/0": false,
+	} {
+		f, ok := findFact(ff, facts.KindSymbol, name)
+		wantFound := !strings.Contains(name, "not_a_function") && !strings.Contains(name, "injected")
+		if ok != wantFound {
+			t.Errorf("%s found = %v, want %v", name, ok, wantFound)
+			continue
+		}
+		if ok && (f.Props["exported"] != exported || f.Props["symbol_kind"] != facts.SymbolFunc) {
+			t.Errorf("%s props = %v, want exported %v", name, f.Props, exported)
+		}
+	}
+
+	var fetches int
+	for _, f := range ff {
+		if f.Name == "MyApp.Accounts.fetch/1" {
+			fetches++
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("fetch/1 emitted %d times, want once for all its clauses", fetches)
+	}
+	if f, _ := findFact(ff, facts.KindSymbol, "MyApp.Accounts.__using__/1"); f.Props["macro"] != true {
+		t.Errorf("__using__ should be marked as a macro: %v", f.Props)
+	}
+	if _, ok := findFact(ff, facts.KindSymbol, "MyApp.Accounts.Helpers"); !ok {
+		t.Error("expected nested module MyApp.Accounts.Helpers")
+	}
+}
+
+func TestExtractFile_DirectivesResolveAliases(t *testing.T) {
+	ff := extractSource(`defmodule MyAppWeb.UserController do
+  use MyAppWeb, :controller
+  alias MyApp.{Accounts, Billing.Invoice}
+  alias MyApp.Accounts.User, as: Account
+  import Ecto.Query, only: [from: 2]
+  require Logger
+  alias __MODULE__.Params
+  import Accounts
+  use Account
+end
+`)
+
+	var targets []string
+	for _, f := range ff {
+		if f.Kind == facts.KindDependency {
+			targets = append(targets, f.Props["import_kind"].(string)+" "+f.Props["module"].(string))
+		}
+	}
+	want := []string{
+		"use MyAppWeb",
+		"alias MyApp.Accounts",
+		"alias MyApp.Billing.Invoice",
+		"alias MyApp.Accounts.User",
+		"import Ecto.Query",
+		"require Logger",
+		"alias MyAppWeb.UserController.Params",
+		"import MyApp.Accounts",
+		"use MyApp.Accounts.User",
+	}
+	if !slices.Equal(targets, want) {
+		t.Errorf("directives =\n%q\nwant\n%q", targets, want)
+	}
+}
+
+// --- Extract ---
+
+func phoenixProject() map[string]string {
+	return map[string]string{
+		"mix.exs": `defmodule MyApp.MixProject do
+  use Mix.Project
+
+  defp deps do
+    [
+      {:phoenix, "~> 1.7.10"},
+      {:ecto_sql, "~> 3.10"}
+    ]
+  end
+end
+`,
+		"lib/my_app/accounts.ex": `defmodule MyApp.Accounts do
+  alias MyApp.Accounts.User
+  alias MyApp.Repo
+
+  def get_user!(id), do: Repo.get!(User, id)
+end
+`,
+		"lib/my_app/accounts/user.ex": `defmodule MyApp.Accounts.User do
+  use Ecto.Schema
+  alias MyApp.Org.Team
+
+  schema "users" do
+    field :email, :string
+    field :name
+    field :roles, {:array, :string}
+    belongs_to :team, Team
+    has_many :posts, MyApp.Blog.Post
+
+    timestamps()
+  end
+end
+`,
+		"lib/my_app/org/team.ex": `defmodule MyApp.Org.Team do
+  use Ecto.Schema
+
+  schema "teams" do
+    field :name, :string
+  end
+end
+`,
+		"lib/my_app/repo.ex": `defmodule MyApp.Repo do
+  use Ecto.Repo, otp_app: :my_app
+end
+`,
+		"lib/my_app_web/router.ex": `defmodule MyAppWeb.Router do
+  use MyAppWeb, :router
+
+  pipeline :browser do
+    plug :accepts, ["html"]
+  end
+
+  pipeline :api do
+    plug :accepts, ["json"]
+  end
+
+  scope "/", MyAppWeb do
+    pipe_through :browser
+
+    get "/", PageController, :home
+    live "/users/:id", UserLive.Show, :show
+  end
+
+  scope "/api", MyAppWeb.Api, as: :api do
+    pipe_through [:api]
+
+    resources "/users", UserController, only: [:index, :show] do
+      resources "/posts", PostController, except: [:delete, :edit, :new, :update]
+    end
+    post "/login", SessionController, :create
+  end
+
+  forward "/graphql", Absinthe.Plug
+end
+`,
+		"lib/my_app_web/controllers/page_controller.ex": `defmodule MyAppWeb.PageController do
+  use MyAppWeb, :controller
+  alias MyApp.Accounts
+
+  def home(conn, _params) do
+    render(conn, :home)
+  end
+
+  defp helper(conn, _params), do: conn
+end
+`,
+	}
+}
+
+func TestDetect(t *testing.T) {
+	if ok, _ := New().Detect(setupRepo(t, map[string]string{"mix.exs": "defmodule X.MixProject do\nend\n"})); !ok {
+		t.Error("Detect should be true with a mix.exs")
+	}
+	if ok, _ := New().Detect(setupRepo(t, map[string]string{"lib/x.ex": "defmodule X do\nend\n"})); ok {
+		t.Error("Detect should be false without a mix.exs")
+	}
+}
+
+func TestExtract_Phoenix(t *testing.T) {
+	ff := extractAll(t, phoenixProject())
+
+	// Controller actions are entry points; private functions are not.
+	home, ok := findFact(ff, facts.KindSymbol, "MyAppWeb.PageController.home/2")
+	if !ok || home.Props["entry_point"] != facts.EntryControllerAction || home.Props["framework"] != "phoenix" {
+		t.Errorf("home/2 = %+v", home)
+	}
+	if helper, _ := findFact(ff, facts.KindSymbol, "MyAppWeb.PageController.helper/2"); helper.Props["exported"] != false || helper.Props["entry_point"] != nil {
+		t.Errorf("helper/2 = %+v", helper)
+	}
+
+	// Routes, with scope paths, aliases and pipelines applied.
+	tests := []struct {
+		method, path, handler, calls string
+		pipelines                    []string
+	}{
+		{"GET", "/", "MyAppWeb.PageController.home", "MyAppWeb.PageController.home/2", []string{"browser"}},
+		{"GET", "/users/:id", "MyAppWeb.UserLive.Show", "MyAppWeb.UserLive.Show.mount/3", []string{"browser"}},
+		{"GET", "/api/users", "MyAppWeb.Api.UserController.index", "MyAppWeb.Api.UserController.index/2", []string{"api"}},
+		{"GET", "/api/users/:id", "MyAppWeb.Api.UserController.show", "MyAppWeb.Api.UserController.show/2", []string{"api"}},
+		{"GET", "/api/users/:user_id/posts", "MyAppWeb.Api.PostController.index", "MyAppWeb.Api.PostController.index/2", []string{"api"}},
+		{"POST", "/api/users/:user_id/posts", "MyAppWeb.Api.PostController.create", "MyAppWeb.Api.PostController.create/2", []string{"api"}},
+		{"GET", "/api/users/:user_id/posts/:id", "MyAppWeb.Api.PostController.show", "MyAppWeb.Api.PostController.show/2", []string{"api"}},
+		{"POST", "/api/login", "MyAppWeb.Api.SessionController.create", "MyAppWeb.Api.SessionController.create/2", []string{"api"}},
+		{"FORWARD", "/graphql", "Absinthe.Plug", "", nil},
+	}
+	var routes int
+	for _, f := range ff {
+		if f.Kind == facts.KindRoute {
+			routes++
+		}
+	}
+	if routes != len(tests) {
+		t.Errorf("got %d routes, want %d", routes, len(tests))
+	}
+	for _, tt := range tests {
+		r, ok := findRoute(ff, tt.method, tt.path)
+		if !ok {
+			t.Errorf("missing route %s %s", tt.method, tt.path)
+			continue
+		}
+		if r.Props["handler"] != tt.handler || r.Props["framework"] != "phoenix" || r.File != "lib/my_app_web/router.ex" {
+			t.Errorf("%s %s props = %v", tt.method, tt.path, r.Props)
+		}
+		if tt.calls != "" && !hasRel(r, facts.RelCalls, tt.calls) {
+			t.Errorf("%s %s should call %s, relations %v", tt.method, tt.path, tt.calls, r.Relations)
+		}
+		if got, _ := r.Props["pipe_through"].([]string); !slices.Equal(got, tt.pipelines) {
+			t.Errorf("%s %s pipe_through = %v, want %v", tt.method, tt.path, got, tt.pipelines)
+		}
+	}
+	if r, _ := findRoute(ff, "GET", "/users/:id"); r.Props["live"] != true || r.Props["action"] != "show" {
+		t.Errorf("live route props = %v", r.Props)
+	}
+
+	// Ecto schemas.
+	users, ok := findFact(ff, facts.KindStorage, "MyApp.Accounts.User")
+	if !ok {
+		t.Fatal("expected storage fact MyApp.Accounts.User")
+	}
+	if users.Props["table"] != "users" || users.Props["framework"] != "ecto" || users.Line != 5 {
+		t.Errorf("users storage = %+v", users)
+	}
+	fields, _ := users.Props["fields"].([]map[string]any)
+	var got []string
+	for _, f := range fields {
+		got = append(got, f["name"].(string)+":"+f["type"].(string))
+	}
+	if want := []string{"email:string", "name:string", "roles:{:array, :string}"}; !slices.Equal(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+	if !hasRel(users, facts.RelDependsOn, "MyApp.Org.Team") || !hasRel(users, facts.RelDependsOn, "MyApp.Blog.Post") {
+		t.Errorf("users relations = %v", users.Relations)
+	}
+
+	// Directives: internal modules resolve to directories, same-directory
+	// ones are dropped, and the others keep their module name.
+	deps := make(map[string]string)
+	for _, f := range ff {
+		if f.Kind == facts.KindDependency && f.File != "mix.exs" && !strings.HasPrefix(f.File, "lib/my_app_web/router") &&
+			f.File != "lib/my_app/org/team.ex" && f.File != "lib/my_app/repo.ex" {
+			deps[f.Name] = f.Props["source"].(string)
+		}
+	}
+	want := map[string]string{
+		"lib/my_app/accounts -> Ecto.Schema":       "external",
+		"lib/my_app/accounts -> lib/my_app/org":    "internal",
+		"lib/my_app -> lib/my_app/accounts":        "internal",
+		"lib/my_app_web/controllers -> lib/my_app": "internal",
+		"lib/my_app_web/controllers -> MyAppWeb":   "external",
+	}
+	if len(deps) != len(want) {
+		t.Errorf("dependencies = %v, want %v", deps, want)
+	}
+	for name, source := range want {
+		if deps[name] != source {
+			t.Errorf("dependency %q source = %q, want %q (all: %v)", name, deps[name], source, deps)
+		}
+	}
+
+	// Module facts per directory.
+	if mod, ok := findFact(ff, facts.KindModule, "lib/my_app_web"); !ok || mod.Props["language"] != "elixir" || mod.Props["framework"] != "phoenix" {
+		t.Errorf("module lib/my_app_web = %+v", mod)
+	}
+}
@@ -0,0 +1,290 @@
+package elixirextractor
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// Router DSL regex patterns.
+var (
+	routerUseRe = regexp.MustCompile(`^\s*use\s+(?:Phoenix\.Router\b|[\w.]+\s*,\s*:router\b)`)
+	// scope "/api", MyAppWeb.Api, as: :api do. Captures: path (group 1),
+	// alias (group 2).
+	scopeRe = regexp.MustCompile(`^\s*scope\b\s*\(?\s*(?:"([^"]*)")?\s*,?\s*([A-Z][\w.]*)?`)
+	// get "/users/:id", UserController, :show. Captures: verb (group 1),
+	// path (group 2), controller or plug (group 3), action (group 4).
+	verbRe        = regexp.MustCompile(`^\s*(get|post|put|patch|delete|options|head|live|forward)\s*\(?\s*"([^"]*)"\s*,\s*([A-Z][\w.]*)(?:\s*,\s*:(\w+))?`)
+	resourcesRe   = regexp.MustCompile(`^\s*resources\s*\(?\s*"([^"]*)"\s*,\s*([A-Z][\w.]*)`)
+	pipeThroughRe = regexp.MustCompile(`^\s*pipe_through\s*\(?\s*(.+)$`)
+	onlyRe        = regexp.MustCompile(`\bonly:\s*\[([^\]]*)\]`)
+	exceptRe      = regexp.MustCompile(`\bexcept:\s*\[([^\]]*)\]`)
+	singletonRe   = regexp.MustCompile(`\bsingleton:\s*true\b`)
+	atomRe        = regexp.MustCompile(`:(\w+)`)
+)
+
+// routerScope is an open block of a router: a scope or resources block
+// with the path and alias it adds, or any other block, which adds none.
+type routerScope struct {
+	path      string
+	nestParam string // /:user_id for the routes nested in a resource
+	alias     string
+	pipelines []string
+}
+
+// restAction describes a single RESTful action of a resources declaration.
+type restAction struct {
+	name   string
+	method string
+	suffix string
+}
+
+// phoenixActions are the routes of resources, in the order Phoenix defines
+// them; update answers both PATCH and PUT.
+var phoenixActions = []restAction{
+	{name: "index", method: "GET", suffix: ""},
+	{name: "edit", method: "GET", suffix: "/:id/edit"},
+	{name: "new", method: "GET", suffix: "/new"},
+	{name: "show", method: "GET", suffix: "/:id"},
+	{name: "create", method: "POST", suffix: ""},
+	{name: "update", method: "PATCH", suffix: "/:id"},
+	{name: "update", method: "PUT", suffix: "/:id"},
+	{name: "delete", method: "DELETE", suffix: "/:id"},
+}
+
+// isRouterFile reports whether a file defines a Phoenix router.
+func isRouterFile(codes []string) bool {
+	for _, code := range codes {
+		if routerUseRe.MatchString(code) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractRoutes parses the Phoenix router DSL of a file into route facts
+// named after their full path. Scope paths and aliases nest, so
+// get "/users", UserController, :index in scope "/api", MyAppWeb becomes
+// /api/users handled by MyAppWeb.UserController.index, with a calls
+// relation to the action (MyAppWeb.UserController.index/2). LiveView
+// routes (live) are GET routes with live: true whose handler is the
+// LiveView's mount/3; forward routes have method FORWARD and the plug as
+// handler. The pipelines of the enclosing scopes are listed in pipe_through.
+func extractRoutes(relFile string, lines, codes []string) []facts.Fact {
+	var result []facts.Fact
+	dir := filepath.Dir(relFile)
+
+	var stack []routerScope
+	for i, code := range codes {
+		lineNum := i + 1
+		line := lines[i]
+		opens, closes := blockDelta(code)
+		var opened *routerScope
+
+		switch {
+		case strings.TrimSpace(code) == "":
+
+		case routerUseRe.MatchString(code):
+
+		case scopeRe.MatchString(code):
+			m := scopeRe.FindStringSubmatch(line)
+			opened = &routerScope{path: m[1], alias: m[2]}
+
+		case pipeThroughRe.MatchString(code):
+			// Pipelines apply to the rest of the enclosing scope.
+			if len(stack) > 0 {
+				for _, a := range atomRe.FindAllStringSubmatch(pipeThroughRe.FindStringSubmatch(code)[1], -1) {
+					stack[len(stack)-1].pipelines = append(stack[len(stack)-1].pipelines, a[1])
+				}
+			}
+
+		case resourcesRe.MatchString(line):
+			m := resourcesRe.FindStringSubmatch(line)
+			path, controller := m[1], routeAlias(stack, m[2])
+			singleton := singletonRe.MatchString(line)
+			prefix := routePrefix(stack)
+			for _, action := range resourceActions(line, singleton) {
+				suffix := action.suffix
+				if singleton {
+					suffix = strings.TrimPrefix(suffix, "/:id")
+				}
+				handler := controller + "." + action.name
+				props := routeProps(stack, action.method, handler)
+				props["resource"] = strings.Trim(path, "/")
+				props["action"] = action.name
+				result = append(result, facts.Fact{
+					Kind:      facts.KindRoute,
+					Name:      joinRoutePath(prefix, path+suffix),
+					File:      relFile,
+					Line:      lineNum,
+					Props:     props,
+					Relations: routeRelations(dir, handler+"/2"),
+				})
+			}
+			opened = &routerScope{path: path}
+			if !singleton {
+				opened.nestParam = "/:" + resourceParam(controller) + "_id"
+			}
+
+		case verbRe.MatchString(line):
+			m := verbRe.FindStringSubmatch(line)
+			verb, path, target, action := m[1], m[2], routeAlias(stack, m[3]), m[4]
+			method, handler, calls := strings.ToUpper(verb), target, ""
+			switch verb {
+			case "live":
+				method, calls = "GET", target+".mount/3"
+			case "forward":
+			default:
+				handler = target + "." + action
+				calls = handler + "/2"
+			}
+			props := routeProps(stack, method, handler)
+			if verb == "live" {
+				props["live"] = true
+			}
+			if action != "" {
+				props["action"] = action
+			}
+			result = append(result, facts.Fact{
+				Kind:      facts.KindRoute,
+				Name:      joinRoutePath(routePrefix(stack), path),
+				File:      relFile,
+				Line:      lineNum,
+				Props:     props,
+				Relations: routeRelations(dir, calls),
+			})
+		}
+
+		// Keep the stack aligned with the block depth: the first block a
+		// scope or resources line opens is its own, any other is empty.
+		for n := 0; n < opens; n++ {
+			if opened != nil {
+				stack = append(stack, *opened)
+				opened = nil
+			} else {
+				stack = append(stack, routerScope{})
+			}
+		}
+		for n := 0; n < closes && len(stack) > 0; n++ {
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	return result
+}
+
+// routeProps returns the props shared by the routes of a router.
+func routeProps(stack []routerScope, method, handler string) map[string]any {
+	props := map[string]any{
+		"method":    method,
+		"framework": "phoenix",
+		"language":  "elixir",
+		"handler":   handler,
+	}
+	var pipelines []string
+	for _, s := range stack {
+		pipelines = append(pipelines, s.pipelines...)
+	}
+	if len(pipelines) > 0 {
+		props["pipe_through"] = pipelines
+	}
+	return props
+}
+
+// routeRelations returns the relations of a route fact: the declaring
+// directory, and the handler function when there is one.
+func routeRelations(dir, handler string) []facts.Relation {
+	rels := []facts.Relation{{Kind: facts.RelDeclares, Target: dir}}
+	if handler != "" {
+		rels = append(rels, facts.Relation{Kind: facts.RelCalls, Target: handler})
+	}
+	return rels
+}
+
+// routePrefix returns the path the open scopes and resources add.
+func routePrefix(stack []routerScope) string {
+	prefix := ""
+	for _, s := range stack {
+		if s.path != "" {
+			prefix = joinRoutePath(prefix, s.path)
+		}
+		prefix += s.nestParam
+	}
+	return prefix
+}
+
+// routeAlias qualifies a controller or plug with the aliases of the open
+// scopes: UserController in scope "/", MyAppWeb becomes
+// MyAppWeb.UserController.
+func routeAlias(stack []routerScope, name string) string {
+	var parts []string
+	for _, s := range stack {
+		if s.alias != "" {
+			parts = append(parts, s.alias)
+		}
+	}
+	return strings.Join(append(parts, name), ".")
+}
+
+// joinRoutePath joins a scope prefix and a route path, so "/" and "/users"
+// give "/users" and "/api" and "/" give "/api".
+func joinRoutePath(prefix, path string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	path = strings.Trim(path, "/")
+	if path != "" {
+		prefix += "/" + path
+	}
+	if prefix == "" {
+		return "/"
+	}
+	return prefix
+}
+
+// resourceActions returns the actions of a resources declaration, filtered
+// by its only: or except: option. A singleton resource has no index.
+func resourceActions(line string, singleton bool) []restAction {
+	var only, except map[string]bool
+	if m := onlyRe.FindStringSubmatch(line); m != nil {
+		only = atomSet(m[1])
+	}
+	if m := exceptRe.FindStringSubmatch(line); m != nil {
+		except = atomSet(m[1])
+	}
+	var result []restAction
+	for _, a := range phoenixActions {
+		if (singleton && a.name == "index") || (only != nil && !only[a.name]) || except[a.name] {
+			continue
+		}
+		result = append(result, a)
+	}
+	return result
+}
+
+// atomSet returns the names of the atoms in s (":index, :show").
+func atomSet(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, m := range atomRe.FindAllStringSubmatch(s, -1) {
+		set[m[1]] = true
+	}
+	return set
+}
+
+// resourceParam returns the parameter name Phoenix derives from a
+// controller for nested routes: MyAppWeb.UserPostController gives
+// "user_post".
+func resourceParam(controller string) string {
+	name := strings.TrimSuffix(controller[strings.LastIndex(controller, ".")+1:], "Controller")
+	var sb strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				sb.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,77 @@
+package elixirextractor
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+var (
+	// field :email, :string. Captures: name (group 1), type (group 2).
+	schemaFieldRe = regexp.MustCompile(`^\s*field\s*\(?\s*:(\w+)\s*(?:,\s*(\{[^}]*\}|[\w.:]+))?`)
+	// belongs_to :user, MyApp.Accounts.User. Captures: kind (group 1),
+	// name (group 2), schema (group 3).
+	schemaAssocRe = regexp.MustCompile(`^\s*(belongs_to|has_one|has_many|many_to_many|embeds_one|embeds_many)\s*\(?\s*:(\w+)\s*,\s*` + moduleName)
+)
+
+// ectoSchema returns the storage fact of the Ecto schema block opening at
+// line start of the module scope, for the table it maps ("users"). The
+// fact is named after the module, so it sits next to the module's symbol.
+// Fields are listed in a fields prop (name and type; Ecto defaults the type
+// to string), and associations become depends_on relations to the
+// associated schema's module, with aliases resolved.
+func ectoSchema(scope *moduleScope, relFile, table string, lines, codes []string, start int) facts.Fact {
+	f := facts.Fact{
+		Kind: facts.KindStorage,
+		Name: scope.name,
+		File: relFile,
+		Line: start + 1,
+		Props: map[string]any{
+			"storage_kind": "model",
+			"table":        table,
+			"language":     "elixir",
+			"framework":    "ecto",
+		},
+		Relations: []facts.Relation{
+			{Kind: facts.RelDeclares, Target: filepath.Dir(relFile)},
+		},
+	}
+
+	var fields []map[string]any
+	var assocs []map[string]any
+	depth := 0
+	for i := start; i < len(codes); i++ {
+		opens, closes := blockDelta(codes[i])
+		depthBefore := depth
+		depth += opens - closes
+		if i > start && depth <= 0 {
+			break
+		}
+		// Only the lines directly in the schema block, not in nested blocks.
+		if depthBefore != 1 || strings.TrimSpace(codes[i]) == "" {
+			continue
+		}
+		if m := schemaFieldRe.FindStringSubmatch(lines[i]); m != nil {
+			typ := strings.TrimPrefix(m[2], ":")
+			if typ == "" {
+				typ = "string"
+			}
+			fields = append(fields, map[string]any{"name": m[1], "type": typ})
+			continue
+		}
+		if m := schemaAssocRe.FindStringSubmatch(lines[i]); m != nil {
+			target := scope.resolve(m[3])
+			assocs = append(assocs, map[string]any{"kind": m[1], "name": m[2], "schema": target})
+			f.Relations = append(f.Relations, facts.Relation{Kind: facts.RelDependsOn, Target: target})
+		}
+	}
+	if len(fields) > 0 {
+		f.Props["fields"] = fields
+	}
+	if len(assocs) > 0 {
+		f.Props["associations"] = assocs
+	}
+	return f
+}
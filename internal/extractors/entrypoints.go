@@ -0,0 +1,33 @@
+package extractors
+
+import (
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// MarkEntryPoints sets the language-agnostic entry_point props on symbols
+// in ff that no extractor has classified: main functions, and exported
+// functions whose name suggests a request handler ("handle", "serve").
+// Framework-specific entry points, such as Rails actions or Cobra commands,
+// are set by the extractors themselves.
+func MarkEntryPoints(ff []facts.Fact) {
+	for i := range ff {
+		f := &ff[i]
+		if f.Kind != facts.KindSymbol || f.Props["symbol_kind"] != facts.SymbolFunc {
+			continue
+		}
+		if _, ok := f.Props["entry_point"]; ok {
+			continue
+		}
+		if strings.HasSuffix(f.Name, ".main") {
+			f.Props["entry_point"] = facts.EntryMain
+			continue
+		}
+		exported, _ := f.Props["exported"].(bool)
+		nameLower := strings.ToLower(f.Name)
+		if exported && (strings.Contains(nameLower, "handle") || strings.Contains(nameLower, "serve")) {
+			f.Props["entry_point"] = facts.EntryHandler
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package extractors
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// EnvFactPrefix prefixes the names of environment variable config facts
+// ("env:DATABASE_URL").
+const EnvFactPrefix = "env:"
+
+// EnvVars collects the environment variable reads of an extraction and
+// emits one config fact per distinct variable. The zero value is ready to
+// use.
+type EnvVars struct {
+	reads map[string][]configRead // variable -> reads, in the order seen
+}
+
+// configRead is a location where a config fact's value is read.
+type configRead struct {
+	file string
+	line int
+}
+
+// Add records a read of the environment variable name at relFile:line.
+func (v *EnvVars) Add(name, relFile string, line int) {
+	if name == "" {
+		return
+	}
+	if v.reads == nil {
+		v.reads = make(map[string][]configRead)
+	}
+	v.reads[name] = append(v.reads[name], configRead{file: relFile, line: line})
+}
+
+// Scan records the environment variable reads of a source file matched by
+// patterns, whose first capture group is the variable name. Lines for which
+// isComment reports true are skipped; it is given the trimmed line and is
+// the comment rule of the file's language (IsCommentLine for C-family
+// languages, IsHashCommentLine for Ruby).
+func (v *EnvVars) Scan(relFile string, src []byte, isComment func(trimmed string) bool, patterns ...*regexp.Regexp) {
+	for i, line := range strings.Split(string(src), "\n") {
+		if isComment(strings.TrimSpace(line)) {
+			continue
+		}
+		for _, re := range patterns {
+			for _, m := range re.FindAllStringSubmatch(line, -1) {
+				v.Add(m[1], relFile, i+1)
+			}
+		}
+	}
+}
+
+// Facts returns a config fact per variable read, named with EnvFactPrefix
+// and located at its first read. Props["reads"] lists every read as
+// "file:line", and a read_by relation points at the module (directory) of
+// each reading file.
+func (v *EnvVars) Facts() []facts.Fact {
+	return configFacts(v.reads, func(name string) facts.Fact {
+		return facts.Fact{
+			Kind: facts.KindConfig,
+			Name: EnvFactPrefix + name,
+			Props: map[string]any{
+				"source":   "env",
+				"variable": name,
+			},
+		}
+	})
+}
+
+// configFacts returns a fact per key of reads, sorted by key, built by
+// newFact and completed with the location of the first read, a reads prop
+// listing every read as "file:line", and a read_by relation per reading
+// module (directory).
+func configFacts(reads map[string][]configRead, newFact func(name string) facts.Fact) []facts.Fact {
+	names := make([]string, 0, len(reads))
+	for name := range reads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]facts.Fact, 0, len(names))
+	for _, name := range names {
+		reads := reads[name]
+		sort.SliceStable(reads, func(i, j int) bool {
+			if reads[i].file != reads[j].file {
+				return reads[i].file < reads[j].file
+			}
+			return reads[i].line < reads[j].line
+		})
+		f := newFact(name)
+		f.File = reads[0].file
+		f.Line = reads[0].line
+		var locations []string
+		seen := make(map[string]bool)
+		for _, r := range reads {
+			loc := fmt.Sprintf("%s:%d", r.file, r.line)
+			if seen[loc] {
+				continue
+			}
+			seen[loc] = true
+			locations = append(locations, loc)
+			if dir := filepath.Dir(r.file); !seen[dir] {
+				seen[dir] = true
+				f.Relations = append(f.Relations, facts.Relation{Kind: facts.RelReadBy, Target: dir})
+			}
+		}
+		f.Props["reads"] = locations
+		result = append(result, f)
+	}
+	return result
+}
@@ -0,0 +1,34 @@
+package extractors
+
+import "fmt"
+
+// FileError records a single file an extractor could not read or parse.
+type FileError struct {
+	File string
+	Err  error
+}
+
+// FileErrors is returned by Extract, together with the facts extracted from
+// the remaining files, when some files failed. The engine keeps those facts
+// and records each failure in the snapshot meta.
+type FileErrors []FileError
+
+func (e FileErrors) Error() string {
+	if len(e) == 1 {
+		return fmt.Sprintf("%s: %v", e[0].File, e[0].Err)
+	}
+	return fmt.Sprintf("%d files failed, first %s: %v", len(e), e[0].File, e[0].Err)
+}
+
+// Add records a failure for file.
+func (e *FileErrors) Add(file string, err error) {
+	*e = append(*e, FileError{File: file, Err: err})
+}
+
+// Err returns e as an error, or nil when no file failed.
+func (e FileErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,53 @@
+package extractors
+
+import (
+	"path/filepath"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// LinkExtensions adds a depends_on relation from each extension function in
+// ff to the type it extends, so exploring a type also shows the extensions
+// that augment it.
+//
+// Extension functions are method facts with an extension: true prop whose
+// receiver prop names the extended type. The receiver is matched by simple
+// name against the class, struct and interface facts in ff, preferring one
+// in the extension's own directory; a receiver declared outside the
+// repository (e.g. String) is linked by its bare name.
+func LinkExtensions(ff []facts.Fact) {
+	types := make(map[string][]facts.Fact)
+	for _, f := range ff {
+		if f.Kind != facts.KindSymbol {
+			continue
+		}
+		switch f.Props["symbol_kind"] {
+		case facts.SymbolClass, facts.SymbolStruct, facts.SymbolInterface:
+			short := simpleTypeName(f.Name)
+			types[short] = append(types[short], f)
+		}
+	}
+
+	for i := range ff {
+		f := &ff[i]
+		if ext, _ := f.Props["extension"].(bool); f.Kind != facts.KindSymbol || !ext {
+			continue
+		}
+		recv, _ := f.Props["receiver"].(string)
+		if recv == "" {
+			continue
+		}
+		target := recv
+		candidates := types[simpleTypeName(recv)]
+		if len(candidates) == 1 {
+			target = candidates[0].Name
+		}
+		for _, c := range candidates {
+			if filepath.Dir(c.File) == filepath.Dir(f.File) {
+				target = c.Name
+				break
+			}
+		}
+		f.Relations = append(f.Relations, facts.Relation{Kind: facts.RelDependsOn, Target: target})
+	}
+}
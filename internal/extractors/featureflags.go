@@ -0,0 +1,96 @@
+package extractors
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// FlagFactPrefix prefixes the names of feature flag config facts
+// ("flag:new-checkout").
+const FlagFactPrefix = "flag:"
+
+// FeatureFlags collects the feature flag reads of a set of source files and
+// emits one config fact per distinct flag key. A read is a call of one of
+// the configured methods whose first argument is a string literal (or a
+// Ruby symbol): client.BoolVariation("new-checkout", ...),
+// unleash.isEnabled('dark-mode'). Keys built at runtime are not followed.
+type FeatureFlags struct {
+	re      *regexp.Regexp
+	reads   map[string][]configRead    // key -> reads, in the order seen
+	methods map[string]map[string]bool // key -> methods it is read with
+}
+
+// NewFeatureFlags returns a collector for calls of methods, matched by
+// name whatever the receiver. It returns nil when methods is empty; a nil
+// collector records nothing.
+func NewFeatureFlags(methods []string) *FeatureFlags {
+	var alts []string
+	for _, m := range methods {
+		if m = strings.TrimSpace(m); m != "" {
+			alts = append(alts, regexp.QuoteMeta(m))
+		}
+	}
+	if len(alts) == 0 {
+		return nil
+	}
+	// Longer names first, so "variationDetail" is not read as "variation".
+	sort.SliceStable(alts, func(i, j int) bool { return len(alts[i]) > len(alts[j]) })
+	return &FeatureFlags{
+		re:      regexp.MustCompile(`(?:^|[^\w$])(` + strings.Join(alts, "|") + `)\s*\(\s*(?:["'` + "`" + `]([^"'` + "`" + `\s]+)["'` + "`" + `]|:(\w+))`),
+		reads:   make(map[string][]configRead),
+		methods: make(map[string]map[string]bool),
+	}
+}
+
+// Scan records the flag reads of a source file. Lines for which isComment
+// reports true, given the trimmed line, are skipped, as in EnvVars.Scan.
+func (v *FeatureFlags) Scan(relFile string, src []byte, isComment func(trimmed string) bool) {
+	if v == nil {
+		return
+	}
+	for i, line := range strings.Split(string(src), "\n") {
+		if isComment(strings.TrimSpace(line)) {
+			continue
+		}
+		for _, m := range v.re.FindAllStringSubmatch(line, -1) {
+			key := m[2]
+			if key == "" {
+				key = m[3]
+			}
+			v.reads[key] = append(v.reads[key], configRead{file: relFile, line: i + 1})
+			if v.methods[key] == nil {
+				v.methods[key] = make(map[string]bool)
+			}
+			v.methods[key][m[1]] = true
+		}
+	}
+}
+
+// Facts returns a config fact per flag key read, named with FlagFactPrefix
+// and located at its first read. Props["reads"] lists every call site as
+// "file:line", Props["methods"] the methods the flag is read with, and a
+// read_by relation points at the module (directory) of each reading file.
+func (v *FeatureFlags) Facts() []facts.Fact {
+	if v == nil {
+		return nil
+	}
+	return configFacts(v.reads, func(key string) facts.Fact {
+		methods := make([]string, 0, len(v.methods[key]))
+		for m := range v.methods[key] {
+			methods = append(methods, m)
+		}
+		sort.Strings(methods)
+		return facts.Fact{
+			Kind: facts.KindConfig,
+			Name: FlagFactPrefix + key,
+			Props: map[string]any{
+				"source":  "feature_flag",
+				"flag":    key,
+				"methods": methods,
+			},
+		}
+	})
+}
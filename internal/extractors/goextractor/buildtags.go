@@ -0,0 +1,140 @@
+package goextractor
+
+import (
+	"go/ast"
+	"go/build"
+	"go/build/constraint"
+	"path/filepath"
+	"strings"
+)
+
+// knownOS and knownArch are the GOOS and GOARCH values recognized in file
+// name suffixes (name_GOOS.go, name_GOARCH.go, name_GOOS_GOARCH.go), as
+// listed by `go tool dist list`.
+var (
+	knownOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+		"hurd": true, "illumos": true, "ios": true, "js": true, "linux": true, "nacl": true,
+		"netbsd": true, "openbsd": true, "plan9": true, "solaris": true, "wasip1": true,
+		"windows": true, "zos": true,
+	}
+	knownArch = map[string]bool{
+		"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true,
+		"arm64be": true, "loong64": true, "mips": true, "mipsle": true, "mips64": true,
+		"mips64le": true, "mips64p32": true, "mips64p32le": true, "ppc": true, "ppc64": true,
+		"ppc64le": true, "riscv": true, "riscv64": true, "s390": true, "s390x": true,
+		"sparc": true, "sparc64": true, "wasm": true,
+	}
+)
+
+// fileConstraint returns the build constraint of a parsed file as a
+// //go:build expression, or "" when the file builds everywhere. It combines
+// the file's //go:build line (or legacy // +build lines) with the GOOS and
+// GOARCH implied by its name, e.g. "linux && amd64" for x_linux_amd64.go.
+func fileConstraint(f *ast.File, relFile string) string {
+	var parts []string
+	if expr := headerConstraint(f); expr != nil {
+		s := expr.String()
+		if _, ok := expr.(*constraint.OrExpr); ok {
+			s = "(" + s + ")"
+		}
+		parts = append(parts, s)
+	}
+	parts = append(parts, nameConstraint(filepath.Base(relFile))...)
+	return strings.Join(parts, " && ")
+}
+
+// headerConstraint parses the build constraint comments above the package
+// clause. A //go:build line takes precedence over // +build lines.
+func headerConstraint(f *ast.File) constraint.Expr {
+	var plus []constraint.Expr
+	for _, group := range f.Comments {
+		if group.Pos() >= f.Package {
+			break
+		}
+		for _, c := range group.List {
+			switch {
+			case constraint.IsGoBuild(c.Text):
+				if expr, err := constraint.Parse(c.Text); err == nil {
+					return expr
+				}
+			case constraint.IsPlusBuild(c.Text):
+				if expr, err := constraint.Parse(c.Text); err == nil {
+					plus = append(plus, expr)
+				}
+			}
+		}
+	}
+	if len(plus) == 0 {
+		return nil
+	}
+	expr := plus[0]
+	for _, x := range plus[1:] {
+		expr = &constraint.AndExpr{X: expr, Y: x}
+	}
+	return expr
+}
+
+// nameConstraint returns the GOOS and GOARCH tags implied by a file name,
+// following the go command's rules: the suffixes are only recognized after
+// an underscore, and a _test suffix is ignored.
+func nameConstraint(name string) []string {
+	name = strings.TrimSuffix(name, ".go")
+	name = strings.TrimSuffix(name, "_test")
+	parts := strings.Split(name, "_")
+	n := len(parts)
+	if n >= 3 && knownOS[parts[n-2]] && knownArch[parts[n-1]] {
+		return []string{parts[n-2], parts[n-1]}
+	}
+	if n >= 2 && (knownOS[parts[n-1]] || knownArch[parts[n-1]]) {
+		return []string{parts[n-1]}
+	}
+	return nil
+}
+
+// buildContext returns the build context used to scope extraction to a
+// target platform, or nil when no target is configured. Unset fields keep
+// the host defaults, as with the go command.
+func buildContext(goos, goarch string, tags []string) *build.Context {
+	if goos == "" && goarch == "" && len(tags) == 0 {
+		return nil
+	}
+	ctx := build.Default
+	if goos != "" {
+		ctx.GOOS = goos
+	}
+	if goarch != "" {
+		ctx.GOARCH = goarch
+	}
+	if ctx.GOOS != build.Default.GOOS || ctx.GOARCH != build.Default.GOARCH {
+		// The go command disables cgo when cross-compiling.
+		ctx.CgoEnabled = false
+	}
+	ctx.BuildTags = tags
+	return &ctx
+}
+
+// inTarget reports whether relFile is part of the configured target
+// platform. Every file is in the target when none is configured.
+func (e *GoExtractor) inTarget(repoPath, relFile string) bool {
+	if e.buildCtx == nil {
+		return true
+	}
+	ok, err := e.buildCtx.MatchFile(filepath.Join(repoPath, filepath.Dir(relFile)), filepath.Base(relFile))
+	// Unreadable files are kept so the parse reports the error.
+	return ok || err != nil
+}
+
+// filterTarget returns the files of files that are in the target platform.
+func (e *GoExtractor) filterTarget(repoPath string, files []string) []string {
+	if e.buildCtx == nil {
+		return files
+	}
+	var kept []string
+	for _, f := range files {
+		if e.inTarget(repoPath, f) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,138 @@
+package goextractor
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// coverBlock is one statement block from a Go coverage profile.
+type coverBlock struct {
+	startLine int
+	endLine   int
+	stmts     int
+	covered   bool
+}
+
+// coverageProfile maps repo-relative file paths to their coverage blocks.
+type coverageProfile map[string][]coverBlock
+
+// loadCoverageProfile parses a profile written by `go test -coverprofile`.
+// File names in the profile are import-path qualified; they are converted to
+// repo-relative paths using modulePath. Blocks listed more than once (e.g. when
+// profiles are concatenated) are merged, counting as covered if any run hit them.
+func loadCoverageProfile(path, repoPath, modulePath string) (coverageProfile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	type blockKey struct {
+		file string
+		pos  string
+	}
+	index := make(map[blockKey]int)
+	profile := make(coverageProfile)
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+
+		// Format: name.go:line.column,line.column numberOfStatements count
+		colon := strings.LastIndex(line, ":")
+		if colon < 0 {
+			return nil, fmt.Errorf("%s:%d: malformed coverage line", path, lineNo)
+		}
+		fields := strings.Fields(line[colon+1:])
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%s:%d: malformed coverage line", path, lineNo)
+		}
+		start, end, ok := parseBlockRange(fields[0])
+		stmts, err1 := strconv.Atoi(fields[1])
+		count, err2 := strconv.Atoi(fields[2])
+		if !ok || err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("%s:%d: malformed coverage line", path, lineNo)
+		}
+
+		file := coverageFilePath(line[:colon], repoPath, modulePath)
+		key := blockKey{file, fields[0]}
+		if i, seen := index[key]; seen {
+			profile[file][i].covered = profile[file][i].covered || count > 0
+			continue
+		}
+		index[key] = len(profile[file])
+		profile[file] = append(profile[file], coverBlock{
+			startLine: start,
+			endLine:   end,
+			stmts:     stmts,
+			covered:   count > 0,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// parseBlockRange parses "12.5,14.2" into start and end lines.
+func parseBlockRange(s string) (start, end int, ok bool) {
+	from, to, found := strings.Cut(s, ",")
+	if !found {
+		return 0, 0, false
+	}
+	startLine, _, _ := strings.Cut(from, ".")
+	endLine, _, _ := strings.Cut(to, ".")
+	var err1, err2 error
+	start, err1 = strconv.Atoi(startLine)
+	end, err2 = strconv.Atoi(endLine)
+	return start, end, err1 == nil && err2 == nil
+}
+
+// coverageFilePath converts a profile file name to a repo-relative path.
+func coverageFilePath(name, repoPath, modulePath string) string {
+	if modulePath != "" && strings.HasPrefix(name, modulePath+"/") {
+		return strings.TrimPrefix(name, modulePath+"/")
+	}
+	if filepath.IsAbs(name) {
+		if rel, err := filepath.Rel(repoPath, name); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return strings.TrimPrefix(filepath.ToSlash(name), "./")
+}
+
+// funcCoverage reports coverage for the statements between startLine and
+// endLine of relFile. Files missing from the profile belong to packages
+// without tests and count as uncovered. ok is false when the range contains
+// no statements, e.g. for an empty function body.
+func (p coverageProfile) funcCoverage(relFile string, startLine, endLine int) (covered bool, pct float64, ok bool) {
+	blocks, inProfile := p[filepath.ToSlash(relFile)]
+	if !inProfile {
+		return false, 0, true
+	}
+	total, hit := 0, 0
+	for _, b := range blocks {
+		if b.startLine < startLine || b.endLine > endLine {
+			continue
+		}
+		total += b.stmts
+		if b.covered {
+			hit += b.stmts
+		}
+	}
+	if total == 0 {
+		return false, 0, false
+	}
+	pct = math.Round(float64(hit)/float64(total)*1000) / 10
+	return hit > 0, pct, true
+}
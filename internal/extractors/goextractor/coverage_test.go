@@ -0,0 +1,112 @@
+package goextractor
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+const coverageSrc = `package svc
+
+func Covered(x int) int {
+	if x > 0 {
+		return x
+	}
+	return -x
+}
+
+func Uncovered() int {
+	y := 1
+	return y
+}
+
+func Empty() {}
+`
+
+func TestExtract_CoverageProfile(t *testing.T) {
+	// Covered: lines 3-8, the x > 0 branch ran but the negative return did not.
+	profile := `mode: set
+testmod/pkg/svc/svc.go:3.25,4.11 1 1
+testmod/pkg/svc/svc.go:4.11,6.3 1 1
+testmod/pkg/svc/svc.go:7.2,7.11 1 0
+testmod/pkg/svc/svc.go:10.24,13.2 2 0
+`
+	dir := setupGoProject(t, map[string]string{
+		"pkg/svc/svc.go":     coverageSrc,
+		"pkg/other/other.go": "package other\n\nfunc Untested() int {\n\treturn 1\n}\n",
+		"coverage/cover.out": profile,
+	})
+
+	e := NewWithOptions(Options{CoverageProfile: "coverage/cover.out"})
+	ff, err := e.Extract(context.Background(), dir, []string{"pkg/svc/svc.go", "pkg/other/other.go"})
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		covered bool
+		pct     float64
+	}{
+		{"pkg/svc.Covered", true, 66.7},
+		{"pkg/svc.Uncovered", false, 0},
+		// Files missing from the profile belong to untested packages.
+		{"pkg/other.Untested", false, 0},
+	}
+	for _, tt := range tests {
+		f, ok := findFact(ff, tt.name)
+		if !ok {
+			t.Fatalf("expected fact %s", tt.name)
+		}
+		if f.Props["covered"] != tt.covered || f.Props["coverage_pct"] != tt.pct {
+			t.Errorf("%s: covered=%v coverage_pct=%v, want %v %v",
+				tt.name, f.Props["covered"], f.Props["coverage_pct"], tt.covered, tt.pct)
+		}
+	}
+
+	// Functions without statements are not annotated.
+	if f, _ := findFact(ff, "pkg/svc.Empty"); f.Props["covered"] != nil {
+		t.Errorf("Empty should not carry coverage, got %v", f.Props["covered"])
+	}
+}
+
+func TestExtract_MissingCoverageProfile(t *testing.T) {
+	dir := setupGoProject(t, map[string]string{"pkg/svc/svc.go": coverageSrc})
+
+	e := NewWithOptions(Options{CoverageProfile: "missing.out"})
+	ff, err := e.Extract(context.Background(), dir, []string{"pkg/svc/svc.go"})
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if f, _ := findFact(ff, "pkg/svc.Covered"); f.Props["covered"] != nil {
+		t.Errorf("expected no coverage props without a profile, got %v", f.Props["covered"])
+	}
+}
+
+func TestLoadCoverageProfile_MergesDuplicateBlocks(t *testing.T) {
+	dir := setupGoProject(t, map[string]string{"cover.out": `mode: count
+testmod/a/a.go:3.10,5.2 2 0
+testmod/a/a.go:3.10,5.2 2 4
+`})
+	path := filepath.Join(dir, "cover.out")
+
+	cov, err := loadCoverageProfile(path, dir, "testmod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cov["a/a.go"]) != 1 {
+		t.Fatalf("expected merged block for a/a.go, got %v", cov)
+	}
+	if covered, pct, ok := cov.funcCoverage("a/a.go", 3, 5); !ok || !covered || pct != 100 {
+		t.Errorf("funcCoverage = %v %v %v, want covered at 100%%", covered, pct, ok)
+	}
+}
+
+func TestLoadCoverageProfile_Malformed(t *testing.T) {
+	dir := setupGoProject(t, map[string]string{"cover.out": "mode: set\nnot a coverage line\n"})
+	path := filepath.Join(dir, "cover.out")
+
+	if _, err := loadCoverageProfile(path, dir, "testmod"); err == nil {
+		t.Error("expected an error for a malformed profile")
+	}
+}
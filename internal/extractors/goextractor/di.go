@@ -0,0 +1,204 @@
+package goextractor
+
+import (
+	"go/ast"
+	"go/types"
+	"slices"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// diFrameworks maps the import paths of the supported DI frameworks to
+// their names.
+var diFrameworks = map[string]string{
+	"github.com/google/wire": "wire",
+	"go.uber.org/fx":         "fx",
+}
+
+// diProviderFuncs lists, per framework, the calls whose arguments are
+// provider functions: wire provider sets and injectors, and fx options.
+var diProviderFuncs = map[string][]string{
+	"wire": {"NewSet", "Build"},
+	"fx":   {"Provide", "Invoke", "Decorate"},
+}
+
+// diSignature records the types a top-level function consumes (parameters)
+// and produces (results other than error), as qualified type names.
+type diSignature struct {
+	consumes []string
+	produces []string
+}
+
+// diRef is a provider function referenced by a DI call.
+type diRef struct {
+	provider  string // qualified function name
+	framework string // "wire" or "fx"
+}
+
+// diIndex collects what linkProviders needs across the packages of one
+// extraction: the signature of every top-level function and the provider
+// references found in DI calls.
+type diIndex struct {
+	sigs map[string]diSignature
+	refs []diRef
+}
+
+func newDIIndex() *diIndex {
+	return &diIndex{sigs: make(map[string]diSignature)}
+}
+
+// addFunc records the signature of a top-level function.
+func (x *diIndex) addFunc(fn *ast.FuncDecl, pkgDir string, imports map[string]string) {
+	if fn.Recv != nil {
+		return
+	}
+	var sig diSignature
+	if fn.Type.Params != nil {
+		for _, p := range fn.Type.Params.List {
+			if name := diTypeName(p.Type, pkgDir, imports); name != "" {
+				sig.consumes = append(sig.consumes, name)
+			}
+		}
+	}
+	if fn.Type.Results != nil {
+		for _, r := range fn.Type.Results.List {
+			if name := diTypeName(r.Type, pkgDir, imports); name != "" {
+				sig.produces = append(sig.produces, name)
+			}
+		}
+	}
+	x.sigs[pkgDir+"."+fn.Name.Name] = sig
+}
+
+// addRefs records the provider functions passed to wire.NewSet, wire.Build,
+// fx.Provide, fx.Invoke and fx.Decorate calls in f. Providers wrapped in
+// fx.Annotate are unwrapped. Arguments that are not function names, such as
+// other provider sets or function literals, are skipped.
+func (x *diIndex) addRefs(f *ast.File, pkgDir string, imports map[string]string) {
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		framework, ok := diCall(call, imports, diProviderFuncs)
+		if !ok {
+			return true
+		}
+		for _, arg := range call.Args {
+			if inner, ok := arg.(*ast.CallExpr); ok && len(inner.Args) > 0 {
+				if _, ok := diCall(inner, imports, map[string][]string{"fx": {"Annotate"}}); ok {
+					arg = inner.Args[0]
+				}
+			}
+			if name := funcRefName(arg, pkgDir, imports); name != "" {
+				x.refs = append(x.refs, diRef{provider: name, framework: framework})
+			}
+		}
+		return true
+	})
+}
+
+// diCall reports whether call is pkg.Fn for a DI framework import and one of
+// the function names listed for that framework in funcs.
+func diCall(call *ast.CallExpr, imports map[string]string, funcs map[string][]string) (string, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	framework := diFrameworks[imports[pkg.Name]]
+	if framework == "" || !slices.Contains(funcs[framework], sel.Sel.Name) {
+		return "", false
+	}
+	return framework, true
+}
+
+// funcRefName returns the qualified name of a function reference: "pkg.NewFoo"
+// for NewFoo, or "<module>.NewFoo" for other.NewFoo.
+func funcRefName(expr ast.Expr, pkgDir string, imports map[string]string) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return pkgDir + "." + t.Name
+	case *ast.SelectorExpr:
+		if x, ok := t.X.(*ast.Ident); ok {
+			if target, ok := imports[x.Name]; ok {
+				return target + "." + t.Sel.Name
+			}
+		}
+	}
+	return ""
+}
+
+// diTypeName returns the qualified name of a named parameter or result type,
+// seen through pointers and type arguments. Predeclared types (error,
+// string, ...) and unnamed types such as slices, maps and funcs yield "".
+func diTypeName(expr ast.Expr, pkgDir string, imports map[string]string) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch x := expr.(type) {
+	case *ast.IndexExpr:
+		expr = x.X
+	case *ast.IndexListExpr:
+		expr = x.X
+	}
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if types.Universe.Lookup(t.Name) != nil {
+			return ""
+		}
+		return pkgDir + "." + t.Name
+	case *ast.SelectorExpr:
+		if x, ok := t.X.(*ast.Ident); ok {
+			if target, ok := imports[x.Name]; ok {
+				return target + "." + t.Sel.Name
+			}
+		}
+	}
+	return ""
+}
+
+// linkProviders adds depends_on relations from each function referenced as
+// a DI provider to the types it consumes and produces. It is marked with a
+// di prop listing the frameworks that reference it and a provides prop
+// listing the produced types. Providers declared outside the extracted
+// packages are skipped.
+func (x *diIndex) linkProviders(ff []facts.Fact) {
+	if len(x.refs) == 0 {
+		return
+	}
+	frameworks := make(map[string][]string) // provider -> frameworks
+	for _, ref := range x.refs {
+		if !slices.Contains(frameworks[ref.provider], ref.framework) {
+			frameworks[ref.provider] = append(frameworks[ref.provider], ref.framework)
+		}
+	}
+
+	for i := range ff {
+		f := &ff[i]
+		if f.Kind != facts.KindSymbol || f.Props["symbol_kind"] != facts.SymbolFunc {
+			continue
+		}
+		fw, ok := frameworks[f.Name]
+		sig, hasSig := x.sigs[f.Name]
+		if !ok || !hasSig {
+			continue
+		}
+		slices.Sort(fw)
+		f.Props["di"] = fw
+		if len(sig.produces) > 0 {
+			f.Props["provides"] = sig.produces
+		}
+		seen := make(map[string]bool)
+		for _, target := range append(append([]string(nil), sig.consumes...), sig.produces...) {
+			if seen[target] {
+				continue
+			}
+			seen[target] = true
+			f.Relations = append(f.Relations, facts.Relation{Kind: facts.RelDependsOn, Target: target})
+		}
+	}
+}
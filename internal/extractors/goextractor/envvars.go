@@ -0,0 +1,51 @@
+package goextractor
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/extractors"
+)
+
+// recordEnvReads records the os.Getenv and os.LookupEnv calls of f whose
+// variable name is a string literal. Calls through a renamed os import are
+// followed; names built at runtime are not.
+func recordEnvReads(fset *token.FileSet, f *ast.File, relFile string, env *extractors.EnvVars) {
+	osName := ""
+	for _, imp := range f.Imports {
+		if strings.Trim(imp.Path.Value, `"`) != "os" {
+			continue
+		}
+		osName = "os"
+		if imp.Name != nil {
+			osName = imp.Name.Name
+		}
+	}
+	if osName == "" || osName == "_" {
+		return
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || (sel.Sel.Name != "Getenv" && sel.Sel.Name != "LookupEnv") {
+			return true
+		}
+		if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != osName {
+			return true
+		}
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		if name, err := strconv.Unquote(lit.Value); err == nil {
+			env.Add(name, relFile, fset.Position(call.Pos()).Line)
+		}
+		return true
+	})
+}
@@ -1,24 +1,86 @@
 package goextractor
 
 import (
+	"bytes"
 	"context"
 	"go/ast"
+	"go/build"
+	"go/doc"
 	"go/parser"
+	"go/printer"
 	"go/token"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/dejo1307/archmcp/internal/extractors"
 	"github.com/dejo1307/archmcp/internal/facts"
 )
 
+// Doc comment capture modes for NewWithDocMode.
+const (
+	DocFirstSentence = "first_sentence" // synopsis only (default)
+	DocFull          = "full"           // complete comment text
+	DocOff           = "off"            // do not record doc comments
+)
+
 // GoExtractor extracts architectural facts from Go source code using go/ast.
-type GoExtractor struct{}
+type GoExtractor struct {
+	docMode         string
+	coverageProfile string
+	tests           bool
+	buildCtx        *build.Context // target platform; nil extracts every file
+	ignored         func(relPath string) bool
+}
 
-// New creates a new GoExtractor.
+// Options configures a GoExtractor.
+type Options struct {
+	// DocComments is the doc comment mode (see DocFirstSentence, DocFull, DocOff).
+	DocComments string
+	// CoverageProfile is the path of a `go test -coverprofile` file, relative
+	// to the repository root unless absolute. When set, function and method
+	// symbols are annotated with covered and coverage_pct props.
+	CoverageProfile string
+	// Tests reads each package's _test.go files, even when the walk ignores
+	// them, to emit test function symbols and tested_by relations.
+	Tests bool
+	// GOOS, GOARCH and BuildTags scope extraction to a target platform:
+	// files whose build constraints or file name suffixes exclude it are
+	// skipped. When all are empty every file is extracted. An empty GOOS or
+	// GOARCH keeps the host value.
+	GOOS      string
+	GOARCH    string
+	BuildTags []string
+}
+
+// New creates a new GoExtractor that records the first sentence of doc comments.
 func New() *GoExtractor {
-	return &GoExtractor{}
+	return NewWithDocMode(DocFirstSentence)
+}
+
+// NewWithDocMode creates a GoExtractor with the given doc comment mode.
+// Unknown or empty modes fall back to DocFirstSentence.
+func NewWithDocMode(mode string) *GoExtractor {
+	return NewWithOptions(Options{DocComments: mode})
+}
+
+// NewWithOptions creates a GoExtractor from the given options.
+func NewWithOptions(opts Options) *GoExtractor {
+	mode := opts.DocComments
+	switch mode {
+	case DocFull, DocOff:
+	default:
+		mode = DocFirstSentence
+	}
+	return &GoExtractor{
+		docMode:         mode,
+		coverageProfile: opts.CoverageProfile,
+		tests:           opts.Tests,
+		buildCtx:        buildContext(opts.GOOS, opts.GOARCH, opts.BuildTags),
+	}
 }
 
 func (e *GoExtractor) Name() string {
@@ -37,19 +99,36 @@ func (e *GoExtractor) Detect(repoPath string) (bool, error) {
 	return true, nil
 }
 
+// SetIgnored sets the repository ignore rules the test files read from disk
+// are checked against (see Options.Tests).
+func (e *GoExtractor) SetIgnored(ignored func(relPath string) bool) {
+	e.ignored = ignored
+}
+
 // Extract parses Go files and emits architectural facts.
 func (e *GoExtractor) Extract(ctx context.Context, repoPath string, files []string) ([]facts.Fact, error) {
 	var allFacts []facts.Fact
+	var fileErrs extractors.FileErrors
 	fset := token.NewFileSet()
 	modulePath := readModulePath(repoPath)
+	cov := e.loadCoverage(repoPath, modulePath)
+	di := newDIIndex()
+	var env extractors.EnvVars
+	var msgs messagingIndex
 
-	// Group files by directory (package)
+	// Group files by directory (package). With tests enabled, test files are
+	// linked separately below rather than extracted as production code.
 	packages := make(map[string][]string)
+	testFiles := make(map[string][]string)
 	for _, f := range files {
-		if !strings.HasSuffix(f, ".go") {
+		if !strings.HasSuffix(f, ".go") || !e.inTarget(repoPath, f) {
 			continue
 		}
 		dir := filepath.Dir(f)
+		if e.tests && strings.HasSuffix(f, "_test.go") {
+			testFiles[dir] = append(testFiles[dir], f)
+			continue
+		}
 		packages[dir] = append(packages[dir], f)
 	}
 
@@ -60,36 +139,61 @@ func (e *GoExtractor) Extract(ctx context.Context, repoPath string, files []stri
 		default:
 		}
 
-		pkgFacts := e.extractPackage(fset, repoPath, pkgDir, pkgFiles, modulePath)
+		pkgFacts := e.extractPackage(fset, repoPath, pkgDir, pkgFiles, modulePath, cov, di, &env, &msgs, &fileErrs)
+		if e.tests {
+			tests := e.filterTarget(repoPath, packageTestFiles(repoPath, pkgDir, testFiles[pkgDir], e.ignored))
+			pkgFacts = append(pkgFacts, linkTests(fset, repoPath, pkgDir, tests, modulePath, pkgFacts, &fileErrs)...)
+		}
 		allFacts = append(allFacts, pkgFacts...)
 	}
 
-	return allFacts, nil
+	// Link wire and fx providers to the types they consume and produce once
+	// every package is extracted, since provider sets span packages.
+	di.linkProviders(allFacts)
+
+	allFacts = append(allFacts, env.Facts()...)
+	// Topics and queues join producers and consumers across packages.
+	allFacts = append(allFacts, msgs.link(allFacts)...)
+	allFacts = append(allFacts, manifestFacts(repoPath)...)
+
+	return allFacts, fileErrs.Err()
 }
 
-func (e *GoExtractor) extractPackage(fset *token.FileSet, repoPath, pkgDir string, files []string, modulePath string) []facts.Fact {
+func (e *GoExtractor) extractPackage(fset *token.FileSet, repoPath, pkgDir string, files []string, modulePath string, cov coverageProfile, di *diIndex, env *extractors.EnvVars, msgs *messagingIndex, fileErrs *extractors.FileErrors) []facts.Fact {
 	var result []facts.Fact
-	var pkgName string
+	var pkgName, pkgDoc string
 
 	for _, relFile := range files {
 		absFile := filepath.Join(repoPath, relFile)
 		src, err := os.ReadFile(absFile)
 		if err != nil {
-			log.Printf("[go-extractor] error reading %s: %v", relFile, err)
+			fileErrs.Add(relFile, err)
 			continue
 		}
 
 		f, err := parser.ParseFile(fset, absFile, src, parser.ParseComments)
 		if err != nil {
-			log.Printf("[go-extractor] error parsing %s: %v", relFile, err)
+			fileErrs.Add(relFile, err)
 			continue
 		}
 
 		if pkgName == "" {
 			pkgName = f.Name.Name
 		}
+		if pkgDoc == "" {
+			pkgDoc = e.docText(f.Doc)
+		}
 
-		fileFacts := e.extractFile(fset, f, relFile, pkgDir, modulePath)
+		fileFacts := e.extractFile(fset, f, relFile, pkgDir, modulePath, cov, di)
+		recordEnvReads(fset, f, relFile, env)
+		msgs.addFile(fset, f, relFile, pkgDir)
+		if bc := fileConstraint(f, relFile); bc != "" {
+			for _, ff := range fileFacts {
+				if ff.Props != nil {
+					ff.Props["build_constraint"] = bc
+				}
+			}
+		}
 		result = append(result, fileFacts...)
 	}
 
@@ -104,16 +208,21 @@ func (e *GoExtractor) extractPackage(fset *token.FileSet, repoPath, pkgDir strin
 				"language": "go",
 			},
 		}
+		if pkgDoc != "" {
+			moduleFact.Props["doc"] = pkgDoc
+		}
 		result = append(result, moduleFact)
 	}
 
 	return result
 }
 
-func (e *GoExtractor) extractFile(fset *token.FileSet, f *ast.File, relFile, pkgDir, modulePath string) []facts.Fact {
+func (e *GoExtractor) extractFile(fset *token.FileSet, f *ast.File, relFile, pkgDir, modulePath string, cov coverageProfile, di *diIndex) []facts.Fact {
 	var result []facts.Fact
 
-	// Extract imports
+	// Extract imports, remembering each package name so embedded types from
+	// other packages can be qualified with the imported module.
+	imports := make(map[string]string) // package name -> module fact name
 	for _, imp := range f.Imports {
 		importPath := strings.Trim(imp.Path.Value, `"`)
 
@@ -124,6 +233,11 @@ func (e *GoExtractor) extractFile(fset *token.FileSet, f *ast.File, relFile, pkg
 		if modulePath != "" && strings.HasPrefix(importPath, modulePath+"/") {
 			relTarget = strings.TrimPrefix(importPath, modulePath+"/")
 		}
+		pkgName := path.Base(importPath)
+		if imp.Name != nil {
+			pkgName = imp.Name.Name
+		}
+		imports[pkgName] = relTarget
 
 		result = append(result, facts.Fact{
 			Kind: facts.KindDependency,
@@ -144,9 +258,18 @@ func (e *GoExtractor) extractFile(fset *token.FileSet, f *ast.File, relFile, pkg
 	for _, decl := range f.Decls {
 		switch d := decl.(type) {
 		case *ast.FuncDecl:
-			result = append(result, e.extractFunc(fset, d, relFile, pkgDir)...)
+			funcFacts := e.extractFunc(fset, d, relFile, pkgDir)
+			di.addFunc(d, pkgDir, imports)
+			if cov != nil && d.Body != nil && len(d.Body.List) > 0 {
+				start, end := fset.Position(d.Pos()).Line, fset.Position(d.End()).Line
+				if covered, pct, ok := cov.funcCoverage(relFile, start, end); ok {
+					funcFacts[0].Props["covered"] = covered
+					funcFacts[0].Props["coverage_pct"] = pct
+				}
+			}
+			result = append(result, funcFacts...)
 		case *ast.GenDecl:
-			result = append(result, e.extractGenDecl(fset, d, relFile, pkgDir)...)
+			result = append(result, e.extractGenDecl(fset, d, relFile, pkgDir, imports)...)
 		}
 	}
 
@@ -156,6 +279,9 @@ func (e *GoExtractor) extractFile(fset *token.FileSet, f *ast.File, relFile, pkg
 	// Extract storage patterns
 	result = append(result, extractStorage(fset, f, relFile, pkgDir)...)
 
+	// Collect DI provider references, linked once all packages are extracted
+	di.addRefs(f, pkgDir, imports)
+
 	return result
 }
 
@@ -193,6 +319,20 @@ func (e *GoExtractor) extractFunc(fset *token.FileSet, fn *ast.FuncDecl, relFile
 	if receiver != "" {
 		symbolFact.Props["receiver"] = receiver
 	}
+	if tp := formatTypeParams(fset, fn.Type.TypeParams); tp != "" {
+		symbolFact.Props["type_params"] = tp
+	}
+	if sig := funcSignature(fset, fn); sig != "" {
+		symbolFact.Props["signature"] = sig
+	}
+	if exported {
+		if doc := e.docText(fn.Doc); doc != "" {
+			symbolFact.Props["doc"] = doc
+		}
+	}
+	if message, ok := deprecation(fn.Doc); ok {
+		extractors.SetDeprecated(symbolFact.Props, message)
+	}
 
 	// Extract function calls
 	if fn.Body != nil {
@@ -203,26 +343,41 @@ func (e *GoExtractor) extractFunc(fset *token.FileSet, fn *ast.FuncDecl, relFile
 				Target: call,
 			})
 		}
+
+		spawns, channels := concurrencyUsage(fn.Body)
+		if spawns {
+			symbolFact.Props["spawns_goroutine"] = true
+		}
+		if channels {
+			symbolFact.Props["uses_channels"] = true
+		}
+
+		symbolFact.Props["complexity"] = cyclomaticComplexity(fn.Body)
 	}
 
 	result = append(result, symbolFact)
 	return result
 }
 
-func (e *GoExtractor) extractGenDecl(fset *token.FileSet, gd *ast.GenDecl, relFile, pkgDir string) []facts.Fact {
+func (e *GoExtractor) extractGenDecl(fset *token.FileSet, gd *ast.GenDecl, relFile, pkgDir string, imports map[string]string) []facts.Fact {
 	var result []facts.Fact
 
 	for _, spec := range gd.Specs {
 		switch s := spec.(type) {
 		case *ast.TypeSpec:
-			result = append(result, e.extractTypeSpec(fset, gd, s, relFile, pkgDir)...)
+			result = append(result, e.extractTypeSpec(fset, gd, s, relFile, pkgDir, imports)...)
+		case *ast.ValueSpec:
+			if gd.Tok == token.VAR {
+				result = append(result, e.extractChannelVars(fset, gd, s, relFile, pkgDir)...)
+				result = append(result, e.extractCobraCommands(fset, gd, s, relFile, pkgDir, imports)...)
+			}
 		}
 	}
 
 	return result
 }
 
-func (e *GoExtractor) extractTypeSpec(fset *token.FileSet, gd *ast.GenDecl, ts *ast.TypeSpec, relFile, pkgDir string) []facts.Fact {
+func (e *GoExtractor) extractTypeSpec(fset *token.FileSet, gd *ast.GenDecl, ts *ast.TypeSpec, relFile, pkgDir string, imports map[string]string) []facts.Fact {
 	var result []facts.Fact
 
 	name := ts.Name.Name
@@ -230,25 +385,17 @@ func (e *GoExtractor) extractTypeSpec(fset *token.FileSet, gd *ast.GenDecl, ts *
 	qualifiedName := pkgDir + "." + name
 
 	var kind string
-	var implements []string
+	var embeds []string
+	var st *ast.StructType
 
 	switch t := ts.Type.(type) {
 	case *ast.StructType:
 		kind = facts.SymbolStruct
-		// Extract embedded types (potential interface implementations)
-		if t.Fields != nil {
-			for _, field := range t.Fields.List {
-				if len(field.Names) == 0 {
-					// Embedded type
-					embeddedName := typeExprToString(field.Type)
-					if embeddedName != "" {
-						implements = append(implements, embeddedName)
-					}
-				}
-			}
-		}
+		embeds = embeddedTypes(t.Fields, pkgDir, imports)
+		st = t
 	case *ast.InterfaceType:
 		kind = facts.SymbolInterface
+		embeds = embeddedTypes(t.Methods, pkgDir, imports)
 	default:
 		kind = facts.SymbolType
 	}
@@ -268,10 +415,33 @@ func (e *GoExtractor) extractTypeSpec(fset *token.FileSet, gd *ast.GenDecl, ts *
 		},
 	}
 
-	for _, impl := range implements {
+	typeParams := formatTypeParams(fset, ts.TypeParams)
+	if typeParams != "" {
+		symbolFact.Props["type_params"] = typeParams
+	}
+	symbolFact.Props["signature"] = typeSignature(fset, ts, typeParams)
+	if st != nil {
+		addStructFields(fset, st, symbolFact.Props)
+	}
+	// A lone, unparenthesized type declaration attaches its comment to the
+	// GenDecl rather than the TypeSpec.
+	docGroup := ts.Doc
+	if docGroup == nil && !gd.Lparen.IsValid() {
+		docGroup = gd.Doc
+	}
+	if exported {
+		if doc := e.docText(docGroup); doc != "" {
+			symbolFact.Props["doc"] = doc
+		}
+	}
+	if message, ok := deprecation(docGroup); ok {
+		extractors.SetDeprecated(symbolFact.Props, message)
+	}
+
+	for _, target := range embeds {
 		symbolFact.Relations = append(symbolFact.Relations, facts.Relation{
-			Kind:   facts.RelImplements,
-			Target: impl,
+			Kind:   facts.RelEmbeds,
+			Target: target,
 		})
 	}
 
@@ -279,6 +449,367 @@ func (e *GoExtractor) extractTypeSpec(fset *token.FileSet, gd *ast.GenDecl, ts *
 	return result
 }
 
+// embeddedTypes returns the qualified names of the types embedded in a struct
+// field list or an interface method list: "pkg.Bar" for a type from the same
+// package and "<module>.Reader" for one from an imported package, so the
+// relation targets match the symbol facts of those types. Embedded pointers
+// (*Bar) count as embedding Bar. Type-set terms in constraint interfaces
+// (~int | string) are not embeddings and are skipped.
+func embeddedTypes(fl *ast.FieldList, pkgDir string, imports map[string]string) []string {
+	if fl == nil {
+		return nil
+	}
+	var embeds []string
+	for _, field := range fl.List {
+		if len(field.Names) > 0 {
+			continue
+		}
+		expr := field.Type
+		if star, ok := expr.(*ast.StarExpr); ok {
+			expr = star.X
+		}
+		switch x := expr.(type) {
+		case *ast.IndexExpr:
+			expr = x.X
+		case *ast.IndexListExpr:
+			expr = x.X
+		}
+		switch t := expr.(type) {
+		case *ast.Ident:
+			embeds = append(embeds, pkgDir+"."+t.Name)
+		case *ast.SelectorExpr:
+			if x, ok := t.X.(*ast.Ident); ok {
+				module := x.Name
+				if target, ok := imports[x.Name]; ok {
+					module = target
+				}
+				embeds = append(embeds, module+"."+t.Sel.Name)
+			}
+		}
+	}
+	return embeds
+}
+
+// addStructFields records a struct's fields in Props["fields"] as
+// name/type/tags entries, with tags keyed by tag name. The wire and column
+// names from json and db tags are also collected into Props["json_keys"] and
+// Props["db_columns"], so a query can find the struct that maps a key.
+// Embedded fields are named after their type.
+func addStructFields(fset *token.FileSet, st *ast.StructType, props map[string]any) {
+	if st.Fields == nil || len(st.Fields.List) == 0 {
+		return
+	}
+	var fields []map[string]any
+	var jsonKeys, dbColumns []string
+	for _, field := range st.Fields.List {
+		typ := nodeString(fset, field.Type)
+		var tags map[string]string
+		if field.Tag != nil {
+			if raw, err := strconv.Unquote(field.Tag.Value); err == nil {
+				tags = parseStructTag(raw)
+			}
+		}
+		if key := tagName(tags["json"]); key != "" {
+			jsonKeys = append(jsonKeys, key)
+		}
+		if col := tagName(tags["db"]); col != "" {
+			dbColumns = append(dbColumns, col)
+		}
+
+		names := make([]string, 0, len(field.Names))
+		for _, n := range field.Names {
+			names = append(names, n.Name)
+		}
+		if len(names) == 0 {
+			names = append(names, strings.TrimPrefix(typ, "*"))
+		}
+		for _, name := range names {
+			entry := map[string]any{"name": name, "type": typ}
+			if len(tags) > 0 {
+				entry["tags"] = tags
+			}
+			fields = append(fields, entry)
+		}
+	}
+	props["fields"] = fields
+	if len(jsonKeys) > 0 {
+		props["json_keys"] = jsonKeys
+	}
+	if len(dbColumns) > 0 {
+		props["db_columns"] = dbColumns
+	}
+}
+
+// parseStructTag splits a struct tag in the conventional `key:"value" ...`
+// form into a map. Parsing stops at the first malformed pair, as
+// reflect.StructTag.Lookup does.
+func parseStructTag(tag string) map[string]string {
+	tags := make(map[string]string)
+	for tag != "" {
+		tag = strings.TrimLeft(tag, " ")
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			break
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// Scan the quoted value, honoring escapes.
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			break
+		}
+		tags[key] = value
+		tag = tag[i+1:]
+	}
+	return tags
+}
+
+// tagName returns the name part of a json- or db-style tag value ("user_id"
+// for "user_id,omitempty"), or "" when the field is skipped ("-") or unnamed.
+func tagName(value string) string {
+	name, _, _ := strings.Cut(value, ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// extractChannelVars emits a variable symbol for each package-level var of
+// channel type, declared either with an explicit chan type or initialized
+// with make(chan T). Other package-level vars are not recorded, apart from
+// Cobra commands (see extractCobraCommands).
+func (e *GoExtractor) extractChannelVars(fset *token.FileSet, gd *ast.GenDecl, vs *ast.ValueSpec, relFile, pkgDir string) []facts.Fact {
+	var result []facts.Fact
+
+	for i, ident := range vs.Names {
+		if ident.Name == "_" {
+			continue
+		}
+		chanType, _ := vs.Type.(*ast.ChanType)
+		if chanType == nil && i < len(vs.Values) {
+			chanType = makeChanType(vs.Values[i])
+		}
+		if chanType == nil {
+			continue
+		}
+
+		symbolFact := facts.Fact{
+			Kind: facts.KindSymbol,
+			Name: pkgDir + "." + ident.Name,
+			File: relFile,
+			Line: fset.Position(ident.Pos()).Line,
+			Props: map[string]any{
+				"symbol_kind": facts.SymbolVariable,
+				"exported":    ident.IsExported(),
+				"language":    "go",
+				"channel":     true,
+				"chan_dir":    chanDir(chanType.Dir),
+				"signature":   "var " + ident.Name + " " + nodeString(fset, chanType),
+			},
+			Relations: []facts.Relation{
+				{Kind: facts.RelDeclares, Target: pkgDir},
+			},
+		}
+		docGroup := vs.Doc
+		if docGroup == nil && !gd.Lparen.IsValid() {
+			docGroup = gd.Doc
+		}
+		if ident.IsExported() {
+			if doc := e.docText(docGroup); doc != "" {
+				symbolFact.Props["doc"] = doc
+			}
+		}
+		if message, ok := deprecation(docGroup); ok {
+			extractors.SetDeprecated(symbolFact.Props, message)
+		}
+		result = append(result, symbolFact)
+	}
+
+	return result
+}
+
+// cobraImportPath is the import path of the Cobra CLI library.
+const cobraImportPath = "github.com/spf13/cobra"
+
+// extractCobraCommands emits a variable symbol for each package-level var
+// initialized with a &cobra.Command{...} literal, marked as a cobra_command
+// entry point. The command prop holds the first word of the Use field.
+func (e *GoExtractor) extractCobraCommands(fset *token.FileSet, gd *ast.GenDecl, vs *ast.ValueSpec, relFile, pkgDir string, imports map[string]string) []facts.Fact {
+	var result []facts.Fact
+
+	for i, ident := range vs.Names {
+		if ident.Name == "_" || i >= len(vs.Values) {
+			continue
+		}
+		lit := cobraCommandLit(vs.Values[i], imports)
+		if lit == nil {
+			continue
+		}
+
+		symbolFact := facts.Fact{
+			Kind: facts.KindSymbol,
+			Name: pkgDir + "." + ident.Name,
+			File: relFile,
+			Line: fset.Position(ident.Pos()).Line,
+			Props: map[string]any{
+				"symbol_kind": facts.SymbolVariable,
+				"exported":    ident.IsExported(),
+				"language":    "go",
+				"entry_point": facts.EntryCobraCommand,
+				"signature":   "var " + ident.Name + " *cobra.Command",
+			},
+			Relations: []facts.Relation{
+				{Kind: facts.RelDeclares, Target: pkgDir},
+			},
+		}
+		if use := cobraUse(lit); use != "" {
+			symbolFact.Props["command"] = use
+		}
+		result = append(result, symbolFact)
+	}
+
+	return result
+}
+
+// cobraCommandLit returns the composite literal of a &cobra.Command{...}
+// expression, resolving the package name through the file's imports.
+func cobraCommandLit(expr ast.Expr, imports map[string]string) *ast.CompositeLit {
+	unary, ok := expr.(*ast.UnaryExpr)
+	if !ok || unary.Op != token.AND {
+		return nil
+	}
+	lit, ok := unary.X.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	sel, ok := lit.Type.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Command" {
+		return nil
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok || imports[pkg.Name] != cobraImportPath {
+		return nil
+	}
+	return lit
+}
+
+// cobraUse returns the command name from the Use field of a cobra.Command
+// literal: the first word of "serve [flags]".
+func cobraUse(lit *ast.CompositeLit) string {
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		if key, ok := kv.Key.(*ast.Ident); !ok || key.Name != "Use" {
+			continue
+		}
+		bl, ok := kv.Value.(*ast.BasicLit)
+		if !ok || bl.Kind != token.STRING {
+			return ""
+		}
+		use, err := strconv.Unquote(bl.Value)
+		if err != nil {
+			return ""
+		}
+		if fields := strings.Fields(use); len(fields) > 0 {
+			return fields[0]
+		}
+		return ""
+	}
+	return ""
+}
+
+// makeChanType returns the channel type of a make(chan T[, n]) expression.
+func makeChanType(expr ast.Expr) *ast.ChanType {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) == 0 {
+		return nil
+	}
+	if fn, ok := call.Fun.(*ast.Ident); !ok || fn.Name != "make" {
+		return nil
+	}
+	chanType, _ := call.Args[0].(*ast.ChanType)
+	return chanType
+}
+
+// chanDir names a channel direction: "send", "recv", or "both".
+func chanDir(dir ast.ChanDir) string {
+	switch dir {
+	case ast.SEND:
+		return "send"
+	case ast.RECV:
+		return "recv"
+	}
+	return "both"
+}
+
+// concurrencyUsage reports whether a function body starts goroutines (a go
+// statement, including inside nested function literals) and whether it
+// operates on channels: sends, receives, select statements, or make(chan T).
+func concurrencyUsage(body *ast.BlockStmt) (spawns, channels bool) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.GoStmt:
+			spawns = true
+		case *ast.SendStmt, *ast.SelectStmt:
+			channels = true
+		case *ast.UnaryExpr:
+			if x.Op == token.ARROW {
+				channels = true
+			}
+		case *ast.CallExpr:
+			if makeChanType(x) != nil {
+				channels = true
+			}
+		}
+		return !(spawns && channels)
+	})
+	return spawns, channels
+}
+
+// cyclomaticComplexity counts the decision points in a function body plus
+// one: if, for and range statements, non-default case and select clauses, and
+// the && and || operators. Function literals count toward the enclosing
+// function, as in gocyclo.
+func cyclomaticComplexity(body *ast.BlockStmt) int {
+	complexity := 1
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			complexity++
+		case *ast.CaseClause:
+			if x.List != nil {
+				complexity++
+			}
+		case *ast.CommClause:
+			if x.Comm != nil {
+				complexity++
+			}
+		case *ast.BinaryExpr:
+			if x.Op == token.LAND || x.Op == token.LOR {
+				complexity++
+			}
+		}
+		return true
+	})
+	return complexity
+}
+
 // extractCalls walks an AST node and extracts function call target names.
 func extractCalls(node ast.Node) []string {
 	var calls []string
@@ -301,6 +832,51 @@ func extractCalls(node ast.Node) []string {
 	return calls
 }
 
+// docText renders a doc comment according to the extractor's doc mode.
+func (e *GoExtractor) docText(cg *ast.CommentGroup) string {
+	if cg == nil || e.docMode == DocOff {
+		return ""
+	}
+	text := cg.Text()
+	if e.docMode == DocFull {
+		return strings.TrimSpace(text)
+	}
+	return new(doc.Package).Synopsis(text)
+}
+
+// deprecation reports whether a doc comment has a "Deprecated: " paragraph,
+// the convention recognized by go doc and gopls, and returns the rest of the
+// paragraph as the message.
+func deprecation(cg *ast.CommentGroup) (string, bool) {
+	if cg == nil {
+		return "", false
+	}
+	for _, para := range strings.Split(cg.Text(), "\n\n") {
+		if rest, ok := strings.CutPrefix(para, "Deprecated: "); ok {
+			return strings.Join(strings.Fields(rest), " "), true
+		}
+	}
+	return "", false
+}
+
+// loadCoverage reads the configured coverage profile. A missing or malformed
+// profile is logged and extraction continues without coverage data.
+func (e *GoExtractor) loadCoverage(repoPath, modulePath string) coverageProfile {
+	if e.coverageProfile == "" {
+		return nil
+	}
+	path := e.coverageProfile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(repoPath, path)
+	}
+	cov, err := loadCoverageProfile(path, repoPath, modulePath)
+	if err != nil {
+		log.Printf("[go-extractor] warning: ignoring coverage profile: %v", err)
+		return nil
+	}
+	return cov
+}
+
 // readModulePath reads the module path from go.mod in the given repo.
 func readModulePath(repoPath string) string {
 	data, err := os.ReadFile(filepath.Join(repoPath, "go.mod"))
@@ -333,6 +909,60 @@ func classifyImport(importPath, modulePath string) string {
 	return "external"
 }
 
+// formatTypeParams renders a type parameter list as it appears in source,
+// e.g. "[K comparable, V any]". Returns "" for non-generic declarations.
+func formatTypeParams(fset *token.FileSet, fl *ast.FieldList) string {
+	if fl == nil || len(fl.List) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(fl.List))
+	for _, field := range fl.List {
+		names := make([]string, 0, len(field.Names))
+		for _, n := range field.Names {
+			names = append(names, n.Name)
+		}
+		parts = append(parts, strings.Join(names, ", ")+" "+nodeString(fset, field.Type))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// funcSignature renders a function declaration header without its body or doc
+// comment, e.g. "func Map[K comparable, V any](m map[K]V) []K".
+func funcSignature(fset *token.FileSet, fn *ast.FuncDecl) string {
+	header := *fn
+	header.Body = nil
+	header.Doc = nil
+	return nodeString(fset, &header)
+}
+
+// typeSignature renders a compact type declaration header. Struct and interface
+// bodies are elided; other underlying types are printed in full.
+func typeSignature(fset *token.FileSet, ts *ast.TypeSpec, typeParams string) string {
+	var underlying string
+	switch ts.Type.(type) {
+	case *ast.StructType:
+		underlying = "struct"
+	case *ast.InterfaceType:
+		underlying = "interface"
+	default:
+		underlying = nodeString(fset, ts.Type)
+	}
+	assign := " "
+	if ts.Assign.IsValid() {
+		assign = " = "
+	}
+	return "type " + ts.Name.Name + typeParams + assign + underlying
+}
+
+// nodeString pretty-prints an AST node back to Go source.
+func nodeString(fset *token.FileSet, node any) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
 // typeExprToString converts a type expression to a string representation.
 func typeExprToString(expr ast.Expr) string {
 	switch t := expr.(type) {
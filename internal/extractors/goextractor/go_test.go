@@ -2,8 +2,10 @@ package goextractor
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/dejo1307/archmcp/internal/facts"
@@ -156,15 +158,34 @@ func TestExtract_StructWithEmbedding(t *testing.T) {
 	ff := extractAll(t, map[string]string{
 		"pkg/embed.go": `package pkg
 
-import "io"
+import (
+	"io"
+
+	stdsync "sync"
+)
 
 type Foo struct {
 	io.Reader
-	Bar
+	*Bar
+	stdsync.Mutex
 	Name string
 }
 
 type Bar struct{}
+
+type ReadCloser interface {
+	io.Reader
+	Closer
+	Read2() error
+}
+
+type Closer interface {
+	Close() error
+}
+
+type Number interface {
+	~int | ~float64
+}
 `,
 	})
 
@@ -176,12 +197,91 @@ type Bar struct{}
 		t.Errorf("Foo symbol_kind = %v, want struct", foo.Props["symbol_kind"])
 	}
 
-	// Should have implements relations for embedded types
-	if !hasRelation(foo, facts.RelImplements, "io.Reader") {
-		t.Error("Foo should have implements relation for io.Reader")
+	// Embedded types become embeds relations, qualified so they resolve.
+	for _, target := range []string{"io.Reader", "pkg.Bar", "sync.Mutex"} {
+		if !hasRelation(foo, facts.RelEmbeds, target) {
+			t.Errorf("Foo should embed %s, relations: %v", target, foo.Relations)
+		}
+	}
+	if hasRelation(foo, facts.RelImplements, "pkg.Bar") {
+		t.Error("embedding should not be reported as implements")
+	}
+
+	rc, _ := findFact(ff, "pkg.ReadCloser")
+	if !hasRelation(rc, facts.RelEmbeds, "io.Reader") || !hasRelation(rc, facts.RelEmbeds, "pkg.Closer") {
+		t.Errorf("ReadCloser should embed io.Reader and pkg.Closer, relations: %v", rc.Relations)
 	}
-	if !hasRelation(foo, facts.RelImplements, "Bar") {
-		t.Error("Foo should have implements relation for Bar")
+	num, _ := findFact(ff, "pkg.Number")
+	for _, r := range num.Relations {
+		if r.Kind == facts.RelEmbeds {
+			t.Errorf("type-set terms are not embeddings, got embeds %s", r.Target)
+		}
+	}
+}
+
+func TestExtract_StructFieldTags(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"model/user.go": "package model\n\n" +
+			"type Base struct{}\n\n" +
+			"type User struct {\n" +
+			"\tBase\n" +
+			"\tID        int64  `json:\"id\" db:\"user_id\"`\n" +
+			"\tEmail     string `json:\"email,omitempty\" validate:\"required,email\"`\n" +
+			"\tFirst, Last string\n" +
+			"\tPassword  string `json:\"-\" db:\"password_hash\"`\n" +
+			"\tNote      *string `json:\",omitempty\" yaml:\"note\"`\n" +
+			"}\n",
+	})
+
+	user, ok := findFact(ff, "model.User")
+	if !ok {
+		t.Fatal("expected fact for model.User")
+	}
+	fields, ok := user.Props["fields"].([]map[string]any)
+	if !ok {
+		t.Fatalf("fields = %#v, want a field list", user.Props["fields"])
+	}
+	var names []string
+	for _, f := range fields {
+		names = append(names, f["name"].(string))
+	}
+	if got := strings.Join(names, ","); got != "Base,ID,Email,First,Last,Password,Note" {
+		t.Errorf("field names = %s", got)
+	}
+
+	email := fields[2]
+	tags, _ := email["tags"].(map[string]string)
+	if email["type"] != "string" || tags["json"] != "email,omitempty" || tags["validate"] != "required,email" {
+		t.Errorf("Email field = %v", email)
+	}
+	if _, ok := fields[3]["tags"]; ok {
+		t.Errorf("untagged field should have no tags, got %v", fields[3])
+	}
+	if fields[6]["type"] != "*string" {
+		t.Errorf("Note type = %v, want *string", fields[6]["type"])
+	}
+
+	if got := fmt.Sprint(user.Props["json_keys"]); got != "[id email]" {
+		t.Errorf("json_keys = %s, want [id email]", got)
+	}
+	if got := fmt.Sprint(user.Props["db_columns"]); got != "[user_id password_hash]" {
+		t.Errorf("db_columns = %s, want [user_id password_hash]", got)
+	}
+
+	base, _ := findFact(ff, "model.Base")
+	if _, ok := base.Props["fields"]; ok {
+		t.Error("empty struct should have no fields prop")
+	}
+}
+
+func TestParseStructTag(t *testing.T) {
+	got := parseStructTag(`json:"name,omitempty" db:"na\"me"  gorm:"column:name;index"`)
+	want := map[string]string{"json": "name,omitempty", "db": `na"me`, "gorm": "column:name;index"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("parseStructTag = %v, want %v", got, want)
+	}
+	if got := parseStructTag(`json:"a" broken db:"b"`); len(got) != 1 || got["json"] != "a" {
+		t.Errorf("malformed tag = %v, want only json", got)
 	}
 }
 
@@ -225,6 +325,47 @@ type Set[T comparable] struct {
 	if set.Props["symbol_kind"] != facts.SymbolStruct {
 		t.Errorf("Set symbol_kind = %v, want struct", set.Props["symbol_kind"])
 	}
+	if set.Props["type_params"] != "[T comparable]" {
+		t.Errorf("Set type_params = %v, want [T comparable]", set.Props["type_params"])
+	}
+	if set.Props["signature"] != "type Set[T comparable] struct" {
+		t.Errorf("Set signature = %v, want 'type Set[T comparable] struct'", set.Props["signature"])
+	}
+}
+
+func TestExtract_GenericFunction(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"pkg/generic.go": `package pkg
+
+func Keys[K comparable, V any](m map[K]V) []K {
+	return nil
+}
+
+func Plain(s string) error { return nil }
+`,
+	})
+
+	keys, ok := findFact(ff, "pkg.Keys")
+	if !ok {
+		t.Fatal("expected fact for pkg.Keys")
+	}
+	if keys.Props["type_params"] != "[K comparable, V any]" {
+		t.Errorf("Keys type_params = %v, want [K comparable, V any]", keys.Props["type_params"])
+	}
+	if keys.Props["signature"] != "func Keys[K comparable, V any](m map[K]V) []K" {
+		t.Errorf("Keys signature = %v", keys.Props["signature"])
+	}
+
+	plain, ok := findFact(ff, "pkg.Plain")
+	if !ok {
+		t.Fatal("expected fact for pkg.Plain")
+	}
+	if _, has := plain.Props["type_params"]; has {
+		t.Errorf("Plain should not have type_params, got %v", plain.Props["type_params"])
+	}
+	if plain.Props["signature"] != "func Plain(s string) error" {
+		t.Errorf("Plain signature = %v", plain.Props["signature"])
+	}
 }
 
 func TestExtract_CallExtraction(t *testing.T) {
@@ -256,6 +397,311 @@ func helper() {}
 	}
 }
 
+func TestExtract_Concurrency(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"pkg/workers.go": `package pkg
+
+// Events carries work to the pool.
+var Events = make(chan string, 16)
+
+var (
+	done    chan<- struct{}
+	results <-chan int
+	counter int
+)
+
+func Start(n int) {
+	for i := 0; i < n; i++ {
+		go worker()
+	}
+}
+
+func Serve() {
+	go func() {
+		select {
+		case e := <-Events:
+			_ = e
+		}
+	}()
+}
+
+func Send(e string) { Events <- e }
+
+func worker() {}
+`,
+	})
+
+	tests := []struct {
+		name             string
+		spawns, channels any
+	}{
+		{"pkg.Start", true, nil},
+		{"pkg.Serve", true, true},
+		{"pkg.Send", nil, true},
+		{"pkg.worker", nil, nil},
+	}
+	for _, tt := range tests {
+		f, ok := findFact(ff, tt.name)
+		if !ok {
+			t.Errorf("expected fact %s", tt.name)
+			continue
+		}
+		if f.Props["spawns_goroutine"] != tt.spawns || f.Props["uses_channels"] != tt.channels {
+			t.Errorf("%s: spawns_goroutine=%v uses_channels=%v, want %v %v",
+				tt.name, f.Props["spawns_goroutine"], f.Props["uses_channels"], tt.spawns, tt.channels)
+		}
+	}
+
+	chans := map[string]string{"pkg.Events": "both", "pkg.done": "send", "pkg.results": "recv"}
+	for name, dir := range chans {
+		f, ok := findFact(ff, name)
+		if !ok {
+			t.Errorf("expected channel var %s", name)
+			continue
+		}
+		if f.Props["symbol_kind"] != facts.SymbolVariable || f.Props["channel"] != true || f.Props["chan_dir"] != dir {
+			t.Errorf("%s props = %v, want a %s channel variable", name, f.Props, dir)
+		}
+	}
+	if f, _ := findFact(ff, "pkg.Events"); f.Props["signature"] != "var Events chan string" || f.Props["doc"] != "Events carries work to the pool." {
+		t.Errorf("Events signature/doc = %v / %v", f.Props["signature"], f.Props["doc"])
+	}
+	if _, ok := findFact(ff, "pkg.counter"); ok {
+		t.Error("non-channel package vars should not be extracted")
+	}
+}
+
+func TestExtract_CobraCommands(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"cmd/root.go": `package cmd
+
+import (
+	cli "github.com/spf13/cobra"
+)
+
+var rootCmd = &cli.Command{Use: "tool", Short: "Root command"}
+
+var (
+	serveCmd = &cli.Command{
+		Use: "serve [flags]",
+	}
+	other = &Command{Use: "not-cobra"}
+)
+
+type Command struct{ Use string }
+`,
+	})
+
+	for name, command := range map[string]string{"cmd.rootCmd": "tool", "cmd.serveCmd": "serve"} {
+		f, ok := findFact(ff, name)
+		if !ok {
+			t.Errorf("expected cobra command %s", name)
+			continue
+		}
+		if f.Props["symbol_kind"] != facts.SymbolVariable || f.Props["entry_point"] != facts.EntryCobraCommand || f.Props["command"] != command {
+			t.Errorf("%s props = %v, want a cobra_command named %q", name, f.Props, command)
+		}
+	}
+	if _, ok := findFact(ff, "cmd.other"); ok {
+		t.Error("a Command literal from another package should not be extracted")
+	}
+}
+
+func TestExtract_BuildConstraints(t *testing.T) {
+	files := map[string]string{
+		"sys/poll.go":             "package sys\n\nfunc Common() {}\n",
+		"sys/poll_linux.go":       "package sys\n\nfunc poll() {}\n",
+		"sys/poll_windows.go":     "package sys\n\nfunc poll() {}\n",
+		"sys/mem_linux_arm64.go":  "package sys\n\nfunc mem() {}\n",
+		"sys/tagged.go":           "//go:build integration || e2e\n\npackage sys\n\nfunc Tagged() {}\n",
+		"sys/legacy.go":           "// +build darwin\n\npackage sys\n\nfunc Legacy() {}\n",
+		"sys/unix_only_darwin.go": "//go:build !ios\n\npackage sys\n\nfunc Mac() {}\n",
+	}
+
+	constraints := func(ff []facts.Fact) map[string][]any {
+		m := make(map[string][]any)
+		for _, f := range ff {
+			if f.Kind == facts.KindSymbol {
+				m[f.Name] = append(m[f.Name], f.Props["build_constraint"])
+			}
+		}
+		return m
+	}
+
+	got := constraints(extractAll(t, files))
+	want := map[string][]any{
+		"sys.Common": {nil},
+		"sys.mem":    {"linux && arm64"},
+		"sys.Tagged": {"(integration || e2e)"},
+		"sys.Legacy": {"darwin"},
+		"sys.Mac":    {"!ios && darwin"},
+	}
+	for name, w := range want {
+		if fmt.Sprint(got[name]) != fmt.Sprint(w) {
+			t.Errorf("%s build_constraint = %v, want %v", name, got[name], w)
+		}
+	}
+	if p := fmt.Sprint(got["sys.poll"]); p != "[linux windows]" && p != "[windows linux]" {
+		t.Errorf("without a target both poll variants should be extracted, got %v", p)
+	}
+
+	// Scoped to linux/amd64 with the integration tag.
+	dir := setupGoProject(t, files)
+	var rel []string
+	for f := range files {
+		rel = append(rel, f)
+	}
+	ff, err := NewWithOptions(Options{GOOS: "linux", GOARCH: "amd64", BuildTags: []string{"integration"}}).Extract(context.Background(), dir, rel)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	got = constraints(ff)
+	for _, name := range []string{"sys.Common", "sys.Tagged"} {
+		if len(got[name]) != 1 {
+			t.Errorf("%s should be extracted for linux/amd64, got %v", name, got[name])
+		}
+	}
+	if fmt.Sprint(got["sys.poll"]) != "[linux]" {
+		t.Errorf("only the linux poll should be extracted, got %v", got["sys.poll"])
+	}
+	for _, name := range []string{"sys.mem", "sys.Legacy", "sys.Mac"} {
+		if _, ok := got[name]; ok {
+			t.Errorf("%s should be skipped for linux/amd64", name)
+		}
+	}
+}
+
+func TestExtract_DIProviders(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"internal/db/db.go": `package db
+
+type Config struct{}
+type Conn struct{}
+
+func Open(cfg Config) (*Conn, error) { return &Conn{}, nil }
+`,
+		"internal/app/app.go": `package app
+
+import (
+	"github.com/google/wire"
+	"testmod/internal/db"
+)
+
+type App struct{}
+type Logger struct{}
+
+func NewApp(c *db.Conn, l *Logger, name string) *App { return &App{} }
+func NewLogger() *Logger                             { return &Logger{} }
+func unused(c *db.Conn) *App                         { return nil }
+
+var Set = wire.NewSet(NewApp, NewLogger, db.Open)
+`,
+		"cmd/main.go": `package main
+
+import (
+	"go.uber.org/fx"
+	"testmod/internal/app"
+)
+
+func main() {
+	fx.New(
+		fx.Provide(fx.Annotate(app.NewLogger, fx.ResultTags("name:\"log\""))),
+		fx.Invoke(run),
+	)
+}
+
+func run(a *app.App) {}
+`,
+	})
+
+	tests := []struct {
+		name      string
+		framework []string
+		deps      []string
+		provides  any
+	}{
+		{"internal/app.NewApp", []string{"wire"}, []string{"internal/db.Conn", "internal/app.Logger", "internal/app.App"}, []string{"internal/app.App"}},
+		{"internal/app.NewLogger", []string{"fx", "wire"}, []string{"internal/app.Logger"}, []string{"internal/app.Logger"}},
+		{"internal/db.Open", []string{"wire"}, []string{"internal/db.Config", "internal/db.Conn"}, []string{"internal/db.Conn"}},
+		{"cmd.run", []string{"fx"}, []string{"internal/app.App"}, nil},
+	}
+	for _, tt := range tests {
+		f, ok := findFact(ff, tt.name)
+		if !ok {
+			t.Errorf("expected fact %s", tt.name)
+			continue
+		}
+		if fmt.Sprint(f.Props["di"]) != fmt.Sprint(tt.framework) || fmt.Sprint(f.Props["provides"]) != fmt.Sprint(tt.provides) {
+			t.Errorf("%s di=%v provides=%v, want %v %v", tt.name, f.Props["di"], f.Props["provides"], tt.framework, tt.provides)
+		}
+		var deps []string
+		for _, r := range f.Relations {
+			if r.Kind == facts.RelDependsOn {
+				deps = append(deps, r.Target)
+			}
+		}
+		if fmt.Sprint(deps) != fmt.Sprint(tt.deps) {
+			t.Errorf("%s depends_on = %v, want %v", tt.name, deps, tt.deps)
+		}
+	}
+	if f, _ := findFact(ff, "internal/app.unused"); f.Props["di"] != nil || hasRelation(f, facts.RelDependsOn, "internal/db.Conn") {
+		t.Errorf("functions not passed to a DI call should not be linked: %+v", f)
+	}
+}
+
+func TestExtract_Complexity(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"pkg/branchy.go": `package pkg
+
+func Simple() int { return 1 }
+
+func Branchy(xs []int, ch chan int) int {
+	total := 0
+	for _, x := range xs {
+		if x > 0 && x < 10 || x == 42 {
+			total += x
+		}
+	}
+	switch total {
+	case 1, 2:
+		total++
+	case 3:
+	default:
+	}
+	select {
+	case v := <-ch:
+		total += v
+	default:
+	}
+	f := func() bool { return total > 0 || len(xs) == 0 }
+	_ = f
+	return total
+}
+
+type T struct{}
+
+func (T) Method(ok bool) {
+	if ok {
+	} else if !ok {
+	}
+}
+`,
+	})
+
+	// Branchy: 1 + range + if + && + || + 2 cases + 1 comm clause + || in the literal.
+	want := map[string]int{"pkg.Simple": 1, "pkg.Branchy": 9, "pkg.T.Method": 3}
+	for name, complexity := range want {
+		f, ok := findFact(ff, name)
+		if !ok {
+			t.Errorf("expected fact %s", name)
+			continue
+		}
+		if f.Props["complexity"] != complexity {
+			t.Errorf("%s complexity = %v, want %d", name, f.Props["complexity"], complexity)
+		}
+	}
+}
+
 func TestExtract_Imports(t *testing.T) {
 	ff := extractAll(t, map[string]string{
 		"pkg/imports.go": `package pkg
@@ -323,6 +769,74 @@ func FuncB() {}
 	}
 }
 
+func TestExtract_DocComments(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"pkg/svc/svc.go": `// Package svc implements the billing service. It talks to Stripe.
+package svc
+
+// Client calls the billing API. It is safe for concurrent use.
+type Client struct{}
+
+type (
+	// Option configures a Client.
+	Option func(*Client)
+)
+
+// NewClient returns a Client. Callers must close it.
+func NewClient() *Client { return nil }
+
+// helper is internal.
+func helper() {}
+`,
+	})
+
+	tests := map[string]string{
+		"pkg/svc":           "Package svc implements the billing service.",
+		"pkg/svc.Client":    "Client calls the billing API.",
+		"pkg/svc.Option":    "Option configures a Client.",
+		"pkg/svc.NewClient": "NewClient returns a Client.",
+	}
+	for name, want := range tests {
+		f, ok := findFact(ff, name)
+		if !ok {
+			t.Errorf("expected fact for %s", name)
+			continue
+		}
+		if got := f.Props["doc"]; got != want {
+			t.Errorf("%s doc = %q, want %q", name, got, want)
+		}
+	}
+
+	// Unexported symbols do not carry docs.
+	if f, _ := findFact(ff, "pkg/svc.helper"); f.Props["doc"] != nil {
+		t.Errorf("unexported helper should not have doc, got %v", f.Props["doc"])
+	}
+}
+
+func TestExtract_DocCommentModes(t *testing.T) {
+	src := map[string]string{
+		"p/p.go": `package p
+
+// Run starts the loop.
+// It blocks until ctx is done.
+func Run() {}
+`,
+	}
+	dir := setupGoProject(t, src)
+
+	full, _ := NewWithDocMode(DocFull).Extract(context.Background(), dir, []string{"p/p.go"})
+	f, _ := findFact(full, "p.Run")
+	if want := "Run starts the loop.\nIt blocks until ctx is done."; f.Props["doc"] != want {
+		t.Errorf("full doc = %q, want %q", f.Props["doc"], want)
+	}
+
+	off, _ := NewWithDocMode(DocOff).Extract(context.Background(), dir, []string{"p/p.go"})
+	f, _ = findFact(off, "p.Run")
+	if _, ok := f.Props["doc"]; ok {
+		t.Errorf("doc should be omitted when disabled, got %v", f.Props["doc"])
+	}
+}
+
 func TestDetect(t *testing.T) {
 	ext := New()
 
@@ -347,3 +861,231 @@ func TestDetect(t *testing.T) {
 		t.Error("expected Detect=false for directory without go.mod")
 	}
 }
+
+func TestExtract_TestedBy(t *testing.T) {
+	files := map[string]string{
+		"calc/calc.go": `package calc
+
+type Adder struct{}
+
+func (Adder) Add(a, b int) int { return a + b }
+
+func New() *Adder { return &Adder{} }
+
+func Unused() {}
+
+const Limit = 10
+`,
+		"calc/calc_test.go": `package calc
+
+import "testing"
+
+func TestNew(t *testing.T) {
+	if New() == nil || Limit == 0 {
+		t.Fatal("nil")
+	}
+}
+
+func helper() {}
+
+func Testify() {}
+`,
+		"calc/ext_test.go": `package calc_test
+
+import (
+	"testing"
+
+	c "testmod/calc"
+)
+
+func BenchmarkAdd(b *testing.B) {
+	var a c.Adder
+	a.Add(1, 2)
+}
+
+func ExampleNew() {
+	_ = c.New()
+}
+`,
+	}
+	dir := setupGoProject(t, files)
+
+	// The walk ignores _test.go files by default; they are read from disk.
+	ext := NewWithOptions(Options{Tests: true})
+	ff, err := ext.Extract(context.Background(), dir, []string{"calc/calc.go"})
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+
+	for sym, wantTests := range map[string][]string{
+		"calc.New":       {"calc.TestNew", "calc.ExampleNew"},
+		"calc.Adder":     {"calc.BenchmarkAdd"},
+		"calc.Adder.Add": {"calc.BenchmarkAdd"},
+	} {
+		f, ok := findFact(ff, sym)
+		if !ok {
+			t.Fatalf("expected fact %s", sym)
+		}
+		for _, test := range wantTests {
+			if !hasRelation(f, facts.RelTestedBy, test) {
+				t.Errorf("%s should be tested_by %s, relations: %v", sym, test, f.Relations)
+			}
+		}
+	}
+	unused, _ := findFact(ff, "calc.Unused")
+	for _, r := range unused.Relations {
+		if r.Kind == facts.RelTestedBy {
+			t.Errorf("calc.Unused is not referenced by tests, got tested_by %s", r.Target)
+		}
+	}
+
+	bench, ok := findFact(ff, "calc.BenchmarkAdd")
+	if !ok || bench.File != "calc/ext_test.go" || bench.Props["test_kind"] != "benchmark" || bench.Props["test"] != true {
+		t.Errorf("BenchmarkAdd fact = %+v", bench)
+	}
+	for _, name := range []string{"calc.helper", "calc.Testify"} {
+		if _, ok := findFact(ff, name); ok {
+			t.Errorf("%s is not a test function", name)
+		}
+	}
+	if len(bench.Relations) != 0 {
+		t.Errorf("test functions should have no relations, got %v", bench.Relations)
+	}
+
+	// Test files read from disk still honor the repository ignore rules.
+	ext.SetIgnored(func(relPath string) bool { return relPath == "calc/ext_test.go" })
+	ff, _ = ext.Extract(context.Background(), dir, []string{"calc/calc.go"})
+	if _, ok := findFact(ff, "calc.BenchmarkAdd"); ok {
+		t.Error("tests in an ignored file should not be linked")
+	}
+	if _, ok := findFact(ff, "calc.TestNew"); !ok {
+		t.Error("tests in other files should still be linked")
+	}
+
+	// Without the option, test files stay out of the facts.
+	ff, _ = New().Extract(context.Background(), dir, []string{"calc/calc.go"})
+	if _, ok := findFact(ff, "calc.TestNew"); ok {
+		t.Error("tests should only be linked with Options.Tests")
+	}
+}
+
+func TestExtract_Deprecated(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"pkg/old.go": `package pkg
+
+// OldFetch fetches a thing.
+//
+// Deprecated: Use Fetch instead,
+// which supports contexts.
+func OldFetch() {}
+
+// Fetch fetches a thing.
+func Fetch() {}
+
+// Legacy is kept for compatibility.
+//
+// Deprecated: Use Modern.
+type Legacy struct{}
+
+// Deprecated: events are delivered by Bus.
+var Events = make(chan int)
+
+// notDeprecated mentions Deprecated: in the middle of a sentence.
+func notDeprecated() {}
+`,
+	})
+
+	for name, want := range map[string]string{
+		"pkg.OldFetch": "Use Fetch instead, which supports contexts.",
+		"pkg.Legacy":   "Use Modern.",
+		"pkg.Events":   "events are delivered by Bus.",
+	} {
+		f, ok := findFact(ff, name)
+		if !ok {
+			t.Fatalf("expected fact %s", name)
+		}
+		if f.Props["deprecated"] != true || f.Props["deprecation"] != want {
+			t.Errorf("%s deprecated = %v, deprecation = %q, want %q", name, f.Props["deprecated"], f.Props["deprecation"], want)
+		}
+	}
+	for _, name := range []string{"pkg.Fetch", "pkg.notDeprecated"} {
+		if f, _ := findFact(ff, name); f.Props["deprecated"] != nil {
+			t.Errorf("%s should not be deprecated", name)
+		}
+	}
+}
+
+func TestExtract_EnvVarReads(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"cmd/server/main.go": `package main
+
+import "os"
+
+func main() {
+	addr := os.Getenv("LISTEN_ADDR")
+	if _, ok := os.LookupEnv("DEBUG"); ok {
+		println(addr)
+	}
+	key := "DYNAMIC"
+	_ = os.Getenv(key)
+}
+`,
+		"internal/db/db.go": `package db
+
+import sys "os"
+
+func DSN() string { return sys.Getenv("LISTEN_ADDR") + sys.Getenv("DATABASE_URL") }
+`,
+	})
+
+	configs := findFactsByKind(ff, facts.KindConfig)
+	if len(configs) != 3 {
+		t.Fatalf("config facts = %v, want LISTEN_ADDR, DEBUG and DATABASE_URL", configs)
+	}
+	addr, ok := findFact(ff, "env:LISTEN_ADDR")
+	if !ok {
+		t.Fatal("expected a config fact for LISTEN_ADDR")
+	}
+	reads, _ := addr.Props["reads"].([]string)
+	if addr.File != "cmd/server/main.go" || addr.Line != 6 || len(reads) != 2 || reads[1] != "internal/db/db.go:5" {
+		t.Errorf("LISTEN_ADDR at %s:%d, reads %v", addr.File, addr.Line, reads)
+	}
+	if !hasRelation(addr, facts.RelReadBy, "cmd/server") || !hasRelation(addr, facts.RelReadBy, "internal/db") {
+		t.Errorf("LISTEN_ADDR should be read by both modules: %v", addr.Relations)
+	}
+}
+
+func TestExtract_GoModDependencies(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"go.mod": `module testmod
+
+go 1.21
+
+require github.com/spf13/cobra v1.8.0
+
+require (
+	github.com/google/uuid v1.6.0
+	golang.org/x/sys v0.20.0 // indirect
+)
+`,
+		"main.go": "package main\n\nfunc main() {}\n",
+	})
+
+	cobra, ok := findFact(ff, "go.mod -> github.com/spf13/cobra")
+	if !ok {
+		t.Fatal("expected a dependency fact for cobra")
+	}
+	if cobra.Kind != facts.KindDependency || cobra.Line != 5 || cobra.Props["source"] != "external" || cobra.Props["version"] != "v1.8.0" {
+		t.Errorf("cobra = %+v", cobra)
+	}
+	if !hasRelation(cobra, facts.RelDependsOn, "github.com/spf13/cobra") {
+		t.Errorf("cobra relations = %v", cobra.Relations)
+	}
+	sys, _ := findFact(ff, "go.mod -> golang.org/x/sys")
+	if sys.Props["indirect"] != true || sys.Props["version"] != "v0.20.0" {
+		t.Errorf("x/sys props = %v, want an indirect v0.20.0", sys.Props)
+	}
+	if uuid, _ := findFact(ff, "go.mod -> github.com/google/uuid"); uuid.Line != 8 || uuid.Props["indirect"] != nil {
+		t.Errorf("uuid = %+v", uuid)
+	}
+}
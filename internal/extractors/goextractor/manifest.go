@@ -0,0 +1,46 @@
+package goextractor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/extractors"
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// manifestFacts returns a dependency fact per module required by the
+// repo's go.mod, in single-line and block form. Requirements marked
+// "// indirect" get an indirect prop.
+func manifestFacts(repoPath string) []facts.Fact {
+	data, err := os.ReadFile(filepath.Join(repoPath, "go.mod"))
+	if err != nil {
+		return nil
+	}
+
+	var result []facts.Fact
+	inBlock := false
+	for i, line := range strings.Split(string(data), "\n") {
+		line, comment, _ := strings.Cut(line, "//")
+		fields := strings.Fields(line)
+		switch {
+		case inBlock && len(fields) == 1 && fields[0] == ")":
+			inBlock = false
+			continue
+		case len(fields) == 2 && fields[0] == "require" && fields[1] == "(":
+			inBlock = true
+			continue
+		case len(fields) == 3 && fields[0] == "require":
+			fields = fields[1:]
+		case !inBlock || len(fields) != 2:
+			continue
+		}
+
+		f := extractors.ManifestDependency("go.mod", i+1, fields[0], fields[1], "go")
+		if strings.TrimSpace(comment) == "indirect" {
+			f.Props["indirect"] = true
+		}
+		result = append(result, f)
+	}
+	return result
+}
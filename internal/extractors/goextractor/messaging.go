@@ -0,0 +1,308 @@
+package goextractor
+
+import (
+	"go/ast"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// Message directions recorded on messaging sites and topic facts.
+const (
+	msgProduce = "producer"
+	msgConsume = "consumer"
+)
+
+// messagingClients maps the import paths of the supported message-queue
+// clients to their transport.
+var messagingClients = map[string]string{
+	"github.com/segmentio/kafka-go":                       "kafka",
+	"github.com/IBM/sarama":                               "kafka",
+	"github.com/Shopify/sarama":                           "kafka",
+	"github.com/confluentinc/confluent-kafka-go/kafka":    "kafka",
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka": "kafka",
+	"github.com/rabbitmq/amqp091-go":                      "rabbitmq",
+	"github.com/streadway/amqp":                           "rabbitmq",
+	"github.com/aws/aws-sdk-go-v2/service/sqs":            "sqs",
+	"github.com/aws/aws-sdk-go/service/sqs":               "sqs",
+}
+
+// messagingFields maps, per transport, the struct literals naming a topic or
+// queue to the field holding it and the direction of the message flow.
+var messagingFields = map[string]map[string]struct{ field, direction string }{
+	"kafka": {
+		"Writer":          {"Topic", msgProduce}, // kafka-go
+		"WriterConfig":    {"Topic", msgProduce},
+		"Message":         {"Topic", msgProduce},
+		"ReaderConfig":    {"Topic", msgConsume},
+		"ProducerMessage": {"Topic", msgProduce}, // sarama
+	},
+	"sqs": {
+		"SendMessageInput":      {"QueueUrl", msgProduce},
+		"SendMessageBatchInput": {"QueueUrl", msgProduce},
+		"ReceiveMessageInput":   {"QueueUrl", msgConsume},
+	},
+}
+
+// messagingCalls maps, per transport, the client methods that publish or
+// subscribe to the argument naming the topic or queue. For RabbitMQ
+// publishes the exchange is used, or the routing key for the default
+// exchange, which routes to the queue of that name.
+var messagingCalls = map[string]map[string]struct {
+	arg       int
+	direction string
+}{
+	"kafka": {
+		"ConsumePartition": {0, msgConsume}, // sarama
+		"Consume":          {1, msgConsume}, // sarama ConsumerGroup.Consume(ctx, topics, handler)
+		"Subscribe":        {0, msgConsume}, // confluent-kafka-go
+		"SubscribeTopics":  {0, msgConsume},
+	},
+	"rabbitmq": {
+		"Publish":            {0, msgProduce},
+		"PublishWithContext": {1, msgProduce},
+		"Consume":            {0, msgConsume},
+		"ConsumeWithContext": {1, msgConsume},
+	},
+}
+
+// messagingSite is one publish or subscribe call found in the source.
+type messagingSite struct {
+	transport, topic, direction string
+	function                    string // enclosing function symbol, or ""
+	file                        string
+	line                        int
+}
+
+// messagingIndex collects the messaging sites of an extraction.
+type messagingIndex struct {
+	sites []messagingSite
+}
+
+// addFile records the publish and subscribe calls of f made through one of
+// the messagingClients. Topics and queues must be string literals (or,
+// for SQS, aws.String of one); names built at runtime are not followed.
+func (m *messagingIndex) addFile(fset *token.FileSet, f *ast.File, relFile, pkgDir string) {
+	transports := make(map[string]string) // package name -> transport
+	for _, imp := range f.Imports {
+		importPath := strings.Trim(imp.Path.Value, `"`)
+		transport, ok := messagingClients[importPath]
+		if !ok {
+			continue
+		}
+		name := path.Base(importPath)
+		switch {
+		case imp.Name != nil:
+			name = imp.Name.Name
+		case strings.HasSuffix(importPath, "/kafka-go"):
+			name = "kafka"
+		case strings.HasSuffix(importPath, "/amqp091-go"):
+			name = "amqp"
+		}
+		transports[name] = transport
+	}
+	if len(transports) == 0 {
+		return
+	}
+
+	add := func(transport, topic, direction, function string, pos token.Pos) {
+		if topic == "" {
+			return
+		}
+		if transport == "sqs" {
+			topic = path.Base(topic) // queue URL -> queue name
+		}
+		m.sites = append(m.sites, messagingSite{
+			transport: transport, topic: topic, direction: direction,
+			function: function, file: relFile, line: fset.Position(pos).Line,
+		})
+	}
+
+	inspect := func(root ast.Node, function string) {
+		ast.Inspect(root, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.CompositeLit:
+				sel, ok := node.Type.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				pkg, ok := sel.X.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				transport := transports[pkg.Name]
+				rule, ok := messagingFields[transport][sel.Sel.Name]
+				if !ok {
+					return true
+				}
+				for _, elt := range node.Elts {
+					kv, ok := elt.(*ast.KeyValueExpr)
+					if !ok {
+						continue
+					}
+					if key, ok := kv.Key.(*ast.Ident); ok && key.Name == rule.field {
+						add(transport, stringValue(kv.Value), rule.direction, function, node.Pos())
+					}
+				}
+			case *ast.CallExpr:
+				sel, ok := node.Fun.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				for _, transport := range transports {
+					rule, ok := messagingCalls[transport][sel.Sel.Name]
+					if !ok || len(node.Args) <= rule.arg {
+						continue
+					}
+					arg := node.Args[rule.arg]
+					if list, ok := arg.(*ast.CompositeLit); ok {
+						for _, elt := range list.Elts {
+							add(transport, stringValue(elt), rule.direction, function, node.Pos())
+						}
+						continue
+					}
+					topic := stringValue(arg)
+					if topic == "" && transport == "rabbitmq" && rule.direction == msgProduce && len(node.Args) > rule.arg+1 {
+						topic = stringValue(node.Args[rule.arg+1]) // default exchange: the routing key
+					}
+					add(transport, topic, rule.direction, function, node.Pos())
+				}
+			}
+			return true
+		})
+	}
+
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			inspect(decl, "")
+			continue
+		}
+		if fn.Body == nil {
+			continue
+		}
+		name := fn.Name.Name
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			name = typeExprToString(fn.Recv.List[0].Type) + "." + name
+		}
+		inspect(fn.Body, pkgDir+"."+name)
+	}
+}
+
+// stringValue returns the value of a string literal, or of aws.String
+// applied to one, and "" for anything else.
+func stringValue(expr ast.Expr) string {
+	if call, ok := expr.(*ast.CallExpr); ok && len(call.Args) == 1 {
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "String" {
+			expr = call.Args[0]
+		}
+	}
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return ""
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+// link emits a route fact per topic or queue, named "<transport>:<topic>"
+// (e.g. "kafka:orders") with a transport prop, and connects the functions
+// around its sites: each producer gets a publishes relation to the topic,
+// and the topic gets a consumed_by relation to each consumer. The topic's
+// direction prop is producer, consumer, or both, after the sides found in
+// the repository. ff is the extraction's facts, whose producer symbols are
+// updated in place.
+func (m *messagingIndex) link(ff []facts.Fact) []facts.Fact {
+	if len(m.sites) == 0 {
+		return nil
+	}
+	sort.SliceStable(m.sites, func(i, j int) bool {
+		a, b := m.sites[i], m.sites[j]
+		if a.file != b.file {
+			return a.file < b.file
+		}
+		return a.line < b.line
+	})
+
+	symbols := make(map[string]int)
+	for i, f := range ff {
+		if f.Kind == facts.KindSymbol {
+			symbols[f.Name] = i
+		}
+	}
+
+	var topics []facts.Fact
+	byName := make(map[string]int)
+	for _, site := range m.sites {
+		name := site.transport + ":" + site.topic
+		idx, ok := byName[name]
+		if !ok {
+			idx = len(topics)
+			byName[name] = idx
+			topics = append(topics, facts.Fact{
+				Kind: facts.KindRoute,
+				Name: name,
+				File: site.file,
+				Line: site.line,
+				Props: map[string]any{
+					"transport": site.transport,
+					"topic":     site.topic,
+					"direction": site.direction,
+					"language":  "go",
+				},
+			})
+		}
+		topic := &topics[idx]
+		if topic.Props["direction"] != site.direction {
+			topic.Props["direction"] = "both"
+		}
+		if site.function == "" {
+			continue
+		}
+
+		switch site.direction {
+		case msgProduce:
+			addUniqueProp(topic.Props, "producers", site.function)
+			if i, ok := symbols[site.function]; ok {
+				rel := facts.Relation{Kind: facts.RelPublishes, Target: name}
+				if !hasRel(ff[i].Relations, rel) {
+					ff[i].Relations = append(ff[i].Relations, rel)
+				}
+			}
+		case msgConsume:
+			addUniqueProp(topic.Props, "consumers", site.function)
+			rel := facts.Relation{Kind: facts.RelConsumedBy, Target: site.function}
+			if !hasRel(topic.Relations, rel) {
+				topic.Relations = append(topic.Relations, rel)
+			}
+		}
+	}
+	return topics
+}
+
+// addUniqueProp appends value to the []string prop key unless present.
+func addUniqueProp(props map[string]any, key, value string) {
+	list, _ := props[key].([]string)
+	for _, v := range list {
+		if v == value {
+			return
+		}
+	}
+	props[key] = append(list, value)
+}
+
+func hasRel(rels []facts.Relation, rel facts.Relation) bool {
+	for _, r := range rels {
+		if r == rel {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package goextractor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+func TestExtractMessaging_Kafka(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"internal/api/orders.go": `package api
+
+import (
+	"net/http"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type Handler struct{ w *kafka.Writer }
+
+func (h *Handler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	h.w.WriteMessages(r.Context(), kafka.Message{Topic: "orders", Value: []byte("{}")})
+}
+`,
+		"internal/worker/worker.go": `package worker
+
+import kafka "github.com/segmentio/kafka-go"
+
+func Run() {
+	r := kafka.NewReader(kafka.ReaderConfig{Brokers: []string{"localhost:9092"}, Topic: "orders"})
+	_ = r
+}
+`,
+		"internal/audit/audit.go": `package audit
+
+import "github.com/IBM/sarama"
+
+func Listen(c sarama.Consumer, g sarama.ConsumerGroup, topic string) {
+	c.ConsumePartition("audit", 0, sarama.OffsetNewest)
+	c.ConsumePartition(topic, 0, sarama.OffsetNewest)
+	g.Consume(nil, []string{"audit", "payments"}, nil)
+}
+`,
+	})
+
+	orders, ok := findFact(ff, "kafka:orders")
+	if !ok {
+		t.Fatal("expected a route fact for the orders topic")
+	}
+	if orders.Kind != facts.KindRoute || orders.Props["transport"] != "kafka" || orders.Props["topic"] != "orders" || orders.Props["direction"] != "both" {
+		t.Errorf("orders topic = %+v", orders)
+	}
+	if orders.File != "internal/api/orders.go" || orders.Line != 12 {
+		t.Errorf("orders topic at %s:%d, want its first site internal/api/orders.go:12", orders.File, orders.Line)
+	}
+	if !hasRelation(orders, facts.RelConsumedBy, "internal/worker.Run") {
+		t.Errorf("orders should be consumed by worker.Run: %v", orders.Relations)
+	}
+	handler, _ := findFact(ff, "internal/api.Handler.CreateOrder")
+	if !hasRelation(handler, facts.RelPublishes, "kafka:orders") {
+		t.Errorf("CreateOrder should publish to kafka:orders: %v", handler.Relations)
+	}
+
+	audit, _ := findFact(ff, "kafka:audit")
+	if audit.Props["direction"] != "consumer" {
+		t.Errorf("audit direction = %v, want consumer", audit.Props["direction"])
+	}
+	if consumers, _ := audit.Props["consumers"].([]string); len(consumers) != 1 || consumers[0] != "internal/audit.Listen" {
+		t.Errorf("audit consumers = %v", audit.Props["consumers"])
+	}
+	if _, ok := findFact(ff, "kafka:payments"); !ok {
+		t.Error("expected a route fact for the payments topic of the consumer group")
+	}
+	if got := len(findFactsByKind(ff, facts.KindRoute)); got != 3 {
+		t.Errorf("route facts = %d, want orders, audit and payments (dynamic topics are skipped)", got)
+	}
+}
+
+func TestExtractMessaging_RabbitMQAndSQS(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"events/events.go": `package events
+
+import (
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+func Publish(ch *amqp.Channel) {
+	ch.PublishWithContext(nil, "", "emails", false, false, amqp.Publishing{})
+	ch.Publish("billing", "invoice.created", false, false, amqp.Publishing{})
+}
+
+func Consume(ch *amqp.Channel) {
+	ch.Consume("emails", "", true, false, false, false, nil)
+}
+
+func Enqueue(c *sqs.Client) {
+	c.SendMessage(nil, &sqs.SendMessageInput{QueueUrl: aws.String("https://sqs.eu-west-1.amazonaws.com/123/thumbnails")})
+}
+
+func Poll(c *sqs.Client) {
+	c.ReceiveMessage(nil, &sqs.ReceiveMessageInput{QueueUrl: aws.String("https://sqs.eu-west-1.amazonaws.com/123/thumbnails")})
+}
+`,
+	})
+
+	emails, _ := findFact(ff, "rabbitmq:emails")
+	if emails.Props["direction"] != "both" || !hasRelation(emails, facts.RelConsumedBy, "events.Consume") {
+		t.Errorf("emails queue = %+v", emails)
+	}
+	if _, ok := findFact(ff, "rabbitmq:billing"); !ok {
+		t.Error("expected a route fact for the billing exchange")
+	}
+	publish, _ := findFact(ff, "events.Publish")
+	if !hasRelation(publish, facts.RelPublishes, "rabbitmq:emails") || !hasRelation(publish, facts.RelPublishes, "rabbitmq:billing") {
+		t.Errorf("Publish relations = %v", publish.Relations)
+	}
+
+	queue, ok := findFact(ff, "sqs:thumbnails")
+	if !ok {
+		t.Fatal("expected a route fact for the thumbnails queue")
+	}
+	if queue.Props["direction"] != "both" || !hasRelation(queue, facts.RelConsumedBy, "events.Poll") {
+		t.Errorf("thumbnails queue = %+v", queue)
+	}
+}
+
+func TestExtractMessaging_FindPathAcrossQueue(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"api/api.go": `package api
+
+import (
+	"net/http"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func Signup(w http.ResponseWriter, r *http.Request) {
+	var wr kafka.Writer
+	wr.WriteMessages(r.Context(), kafka.Message{Topic: "signups"})
+}
+`,
+		"mailer/mailer.go": `package mailer
+
+import "github.com/segmentio/kafka-go"
+
+func Welcome() {
+	kafka.NewReader(kafka.ReaderConfig{Topic: "signups"})
+}
+`,
+	})
+
+	store := facts.NewStore()
+	store.Add(ff...)
+	store.BuildGraph()
+	result := store.Graph().FindPath(context.Background(), "api.Signup", "mailer.Welcome", nil, 0)
+	if !result.Found || len(result.Path) != 3 || result.Path[1].Name != "kafka:signups" {
+		t.Errorf("path = %+v, want api.Signup -> kafka:signups -> mailer.Welcome", result)
+	}
+}
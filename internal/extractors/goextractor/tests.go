@@ -0,0 +1,192 @@
+package goextractor
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/extractors"
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// Test function kinds recorded in the test_kind prop.
+var testPrefixes = []struct{ prefix, kind string }{
+	{"Test", "test"},
+	{"Benchmark", "benchmark"},
+	{"Fuzz", "fuzz"},
+	{"Example", "example"},
+}
+
+// packageTestFiles returns the _test.go files of pkgDir: those in the walked
+// file list plus any on disk, since the default ignore patterns keep test
+// files out of the walk. Files on disk that ignored reports are skipped, so
+// .archmcpignore still applies to them; ignored may be nil.
+func packageTestFiles(repoPath, pkgDir string, walked []string, ignored func(string) bool) []string {
+	seen := make(map[string]bool, len(walked))
+	files := append([]string(nil), walked...)
+	for _, f := range walked {
+		seen[f] = true
+	}
+	matches, _ := filepath.Glob(filepath.Join(repoPath, pkgDir, "*_test.go"))
+	for _, m := range matches {
+		rel := filepath.Join(pkgDir, filepath.Base(m))
+		if !seen[rel] && (ignored == nil || !ignored(rel)) {
+			seen[rel] = true
+			files = append(files, rel)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// linkTests parses the test files of pkgDir and returns a symbol fact for
+// each Test, Benchmark, Fuzz and Example function. Every production symbol
+// of the package that a test function references gets a tested_by relation
+// to it, appended to pkgFacts in place. Both internal (package foo) and
+// external (package foo_test) tests are linked. Test functions get no
+// declares relation, so they do not count toward module size or coupling.
+//
+// Without type information references are matched by name: identifiers
+// naming a package-level symbol, selectors on the package's import in
+// external tests, and selectors naming a method declared on exactly one
+// type of the package.
+func linkTests(fset *token.FileSet, repoPath, pkgDir string, testFiles []string, modulePath string, pkgFacts []facts.Fact, fileErrs *extractors.FileErrors) []facts.Fact {
+	if len(testFiles) == 0 {
+		return nil
+	}
+
+	// Index the package's symbols: top-level names and methods by name.
+	topLevel := make(map[string]int)
+	methods := make(map[string][]int)
+	for i, f := range pkgFacts {
+		if f.Kind != facts.KindSymbol || !strings.HasPrefix(f.Name, pkgDir+".") {
+			continue
+		}
+		rest := strings.TrimPrefix(f.Name, pkgDir+".")
+		if dot := strings.LastIndex(rest, "."); dot >= 0 {
+			methods[rest[dot+1:]] = append(methods[rest[dot+1:]], i)
+		} else {
+			topLevel[rest] = i
+		}
+	}
+	if len(topLevel) == 0 && len(methods) == 0 {
+		return nil
+	}
+	importPath := pkgDir
+	if modulePath != "" {
+		importPath = path.Join(modulePath, filepath.ToSlash(pkgDir))
+	}
+
+	var result []facts.Fact
+	for _, relFile := range testFiles {
+		absFile := filepath.Join(repoPath, relFile)
+		src, err := os.ReadFile(absFile)
+		if err != nil {
+			fileErrs.Add(relFile, err)
+			continue
+		}
+		f, err := parser.ParseFile(fset, absFile, src, 0)
+		if err != nil {
+			fileErrs.Add(relFile, err)
+			continue
+		}
+
+		// The name the package under test is imported as, in external tests.
+		var pkgAlias string
+		for _, imp := range f.Imports {
+			if strings.Trim(imp.Path.Value, `"`) != importPath {
+				continue
+			}
+			pkgAlias = path.Base(importPath)
+			if imp.Name != nil {
+				pkgAlias = imp.Name.Name
+			}
+		}
+		external := strings.HasSuffix(f.Name.Name, "_test")
+
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || fn.Body == nil {
+				continue
+			}
+			kind := testKind(fn.Name.Name)
+			if kind == "" {
+				continue
+			}
+			testName := pkgDir + "." + fn.Name.Name
+
+			linked := make(map[int]bool)
+			link := func(idx int) {
+				if linked[idx] {
+					return
+				}
+				linked[idx] = true
+				pkgFacts[idx].Relations = append(pkgFacts[idx].Relations, facts.Relation{
+					Kind:   facts.RelTestedBy,
+					Target: testName,
+				})
+			}
+			var visit func(n ast.Node) bool
+			visit = func(n ast.Node) bool {
+				switch x := n.(type) {
+				case *ast.SelectorExpr:
+					if id, ok := x.X.(*ast.Ident); ok && pkgAlias != "" && id.Name == pkgAlias {
+						if idx, ok := topLevel[x.Sel.Name]; ok {
+							link(idx)
+						}
+						return false
+					}
+					if idxs := methods[x.Sel.Name]; len(idxs) == 1 {
+						link(idxs[0])
+					}
+					// Only the operand can name a package-level symbol; the
+					// selected name is a field or method.
+					ast.Inspect(x.X, visit)
+					return false
+				case *ast.Ident:
+					if idx, ok := topLevel[x.Name]; ok && !external {
+						link(idx)
+					}
+				}
+				return true
+			}
+			ast.Inspect(fn.Body, visit)
+
+			result = append(result, facts.Fact{
+				Kind: facts.KindSymbol,
+				Name: testName,
+				File: relFile,
+				Line: fset.Position(fn.Pos()).Line,
+				Props: map[string]any{
+					"symbol_kind":    facts.SymbolFunc,
+					"test":           true,
+					"test_kind":      kind,
+					"test_framework": "testing",
+					"language":       "go",
+				},
+			})
+		}
+	}
+	return result
+}
+
+// testKind returns the test_kind of a function named like a test, benchmark,
+// fuzz target or example, or "" for any other function. As with go test, the
+// prefix must be followed by the end of the name or a non-lowercase letter.
+func testKind(name string) string {
+	for _, p := range testPrefixes {
+		rest, ok := strings.CutPrefix(name, p.prefix)
+		if !ok {
+			continue
+		}
+		if rest == "" || !(rest[0] >= 'a' && rest[0] <= 'z') {
+			return p.kind
+		}
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package kotlinextractor
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/extractors"
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// gradleDependencyRe matches a dependency declared with a string
+// coordinate in build.gradle or build.gradle.kts
+// (implementation("io.ktor:ktor-server-core:2.3.7"),
+// testImplementation 'junit:junit:4.13.2'), capturing the configuration,
+// group:artifact and optional version. Version catalog (libs.x) and project
+// dependencies are not matched.
+var gradleDependencyRe = regexp.MustCompile(`^\s*(\w+)\s*\(?\s*(?:platform\s*\(\s*)?["']([\w.-]+:[\w.-]+)(?::([^"':@]+))?(?:@\w+)?["']`)
+
+// gradleConfigurations are the dependency configurations recognized by
+// gradleDependencyFacts.
+var gradleConfigurations = map[string]bool{
+	"implementation": true, "api": true, "compileOnly": true, "runtimeOnly": true,
+	"testImplementation": true, "testCompileOnly": true, "testRuntimeOnly": true,
+	"androidTestImplementation": true, "debugImplementation": true,
+	"kapt": true, "ksp": true, "annotationProcessor": true, "classpath": true,
+	"compile": true, "testCompile": true,
+}
+
+// gradleDependencyFacts returns a dependency fact per group:artifact declared in
+// the build.gradle or build.gradle.kts files of the root and app/
+// directories, with its version and a configuration prop
+// ("implementation", "testImplementation", ...).
+func gradleDependencyFacts(repoPath string) []facts.Fact {
+	var result []facts.Fact
+	for _, manifest := range []string{"build.gradle.kts", "build.gradle", "app/build.gradle.kts", "app/build.gradle"} {
+		data, err := os.ReadFile(filepath.Join(repoPath, manifest))
+		if err != nil {
+			continue
+		}
+		for i, line := range strings.Split(string(data), "\n") {
+			m := gradleDependencyRe.FindStringSubmatch(line)
+			if m == nil || !gradleConfigurations[m[1]] {
+				continue
+			}
+			f := extractors.ManifestDependency(manifest, i+1, m[2], m[3], "kotlin")
+			f.Props["configuration"] = m[1]
+			result = append(result, f)
+		}
+	}
+	return result
+}
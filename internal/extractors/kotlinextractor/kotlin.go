@@ -3,12 +3,14 @@ package kotlinextractor
 import (
 	"bufio"
 	"context"
+	"encoding/xml"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 
+	"github.com/dejo1307/archmcp/internal/extractors"
 	"github.com/dejo1307/archmcp/internal/facts"
 )
 
@@ -43,6 +45,7 @@ func (e *KotlinExtractor) Detect(repoPath string) (bool, error) {
 // Extract parses Kotlin files and emits architectural facts.
 func (e *KotlinExtractor) Extract(ctx context.Context, repoPath string, files []string) ([]facts.Fact, error) {
 	var allFacts []facts.Fact
+	var fileErrs extractors.FileErrors
 
 	isAndroid := detectAndroidProject(repoPath)
 
@@ -66,7 +69,7 @@ func (e *KotlinExtractor) Extract(ctx context.Context, repoPath string, files []
 		absFile := filepath.Join(repoPath, relFile)
 		f, err := os.Open(absFile)
 		if err != nil {
-			log.Printf("[kotlin-extractor] error reading %s: %v", relFile, err)
+			fileErrs.Add(relFile, err)
 			continue
 		}
 
@@ -78,6 +81,17 @@ func (e *KotlinExtractor) Extract(ctx context.Context, repoPath string, files []
 		modules[dir] = true
 	}
 
+	if isAndroid {
+		markManifestActivities(allFacts, readManifestActivities(repoPath))
+	}
+
+	// Link override fun declarations to the supertype members they override.
+	extractors.LinkOverrides(allFacts, true)
+	// Link extension functions to the types they extend.
+	extractors.LinkExtensions(allFacts)
+
+	allFacts = append(allFacts, gradleDependencyFacts(repoPath)...)
+
 	for dir := range modules {
 		allFacts = append(allFacts, facts.Fact{
 			Kind: facts.KindModule,
@@ -89,7 +103,7 @@ func (e *KotlinExtractor) Extract(ctx context.Context, repoPath string, files []
 		})
 	}
 
-	return allFacts, nil
+	return allFacts, fileErrs.Err()
 }
 
 // --- Regex patterns ---
@@ -116,6 +130,15 @@ var (
 		`^\s*(?:(?:public|private|internal|protected|open|abstract|override|inline|suspend|operator|infix|tailrec|external)\s+)*` +
 			`fun\s+(?:<[^>]*>\s+)?(\w+)\s*\(`)
 
+	// Extension function declarations ("fun <T> List<T>.second()").
+	// Captures: receiver type (group 1), name (group 2).
+	extFuncRe = regexp.MustCompile(
+		`^\s*(?:(?:public|private|internal|protected|open|abstract|override|inline|suspend|operator|infix|tailrec|external)\s+)*` +
+			`fun\s+(?:<[^>]*>\s+)?((?:\w+\.)*\w+(?:<[^()]*>)?\??)\.(\w+)\s*\(`)
+
+	// Override modifier before a fun keyword.
+	overrideRe = regexp.MustCompile(`\boverride\s+(?:\w+\s+)*fun\s`)
+
 	// Top-level property declarations.
 	propRe = regexp.MustCompile(
 		`^\s*(?:(?:public|private|internal|protected|open|abstract|override|lateinit|const|lazy)\s+)*(val|var)\s+(\w+)`)
@@ -150,11 +173,14 @@ func extractFile(f *os.File, relFile string, isAndroid bool, sourceRoot, basePac
 		braceDepth         int
 		pendingAnnotations []string
 		pending            *pendingClass
+		memberType         string // top-level type whose body is being read
 	)
 
+	var lines []string
 	for scanner.Scan() {
 		lineNum++
 		line := scanner.Text()
+		lines = append(lines, line)
 
 		// Track brace depth for top-level detection.
 		braceDepth += strings.Count(line, "{") - strings.Count(line, "}")
@@ -174,6 +200,7 @@ func extractFile(f *os.File, relFile string, isAndroid bool, sourceRoot, basePac
 					}
 				}
 				result = append(result, fact)
+				memberType = fact.Name
 				pending = nil
 			}
 			continue
@@ -182,7 +209,7 @@ func extractFile(f *os.File, relFile string, isAndroid bool, sourceRoot, basePac
 		// Collect annotations (apply to the next declaration).
 		if m := annotationRe.FindStringSubmatch(line); m != nil {
 			trimmed := strings.TrimSpace(line)
-			if strings.HasPrefix(trimmed, "@") && !funcRe.MatchString(line) && !classRe.MatchString(line) && !objectRe.MatchString(line) {
+			if strings.HasPrefix(trimmed, "@") && !funcRe.MatchString(line) && !extFuncRe.MatchString(line) && !classRe.MatchString(line) && !objectRe.MatchString(line) {
 				pendingAnnotations = append(pendingAnnotations, m[1])
 				continue
 			}
@@ -193,6 +220,21 @@ func extractFile(f *os.File, relFile string, isAndroid bool, sourceRoot, basePac
 		inlineAnnotations := collectInlineAnnotations(line)
 		allAnnotations := append(pendingAnnotations, inlineAnnotations...)
 
+		// Functions declared directly in a top-level type body are methods.
+		// The depth is taken at the start of the line, so one-line bodies
+		// like "{}" count.
+		startDepth := effectiveDepth + strings.Count(line, "}")
+		if startDepth == 1 && memberType != "" {
+			if m := funcRe.FindStringSubmatch(line); m != nil {
+				result = append(result, buildMethodFact(memberType, m[1], line, relFile, lineNum))
+				pendingAnnotations = nil
+				continue
+			}
+		}
+		if startDepth == 0 && strings.TrimSpace(line) != "" {
+			memberType = ""
+		}
+
 		if effectiveDepth == 0 {
 			// Import statements.
 			if m := importRe.FindStringSubmatch(line); m != nil {
@@ -267,6 +309,7 @@ func extractFile(f *os.File, relFile string, isAndroid bool, sourceRoot, basePac
 					}
 				}
 				result = append(result, fact)
+				memberType = fact.Name
 				pendingAnnotations = nil
 				continue
 			}
@@ -305,6 +348,14 @@ func extractFile(f *os.File, relFile string, isAndroid bool, sourceRoot, basePac
 				}
 
 				result = append(result, of)
+				memberType = of.Name
+				pendingAnnotations = nil
+				continue
+			}
+
+			// Extension functions are named after the type they extend.
+			if m := extFuncRe.FindStringSubmatch(line); m != nil {
+				result = append(result, buildExtensionFact(dir, m[1], m[2], line, relFile, lineNum))
 				pendingAnnotations = nil
 				continue
 			}
@@ -410,9 +461,60 @@ func extractFile(f *os.File, relFile string, isAndroid bool, sourceRoot, basePac
 		}
 	}
 
+	extractors.MarkDeprecated(result, relFile, lines, kotlinDeprecation)
+	extractors.MarkAnnotations(result, relFile, lines)
+	if framework := kotlinTestFramework(lines); framework != "" {
+		extractors.MarkTests(result, relFile, lines, func(f facts.Fact, prelude []string) (string, string, bool) {
+			return extractors.TestKindTest, framework, f.Props["symbol_kind"] == facts.SymbolMethod && testAnnotationRe.MatchString(extractors.CodeText(prelude))
+		})
+	}
 	return result
 }
 
+// buildMethodFact creates a method symbol fact for a function declared in
+// the body of typeName, named after the type (e.g. "app/src/main.MainActivity.onCreate").
+func buildMethodFact(typeName, name, line, relFile string, lineNum int) facts.Fact {
+	dir := filepath.Dir(relFile)
+	f := facts.Fact{
+		Kind: facts.KindSymbol,
+		Name: typeName + "." + name,
+		File: relFile,
+		Line: lineNum,
+		Props: map[string]any{
+			"symbol_kind": facts.SymbolMethod,
+			"exported":    !privateOrInternalRe.MatchString(line),
+			"language":    "kotlin",
+			"receiver":    strings.TrimPrefix(typeName, dir+"."),
+		},
+		Relations: []facts.Relation{
+			{Kind: facts.RelDeclares, Target: dir},
+		},
+	}
+	if overrideRe.MatchString(line) {
+		f.Props["override"] = true
+	}
+	if strings.Contains(line, "suspend ") {
+		f.Props["suspend"] = true
+	}
+	return f
+}
+
+// buildExtensionFact creates a method symbol fact for a top-level extension
+// function, named after the simple name of its receiver type (e.g.
+// "app/src/main.String.isEmail" for "fun String?.isEmail()").
+func buildExtensionFact(dir, receiverType, name, line, relFile string, lineNum int) facts.Fact {
+	recv := strings.TrimSuffix(receiverType, "?")
+	if i := strings.Index(recv, "<"); i >= 0 {
+		recv = recv[:i]
+	}
+	recv = recv[strings.LastIndex(recv, ".")+1:]
+
+	f := buildMethodFact(dir+"."+recv, name, line, relFile, lineNum)
+	f.Props["extension"] = true
+	f.Props["receiver_type"] = receiverType
+	return f
+}
+
 // buildClassFact creates a symbol fact for a class/interface declaration.
 func buildClassFact(dir, relFile string, pc *pendingClass, supertypes string, isAndroid bool) facts.Fact {
 	symbolKind := facts.SymbolClass
@@ -462,6 +564,9 @@ func buildClassFact(dir, relFile string, pc *pendingClass, supertypes string, is
 		}
 	}
 
+	if containsAnnotation(pc.annotations, "SpringBootApplication") {
+		f.Props["entry_point"] = facts.EntrySpringBootApp
+	}
 	if isAndroid {
 		addAndroidProps(&f, pc.name, pc.annotations, supertypes)
 	}
@@ -495,13 +600,17 @@ func extractSupertypesFromText(text string) string {
 
 // --- Android detection helpers ---
 
-// detectAndroidProject checks for AndroidManifest.xml.
-func detectAndroidProject(repoPath string) bool {
-	candidates := []string{
+// manifestPaths returns the locations checked for AndroidManifest.xml.
+func manifestPaths(repoPath string) []string {
+	return []string{
 		filepath.Join(repoPath, "app", "src", "main", "AndroidManifest.xml"),
 		filepath.Join(repoPath, "src", "main", "AndroidManifest.xml"),
 	}
-	for _, p := range candidates {
+}
+
+// detectAndroidProject checks for AndroidManifest.xml.
+func detectAndroidProject(repoPath string) bool {
+	for _, p := range manifestPaths(repoPath) {
 		if _, err := os.Stat(p); err == nil {
 			return true
 		}
@@ -509,6 +618,83 @@ func detectAndroidProject(repoPath string) bool {
 	return false
 }
 
+// androidManifest is the subset of AndroidManifest.xml needed to find the
+// activities that can be started from outside the app.
+type androidManifest struct {
+	Activities []struct {
+		Name          string `xml:"name,attr"`
+		IntentFilters []struct {
+			Actions []struct {
+				Name string `xml:"name,attr"`
+			} `xml:"action"`
+			Categories []struct {
+				Name string `xml:"name,attr"`
+			} `xml:"category"`
+		} `xml:"intent-filter"`
+	} `xml:"application>activity"`
+}
+
+// readManifestActivities returns the activities declared with at least one
+// intent filter, keyed by simple class name. The value reports whether the
+// activity is the launcher (action MAIN, category LAUNCHER). A missing or
+// malformed manifest yields no activities.
+func readManifestActivities(repoPath string) map[string]bool {
+	activities := make(map[string]bool)
+	for _, p := range manifestPaths(repoPath) {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		var m androidManifest
+		if err := xml.Unmarshal(data, &m); err != nil {
+			log.Printf("[kotlin] parsing %s: %v", p, err)
+			continue
+		}
+		for _, a := range m.Activities {
+			if len(a.IntentFilters) == 0 {
+				continue
+			}
+			name := a.Name[strings.LastIndex(a.Name, ".")+1:]
+			if name == "" {
+				continue
+			}
+			for _, f := range a.IntentFilters {
+				var main, launcher bool
+				for _, act := range f.Actions {
+					main = main || act.Name == "android.intent.action.MAIN"
+				}
+				for _, c := range f.Categories {
+					launcher = launcher || c.Name == "android.intent.category.LAUNCHER"
+				}
+				activities[name] = activities[name] || (main && launcher)
+			}
+		}
+	}
+	return activities
+}
+
+// markManifestActivities marks the classes named by activities as activity
+// entry points, and the launcher activity with launcher: true.
+func markManifestActivities(ff []facts.Fact, activities map[string]bool) {
+	if len(activities) == 0 {
+		return
+	}
+	for i := range ff {
+		f := &ff[i]
+		if f.Kind != facts.KindSymbol || f.Props["symbol_kind"] != facts.SymbolClass {
+			continue
+		}
+		launcher, ok := activities[f.Name[strings.LastIndex(f.Name, ".")+1:]]
+		if !ok {
+			continue
+		}
+		f.Props["entry_point"] = facts.EntryActivity
+		if launcher {
+			f.Props["launcher"] = true
+		}
+	}
+}
+
 // addAndroidProps classifies a class/interface declaration as an Android component.
 func addAndroidProps(f *facts.Fact, name string, annotations []string, supertypes string) {
 	// Annotation-based classification.
@@ -684,6 +870,41 @@ func collectInlineAnnotations(line string) []string {
 	return result
 }
 
+// deprecatedRe matches a @Deprecated annotation (kotlin.Deprecated or
+// java.lang.Deprecated) and captures its message argument, if any.
+var deprecatedRe = regexp.MustCompile(`@(?:[\w.]+\.)?Deprecated\b(?:\s*\(\s*(?:message\s*=\s*)?"((?:[^"\\]|\\.)*)")?`)
+
+// kotlinDeprecation detects a @Deprecated annotation in a declaration's
+// prelude and returns its message.
+func kotlinDeprecation(prelude []string) (string, bool) {
+	m := deprecatedRe.FindStringSubmatch(extractors.CodeText(prelude))
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// testAnnotationRe matches the JUnit and kotlin.test annotations that mark a
+// test method. Class-level annotations such as @TestInstance do not match.
+var testAnnotationRe = regexp.MustCompile(`@(?:[\w.]+\.)?(?:Test|ParameterizedTest|RepeatedTest|TestFactory)\b`)
+
+// kotlinTestFramework returns the test framework a file imports: "junit5",
+// "junit4" or "kotlin.test", or "" when it imports none of them.
+func kotlinTestFramework(lines []string) string {
+	for _, line := range lines {
+		t := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(t, "import org.junit.jupiter."):
+			return "junit5"
+		case strings.HasPrefix(t, "import org.junit."):
+			return "junit4"
+		case strings.HasPrefix(t, "import kotlin.test."):
+			return "kotlin.test"
+		}
+	}
+	return ""
+}
+
 func containsAnnotation(annotations []string, name string) bool {
 	for _, a := range annotations {
 		if a == name {
@@ -772,6 +993,49 @@ func detectKotlinBasePackage(repoPath string) string {
 	return ""
 }
 
+// gradleFrameworks match framework versions in Gradle build files, either as
+// a plugin (id("org.springframework.boot") version "3.2.0") or as a
+// dependency coordinate ("org.springframework.boot:spring-boot-starter:3.2.0").
+// Versions given through variables ($ktorVersion) are not resolved.
+var gradleFrameworks = []struct {
+	name  string
+	regex *regexp.Regexp
+}{
+	{"spring-boot", regexp.MustCompile(`id\s*\(?\s*["']org\.springframework\.boot["']\s*\)?\s+version\s+["']([^"'$]+)["']`)},
+	{"spring-boot", regexp.MustCompile(`["']org\.springframework\.boot:spring-boot[\w-]*:([\w.+-]+)["']`)},
+	{"ktor", regexp.MustCompile(`id\s*\(?\s*["']io\.ktor\.plugin["']\s*\)?\s+version\s+["']([^"'$]+)["']`)},
+	{"ktor", regexp.MustCompile(`["']io\.ktor:ktor-[\w-]+:([\w.+-]+)["']`)},
+}
+
+// DetectFrameworks reads Spring Boot and Ktor versions from the build.gradle
+// or build.gradle.kts files in the root and app/ directories.
+func (e *KotlinExtractor) DetectFrameworks(repoPath string) []facts.FrameworkInfo {
+	candidates := []string{
+		filepath.Join(repoPath, "build.gradle.kts"),
+		filepath.Join(repoPath, "build.gradle"),
+		filepath.Join(repoPath, "app", "build.gradle.kts"),
+		filepath.Join(repoPath, "app", "build.gradle"),
+	}
+	var out []facts.FrameworkInfo
+	seen := make(map[string]bool)
+	for _, path := range candidates {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for _, fw := range gradleFrameworks {
+			if seen[fw.name] {
+				continue
+			}
+			if m := fw.regex.FindSubmatch(data); m != nil {
+				seen[fw.name] = true
+				out = append(out, facts.FrameworkInfo{Name: fw.name, Version: string(m[1])})
+			}
+		}
+	}
+	return out
+}
+
 // resolveKotlinImport normalizes a Kotlin import path.
 // Internal imports (matching the base package) are converted from dotted package names
 // to filesystem-relative paths so the graph can match them to module facts.
@@ -1,10 +1,13 @@
 package kotlinextractor
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
+	"github.com/dejo1307/archmcp/internal/extractors"
 	"github.com/dejo1307/archmcp/internal/facts"
 )
 
@@ -336,3 +339,331 @@ enum class Direction {
 		t.Errorf("enum = %v, want true", f.Props["enum"])
 	}
 }
+
+func TestDetectFrameworks_Gradle(t *testing.T) {
+	dir := t.TempDir()
+	build := `plugins {
+    kotlin("jvm") version "1.9.22"
+    id("org.springframework.boot") version "3.2.1"
+}
+
+dependencies {
+    implementation("io.ktor:ktor-server-core:$ktorVersion")
+    implementation("io.ktor:ktor-client-cio:2.3.7")
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "build.gradle.kts"), []byte(build), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got := New().DetectFrameworks(dir)
+	want := []facts.FrameworkInfo{{Name: "spring-boot", Version: "3.2.1"}, {Name: "ktor", Version: "2.3.7"}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("DetectFrameworks = %v, want %v", got, want)
+	}
+}
+
+func TestExtract_Overrides(t *testing.T) {
+	ff := extractFromString(t, `
+interface Repository {
+    fun load(id: String): Item
+}
+
+open class BaseRepository : Repository {
+    override fun load(id: String): Item = fetch(id)
+    open fun close() {}
+}
+
+class CachedRepository(private val cache: Cache) : BaseRepository() {
+    init { cache.warm() }
+    override fun load(id: String): Item {
+        return cache.get(id)
+    }
+    private fun evict() {}
+    companion object {
+        fun create(): CachedRepository = CachedRepository(Cache())
+    }
+}
+`, false)
+	extractors.LinkOverrides(ff, true)
+
+	load, ok := findFact(ff, "pkg.CachedRepository.load")
+	if !ok {
+		t.Fatal("expected method fact for pkg.CachedRepository.load")
+	}
+	if load.Props["symbol_kind"] != facts.SymbolMethod || load.Props["override"] != true {
+		t.Errorf("props = %v, want an override method", load.Props)
+	}
+	if !hasRelation(load, facts.RelOverrides, "pkg.BaseRepository.load") {
+		t.Errorf("expected overrides -> pkg.BaseRepository.load, got %v", load.Relations)
+	}
+	base, _ := findFact(ff, "pkg.BaseRepository.load")
+	if !hasRelation(base, facts.RelOverrides, "pkg.Repository.load") {
+		t.Errorf("expected overrides -> pkg.Repository.load, got %v", base.Relations)
+	}
+
+	if evict, _ := findFact(ff, "pkg.CachedRepository.evict"); evict.Props["exported"] != false {
+		t.Error("private method should not be exported")
+	}
+	if _, ok := findFact(ff, "pkg.CachedRepository.create"); ok {
+		t.Error("companion object members should not be methods of the class")
+	}
+}
+
+func TestExtract_ExtensionFunctions(t *testing.T) {
+	ff := extractFromString(t, `
+class User(val email: String)
+
+fun User.displayName(): String = email.substringBefore("@")
+internal fun String?.isEmail(): Boolean = this?.contains("@") == true
+inline fun <T> List<T>.second(): T = this[1]
+fun topLevel() = Unit
+`, false)
+	extractors.LinkExtensions(ff)
+
+	dn, ok := findFact(ff, "pkg.User.displayName")
+	if !ok {
+		t.Fatal("expected extension fact for pkg.User.displayName")
+	}
+	if dn.Props["symbol_kind"] != facts.SymbolMethod || dn.Props["extension"] != true || dn.Props["receiver"] != "User" {
+		t.Errorf("props = %v", dn.Props)
+	}
+	if !hasRelation(dn, facts.RelDependsOn, "pkg.User") {
+		t.Errorf("expected depends_on -> pkg.User, got %v", dn.Relations)
+	}
+
+	email, ok := findFact(ff, "pkg.String.isEmail")
+	if !ok {
+		t.Fatal("expected extension fact for pkg.String.isEmail")
+	}
+	if email.Props["receiver_type"] != "String?" || email.Props["exported"] != false {
+		t.Errorf("props = %v", email.Props)
+	}
+	// String is not declared in the repo, so the bare name is the target.
+	if !hasRelation(email, facts.RelDependsOn, "String") {
+		t.Errorf("expected depends_on -> String, got %v", email.Relations)
+	}
+
+	if second, ok := findFact(ff, "pkg.List.second"); !ok || second.Props["receiver_type"] != "List<T>" {
+		t.Errorf("expected generic extension pkg.List.second, got %v", second.Props)
+	}
+	if f, _ := findFact(ff, "pkg.topLevel"); f.Props["symbol_kind"] != facts.SymbolFunc {
+		t.Errorf("plain functions should stay functions, got %v", f.Props)
+	}
+}
+
+func TestExtract_EntryPoints(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app/src/main/AndroidManifest.xml": `<?xml version="1.0" encoding="utf-8"?>
+<manifest xmlns:android="http://schemas.android.com/apk/res/android" package="com.example">
+    <application android:name=".App">
+        <activity android:name=".MainActivity" android:exported="true">
+            <intent-filter>
+                <action android:name="android.intent.action.MAIN" />
+                <category android:name="android.intent.category.LAUNCHER" />
+            </intent-filter>
+        </activity>
+        <activity android:name="com.example.share.ShareActivity">
+            <intent-filter>
+                <action android:name="android.intent.action.SEND" />
+            </intent-filter>
+        </activity>
+        <activity android:name=".SettingsActivity" />
+    </application>
+</manifest>
+`,
+		"app/src/main/kotlin/MainActivity.kt":     "class MainActivity : AppCompatActivity()\n",
+		"app/src/main/kotlin/ShareActivity.kt":    "class ShareActivity : AppCompatActivity()\n",
+		"app/src/main/kotlin/SettingsActivity.kt": "class SettingsActivity : AppCompatActivity()\n",
+		"server/Application.kt":                   "@SpringBootApplication\nclass Application\n",
+	}
+	var rel []string
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		rel = append(rel, name)
+	}
+	ff, err := New().Extract(context.Background(), dir, rel)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+
+	main, _ := findFact(ff, "app/src/main/kotlin.MainActivity")
+	if main.Props["entry_point"] != facts.EntryActivity || main.Props["launcher"] != true {
+		t.Errorf("MainActivity props = %v, want a launcher activity entry point", main.Props)
+	}
+	share, _ := findFact(ff, "app/src/main/kotlin.ShareActivity")
+	if share.Props["entry_point"] != facts.EntryActivity || share.Props["launcher"] != nil {
+		t.Errorf("ShareActivity props = %v, want a non-launcher activity entry point", share.Props)
+	}
+	// Declared without an intent filter: only reachable from inside the app.
+	if settings, _ := findFact(ff, "app/src/main/kotlin.SettingsActivity"); settings.Props["entry_point"] != nil {
+		t.Errorf("SettingsActivity entry_point = %v, want none", settings.Props["entry_point"])
+	}
+	if app, _ := findFact(ff, "server.Application"); app.Props["entry_point"] != facts.EntrySpringBootApp {
+		t.Errorf("Application entry_point = %v, want %s", app.Props["entry_point"], facts.EntrySpringBootApp)
+	}
+}
+
+func TestExtract_Deprecated(t *testing.T) {
+	ff := extractFromString(t, `package com.example
+
+@Deprecated("Use NewClient")
+class OldClient
+
+@Deprecated(
+    message = "Use fetch",
+    level = DeprecationLevel.WARNING
+)
+fun load() = Unit
+
+@java.lang.Deprecated
+fun legacy() = Unit
+
+/** Not @Deprecated, just documented. */
+fun current() = Unit
+
+@Deprecated("Use NewVal")
+val oldVal = 1
+`, false)
+
+	for name, want := range map[string]string{
+		"pkg.OldClient": "Use NewClient",
+		"pkg.load":      "Use fetch",
+		"pkg.legacy":    "",
+		"pkg.oldVal":    "Use NewVal",
+	} {
+		f, ok := findFact(ff, name)
+		if !ok {
+			t.Fatalf("expected fact %s", name)
+		}
+		msg, _ := f.Props["deprecation"].(string)
+		if f.Props["deprecated"] != true || msg != want {
+			t.Errorf("%s deprecated = %v, deprecation = %q, want %q", name, f.Props["deprecated"], msg, want)
+		}
+	}
+	if f, _ := findFact(ff, "pkg.current"); f.Props["deprecated"] != nil {
+		t.Error("a @Deprecated mention in a comment should not deprecate the symbol")
+	}
+}
+
+func TestExtract_Annotations(t *testing.T) {
+	ff := extractFromString(t, `package com.example
+
+@Service
+@Transactional(readOnly = true)
+class OrderService(private val repo: OrderRepository) {
+    @Transactional
+    @Cacheable(
+        value = ["orders"],
+        key = "#id"
+    )
+    fun find(id: String): Order = repo.get(id)
+
+    @field:JsonProperty("total")
+    val total: Int = 0
+
+    // @Transactional is documented, not applied
+    fun plain() = Unit
+}
+`, false)
+
+	for name, want := range map[string][]string{
+		"pkg.OrderService":      {"Service", "Transactional"},
+		"pkg.OrderService.find": {"Transactional", "Cacheable"},
+	} {
+		f, ok := findFact(ff, name)
+		if !ok {
+			t.Fatalf("expected fact %s", name)
+		}
+		if got, _ := f.Props["annotations"].([]string); !slices.Equal(got, want) {
+			t.Errorf("%s annotations = %v, want %v", name, got, want)
+		}
+	}
+	if f, _ := findFact(ff, "pkg.OrderService.plain"); f.Props["annotations"] != nil {
+		t.Errorf("annotations = %v, want none from a comment", f.Props["annotations"])
+	}
+}
+
+func TestExtract_JUnitTests(t *testing.T) {
+	ff := extractFromString(t, `package com.example
+
+import org.junit.jupiter.api.Test
+import org.junit.jupiter.params.ParameterizedTest
+
+class UserServiceTest {
+    @Test
+    fun savesUser() {
+        service.save(user)
+    }
+
+    @ParameterizedTest
+    @ValueSource(strings = ["a", "b"])
+    fun rejectsInvalid(name: String) {
+        assertThrows { service.save(name) }
+    }
+
+    private fun user(): User {
+        return User("a")
+    }
+}
+`, false)
+
+	for _, name := range []string{"pkg.UserServiceTest.savesUser", "pkg.UserServiceTest.rejectsInvalid"} {
+		f, ok := findFact(ff, name)
+		if !ok {
+			t.Fatalf("expected fact %s", name)
+		}
+		if f.Props["test"] != true || f.Props["test_kind"] != "test" || f.Props["test_framework"] != "junit5" {
+			t.Errorf("%s props = %v, want a junit5 test", name, f.Props)
+		}
+	}
+	if helper, ok := findFact(ff, "pkg.UserServiceTest.user"); !ok || helper.Props["test"] != nil {
+		t.Errorf("helper without @Test should not be a test: %+v", helper)
+	}
+}
+
+func TestGradleDependencyFacts(t *testing.T) {
+	dir := t.TempDir()
+	build := `plugins {
+    kotlin("jvm") version "1.9.22"
+}
+
+dependencies {
+    implementation("io.ktor:ktor-server-core:2.3.7")
+    implementation(platform("org.jetbrains.kotlinx:kotlinx-coroutines-bom:1.8.0"))
+    implementation(libs.serialization)
+    implementation(project(":core"))
+    testImplementation 'junit:junit:4.13.2'
+    kapt("com.google.dagger:dagger-compiler")
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "build.gradle.kts"), []byte(build), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ff := gradleDependencyFacts(dir)
+	want := []struct{ name, version, configuration string }{
+		{"io.ktor:ktor-server-core", "2.3.7", "implementation"},
+		{"org.jetbrains.kotlinx:kotlinx-coroutines-bom", "1.8.0", "implementation"},
+		{"junit:junit", "4.13.2", "testImplementation"},
+		{"com.google.dagger:dagger-compiler", "", "kapt"},
+	}
+	if len(ff) != len(want) {
+		t.Fatalf("dependencies = %+v, want %d", ff, len(want))
+	}
+	for i, w := range want {
+		f := ff[i]
+		version, _ := f.Props["version"].(string)
+		if f.Name != "build.gradle.kts -> "+w.name || version != w.version || f.Props["configuration"] != w.configuration {
+			t.Errorf("dependency %d = %s %q %v, want %s %q %s", i, f.Name, version, f.Props["configuration"], w.name, w.version, w.configuration)
+		}
+	}
+	if ff[0].Line != 6 || !hasRelation(ff[0], facts.RelDependsOn, "io.ktor:ktor-server-core") {
+		t.Errorf("ktor = %+v", ff[0])
+	}
+}
@@ -0,0 +1,48 @@
+package extractors
+
+import (
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// ManifestDependency returns the fact for a package declared in a
+// dependency manifest (go.mod, package.json, Gemfile, ...), as opposed to
+// the per-file import facts. It is named "<manifest> -> <package>", has
+// source "external", the manifest, package and declared version (omitted
+// when the manifest pins none) as props, and a depends_on relation to the
+// package. Callers add ecosystem-specific props such as dev or indirect.
+func ManifestDependency(manifest string, line int, pkg, version, language string) facts.Fact {
+	f := facts.Fact{
+		Kind: facts.KindDependency,
+		Name: manifest + " -> " + pkg,
+		File: manifest,
+		Line: line,
+		Props: map[string]any{
+			"language": language,
+			"source":   "external",
+			"manifest": manifest,
+			"package":  pkg,
+		},
+		Relations: []facts.Relation{
+			{Kind: facts.RelDependsOn, Target: pkg},
+		},
+	}
+	if version != "" {
+		f.Props["version"] = version
+	}
+	return f
+}
+
+// LineOf returns the 1-based line of the first occurrence of substr in src
+// at or after line from, or from when there is none. Manifest parsers that
+// decode a whole file (such as package.json) use it to locate entries.
+func LineOf(src, substr string, from int) int {
+	lines := strings.Split(src, "\n")
+	for i := max(from-1, 0); i < len(lines); i++ {
+		if strings.Contains(lines[i], substr) {
+			return i + 1
+		}
+	}
+	return from
+}
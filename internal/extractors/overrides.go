@@ -0,0 +1,116 @@
+package extractors
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// LinkOverrides adds an overrides relation from each method in ff to the
+// method of the same name on its nearest supertype, so traversals can follow
+// polymorphic dispatch up and down a hierarchy.
+//
+// Methods are symbol facts of kind method whose receiver prop names a type
+// declared in the same directory ("<dir>.<Type>.<method>"). Supertypes are
+// the implements relations of type facts, matched by simple name and
+// preferring a type in the subtype's own directory; supertypes outside the
+// repository end the search. When explicitOnly is set, only methods with an
+// override: true prop are linked, for languages that mark overrides.
+func LinkOverrides(ff []facts.Fact, explicitOnly bool) {
+	type typeInfo struct {
+		dir    string
+		supers []string
+	}
+	types := make(map[string]*typeInfo)
+	byShort := make(map[string][]string)
+	methods := make(map[string]map[string]string) // type -> method -> fact name
+
+	for _, f := range ff {
+		if f.Kind != facts.KindSymbol {
+			continue
+		}
+		switch f.Props["symbol_kind"] {
+		case facts.SymbolClass, facts.SymbolStruct, facts.SymbolInterface:
+			info := &typeInfo{dir: filepath.Dir(f.File)}
+			for _, r := range f.Relations {
+				if r.Kind == facts.RelImplements {
+					info.supers = append(info.supers, simpleTypeName(r.Target))
+				}
+			}
+			types[f.Name] = info
+			short := simpleTypeName(f.Name)
+			byShort[short] = append(byShort[short], f.Name)
+		case facts.SymbolMethod:
+			recv, _ := f.Props["receiver"].(string)
+			if recv == "" {
+				continue
+			}
+			owner := filepath.Dir(f.File) + "." + recv
+			if methods[owner] == nil {
+				methods[owner] = make(map[string]string)
+			}
+			methods[owner][f.Name[strings.LastIndex(f.Name, ".")+1:]] = f.Name
+		}
+	}
+
+	// resolve maps a supertype's simple name to a type fact.
+	resolve := func(short, fromDir string) string {
+		candidates := byShort[short]
+		for _, c := range candidates {
+			if types[c].dir == fromDir {
+				return c
+			}
+		}
+		if len(candidates) == 1 {
+			return candidates[0]
+		}
+		return ""
+	}
+
+	for i := range ff {
+		f := &ff[i]
+		if f.Kind != facts.KindSymbol || f.Props["symbol_kind"] != facts.SymbolMethod {
+			continue
+		}
+		if override, _ := f.Props["override"].(bool); explicitOnly && !override {
+			continue
+		}
+		recv, _ := f.Props["receiver"].(string)
+		owner, ok := types[filepath.Dir(f.File)+"."+recv]
+		if recv == "" || !ok {
+			continue
+		}
+		name := f.Name[strings.LastIndex(f.Name, ".")+1:]
+
+		// Breadth-first, so the nearest declaration wins.
+		visited := make(map[string]bool)
+		var queue []string
+		for _, s := range owner.supers {
+			queue = append(queue, resolve(s, owner.dir))
+		}
+		for len(queue) > 0 {
+			t := queue[0]
+			queue = queue[1:]
+			if t == "" || visited[t] {
+				continue
+			}
+			visited[t] = true
+			if target, ok := methods[t][name]; ok {
+				f.Relations = append(f.Relations, facts.Relation{Kind: facts.RelOverrides, Target: target})
+				break
+			}
+			for _, s := range types[t].supers {
+				queue = append(queue, resolve(s, types[t].dir))
+			}
+		}
+	}
+}
+
+// simpleTypeName strips qualifiers and type arguments: "pkg.Base<T>" -> "Base".
+func simpleTypeName(name string) string {
+	if i := strings.Index(name, "<"); i >= 0 {
+		name = name[:i]
+	}
+	return name[strings.LastIndex(name, ".")+1:]
+}
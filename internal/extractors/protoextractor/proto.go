@@ -0,0 +1,582 @@
+package protoextractor
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/extractors"
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// ProtoExtractor extracts the gRPC API surface from Protocol Buffers
+// definitions. Each rpc becomes a KindRoute fact named after its gRPC path,
+// and messages, enums and services become symbols. Type references resolve
+// across files by fully qualified proto name, so message fields and rpc
+// signatures turn into depends_on relations.
+type ProtoExtractor struct{}
+
+// New creates a new ProtoExtractor.
+func New() *ProtoExtractor {
+	return &ProtoExtractor{}
+}
+
+func (e *ProtoExtractor) Name() string {
+	return "proto"
+}
+
+// Detect returns true if the repository contains a .proto file.
+func (e *ProtoExtractor) Detect(repoPath string) (bool, error) {
+	found := false
+	err := filepath.WalkDir(repoPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || found {
+			return err
+		}
+		if d.IsDir() {
+			if p != repoPath && skipDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isProtoFile(p) {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found, err
+}
+
+// Extract parses every .proto file first, so references to messages defined
+// in other files resolve, then emits the facts file by file.
+func (e *ProtoExtractor) Extract(ctx context.Context, repoPath string, files []string) ([]facts.Fact, error) {
+	var fileErrs extractors.FileErrors
+	var defs []*protoDef
+
+	for _, relFile := range files {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		if !isProtoFile(relFile) {
+			continue
+		}
+		src, err := os.ReadFile(filepath.Join(repoPath, relFile))
+		if err != nil {
+			fileErrs.Add(relFile, err)
+			continue
+		}
+		defs = append(defs, parseFile(string(src), filepath.ToSlash(relFile))...)
+	}
+
+	idx := make(map[string]*protoDef, len(defs))
+	for _, d := range defs {
+		if d.kind != defService {
+			idx[d.full] = d
+		}
+	}
+
+	var allFacts []facts.Fact
+	for _, d := range defs {
+		allFacts = append(allFacts, d.facts(idx)...)
+	}
+	return allFacts, fileErrs.Err()
+}
+
+// Definition kinds.
+const (
+	defMessage = "message"
+	defEnum    = "enum"
+	defService = "service"
+)
+
+// protoDef is a message, enum or service declared in a .proto file.
+type protoDef struct {
+	kind   string
+	full   string // fully qualified proto name, e.g. "acme.users.v1.User"
+	pkg    string
+	file   string
+	line   int
+	fields []protoField
+	values []string // enum values
+	rpcs   []protoRPC
+}
+
+type protoField struct {
+	name string
+	typ  string // as written, e.g. "repeated Address" or "map<string, Address>"
+	ref  string // the referenced type name, e.g. "Address"
+}
+
+type protoRPC struct {
+	name         string
+	req, resp    string
+	clientStream bool
+	serverStream bool
+	line         int
+}
+
+// factName returns the fact name of the definition: its fully qualified
+// proto name, or "<dir>.<Name>" for files without a package declaration.
+func (d *protoDef) factName() string {
+	if d.pkg != "" {
+		return d.full
+	}
+	return path.Dir(d.file) + "." + d.full
+}
+
+// scope is where type references inside the definition are resolved from.
+func (d *protoDef) scope() string {
+	if d.kind == defService {
+		return d.pkg
+	}
+	return d.full
+}
+
+// resolve looks a type reference up the way protoc does: a leading dot means
+// fully qualified, otherwise the reference is tried in the innermost scope
+// first and then in each enclosing one. Scalars and types defined outside
+// the repository, such as google.protobuf.Timestamp, yield nil.
+func resolve(idx map[string]*protoDef, scope, ref string) *protoDef {
+	if full, ok := strings.CutPrefix(ref, "."); ok {
+		return idx[full]
+	}
+	for s := scope; s != ""; s = parentScope(s) {
+		if d, ok := idx[s+"."+ref]; ok {
+			return d
+		}
+	}
+	return idx[ref]
+}
+
+func parentScope(s string) string {
+	if i := strings.LastIndex(s, "."); i >= 0 {
+		return s[:i]
+	}
+	return ""
+}
+
+// facts emits the definition's symbol fact, plus one route fact per rpc of
+// a service.
+func (d *protoDef) facts(idx map[string]*protoDef) []facts.Fact {
+	dir := path.Dir(d.file)
+	props := map[string]any{
+		"language":   "proto",
+		"proto_kind": d.kind,
+		"exported":   true,
+	}
+	if d.pkg != "" {
+		props["package"] = d.pkg
+	}
+	rels := []facts.Relation{{Kind: facts.RelDeclares, Target: dir}}
+	seen := map[string]bool{d.factName(): true}
+	dependOn := func(rels []facts.Relation, target *protoDef) []facts.Relation {
+		if target == nil || seen[target.factName()] {
+			return rels
+		}
+		seen[target.factName()] = true
+		return append(rels, facts.Relation{Kind: facts.RelDependsOn, Target: target.factName()})
+	}
+
+	var routes []facts.Fact
+	switch d.kind {
+	case defMessage:
+		props["symbol_kind"] = facts.SymbolStruct
+		if len(d.fields) > 0 {
+			fields := make([]map[string]any, len(d.fields))
+			for i, f := range d.fields {
+				fields[i] = map[string]any{"name": f.name, "type": f.typ}
+				rels = dependOn(rels, resolve(idx, d.scope(), f.ref))
+			}
+			props["fields"] = fields
+		}
+	case defEnum:
+		props["symbol_kind"] = facts.SymbolType
+		if len(d.values) > 0 {
+			props["values"] = d.values
+		}
+	case defService:
+		props["symbol_kind"] = facts.SymbolInterface
+		var names []string
+		for _, r := range d.rpcs {
+			names = append(names, r.name)
+			req, resp := resolve(idx, d.scope(), r.req), resolve(idx, d.scope(), r.resp)
+			rels = dependOn(rels, req)
+			rels = dependOn(rels, resp)
+			routes = append(routes, d.routeFact(r, req, resp))
+		}
+		if len(names) > 0 {
+			props["rpcs"] = names
+		}
+	}
+
+	sym := facts.Fact{
+		Kind:      facts.KindSymbol,
+		Name:      d.factName(),
+		File:      d.file,
+		Line:      d.line,
+		Props:     props,
+		Relations: rels,
+	}
+	return append([]facts.Fact{sym}, routes...)
+}
+
+// routeFact returns the route fact of an rpc, named after its gRPC path
+// ("/acme.users.v1.UserService/GetUser").
+func (d *protoDef) routeFact(r protoRPC, req, resp *protoDef) facts.Fact {
+	grpcPath := "/" + d.full + "/" + r.name
+	props := map[string]any{
+		"path":          grpcPath,
+		"protocol":      "grpc",
+		"framework":     "grpc",
+		"service":       d.factName(),
+		"rpc":           r.name,
+		"request_type":  typeName(req, r.req),
+		"response_type": typeName(resp, r.resp),
+		"language":      "proto",
+	}
+	if r.clientStream {
+		props["client_streaming"] = true
+	}
+	if r.serverStream {
+		props["server_streaming"] = true
+	}
+	rels := []facts.Relation{{Kind: facts.RelDeclares, Target: path.Dir(d.file)}}
+	if req != nil {
+		rels = append(rels, facts.Relation{Kind: facts.RelDependsOn, Target: req.factName()})
+	}
+	if resp != nil && resp != req {
+		rels = append(rels, facts.Relation{Kind: facts.RelDependsOn, Target: resp.factName()})
+	}
+	return facts.Fact{
+		Kind:      facts.KindRoute,
+		Name:      grpcPath,
+		File:      d.file,
+		Line:      r.line,
+		Props:     props,
+		Relations: rels,
+	}
+}
+
+// typeName is the message's fact name when it resolved, or the reference as
+// written.
+func typeName(d *protoDef, ref string) string {
+	if d != nil {
+		return d.factName()
+	}
+	return strings.TrimPrefix(ref, ".")
+}
+
+// --- Parsing ---
+
+// token is a lexical token of a .proto file. Identifiers keep their dots,
+// so "google.protobuf.Timestamp" is a single token.
+type token struct {
+	text string
+	line int
+	str  bool // string literal
+}
+
+// lex splits src into tokens, dropping whitespace and comments.
+func lex(src string) []token {
+	var toks []token
+	line := 1
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+		case c == ' ' || c == '\t' || c == '\r':
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			i-- // let the newline be counted
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			i += 2
+			for i < len(src) && !(src[i] == '*' && i+1 < len(src) && src[i+1] == '/') {
+				if src[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			i++ // skip the closing '/'
+		case c == '"' || c == '\'':
+			start, startLine := i, line
+			for i++; i < len(src) && src[i] != c; i++ {
+				if src[i] == '\\' {
+					i++
+				} else if src[i] == '\n' {
+					line++
+				}
+			}
+			end := min(i, len(src))
+			toks = append(toks, token{text: src[start+1 : end], line: startLine, str: true})
+		case isIdentByte(c):
+			start := i
+			for i+1 < len(src) && isIdentByte(src[i+1]) {
+				i++
+			}
+			toks = append(toks, token{text: src[start : i+1], line: line})
+		default:
+			toks = append(toks, token{text: string(c), line: line})
+		}
+	}
+	return toks
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '.' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// parser walks the tokens of one file and collects its definitions.
+type parser struct {
+	toks []token
+	pos  int
+	file string
+	pkg  string
+	defs []*protoDef
+}
+
+// parseFile returns the messages, enums and services declared in a .proto
+// file, nested messages and enums included.
+func parseFile(src, relFile string) []*protoDef {
+	p := &parser{toks: lex(src), file: relFile}
+	for !p.eof() {
+		t := p.next()
+		if t.str {
+			continue
+		}
+		switch t.text {
+		case "package":
+			p.pkg = p.next().text
+			p.skipStatement()
+		case "message":
+			p.parseMessage(p.pkg)
+		case "enum":
+			p.parseEnum(p.pkg)
+		case "service":
+			p.parseService()
+		case ";", "}":
+		default:
+			// syntax, edition, import, option, extend
+			p.skipStatement()
+		}
+	}
+	return p.defs
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.toks)
+}
+
+func (p *parser) next() token {
+	if p.eof() {
+		return token{}
+	}
+	t := p.toks[p.pos]
+	p.pos++
+	return t
+}
+
+func (p *parser) peek() string {
+	if p.eof() {
+		return ""
+	}
+	return p.toks[p.pos].text
+}
+
+// accept consumes the next token if it is s.
+func (p *parser) accept(s string) bool {
+	if !p.eof() && !p.toks[p.pos].str && p.toks[p.pos].text == s {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// skipStatement consumes the rest of a statement: up to its terminating
+// semicolon, or through its body if it has a brace-delimited one.
+func (p *parser) skipStatement() {
+	depth := 0
+	for !p.eof() {
+		t := p.next()
+		if t.str {
+			continue
+		}
+		switch t.text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth <= 0 {
+				return
+			}
+		case ";":
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+// open starts a named block: it returns the name and its line, and false
+// when the name is not followed by a body.
+func (p *parser) open() (string, int, bool) {
+	name := p.next()
+	if !p.accept("{") {
+		p.skipStatement()
+		return "", 0, false
+	}
+	return name.text, name.line, true
+}
+
+func (p *parser) define(kind, prefix, name string, line int) *protoDef {
+	d := &protoDef{kind: kind, full: qualify(prefix, name), pkg: p.pkg, file: p.file, line: line}
+	p.defs = append(p.defs, d)
+	return d
+}
+
+func qualify(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func (p *parser) parseMessage(prefix string) {
+	name, line, ok := p.open()
+	if !ok {
+		return
+	}
+	p.parseMessageBody(p.define(defMessage, prefix, name, line))
+}
+
+// parseMessageBody reads fields and nested definitions up to the closing
+// brace. Fields of a oneof belong to the enclosing message.
+func (p *parser) parseMessageBody(d *protoDef) {
+	for !p.eof() {
+		t := p.next()
+		if t.str {
+			continue
+		}
+		switch t.text {
+		case "}":
+			return
+		case ";":
+		case "message":
+			p.parseMessage(d.full)
+		case "enum":
+			p.parseEnum(d.full)
+		case "oneof":
+			if _, _, ok := p.open(); ok {
+				p.parseMessageBody(d)
+			}
+		case "option", "reserved", "extensions", "extend", "group":
+			p.skipStatement()
+		case "map":
+			if !p.accept("<") {
+				p.skipStatement()
+				continue
+			}
+			key := p.next().text
+			p.accept(",")
+			val := p.next().text
+			p.accept(">")
+			d.fields = append(d.fields, protoField{name: p.next().text, typ: "map<" + key + ", " + val + ">", ref: val})
+			p.skipStatement()
+		default:
+			typ, label := t.text, ""
+			switch typ {
+			case "repeated", "optional", "required":
+				label, typ = typ, p.next().text
+			}
+			f := protoField{name: p.next().text, typ: typ, ref: typ}
+			if label == "repeated" {
+				f.typ = "repeated " + typ
+			}
+			d.fields = append(d.fields, f)
+			p.skipStatement()
+		}
+	}
+}
+
+func (p *parser) parseEnum(prefix string) {
+	name, line, ok := p.open()
+	if !ok {
+		return
+	}
+	d := p.define(defEnum, prefix, name, line)
+	for !p.eof() {
+		t := p.next()
+		switch {
+		case t.str:
+		case t.text == "}":
+			return
+		case t.text == "option" || t.text == "reserved":
+			p.skipStatement()
+		case p.peek() == "=":
+			d.values = append(d.values, t.text)
+			p.skipStatement()
+		}
+	}
+}
+
+func (p *parser) parseService() {
+	name, line, ok := p.open()
+	if !ok {
+		return
+	}
+	d := p.define(defService, p.pkg, name, line)
+	for !p.eof() {
+		t := p.next()
+		switch {
+		case t.str:
+		case t.text == "}":
+			return
+		case t.text == ";":
+		case t.text == "rpc":
+			if r, ok := p.parseRPC(); ok {
+				d.rpcs = append(d.rpcs, r)
+			}
+		default:
+			p.skipStatement()
+		}
+	}
+}
+
+// parseRPC reads "Name (stream Req) returns (stream Resp)" and the rpc's
+// terminating semicolon or options body.
+func (p *parser) parseRPC() (protoRPC, bool) {
+	name := p.next()
+	r := protoRPC{name: name.text, line: name.line}
+	ok := p.accept("(")
+	r.clientStream = p.accept("stream")
+	r.req = p.next().text
+	ok = ok && p.accept(")") && p.accept("returns") && p.accept("(")
+	r.serverStream = p.accept("stream")
+	r.resp = p.next().text
+	ok = ok && p.accept(")")
+	if p.peek() == "{" || !p.accept(";") {
+		p.skipStatement()
+	}
+	return r, ok
+}
+
+func isProtoFile(p string) bool {
+	return strings.ToLower(filepath.Ext(p)) == ".proto"
+}
+
+// skipDir returns true for directories that should never be descended into.
+func skipDir(name string) bool {
+	switch name {
+	case "vendor", "node_modules", ".git", ".archmcp", "build", ".build":
+		return true
+	}
+	return false
+}
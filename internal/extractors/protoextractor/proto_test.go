@@ -0,0 +1,246 @@
+package protoextractor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// setupRepo writes the given files (relative path -> content) into a temp dir.
+func setupRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for rel, content := range files {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func extractAll(t *testing.T, files map[string]string) map[string]facts.Fact {
+	t.Helper()
+	dir := setupRepo(t, files)
+	var rel []string
+	for f := range files {
+		rel = append(rel, f)
+	}
+	ff, err := New().Extract(context.Background(), dir, rel)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	m := make(map[string]facts.Fact, len(ff))
+	for _, f := range ff {
+		m[f.Name] = f
+	}
+	return m
+}
+
+func hasRel(f facts.Fact, kind, target string) bool {
+	for _, r := range f.Relations {
+		if r.Kind == kind && r.Target == target {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  bool
+	}{
+		{"nested proto", map[string]string{"api/users/v1/users.proto": `syntax = "proto3";`}, true},
+		{"no proto", map[string]string{"main.go": "package main"}, false},
+		{"vendored proto", map[string]string{"vendor/google/api/http.proto": `syntax = "proto3";`}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New().Detect(setupRepo(t, tt.files))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Detect = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+const usersProto = `syntax = "proto3";
+
+package acme.users.v1;
+
+import "google/protobuf/timestamp.proto";
+import "acme/common/v1/page.proto";
+
+option go_package = "github.com/acme/api/users/v1;usersv1";
+
+// UserService manages accounts.
+service UserService {
+  rpc GetUser(GetUserRequest) returns (User);
+  rpc ListUsers(ListUsersRequest) returns (stream User) {
+    option (google.api.http) = { get: "/v1/users" };
+  }
+  /* Bidirectional sync. */
+  rpc Sync(stream User) returns (stream User);
+}
+
+message User {
+  string id = 1;
+  Role role = 2;
+  repeated Address addresses = 3 [json_name = "addr"];
+  map<string, Address> labels = 4;
+  google.protobuf.Timestamp created_at = 5;
+  oneof contact {
+    string email = 6;
+    Phone phone = 7;
+  }
+
+  message Address {
+    string city = 1;
+  }
+
+  enum Role {
+    ROLE_UNSPECIFIED = 0;
+    ROLE_ADMIN = 1 [deprecated = true];
+  }
+}
+
+message Phone { string number = 1; }
+
+message GetUserRequest { string id = 1; }
+
+message ListUsersRequest {
+  .acme.common.v1.PageRequest page = 1;
+}
+`
+
+const pageProto = `syntax = "proto3";
+package acme.common.v1;
+
+message PageRequest {
+  int32 size = 1;
+  string token = 2;
+}
+`
+
+func TestExtract_ServicesAndMessages(t *testing.T) {
+	m := extractAll(t, map[string]string{
+		"proto/acme/users/v1/users.proto": usersProto,
+		"proto/acme/common/v1/page.proto": pageProto,
+	})
+
+	svc, ok := m["acme.users.v1.UserService"]
+	if !ok {
+		t.Fatalf("missing service symbol; got %v", keys(m))
+	}
+	if svc.Props["symbol_kind"] != facts.SymbolInterface || svc.Props["proto_kind"] != "service" || svc.Line != 11 {
+		t.Errorf("service props = %v, line %d", svc.Props, svc.Line)
+	}
+	if got := svc.Props["rpcs"]; !reflect.DeepEqual(got, []string{"GetUser", "ListUsers", "Sync"}) {
+		t.Errorf("rpcs = %v", got)
+	}
+	for _, target := range []string{"acme.users.v1.GetUserRequest", "acme.users.v1.User", "acme.users.v1.ListUsersRequest"} {
+		if !hasRel(svc, facts.RelDependsOn, target) {
+			t.Errorf("service should depend on %s: %v", target, svc.Relations)
+		}
+	}
+	if !hasRel(svc, facts.RelDeclares, "proto/acme/users/v1") {
+		t.Errorf("service should be declared by its directory: %v", svc.Relations)
+	}
+
+	get := m["/acme.users.v1.UserService/GetUser"]
+	if get.Kind != facts.KindRoute || get.Line != 12 {
+		t.Fatalf("GetUser route = %+v", get)
+	}
+	if get.Props["request_type"] != "acme.users.v1.GetUserRequest" || get.Props["response_type"] != "acme.users.v1.User" ||
+		get.Props["service"] != "acme.users.v1.UserService" || get.Props["rpc"] != "GetUser" || get.Props["protocol"] != "grpc" {
+		t.Errorf("GetUser props = %v", get.Props)
+	}
+	if !hasRel(get, facts.RelDependsOn, "acme.users.v1.GetUserRequest") || !hasRel(get, facts.RelDependsOn, "acme.users.v1.User") {
+		t.Errorf("GetUser relations = %v", get.Relations)
+	}
+
+	list := m["/acme.users.v1.UserService/ListUsers"]
+	if list.Props["server_streaming"] != true || list.Props["client_streaming"] != nil {
+		t.Errorf("ListUsers streaming props = %v", list.Props)
+	}
+	sync := m["/acme.users.v1.UserService/Sync"]
+	if sync.Props["server_streaming"] != true || sync.Props["client_streaming"] != true || len(sync.Relations) != 2 {
+		t.Errorf("Sync = %+v", sync)
+	}
+
+	user := m["acme.users.v1.User"]
+	if user.Props["symbol_kind"] != facts.SymbolStruct || user.Props["package"] != "acme.users.v1" {
+		t.Errorf("User props = %v", user.Props)
+	}
+	wantFields := []map[string]any{
+		{"name": "id", "type": "string"},
+		{"name": "role", "type": "Role"},
+		{"name": "addresses", "type": "repeated Address"},
+		{"name": "labels", "type": "map<string, Address>"},
+		{"name": "created_at", "type": "google.protobuf.Timestamp"},
+		{"name": "email", "type": "string"},
+		{"name": "phone", "type": "Phone"},
+	}
+	if got := user.Props["fields"]; !reflect.DeepEqual(got, wantFields) {
+		t.Errorf("User fields = %v", got)
+	}
+	// Nested types resolve before top-level ones; external types are skipped.
+	for _, target := range []string{"acme.users.v1.User.Role", "acme.users.v1.User.Address", "acme.users.v1.Phone"} {
+		if !hasRel(user, facts.RelDependsOn, target) {
+			t.Errorf("User should depend on %s: %v", target, user.Relations)
+		}
+	}
+	if len(user.Relations) != 4 {
+		t.Errorf("User relations = %v, want declares plus 3 depends_on", user.Relations)
+	}
+
+	role := m["acme.users.v1.User.Role"]
+	if role.Props["symbol_kind"] != facts.SymbolType || !reflect.DeepEqual(role.Props["values"], []string{"ROLE_UNSPECIFIED", "ROLE_ADMIN"}) {
+		t.Errorf("Role props = %v", role.Props)
+	}
+
+	// A fully qualified reference crosses files.
+	if !hasRel(m["acme.users.v1.ListUsersRequest"], facts.RelDependsOn, "acme.common.v1.PageRequest") {
+		t.Errorf("ListUsersRequest relations = %v", m["acme.users.v1.ListUsersRequest"].Relations)
+	}
+	if _, ok := m["acme.common.v1.PageRequest"]; !ok {
+		t.Error("missing PageRequest from the second file")
+	}
+}
+
+func TestExtract_NoPackage(t *testing.T) {
+	m := extractAll(t, map[string]string{
+		"protos/ping.proto": `syntax = "proto3";
+service Pinger { rpc Ping(Ping) returns (Ping); }
+message Ping { string id = 1; }
+`,
+	})
+	ping, ok := m["protos.Ping"]
+	if !ok {
+		t.Fatalf("messages without a package are named after their directory; got %v", keys(m))
+	}
+	route := m["/Pinger/Ping"]
+	if route.Props["request_type"] != "protos.Ping" || !hasRel(route, facts.RelDependsOn, ping.Name) || len(route.Relations) != 2 {
+		t.Errorf("Ping route = %+v", route)
+	}
+}
+
+func keys(m map[string]facts.Fact) []string {
+	var out []string
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
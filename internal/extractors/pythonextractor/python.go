@@ -4,12 +4,12 @@ import (
 	"bufio"
 	"context"
 	"io/fs"
-	"log"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 
+	"github.com/dejo1307/archmcp/internal/extractors"
 	"github.com/dejo1307/archmcp/internal/facts"
 )
 
@@ -72,6 +72,7 @@ func (e *PythonExtractor) Detect(repoPath string) (bool, error) {
 // Extract parses Python files and emits architectural facts.
 func (e *PythonExtractor) Extract(ctx context.Context, repoPath string, files []string) ([]facts.Fact, error) {
 	var allFacts []facts.Fact
+	var fileErrs extractors.FileErrors
 	modules := make(map[string]bool)
 
 	for _, relFile := range files {
@@ -88,7 +89,7 @@ func (e *PythonExtractor) Extract(ctx context.Context, repoPath string, files []
 		absFile := filepath.Join(repoPath, relFile)
 		f, err := os.Open(absFile)
 		if err != nil {
-			log.Printf("[python-extractor] error reading %s: %v", relFile, err)
+			fileErrs.Add(relFile, err)
 			continue
 		}
 
@@ -111,7 +112,7 @@ func (e *PythonExtractor) Extract(ctx context.Context, repoPath string, files []
 		})
 	}
 
-	return allFacts, nil
+	return allFacts, fileErrs.Err()
 }
 
 // --- Regex patterns ---
@@ -167,6 +168,7 @@ func extractFile(f *os.File, relFile string) []facts.Fact {
 
 	var (
 		lineNum        int
+		lines          []string
 		scopeStack     []scopeEntry
 		pendingRoutes  []pendingRoute
 		inDocstring    bool
@@ -176,6 +178,7 @@ func extractFile(f *os.File, relFile string) []facts.Fact {
 	for scanner.Scan() {
 		lineNum++
 		line := scanner.Text()
+		lines = append(lines, line)
 		trimmed := strings.TrimSpace(line)
 
 		// Handle multi-line docstrings / triple-quoted strings.
@@ -413,6 +416,7 @@ func extractFile(f *os.File, relFile string) []facts.Fact {
 		}
 	}
 
+	extractors.MarkAnnotations(result, relFile, lines)
 	return result
 }
 
@@ -3,6 +3,7 @@ package pythonextractor
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/dejo1307/archmcp/internal/facts"
@@ -917,3 +918,47 @@ func keys(m map[string]facts.Fact) []string {
 	}
 	return out
 }
+
+func TestExtractFile_Decorators(t *testing.T) {
+	src := `
+@dataclass(frozen=True)
+class Order:
+    total: int
+
+    @property
+    def label(self):
+        return str(self.total)
+
+@app.get(
+    "/orders/{id}",
+)
+@requires_auth
+async def get_order(id):
+    return None
+
+def plain():
+    return None
+`
+	f := writeAndOpen(t, "orders.py", src)
+	defer f.Close()
+
+	relFile := "app/orders.py"
+	idx := byName(extractFile(f, relFile))
+
+	for name, want := range map[string][]string{
+		mod(relFile) + ".Order":       {"dataclass"},
+		mod(relFile) + ".Order.label": {"property"},
+		mod(relFile) + ".get_order":   {"app.get", "requires_auth"},
+	} {
+		fact, ok := idx[name]
+		if !ok {
+			t.Fatalf("missing fact %q; got keys: %v", name, keys(idx))
+		}
+		if got, _ := fact.Props["annotations"].([]string); !slices.Equal(got, want) {
+			t.Errorf("%s annotations = %v, want %v", name, got, want)
+		}
+	}
+	if got := idx[mod(relFile)+".plain"].Props["annotations"]; got != nil {
+		t.Errorf("plain annotations = %v, want none", got)
+	}
+}
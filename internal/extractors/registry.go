@@ -16,6 +16,21 @@ type Extractor interface {
 	Extract(ctx context.Context, repoPath string, files []string) ([]facts.Fact, error)
 }
 
+// FrameworkDetector is implemented by extractors that can read framework
+// versions from the repository's manifests (package.json, Gemfile.lock,
+// build files). The engine records them in the snapshot meta.
+type FrameworkDetector interface {
+	DetectFrameworks(repoPath string) []facts.FrameworkInfo
+}
+
+// IgnoreAware is implemented by extractors that read files the walk did not
+// list, such as the Go extractor's test files. Before each Extract call the
+// engine passes a function reporting whether the repository's .archmcpignore
+// and .archmcp.yaml rules ignore a path relative to the repo root.
+type IgnoreAware interface {
+	SetIgnored(ignored func(relPath string) bool)
+}
+
 // Registry holds registered extractors.
 type Registry struct {
 	extractors []Extractor
@@ -0,0 +1,102 @@
+package rubyextractor
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/extractors"
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+var (
+	// gemfileGemRe matches a gem declaration: the name, then the rest of the
+	// arguments (version constraints and options).
+	gemfileGemRe = regexp.MustCompile(`^\s*gem\s*\(?\s*['"]([^'"]+)['"](.*)`)
+	// gemfileGroupRe matches the opening of a group block and its groups.
+	gemfileGroupRe = regexp.MustCompile(`^\s*group\s*\(?\s*(.+?)\)?\s+do\s*(?:\|.*\|)?\s*$`)
+	// gemfileBlockRe matches the opening of any other block closed by end
+	// (platforms, source, git and path blocks, or an if around gems).
+	gemfileBlockRe  = regexp.MustCompile(`\bdo\s*(?:\|.*\|)?\s*$|^\s*(?:if|unless|case|begin)\b`)
+	gemfileEndRe    = regexp.MustCompile(`^\s*end\s*$`)
+	gemfileStringRe = regexp.MustCompile(`^\s*['"]([^'"]+)['"]`)
+	gemfileSymRe    = regexp.MustCompile(`:(\w+)`)
+	gemfileGroupOpt = regexp.MustCompile(`\bgroups?:\s*(\[[^\]]*\]|:\w+)`)
+)
+
+// manifestFacts returns a dependency fact per gem declared in the repo's
+// Gemfile. The version is the declared constraint ("~> 7.1", or several
+// joined with ", "), and locked_version the version in Gemfile.lock. Gems
+// in a group block or with a group: option get a groups prop.
+func manifestFacts(repoPath string) []facts.Fact {
+	data, err := os.ReadFile(filepath.Join(repoPath, "Gemfile"))
+	if err != nil {
+		return nil
+	}
+	locked := lockedGems(repoPath)
+
+	var result []facts.Fact
+	var blocks [][]string // groups of each open block, innermost last
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		if gemfileEndRe.MatchString(line) {
+			if len(blocks) > 0 {
+				blocks = blocks[:len(blocks)-1]
+			}
+			continue
+		}
+		if m := gemfileGroupRe.FindStringSubmatch(line); m != nil {
+			blocks = append(blocks, gemfileSymbols(m[1]))
+			continue
+		}
+
+		m := gemfileGemRe.FindStringSubmatch(line)
+		if m == nil {
+			if gemfileBlockRe.MatchString(line) {
+				blocks = append(blocks, nil)
+			}
+			continue
+		}
+		name, rest := m[1], m[2]
+
+		var constraints []string
+		for {
+			rest = strings.TrimPrefix(strings.TrimSpace(rest), ",")
+			c := gemfileStringRe.FindStringSubmatch(rest)
+			if c == nil {
+				break
+			}
+			constraints = append(constraints, c[1])
+			rest = rest[len(c[0]):]
+		}
+
+		f := extractors.ManifestDependency("Gemfile", i+1, name, strings.Join(constraints, ", "), "ruby")
+		if v, ok := locked[name]; ok {
+			f.Props["locked_version"] = v
+		}
+		var groups []string
+		for _, b := range blocks {
+			groups = append(groups, b...)
+		}
+		if g := gemfileGroupOpt.FindStringSubmatch(rest); g != nil {
+			groups = append(groups, gemfileSymbols(g[1])...)
+		}
+		if len(groups) > 0 {
+			f.Props["groups"] = groups
+		}
+		result = append(result, f)
+	}
+	return result
+}
+
+// gemfileSymbols returns the names of the Ruby symbols in s (":development, :test").
+func gemfileSymbols(s string) []string {
+	var out []string
+	for _, m := range gemfileSymRe.FindAllStringSubmatch(s, -1) {
+		out = append(out, m[1])
+	}
+	return out
+}
@@ -13,17 +13,19 @@ import (
 
 // Route DSL regex patterns.
 var (
-	httpVerbRe  = regexp.MustCompile(`^\s*(get|post|put|patch|delete)\s+['"]([^'"]+)['"](?:\s*,\s*to:\s*['"]([^'"]+)['"])?`)
-	resourcesRe = regexp.MustCompile(`^\s*resources?\s+:(\w+)`)
-	namespaceRe = regexp.MustCompile(`^\s*namespace\s+:(\w+)`)
-	scopePathRe = regexp.MustCompile(`^\s*scope\s+['"]([^'"]+)['"]`)
-	scopeModRe  = regexp.MustCompile(`^\s*scope\s+module:\s*[:'"](\w+)`)
-	rootRe      = regexp.MustCompile(`^\s*root\s+(?:to:\s*)?['"]([^'"]+)['"]`)
-	drawRe      = regexp.MustCompile(`^\s*draw\s*\(\s*:(\w+)\s*\)`)
-	memberRe    = regexp.MustCompile(`^\s*(member|collection)\s+do\b`)
-	doBlockRe   = regexp.MustCompile(`\bdo\s*(?:\|[^|]*\|)?\s*$`)
-	onlyRe      = regexp.MustCompile(`only:\s*\[([^\]]*)\]`)
-	exceptRe    = regexp.MustCompile(`except:\s*\[([^\]]*)\]`)
+	httpVerbRe   = regexp.MustCompile(`^\s*(get|post|put|patch|delete)\s+['"]([^'"]+)['"](?:\s*,\s*to:\s*['"]([^'"]+)['"])?`)
+	verbSymRe    = regexp.MustCompile(`^\s*(get|post|put|patch|delete)\s+:(\w+)`)
+	resourcesRe  = regexp.MustCompile(`^\s*(resources?)\s+:(\w+)`)
+	controllerRe = regexp.MustCompile(`controller:\s*[:'"]([\w/]+)`)
+	namespaceRe  = regexp.MustCompile(`^\s*namespace\s+:(\w+)`)
+	scopePathRe  = regexp.MustCompile(`^\s*scope\s+['"]([^'"]+)['"]`)
+	scopeModRe   = regexp.MustCompile(`^\s*scope\s+module:\s*[:'"](\w+)`)
+	rootRe       = regexp.MustCompile(`^\s*root\s+(?:to:\s*)?['"]([^'"]+)['"]`)
+	drawRe       = regexp.MustCompile(`^\s*draw\s*\(\s*:(\w+)\s*\)`)
+	memberRe     = regexp.MustCompile(`^\s*(member|collection)\s+do\b`)
+	doBlockRe    = regexp.MustCompile(`\bdo\s*(?:\|[^|]*\|)?\s*$`)
+	onlyRe       = regexp.MustCompile(`only:\s*(\[[^\]]*\]|%[iw]\[[^\]]*\]|:\w+)`)
+	exceptRe     = regexp.MustCompile(`except:\s*(\[[^\]]*\]|%[iw]\[[^\]]*\]|:\w+)`)
 )
 
 // extractAllRoutes finds and parses all Rails route files in the repository.
@@ -80,6 +82,13 @@ func isRouteFile(relFile string) bool {
 type routeScope struct {
 	pathPrefix string
 	module     string
+
+	// Set for the block of a resources/resource declaration.
+	controller string // controller in path form, e.g. "users"
+	singular   bool   // resource (no :id) rather than resources
+	nestParam  string // e.g. "/:user_id", prefixed to routes of nested resources
+
+	member bool // member or collection block of a resource
 }
 
 // parseRouteFile parses a single Rails route file.
@@ -93,7 +102,6 @@ func parseRouteFile(f *os.File, relFile string) []facts.Fact {
 		lineNum    int
 		scopeStack []routeScope
 		depth      int
-		currentResource string
 	)
 
 	for scanner.Scan() {
@@ -114,7 +122,6 @@ func parseRouteFile(f *os.File, relFile string) []facts.Fact {
 			if depth < len(scopeStack) {
 				scopeStack = scopeStack[:depth]
 			}
-			currentResource = ""
 			continue
 		}
 
@@ -182,9 +189,7 @@ func parseRouteFile(f *os.File, relFile string) []facts.Fact {
 					"language":  "ruby",
 					"handler":   m[1],
 				},
-				Relations: []facts.Relation{
-					{Kind: facts.RelDeclares, Target: filepath.Dir(relFile)},
-				},
+				Relations: routeRelations(relFile, controllerAction(scopeStack, m[1])),
 			})
 			continue
 		}
@@ -210,52 +215,81 @@ func parseRouteFile(f *os.File, relFile string) []facts.Fact {
 			}
 
 			result = append(result, facts.Fact{
-				Kind:  facts.KindRoute,
-				Name:  fullPath,
-				File:  relFile,
-				Line:  lineNum,
-				Props: props,
-				Relations: []facts.Relation{
-					{Kind: facts.RelDeclares, Target: filepath.Dir(relFile)},
+				Kind:      facts.KindRoute,
+				Name:      fullPath,
+				File:      relFile,
+				Line:      lineNum,
+				Props:     props,
+				Relations: routeRelations(relFile, controllerAction(scopeStack, handler)),
+			})
+			continue
+		}
+
+		// Custom actions inside a resource block: get :preview, post :publish.
+		if m := verbSymRe.FindStringSubmatch(line); m != nil && enclosingResource(scopeStack) != nil {
+			handler := enclosingResource(scopeStack).controller + "#" + m[2]
+			result = append(result, facts.Fact{
+				Kind: facts.KindRoute,
+				Name: prefix + "/" + m[2],
+				File: relFile,
+				Line: lineNum,
+				Props: map[string]any{
+					"method":    strings.ToUpper(m[1]),
+					"framework": "rails",
+					"language":  "ruby",
+					"action":    m[2],
+					"handler":   handler,
 				},
+				Relations: routeRelations(relFile, controllerAction(scopeStack, handler)),
 			})
 			continue
 		}
 
 		// resources / resource.
 		if m := resourcesRe.FindStringSubmatch(line); m != nil {
-			resourceName := m[1]
-			currentResource = resourceName
+			singular := m[1] == "resource"
+			resourceName := m[2]
 			resourcePath := prefix + "/" + resourceName
 
-			actions := restfulActions(line)
-			for _, action := range actions {
-				method := action.method
-				path := resourcePath + action.suffix
+			// Singular resources still route to a plural controller.
+			controller := resourceName
+			if singular {
+				controller = pluralize(resourceName)
+			}
+			if cm := controllerRe.FindStringSubmatch(line); cm != nil {
+				controller = cm[1]
+			}
+
+			scope := routeScope{pathPrefix: "/" + resourceName, controller: controller, singular: singular}
+			if !singular {
+				scope.nestParam = "/:" + singularize(resourceName) + "_id"
+			}
+			withResource := append(scopeStack[:len(scopeStack):len(scopeStack)], scope)
 
+			for _, action := range restfulActions(line, singular) {
+				handler := controller + "#" + action.name
 				props := map[string]any{
-					"method":    method,
+					"method":    action.method,
 					"framework": "rails",
 					"language":  "ruby",
 					"resource":  resourceName,
 					"action":    action.name,
+					"handler":   handler,
 				}
 
 				result = append(result, facts.Fact{
-					Kind:  facts.KindRoute,
-					Name:  path,
-					File:  relFile,
-					Line:  lineNum,
-					Props: props,
-					Relations: []facts.Relation{
-						{Kind: facts.RelDeclares, Target: filepath.Dir(relFile)},
-					},
+					Kind:      facts.KindRoute,
+					Name:      resourcePath + action.suffix,
+					File:      relFile,
+					Line:      lineNum,
+					Props:     props,
+					Relations: routeRelations(relFile, controllerAction(withResource, handler)),
 				})
 			}
 
 			// If there's a do block, push resource as a scope.
 			if doBlockRe.MatchString(line) {
-				scopeStack = append(scopeStack, routeScope{pathPrefix: "/" + resourceName})
+				scopeStack = withResource
 				depth++
 			}
 			continue
@@ -263,18 +297,19 @@ func parseRouteFile(f *os.File, relFile string) []facts.Fact {
 
 		// member do / collection do.
 		if m := memberRe.FindStringSubmatch(line); m != nil {
-			blockType := m[1]
 			memberPrefix := ""
-			if blockType == "member" && currentResource != "" {
+			if res := enclosingResource(scopeStack); m[1] == "member" && res != nil && !res.singular {
 				memberPrefix = "/:id"
 			}
-			scopeStack = append(scopeStack, routeScope{pathPrefix: memberPrefix})
+			scopeStack = append(scopeStack, routeScope{pathPrefix: memberPrefix, member: true})
 			depth++
 			continue
 		}
 
-		// Track other do blocks for depth.
+		// Track other do blocks for depth, with an empty scope so the scope
+		// stack stays aligned with the block depth.
 		if doBlockRe.MatchString(line) {
+			scopeStack = append(scopeStack, routeScope{})
 			depth++
 		}
 	}
@@ -282,17 +317,69 @@ func parseRouteFile(f *os.File, relFile string) []facts.Fact {
 	return result
 }
 
-// buildPrefix constructs the current URL prefix from the scope stack.
+// buildPrefix constructs the current URL prefix from the scope stack. A
+// resource's id parameter (/users/:user_id) is only added for routes nested
+// in it, not for its own member and collection routes.
 func buildPrefix(stack []routeScope) string {
 	var parts []string
-	for _, s := range stack {
+	for i, s := range stack {
 		if s.pathPrefix != "" {
 			parts = append(parts, s.pathPrefix)
 		}
+		if s.nestParam != "" && (i+1 >= len(stack) || !stack[i+1].member) {
+			parts = append(parts, s.nestParam)
+		}
 	}
 	return strings.Join(parts, "")
 }
 
+// enclosingResource returns the innermost resource scope a member or
+// collection block, or a custom route, belongs to.
+func enclosingResource(stack []routeScope) *routeScope {
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i].controller != "" {
+			return &stack[i]
+		}
+		if !stack[i].member {
+			return nil
+		}
+	}
+	return nil
+}
+
+// routeRelations returns the relations of a route fact: the declaring
+// directory, plus a calls relation to the controller action when known.
+func routeRelations(relFile, action string) []facts.Relation {
+	rels := []facts.Relation{{Kind: facts.RelDeclares, Target: filepath.Dir(relFile)}}
+	if action != "" {
+		rels = append(rels, facts.Relation{Kind: facts.RelCalls, Target: action})
+	}
+	return rels
+}
+
+// controllerAction converts a Rails handler ("users#index",
+// "admin/users#index") into the instance method symbol the Ruby extractor
+// emits for the action ("Admin::UsersController#index"). Namespace and
+// scope module: blocks qualify the controller.
+func controllerAction(stack []routeScope, handler string) string {
+	controller, action, ok := strings.Cut(handler, "#")
+	if !ok || controller == "" || action == "" {
+		return ""
+	}
+	var parts []string
+	if !strings.HasPrefix(controller, "/") {
+		for _, s := range stack {
+			if s.module != "" {
+				parts = append(parts, snakeToCamel(s.module))
+			}
+		}
+	}
+	for _, seg := range strings.Split(strings.Trim(controller, "/"), "/") {
+		parts = append(parts, snakeToCamel(seg))
+	}
+	return strings.Join(parts, "::") + "Controller#" + action
+}
+
 // restAction describes a single RESTful action.
 type restAction struct {
 	name   string
@@ -300,8 +387,10 @@ type restAction struct {
 	suffix string
 }
 
-// restfulActions returns the set of REST actions for a resources declaration.
-func restfulActions(line string) []restAction {
+// restfulActions returns the set of REST actions for a resources
+// declaration, or for a singular resource declaration, which has no index
+// action and no :id segment.
+func restfulActions(line string, singular bool) []restAction {
 	all := []restAction{
 		{name: "index", method: "GET", suffix: ""},
 		{name: "create", method: "POST", suffix: ""},
@@ -311,6 +400,16 @@ func restfulActions(line string) []restAction {
 		{name: "edit", method: "GET", suffix: "/:id/edit"},
 		{name: "destroy", method: "DELETE", suffix: "/:id"},
 	}
+	if singular {
+		all = []restAction{
+			{name: "create", method: "POST", suffix: ""},
+			{name: "new", method: "GET", suffix: "/new"},
+			{name: "show", method: "GET", suffix: ""},
+			{name: "update", method: "PATCH", suffix: ""},
+			{name: "edit", method: "GET", suffix: "/edit"},
+			{name: "destroy", method: "DELETE", suffix: ""},
+		}
+	}
 
 	// Check for only: [...] filter.
 	if m := onlyRe.FindStringSubmatch(line); m != nil {
@@ -327,9 +426,16 @@ func restfulActions(line string) []restAction {
 	return all
 }
 
-// parseSymbolList extracts symbol names from a string like ":index, :show, :create".
+// parseSymbolList extracts action names from "[:index, :show]", ":show",
+// or "%i[index show]".
 func parseSymbolList(s string) map[string]bool {
 	result := make(map[string]bool)
+	if strings.HasPrefix(s, "%") {
+		for _, w := range strings.Fields(strings.Trim(s[2:], "[]")) {
+			result[w] = true
+		}
+		return result
+	}
 	for _, m := range symbolListRe.FindAllStringSubmatch(s, -1) {
 		result[m[1]] = true
 	}
@@ -2,16 +2,25 @@ package rubyextractor
 
 import (
 	"bufio"
+	"bytes"
 	"context"
-	"log"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 
+	"github.com/dejo1307/archmcp/internal/extractors"
 	"github.com/dejo1307/archmcp/internal/facts"
 )
 
+// envReadPatterns match environment variable reads: ENV["NAME"] and
+// ENV.fetch("NAME"), ENV.key?("NAME") and the like.
+var envReadPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bENV\[\s*["']([^"']+)["']\s*\]`),
+	regexp.MustCompile(`\bENV\.(?:fetch|key\?|has_key\?|include\?)\(\s*["']([^"']+)["']`),
+}
+
 // RubyExtractor extracts architectural facts from Ruby source code using line-based regex parsing.
 type RubyExtractor struct{}
 
@@ -35,6 +44,7 @@ func (e *RubyExtractor) Detect(repoPath string) (bool, error) {
 // Extract parses Ruby files and emits architectural facts.
 func (e *RubyExtractor) Extract(ctx context.Context, repoPath string, files []string) ([]facts.Fact, error) {
 	var allFacts []facts.Fact
+	var fileErrs extractors.FileErrors
 
 	isRails := detectRailsProject(repoPath)
 
@@ -44,6 +54,7 @@ func (e *RubyExtractor) Extract(ctx context.Context, repoPath string, files []st
 
 	// Track directories that contain Ruby files for module emission.
 	modules := make(map[string]bool)
+	var env extractors.EnvVars
 
 	// Pass 2: parse .rb files.
 	for _, relFile := range files {
@@ -63,15 +74,28 @@ func (e *RubyExtractor) Extract(ctx context.Context, repoPath string, files []st
 		}
 
 		absFile := filepath.Join(repoPath, relFile)
-		f, err := os.Open(absFile)
+		src, err := os.ReadFile(absFile)
 		if err != nil {
-			log.Printf("[ruby-extractor] error reading %s: %v", relFile, err)
+			fileErrs.Add(relFile, err)
 			continue
 		}
 
 		exported := isPublicAPI(relFile, pkgInfo)
-		fileFacts := extractFile(f, relFile, isRails, exported)
-		f.Close()
+		fileFacts := extractFile(bytes.NewReader(src), relFile, isRails, exported)
+
+		// Spec blocks and environment variable reads are not declarations,
+		// so the source is scanned again for them.
+		if isMinitestFile(relFile) {
+			markMinitestMethods(fileFacts, relFile)
+		}
+		env.Scan(relFile, src, extractors.IsHashCommentLine, envReadPatterns...)
+		if isSpecFile(relFile) || isMinitestFile(relFile) {
+			framework := "rspec"
+			if isMinitestFile(relFile) {
+				framework = "minitest"
+			}
+			fileFacts = append(fileFacts, extractSpecBlocks(strings.Split(string(src), "\n"), relFile, framework)...)
+		}
 
 		// Collect storage facts from ActiveRecord patterns found during file parsing.
 		storageFacts := extractStorageFacts(relFile, fileFacts)
@@ -88,6 +112,9 @@ func (e *RubyExtractor) Extract(ctx context.Context, repoPath string, files []st
 		modules[dir] = true
 	}
 
+	allFacts = append(allFacts, env.Facts()...)
+	allFacts = append(allFacts, manifestFacts(repoPath)...)
+
 	// Emit module facts for directories not already covered by packwerk packages.
 	for dir := range modules {
 		if pkgInfo.isPackage(dir) {
@@ -113,7 +140,7 @@ func (e *RubyExtractor) Extract(ctx context.Context, repoPath string, files []st
 		allFacts = append(allFacts, routeFacts...)
 	}
 
-	return allFacts, nil
+	return allFacts, fileErrs.Err()
 }
 
 // --- Rails detection ---
@@ -131,6 +158,54 @@ func detectRailsProject(repoPath string) bool {
 	return false
 }
 
+// isControllerFile reports whether relFile lives under an app/controllers
+// directory, including one inside a packwerk pack.
+func isControllerFile(relFile string) bool {
+	relFile = filepath.ToSlash(relFile)
+	return strings.HasPrefix(relFile, "app/controllers/") || strings.Contains(relFile, "/app/controllers/")
+}
+
+// rubyFrameworkGems are the gems reported as frameworks by DetectFrameworks.
+var rubyFrameworkGems = []string{"rails", "sinatra", "hanami", "grape"}
+
+// gemSpecPattern matches a top-level gem entry in a Gemfile.lock specs
+// section ("    rails (7.1.2)"). Dependencies of a gem are indented further.
+var gemSpecPattern = regexp.MustCompile(`^    ([A-Za-z0-9_.-]+) \(([^)]+)\)$`)
+
+// DetectFrameworks reads the locked versions of framework gems from
+// Gemfile.lock.
+func (e *RubyExtractor) DetectFrameworks(repoPath string) []facts.FrameworkInfo {
+	locked := lockedGems(repoPath)
+	var out []facts.FrameworkInfo
+	for _, gem := range rubyFrameworkGems {
+		if version, ok := locked[gem]; ok {
+			out = append(out, facts.FrameworkInfo{Name: gem, Version: version})
+		}
+	}
+	return out
+}
+
+// lockedGems returns the locked version of each top-level gem in
+// Gemfile.lock, or nil when there is no lockfile.
+func lockedGems(repoPath string) map[string]string {
+	f, err := os.Open(filepath.Join(repoPath, "Gemfile.lock"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	locked := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if m := gemSpecPattern.FindStringSubmatch(scanner.Text()); m != nil {
+			if _, ok := locked[m[1]]; !ok {
+				locked[m[1]] = m[2]
+			}
+		}
+	}
+	return locked
+}
+
 // --- Regex patterns ---
 
 var (
@@ -172,8 +247,37 @@ var (
 	// We require whitespace after = to distinguish from setter defs (def foo=(v))
 	// and from == comparisons. RE2 has no lookahead, so we use \s as the guard.
 	endlessMethodRe = regexp.MustCompile(`\)\s*=\s|\bdef\s+(?:self\.)?[\w?!]+\s*=\s`)
+
+	// selfCallRe matches explicit calls on self: self.method
+	selfCallRe = regexp.MustCompile(`\bself\.([a-z_]\w*[?!]?)`)
+	// sendCallRe matches dynamic dispatch with a literal symbol or string,
+	// optionally on self: send(:method), self.public_send("method")
+	sendCallRe = regexp.MustCompile(`(?:^|[^.\w]|\bself\.)(?:send|public_send|__send__)\s*\(?\s*(?::|['"])([a-z_]\w*[?!]?)`)
+	// identRe matches candidate bare method names; callers filter out
+	// receivers, symbols, hash keys, and names not defined in the class.
+	identRe = regexp.MustCompile(`[a-z_]\w*[?!]?`)
+)
+
+// Confidence levels recorded in a method's call_confidence prop, keyed by
+// callee. All call edges are regex heuristics; the level says how likely the
+// target is right.
+const (
+	// callConfidenceHigh: Constant.method calls, and self.method or
+	// send(:method) calls to a method defined in the same class.
+	callConfidenceHigh = "high"
+	// callConfidenceMedium: bare calls to a method defined in the same
+	// class, which a local variable of the same name could shadow.
+	callConfidenceMedium = "medium"
+	// callConfidenceLow: calls on a lowercase receiver whose type is unknown.
+	callConfidenceLow = "low"
 )
 
+// localCall is a call to a method of the enclosing class.
+type localCall struct {
+	name     string
+	explicit bool // self.method or send(:method) rather than a bare call
+}
+
 // scopeEntry tracks a class/module nesting level.
 type scopeEntry struct {
 	name  string
@@ -184,11 +288,13 @@ type scopeEntry struct {
 // methodEntry tracks an active method body for call accumulation.
 type methodEntry struct {
 	name       string
+	scope      string // qualified class/module name, empty at the top level
+	isSelf     bool   // class method (def self.x or module_function)
 	startDepth int
 }
 
 // extractFile parses a single Ruby file and returns facts.
-func extractFile(f *os.File, relFile string, isRails bool, exportedByPackwerk bool) []facts.Fact {
+func extractFile(f io.Reader, relFile string, isRails bool, exportedByPackwerk bool) []facts.Fact {
 	var result []facts.Fact
 	dir := filepath.Dir(relFile)
 
@@ -206,6 +312,10 @@ func extractFile(f *os.File, relFile string, isRails bool, exportedByPackwerk bo
 		heredocEnd     string // non-empty when inside a heredoc
 	)
 	callAccum := make(map[string][]string)
+	localAccum := make(map[string][]localCall)
+	// methodScopes records the enclosing scope of each method body so local
+	// calls can be resolved against that scope's methods once the file is read.
+	methodScopes := make(map[string]methodEntry)
 
 	for scanner.Scan() {
 		lineNum++
@@ -410,21 +520,19 @@ func extractFile(f *os.File, relFile string, isRails bool, exportedByPackwerk bo
 			}
 			if isRails {
 				props["framework"] = "rails"
+				// Public instance methods of a controller are its actions.
+				if !isSelf && visibility == "public" && isControllerFile(relFile) && strings.HasSuffix(scopeName, "Controller") {
+					props["entry_point"] = facts.EntryControllerAction
+				}
 			}
 
+			entry := methodEntry{name: fullName, scope: scopeName, isSelf: isSelf, startDepth: depth}
+			methodScopes[fullName] = entry
+
 			// For endless methods, extract calls from the expression on this line.
-			var defLineCalls []string
 			if isEndless {
-				defLineCalls = extractRubyCalls(line)
-			}
-
-			rels := []facts.Relation{{Kind: facts.RelDeclares, Target: dir}}
-			seen := make(map[string]bool)
-			for _, callee := range defLineCalls {
-				if !seen[callee] {
-					seen[callee] = true
-					rels = append(rels, facts.Relation{Kind: facts.RelCalls, Target: callee})
-				}
+				callAccum[fullName] = append(callAccum[fullName], extractRubyCalls(line)...)
+				localAccum[fullName] = append(localAccum[fullName], extractLocalCalls(line)...)
 			}
 
 			result = append(result, facts.Fact{
@@ -433,12 +541,12 @@ func extractFile(f *os.File, relFile string, isRails bool, exportedByPackwerk bo
 				File:      relFile,
 				Line:      lineNum,
 				Props:     props,
-				Relations: rels,
+				Relations: []facts.Relation{{Kind: facts.RelDeclares, Target: dir}},
 			})
 
 			// Endless and inline one-liners have no body — don't push to stack or increment depth.
 			if !isInline && !isEndless {
-				methodStack = append(methodStack, methodEntry{name: fullName, startDepth: depth})
+				methodStack = append(methodStack, entry)
 				depth++
 			}
 
@@ -610,6 +718,7 @@ func extractFile(f *os.File, relFile string, isRails bool, exportedByPackwerk bo
 		if len(methodStack) > 0 {
 			mName := methodStack[len(methodStack)-1].name
 			callAccum[mName] = append(callAccum[mName], extractRubyCalls(line)...)
+			localAccum[mName] = append(localAccum[mName], extractLocalCalls(line)...)
 		}
 
 		// Track depth for other block openers (if/unless/case/while/do etc.).
@@ -624,34 +733,98 @@ func extractFile(f *os.File, relFile string, isRails bool, exportedByPackwerk bo
 		}
 	}
 
+	// Names of the methods and attributes defined in this file, used to
+	// resolve self, send, and bare calls to a method of the same class.
+	defined := make(map[string]bool)
+	for _, f := range result {
+		if f.Kind == facts.KindSymbol {
+			defined[f.Name] = true
+		}
+	}
+
 	// Attach accumulated RelCalls edges to each method/function fact.
-	seen := make(map[string]map[string]bool)
 	for i, f := range result {
 		sk, _ := f.Props["symbol_kind"].(string)
 		if f.Kind != facts.KindSymbol ||
 			(sk != facts.SymbolMethod && sk != facts.SymbolFunc) {
 			continue
 		}
-		calls, ok := callAccum[f.Name]
-		if !ok {
+		if _, ok := methodScopes[f.Name]; !ok {
 			continue
 		}
-		if seen[f.Name] == nil {
-			seen[f.Name] = make(map[string]bool)
+
+		confidence := make(map[string]any)
+		var callees []string
+		add := func(callee, level string) {
+			prev, seen := confidence[callee]
+			if !seen {
+				callees = append(callees, callee)
+			}
+			if !seen || confidenceRank(level) > confidenceRank(prev.(string)) {
+				confidence[callee] = level
+			}
+		}
+		for _, callee := range callAccum[f.Name] {
+			if startsWithUpper(callee) {
+				add(callee, callConfidenceHigh)
+			} else {
+				add(callee, callConfidenceLow)
+			}
 		}
-		for _, callee := range calls {
-			if seen[f.Name][callee] {
+		for _, lc := range localAccum[f.Name] {
+			target := resolveLocalCall(methodScopes[f.Name], dir, lc.name)
+			if target == f.Name || !defined[target] {
 				continue
 			}
-			seen[f.Name][callee] = true
+			if lc.explicit {
+				add(target, callConfidenceHigh)
+			} else {
+				add(target, callConfidenceMedium)
+			}
+		}
+		if len(callees) == 0 {
+			continue
+		}
+
+		for _, callee := range callees {
 			result[i].Relations = append(result[i].Relations,
 				facts.Relation{Kind: facts.RelCalls, Target: callee})
 		}
+		result[i].Props["call_confidence"] = confidence
 	}
 
 	return result
 }
 
+// resolveLocalCall names the method a self, send, or bare call refers to:
+// an instance method (Scope#name) from instance methods and a class method
+// (Scope.name) from class methods. Top-level methods call dir.name.
+func resolveLocalCall(caller methodEntry, dir, name string) string {
+	switch {
+	case caller.scope == "":
+		return dir + "." + name
+	case caller.isSelf:
+		return caller.scope + "." + name
+	default:
+		return caller.scope + "#" + name
+	}
+}
+
+func confidenceRank(level string) int {
+	switch level {
+	case callConfidenceHigh:
+		return 2
+	case callConfidenceMedium:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func startsWithUpper(s string) bool {
+	return s != "" && s[0] >= 'A' && s[0] <= 'Z'
+}
+
 // qualifiedName builds a fully-qualified Ruby name from the scope stack.
 func qualifiedName(stack []scopeEntry, name string) string {
 	var parts []string
@@ -690,6 +863,48 @@ func extractRubyCalls(line string) []string {
 	return out
 }
 
+// extractLocalCalls returns candidate calls to methods of the enclosing class
+// found on a single source line: self.method, send(:method) and its
+// variants, and bare identifiers. Identifiers used as receivers, symbols,
+// instance/global variables, or hash keys are skipped; the caller discards
+// names that are not methods of the class.
+func extractLocalCalls(line string) []localCall {
+	var out []localCall
+	explicit := make(map[string]bool)
+	for _, m := range selfCallRe.FindAllStringSubmatch(line, -1) {
+		explicit[m[1]] = true
+		out = append(out, localCall{name: m[1], explicit: true})
+	}
+	for _, m := range sendCallRe.FindAllStringSubmatch(line, -1) {
+		explicit[m[1]] = true
+		out = append(out, localCall{name: m[1], explicit: true})
+	}
+
+	for _, loc := range identRe.FindAllStringIndex(line, -1) {
+		start, end := loc[0], loc[1]
+		if start > 0 {
+			prev := line[start-1]
+			if prev == '.' || prev == ':' || prev == '@' || prev == '$' || prev == '_' ||
+				(prev >= 'a' && prev <= 'z') || (prev >= 'A' && prev <= 'Z') || (prev >= '0' && prev <= '9') {
+				continue
+			}
+		}
+		rest := strings.TrimLeft(line[end:], " \t")
+		if strings.HasPrefix(rest, ".") && !strings.HasPrefix(rest, "..") {
+			continue // receiver of another call
+		}
+		if strings.HasPrefix(rest, ":") && !strings.HasPrefix(rest, "::") {
+			continue // hash key or keyword argument
+		}
+		name := line[start:end]
+		if explicit[name] {
+			continue
+		}
+		out = append(out, localCall{name: name})
+	}
+	return out
+}
+
 // isRubyFile returns true if the file has a .rb extension.
 func isRubyFile(path string) bool {
 	return strings.HasSuffix(strings.ToLower(path), ".rb")
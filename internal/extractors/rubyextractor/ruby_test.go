@@ -1,6 +1,7 @@
 package rubyextractor
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -386,6 +387,57 @@ end
 	}
 }
 
+func TestExtractFile_ControllerActions(t *testing.T) {
+	src := `module Admin
+  class UsersController < ApplicationController
+    def index
+      @users = User.all
+    end
+
+    def self.permitted
+      []
+    end
+
+    private
+
+    def user_params
+      params.require(:user)
+    end
+  end
+end
+`
+	dir := t.TempDir()
+	path := filepath.Join(dir, "users_controller.rb")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	result := extractFile(f, "app/controllers/admin/users_controller.rb", true, true)
+
+	byName := make(map[string]facts.Fact)
+	for _, fact := range result {
+		byName[fact.Name] = fact
+	}
+	if ep := byName["Admin::UsersController#index"].Props["entry_point"]; ep != facts.EntryControllerAction {
+		t.Errorf("index entry_point = %v, want %s", ep, facts.EntryControllerAction)
+	}
+	for _, name := range []string{"Admin::UsersController.permitted", "Admin::UsersController#user_params"} {
+		fact, ok := byName[name]
+		if !ok {
+			t.Errorf("missing method %s", name)
+			continue
+		}
+		if ep := fact.Props["entry_point"]; ep != nil {
+			t.Errorf("%s entry_point = %v, want none", name, ep)
+		}
+	}
+}
+
 func TestExtractFile_EndlessMethodCall(t *testing.T) {
 	// Ruby 3.0+ endless method: def name(args) = Expr.call(args)
 	// The call is on the same line as the def — must be captured directly.
@@ -424,6 +476,109 @@ end
 	}
 }
 
+func TestExtractFile_LocalMethodCalls(t *testing.T) {
+	src := `class OrderProcessor
+  attr_reader :order
+
+  def self.run(id)
+    new(id).process
+    build_logger
+  end
+
+  def self.build_logger
+    Rails.logger
+  end
+
+  def process
+    validate!
+    self.charge(order.total)
+    send(:notify, retries: 3)
+    service.call(order)
+    process if retry?
+  end
+
+  def charge(amount) = gateway(amount: amount)
+
+  private
+
+  def validate!
+    raise "invalid" unless order
+  end
+
+  def notify(retries:)
+  end
+
+  def gateway
+  end
+
+  def retry?
+  end
+end
+`
+	dir := t.TempDir()
+	path := filepath.Join(dir, "order_processor.rb")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	result := extractFile(f, "app/services/order_processor.rb", false, true)
+	byName := make(map[string]facts.Fact)
+	for _, fact := range result {
+		byName[fact.Name] = fact
+	}
+
+	process := byName["OrderProcessor#process"]
+	confidence, _ := process.Props["call_confidence"].(map[string]any)
+	want := map[string]string{
+		"OrderProcessor#validate!": "medium",
+		"OrderProcessor#charge":    "high",
+		"OrderProcessor#notify":    "high",
+		"OrderProcessor#order":     "medium", // attr_reader
+		"OrderProcessor#retry?":    "medium",
+		"service.call":             "low",
+	}
+	for callee, level := range want {
+		if !hasCall(process, callee) {
+			t.Errorf("OrderProcessor#process missing RelCalls -> %s; relations = %v", callee, process.Relations)
+		}
+		if confidence[callee] != level {
+			t.Errorf("call_confidence[%s] = %v, want %s", callee, confidence[callee], level)
+		}
+	}
+	if hasCall(process, "OrderProcessor#process") {
+		t.Error("recursive calls should not produce a self edge")
+	}
+	if hasCall(process, "OrderProcessor#retries") {
+		t.Error("keyword arguments must not be taken as calls")
+	}
+
+	// Class methods resolve bare calls to other class methods.
+	run := byName["OrderProcessor.run"]
+	if !hasCall(run, "OrderProcessor.build_logger") {
+		t.Errorf("OrderProcessor.run missing RelCalls -> OrderProcessor.build_logger; relations = %v", run.Relations)
+	}
+	if hasCall(run, "OrderProcessor#process") {
+		t.Error("class methods must not resolve calls to instance methods")
+	}
+	if c, _ := byName["OrderProcessor.build_logger"].Props["call_confidence"].(map[string]any); c["Rails.logger"] != "high" {
+		t.Errorf("Constant.method calls should be high confidence, got %v", c)
+	}
+
+	// Endless methods resolve local calls from their expression.
+	if charge := byName["OrderProcessor#charge"]; !hasCall(charge, "OrderProcessor#gateway") {
+		t.Errorf("OrderProcessor#charge missing RelCalls -> OrderProcessor#gateway; relations = %v", charge.Relations)
+	}
+
+	if _, ok := byName["OrderProcessor#gateway"].Props["call_confidence"]; ok {
+		t.Error("methods without calls should not carry call_confidence")
+	}
+}
+
 func TestExtractRubyCalls_QualifiedAndReceiver(t *testing.T) {
 	cases := []struct {
 		line  string
@@ -545,3 +700,262 @@ dependencies:
 		t.Fatal("missing root module fact (should be named 'root', not '.')")
 	}
 }
+
+func TestDetectFrameworks_GemfileLock(t *testing.T) {
+	dir := t.TempDir()
+	lock := `GEM
+  remote: https://rubygems.org/
+  specs:
+    actionpack (7.1.2)
+      rack (>= 2.2.4)
+    rails (7.1.2)
+      actionpack (= 7.1.2)
+    sinatra (4.0.0)
+
+DEPENDENCIES
+  rails (~> 7.1)
+`
+	if err := os.WriteFile(filepath.Join(dir, "Gemfile.lock"), []byte(lock), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got := New().DetectFrameworks(dir)
+	want := []facts.FrameworkInfo{{Name: "rails", Version: "7.1.2"}, {Name: "sinatra", Version: "4.0.0"}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("DetectFrameworks = %v, want %v", got, want)
+	}
+	if got := New().DetectFrameworks(t.TempDir()); len(got) != 0 {
+		t.Errorf("without Gemfile.lock: %v", got)
+	}
+}
+
+func TestParseRouteFile_Resources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.rb")
+	src := `Rails.application.routes.draw do
+  root "home#index"
+  resources :users, only: [:index, :show] do
+    resources :comments, only: %i[index create]
+    member do
+      post :activate
+    end
+    collection do
+      get :search
+    end
+  end
+  resource :profile, except: :destroy
+  namespace :admin do
+    resources :reports, only: :show
+    get "stats", to: "dashboard#stats"
+  end
+  resources :photos, controller: "images", only: [:index]
+end
+`
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// method + path -> calls target
+	got := make(map[string]string)
+	for _, r := range parseRouteFile(f, "config/routes.rb") {
+		target := ""
+		for _, rel := range r.Relations {
+			if rel.Kind == facts.RelCalls {
+				target = rel.Target
+			}
+		}
+		got[r.Props["method"].(string)+" "+r.Name] = target
+	}
+
+	want := map[string]string{
+		"GET /":                         "HomeController#index",
+		"GET /users":                    "UsersController#index",
+		"GET /users/:id":                "UsersController#show",
+		"GET /users/:user_id/comments":  "CommentsController#index",
+		"POST /users/:user_id/comments": "CommentsController#create",
+		"POST /users/:id/activate":      "UsersController#activate",
+		"GET /users/search":             "UsersController#search",
+		"GET /profile":                  "ProfilesController#show",
+		"POST /profile":                 "ProfilesController#create",
+		"GET /profile/new":              "ProfilesController#new",
+		"GET /profile/edit":             "ProfilesController#edit",
+		"PATCH /profile":                "ProfilesController#update",
+		"GET /admin/reports/:id":        "Admin::ReportsController#show",
+		"GET /admin/stats":              "Admin::DashboardController#stats",
+		"GET /photos":                   "ImagesController#index",
+	}
+	for route, target := range want {
+		if got[route] != target {
+			t.Errorf("%s calls %q, want %q", route, got[route], target)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d routes, want %d: %v", len(got), len(want), got)
+	}
+}
+
+func TestExtract_SpecAndMinitestTests(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"spec/models/user_spec.rb": `require "rails_helper"
+
+RSpec.describe User, type: :model do
+  describe "#save" do
+    it "persists the user" do
+      expect(user.save).to be(true)
+    end
+
+    context 'when invalid' do
+      it("raises") { expect { user.save! }.to raise_error }
+    end
+  end
+
+  it "has a name" do
+  end
+end
+`,
+		"test/user_test.rb": `class UserTest < Minitest::Test
+  def test_name
+    assert_equal "a", User.new("a").name
+  end
+
+  def build_user
+    User.new("a")
+  end
+end
+`,
+	}
+	var relFiles []string
+	for rel, src := range files {
+		abs := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		relFiles = append(relFiles, rel)
+	}
+	ff, err := New().Extract(context.Background(), dir, relFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]facts.Fact)
+	for _, f := range ff {
+		byName[f.Name] = f
+	}
+
+	for name, want := range map[string]struct {
+		kind, framework string
+		line            int
+	}{
+		"spec/models/user_spec.rb > User":                                 {"suite", "rspec", 3},
+		"spec/models/user_spec.rb > User > #save > persists the user":     {"test", "rspec", 5},
+		"spec/models/user_spec.rb > User > #save > when invalid > raises": {"test", "rspec", 10},
+		"spec/models/user_spec.rb > User > has a name":                    {"test", "rspec", 14},
+		"UserTest#test_name": {"test", "minitest", 2},
+	} {
+		f, ok := byName[name]
+		if !ok {
+			t.Errorf("expected test %q", name)
+			continue
+		}
+		if f.Props["test"] != true || f.Props["test_kind"] != want.kind || f.Props["test_framework"] != want.framework || f.Line != want.line {
+			t.Errorf("%q: line %d, props %v; want line %d, %s %s", name, f.Line, f.Props, want.line, want.kind, want.framework)
+		}
+	}
+	if f := byName["UserTest#build_user"]; f.Props["test"] != nil {
+		t.Errorf("build_user should not be a test: %v", f.Props)
+	}
+}
+
+func TestExtract_EnvVarReads(t *testing.T) {
+	dir := t.TempDir()
+	src := `class Mailer
+  # ENV["COMMENTED_OUT"]
+  def host
+    ENV["SMTP_HOST"] || ENV.fetch('SMTP_PORT', "25")
+  end
+end
+`
+	if err := os.WriteFile(filepath.Join(dir, "mailer.rb"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ff, err := New().Extract(context.Background(), dir, []string{"mailer.rb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range ff {
+		if f.Kind == facts.KindConfig {
+			names = append(names, f.Name)
+			if f.Line != 4 || f.Props["source"] != "env" {
+				t.Errorf("%s: line %d, props %v", f.Name, f.Line, f.Props)
+			}
+		}
+	}
+	if strings.Join(names, ",") != "env:SMTP_HOST,env:SMTP_PORT" {
+		t.Errorf("config facts = %v, want SMTP_HOST and SMTP_PORT", names)
+	}
+}
+
+func TestExtract_GemfileDependencies(t *testing.T) {
+	dir := t.TempDir()
+	gemfile := `source "https://rubygems.org"
+
+gem "rails", "~> 7.1", ">= 7.1.2"
+gem 'pg'
+# gem "commented_out"
+
+group :development, :test do
+  gem "rspec-rails"
+  if ENV["CI"]
+    gem "simplecov", require: false
+  end
+end
+
+gem "sidekiq", group: :jobs
+`
+	lock := `GEM
+  remote: https://rubygems.org/
+  specs:
+    pg (1.5.4)
+    rails (7.1.3)
+      actionpack (= 7.1.3)
+`
+	for name, content := range map[string]string{"Gemfile": gemfile, "Gemfile.lock": lock, "app/models/user.rb": "class User\nend\n"} {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ff, err := New().Extract(context.Background(), dir, []string{"app/models/user.rb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]facts.Fact)
+	for _, f := range ff {
+		if f.Kind == facts.KindDependency && f.File == "Gemfile" {
+			byName[f.Props["package"].(string)] = f
+		}
+	}
+	if len(byName) != 5 {
+		t.Fatalf("Gemfile dependencies = %v, want rails, pg, rspec-rails, simplecov and sidekiq", byName)
+	}
+	rails := byName["rails"]
+	if rails.Line != 3 || rails.Props["version"] != "~> 7.1, >= 7.1.2" || rails.Props["locked_version"] != "7.1.3" || rails.Props["source"] != "external" {
+		t.Errorf("rails = %+v", rails)
+	}
+	if pg := byName["pg"]; pg.Props["version"] != nil || pg.Props["locked_version"] != "1.5.4" {
+		t.Errorf("pg props = %v, want no declared version and locked 1.5.4", pg.Props)
+	}
+	for name, want := range map[string]string{"rspec-rails": "development,test", "simplecov": "development,test", "sidekiq": "jobs", "rails": ""} {
+		groups, _ := byName[name].Props["groups"].([]string)
+		if strings.Join(groups, ",") != want {
+			t.Errorf("%s groups = %v, want %q", name, groups, want)
+		}
+	}
+}
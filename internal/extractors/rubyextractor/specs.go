@@ -0,0 +1,91 @@
+package rubyextractor
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/extractors"
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// specBlockRe matches the opening line of an RSpec (or Minitest spec) block
+// with a title: a quoted string or a constant, as in
+// `RSpec.describe User, type: :model do` or `it "saves the user" do`.
+var specBlockRe = regexp.MustCompile(`^(\s*)(?:RSpec\.)?(describe|context|feature|it|specify|scenario|example)[\s(]+("[^"]*"|'[^']*'|[A-Z][\w:]*)(.*)$`)
+
+// specBlockKinds maps spec block methods to their test_kind.
+var specBlockKinds = map[string]string{
+	"describe": extractors.TestKindSuite,
+	"context":  extractors.TestKindSuite,
+	"feature":  extractors.TestKindSuite,
+	"it":       extractors.TestKindTest,
+	"specify":  extractors.TestKindTest,
+	"scenario": extractors.TestKindTest,
+	"example":  extractors.TestKindTest,
+}
+
+// isSpecFile and isMinitestFile classify Ruby test files by their suffix.
+func isSpecFile(relFile string) bool     { return strings.HasSuffix(relFile, "_spec.rb") }
+func isMinitestFile(relFile string) bool { return strings.HasSuffix(relFile, "_test.rb") }
+
+// extractSpecBlocks returns a symbol fact for each describe, context and it
+// block of a spec file, named after the file and the titles of its enclosing
+// blocks ("spec/models/user_spec.rb > User > saves"). Blocks are nested by
+// indentation, so files that are not conventionally indented nest loosely.
+// Like Go test functions, spec blocks get no declares relation.
+func extractSpecBlocks(lines []string, relFile, framework string) []facts.Fact {
+	type open struct {
+		indent int
+		title  string
+	}
+	var stack []open
+	var result []facts.Fact
+	for i, line := range lines {
+		m := specBlockRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if rest := m[4]; !strings.Contains(rest, " do") && !strings.Contains(rest, "{") {
+			continue
+		}
+		indent := len(m[1])
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		title := strings.Trim(m[3], `"'`)
+		titles := make([]string, 0, len(stack)+1)
+		for _, o := range stack {
+			titles = append(titles, o.title)
+		}
+		titles = append(titles, title)
+
+		kind := specBlockKinds[m[2]]
+		f := facts.Fact{
+			Kind:  facts.KindSymbol,
+			Name:  relFile + " > " + strings.Join(titles, " > "),
+			File:  relFile,
+			Line:  i + 1,
+			Props: map[string]any{"symbol_kind": facts.SymbolFunc, "language": "ruby"},
+		}
+		extractors.SetTest(f.Props, kind, framework)
+		result = append(result, f)
+		if kind == extractors.TestKindSuite {
+			stack = append(stack, open{indent: indent, title: title})
+		}
+	}
+	return result
+}
+
+// markMinitestMethods tags the test_* instance methods of a Minitest file
+// as tests.
+func markMinitestMethods(ff []facts.Fact, relFile string) {
+	for i := range ff {
+		f := &ff[i]
+		if f.Kind != facts.KindSymbol || f.File != relFile || f.Props["symbol_kind"] != facts.SymbolMethod {
+			continue
+		}
+		if _, name, ok := strings.Cut(f.Name, "#"); ok && strings.HasPrefix(name, "test_") {
+			extractors.SetTest(f.Props, extractors.TestKindTest, "minitest")
+		}
+	}
+}
@@ -0,0 +1,558 @@
+package scalaextractor
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/dejo1307/archmcp/internal/extractors"
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// ScalaExtractor extracts architectural facts from Scala source code using line-based regex parsing.
+type ScalaExtractor struct{}
+
+// New creates a new ScalaExtractor.
+func New() *ScalaExtractor {
+	return &ScalaExtractor{}
+}
+
+func (e *ScalaExtractor) Name() string {
+	return "scala"
+}
+
+// Detect returns true if the repository is an sbt project.
+func (e *ScalaExtractor) Detect(repoPath string) (bool, error) {
+	if _, err := os.Stat(filepath.Join(repoPath, "build.sbt")); err == nil {
+		return true, nil
+	}
+	return false, nil
+}
+
+// Extract parses Scala files and emits architectural facts.
+func (e *ScalaExtractor) Extract(ctx context.Context, repoPath string, files []string) ([]facts.Fact, error) {
+	var allFacts []facts.Fact
+	var fileErrs extractors.FileErrors
+
+	// Map every package declared in the repo to its directory so imports of
+	// internal packages resolve to module paths.
+	packages := collectPackages(repoPath, files)
+
+	modules := make(map[string]bool)
+
+	for _, relFile := range files {
+		select {
+		case <-ctx.Done():
+			return allFacts, ctx.Err()
+		default:
+		}
+
+		if !isScalaFile(relFile) {
+			continue
+		}
+
+		absFile := filepath.Join(repoPath, relFile)
+		f, err := os.Open(absFile)
+		if err != nil {
+			fileErrs.Add(relFile, err)
+			continue
+		}
+
+		fileFacts := extractFile(f, relFile, packages)
+		f.Close()
+		allFacts = append(allFacts, fileFacts...)
+
+		dir := filepath.Dir(relFile)
+		modules[dir] = true
+	}
+
+	for dir := range modules {
+		allFacts = append(allFacts, facts.Fact{
+			Kind: facts.KindModule,
+			Name: dir,
+			File: dir,
+			Props: map[string]any{
+				"language": "scala",
+			},
+		})
+	}
+
+	return allFacts, fileErrs.Err()
+}
+
+// --- Regex patterns ---
+
+var (
+	packageRe = regexp.MustCompile(`^\s*package\s+([\w.]+)\s*$`)
+	importRe  = regexp.MustCompile(`^\s*import\s+(.+?)\s*;?\s*$`)
+
+	// Modifiers that may precede a declaration, including qualified access
+	// like private[orders].
+	modifiers = `((?:(?:private|protected)(?:\[\w+\])?\s+|(?:final|sealed|abstract|implicit|lazy|override|case|open|inline|transparent)\s+)*)`
+
+	// Class / object / trait declarations.
+	// Captures: modifiers (group 1), keyword (group 2), name (group 3).
+	typeRe = regexp.MustCompile(`^\s*` + modifiers + `(class|object|trait)\s+(\w+)`)
+
+	defRe = regexp.MustCompile(`^\s*` + modifiers + `def\s+([\w]+|` + "`[^`]+`" + `)`)
+
+	valRe = regexp.MustCompile(`^\s*` + modifiers + `(val|var)\s+(\w+)`)
+
+	// Visibility check — private or protected means not exported.
+	privateOrProtectedRe = regexp.MustCompile(`\b(private|protected)\b`)
+)
+
+// scope is an enclosing class, object, or trait whose body starts at depth.
+type scope struct {
+	name  string
+	depth int
+}
+
+// pendingType tracks a type declaration that spans multiple lines, such as a
+// case class with one parameter per line or an extends clause on the next line.
+type pendingType struct {
+	modifiers  string
+	keyword    string
+	name       string
+	owner      string
+	line       int
+	parenDepth int
+	text       string // declaration text after the type name
+}
+
+// extractFile parses a single Scala file and returns facts.
+func extractFile(f *os.File, relFile string, packages map[string]string) []facts.Fact {
+	var result []facts.Fact
+	dir := filepath.Dir(relFile)
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 256*1024), 1024*1024)
+
+	var (
+		lineNum      int
+		braceDepth   int
+		scopes       []scope
+		pending      *pendingType
+		inComment    bool
+		pendingDepth int
+	)
+
+	// emitType finalizes a type declaration. A type whose body opens on
+	// the declaration becomes the scope for the members that follow.
+	emitType := func(pt *pendingType, bodyDepth int) {
+		result = append(result, buildTypeFact(dir, relFile, pt))
+		if strings.Contains(pt.text, "{") {
+			scopes = append(scopes, scope{name: qualify(pt.owner, pt.name), depth: bodyDepth})
+		}
+	}
+
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		// Skip Scaladoc and block comments so braces inside them are not counted.
+		if inComment {
+			if strings.Contains(line, "*/") {
+				inComment = false
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "/*") {
+			inComment = !strings.Contains(trimmed, "*/")
+			continue
+		}
+		if strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+		code := stripLineComment(line)
+
+		depthBefore := braceDepth
+		braceDepth += strings.Count(code, "{") - strings.Count(code, "}")
+
+		// Continue a multi-line type declaration until its constructor
+		// parameters close and no extends/with clause follows.
+		if pending != nil {
+			if pending.parenDepth > 0 || strings.HasPrefix(trimmed, "extends ") || strings.HasPrefix(trimmed, "with ") {
+				pending.parenDepth += strings.Count(code, "(") - strings.Count(code, ")")
+				pending.text += " " + strings.TrimSpace(code)
+				if strings.Contains(code, "{") {
+					emitType(pending, pendingDepth+1)
+					pending = nil
+				}
+				continue
+			}
+			emitType(pending, pendingDepth+1)
+			pending = nil
+		}
+
+		// Leave scopes whose bodies have closed.
+		for len(scopes) > 0 && depthBefore < scopes[len(scopes)-1].depth {
+			scopes = scopes[:len(scopes)-1]
+		}
+
+		// Declarations are only recorded at the top level or directly in a
+		// type body, never inside method bodies.
+		owner := ""
+		if len(scopes) > 0 {
+			top := scopes[len(scopes)-1]
+			if depthBefore != top.depth {
+				continue
+			}
+			owner = top.name
+		} else if depthBefore != 0 {
+			continue
+		}
+
+		// Import statements.
+		if owner == "" {
+			if m := importRe.FindStringSubmatch(code); m != nil {
+				resolved, isExternal := resolveScalaImport(m[1], packages)
+				if resolved == "" {
+					continue
+				}
+
+				importSource := "internal"
+				if isExternal {
+					importSource = "external"
+				}
+
+				result = append(result, facts.Fact{
+					Kind: facts.KindDependency,
+					Name: dir + " -> " + resolved,
+					File: relFile,
+					Line: lineNum,
+					Props: map[string]any{
+						"language": "scala",
+						"source":   importSource,
+					},
+					Relations: []facts.Relation{
+						{Kind: facts.RelImports, Target: resolved},
+					},
+				})
+				continue
+			}
+		}
+
+		// Class / object / trait declarations.
+		if m := typeRe.FindStringSubmatch(code); m != nil {
+			nameIdx := strings.Index(code, m[2]+" "+m[3])
+			rest := code[nameIdx+len(m[2])+1+len(m[3]):]
+			pt := &pendingType{
+				modifiers:  m[1],
+				keyword:    m[2],
+				name:       m[3],
+				owner:      owner,
+				line:       lineNum,
+				parenDepth: strings.Count(rest, "(") - strings.Count(rest, ")"),
+				text:       rest,
+			}
+			if strings.Contains(rest, "{") {
+				emitType(pt, depthBefore+1)
+			} else {
+				// The declaration may continue on the next lines.
+				pending = pt
+				pendingDepth = depthBefore
+			}
+			continue
+		}
+
+		// Function / method declarations.
+		if m := defRe.FindStringSubmatch(code); m != nil {
+			name := strings.Trim(m[2], "`")
+
+			symbolKind := facts.SymbolFunc
+			if owner != "" {
+				symbolKind = facts.SymbolMethod
+			}
+
+			result = append(result, facts.Fact{
+				Kind: facts.KindSymbol,
+				Name: dir + "." + qualify(owner, name),
+				File: relFile,
+				Line: lineNum,
+				Props: map[string]any{
+					"symbol_kind": symbolKind,
+					"exported":    !privateOrProtectedRe.MatchString(m[1]),
+					"language":    "scala",
+				},
+				Relations: []facts.Relation{
+					{Kind: facts.RelDeclares, Target: dir},
+				},
+			})
+			continue
+		}
+
+		// Value / variable declarations.
+		if m := valRe.FindStringSubmatch(code); m != nil {
+			symbolKind := facts.SymbolVariable
+			if m[2] == "val" {
+				symbolKind = facts.SymbolConstant
+			}
+
+			result = append(result, facts.Fact{
+				Kind: facts.KindSymbol,
+				Name: dir + "." + qualify(owner, m[3]),
+				File: relFile,
+				Line: lineNum,
+				Props: map[string]any{
+					"symbol_kind": symbolKind,
+					"exported":    !privateOrProtectedRe.MatchString(m[1]),
+					"language":    "scala",
+				},
+				Relations: []facts.Relation{
+					{Kind: facts.RelDeclares, Target: dir},
+				},
+			})
+			continue
+		}
+	}
+
+	if pending != nil {
+		emitType(pending, pendingDepth+1)
+	}
+
+	return result
+}
+
+// buildTypeFact creates a symbol fact for a class, object, or trait declaration.
+func buildTypeFact(dir, relFile string, pt *pendingType) facts.Fact {
+	symbolKind := facts.SymbolClass
+	if pt.keyword == "trait" {
+		symbolKind = facts.SymbolInterface
+	}
+
+	f := facts.Fact{
+		Kind: facts.KindSymbol,
+		Name: dir + "." + qualify(pt.owner, pt.name),
+		File: relFile,
+		Line: pt.line,
+		Props: map[string]any{
+			"symbol_kind": symbolKind,
+			"exported":    !privateOrProtectedRe.MatchString(pt.modifiers),
+			"language":    "scala",
+		},
+		Relations: []facts.Relation{
+			{Kind: facts.RelDeclares, Target: dir},
+		},
+	}
+
+	isCase := strings.Contains(pt.modifiers, "case")
+	if pt.keyword == "object" {
+		f.Props["object"] = true
+		if isCase {
+			f.Props["case_object"] = true
+		}
+	}
+	if pt.keyword == "class" && isCase {
+		f.Props["data_class"] = true
+	}
+	if strings.Contains(pt.modifiers, "sealed") {
+		f.Props["sealed"] = true
+	}
+	if strings.Contains(pt.modifiers, "abstract") {
+		f.Props["abstract"] = true
+	}
+
+	for _, st := range parseSupertypes(extractSupertypesFromText(pt.text)) {
+		f.Relations = append(f.Relations, facts.Relation{
+			Kind:   facts.RelImplements,
+			Target: st,
+		})
+	}
+
+	return f
+}
+
+// --- Parsing helpers ---
+
+// extractSupertypesFromText returns the clause following "extends" in a
+// declaration, skipping constructor parameters and type parameters. The
+// clause ends at the opening brace of the body or a Scala 3 colon.
+func extractSupertypesFromText(text string) string {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case 'e':
+			if depth <= 0 && strings.HasPrefix(text[i:], "extends ") && (i == 0 || text[i-1] == ' ' || text[i-1] == ')' || text[i-1] == ']') {
+				rest := text[i+len("extends "):]
+				if idx := strings.Index(rest, "{"); idx >= 0 {
+					rest = rest[:idx]
+				}
+				if idx := strings.Index(rest, ":"); idx >= 0 && strings.TrimSpace(rest[idx+1:]) == "" {
+					rest = rest[:idx]
+				}
+				return strings.TrimSpace(rest)
+			}
+		}
+	}
+	return ""
+}
+
+// parseSupertypes splits a clause like "Actor with ActorLogging" or
+// "Base[T](x), Serializable" into simple type names.
+func parseSupertypes(clause string) []string {
+	if clause == "" {
+		return nil
+	}
+	var result []string
+	depth := 0
+	start := 0
+	flush := func(end int) {
+		if t := extractTypeName(clause[start:end]); t != "" {
+			result = append(result, t)
+		}
+	}
+	for i := 0; i < len(clause); i++ {
+		switch clause[i] {
+		case '[', '(':
+			depth++
+		case ']', ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				flush(i)
+				start = i + 1
+			}
+		case ' ':
+			if depth == 0 && strings.HasPrefix(clause[i:], " with ") {
+				flush(i)
+				start = i + len(" with ")
+				i = start - 1
+			}
+		}
+	}
+	flush(len(clause))
+	return result
+}
+
+// extractTypeName extracts the simple type name from an entry like
+// "akka.actor.Actor", "Base[T]", or "Entity(id)".
+func extractTypeName(s string) string {
+	s = strings.TrimSpace(s)
+	if idx := strings.IndexAny(s, "[( "); idx >= 0 {
+		s = s[:idx]
+	}
+	if idx := strings.LastIndex(s, "."); idx >= 0 {
+		s = s[idx+1:]
+	}
+	return s
+}
+
+// qualify joins an enclosing type name and a member name.
+func qualify(owner, name string) string {
+	if owner == "" {
+		return name
+	}
+	return owner + "." + name
+}
+
+// stripLineComment removes a trailing // comment that is not inside a string.
+func stripLineComment(line string) string {
+	inString := false
+	for i := 0; i < len(line)-1; i++ {
+		switch {
+		case line[i] == '"':
+			inString = !inString
+		case !inString && line[i] == '/' && line[i+1] == '/':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func isScalaFile(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".scala")
+}
+
+// collectPackages reads the package clauses of every Scala file and maps each
+// package to the directory declaring it. Chained clauses
+// ("package com.acme" followed by "package orders") are joined.
+func collectPackages(repoPath string, files []string) map[string]string {
+	packages := make(map[string]string)
+	for _, relFile := range files {
+		if !isScalaFile(relFile) {
+			continue
+		}
+		f, err := os.Open(filepath.Join(repoPath, relFile))
+		if err != nil {
+			continue
+		}
+		if pkg := readPackage(f); pkg != "" {
+			if _, exists := packages[pkg]; !exists {
+				packages[pkg] = filepath.ToSlash(filepath.Dir(relFile))
+			}
+		}
+		f.Close()
+	}
+	return packages
+}
+
+// readPackage returns the full package name declared at the top of a file.
+func readPackage(f *os.File) string {
+	var parts []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		trimmed := strings.TrimSpace(scanner.Text())
+		if trimmed == "" || strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*") || strings.HasPrefix(trimmed, "*") {
+			continue
+		}
+		m := packageRe.FindStringSubmatch(trimmed)
+		if m == nil {
+			break
+		}
+		parts = append(parts, m[1])
+	}
+	return strings.Join(parts, ".")
+}
+
+// resolveScalaImport maps an import clause to the package it imports from.
+// Selectors ("{A, B}", "_", "*", "given") and trailing type or object names
+// are dropped, so "com.acme.orders.{Order, OrderId}" yields "com.acme.orders".
+// Packages declared in the repo are converted to their directory so the graph
+// can match them to module facts; everything else is external and kept dotted.
+func resolveScalaImport(clause string, packages map[string]string) (string, bool) {
+	clause = strings.TrimPrefix(clause, "_root_.")
+	if idx := strings.IndexAny(clause, "{ "); idx >= 0 {
+		clause = clause[:idx]
+	}
+	segments := strings.Split(strings.Trim(clause, "."), ".")
+
+	// Drop the imported member: wildcards and capitalized type/object names.
+	for len(segments) > 1 {
+		last := segments[len(segments)-1]
+		if last == "_" || last == "*" || last == "given" || last == "" || startsUpper(last) {
+			segments = segments[:len(segments)-1]
+			continue
+		}
+		break
+	}
+	if len(segments) == 0 || segments[0] == "" {
+		return "", true
+	}
+
+	// Longest declared package prefix wins; deeper segments are subpackages.
+	for i := len(segments); i > 0; i-- {
+		pkg := strings.Join(segments[:i], ".")
+		if dir, ok := packages[pkg]; ok {
+			return strings.Join(append([]string{dir}, segments[i:]...), "/"), false
+		}
+	}
+
+	return strings.Join(segments, "."), true
+}
+
+func startsUpper(s string) bool {
+	for _, r := range s {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
@@ -0,0 +1,286 @@
+package scalaextractor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// --- helpers ---
+
+func extractFromString(t *testing.T, src string, packages map[string]string) []facts.Fact {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Test.scala")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	return extractFile(f, "pkg/Test.scala", packages)
+}
+
+func findFact(ff []facts.Fact, name string) (facts.Fact, bool) {
+	for _, f := range ff {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return facts.Fact{}, false
+}
+
+func hasRelation(f facts.Fact, relKind, target string) bool {
+	for _, r := range f.Relations {
+		if r.Kind == relKind && r.Target == target {
+			return true
+		}
+	}
+	return false
+}
+
+// --- Unit tests for parsing helpers ---
+
+func TestParseSupertypes(t *testing.T) {
+	tests := []struct {
+		clause string
+		want   []string
+	}{
+		{"Actor with ActorLogging", []string{"Actor", "ActorLogging"}},
+		{"Base[T](id) with Serializable", []string{"Base", "Serializable"}},
+		{"akka.actor.Actor", []string{"Actor"}},
+		{"A, B", []string{"A", "B"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := parseSupertypes(tt.clause)
+		if len(got) != len(tt.want) {
+			t.Errorf("parseSupertypes(%q) = %v, want %v", tt.clause, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("parseSupertypes(%q)[%d] = %q, want %q", tt.clause, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestResolveScalaImport(t *testing.T) {
+	packages := map[string]string{
+		"com.acme.orders": "orders/src/main/scala/com/acme/orders",
+	}
+	tests := []struct {
+		clause       string
+		want         string
+		wantExternal bool
+	}{
+		{"com.acme.orders.OrderService", "orders/src/main/scala/com/acme/orders", false},
+		{"com.acme.orders.{Order, OrderId}", "orders/src/main/scala/com/acme/orders", false},
+		{"com.acme.orders._", "orders/src/main/scala/com/acme/orders", false},
+		{"com.acme.orders.model.Order", "orders/src/main/scala/com/acme/orders/model", false},
+		{"akka.actor.typed.scaladsl.Behaviors", "akka.actor.typed.scaladsl", true},
+		{"scala.concurrent.duration._", "scala.concurrent.duration", true},
+		{"_root_.com.acme.orders.Order", "orders/src/main/scala/com/acme/orders", false},
+	}
+	for _, tt := range tests {
+		got, external := resolveScalaImport(tt.clause, packages)
+		if got != tt.want || external != tt.wantExternal {
+			t.Errorf("resolveScalaImport(%q) = %q, %v; want %q, %v", tt.clause, got, external, tt.want, tt.wantExternal)
+		}
+	}
+}
+
+// --- Extraction tests ---
+
+func TestExtract_Declarations(t *testing.T) {
+	src := `package com.acme.orders
+
+/** Handles orders. { not a brace } */
+trait OrderRepository {
+  def find(id: String): Option[Order]
+}
+
+final case class Order(id: String, total: BigDecimal)
+
+object OrderService {
+  val DefaultTimeout = 5
+  private var counter = 0
+
+  def place(order: Order): Unit = {
+    val local = 1
+  }
+}
+
+abstract class BaseActor
+
+private[orders] def helper(): Int = 1
+`
+	ff := extractFromString(t, src, nil)
+
+	tests := []struct {
+		name       string
+		symbolKind string
+		exported   bool
+	}{
+		{"pkg.OrderRepository", facts.SymbolInterface, true},
+		{"pkg.OrderRepository.find", facts.SymbolMethod, true},
+		{"pkg.Order", facts.SymbolClass, true},
+		{"pkg.OrderService", facts.SymbolClass, true},
+		{"pkg.OrderService.DefaultTimeout", facts.SymbolConstant, true},
+		{"pkg.OrderService.counter", facts.SymbolVariable, false},
+		{"pkg.OrderService.place", facts.SymbolMethod, true},
+		{"pkg.BaseActor", facts.SymbolClass, true},
+		{"pkg.helper", facts.SymbolFunc, false},
+	}
+	for _, tt := range tests {
+		f, ok := findFact(ff, tt.name)
+		if !ok {
+			t.Errorf("expected fact %s", tt.name)
+			continue
+		}
+		if f.Props["symbol_kind"] != tt.symbolKind || f.Props["exported"] != tt.exported {
+			t.Errorf("%s: symbol_kind=%v exported=%v, want %s %v",
+				tt.name, f.Props["symbol_kind"], f.Props["exported"], tt.symbolKind, tt.exported)
+		}
+	}
+
+	if _, ok := findFact(ff, "pkg.OrderService.local"); ok {
+		t.Error("locals inside method bodies should not be extracted")
+	}
+	if f, _ := findFact(ff, "pkg.Order"); f.Props["data_class"] != true {
+		t.Errorf("case class should set data_class, got %v", f.Props)
+	}
+	if f, _ := findFact(ff, "pkg.OrderService"); f.Props["object"] != true || f.Props["data_class"] != nil {
+		t.Errorf("object props = %v", f.Props)
+	}
+	if f, _ := findFact(ff, "pkg.BaseActor"); f.Props["abstract"] != true {
+		t.Errorf("abstract class props = %v", f.Props)
+	}
+}
+
+func TestExtract_ExtendsWith(t *testing.T) {
+	src := `package com.acme.orders
+
+class OrderActor(repo: OrderRepository) extends Actor with ActorLogging with Timers {
+  def receive: Receive = Actor.emptyBehavior
+}
+
+object OrderActor {
+  sealed trait Command
+  final case class PlaceOrder(
+      id: String,
+      replyTo: ActorRef[Done]
+  ) extends Command
+  case object Stop extends Command
+}
+`
+	ff := extractFromString(t, src, nil)
+
+	actor, ok := findFact(ff, "pkg.OrderActor")
+	if !ok {
+		t.Fatal("expected fact pkg.OrderActor")
+	}
+	for _, st := range []string{"Actor", "ActorLogging", "Timers"} {
+		if !hasRelation(actor, facts.RelImplements, st) {
+			t.Errorf("OrderActor should implement %s, relations: %v", st, actor.Relations)
+		}
+	}
+	if hasRelation(actor, facts.RelImplements, "OrderRepository") {
+		t.Error("constructor parameter types must not become supertypes")
+	}
+
+	place, ok := findFact(ff, "pkg.OrderActor.PlaceOrder")
+	if !ok {
+		t.Fatal("expected multi-line case class pkg.OrderActor.PlaceOrder")
+	}
+	if place.Line != 9 || place.Props["data_class"] != true || !hasRelation(place, facts.RelImplements, "Command") {
+		t.Errorf("PlaceOrder = line %d props %v relations %v", place.Line, place.Props, place.Relations)
+	}
+
+	stop, ok := findFact(ff, "pkg.OrderActor.Stop")
+	if !ok {
+		t.Fatal("expected case object pkg.OrderActor.Stop")
+	}
+	if stop.Props["object"] != true || stop.Props["case_object"] != true || !hasRelation(stop, facts.RelImplements, "Command") {
+		t.Errorf("Stop = props %v relations %v", stop.Props, stop.Relations)
+	}
+	if f, _ := findFact(ff, "pkg.OrderActor.Command"); f.Props["sealed"] != true {
+		t.Errorf("sealed trait props = %v", f.Props)
+	}
+}
+
+func TestExtract_Imports(t *testing.T) {
+	packages := map[string]string{"com.acme.billing": "billing/src/main/scala/com/acme/billing"}
+	src := `package com.acme.orders
+
+import com.acme.billing.{Invoice, InvoiceService}
+import akka.actor.Actor
+
+object Orders {
+  import Actor._
+}
+`
+	ff := extractFromString(t, src, packages)
+
+	var deps []facts.Fact
+	for _, f := range ff {
+		if f.Kind == facts.KindDependency {
+			deps = append(deps, f)
+		}
+	}
+	if len(deps) != 2 {
+		t.Fatalf("expected 2 top-level imports, got %d: %v", len(deps), deps)
+	}
+	if !hasRelation(deps[0], facts.RelImports, "billing/src/main/scala/com/acme/billing") || deps[0].Props["source"] != "internal" {
+		t.Errorf("internal import = %v %v", deps[0].Relations, deps[0].Props)
+	}
+	if !hasRelation(deps[1], facts.RelImports, "akka.actor") || deps[1].Props["source"] != "external" {
+		t.Errorf("external import = %v %v", deps[1].Relations, deps[1].Props)
+	}
+}
+
+func TestExtract_ResolvesPackagesAcrossFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"build.sbt": `name := "shop"`,
+		"billing/src/main/scala/com/acme/billing/Invoice.scala": "package com.acme\npackage billing\n\ncase class Invoice(id: String)\n",
+		"orders/src/main/scala/com/acme/orders/Order.scala":     "package com.acme.orders\n\nimport com.acme.billing.Invoice\n\ncase class Order(invoice: Invoice)\n",
+	}
+	var rel []string
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		rel = append(rel, name)
+	}
+
+	e := New()
+	if ok, _ := e.Detect(dir); !ok {
+		t.Fatal("expected build.sbt to be detected")
+	}
+	ff, err := e.Extract(context.Background(), dir, rel)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+
+	dep, ok := findFact(ff, "orders/src/main/scala/com/acme/orders -> billing/src/main/scala/com/acme/billing")
+	if !ok {
+		t.Fatalf("expected an internal dependency on the billing module, got %v", ff)
+	}
+	if dep.Props["source"] != "internal" {
+		t.Errorf("dependency source = %v, want internal", dep.Props["source"])
+	}
+	if m, ok := findFact(ff, "billing/src/main/scala/com/acme/billing"); !ok || m.Kind != facts.KindModule {
+		t.Errorf("expected billing module fact, got %v", m)
+	}
+}
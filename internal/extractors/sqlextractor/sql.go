@@ -0,0 +1,324 @@
+package sqlextractor
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/extractors"
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// SQLExtractor extracts table facts from SQL migration files. Each table
+// becomes a KindStorage fact with depends_on relations along its foreign keys.
+type SQLExtractor struct{}
+
+// New creates a new SQLExtractor.
+func New() *SQLExtractor {
+	return &SQLExtractor{}
+}
+
+func (e *SQLExtractor) Name() string {
+	return "sql"
+}
+
+// Detect returns true if the repository contains .sql files inside a
+// migrations/ or db/ directory.
+func (e *SQLExtractor) Detect(repoPath string) (bool, error) {
+	found := false
+	err := filepath.WalkDir(repoPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || found {
+			return err
+		}
+		if d.IsDir() {
+			if skipDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, relErr := filepath.Rel(repoPath, path)
+		if relErr == nil && isMigrationFile(rel) {
+			found = true
+		}
+		return nil
+	})
+	return found, err
+}
+
+// tableInfo accumulates everything known about a table across migration files.
+type tableInfo struct {
+	fact    facts.Fact
+	columns []string
+	refs    map[string]bool
+}
+
+// Extract parses migration files in order and emits one storage fact per table.
+// Tables created in one migration and altered in later ones are merged so
+// foreign keys added via ALTER TABLE appear on the same fact.
+func (e *SQLExtractor) Extract(ctx context.Context, repoPath string, files []string) ([]facts.Fact, error) {
+	var migrations []string
+	for _, f := range files {
+		if isMigrationFile(f) {
+			migrations = append(migrations, f)
+		}
+	}
+	// Migration files are conventionally prefixed with a sortable version.
+	sort.Strings(migrations)
+
+	tables := make(map[string]*tableInfo)
+	var order []string
+	var fileErrs extractors.FileErrors
+
+	for _, relFile := range migrations {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		src, err := os.ReadFile(filepath.Join(repoPath, relFile))
+		if err != nil {
+			fileErrs.Add(relFile, err)
+			continue
+		}
+
+		for _, stmt := range splitStatements(string(src)) {
+			name, op, columns, refs := parseStatement(stmt.text)
+			if name == "" {
+				continue
+			}
+			t, ok := tables[name]
+			if !ok {
+				t = &tableInfo{
+					fact: facts.Fact{
+						Kind: facts.KindStorage,
+						Name: name,
+						File: relFile,
+						Line: stmt.line,
+						Props: map[string]any{
+							"storage_kind": "table",
+							"operation":    op,
+							"language":     "sql",
+							"source":       "migration",
+						},
+						Relations: []facts.Relation{
+							{Kind: facts.RelDeclares, Target: filepath.ToSlash(filepath.Dir(relFile))},
+						},
+					},
+					refs: make(map[string]bool),
+				}
+				tables[name] = t
+				order = append(order, name)
+			} else if op == "CREATE" && t.fact.Props["operation"] != "CREATE" {
+				// A CREATE seen after an ALTER (e.g. unsorted file names) is the canonical location.
+				t.fact.File = relFile
+				t.fact.Line = stmt.line
+				t.fact.Props["operation"] = op
+			}
+			t.columns = append(t.columns, columns...)
+			for _, ref := range refs {
+				if ref != name {
+					t.refs[ref] = true
+				}
+			}
+		}
+	}
+
+	result := make([]facts.Fact, 0, len(order))
+	for _, name := range order {
+		t := tables[name]
+		if len(t.columns) > 0 {
+			t.fact.Props["columns"] = t.columns
+		}
+		refs := make([]string, 0, len(t.refs))
+		for ref := range t.refs {
+			refs = append(refs, ref)
+		}
+		sort.Strings(refs)
+		for _, ref := range refs {
+			t.fact.Relations = append(t.fact.Relations, facts.Relation{
+				Kind:   facts.RelDependsOn,
+				Target: ref,
+			})
+		}
+		result = append(result, t.fact)
+	}
+	return result, fileErrs.Err()
+}
+
+var (
+	createTableRe = regexp.MustCompile(`(?is)^CREATE\s+(?:(?:GLOBAL|LOCAL)\s+)?(?:TEMP(?:ORARY)?\s+)?(?:UNLOGGED\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([\w."` + "`" + `\[\]]+)\s*\((.*)\)`)
+	alterTableRe  = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:ONLY\s+)?(?:IF\s+EXISTS\s+)?([\w."` + "`" + `\[\]]+)`)
+	referencesRe  = regexp.MustCompile(`(?i)\bREFERENCES\s+([\w."` + "`" + `\[\]]+)`)
+	constraintRe  = regexp.MustCompile(`(?i)^(?:CONSTRAINT|PRIMARY|FOREIGN|UNIQUE|CHECK|INDEX|KEY|EXCLUDE)\b`)
+)
+
+// parseStatement inspects a single SQL statement. It returns the normalized
+// table name, the operation ("CREATE" or "ALTER"), any column names declared,
+// and the tables referenced by foreign keys. name is "" for other statements.
+func parseStatement(stmt string) (name, op string, columns, refs []string) {
+	if m := createTableRe.FindStringSubmatch(stmt); m != nil {
+		name = normalizeTableName(m[1])
+		for _, def := range splitTopLevel(m[2]) {
+			def = strings.TrimSpace(def)
+			if def == "" {
+				continue
+			}
+			if !constraintRe.MatchString(def) {
+				if fields := strings.Fields(def); len(fields) > 0 {
+					columns = append(columns, normalizeIdentifier(fields[0]))
+				}
+			}
+			for _, rm := range referencesRe.FindAllStringSubmatch(def, -1) {
+				refs = append(refs, normalizeTableName(rm[1]))
+			}
+		}
+		return name, "CREATE", columns, refs
+	}
+
+	if m := alterTableRe.FindStringSubmatch(stmt); m != nil {
+		name = normalizeTableName(m[1])
+		for _, rm := range referencesRe.FindAllStringSubmatch(stmt, -1) {
+			refs = append(refs, normalizeTableName(rm[1]))
+		}
+		return name, "ALTER", nil, refs
+	}
+
+	return "", "", nil, nil
+}
+
+// statement is a SQL statement with the line it starts on.
+type statement struct {
+	text string
+	line int
+}
+
+// splitStatements strips comments and splits SQL source on semicolons that
+// are outside string literals, tracking each statement's starting line.
+func splitStatements(src string) []statement {
+	var result []statement
+	var cur strings.Builder
+	line := 1
+	startLine := 0
+	inString := false
+
+	flush := func() {
+		text := strings.TrimSpace(cur.String())
+		if text != "" {
+			result = append(result, statement{text: text, line: startLine})
+		}
+		cur.Reset()
+		startLine = 0
+	}
+
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if !inString {
+			// Line comment
+			if c == '-' && i+1 < len(src) && src[i+1] == '-' {
+				for i < len(src) && src[i] != '\n' {
+					i++
+				}
+				if i < len(src) {
+					line++
+					cur.WriteByte('\n')
+				}
+				continue
+			}
+			// Block comment
+			if c == '/' && i+1 < len(src) && src[i+1] == '*' {
+				i += 2
+				for i < len(src) && !(src[i] == '*' && i+1 < len(src) && src[i+1] == '/') {
+					if src[i] == '\n' {
+						line++
+					}
+					i++
+				}
+				i++ // skip the closing '/'
+				cur.WriteByte(' ')
+				continue
+			}
+			if c == ';' {
+				flush()
+				continue
+			}
+		}
+		if c == '\'' {
+			inString = !inString
+		}
+		if c == '\n' {
+			line++
+		} else if startLine == 0 && c != ' ' && c != '\t' && c != '\r' {
+			startLine = line
+		}
+		cur.WriteByte(c)
+	}
+	flush()
+	return result
+}
+
+// splitTopLevel splits a column definition list on commas that are not nested
+// inside parentheses, e.g. "id INT, price NUMERIC(10,2)" yields two parts.
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth := 0
+	start := 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// normalizeTableName strips quoting and any schema qualifier so that
+// "public"."users" and `users` both map to users, matching the table names
+// emitted by the code-level storage extractors.
+func normalizeTableName(raw string) string {
+	name := normalizeIdentifier(raw)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+func normalizeIdentifier(raw string) string {
+	return strings.NewReplacer(`"`, "", "`", "", "[", "", "]", "").Replace(raw)
+}
+
+// isMigrationFile returns true for .sql files located under a directory named
+// "migrations" or "db".
+func isMigrationFile(relFile string) bool {
+	if strings.ToLower(filepath.Ext(relFile)) != ".sql" {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(filepath.Dir(relFile)), "/")
+	for _, part := range parts {
+		switch strings.ToLower(part) {
+		case "migrations", "db":
+			return true
+		}
+	}
+	return false
+}
+
+// skipDir returns true for directories that should never be descended into.
+func skipDir(name string) bool {
+	switch name {
+	case "vendor", "node_modules", ".git", ".archmcp", "tmp", "log", "build", ".build", ".gradle":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package sqlextractor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// setupRepo writes the given files (relative path -> content) into a temp dir.
+func setupRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for rel, content := range files {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func extractAll(t *testing.T, files map[string]string) map[string]facts.Fact {
+	t.Helper()
+	dir := setupRepo(t, files)
+	var rel []string
+	for f := range files {
+		rel = append(rel, f)
+	}
+	ff, err := New().Extract(context.Background(), dir, rel)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	m := make(map[string]facts.Fact, len(ff))
+	for _, f := range ff {
+		m[f.Name] = f
+	}
+	return m
+}
+
+func hasRel(f facts.Fact, kind, target string) bool {
+	for _, r := range f.Relations {
+		if r.Kind == kind && r.Target == target {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  bool
+	}{
+		{"migrations dir", map[string]string{"migrations/001_init.sql": "CREATE TABLE a (id INT);"}, true},
+		{"nested db dir", map[string]string{"services/api/db/schema.sql": "CREATE TABLE a (id INT);"}, true},
+		{"sql outside migrations", map[string]string{"scripts/seed.sql": "INSERT INTO a VALUES (1);"}, false},
+		{"vendored migrations", map[string]string{"vendor/lib/migrations/001.sql": "CREATE TABLE a (id INT);"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New().Detect(setupRepo(t, tt.files))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Detect = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtract_CreateTableWithForeignKeys(t *testing.T) {
+	src := `-- users and their orders
+CREATE TABLE IF NOT EXISTS "public"."users" (
+    id BIGSERIAL PRIMARY KEY,
+    email TEXT NOT NULL UNIQUE
+);
+
+/* orders reference users */
+CREATE TABLE orders (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    total NUMERIC(10, 2),
+    parent_id BIGINT,
+    CONSTRAINT fk_parent FOREIGN KEY (parent_id) REFERENCES orders(id)
+);
+`
+	got := extractAll(t, map[string]string{"db/migrations/001_init.sql": src})
+
+	users, ok := got["users"]
+	if !ok {
+		t.Fatalf("expected users table fact, got %v", got)
+	}
+	if users.Kind != facts.KindStorage || users.Props["storage_kind"] != "table" {
+		t.Errorf("users: kind=%q storage_kind=%v", users.Kind, users.Props["storage_kind"])
+	}
+	if users.Line != 2 {
+		t.Errorf("users line = %d, want 2", users.Line)
+	}
+
+	orders := got["orders"]
+	if orders.Line != 8 {
+		t.Errorf("orders line = %d, want 8", orders.Line)
+	}
+	cols, _ := orders.Props["columns"].([]string)
+	want := []string{"id", "user_id", "total", "parent_id"}
+	if len(cols) != len(want) {
+		t.Fatalf("orders columns = %v, want %v", cols, want)
+	}
+	for i := range want {
+		if cols[i] != want[i] {
+			t.Errorf("orders columns[%d] = %q, want %q", i, cols[i], want[i])
+		}
+	}
+	if !hasRel(orders, facts.RelDependsOn, "users") {
+		t.Errorf("expected orders depends_on users, got %v", orders.Relations)
+	}
+	if hasRel(orders, facts.RelDependsOn, "orders") {
+		t.Error("self-referencing foreign key should not produce a relation")
+	}
+}
+
+func TestExtract_AlterTableAddsForeignKey(t *testing.T) {
+	got := extractAll(t, map[string]string{
+		"migrations/001_accounts.sql": "CREATE TABLE accounts (id INT PRIMARY KEY);",
+		"migrations/002_payments.sql": "CREATE TABLE payments (id INT, account_id INT);",
+		"migrations/003_fk.sql": `ALTER TABLE payments
+  ADD CONSTRAINT fk_account FOREIGN KEY (account_id) REFERENCES accounts (id);`,
+	})
+
+	payments := got["payments"]
+	if payments.File != "migrations/002_payments.sql" {
+		t.Errorf("payments file = %q, want the CREATE migration", payments.File)
+	}
+	if payments.Props["operation"] != "CREATE" {
+		t.Errorf("payments operation = %v, want CREATE", payments.Props["operation"])
+	}
+	if !hasRel(payments, facts.RelDependsOn, "accounts") {
+		t.Errorf("expected payments depends_on accounts, got %v", payments.Relations)
+	}
+	if len(got) != 2 {
+		t.Errorf("expected 2 table facts, got %d", len(got))
+	}
+}
+
+func TestExtract_IgnoresNonMigrationFiles(t *testing.T) {
+	got := extractAll(t, map[string]string{
+		"scripts/adhoc.sql":  "CREATE TABLE scratch (id INT);",
+		"migrations/001.sql": "INSERT INTO things VALUES ('a;b');",
+	})
+	if len(got) != 0 {
+		t.Errorf("expected no facts, got %v", got)
+	}
+}
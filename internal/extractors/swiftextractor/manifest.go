@@ -0,0 +1,67 @@
+package swiftextractor
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/extractors"
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+var (
+	// spmPackageRe matches a remote package dependency in Package.swift and
+	// captures its URL and the requirement after it.
+	spmPackageRe = regexp.MustCompile(`\.package\s*\((?:\s*name:\s*"[^"]*"\s*,)?\s*url:\s*"([^"]+)"\s*,\s*([^\n]*)`)
+	// spmRequirementRe matches the requirement forms: from:, exact:,
+	// branch:, revision:, .upToNextMajor(from:) and friends, and ranges.
+	spmRequirementRe = regexp.MustCompile(`^(?:\.?(from|exact|branch|revision|upToNextMajor|upToNextMinor)\s*[:(]\s*(?:from:\s*)?"([^"]+)"|"([^"]+)"\s*(\.\.[.<])\s*"([^"]+)")`)
+)
+
+// manifestFacts returns a dependency fact per remote package declared in
+// the repo's Package.swift, named after its URL without the scheme and
+// .git suffix (github.com/apple/swift-log). The version is the declared
+// requirement: the version of from:, .upToNextMajor and exact: ("1.5.0"),
+// a range ("1.0.0..<2.0.0"), or the branch or revision, marked by a
+// requirement prop. Local path packages are skipped.
+func manifestFacts(repoPath string) []facts.Fact {
+	data, err := os.ReadFile(filepath.Join(repoPath, "Package.swift"))
+	if err != nil {
+		return nil
+	}
+
+	var result []facts.Fact
+	src := string(data)
+	for _, m := range spmPackageRe.FindAllStringSubmatchIndex(src, -1) {
+		url := src[m[2]:m[3]]
+		name := strings.TrimSuffix(url, ".git")
+		if i := strings.Index(name, "://"); i >= 0 {
+			name = name[i+3:]
+		}
+		name = path.Clean(strings.TrimPrefix(name, "git@"))
+		name = strings.Replace(name, ":", "/", 1) // git@github.com:owner/repo
+
+		var version, requirement string
+		if r := spmRequirementRe.FindStringSubmatch(strings.TrimSpace(src[m[4]:m[5]])); r != nil {
+			switch {
+			case r[2] != "":
+				version = r[2]
+				if r[1] == "branch" || r[1] == "revision" {
+					requirement = r[1]
+				}
+			case r[3] != "":
+				version = r[3] + r[4] + r[5]
+			}
+		}
+		line := strings.Count(src[:m[0]], "\n") + 1
+		f := extractors.ManifestDependency("Package.swift", line, name, version, "swift")
+		f.Props["url"] = url
+		if requirement != "" {
+			f.Props["requirement"] = requirement
+		}
+		result = append(result, f)
+	}
+	return result
+}
@@ -2,13 +2,15 @@ package swiftextractor
 
 import (
 	"bufio"
+	"bytes"
 	"context"
-	"log"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 
+	"github.com/dejo1307/archmcp/internal/extractors"
 	"github.com/dejo1307/archmcp/internal/facts"
 )
 
@@ -61,14 +63,17 @@ func (e *SwiftExtractor) Detect(repoPath string) (bool, error) {
 //   - Pass 2: resolve type references to discover cross-module dependencies
 func (e *SwiftExtractor) Extract(ctx context.Context, repoPath string, files []string) ([]facts.Fact, error) {
 	var allFacts []facts.Fact
+	var fileErrs extractors.FileErrors
 
 	isiOS := detectiOSProject(repoPath)
 
 	modules := make(map[string]bool)
 	typeIndex := make(map[string]string) // typeName -> module (directory)
 	var swiftFiles []string
+	var env extractors.EnvVars
 
-	// Pass 1: extract declarations and build type index.
+	// Pass 1: extract declarations and environment variable reads, and
+	// build the type index.
 	for _, relFile := range files {
 		select {
 		case <-ctx.Done():
@@ -83,14 +88,14 @@ func (e *SwiftExtractor) Extract(ctx context.Context, repoPath string, files []s
 		swiftFiles = append(swiftFiles, relFile)
 
 		absFile := filepath.Join(repoPath, relFile)
-		f, err := os.Open(absFile)
+		src, err := os.ReadFile(absFile)
 		if err != nil {
-			log.Printf("[swift-extractor] error reading %s: %v", relFile, err)
+			fileErrs.Add(relFile, err)
 			continue
 		}
 
-		fileFacts := extractFile(f, relFile, isiOS)
-		f.Close()
+		fileFacts := extractFile(bytes.NewReader(src), relFile, isiOS)
+		env.Scan(relFile, src, extractors.IsCommentLine, envReadRe)
 		allFacts = append(allFacts, fileFacts...)
 
 		dir := filepath.Dir(relFile)
@@ -134,6 +139,11 @@ func (e *SwiftExtractor) Extract(ctx context.Context, repoPath string, files []s
 		}
 	}
 
+	// Link override func declarations to the superclass methods they override.
+	extractors.LinkOverrides(allFacts, true)
+	// Link functions declared in extensions to the types they extend.
+	extractors.LinkExtensions(allFacts)
+
 	// Emit module facts.
 	for dir := range modules {
 		allFacts = append(allFacts, facts.Fact{
@@ -186,10 +196,16 @@ func (e *SwiftExtractor) Extract(ctx context.Context, repoPath string, files []s
 			})
 		}
 	}
+	allFacts = append(allFacts, env.Facts()...)
+	allFacts = append(allFacts, manifestFacts(repoPath)...)
 
-	return allFacts, nil
+	return allFacts, fileErrs.Err()
 }
 
+// envReadRe matches environment variable reads through
+// ProcessInfo.processInfo.environment["NAME"].
+var envReadRe = regexp.MustCompile(`ProcessInfo\.processInfo\.environment\[\s*"([^"]+)"\s*\]`)
+
 // typeRefRe matches type annotations like "name: TypeName" in property declarations and parameters.
 var typeRefRe = regexp.MustCompile(`:\s*([A-Z][A-Za-z0-9_]+)`)
 
@@ -307,6 +323,9 @@ var (
 		`^\s*(?:(?:public|private|fileprivate|internal|open|override|static|class|mutating|nonmutating|@\w+\s+)*\s*)` +
 			`func\s+(\w+)\s*[(<]`)
 
+	// Override modifier before a func keyword.
+	overrideRe = regexp.MustCompile(`\boverride\s+(?:\S+\s+)*func\s`)
+
 	// Property declarations (let/var).
 	propRe = regexp.MustCompile(
 		`^\s*(?:(?:public|private|fileprivate|internal|open|static|class|override|lazy|weak|unowned|@\w+\s+)*\s*)` +
@@ -336,7 +355,7 @@ type pendingDecl struct {
 }
 
 // extractFile parses a single Swift file and returns facts.
-func extractFile(f *os.File, relFile string, isiOS bool) []facts.Fact {
+func extractFile(f io.Reader, relFile string, isiOS bool) []facts.Fact {
 	var result []facts.Fact
 	dir := filepath.Dir(relFile)
 
@@ -353,12 +372,16 @@ func extractFile(f *os.File, relFile string, isiOS bool) []facts.Fact {
 		sigTypeIdx    int
 		sigMembers    []string
 		sigPublished  []string // tracks @Published property names
+		// Extension capture: the type a top-level extension body extends.
+		extType string
 	)
 	const sigMaxMembers = 15
 
+	var lines []string
 	for scanner.Scan() {
 		lineNum++
 		line := scanner.Text()
+		lines = append(lines, line)
 
 		// Track brace depth for top-level detection.
 		braceDepth += strings.Count(line, "{") - strings.Count(line, "}")
@@ -379,9 +402,30 @@ func extractFile(f *os.File, relFile string, isiOS bool) []facts.Fact {
 			sigPublished = nil
 		}
 
+		// Functions declared directly in a top-level extension body are
+		// methods of the extended type.
+		if extType != "" {
+			if braceDepth == 0 {
+				extType = ""
+			} else if startDepth := braceDepth - strings.Count(line, "{") + strings.Count(line, "}"); startDepth == 1 {
+				if m := funcRe.FindStringSubmatch(line); m != nil {
+					mf := buildMethodFact(dir+"."+extType, m[1], line, relFile, lineNum)
+					mf.Props["extension"] = true
+					result = append(result, mf)
+				}
+			}
+		}
+
 		// Capture member declarations inside a top-level type body.
 		if sigCapture && braceDepth >= 1 {
 			memberEffective := braceDepth - strings.Count(line, "{")
+			// Depth at the start of the line, so one-line bodies like "{}" count.
+			startDepth := memberEffective + strings.Count(line, "}")
+			if startDepth == 1 && sigTypeIdx < len(result) {
+				if m := funcRe.FindStringSubmatch(line); m != nil {
+					result = append(result, buildMethodFact(result[sigTypeIdx].Name, m[1], line, relFile, lineNum))
+				}
+			}
 			if memberEffective == 1 && len(sigMembers) < sigMaxMembers {
 				trimmed := strings.TrimSpace(line)
 				if trimmed != "" && !strings.HasPrefix(trimmed, "//") &&
@@ -685,9 +729,13 @@ func extractFile(f *os.File, relFile string, isiOS bool) []facts.Fact {
 				continue
 			}
 
-			// Extension declarations — emit implements relations only.
+			// Extension declarations — emit implements relations for the
+			// conformances and capture the functions in the body.
 			if m := extensionRe.FindStringSubmatch(line); m != nil {
 				name := m[1]
+				if braceDepth > 0 {
+					extType = name
+				}
 
 				if colonIdx := strings.Index(line, ":"); colonIdx >= 0 {
 					rest := line[colonIdx+1:]
@@ -824,6 +872,13 @@ func extractFile(f *os.File, relFile string, isiOS bool) []facts.Fact {
 		}
 	}
 
+	extractors.MarkDeprecated(result, relFile, lines, swiftDeprecation)
+	extractors.MarkAnnotations(result, relFile, lines)
+	if xctest, swiftTesting := swiftTestImports(lines); xctest || swiftTesting {
+		extractors.MarkTests(result, relFile, lines, func(f facts.Fact, prelude []string) (string, string, bool) {
+			return swiftTest(f, prelude, xctest, swiftTesting)
+		})
+	}
 	return result
 }
 
@@ -887,6 +942,31 @@ func buildDeclFact(dir, relFile string, pd *pendingDecl, supertypes string, isiO
 	return f
 }
 
+// buildMethodFact creates a method symbol fact for a function declared in
+// the body of typeName, named after the type (e.g. "Sources/App.HomeView.load").
+func buildMethodFact(typeName, name, line, relFile string, lineNum int) facts.Fact {
+	dir := filepath.Dir(relFile)
+	f := facts.Fact{
+		Kind: facts.KindSymbol,
+		Name: typeName + "." + name,
+		File: relFile,
+		Line: lineNum,
+		Props: map[string]any{
+			"symbol_kind": facts.SymbolMethod,
+			"exported":    !isPrivateAccess(line),
+			"language":    "swift",
+			"receiver":    strings.TrimPrefix(typeName, dir+"."),
+		},
+		Relations: []facts.Relation{
+			{Kind: facts.RelDeclares, Target: dir},
+		},
+	}
+	if overrideRe.MatchString(line) {
+		f.Props["override"] = true
+	}
+	return f
+}
+
 // extractSupertypesFromText finds the supertype clause after ":" in text that may
 // contain generic parameters. It skips content inside balanced parentheses and angle brackets.
 func extractSupertypesFromText(text string) string {
@@ -952,6 +1032,7 @@ func addIOSProps(f *facts.Fact, name string, annotations []string, supertypes st
 	// SwiftUI App entry point.
 	if containsAnnotation(annotations, "main") && supertypeMatches(supertypes, "App") {
 		f.Props["ios_component"] = "swiftui_app"
+		f.Props["entry_point"] = facts.EntryApp
 		f.Props["framework"] = "swiftui"
 		return
 	}
@@ -1080,6 +1161,73 @@ func extractTypeName(s string) string {
 	return s
 }
 
+var (
+	// availableRe matches an @available attribute and captures its arguments.
+	availableRe = regexp.MustCompile(`@available\s*\(([^)]*)\)`)
+	// deprecatedArgRe matches the deprecated argument of @available, bare
+	// (@available(*, deprecated)) or with a version (deprecated: 13.0).
+	deprecatedArgRe = regexp.MustCompile(`(?:^|,)\s*deprecated\b`)
+	// messageArgRe and renamedArgRe capture the message and renamed
+	// arguments of @available.
+	messageArgRe = regexp.MustCompile(`\bmessage\s*:\s*"((?:[^"\\]|\\.)*)"`)
+	renamedArgRe = regexp.MustCompile(`\brenamed\s*:\s*"((?:[^"\\]|\\.)*)"`)
+)
+
+// swiftTestAttrRe matches the @Test attribute of the Swift Testing library.
+var swiftTestAttrRe = regexp.MustCompile(`@Test\b`)
+
+// swiftTestImports reports whether a file imports XCTest and the Swift
+// Testing library.
+func swiftTestImports(lines []string) (xctest, swiftTesting bool) {
+	for _, line := range lines {
+		switch strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "@testable ")) {
+		case "import XCTest":
+			xctest = true
+		case "import Testing":
+			swiftTesting = true
+		}
+	}
+	return xctest, swiftTesting
+}
+
+// swiftTest detects a test function: a function marked @Test in a file
+// importing Testing, or a method whose name starts with "test" in a file
+// importing XCTest, as XCTestCase discovers them.
+func swiftTest(f facts.Fact, prelude []string, xctest, swiftTesting bool) (string, string, bool) {
+	kind := f.Props["symbol_kind"]
+	if kind != facts.SymbolMethod && kind != facts.SymbolFunc {
+		return "", "", false
+	}
+	if swiftTesting && swiftTestAttrRe.MatchString(extractors.CodeText(prelude)) {
+		return extractors.TestKindTest, "swift-testing", true
+	}
+	name := f.Name[strings.LastIndex(f.Name, ".")+1:]
+	if xctest && kind == facts.SymbolMethod && strings.HasPrefix(name, "test") {
+		return extractors.TestKindTest, "xctest", true
+	}
+	return "", "", false
+}
+
+// swiftDeprecation detects an @available attribute with a deprecated
+// argument in a declaration's prelude. The message is the message argument,
+// or "renamed to X" when only renamed is given.
+func swiftDeprecation(prelude []string) (string, bool) {
+	for _, m := range availableRe.FindAllStringSubmatch(extractors.CodeText(prelude), -1) {
+		args := m[1]
+		if !deprecatedArgRe.MatchString(args) {
+			continue
+		}
+		if msg := messageArgRe.FindStringSubmatch(args); msg != nil {
+			return msg[1], true
+		}
+		if renamed := renamedArgRe.FindStringSubmatch(args); renamed != nil {
+			return "renamed to " + renamed[1], true
+		}
+		return "", true
+	}
+	return "", false
+}
+
 // collectInlineAnnotations extracts attribute names from a line that also contains a declaration.
 func collectInlineAnnotations(line string) []string {
 	var result []string
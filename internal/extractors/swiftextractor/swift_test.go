@@ -1,11 +1,14 @@
 package swiftextractor
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/dejo1307/archmcp/internal/extractors"
 	"github.com/dejo1307/archmcp/internal/facts"
 )
 
@@ -287,6 +290,9 @@ struct MyApp: App {
 	if f.Props["ios_component"] != "swiftui_app" {
 		t.Errorf("ios_component = %v, want swiftui_app", f.Props["ios_component"])
 	}
+	if f.Props["entry_point"] != facts.EntryApp {
+		t.Errorf("entry_point = %v, want %s", f.Props["entry_point"], facts.EntryApp)
+	}
 }
 
 func TestClassify_UIKitController(t *testing.T) {
@@ -540,3 +546,276 @@ protocol Repository: Sendable {
 		t.Error("expected implements relation for Sendable")
 	}
 }
+
+func TestMethodOverrides(t *testing.T) {
+	ff := extractFromString(t, `
+class BaseViewController: UIViewController {
+    override func viewDidLoad() {
+        super.viewDidLoad()
+    }
+    func configure() {}
+}
+
+final class ProfileViewController: BaseViewController {
+    @objc override func viewDidLoad() {
+        super.viewDidLoad()
+    }
+    override func configure() {}
+}
+`, true)
+	extractors.LinkOverrides(ff, true)
+
+	m, ok := findFact(ff, "pkg.ProfileViewController.viewDidLoad")
+	if !ok {
+		t.Fatal("expected method fact for pkg.ProfileViewController.viewDidLoad")
+	}
+	if m.Props["symbol_kind"] != facts.SymbolMethod || m.Props["override"] != true || m.Props["receiver"] != "ProfileViewController" {
+		t.Errorf("props = %v", m.Props)
+	}
+	if !hasRelation(m, facts.RelOverrides, "pkg.BaseViewController.viewDidLoad") {
+		t.Errorf("expected overrides -> pkg.BaseViewController.viewDidLoad, got %v", m.Relations)
+	}
+	c, _ := findFact(ff, "pkg.ProfileViewController.configure")
+	if !hasRelation(c, facts.RelOverrides, "pkg.BaseViewController.configure") {
+		t.Errorf("expected overrides -> pkg.BaseViewController.configure, got %v", c.Relations)
+	}
+
+	// UIViewController is not in the repo, so the base override has no target.
+	base, _ := findFact(ff, "pkg.BaseViewController.viewDidLoad")
+	for _, r := range base.Relations {
+		if r.Kind == facts.RelOverrides {
+			t.Errorf("unexpected overrides relation %v", r)
+		}
+	}
+}
+
+func TestExtension_Functions(t *testing.T) {
+	ff := extractFromString(t, `
+struct User {
+    let email: String
+}
+
+extension User: Identifiable {
+    var id: String { email }
+    func displayName() -> String {
+        return email
+    }
+    private func normalize() {}
+}
+
+extension String {
+    func trimmed() -> String { trimmingCharacters(in: .whitespaces) }
+}
+
+func topLevel() {
+}
+`, false)
+	extractors.LinkExtensions(ff)
+
+	dn, ok := findFact(ff, "pkg.User.displayName")
+	if !ok {
+		t.Fatal("expected method fact for pkg.User.displayName")
+	}
+	if dn.Props["symbol_kind"] != facts.SymbolMethod || dn.Props["extension"] != true || dn.Props["receiver"] != "User" {
+		t.Errorf("props = %v", dn.Props)
+	}
+	if !hasRelation(dn, facts.RelDependsOn, "pkg.User") {
+		t.Errorf("expected depends_on -> pkg.User, got %v", dn.Relations)
+	}
+	if n, _ := findFact(ff, "pkg.User.normalize"); n.Props["exported"] != false {
+		t.Error("private extension method should not be exported")
+	}
+	if _, ok := findFact(ff, "pkg.User+Identifiable"); !ok {
+		t.Error("conformance fact should still be emitted")
+	}
+
+	tr, ok := findFact(ff, "pkg.String.trimmed")
+	if !ok {
+		t.Fatal("expected method fact for pkg.String.trimmed")
+	}
+	if !hasRelation(tr, facts.RelDependsOn, "String") {
+		t.Errorf("expected depends_on -> String, got %v", tr.Relations)
+	}
+
+	if f, _ := findFact(ff, "pkg.topLevel"); f.Props["symbol_kind"] != facts.SymbolFunc {
+		t.Errorf("functions after an extension should be top-level, got %v", f.Props)
+	}
+}
+
+func TestExtract_Deprecated(t *testing.T) {
+	ff := extractFromString(t, `import Foundation
+
+@available(*, deprecated, message: "Use NewStore")
+class OldStore {
+}
+
+@available(iOS, deprecated: 13.0, renamed: "fetchAll")
+func fetch() {
+}
+
+@available(iOS 15, *)
+func modern() {
+}
+
+@available(*, deprecated)
+struct Legacy {
+}
+`, false)
+
+	for name, want := range map[string]string{
+		"pkg.OldStore": "Use NewStore",
+		"pkg.fetch":    "renamed to fetchAll",
+		"pkg.Legacy":   "",
+	} {
+		f, ok := findFact(ff, name)
+		if !ok {
+			t.Fatalf("expected fact %s", name)
+		}
+		msg, _ := f.Props["deprecation"].(string)
+		if f.Props["deprecated"] != true || msg != want {
+			t.Errorf("%s deprecated = %v, deprecation = %q, want %q", name, f.Props["deprecated"], msg, want)
+		}
+	}
+	if f, _ := findFact(ff, "pkg.modern"); f.Props["deprecated"] != nil {
+		t.Error("an availability attribute without deprecated should not deprecate the symbol")
+	}
+}
+
+func TestExtract_Annotations(t *testing.T) {
+	ff := extractFromString(t, `import SwiftUI
+
+@MainActor
+final class SessionStore {
+    @objc(refreshWithCompletion:) @discardableResult
+    func refresh(completion: @escaping () -> Void) -> Bool {
+        return true
+    }
+}
+
+func plain(handler: @escaping () -> Void) {
+}
+`, false)
+
+	for name, want := range map[string][]string{
+		"pkg.SessionStore":         {"MainActor"},
+		"pkg.SessionStore.refresh": {"objc", "discardableResult"},
+	} {
+		f, ok := findFact(ff, name)
+		if !ok {
+			t.Fatalf("expected fact %s", name)
+		}
+		if got, _ := f.Props["annotations"].([]string); !slices.Equal(got, want) {
+			t.Errorf("%s annotations = %v, want %v", name, got, want)
+		}
+	}
+	if f, _ := findFact(ff, "pkg.plain"); f.Props["annotations"] != nil {
+		t.Errorf("annotations = %v, want none from a parameter attribute", f.Props["annotations"])
+	}
+}
+
+func TestExtract_XCTestAndSwiftTesting(t *testing.T) {
+	ff := extractFromString(t, `import XCTest
+import Testing
+@testable import App
+
+final class LoginTests: XCTestCase {
+    func testLoginSucceeds() {
+        XCTAssertTrue(login())
+    }
+
+    func makeUser() -> User {
+        return User()
+    }
+}
+
+struct CartTests {
+    @Test
+    func addsItem() {
+        #expect(cart.count == 1)
+    }
+}
+`, false)
+
+	for name, framework := range map[string]string{
+		"pkg.LoginTests.testLoginSucceeds": "xctest",
+		"pkg.CartTests.addsItem":           "swift-testing",
+	} {
+		f, ok := findFact(ff, name)
+		if !ok {
+			t.Fatalf("expected fact %s", name)
+		}
+		if f.Props["test"] != true || f.Props["test_framework"] != framework {
+			t.Errorf("%s props = %v, want a %s test", name, f.Props, framework)
+		}
+	}
+	if helper, ok := findFact(ff, "pkg.LoginTests.makeUser"); !ok || helper.Props["test"] != nil {
+		t.Errorf("makeUser should not be a test: %+v", helper)
+	}
+}
+
+func TestExtract_EnvVarReads(t *testing.T) {
+	dir := t.TempDir()
+	src := `import Foundation
+
+struct Config {
+    let token = ProcessInfo.processInfo.environment["API_TOKEN"] ?? ""
+}
+`
+	if err := os.MkdirAll(filepath.Join(dir, "App"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "App/Config.swift"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ff, err := New().Extract(context.Background(), dir, []string{"App/Config.swift"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, ok := findFact(ff, "env:API_TOKEN")
+	if !ok || f.Kind != facts.KindConfig || f.Line != 4 || !hasRelation(f, facts.RelReadBy, "App") {
+		t.Errorf("API_TOKEN config fact = %+v", f)
+	}
+}
+
+func TestManifestFacts_PackageSwift(t *testing.T) {
+	dir := t.TempDir()
+	manifest := `// swift-tools-version:5.9
+import PackageDescription
+
+let package = Package(
+    name: "App",
+    dependencies: [
+        .package(url: "https://github.com/apple/swift-log.git", from: "1.5.0"),
+        .package(url: "https://github.com/pointfreeco/swift-composable-architecture", .upToNextMajor(from: "1.7.0")),
+        .package(name: "Alamofire", url: "https://github.com/Alamofire/Alamofire.git", "5.0.0"..<"6.0.0"),
+        .package(url: "git@github.com:org/internal-kit.git", branch: "main"),
+        .package(path: "../LocalKit"),
+    ]
+)
+`
+	if err := os.WriteFile(filepath.Join(dir, "Package.swift"), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ff := manifestFacts(dir)
+	if len(ff) != 4 {
+		t.Fatalf("dependencies = %+v, want 4 remote packages", ff)
+	}
+	want := []struct {
+		name, version string
+		line          int
+	}{
+		{"github.com/apple/swift-log", "1.5.0", 7},
+		{"github.com/pointfreeco/swift-composable-architecture", "1.7.0", 8},
+		{"github.com/Alamofire/Alamofire", "5.0.0..<6.0.0", 9},
+		{"github.com/org/internal-kit", "main", 10},
+	}
+	for i, w := range want {
+		f := ff[i]
+		if f.Name != "Package.swift -> "+w.name || f.Props["version"] != w.version || f.Line != w.line || f.Props["source"] != "external" {
+			t.Errorf("dependency %d = %s %v line %d, want %s %s line %d", i, f.Name, f.Props["version"], f.Line, w.name, w.version, w.line)
+		}
+	}
+	if ff[3].Props["requirement"] != "branch" || ff[0].Props["requirement"] != nil {
+		t.Errorf("requirement props = %v, %v", ff[0].Props["requirement"], ff[3].Props["requirement"])
+	}
+}
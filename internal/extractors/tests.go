@@ -0,0 +1,35 @@
+package extractors
+
+import "github.com/dejo1307/archmcp/internal/facts"
+
+// Test kinds recorded in Props["test_kind"]. Extractors may record others,
+// such as the Go extractor's "benchmark", "fuzz" and "example".
+const (
+	TestKindTest  = "test"
+	TestKindSuite = "suite" // a describe or context block grouping tests
+)
+
+// SetTest marks a symbol as a test: Props["test"] is set to true,
+// Props["test_kind"] to kind, and Props["test_framework"] to the framework
+// that runs it ("testing", "jest", "rspec", "xctest", "junit5", ...).
+func SetTest(props map[string]any, kind, framework string) {
+	props["test"] = true
+	props["test_kind"] = kind
+	props["test_framework"] = framework
+}
+
+// MarkTests marks the symbols of ff declared in relFile as tests when detect
+// reports one, given the symbol and its prelude (see DeclarationPrelude).
+// lines are the lines of relFile. It serves the line-based extractors, whose
+// tests are methods marked by an annotation or by their name.
+func MarkTests(ff []facts.Fact, relFile string, lines []string, detect func(f facts.Fact, prelude []string) (kind, framework string, ok bool)) {
+	for i := range ff {
+		f := &ff[i]
+		if f.Kind != facts.KindSymbol || f.File != relFile || f.Line <= 0 || f.Line > len(lines) {
+			continue
+		}
+		if kind, framework, ok := detect(*f, DeclarationPrelude(lines, f.Line)); ok {
+			SetTest(f.Props, kind, framework)
+		}
+	}
+}
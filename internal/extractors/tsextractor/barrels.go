@@ -0,0 +1,228 @@
+package tsextractor
+
+import (
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+
+	sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// maxReexportDepth bounds how many barrels an import is followed through,
+// which also breaks re-export cycles.
+const maxReexportDepth = 10
+
+// barrelIndex collects, across the files of one extraction, what
+// resolveBarrelImports needs: the re-exports and exported declarations of
+// each module file, and the named imports to resolve. Module files are keyed
+// like resolved import paths: "src/components/Button" for Button.tsx, and
+// also "src/components" for an index file.
+type barrelIndex struct {
+	modules map[string]*moduleExports
+	imports []namedImport
+}
+
+// moduleExports is what one module file exports.
+type moduleExports struct {
+	declared map[string]bool     // exported top-level declarations
+	named    map[string]reexport // export { A, B as C } from "./x", by exported name
+	star     []string            // export * from "./x"
+}
+
+// reexport is the source of a name re-exported with export { ... } from.
+type reexport struct {
+	module string // resolved module path
+	name   string // name in that module
+}
+
+// namedImport is an import statement with named specifiers, identified by
+// the location and target of the dependency fact it produced.
+type namedImport struct {
+	file   string
+	line   int
+	target string   // resolved module path imported from
+	names  []string // imported names, before local aliases
+}
+
+func newBarrelIndex() *barrelIndex {
+	return &barrelIndex{modules: make(map[string]*moduleExports)}
+}
+
+// module returns the exports of the module file relFile, creating them.
+func (x *barrelIndex) module(relFile string) *moduleExports {
+	key := moduleKey(filepath.ToSlash(relFile))
+	m := x.modules[key]
+	if m == nil {
+		m = &moduleExports{declared: make(map[string]bool), named: make(map[string]reexport)}
+		x.modules[key] = m
+		if path.Base(key) == "index" {
+			x.modules[path.Dir(key)] = m
+		}
+	}
+	return m
+}
+
+// addDeclared records the exported top-level symbols of relFile among decls.
+func (x *barrelIndex) addDeclared(relFile string, decls []facts.Fact) {
+	prefix := filepath.Dir(relFile) + "."
+	for _, f := range decls {
+		name, ok := strings.CutPrefix(f.Name, prefix)
+		if !ok || f.Kind != facts.KindSymbol || f.File != relFile || strings.Contains(name, ".") {
+			continue
+		}
+		if exported, _ := f.Props["exported"].(bool); exported {
+			x.module(relFile).declared[name] = true
+		}
+	}
+}
+
+// addReexport records an export ... from statement of relFile that
+// re-exports from the resolved module target.
+func (x *barrelIndex) addReexport(stmt *sitter.Node, src []byte, relFile, target string) {
+	m := x.module(relFile)
+	clause := findChildByKind(stmt, "export_clause")
+	if clause == nil {
+		if findChildByKind(stmt, "namespace_export") == nil {
+			m.star = append(m.star, moduleKey(target))
+		}
+		return
+	}
+	for i := range clause.NamedChildCount() {
+		spec := clause.NamedChild(i)
+		nameNode := spec.ChildByFieldName("name")
+		if spec.Kind() != "export_specifier" || nameNode == nil {
+			continue
+		}
+		exported := nodeText(nameNode, src)
+		if alias := spec.ChildByFieldName("alias"); alias != nil {
+			exported = nodeText(alias, src)
+		}
+		m.named[exported] = reexport{module: moduleKey(target), name: nodeText(nameNode, src)}
+	}
+}
+
+// addImport records the named specifiers of an internal import statement.
+func (x *barrelIndex) addImport(stmt *sitter.Node, src []byte, relFile string, line int, target string) {
+	clause := findChildByKind(stmt, "import_clause")
+	if clause == nil {
+		return
+	}
+	named := findChildByKind(clause, "named_imports")
+	if named == nil {
+		return
+	}
+	imp := namedImport{file: relFile, line: line, target: target}
+	for i := range named.NamedChildCount() {
+		spec := named.NamedChild(i)
+		if nameNode := spec.ChildByFieldName("name"); spec.Kind() == "import_specifier" && nameNode != nil {
+			imp.names = append(imp.names, nodeText(nameNode, src))
+		}
+	}
+	if len(imp.names) > 0 {
+		x.imports = append(x.imports, imp)
+	}
+}
+
+// declaringModule follows name through the re-exports of module and returns
+// the module file that declares it, or "" when it cannot be traced to a
+// declaration.
+func (x *barrelIndex) declaringModule(module, name string, depth int) string {
+	m := x.modules[moduleKey(module)]
+	if m == nil || depth > maxReexportDepth {
+		return ""
+	}
+	if m.declared[name] {
+		return moduleKey(module)
+	}
+	if r, ok := m.named[name]; ok {
+		if decl := x.declaringModule(r.module, r.name, depth+1); decl != "" {
+			return decl
+		}
+		return r.module
+	}
+	for _, star := range m.star {
+		if decl := x.declaringModule(star, name, depth+1); decl != "" {
+			return decl
+		}
+	}
+	return ""
+}
+
+// resolveBarrelImports points the imports relations of named imports from
+// barrel files at the modules declaring the imported names, so an import of
+// Button from "@/components" depends on src/components/Button rather than
+// on the barrel. A name that cannot be traced keeps the barrel as its
+// target. Rewritten dependency facts keep their name and record the barrel
+// in Props["via"].
+func (x *barrelIndex) resolveBarrelImports(ff []facts.Fact) {
+	// An import statement's dependency fact is found by its location and
+	// the module it imports, as a line may hold several imports.
+	type key struct {
+		file   string
+		line   int
+		target string
+	}
+	deps := make(map[key]int)
+	for i, f := range ff {
+		if f.Kind != facts.KindDependency {
+			continue
+		}
+		for _, rel := range f.Relations {
+			if rel.Kind == facts.RelImports {
+				deps[key{f.File, f.Line, rel.Target}] = i
+			}
+		}
+	}
+
+	for _, imp := range x.imports {
+		m := x.modules[moduleKey(imp.target)]
+		if m == nil || (len(m.named) == 0 && len(m.star) == 0) {
+			continue // not a barrel
+		}
+		i, ok := deps[key{imp.file, imp.line, imp.target}]
+		if !ok {
+			continue
+		}
+		var targets []string
+		for _, name := range imp.names {
+			target := x.declaringModule(imp.target, name, 0)
+			if target == "" {
+				target = imp.target
+			}
+			if !slices.Contains(targets, target) {
+				targets = append(targets, target)
+			}
+		}
+		if len(targets) == 1 && targets[0] == imp.target {
+			continue
+		}
+
+		f := &ff[i]
+		var rels []facts.Relation
+		for _, rel := range f.Relations {
+			if rel.Kind != facts.RelImports || rel.Target != imp.target {
+				rels = append(rels, rel)
+				continue
+			}
+			for _, t := range targets {
+				rels = append(rels, facts.Relation{Kind: facts.RelImports, Target: t})
+			}
+		}
+		f.Relations = rels
+		f.Props["via"] = imp.target
+	}
+}
+
+// moduleKey normalizes a module file path or resolved import path by
+// dropping a source extension ("./Button.js" and "./Button" name the same
+// module).
+func moduleKey(p string) string {
+	switch path.Ext(p) {
+	case ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs":
+		return strings.TrimSuffix(p, path.Ext(p))
+	}
+	return p
+}
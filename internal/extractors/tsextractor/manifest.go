@@ -0,0 +1,65 @@
+package tsextractor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/dejo1307/archmcp/internal/extractors"
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// packageJSONSections are the package.json dependency sections read by
+// manifestFacts, with the prop set on the facts of each.
+var packageJSONSections = []struct{ key, prop string }{
+	{"dependencies", ""},
+	{"devDependencies", "dev"},
+	{"peerDependencies", "peer"},
+	{"optionalDependencies", "optional"},
+}
+
+// manifestFacts returns a dependency fact per package declared in the
+// package.json of the TypeScript root, with its version range. Packages
+// from devDependencies, peerDependencies and optionalDependencies get a dev,
+// peer or optional prop.
+func manifestFacts(repoPath string) []facts.Fact {
+	tsRoot, _ := findTSRoot(repoPath)
+	data, err := os.ReadFile(filepath.Join(tsRoot, "package.json"))
+	if err != nil {
+		return nil
+	}
+	var pkg map[string]json.RawMessage
+	if json.Unmarshal(data, &pkg) != nil {
+		return nil
+	}
+	rel, err := filepath.Rel(repoPath, filepath.Join(tsRoot, "package.json"))
+	if err != nil {
+		return nil
+	}
+	manifest := filepath.ToSlash(rel)
+
+	var result []facts.Fact
+	src := string(data)
+	for _, section := range packageJSONSections {
+		var deps map[string]string
+		if raw, ok := pkg[section.key]; !ok || json.Unmarshal(raw, &deps) != nil {
+			continue
+		}
+		names := make([]string, 0, len(deps))
+		for name := range deps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		start := extractors.LineOf(src, `"`+section.key+`"`, 1)
+		for _, name := range names {
+			f := extractors.ManifestDependency(manifest, extractors.LineOf(src, `"`+name+`"`, start), name, deps[name], "typescript")
+			if section.prop != "" {
+				f.Props[section.prop] = true
+			}
+			result = append(result, f)
+		}
+	}
+	return result
+}
@@ -0,0 +1,227 @@
+package tsextractor
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+
+	sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// annotateReact classifies top-level functions in result as React components
+// or hooks and adds depends_on relations to the hooks and child components
+// they reference. Components are PascalCase functions in .tsx files whose body
+// contains JSX; hooks are functions named use*. Only references that resolve
+// to a symbol in this file or to an internal import are recorded, so built-in
+// hooks such as useState and third-party components do not add noise.
+func annotateReact(root *sitter.Node, src []byte, relFile string, aliases map[string]string, result []facts.Fact) {
+	dir := filepath.Dir(relFile)
+	isTSX := strings.HasSuffix(relFile, ".tsx")
+
+	byName := make(map[string]int, len(result))
+	for i, f := range result {
+		if f.Kind == facts.KindSymbol {
+			byName[f.Name] = i
+		}
+	}
+
+	fns := topLevelFunctions(root, src)
+	targets := importedSymbols(root, src, dir, aliases)
+	for local := range fns {
+		targets[local] = dir + "." + local
+	}
+
+	for name, fn := range fns {
+		idx, ok := byName[dir+"."+name]
+		if !ok {
+			continue
+		}
+		f := &result[idx]
+
+		switch {
+		case isHookName(name):
+			f.Props["react_hook"] = true
+		case isTSX && isComponentName(name) && containsJSX(fn):
+			f.Props["react_component"] = true
+		default:
+			continue
+		}
+
+		seen := make(map[string]bool)
+		for _, ref := range reactReferences(fn, src) {
+			target, ok := targets[ref]
+			if !ok || ref == name || seen[target] {
+				continue
+			}
+			seen[target] = true
+			f.Relations = append(f.Relations, facts.Relation{
+				Kind:   facts.RelDependsOn,
+				Target: target,
+			})
+		}
+	}
+}
+
+// topLevelFunctions maps the names of top-level (optionally exported) function
+// declarations and function-valued consts to their function node. Consts
+// wrapped in a call such as memo(() => ...) or forwardRef(function ...) map to
+// the inner function.
+func topLevelFunctions(root *sitter.Node, src []byte) map[string]*sitter.Node {
+	fns := make(map[string]*sitter.Node)
+	for i := range root.ChildCount() {
+		node := root.Child(i)
+		if node.Kind() == "export_statement" {
+			if decl := findChildByKind(node, "function_declaration"); decl != nil {
+				node = decl
+			} else if decl := findChildByKind(node, "lexical_declaration"); decl != nil {
+				node = decl
+			}
+		}
+
+		switch node.Kind() {
+		case "function_declaration":
+			if name := findChildByKind(node, "identifier"); name != nil {
+				fns[nodeText(name, src)] = node
+			}
+		case "lexical_declaration":
+			for j := range node.ChildCount() {
+				decl := node.Child(j)
+				if decl.Kind() != "variable_declarator" {
+					continue
+				}
+				name := findChildByKind(decl, "identifier")
+				value := decl.ChildByFieldName("value")
+				if name == nil || value == nil {
+					continue
+				}
+				if fn := functionValue(value); fn != nil {
+					fns[nodeText(name, src)] = fn
+				}
+			}
+		}
+	}
+	return fns
+}
+
+// functionValue returns the function node for a const initializer, unwrapping
+// a single level of call such as memo(...) or forwardRef(...).
+func functionValue(value *sitter.Node) *sitter.Node {
+	switch value.Kind() {
+	case "arrow_function", "function_expression", "function":
+		return value
+	case "call_expression":
+		args := value.ChildByFieldName("arguments")
+		if args == nil {
+			return nil
+		}
+		for i := range args.NamedChildCount() {
+			if fn := functionValue(args.NamedChild(i)); fn != nil {
+				return fn
+			}
+		}
+	}
+	return nil
+}
+
+// importedSymbols maps local names bound by internal imports to the qualified
+// symbol name they refer to ("<dir of resolved path>.<name>").
+func importedSymbols(root *sitter.Node, src []byte, dir string, aliases map[string]string) map[string]string {
+	result := make(map[string]string)
+	for i := range root.ChildCount() {
+		stmt := root.Child(i)
+		if stmt.Kind() != "import_statement" {
+			continue
+		}
+		source := findChildByKind(stmt, "string")
+		clause := findChildByKind(stmt, "import_clause")
+		if source == nil || clause == nil {
+			continue
+		}
+		resolved, isExternal := resolveImportPath(strings.Trim(nodeText(source, src), `"'`), dir, aliases)
+		if isExternal {
+			continue
+		}
+		targetDir := filepath.ToSlash(filepath.Dir(resolved))
+
+		for j := range clause.ChildCount() {
+			c := clause.Child(j)
+			switch c.Kind() {
+			case "identifier": // default import
+				local := nodeText(c, src)
+				result[local] = targetDir + "." + local
+			case "named_imports":
+				for k := range c.NamedChildCount() {
+					spec := c.NamedChild(k)
+					if spec.Kind() != "import_specifier" {
+						continue
+					}
+					nameNode := spec.ChildByFieldName("name")
+					if nameNode == nil {
+						continue
+					}
+					local := nodeText(nameNode, src)
+					if alias := spec.ChildByFieldName("alias"); alias != nil {
+						local = nodeText(alias, src)
+					}
+					result[local] = targetDir + "." + nodeText(nameNode, src)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// reactReferences returns the hook calls and PascalCase JSX element names
+// found inside fn, in source order.
+func reactReferences(fn *sitter.Node, src []byte) []string {
+	var refs []string
+	var walk func(n *sitter.Node)
+	walk = func(n *sitter.Node) {
+		switch n.Kind() {
+		case "call_expression":
+			if callee := n.ChildByFieldName("function"); callee != nil && callee.Kind() == "identifier" {
+				if name := nodeText(callee, src); isHookName(name) {
+					refs = append(refs, name)
+				}
+			}
+		case "jsx_opening_element", "jsx_self_closing_element":
+			if tag := n.ChildByFieldName("name"); tag != nil && tag.Kind() == "identifier" {
+				if name := nodeText(tag, src); isComponentName(name) {
+					refs = append(refs, name)
+				}
+			}
+		}
+		for i := range n.ChildCount() {
+			walk(n.Child(i))
+		}
+	}
+	walk(fn)
+	return refs
+}
+
+// containsJSX reports whether any JSX element or fragment appears under n.
+func containsJSX(n *sitter.Node) bool {
+	switch n.Kind() {
+	case "jsx_element", "jsx_self_closing_element", "jsx_fragment":
+		return true
+	}
+	for i := range n.ChildCount() {
+		if containsJSX(n.Child(i)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHookName follows the React rules-of-hooks naming convention: "use"
+// followed by an uppercase letter, e.g. useAuth.
+func isHookName(name string) bool {
+	return len(name) > 3 && strings.HasPrefix(name, "use") && name[3] >= 'A' && name[3] <= 'Z'
+}
+
+// isComponentName reports whether name is PascalCase, which React requires
+// for user-defined components.
+func isComponentName(name string) bool {
+	return name != "" && name[0] >= 'A' && name[0] <= 'Z'
+}
@@ -0,0 +1,123 @@
+package tsextractor
+
+import (
+	"testing"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+func TestExtract_ReactComponentsAndHooks(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"src/hooks/useAuth.ts": `import { useState } from "react";
+
+export function useAuth() {
+  const [user] = useState(null);
+  return user;
+}
+`,
+		"src/components/Avatar.tsx": `export const Avatar = ({ src }: { src: string }) => <img src={src} />;
+`,
+		"src/components/Header.tsx": `import { useAuth } from "../hooks/useAuth";
+import { Avatar } from "./Avatar";
+import { Link } from "next/link";
+
+function Title() {
+  return <h1>Title</h1>;
+}
+
+export default function Header() {
+  const user = useAuth();
+  return (
+    <header>
+      <Title />
+      <Avatar src={user.avatar} />
+      <Link href="/">Home</Link>
+    </header>
+  );
+}
+
+export function formatName(name: string) {
+  return name.trim();
+}
+`,
+	}, false)
+
+	hook, ok := findFact(ff, "src/hooks.useAuth")
+	if !ok {
+		t.Fatal("expected fact for src/hooks.useAuth")
+	}
+	if hook.Props["react_hook"] != true {
+		t.Errorf("useAuth should be tagged react_hook, props = %v", hook.Props)
+	}
+	// Built-in hooks from external packages are not recorded.
+	if hasRelation(hook, facts.RelDependsOn, "useState") {
+		t.Error("external hooks should not produce depends_on relations")
+	}
+
+	header, ok := findFact(ff, "src/components.Header")
+	if !ok {
+		t.Fatal("expected fact for src/components.Header")
+	}
+	if header.Props["react_component"] != true {
+		t.Errorf("Header should be tagged react_component, props = %v", header.Props)
+	}
+	for _, target := range []string{"src/hooks.useAuth", "src/components.Avatar", "src/components.Title"} {
+		if !hasRelation(header, facts.RelDependsOn, target) {
+			t.Errorf("expected Header depends_on %s, relations = %v", target, header.Relations)
+		}
+	}
+	if hasRelation(header, facts.RelDependsOn, "next/link.Link") {
+		t.Error("third-party components should not produce depends_on relations")
+	}
+
+	avatar, _ := findFact(ff, "src/components.Avatar")
+	if avatar.Props["react_component"] != true {
+		t.Errorf("arrow-function Avatar should be tagged react_component, props = %v", avatar.Props)
+	}
+
+	plain, _ := findFact(ff, "src/components.formatName")
+	if plain.Props["react_component"] != nil || plain.Props["react_hook"] != nil {
+		t.Errorf("formatName should not be classified, props = %v", plain.Props)
+	}
+}
+
+func TestExtract_ReactComponentRequiresTSX(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"src/util.ts": `export function Builder() { return { build: () => 1 }; }`,
+	}, false)
+	f, ok := findFact(ff, "src.Builder")
+	if !ok {
+		t.Fatal("expected fact for src.Builder")
+	}
+	if f.Props["react_component"] != nil {
+		t.Error("PascalCase functions in .ts files are not components")
+	}
+}
+
+func TestExtract_ReactMemoComponent(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"src/List.tsx": `import { memo } from "react";
+export const List = memo(() => <ul><Item /></ul>);
+const Item = () => <li />;
+`,
+	}, false)
+	f, ok := findFact(ff, "src.List")
+	if !ok {
+		t.Fatal("expected fact for src.List")
+	}
+	if f.Props["react_component"] != true {
+		t.Errorf("memo-wrapped List should be tagged react_component, props = %v", f.Props)
+	}
+	if !hasRelation(f, facts.RelDependsOn, "src.Item") {
+		t.Errorf("expected List depends_on src.Item, relations = %v", f.Relations)
+	}
+}
+
+func TestIsHookName(t *testing.T) {
+	tests := map[string]bool{"useAuth": true, "use": false, "user": false, "useful": false, "UseAuth": false}
+	for name, want := range tests {
+		if got := isHookName(name); got != want {
+			t.Errorf("isHookName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
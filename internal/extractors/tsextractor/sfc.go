@@ -0,0 +1,151 @@
+package tsextractor
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/dejo1307/archmcp/internal/extractors"
+	"github.com/dejo1307/archmcp/internal/facts"
+
+	sitter "github.com/tree-sitter/go-tree-sitter"
+	typescript "github.com/tree-sitter/tree-sitter-typescript/bindings/go"
+)
+
+// sfcFrameworks maps single-file component extensions to their framework.
+var sfcFrameworks = map[string]string{
+	".vue":    "vue",
+	".svelte": "svelte",
+}
+
+var (
+	// scriptBlockRe matches a <script> block of a single-file component:
+	// Vue's <script> and <script setup>, Svelte's instance and
+	// context="module" scripts.
+	scriptBlockRe = regexp.MustCompile(`(?is)<script\b([^>]*)>(.*?)</script\s*>`)
+	// scriptLangRe reads the lang attribute of a <script> tag.
+	scriptLangRe = regexp.MustCompile(`(?i)\blang\s*=\s*["']?(\w+)`)
+)
+
+// sfcFramework returns the framework of a single-file component, or "" when
+// path is not one.
+func sfcFramework(path string) string {
+	return sfcFrameworks[strings.ToLower(filepath.Ext(path))]
+}
+
+// extractSFC extracts a Vue or Svelte single-file component. The file is one
+// component symbol named after the file (UserCard.vue and user-card.vue are
+// both "UserCard"), with Props["component"] and Props["framework"] set and a
+// depends_on relation to each child component it imports. The <script>
+// blocks are parsed like a TypeScript file for imports and declarations.
+func (e *TSExtractor) extractSFC(src []byte, relFile string, aliases map[string]string, barrels *barrelIndex) []facts.Fact {
+	dir := filepath.Dir(relFile)
+	framework := sfcFramework(relFile)
+	component := facts.Fact{
+		Kind: facts.KindSymbol,
+		Name: dir + "." + componentName(relFile),
+		File: relFile,
+		Line: 1,
+		Props: map[string]any{
+			"symbol_kind": facts.SymbolClass,
+			"exported":    true,
+			"language":    "typescript",
+			"component":   true,
+			"framework":   framework,
+		},
+		Relations: []facts.Relation{
+			{Kind: facts.RelDeclares, Target: dir},
+		},
+	}
+
+	script, tsx, ok := sfcScript(src)
+	if !ok {
+		return []facts.Fact{component}
+	}
+
+	lang := typescript.LanguageTypescript()
+	if tsx {
+		lang = typescript.LanguageTSX()
+	}
+	parser := sitter.NewParser()
+	defer parser.Close()
+	parser.SetLanguage(sitter.NewLanguage(lang))
+	tree := parser.Parse(script, nil)
+	defer tree.Close()
+	root := tree.RootNode()
+
+	imports := e.extractImports(root, script, relFile, aliases, barrels)
+	seen := make(map[string]bool)
+	for _, imp := range imports {
+		for _, rel := range imp.Relations {
+			if sfcFramework(rel.Target) == "" || imp.Props["source"] != "internal" {
+				continue
+			}
+			child := filepath.Dir(rel.Target) + "." + componentName(rel.Target)
+			if !seen[child] {
+				seen[child] = true
+				component.Relations = append(component.Relations, facts.Relation{Kind: facts.RelDependsOn, Target: child})
+			}
+		}
+	}
+	decls := e.extractDeclarations(root, script, relFile)
+	barrels.addDeclared(relFile, decls)
+	lines := strings.Split(string(script), "\n")
+	extractors.MarkDeprecated(decls, relFile, lines, jsDocDeprecation)
+	extractors.MarkAnnotations(decls, relFile, lines)
+
+	result := append([]facts.Fact{component}, imports...)
+	return append(result, decls...)
+}
+
+// sfcScript returns the source of a single-file component with everything
+// outside its <script> blocks blanked out, so byte offsets and line numbers
+// still match the file. tsx reports a lang="tsx" or lang="jsx" script. ok is
+// false when the file has no script block.
+func sfcScript(src []byte) (script []byte, tsx, ok bool) {
+	blocks := scriptBlockRe.FindAllSubmatchIndex(src, -1)
+	if len(blocks) == 0 {
+		return nil, false, false
+	}
+	script = make([]byte, len(src))
+	for i, b := range src {
+		if b == '\n' {
+			script[i] = '\n'
+		} else {
+			script[i] = ' '
+		}
+	}
+	for _, m := range blocks {
+		copy(script[m[4]:m[5]], src[m[4]:m[5]])
+		if lang := scriptLangRe.FindSubmatch(src[m[2]:m[3]]); lang != nil {
+			switch strings.ToLower(string(lang[1])) {
+			case "tsx", "jsx":
+				tsx = true
+			}
+		}
+	}
+	return script, tsx, true
+}
+
+// componentName derives a component name from a file name the way Vue and
+// Svelte tooling register them: the base name in PascalCase, with dashes,
+// underscores and other separators removed ("user-card.vue" → "UserCard",
+// "+page.svelte" → "Page").
+func componentName(path string) string {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	var sb strings.Builder
+	upper := true
+	for _, r := range base {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,102 @@
+package tsextractor
+
+import (
+	"testing"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+func TestExtract_VueAndSvelteComponents(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"src/components/UserList.vue": `<template>
+  <ul><user-card v-for="u in users" :user="u" /></ul>
+</template>
+
+<script setup lang="ts">
+import UserCard from "./user-card.vue";
+import { fetchUsers } from "../api/users";
+import { ref } from "vue";
+
+const users = ref([]);
+
+/** @deprecated use fetchUsers directly */
+export function reload(): void {
+  fetchUsers();
+}
+</script>
+
+<style scoped>
+ul { margin: 0; }
+</style>
+`,
+		"src/components/user-card.vue": `<template><li>{{ user.name }}</li></template>
+`,
+		"src/routes/+page.svelte": `<script context="module">
+export const prerender = true;
+</script>
+
+<script>
+import Counter from "../components/Counter.svelte";
+export let title = "";
+</script>
+
+<h1>{title}</h1>
+<Counter />
+`,
+	}, false)
+
+	list, ok := findFact(ff, "src/components.UserList")
+	if !ok {
+		t.Fatal("expected a component fact for UserList.vue")
+	}
+	if list.Props["component"] != true || list.Props["framework"] != "vue" || list.File != "src/components/UserList.vue" {
+		t.Errorf("UserList props = %v, file = %s", list.Props, list.File)
+	}
+	if !hasRelation(list, facts.RelDependsOn, "src/components.UserCard") {
+		t.Errorf("UserList should depend on the imported UserCard component, relations = %v", list.Relations)
+	}
+	if hasRelation(list, facts.RelDependsOn, "src/api.fetchUsers") {
+		t.Error("only child components should become component dependencies")
+	}
+
+	if _, ok := findFact(ff, "src/components.UserCard"); !ok {
+		t.Error("a component without a script block should still get a component fact")
+	}
+
+	// Script declarations and imports keep the file's line numbers.
+	reload, ok := findFact(ff, "src/components.reload")
+	if !ok {
+		t.Fatal("expected a fact for the script function reload")
+	}
+	if reload.Line != 13 || reload.Props["deprecated"] != true {
+		t.Errorf("reload line = %d, props = %v; want line 13, deprecated", reload.Line, reload.Props)
+	}
+	imp, ok := findFact(ff, "src/components -> src/api/users")
+	if !ok || imp.Line != 7 {
+		t.Errorf("import of ../api/users = %+v, want it on line 7", imp)
+	}
+
+	page, ok := findFact(ff, "src/routes.Page")
+	if !ok {
+		t.Fatal("expected a component fact for +page.svelte")
+	}
+	if page.Props["framework"] != "svelte" || !hasRelation(page, facts.RelDependsOn, "src/components.Counter") {
+		t.Errorf("page props = %v, relations = %v", page.Props, page.Relations)
+	}
+	if _, ok := findFact(ff, "src/routes.prerender"); !ok {
+		t.Error("declarations in a context=\"module\" script should be extracted")
+	}
+}
+
+func TestComponentName(t *testing.T) {
+	for file, want := range map[string]string{
+		"src/UserCard.vue":        "UserCard",
+		"src/user-card.vue":       "UserCard",
+		"src/routes/+page.svelte": "Page",
+		"src/nav_bar.svelte":      "NavBar",
+	} {
+		if got := componentName(file); got != want {
+			t.Errorf("componentName(%q) = %q, want %q", file, got, want)
+		}
+	}
+}
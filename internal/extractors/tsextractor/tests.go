@@ -0,0 +1,149 @@
+package tsextractor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/extractors"
+	"github.com/dejo1307/archmcp/internal/facts"
+
+	sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// testBlockFuncs maps the Jest/Vitest globals that declare tests to the
+// test_kind of the block.
+var testBlockFuncs = map[string]string{
+	"describe": extractors.TestKindSuite,
+	"it":       extractors.TestKindTest,
+	"test":     extractors.TestKindTest,
+}
+
+// isTestFile reports whether relFile is named like a Jest or Vitest test
+// file: *.test.ts, *.spec.tsx, or a file under __tests__.
+func isTestFile(relFile string) bool {
+	base := filepath.Base(relFile)
+	return strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") ||
+		strings.Contains(filepath.ToSlash(relFile), "__tests__/")
+}
+
+// detectTestFramework returns the test runner declared in the package.json
+// of the TypeScript root: "vitest" when vitest is a dependency, else "jest".
+func detectTestFramework(repoPath string) string {
+	tsRoot, _ := findTSRoot(repoPath)
+	data, err := os.ReadFile(filepath.Join(tsRoot, "package.json"))
+	if err != nil {
+		return "jest"
+	}
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return "jest"
+	}
+	_, dep := pkg.Dependencies["vitest"]
+	_, devDep := pkg.DevDependencies["vitest"]
+	if dep || devDep {
+		return "vitest"
+	}
+	return "jest"
+}
+
+// extractTestBlocks returns a symbol fact for each describe, it and test
+// block of a test file, including describe.each, it.only and the like. A
+// block is named after the file and the titles of its enclosing blocks
+// ("src/user.test.ts > UserService > saves a user"). An import from vitest
+// or @jest/globals overrides the framework detected for the repository. Like
+// Go test functions, test blocks get no declares relation, so they do not
+// count toward module size or coupling.
+func extractTestBlocks(root *sitter.Node, src []byte, relFile, framework string) []facts.Fact {
+	for i := range root.ChildCount() {
+		stmt := root.Child(i)
+		if stmt.Kind() != "import_statement" {
+			continue
+		}
+		if source := findChildByKind(stmt, "string"); source != nil {
+			switch strings.Trim(nodeText(source, src), `"'`) {
+			case "vitest":
+				framework = "vitest"
+			case "@jest/globals":
+				framework = "jest"
+			}
+		}
+	}
+
+	var result []facts.Fact
+	var walk func(block *sitter.Node, titles []string)
+	walk = func(block *sitter.Node, titles []string) {
+		for i := range block.NamedChildCount() {
+			stmt := block.NamedChild(i)
+			if stmt.Kind() != "expression_statement" || stmt.NamedChildCount() == 0 {
+				continue
+			}
+			call := stmt.NamedChild(0)
+			kind, title, body := testBlock(call, src)
+			if kind == "" {
+				continue
+			}
+			path := append(append([]string(nil), titles...), title)
+			f := facts.Fact{
+				Kind:  facts.KindSymbol,
+				Name:  relFile + " > " + strings.Join(path, " > "),
+				File:  relFile,
+				Line:  int(call.StartPosition().Row) + 1,
+				Props: map[string]any{"symbol_kind": facts.SymbolFunc, "language": "typescript"},
+			}
+			extractors.SetTest(f.Props, kind, framework)
+			result = append(result, f)
+			if kind == extractors.TestKindSuite && body != nil {
+				walk(body, path)
+			}
+		}
+	}
+	walk(root, nil)
+	return result
+}
+
+// testBlock reports whether call is a describe, it or test call (optionally
+// through a modifier, as in it.each(...)(...) or describe.skip(...)), with
+// its title and the body of its callback.
+func testBlock(call *sitter.Node, src []byte) (kind, title string, body *sitter.Node) {
+	if call.Kind() != "call_expression" {
+		return "", "", nil
+	}
+	callee := call.ChildByFieldName("function")
+	// describe.each(table)("title", fn): the title is in the outer call.
+	if callee != nil && callee.Kind() == "call_expression" {
+		callee = callee.ChildByFieldName("function")
+	}
+	for callee != nil && callee.Kind() == "member_expression" {
+		callee = callee.ChildByFieldName("object")
+	}
+	if callee == nil || callee.Kind() != "identifier" {
+		return "", "", nil
+	}
+	kind = testBlockFuncs[nodeText(callee, src)]
+	args := call.ChildByFieldName("arguments")
+	if kind == "" || args == nil || args.NamedChildCount() == 0 {
+		return "", "", nil
+	}
+	switch first := args.NamedChild(0); first.Kind() {
+	case "string", "template_string":
+		title = strings.Trim(nodeText(first, src), "\"'`")
+	case "identifier", "member_expression":
+		title = nodeText(first, src) // describe(UserService, ...)
+	default:
+		return "", "", nil
+	}
+	for i := range args.NamedChildCount() {
+		switch fn := args.NamedChild(i); fn.Kind() {
+		case "arrow_function", "function_expression", "function":
+			if b := fn.ChildByFieldName("body"); b != nil && b.Kind() == "statement_block" {
+				body = b
+			}
+		}
+	}
+	return kind, title, body
+}
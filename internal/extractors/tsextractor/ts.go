@@ -3,17 +3,26 @@ package tsextractor
 import (
 	"context"
 	"encoding/json"
-	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
+	"github.com/dejo1307/archmcp/internal/extractors"
 	"github.com/dejo1307/archmcp/internal/facts"
 
 	sitter "github.com/tree-sitter/go-tree-sitter"
 	typescript "github.com/tree-sitter/tree-sitter-typescript/bindings/go"
 )
 
+// envReadPatterns match environment variable reads: process.env.NAME,
+// process.env["NAME"], and Vite's import.meta.env.NAME.
+var envReadPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bprocess\.env\.([A-Za-z_]\w*)`),
+	regexp.MustCompile(`\bprocess\.env\[\s*["'\x60]([^"'\x60]+)["'\x60]\s*\]`),
+	regexp.MustCompile(`\bimport\.meta\.env\.([A-Za-z_]\w*)`),
+}
+
 // TSExtractor extracts architectural facts from TypeScript/TSX source code using tree-sitter.
 type TSExtractor struct{}
 
@@ -67,7 +76,7 @@ func hasTSMarkers(dir string) bool {
 		}
 	}
 
-	// package.json with a typescript dependency
+	// package.json with a typescript, vue or svelte dependency
 	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
 	if err != nil {
 		return false
@@ -78,17 +87,21 @@ func hasTSMarkers(dir string) bool {
 	}
 	for _, key := range []string{"dependencies", "devDependencies"} {
 		if deps, ok := pkg[key].(map[string]any); ok {
-			if _, ok := deps["typescript"]; ok {
-				return true
+			for _, name := range []string{"typescript", "vue", "svelte"} {
+				if _, ok := deps[name]; ok {
+					return true
+				}
 			}
 		}
 	}
 	return false
 }
 
-// Extract parses TypeScript/TSX files and emits architectural facts.
+// Extract parses TypeScript/TSX files, and the scripts of Vue and Svelte
+// single-file components, and emits architectural facts.
 func (e *TSExtractor) Extract(ctx context.Context, repoPath string, files []string) ([]facts.Fact, error) {
 	var allFacts []facts.Fact
+	var fileErrs extractors.FileErrors
 
 	// Detect if this is a Next.js project
 	isNextJS := detectNextJS(repoPath)
@@ -96,6 +109,13 @@ func (e *TSExtractor) Extract(ctx context.Context, repoPath string, files []stri
 	// Parse tsconfig.json for path alias mappings (e.g., "@/*" → "src/*")
 	aliases := parseTSPathAliases(repoPath)
 
+	testFramework := detectTestFramework(repoPath)
+
+	// Re-exports of barrel files, resolved once every file has been read
+	barrels := newBarrelIndex()
+
+	var env extractors.EnvVars
+
 	// Group files by directory for module detection
 	modules := make(map[string]bool)
 
@@ -106,24 +126,40 @@ func (e *TSExtractor) Extract(ctx context.Context, repoPath string, files []stri
 		default:
 		}
 
-		if !isTypeScriptFile(relFile) {
+		if !isTypeScriptFile(relFile) && sfcFramework(relFile) == "" {
 			continue
 		}
 
 		absFile := filepath.Join(repoPath, relFile)
 		src, err := os.ReadFile(absFile)
 		if err != nil {
-			log.Printf("[ts-extractor] error reading %s: %v", relFile, err)
+			fileErrs.Add(relFile, err)
 			continue
 		}
 
-		fileFacts := e.extractFile(src, relFile, isNextJS, aliases)
+		env.Scan(relFile, src, extractors.IsCommentLine, envReadPatterns...)
+
+		var fileFacts []facts.Fact
+		if sfcFramework(relFile) != "" {
+			fileFacts = e.extractSFC(src, relFile, aliases, barrels)
+		} else {
+			fileFacts = e.extractFile(src, relFile, isNextJS, aliases, testFramework, barrels)
+		}
 		allFacts = append(allFacts, fileFacts...)
 
 		dir := filepath.Dir(relFile)
 		modules[dir] = true
 	}
 
+	// Imports from barrel files depend on the modules declaring the names.
+	barrels.resolveBarrelImports(allFacts)
+
+	// Methods shadowing a base class method override it.
+	extractors.LinkOverrides(allFacts, false)
+
+	allFacts = append(allFacts, env.Facts()...)
+	allFacts = append(allFacts, manifestFacts(repoPath)...)
+
 	// Emit module facts for each directory
 	for dir := range modules {
 		allFacts = append(allFacts, facts.Fact{
@@ -136,10 +172,10 @@ func (e *TSExtractor) Extract(ctx context.Context, repoPath string, files []stri
 		})
 	}
 
-	return allFacts, nil
+	return allFacts, fileErrs.Err()
 }
 
-func (e *TSExtractor) extractFile(src []byte, relFile string, isNextJS bool, aliases map[string]string) []facts.Fact {
+func (e *TSExtractor) extractFile(src []byte, relFile string, isNextJS bool, aliases map[string]string, testFramework string, barrels *barrelIndex) []facts.Fact {
 	var result []facts.Fact
 
 	// Parse openapi-typescript generated files for backend API route dependencies.
@@ -163,8 +199,17 @@ func (e *TSExtractor) extractFile(src []byte, relFile string, isNextJS bool, ali
 	root := tree.RootNode()
 
 	// Extract from the tree
-	result = append(result, e.extractImports(root, src, relFile, aliases)...)
-	result = append(result, e.extractDeclarations(root, src, relFile)...)
+	result = append(result, e.extractImports(root, src, relFile, aliases, barrels)...)
+	decls := e.extractDeclarations(root, src, relFile)
+	barrels.addDeclared(relFile, decls)
+	annotateReact(root, src, relFile, aliases, decls)
+	lines := strings.Split(string(src), "\n")
+	extractors.MarkDeprecated(decls, relFile, lines, jsDocDeprecation)
+	extractors.MarkAnnotations(decls, relFile, lines)
+	result = append(result, decls...)
+	if isTestFile(relFile) {
+		result = append(result, extractTestBlocks(root, src, relFile, testFramework)...)
+	}
 
 	// Detect Next.js routes
 	if isNextJS {
@@ -176,18 +221,24 @@ func (e *TSExtractor) extractFile(src []byte, relFile string, isNextJS bool, ali
 	return result
 }
 
-func (e *TSExtractor) extractImports(root *sitter.Node, src []byte, relFile string, aliases map[string]string) []facts.Fact {
+// extractImports emits a dependency fact for each import statement, and for
+// each export ... from statement re-exporting another module, recording both
+// in barrels.
+func (e *TSExtractor) extractImports(root *sitter.Node, src []byte, relFile string, aliases map[string]string, barrels *barrelIndex) []facts.Fact {
 	var result []facts.Fact
 	dir := filepath.Dir(relFile)
 
 	for i := range root.ChildCount() {
 		child := root.Child(i)
-		if child.Kind() != "import_statement" {
+		if child.Kind() != "import_statement" && child.Kind() != "export_statement" {
 			continue
 		}
 
 		// Find the import source (string)
-		source := findChildByKind(child, "string")
+		source := child.ChildByFieldName("source")
+		if child.Kind() == "import_statement" {
+			source = findChildByKind(child, "string")
+		}
 		if source == nil {
 			continue
 		}
@@ -202,11 +253,20 @@ func (e *TSExtractor) extractImports(root *sitter.Node, src []byte, relFile stri
 			importSource = "external"
 		}
 
+		line := int(child.StartPosition().Row) + 1
+		if !isExternal {
+			if child.Kind() == "export_statement" {
+				barrels.addReexport(child, src, relFile, resolved)
+			} else {
+				barrels.addImport(child, src, relFile, line, resolved)
+			}
+		}
+
 		result = append(result, facts.Fact{
 			Kind: facts.KindDependency,
 			Name: dir + " -> " + resolved,
 			File: relFile,
-			Line: int(child.StartPosition().Row) + 1,
+			Line: line,
 			Props: map[string]any{
 				"language": "typescript",
 				"source":   importSource,
@@ -269,6 +329,7 @@ func (e *TSExtractor) extractNode(node *sitter.Node, src []byte, relFile, dir st
 					"symbol_kind": facts.SymbolFunc,
 					"exported":    isExported,
 					"language":    "typescript",
+					"complexity":  cyclomaticComplexity(node),
 				},
 				Relations: []facts.Relation{
 					{Kind: facts.RelDeclares, Target: dir},
@@ -295,12 +356,23 @@ func (e *TSExtractor) extractNode(node *sitter.Node, src []byte, relFile, dir st
 				},
 			}
 
-			// Check for implements clause (nested under class_heritage)
+			// Check for extends and implements clauses (nested under class_heritage)
 			for j := range node.ChildCount() {
 				c := node.Child(j)
 				if c.Kind() == "class_heritage" {
 					for k := range c.ChildCount() {
 						heritage := c.Child(k)
+						if heritage.Kind() == "extends_clause" {
+							for l := range heritage.ChildCount() {
+								t := heritage.Child(l)
+								if t.Kind() == "identifier" || t.Kind() == "member_expression" {
+									f.Relations = append(f.Relations, facts.Relation{
+										Kind:   facts.RelImplements,
+										Target: nodeText(t, src),
+									})
+								}
+							}
+						}
 						if heritage.Kind() == "implements_clause" {
 							for l := range heritage.ChildCount() {
 								t := heritage.Child(l)
@@ -345,7 +417,7 @@ func (e *TSExtractor) extractNode(node *sitter.Node, src []byte, relFile, dir st
 							break
 						}
 					}
-					result = append(result, facts.Fact{
+					method := facts.Fact{
 						Kind: facts.KindSymbol,
 						Name: dir + "." + symbolName + "." + mName,
 						File: relFile,
@@ -359,7 +431,13 @@ func (e *TSExtractor) extractNode(node *sitter.Node, src []byte, relFile, dir st
 						Relations: []facts.Relation{
 							{Kind: facts.RelDeclares, Target: dir},
 						},
-					})
+					}
+					if member.Kind() == "method_definition" {
+						method.Props["complexity"] = cyclomaticComplexity(member)
+					} else if fn := findChildByKind(member, "arrow_function"); fn != nil {
+						method.Props["complexity"] = cyclomaticComplexity(fn)
+					}
+					result = append(result, method)
 				}
 			}
 		}
@@ -419,7 +497,7 @@ func (e *TSExtractor) extractNode(node *sitter.Node, src []byte, relFile, dir st
 						symbolKind = facts.SymbolFunc
 					}
 
-					result = append(result, facts.Fact{
+					f := facts.Fact{
 						Kind: facts.KindSymbol,
 						Name: dir + "." + symbolName,
 						File: relFile,
@@ -432,7 +510,11 @@ func (e *TSExtractor) extractNode(node *sitter.Node, src []byte, relFile, dir st
 						Relations: []facts.Relation{
 							{Kind: facts.RelDeclares, Target: dir},
 						},
-					})
+					}
+					if value != nil {
+						f.Props["complexity"] = cyclomaticComplexity(value)
+					}
+					result = append(result, f)
 				}
 			}
 		}
@@ -441,6 +523,23 @@ func (e *TSExtractor) extractNode(node *sitter.Node, src []byte, relFile, dir st
 	return result
 }
 
+// jsDocDeprecation detects a @deprecated tag in the doc comment above a
+// declaration and returns the text following the tag on its line.
+func jsDocDeprecation(prelude []string) (string, bool) {
+	for _, l := range prelude {
+		t := strings.TrimSpace(l)
+		if !extractors.IsCommentLine(t) {
+			continue
+		}
+		_, rest, ok := strings.Cut(t, "@deprecated")
+		if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\t' && !strings.HasPrefix(rest, "*/")) {
+			continue
+		}
+		return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), "*/")), true
+	}
+	return "", false
+}
+
 // detectRoute checks if a file path corresponds to a Next.js route.
 func detectRoute(relFile string) *facts.Fact {
 	// Next.js App Router: app/**/page.tsx, app/**/route.tsx
@@ -580,11 +679,85 @@ func detectNextJSAt(dir string) bool {
 	return false
 }
 
+// frameworkPackages maps npm package names to the framework they identify.
+var frameworkPackages = []struct{ pkg, name string }{
+	{"react", "react"},
+	{"next", "nextjs"},
+	{"vue", "vue"},
+	{"svelte", "svelte"},
+	{"@angular/core", "angular"},
+	{"express", "express"},
+	{"@nestjs/core", "nestjs"},
+}
+
+// DetectFrameworks reads framework versions from the package.json of the
+// TypeScript root and, in a monorepo, the repository root. Versions are the
+// declared ranges with the range operator stripped ("^18.2.0" → "18.2.0").
+func (e *TSExtractor) DetectFrameworks(repoPath string) []facts.FrameworkInfo {
+	dirs := []string{repoPath}
+	if tsRoot, _ := findTSRoot(repoPath); tsRoot != repoPath {
+		dirs = []string{tsRoot, repoPath}
+	}
+
+	var out []facts.FrameworkInfo
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+		if err != nil {
+			continue
+		}
+		var pkg struct {
+			Dependencies    map[string]string `json:"dependencies"`
+			DevDependencies map[string]string `json:"devDependencies"`
+		}
+		if json.Unmarshal(data, &pkg) != nil {
+			continue
+		}
+		for _, fp := range frameworkPackages {
+			version, ok := pkg.Dependencies[fp.pkg]
+			if !ok {
+				version, ok = pkg.DevDependencies[fp.pkg]
+			}
+			if !ok || seen[fp.name] {
+				continue
+			}
+			seen[fp.name] = true
+			out = append(out, facts.FrameworkInfo{Name: fp.name, Version: strings.TrimLeft(version, "^~>=< ")})
+		}
+	}
+	return out
+}
+
 func isTypeScriptFile(path string) bool {
 	ext := strings.ToLower(filepath.Ext(path))
 	return ext == ".ts" || ext == ".tsx"
 }
 
+// cyclomaticComplexity counts the decision points in a function node plus
+// one: if, loop, non-default case, catch and ternary nodes, and the && and ||
+// operators. Nested functions count toward the enclosing one, matching the
+// Go extractor.
+func cyclomaticComplexity(fn *sitter.Node) int {
+	complexity := 1
+	var walk func(n *sitter.Node)
+	walk = func(n *sitter.Node) {
+		switch n.Kind() {
+		case "if_statement", "for_statement", "for_in_statement", "while_statement", "do_statement",
+			"switch_case", "catch_clause", "ternary_expression":
+			complexity++
+		case "binary_expression":
+			if op := n.ChildByFieldName("operator"); op != nil && (op.Kind() == "&&" || op.Kind() == "||") {
+				complexity++
+			}
+		}
+		for i := range n.ChildCount() {
+			walk(n.Child(i))
+		}
+	}
+	walk(fn)
+	return complexity
+}
+
 func findChildByKind(node *sitter.Node, kind string) *sitter.Node {
 	for i := range node.ChildCount() {
 		child := node.Child(i)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/dejo1307/archmcp/internal/facts"
@@ -361,6 +362,56 @@ func TestExtract_ClassMethods(t *testing.T) {
 	}
 }
 
+func TestExtract_Complexity(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"src/logic.ts": `export function simple() { return 1 }
+
+export function branchy(xs: number[], mode: string): number {
+  let total = 0
+  for (const x of xs) {
+    if (x > 0 && x < 10 || x === 42) total += x
+  }
+  switch (mode) {
+    case "a":
+    case "b":
+      total++
+      break
+    default:
+  }
+  try {
+    total = mode ? total : -total
+  } catch (e) {}
+  return total
+}
+
+export const arrow = (a: boolean) => {
+  while (a) { a = false }
+  return a ?? true
+}
+
+export class Svc {
+  run(n: number) {
+    if (n > 1) { return 1 } else if (n > 0) { return 2 }
+    return 0
+  }
+}
+`,
+	}, false)
+
+	// branchy: 1 + for + if + && + || + 2 cases + catch + ternary.
+	want := map[string]int{"src.simple": 1, "src.branchy": 9, "src.arrow": 2, "src.Svc.run": 3}
+	for name, complexity := range want {
+		f, ok := findFact(ff, name)
+		if !ok {
+			t.Errorf("expected fact %s", name)
+			continue
+		}
+		if f.Props["complexity"] != complexity {
+			t.Errorf("%s complexity = %v, want %d", name, f.Props["complexity"], complexity)
+		}
+	}
+}
+
 func TestIsTypeScriptFile(t *testing.T) {
 	tests := []struct {
 		path string
@@ -377,3 +428,358 @@ func TestIsTypeScriptFile(t *testing.T) {
 		}
 	}
 }
+
+func TestDetectFrameworks_PackageJSON(t *testing.T) {
+	dir := t.TempDir()
+	pkg := `{
+  "dependencies": {"next": "14.1.0", "react": "^18.2.0"},
+  "devDependencies": {"typescript": "~5.3.0"}
+}`
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(pkg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got := New().DetectFrameworks(dir)
+	want := []facts.FrameworkInfo{{Name: "react", Version: "18.2.0"}, {Name: "nextjs", Version: "14.1.0"}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("DetectFrameworks = %v, want %v", got, want)
+	}
+}
+
+func TestExtract_MethodOverrides(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"src/base/shape.ts": `export class Shape {
+  area(): number { return 0; }
+  describe(): string { return "shape"; }
+}`,
+		"src/shapes/square.ts": `import { Shape } from "../base/shape";
+export class Square extends Shape {
+  area(): number { return this.side * this.side; }
+  scale(k: number) {}
+}
+export class Tile extends Square {
+  describe(): string { return "tile"; }
+}`,
+	}, false)
+
+	square, _ := findFact(ff, "src/shapes.Square")
+	if !hasRelation(square, facts.RelImplements, "Shape") {
+		t.Errorf("expected extends to be recorded as implements Shape, got %v", square.Relations)
+	}
+
+	area, _ := findFact(ff, "src/shapes.Square.area")
+	if !hasRelation(area, facts.RelOverrides, "src/base.Shape.area") {
+		t.Errorf("expected overrides -> src/base.Shape.area, got %v", area.Relations)
+	}
+	describe, _ := findFact(ff, "src/shapes.Tile.describe")
+	if !hasRelation(describe, facts.RelOverrides, "src/base.Shape.describe") {
+		t.Errorf("expected overrides through Square to src/base.Shape.describe, got %v", describe.Relations)
+	}
+	scale, _ := findFact(ff, "src/shapes.Square.scale")
+	for _, r := range scale.Relations {
+		if r.Kind == facts.RelOverrides {
+			t.Errorf("scale overrides nothing, got %v", r)
+		}
+	}
+}
+
+func TestExtract_HierarchyResolvesBareSupertypes(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"src/base/base.ts": `export class BaseService {}`,
+		"src/users/service.ts": `import { BaseService } from "../base/base";
+export class UserService extends BaseService {}
+export class AdminService extends UserService {}`,
+	}, false)
+
+	s := facts.NewStore()
+	s.Add(ff...)
+	s.BuildGraph()
+	g := s.Graph()
+
+	base := g.Hierarchy(context.Background(), "src/base.BaseService", 0)
+	if base.DescendantCount != 2 || len(base.Descendants) != 1 || base.Descendants[0].Name != "src/users.UserService" {
+		t.Fatalf("BaseService descendants = %+v, want UserService and its subtype", base.Descendants)
+	}
+	if sub := base.Descendants[0].Children; len(sub) != 1 || sub[0].Name != "src/users.AdminService" {
+		t.Errorf("UserService subtypes = %+v, want AdminService", sub)
+	}
+
+	admin := g.Hierarchy(context.Background(), "src/users.AdminService", 0)
+	if admin.AncestorCount != 2 || len(admin.Ancestors) != 1 || admin.Ancestors[0].Name != "src/users.UserService" {
+		t.Fatalf("AdminService ancestors = %+v, want UserService", admin.Ancestors)
+	}
+	if up := admin.Ancestors[0].Children; len(up) != 1 || up[0].Name != "src/base.BaseService" || up[0].File != "src/base/base.ts" {
+		t.Errorf("UserService supertypes = %+v, want src/base.BaseService", up)
+	}
+}
+
+func TestExtract_Deprecated(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"src/api.ts": `/**
+ * Fetches users.
+ * @deprecated Use fetchAllUsers instead.
+ */
+export function fetchUsers() { return [] }
+
+/** @deprecated */
+export const legacy = 1
+
+/** See @deprecatedAlias for details. */
+export function current() { return 1 }
+
+export class Client {
+  /** @deprecated Use send. */
+  post() {}
+}
+`,
+	}, false)
+
+	for name, want := range map[string]string{
+		"src.fetchUsers":  "Use fetchAllUsers instead.",
+		"src.legacy":      "",
+		"src.Client.post": "Use send.",
+	} {
+		f, ok := findFact(ff, name)
+		if !ok {
+			t.Fatalf("expected fact %s", name)
+		}
+		msg, _ := f.Props["deprecation"].(string)
+		if f.Props["deprecated"] != true || msg != want {
+			t.Errorf("%s deprecated = %v, deprecation = %q, want %q", name, f.Props["deprecated"], msg, want)
+		}
+	}
+	for _, name := range []string{"src.current", "src.Client"} {
+		if f, _ := findFact(ff, name); f.Props["deprecated"] != nil {
+			t.Errorf("%s should not be deprecated", name)
+		}
+	}
+}
+
+// --- Test block tests ---
+
+func TestExtract_Decorators(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"src/orders.controller.ts": `@Controller("orders")
+@UseGuards(AuthGuard)
+export class OrdersController {
+  @Get(":id")
+  @Authorize("orders:read")
+  findOne() { return null }
+
+  list() { return [] }
+}
+`,
+	}, false)
+
+	for name, want := range map[string][]string{
+		"src.OrdersController":         {"Controller", "UseGuards"},
+		"src.OrdersController.findOne": {"Get", "Authorize"},
+	} {
+		f, ok := findFact(ff, name)
+		if !ok {
+			t.Fatalf("expected fact %s", name)
+		}
+		if got, _ := f.Props["annotations"].([]string); !slices.Equal(got, want) {
+			t.Errorf("%s annotations = %v, want %v", name, got, want)
+		}
+	}
+	if f, _ := findFact(ff, "src.OrdersController.list"); f.Props["annotations"] != nil {
+		t.Errorf("annotations = %v, want none", f.Props["annotations"])
+	}
+}
+
+func TestExtract_JestAndVitestBlocks(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"src/user.test.ts": `import { saveUser } from "./user";
+
+describe("UserService", () => {
+  it("saves a user", () => {
+    expect(saveUser()).toBe(true);
+  });
+
+  describe.each([1, 2])("with %i items", (n) => {
+    test.only("counts", () => {});
+  });
+});
+
+test(` + "`works at the top level`" + `, function () {});
+`,
+		"src/cart.spec.ts": `import { describe, it } from "vitest";
+
+describe("Cart", () => {
+  it("is empty", () => {});
+});
+`,
+		// describe calls outside test files are ignored.
+		"src/user.ts": `export function saveUser() { return true; }
+describe("not a test file", () => {});
+`,
+	}, false)
+
+	for name, want := range map[string]struct {
+		kind, framework string
+		line            int
+	}{
+		"src/user.test.ts > UserService":                          {"suite", "jest", 3},
+		"src/user.test.ts > UserService > saves a user":           {"test", "jest", 4},
+		"src/user.test.ts > UserService > with %i items > counts": {"test", "jest", 9},
+		"src/user.test.ts > works at the top level":               {"test", "jest", 13},
+		"src/cart.spec.ts > Cart > is empty":                      {"test", "vitest", 4},
+	} {
+		f, ok := findFact(ff, name)
+		if !ok {
+			t.Errorf("expected test block %q", name)
+			continue
+		}
+		if f.Props["test"] != true || f.Props["test_kind"] != want.kind || f.Props["test_framework"] != want.framework || f.Line != want.line {
+			t.Errorf("%q: line %d, props %v; want line %d, %s %s", name, f.Line, f.Props, want.line, want.kind, want.framework)
+		}
+		if len(f.Relations) != 0 {
+			t.Errorf("%q should have no declares relation: %v", name, f.Relations)
+		}
+	}
+	if _, ok := findFact(ff, "src/user.ts > not a test file"); ok {
+		t.Error("describe outside a test file should not be extracted")
+	}
+}
+
+func TestExtract_BarrelReexports(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"src/lib/index.ts": `export { useAuth, login as signIn } from "../features/auth/useAuth";
+export * from "./format";
+export * as http from "../net/http";
+`,
+		"src/lib/format.ts": `export * from "../utils/dates";
+`,
+		"src/utils/dates.ts": `export function formatDate(d: Date): string { return ""; }
+`,
+		"src/features/auth/useAuth.ts": `export function useAuth() {}
+export function login() {}
+`,
+		"src/pages/home.ts": `import { useAuth, signIn, formatDate, missing } from "../lib";
+import { formatDate as fmt } from "../lib/format.js";
+`,
+	}, false)
+
+	imp, ok := findFact(ff, "src/pages -> src/lib")
+	if !ok {
+		t.Fatal("expected the import of the barrel")
+	}
+	if imp.Props["via"] != "src/lib" {
+		t.Errorf("via = %v, want src/lib", imp.Props["via"])
+	}
+	for _, target := range []string{"src/features/auth/useAuth", "src/utils/dates", "src/lib"} {
+		if !hasRelation(imp, facts.RelImports, target) {
+			t.Errorf("import should resolve to %s (unresolved names stay on the barrel), relations = %v", target, imp.Relations)
+		}
+	}
+	if len(imp.Relations) != 3 {
+		t.Errorf("relations = %v, want one per distinct declaring module", imp.Relations)
+	}
+
+	direct, ok := findFact(ff, "src/pages -> src/lib/format.js")
+	if !ok || !hasRelation(direct, facts.RelImports, "src/utils/dates") {
+		t.Errorf("import through a barrel named with an extension = %+v", direct)
+	}
+
+	// The re-exports are dependencies of the barrel itself.
+	if _, ok := findFact(ff, "src/lib -> src/features/auth/useAuth"); !ok {
+		t.Error("expected a dependency fact for export ... from")
+	}
+	if _, ok := findFact(ff, "src/lib -> src/net/http"); !ok {
+		t.Error("expected a dependency fact for export * as ... from")
+	}
+}
+
+func TestExtract_BarrelImportsOnOneLine(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"src/lib/index.ts": `export * from "./format";
+export { useAuth } from "../auth/useAuth";
+`,
+		"src/lib/format.ts": `export * from "../utils/dates";
+`,
+		"src/utils/dates.ts": `export function formatDate(d: Date): string { return ""; }
+`,
+		"src/auth/useAuth.ts": `export function useAuth() {}
+`,
+		"src/pages/home.ts": `import { useAuth } from "../lib"; import { formatDate } from "../lib/format";
+`,
+	}, false)
+
+	// Each import is resolved on its own dependency fact.
+	for name, target := range map[string]string{
+		"src/pages -> src/lib":        "src/auth/useAuth",
+		"src/pages -> src/lib/format": "src/utils/dates",
+	} {
+		f, ok := findFact(ff, name)
+		if !ok {
+			t.Fatalf("expected %s", name)
+		}
+		if len(f.Relations) != 1 || !hasRelation(f, facts.RelImports, target) {
+			t.Errorf("%s relations = %v, want imports %s", name, f.Relations, target)
+		}
+	}
+}
+
+func TestExtract_EnvVarReads(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"src/config.ts": `export const apiUrl = process.env.API_URL ?? "";
+export const region = process.env["AWS_REGION"];
+// process.env.COMMENTED_OUT
+export const mode = import.meta.env.VITE_MODE;
+`,
+		"src/api/client.ts": `const base = process.env.API_URL;
+class Client {
+  #token = process.env.API_TOKEN;
+}
+`,
+	}, false)
+
+	// A private field (#token) is code, not a # comment.
+	for _, name := range []string{"env:API_URL", "env:AWS_REGION", "env:VITE_MODE", "env:API_TOKEN"} {
+		if f, ok := findFact(ff, name); !ok || f.Kind != facts.KindConfig {
+			t.Errorf("expected a config fact %s", name)
+		}
+	}
+	if _, ok := findFact(ff, "env:COMMENTED_OUT"); ok {
+		t.Error("reads in comments should be skipped")
+	}
+	api, _ := findFact(ff, "env:API_URL")
+	if reads, _ := api.Props["reads"].([]string); len(reads) != 2 || !hasRelation(api, facts.RelReadBy, "src/api") {
+		t.Errorf("API_URL reads = %v, relations = %v", api.Props["reads"], api.Relations)
+	}
+}
+
+func TestExtract_PackageJSONDependencies(t *testing.T) {
+	ff := extractAll(t, map[string]string{
+		"package.json": `{
+  "name": "web",
+  "dependencies": {
+    "react": "^18.2.0",
+    "@tanstack/react-query": "5.17.0"
+  },
+  "devDependencies": {
+    "typescript": "~5.3.3"
+  }
+}
+`,
+		"src/index.ts": "export const x = 1;\n",
+	}, false)
+
+	react, ok := findFact(ff, "package.json -> react")
+	if !ok {
+		t.Fatal("expected a dependency fact for react")
+	}
+	if react.Kind != facts.KindDependency || react.Line != 4 || react.Props["version"] != "^18.2.0" || react.Props["source"] != "external" {
+		t.Errorf("react = %+v", react)
+	}
+	if query, _ := findFact(ff, "package.json -> @tanstack/react-query"); query.Line != 5 || !hasRelation(query, facts.RelDependsOn, "@tanstack/react-query") {
+		t.Errorf("react-query = %+v", query)
+	}
+	ts, _ := findFact(ff, "package.json -> typescript")
+	if ts.Props["dev"] != true || ts.Line != 8 {
+		t.Errorf("typescript = %+v, want a dev dependency on line 8", ts)
+	}
+	if react.Props["dev"] != nil {
+		t.Error("react should not be a dev dependency")
+	}
+}
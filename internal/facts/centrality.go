@@ -0,0 +1,250 @@
+package facts
+
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+// CentralNode is a graph node ranked by betweenness centrality.
+type CentralNode struct {
+	Name        string  `json:"name"`
+	Kind        string  `json:"kind,omitempty"`
+	File        string  `json:"file,omitempty"`
+	Betweenness float64 `json:"betweenness"`
+	FanIn       int     `json:"fan_in"`
+	FanOut      int     `json:"fan_out"`
+	WeightIn    int     `json:"weight_in"`
+	WeightOut   int     `json:"weight_out"`
+}
+
+// CentralityResult holds the top nodes of a centrality computation.
+type CentralityResult struct {
+	Nodes           []CentralNode `json:"nodes"`
+	NodesConsidered int           `json:"nodes_considered"`
+	EdgesConsidered int           `json:"edges_considered"`
+	// TruncatedByCancel is set when the context was cancelled before every
+	// source was processed; scores then reflect only a subset of paths.
+	TruncatedByCancel bool `json:"truncated_by_cancel,omitempty"`
+}
+
+// Betweenness computes normalized betweenness centrality (Brandes' algorithm)
+// over the subgraph of nodes whose fact kind is in nodeKinds (nil = all) and
+// edges whose relation kind is in relKinds (nil = all). A node scores highly
+// when many shortest paths between other nodes pass through it, so leaf
+// utilities that are widely imported but import nothing themselves score 0.
+// The second return value is false if ctx was cancelled mid-computation.
+func (g *Graph) Betweenness(ctx context.Context, relKinds, nodeKinds []string) (map[string]float64, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	names, adj, _ := g.centralitySubgraph(relKinds, nodeKinds)
+	scores, complete := brandes(ctx, adj)
+
+	result := make(map[string]float64, len(names))
+	for i, name := range names {
+		result[name] = scores[i]
+	}
+	return result, complete
+}
+
+// CentralNodes ranks nodes by betweenness centrality and returns the top
+// limit (0 = default 20, max 200). Ties are broken by total edge weight and
+// then by name. Fan-in/fan-out count distinct neighbours in the subgraph;
+// the weight fields sum the edge weights behind them.
+func (g *Graph) CentralNodes(ctx context.Context, relKinds, nodeKinds []string, limit int) CentralityResult {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	names, adj, weights := g.centralitySubgraph(relKinds, nodeKinds)
+	scores, complete := brandes(ctx, adj)
+
+	nodes := make([]CentralNode, len(names))
+	edgeCount := 0
+	for i, name := range names {
+		nodes[i].Name = name
+		nodes[i].Betweenness = math.Round(scores[i]*10000) / 10000
+		if idx, ok := g.factIdx[name]; ok && idx < len(g.facts) {
+			nodes[i].Kind = g.facts[idx].Kind
+			nodes[i].File = g.facts[idx].File
+		}
+	}
+	for u, targets := range adj {
+		edgeCount += len(targets)
+		for j, v := range targets {
+			w := weights[u][j]
+			nodes[u].FanOut++
+			nodes[u].WeightOut += w
+			nodes[v].FanIn++
+			nodes[v].WeightIn += w
+		}
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Betweenness != nodes[j].Betweenness {
+			return nodes[i].Betweenness > nodes[j].Betweenness
+		}
+		wi := nodes[i].WeightIn + nodes[i].WeightOut
+		wj := nodes[j].WeightIn + nodes[j].WeightOut
+		if wi != wj {
+			return wi > wj
+		}
+		return nodes[i].Name < nodes[j].Name
+	})
+	if len(nodes) > limit {
+		nodes = nodes[:limit]
+	}
+
+	return CentralityResult{
+		Nodes:             nodes,
+		NodesConsidered:   len(names),
+		EdgesConsidered:   edgeCount,
+		TruncatedByCancel: !complete,
+	}
+}
+
+// centralitySubgraph builds an index-based adjacency list for the filtered
+// subgraph. Parallel edges of different relation kinds are merged into one
+// neighbour whose weight is the sum of theirs; self-loops are dropped.
+// Callers must hold g.mu.
+func (g *Graph) centralitySubgraph(relKinds, nodeKinds []string) ([]string, [][]int, [][]int) {
+	relSet := toSet(relKinds)
+	kindSet := toSet(nodeKinds)
+
+	include := func(name string) bool {
+		if kindSet == nil {
+			return true
+		}
+		idx, ok := g.factIdx[name]
+		if !ok || idx >= len(g.facts) {
+			return false
+		}
+		_, ok = kindSet[g.facts[idx].Kind]
+		return ok
+	}
+
+	index := make(map[string]int)
+	var names []string
+	add := func(name string) {
+		if _, ok := index[name]; !ok && include(name) {
+			index[name] = len(names)
+			names = append(names, name)
+		}
+	}
+	for source, edges := range g.forward {
+		for _, e := range edges {
+			if relSet != nil {
+				if _, ok := relSet[e.RelKind]; !ok {
+					continue
+				}
+			}
+			add(source)
+			add(e.Target)
+		}
+	}
+	// Map iteration order is random; sort so results are deterministic.
+	sort.Strings(names)
+	for i, name := range names {
+		index[name] = i
+	}
+
+	adj := make([][]int, len(names))
+	weights := make([][]int, len(names))
+	for u, source := range names {
+		pos := make(map[int]int)
+		for _, e := range g.forward[source] {
+			if relSet != nil {
+				if _, ok := relSet[e.RelKind]; !ok {
+					continue
+				}
+			}
+			v, ok := index[e.Target]
+			if !ok || v == u {
+				continue
+			}
+			if p, seen := pos[v]; seen {
+				weights[u][p] += e.Weight
+				continue
+			}
+			pos[v] = len(adj[u])
+			adj[u] = append(adj[u], v)
+			weights[u] = append(weights[u], e.Weight)
+		}
+	}
+	return names, adj, weights
+}
+
+// brandes computes betweenness centrality for an unweighted directed graph
+// given as an adjacency list, normalized by (n-1)(n-2). If ctx is cancelled
+// it stops early and returns the scores accumulated so far with false.
+func brandes(ctx context.Context, adj [][]int) ([]float64, bool) {
+	n := len(adj)
+	scores := make([]float64, n)
+	if n < 3 {
+		return scores, true
+	}
+
+	sigma := make([]float64, n)
+	dist := make([]int, n)
+	delta := make([]float64, n)
+	preds := make([][]int, n)
+	stack := make([]int, 0, n)
+	queue := make([]int, 0, n)
+
+	complete := true
+	for s := 0; s < n; s++ {
+		if s%cancelCheckInterval == 0 && ctx.Err() != nil {
+			complete = false
+			break
+		}
+
+		for i := range sigma {
+			sigma[i] = 0
+			dist[i] = -1
+			delta[i] = 0
+			preds[i] = preds[i][:0]
+		}
+		sigma[s] = 1
+		dist[s] = 0
+		stack = stack[:0]
+		queue = append(queue[:0], s)
+
+		for qi := 0; qi < len(queue); qi++ {
+			v := queue[qi]
+			stack = append(stack, v)
+			for _, w := range adj[v] {
+				if dist[w] < 0 {
+					dist[w] = dist[v] + 1
+					queue = append(queue, w)
+				}
+				if dist[w] == dist[v]+1 {
+					sigma[w] += sigma[v]
+					preds[w] = append(preds[w], v)
+				}
+			}
+		}
+
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+			for _, v := range preds[w] {
+				delta[v] += sigma[v] / sigma[w] * (1 + delta[w])
+			}
+			if w != s {
+				scores[w] += delta[w]
+			}
+		}
+	}
+
+	norm := float64((n - 1) * (n - 2))
+	for i := range scores {
+		scores[i] /= norm
+	}
+	return scores, complete
+}
@@ -0,0 +1,123 @@
+package facts
+
+import (
+	"context"
+	"testing"
+)
+
+// buildHubGraph creates a module graph where "hub" sits between two layers
+// and "util" is a leaf imported by everyone:
+//
+//	api1 ─┐          ┌─> db
+//	api2 ─┼─> hub ───┤
+//	api3 ─┘          └─> cache
+//	(every module) ──> util
+func buildHubGraph() *Graph {
+	mod := func(name string, targets ...string) Fact {
+		f := Fact{Kind: KindModule, Name: name, File: name + "/doc.go"}
+		for _, t := range targets {
+			f.Relations = append(f.Relations, Relation{Kind: RelImports, Target: t})
+		}
+		return f
+	}
+	s := NewStore()
+	s.Add(
+		mod("api1", "hub", "util"),
+		mod("api2", "hub", "util"),
+		mod("api3", "hub", "util"),
+		mod("hub", "db", "cache", "util"),
+		mod("db", "util"),
+		mod("cache", "util"),
+		mod("util"),
+	)
+	s.BuildGraph()
+	return s.Graph()
+}
+
+func TestEdgeWeight_CountsCollapsedRelations(t *testing.T) {
+	s := NewStore()
+	s.Add(
+		Fact{Kind: KindModule, Name: "internal/server"},
+		Fact{Kind: KindModule, Name: "internal/config"},
+		Fact{Kind: KindDependency, Name: "internal/server -> internal/config", File: "internal/server/a.go",
+			Relations: []Relation{{Kind: RelImports, Target: "internal/config"}}},
+		Fact{Kind: KindDependency, Name: "internal/server -> internal/config", File: "internal/server/b.go",
+			Relations: []Relation{{Kind: RelImports, Target: "internal/config"}}},
+	)
+	s.BuildGraph()
+	g := s.Graph()
+
+	if w := g.EdgeWeight("internal/server", "internal/config"); w != 2 {
+		t.Errorf("EdgeWeight = %d, want 2 (one per importing file)", w)
+	}
+	if w := g.EdgeWeight("internal/config", "internal/server"); w != 0 {
+		t.Errorf("reverse EdgeWeight = %d, want 0", w)
+	}
+	// Weighting must not duplicate edges.
+	if n := len(g.Forward()["internal/server"]); n != 1 {
+		t.Errorf("expected a single server->config edge, got %d", n)
+	}
+}
+
+func TestBetweenness_HubOutranksLeafUtility(t *testing.T) {
+	g := buildHubGraph()
+
+	scores, complete := g.Betweenness(context.Background(), nil, nil)
+	if !complete {
+		t.Fatal("expected a complete computation")
+	}
+	if scores["util"] != 0 {
+		t.Errorf("leaf utility betweenness = %v, want 0", scores["util"])
+	}
+	if scores["hub"] <= 0 {
+		t.Errorf("hub betweenness = %v, want > 0", scores["hub"])
+	}
+	for _, name := range []string{"api1", "db", "cache"} {
+		if scores[name] >= scores["hub"] {
+			t.Errorf("%s betweenness %v should be below hub %v", name, scores[name], scores["hub"])
+		}
+	}
+}
+
+func TestCentralNodes_RankingAndLimit(t *testing.T) {
+	g := buildHubGraph()
+
+	result := g.CentralNodes(context.Background(), []string{RelImports}, []string{KindModule}, 2)
+	if result.NodesConsidered != 7 {
+		t.Errorf("NodesConsidered = %d, want 7", result.NodesConsidered)
+	}
+	if len(result.Nodes) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(result.Nodes))
+	}
+	top := result.Nodes[0]
+	if top.Name != "hub" {
+		t.Fatalf("top node = %q, want hub", top.Name)
+	}
+	if top.FanIn != 3 || top.FanOut != 3 {
+		t.Errorf("hub fan_in/fan_out = %d/%d, want 3/3", top.FanIn, top.FanOut)
+	}
+	if top.Kind != KindModule || top.File != "hub/doc.go" {
+		t.Errorf("hub metadata = %q %q", top.Kind, top.File)
+	}
+}
+
+func TestCentralNodes_NodeKindFilter(t *testing.T) {
+	g, _ := buildTestGraph()
+
+	// Only modules C and E remain; the single E->C edge has no intermediaries.
+	result := g.CentralNodes(context.Background(), nil, []string{KindModule}, 0)
+	if result.NodesConsidered != 2 || result.EdgesConsidered != 1 {
+		t.Errorf("considered %d nodes / %d edges, want 2 / 1", result.NodesConsidered, result.EdgesConsidered)
+	}
+}
+
+func TestCentralNodes_CancelledContext(t *testing.T) {
+	g := buildHubGraph()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := g.CentralNodes(ctx, nil, nil, 0)
+	if !result.TruncatedByCancel {
+		t.Error("expected TruncatedByCancel for a cancelled context")
+	}
+}
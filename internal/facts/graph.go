@@ -1,6 +1,10 @@
 package facts
 
 import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -13,13 +17,27 @@ type Graph struct {
 	reverse  map[string][]Edge    // fact name → incoming edges
 	facts    []Fact               // reference to the store's facts (for metadata lookups)
 	factIdx  map[string]int       // fact name → first index in facts slice
-	edgeSeen map[string]struct{}  // deduplication: "source\x00kind\x00target"
+	edgeSeen map[string][2]int    // deduplication: "source\x00kind\x00target" → forward/reverse slice index
+
+	// Kept so AddFacts and RemoveByFile can update the module→import edges
+	// derived from dependency facts.
+	modules map[string]int        // module name → number of module facts
+	derived map[int][]derivedEdge // fact index → module edges it contributed
+
+	// relKinds restricts the relations ingested as edges; nil keeps all.
+	relKinds map[string]struct{}
+}
+
+// derivedEdge is a module→import edge synthesized from a dependency fact.
+type derivedEdge struct {
+	source, target string
 }
 
 // Edge represents a directed relationship between two facts.
 type Edge struct {
 	RelKind string // "imports", "calls", "declares", "implements", "depends_on"
 	Target  string // target fact name (forward) or source fact name (reverse)
+	Weight  int    // number of relations collapsed into this edge (always >= 1)
 }
 
 // TraversalResult holds the output of a graph traversal.
@@ -27,8 +45,24 @@ type TraversalResult struct {
 	Nodes []TraversalNode `json:"nodes"`
 	Edges []TraversalEdge `json:"edges"`
 	Stats TraversalStats  `json:"stats"`
+	Page  *PageInfo       `json:"page,omitempty"`
+}
+
+// PageInfo describes the window of nodes kept by Paginate.
+type PageInfo struct {
+	SortBy  string `json:"sort_by"`
+	Offset  int    `json:"offset"`
+	Limit   int    `json:"limit,omitempty"`
+	Total   int    `json:"total"` // nodes before paging
+	HasMore bool   `json:"has_more"`
 }
 
+// Sort orders for Paginate.
+const (
+	SortByDepth = "depth" // depth, then name (default)
+	SortByName  = "name"  // name, then depth
+)
+
 // TraversalNode is a node visited during traversal.
 type TraversalNode struct {
 	Name  string `json:"name"`
@@ -51,6 +85,9 @@ type TraversalStats struct {
 	EdgesTraversed  int  `json:"edges_traversed"`
 	MaxDepthReached int  `json:"max_depth_reached"`
 	Truncated       bool `json:"truncated"`
+	// TruncatedByCancel is set when the context was cancelled before the
+	// traversal finished; the result holds whatever was collected so far.
+	TruncatedByCancel bool `json:"truncated_by_cancel,omitempty"`
 }
 
 // ImpactResult holds depth-bucketed impact analysis results.
@@ -61,6 +98,7 @@ type ImpactResult struct {
 	Summary  string                    `json:"summary"`
 	Stats    TraversalStats            `json:"stats"`
 	Forward  *TraversalResult          `json:"forward_dependencies,omitempty"`
+	Page     *PageInfo                 `json:"page,omitempty"`
 }
 
 // PathResult holds a shortest-path result.
@@ -70,8 +108,15 @@ type PathResult struct {
 	Found bool            `json:"found"`
 	Path  []TraversalNode `json:"path,omitempty"`
 	Edges []TraversalEdge `json:"edges,omitempty"`
+	// TruncatedByCancel is set when the search was abandoned because the
+	// context was cancelled; Found is false in that case.
+	TruncatedByCancel bool `json:"truncated_by_cancel,omitempty"`
 }
 
+// cancelCheckInterval is how many dequeued BFS items are processed between
+// context checks, keeping ctx.Err() off the hot path on large graphs.
+const cancelCheckInterval = 64
+
 // NewGraph builds a Graph from a slice of facts. The graph constructs both forward
 // and reverse adjacency lists in a single O(F+R) pass.
 //
@@ -81,58 +126,213 @@ type PathResult struct {
 // dependencies are separate facts: module "internal/server" ←→ dependency fact
 // "internal/server -> internal/config" → target "internal/config".
 func NewGraph(ff []Fact) *Graph {
+	return NewGraphWithRelations(ff, nil)
+}
+
+// NewGraphWithRelations is NewGraph ingesting only the relations of
+// relKinds (nil = all), for large repos where edges such as declares and
+// calls are not needed. Every fact is still indexed as a node. The
+// module→import edges of dependency facts are kept with imports.
+func NewGraphWithRelations(ff []Fact, relKinds []string) *Graph {
 	g := &Graph{
 		forward:  make(map[string][]Edge),
 		reverse:  make(map[string][]Edge),
-		facts:    ff,
 		factIdx:  make(map[string]int, len(ff)),
-		edgeSeen: make(map[string]struct{}),
+		edgeSeen: make(map[string][2]int),
+		modules:  make(map[string]int),
+		derived:  make(map[int][]derivedEdge),
+		relKinds: toSet(relKinds),
 	}
+	g.addFacts(ff, 0)
+
+	// edgeSeen is only needed during construction; release it so the GC can
+	// reclaim the O(edges × 3 strings) backing memory.
+	g.edgeSeen = nil
+
+	return g
+}
+
+// AddFacts adds facts to the graph without rebuilding it, leaving it as
+// NewGraph would build it from the old and new facts together. Module→import
+// edges of dependency facts already in the graph are re-resolved when the new
+// facts declare modules.
+func (g *Graph) AddFacts(ff []Fact) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	n := len(g.facts)
+	// Copy rather than append in place: g.facts may share its backing array
+	// with the store's facts.
+	g.addFacts(append(g.facts[:n:n], ff...), n)
+}
+
+// addFacts indexes all[start:] and adds their edges; all[:start] must be the
+// facts already in the graph. The caller holds g.mu or owns g.
+func (g *Graph) addFacts(all []Fact, start int) {
+	g.facts = all
 
 	// First pass: index all fact names and collect module names
-	moduleNames := make(map[string]bool)
-	for i, f := range ff {
+	modulesChanged := false
+	for i := start; i < len(all); i++ {
+		f := all[i]
 		if f.Name != "" {
 			if _, exists := g.factIdx[f.Name]; !exists {
 				g.factIdx[f.Name] = i
 			}
 		}
 		if f.Kind == KindModule {
-			moduleNames[f.Name] = true
+			if g.modules[f.Name] == 0 {
+				modulesChanged = true
+			}
+			g.modules[f.Name]++
 		}
 	}
+	if modulesChanged {
+		g.resyncDerived(0, start)
+	}
 
 	// Second pass: build adjacency lists
-	for _, f := range ff {
-		for _, rel := range f.Relations {
+	for i := start; i < len(all); i++ {
+		g.addFactEdges(i)
+	}
+}
+
+// addFactEdges adds the edges of the fact at index i.
+func (g *Graph) addFactEdges(i int) {
+	f := g.facts[i]
+	for _, rel := range f.Relations {
+		if g.ingests(rel.Kind) {
 			g.addEdge(f.Name, rel.Kind, rel.Target)
 		}
+	}
 
-		// For dependency facts with imports, also create module→target edges
-		// so that traversing from a module follows through to its imports.
-		// The target is resolved to the nearest ancestor that is a known module,
-		// handling cases where import paths point to files within a module directory
-		// (e.g., "src/types/tournament" resolves to module "src/types").
-		if f.Kind == KindDependency && f.File != "" {
-			modName := fileDirectory(f.File)
-			if moduleNames[modName] {
-				for _, rel := range f.Relations {
-					if rel.Kind == RelImports {
-						target := resolveToModule(rel.Target, moduleNames)
-						if target != "" && target != modName {
-							g.addEdge(modName, RelImports, target)
-						}
-					}
-				}
+	// For dependency facts with imports, also create module→target edges
+	// so that traversing from a module follows through to its imports.
+	if d := g.derivedEdges(f); len(d) > 0 {
+		g.derived[i] = d
+		for _, e := range d {
+			g.addEdge(e.source, RelImports, e.target)
+		}
+	}
+}
+
+// ingests reports whether relations of relKind become edges.
+func (g *Graph) ingests(relKind string) bool {
+	if g.relKinds == nil {
+		return true
+	}
+	_, ok := g.relKinds[relKind]
+	return ok
+}
+
+// derivedEdges returns the module→import edges of a dependency fact. The
+// target is resolved to the nearest ancestor that is a known module,
+// handling cases where import paths point to files within a module directory
+// (e.g., "src/types/tournament" resolves to module "src/types").
+func (g *Graph) derivedEdges(f Fact) []derivedEdge {
+	if f.Kind != KindDependency || f.File == "" || !g.ingests(RelImports) {
+		return nil
+	}
+	modName := fileDirectory(f.File)
+	if g.modules[modName] == 0 {
+		return nil
+	}
+	var out []derivedEdge
+	for _, rel := range f.Relations {
+		if rel.Kind == RelImports {
+			target := resolveToModule(rel.Target, g.modules)
+			if target != "" && target != modName {
+				out = append(out, derivedEdge{modName, target})
 			}
 		}
 	}
+	return out
+}
 
-	// edgeSeen is only needed during construction; release it so the GC can
-	// reclaim the O(edges × 3 strings) backing memory.
-	g.edgeSeen = nil
+// resyncDerived recomputes the module→import edges of the dependency facts
+// in g.facts[from:to] after the set of modules changed.
+func (g *Graph) resyncDerived(from, to int) {
+	for i := from; i < to; i++ {
+		f := g.facts[i]
+		if f.Kind != KindDependency || f.File == "" {
+			continue
+		}
+		want := g.derivedEdges(f)
+		if slices.Equal(want, g.derived[i]) {
+			continue
+		}
+		for _, e := range g.derived[i] {
+			g.removeEdge(e.source, RelImports, e.target)
+		}
+		delete(g.derived, i)
+		if len(want) > 0 {
+			g.derived[i] = want
+			for _, e := range want {
+				g.addEdge(e.source, RelImports, e.target)
+			}
+		}
+	}
+}
 
-	return g
+// RemoveByFile removes the facts whose File is file, together with the edges
+// they contributed, and returns how many were removed. Edges other facts
+// also contribute stay, with their weight reduced. The graph is left as
+// NewGraph would build it from the remaining facts, except that edges may be
+// listed in a different order.
+func (g *Graph) RemoveByFile(file string) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.removeFacts(func(f Fact) bool { return f.File == file })
+}
+
+// removeFacts removes the facts for which drop returns true, like
+// RemoveByFile, and returns how many were removed. The caller holds g.mu or
+// owns g.
+func (g *Graph) removeFacts(drop func(Fact) bool) int {
+	kept := make([]Fact, 0, len(g.facts))
+	derived := make(map[int][]derivedEdge, len(g.derived))
+	removed := 0
+	modulesChanged := false
+	for i, f := range g.facts {
+		if !drop(f) {
+			if d, ok := g.derived[i]; ok {
+				derived[len(kept)] = d
+			}
+			kept = append(kept, f)
+			continue
+		}
+		removed++
+		for _, rel := range f.Relations {
+			if g.ingests(rel.Kind) {
+				g.removeEdge(f.Name, rel.Kind, rel.Target)
+			}
+		}
+		for _, e := range g.derived[i] {
+			g.removeEdge(e.source, RelImports, e.target)
+		}
+		if f.Kind == KindModule {
+			g.modules[f.Name]--
+			if g.modules[f.Name] == 0 {
+				delete(g.modules, f.Name)
+				modulesChanged = true
+			}
+		}
+	}
+	if removed == 0 {
+		return 0
+	}
+
+	g.facts = kept
+	g.derived = derived
+	g.factIdx = make(map[string]int, len(kept))
+	for i, f := range kept {
+		if _, exists := g.factIdx[f.Name]; !exists && f.Name != "" {
+			g.factIdx[f.Name] = i
+		}
+	}
+	if modulesChanged {
+		g.resyncDerived(0, len(kept))
+	}
+	return removed
 }
 
 // Traverse performs a BFS traversal from the given start node.
@@ -141,7 +341,24 @@ func NewGraph(ff []Fact) *Graph {
 // nodeKinds filters result nodes to specific fact kinds (nil = all).
 // maxDepth limits traversal depth (0 = use default 5).
 // maxNodes limits total returned nodes (0 = use default 100).
-func (g *Graph) Traverse(start, direction string, relKinds, nodeKinds []string, maxDepth, maxNodes int) TraversalResult {
+// If ctx is cancelled mid-traversal, the partial result is returned with
+// Stats.TruncatedByCancel set.
+func (g *Graph) Traverse(ctx context.Context, start, direction string, relKinds, nodeKinds []string, maxDepth, maxNodes int) TraversalResult {
+	var include func(Fact) bool
+	if kindSet := toSet(nodeKinds); kindSet != nil {
+		include = func(f Fact) bool {
+			_, ok := kindSet[f.Kind]
+			return ok
+		}
+	}
+	return g.TraverseFunc(ctx, start, direction, relKinds, include, maxDepth, maxNodes)
+}
+
+// TraverseFunc is Traverse with an arbitrary result filter: nodes for which
+// include returns false are traversed through but not returned (nil = all).
+// Nodes without a fact, such as external dependencies, are passed as a fact
+// with just their name.
+func (g *Graph) TraverseFunc(ctx context.Context, start, direction string, relKinds []string, include func(Fact) bool, maxDepth, maxNodes int) TraversalResult {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
@@ -164,7 +381,6 @@ func (g *Graph) Traverse(start, direction string, relKinds, nodeKinds []string,
 	}
 
 	relSet := toSet(relKinds)
-	kindSet := toSet(nodeKinds)
 
 	var result TraversalResult
 	visited := make(map[string]bool)
@@ -185,6 +401,10 @@ func (g *Graph) Traverse(start, direction string, relKinds, nodeKinds []string,
 	// Use an index pointer instead of re-slicing to avoid keeping the full
 	// backing array alive for the duration of traversal.
 	for qi := 0; qi < len(queue); qi++ {
+		if qi%cancelCheckInterval == 0 && ctx.Err() != nil {
+			result.Stats.TruncatedByCancel = true
+			break
+		}
 		item := queue[qi]
 
 		if item.depth >= maxDepth {
@@ -228,13 +448,11 @@ func (g *Graph) Traverse(start, direction string, relKinds, nodeKinds []string,
 
 			node := g.nodeFor(e.Target, newDepth)
 
-			// Apply node kind filter
-			if kindSet != nil {
-				if _, ok := kindSet[node.Kind]; !ok {
-					// Still traverse through this node but don't include it in results
-					queue = append(queue, queueItem{name: e.Target, depth: newDepth})
-					continue
-				}
+			// Apply the node filter
+			if include != nil && !include(g.factFor(e.Target)) {
+				// Still traverse through this node but don't include it in results
+				queue = append(queue, queueItem{name: e.Target, depth: newDepth})
+				continue
 			}
 
 			if len(result.Nodes) >= maxNodes {
@@ -257,7 +475,8 @@ func (g *Graph) Traverse(start, direction string, relKinds, nodeKinds []string,
 // FindPath finds the shortest path between two nodes using BFS.
 // relKinds filters to specific relation types (nil = all).
 // maxDepth limits search depth (0 = use default 10).
-func (g *Graph) FindPath(from, to string, relKinds []string, maxDepth int) PathResult {
+// If ctx is cancelled before a path is found, TruncatedByCancel is set.
+func (g *Graph) FindPath(ctx context.Context, from, to string, relKinds []string, maxDepth int) PathResult {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
@@ -293,8 +512,13 @@ func (g *Graph) FindPath(from, to string, relKinds []string, maxDepth int) PathR
 	queue := []queueItem{{name: from, depth: 0}}
 
 	found := false
+	cancelled := false
 	// Use an index pointer to avoid keeping the full backing array alive.
 	for qi := 0; qi < len(queue) && !found; qi++ {
+		if qi%cancelCheckInterval == 0 && ctx.Err() != nil {
+			cancelled = true
+			break
+		}
 		item := queue[qi]
 
 		if item.depth >= maxDepth {
@@ -322,7 +546,7 @@ func (g *Graph) FindPath(from, to string, relKinds []string, maxDepth int) PathR
 		}
 	}
 
-	result := PathResult{From: from, To: to, Found: found}
+	result := PathResult{From: from, To: to, Found: found, TruncatedByCancel: cancelled}
 	if !found {
 		return result
 	}
@@ -359,7 +583,8 @@ func (g *Graph) FindPath(from, to string, relKinds []string, maxDepth int) PathR
 // ImpactSet computes the transitive set of nodes affected by changing the target.
 // It performs a reverse BFS and groups results by depth.
 // If includeForward is true, it also includes what the target depends on.
-func (g *Graph) ImpactSet(target string, maxDepth, maxNodes int, includeForward bool) ImpactResult {
+// Cancellation of ctx is reported through Stats.TruncatedByCancel.
+func (g *Graph) ImpactSet(ctx context.Context, target string, maxDepth, maxNodes int, includeForward bool) ImpactResult {
 	if maxDepth <= 0 {
 		maxDepth = 3
 	}
@@ -374,7 +599,7 @@ func (g *Graph) ImpactSet(target string, maxDepth, maxNodes int, includeForward
 	}
 
 	// Reverse traversal: who depends on target?
-	rev := g.Traverse(target, "reverse", nil, nil, maxDepth, maxNodes)
+	rev := g.Traverse(ctx, target, "reverse", nil, nil, maxDepth, maxNodes)
 
 	result := ImpactResult{
 		Target:  target,
@@ -383,7 +608,9 @@ func (g *Graph) ImpactSet(target string, maxDepth, maxNodes int, includeForward
 		Stats:   rev.Stats,
 	}
 
-	// Bucket nodes by depth (skip depth 0 which is the target itself)
+	// Bucket nodes by depth (skip depth 0 which is the target itself),
+	// sorted by name within each depth.
+	sortNodes(rev.Nodes, SortByDepth)
 	for _, n := range rev.Nodes {
 		if n.Depth > 0 {
 			result.ByDepth[n.Depth] = append(result.ByDepth[n.Depth], n)
@@ -395,35 +622,170 @@ func (g *Graph) ImpactSet(target string, maxDepth, maxNodes int, includeForward
 
 	// Optionally include forward dependencies
 	if includeForward {
-		fwd := g.Traverse(target, "forward", nil, nil, maxDepth, maxNodes)
+		fwd := g.Traverse(ctx, target, "forward", nil, nil, maxDepth, maxNodes)
 		result.Forward = &fwd
 	}
 
 	return result
 }
 
+// Paginate sorts the nodes by sortBy (SortByDepth or SortByName; empty means
+// SortByDepth) and keeps limit of them starting at offset (limit <= 0 keeps
+// the rest). Edges are kept if they touch a kept node. Page describes the
+// window. Paging applies to the nodes already collected, so maxNodes of the
+// traversal still bounds the total.
+func (r *TraversalResult) Paginate(sortBy string, offset, limit int) {
+	if sortBy == "" {
+		sortBy = SortByDepth
+	}
+	sortNodes(r.Nodes, sortBy)
+	var page PageInfo
+	r.Nodes, page = pageNodes(r.Nodes, sortBy, offset, limit)
+	r.Edges = edgesTouching(r.Edges, r.Nodes)
+	r.Page = &page
+}
+
+// Paginate pages the impacted nodes across all depths the way
+// TraversalResult.Paginate does and rebuilds ByDepth from the kept nodes.
+// Summary and Stats keep describing the full impact set.
+func (r *ImpactResult) Paginate(sortBy string, offset, limit int) {
+	if sortBy == "" {
+		sortBy = SortByDepth
+	}
+	var nodes []TraversalNode
+	for _, bucket := range r.ByDepth {
+		nodes = append(nodes, bucket...)
+	}
+	sortNodes(nodes, sortBy)
+	var page PageInfo
+	nodes, page = pageNodes(nodes, sortBy, offset, limit)
+
+	r.ByDepth = make(map[int][]TraversalNode)
+	for _, n := range nodes {
+		r.ByDepth[n.Depth] = append(r.ByDepth[n.Depth], n)
+	}
+	r.Edges = edgesTouching(r.Edges, nodes)
+	r.Page = &page
+}
+
+// sortNodes sorts nodes in place by depth then name, or by name then depth.
+func sortNodes(nodes []TraversalNode, sortBy string) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		if sortBy == SortByName {
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			return a.Depth < b.Depth
+		}
+		if a.Depth != b.Depth {
+			return a.Depth < b.Depth
+		}
+		return a.Name < b.Name
+	})
+}
+
+func pageNodes(nodes []TraversalNode, sortBy string, offset, limit int) ([]TraversalNode, PageInfo) {
+	if offset < 0 {
+		offset = 0
+	}
+	page := PageInfo{SortBy: sortBy, Offset: offset, Limit: limit, Total: len(nodes)}
+	if offset > len(nodes) {
+		offset = len(nodes)
+	}
+	end := len(nodes)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+		page.HasMore = true
+	}
+	return nodes[offset:end], page
+}
+
+// edgesTouching returns the edges with a source or target among nodes.
+func edgesTouching(edges []TraversalEdge, nodes []TraversalNode) []TraversalEdge {
+	keep := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		keep[n.Name] = true
+	}
+	var out []TraversalEdge
+	for _, e := range edges {
+		if keep[e.Source] || keep[e.Target] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
 func (g *Graph) addEdge(source, relKind, target string) {
 	key := source + "\x00" + relKind + "\x00" + target
-	if _, exists := g.edgeSeen[key]; exists {
+	if idx, exists := g.edgeSeen[key]; exists {
+		// Repeated relations (e.g. several files in a module importing the
+		// same package) collapse into one edge with a higher weight.
+		g.forward[source][idx[0]].Weight++
+		g.reverse[target][idx[1]].Weight++
 		return
 	}
-	g.edgeSeen[key] = struct{}{}
+	if g.edgeSeen == nil {
+		// After construction, look the edge up in the source's list.
+		if i := edgeIndex(g.forward[source], relKind, target); i >= 0 {
+			g.forward[source][i].Weight++
+			g.reverse[target][edgeIndex(g.reverse[target], relKind, source)].Weight++
+			return
+		}
+	} else {
+		g.edgeSeen[key] = [2]int{len(g.forward[source]), len(g.reverse[target])}
+	}
 	g.forward[source] = append(g.forward[source], Edge{
 		RelKind: relKind,
 		Target:  target,
+		Weight:  1,
 	})
 	g.reverse[target] = append(g.reverse[target], Edge{
 		RelKind: relKind,
 		Target:  source,
+		Weight:  1,
 	})
 }
 
+// removeEdge drops one relation's worth of weight from an edge, removing the
+// edge once no relation backs it.
+func (g *Graph) removeEdge(source, relKind, target string) {
+	i := edgeIndex(g.forward[source], relKind, target)
+	if i < 0 {
+		return
+	}
+	j := edgeIndex(g.reverse[target], relKind, source)
+	if g.forward[source][i].Weight > 1 {
+		g.forward[source][i].Weight--
+		g.reverse[target][j].Weight--
+		return
+	}
+	g.forward[source] = slices.Delete(g.forward[source], i, i+1)
+	if len(g.forward[source]) == 0 {
+		delete(g.forward, source)
+	}
+	g.reverse[target] = slices.Delete(g.reverse[target], j, j+1)
+	if len(g.reverse[target]) == 0 {
+		delete(g.reverse, target)
+	}
+}
+
+// edgeIndex returns the index of the edge of relKind to target in edges, or -1.
+func edgeIndex(edges []Edge, relKind, target string) int {
+	for i, e := range edges {
+		if e.RelKind == relKind && e.Target == target {
+			return i
+		}
+	}
+	return -1
+}
+
 // resolveToModule finds the closest matching module for a target by trying
 // the target itself, then walking up parent directories until a match is found.
-func resolveToModule(target string, moduleNames map[string]bool) string {
+func resolveToModule(target string, moduleNames map[string]int) string {
 	cur := target
 	for {
-		if moduleNames[cur] {
+		if moduleNames[cur] > 0 {
 			return cur
 		}
 		parent := fileDirectory(cur)
@@ -472,18 +834,74 @@ func (g *Graph) ReverseFacts(targetName, relKind string) []Fact {
 	return result
 }
 
-// Forward returns the forward adjacency map (for use by explainers like cycles).
+// Forward returns a copy of the forward adjacency map (for use by explainers
+// like cycles). The copy stays valid while the graph is extended.
 func (g *Graph) Forward() map[string][]Edge {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
-	return g.forward
+	return copyAdjacency(g.forward)
 }
 
-// Reverse returns the reverse adjacency map.
+// Reverse returns a copy of the reverse adjacency map.
 func (g *Graph) Reverse() map[string][]Edge {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
-	return g.reverse
+	return copyAdjacency(g.reverse)
+}
+
+// OutEdges returns a copy of the outgoing edges of a fact.
+func (g *Graph) OutEdges(name string) []Edge {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return slices.Clone(g.forward[name])
+}
+
+// InEdges returns a copy of the incoming edges of a fact; the Target of
+// each edge is its source.
+func (g *Graph) InEdges(name string) []Edge {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return slices.Clone(g.reverse[name])
+}
+
+// copyAdjacency copies an adjacency map and its edge slices, so callers
+// can read it without holding the graph lock.
+func copyAdjacency(adj map[string][]Edge) map[string][]Edge {
+	out := make(map[string][]Edge, len(adj))
+	for name, edges := range adj {
+		out[name] = slices.Clone(edges)
+	}
+	return out
+}
+
+// clone returns a copy of g indexing ff, a copy of its facts, that can be
+// changed without affecting g.
+func (g *Graph) clone(ff []Fact) *Graph {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return &Graph{
+		forward:  copyAdjacency(g.forward),
+		reverse:  copyAdjacency(g.reverse),
+		facts:    ff[:len(g.facts)],
+		factIdx:  maps.Clone(g.factIdx),
+		modules:  maps.Clone(g.modules),
+		derived:  maps.Clone(g.derived),
+		relKinds: g.relKinds,
+	}
+}
+
+// EdgeWeight returns the total weight of edges from source to target,
+// summed across relation kinds. It returns 0 when the nodes are not connected.
+func (g *Graph) EdgeWeight(source, target string) int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	w := 0
+	for _, e := range g.forward[source] {
+		if e.Target == target {
+			w += e.Weight
+		}
+	}
+	return w
 }
 
 // NodeCount returns the number of unique nodes in the graph.
@@ -504,6 +922,15 @@ func (g *Graph) EdgeCount() int {
 	return count
 }
 
+// factFor returns the fact named name, or a fact with just the name when the
+// graph has none.
+func (g *Graph) factFor(name string) Fact {
+	if idx, ok := g.factIdx[name]; ok && idx < len(g.facts) {
+		return g.facts[idx]
+	}
+	return Fact{Name: name}
+}
+
 func (g *Graph) nodeFor(name string, depth int) TraversalNode {
 	node := TraversalNode{Name: name, Depth: depth}
 	if idx, ok := g.factIdx[name]; ok && idx < len(g.facts) {
@@ -544,16 +971,20 @@ func (g *Graph) buildImpactSummary(byDepth map[int][]TraversalNode) string {
 			summary += "; "
 		}
 		summary += "depth " + itoa(d) + ": "
-		first := true
-		for kind, count := range kindCount {
-			if !first {
+		kinds := make([]string, 0, len(kindCount))
+		for kind := range kindCount {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		for i, kind := range kinds {
+			if i > 0 {
 				summary += ", "
 			}
+			count := kindCount[kind]
 			summary += itoa(count) + " " + kind
 			if count > 1 {
 				summary += "s"
 			}
-			first = false
 		}
 	}
 
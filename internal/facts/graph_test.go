@@ -1,6 +1,10 @@
 package facts
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -80,7 +84,7 @@ func TestNewGraph_BuildsAdjacencyLists(t *testing.T) {
 func TestTraverse_ForwardFromA(t *testing.T) {
 	g, _ := buildTestGraph()
 
-	result := g.Traverse("A", "forward", nil, nil, 10, 100)
+	result := g.Traverse(context.Background(), "A", "forward", nil, nil, 10, 100)
 
 	// A -> B -> C -> D, A -> E -> C (C already visited)
 	// Should visit: A, B, C, D, E
@@ -103,7 +107,7 @@ func TestTraverse_ForwardFromA(t *testing.T) {
 func TestTraverse_ReverseFromD(t *testing.T) {
 	g, _ := buildTestGraph()
 
-	result := g.Traverse("D", "reverse", nil, nil, 10, 100)
+	result := g.Traverse(context.Background(), "D", "reverse", nil, nil, 10, 100)
 
 	// D is called by C, C is called by B and E, B is called by A, E is imported by A
 	// Reverse from D: D <- C <- B <- A, C <- E <- A (A already visited)
@@ -118,7 +122,7 @@ func TestTraverse_ReverseFromD(t *testing.T) {
 func TestTraverse_DepthLimit(t *testing.T) {
 	g, _ := buildTestGraph()
 
-	result := g.Traverse("A", "forward", nil, nil, 1, 100)
+	result := g.Traverse(context.Background(), "A", "forward", nil, nil, 1, 100)
 
 	// Depth 1: A -> B, A -> E (only direct neighbors)
 	names := nodeNames(result.Nodes)
@@ -133,7 +137,7 @@ func TestTraverse_DepthLimit(t *testing.T) {
 func TestTraverse_MaxNodesLimit(t *testing.T) {
 	g, _ := buildTestGraph()
 
-	result := g.Traverse("A", "forward", nil, nil, 10, 3)
+	result := g.Traverse(context.Background(), "A", "forward", nil, nil, 10, 3)
 
 	// Should only return at most 3 nodes
 	if len(result.Nodes) > 3 {
@@ -148,7 +152,7 @@ func TestTraverse_RelationKindFilter(t *testing.T) {
 	g, _ := buildTestGraph()
 
 	// Only follow "calls" relations from A
-	result := g.Traverse("A", "forward", []string{RelCalls}, nil, 10, 100)
+	result := g.Traverse(context.Background(), "A", "forward", []string{RelCalls}, nil, 10, 100)
 
 	names := nodeNames(result.Nodes)
 	// A --calls-> B --calls-> C --calls-> D (imports to E skipped)
@@ -167,7 +171,7 @@ func TestTraverse_NodeKindFilter(t *testing.T) {
 
 	// Traverse from A but only include module-kind nodes in results
 	// C and E are modules, A/B/D are symbols
-	result := g.Traverse("A", "forward", nil, []string{KindModule}, 10, 100)
+	result := g.Traverse(context.Background(), "A", "forward", nil, []string{KindModule}, 10, 100)
 
 	names := nodeNames(result.Nodes)
 	// Should traverse through symbols but only include modules in result
@@ -181,10 +185,25 @@ func TestTraverse_NodeKindFilter(t *testing.T) {
 	}
 }
 
+func TestTraverseFunc_Include(t *testing.T) {
+	g, _ := buildTestGraph()
+
+	// Only nodes on files after b.go: C (c.go), D (d.go), E (e.go). B is
+	// traversed through, so D is still reached.
+	result := g.TraverseFunc(context.Background(), "A", "forward", nil, func(f Fact) bool {
+		return f.File > "b.go"
+	}, 10, 100)
+
+	names := nodeNames(result.Nodes)
+	if len(names) != 4 || !contains(names, "A") || !contains(names, "C") || !contains(names, "D") || !contains(names, "E") {
+		t.Errorf("nodes = %v, want A, C, D, E", names)
+	}
+}
+
 func TestTraverse_CycleHandling(t *testing.T) {
 	g, _ := buildCyclicGraph()
 
-	result := g.Traverse("A", "forward", nil, nil, 20, 100)
+	result := g.Traverse(context.Background(), "A", "forward", nil, nil, 20, 100)
 
 	// Should visit A, B, C without infinite loop
 	if result.Stats.NodesVisited != 3 {
@@ -201,7 +220,7 @@ func TestTraverse_CycleHandling(t *testing.T) {
 func TestTraverse_DisconnectedNode(t *testing.T) {
 	g, _ := buildTestGraph()
 
-	result := g.Traverse("F", "forward", nil, nil, 10, 100)
+	result := g.Traverse(context.Background(), "F", "forward", nil, nil, 10, 100)
 
 	// F is disconnected, should only return F itself
 	if len(result.Nodes) != 1 || result.Nodes[0].Name != "F" {
@@ -212,7 +231,7 @@ func TestTraverse_DisconnectedNode(t *testing.T) {
 func TestTraverse_NonexistentStart(t *testing.T) {
 	g, _ := buildTestGraph()
 
-	result := g.Traverse("NONEXISTENT", "forward", nil, nil, 10, 100)
+	result := g.Traverse(context.Background(), "NONEXISTENT", "forward", nil, nil, 10, 100)
 
 	// Should still return the start node (with no metadata)
 	if len(result.Nodes) != 1 || result.Nodes[0].Name != "NONEXISTENT" {
@@ -223,7 +242,7 @@ func TestTraverse_NonexistentStart(t *testing.T) {
 func TestFindPath_DirectConnection(t *testing.T) {
 	g, _ := buildTestGraph()
 
-	result := g.FindPath("A", "B", nil, 10)
+	result := g.FindPath(context.Background(), "A", "B", nil, 10)
 
 	if !result.Found {
 		t.Fatal("path A->B should be found")
@@ -239,7 +258,7 @@ func TestFindPath_DirectConnection(t *testing.T) {
 func TestFindPath_MultiHop(t *testing.T) {
 	g, _ := buildTestGraph()
 
-	result := g.FindPath("A", "D", nil, 10)
+	result := g.FindPath(context.Background(), "A", "D", nil, 10)
 
 	if !result.Found {
 		t.Fatal("path A->D should be found")
@@ -259,7 +278,7 @@ func TestFindPath_NoPath(t *testing.T) {
 	g, _ := buildTestGraph()
 
 	// F is disconnected
-	result := g.FindPath("A", "F", nil, 10)
+	result := g.FindPath(context.Background(), "A", "F", nil, 10)
 
 	if result.Found {
 		t.Error("path A->F should not exist")
@@ -272,7 +291,7 @@ func TestFindPath_NoPath(t *testing.T) {
 func TestFindPath_SameNode(t *testing.T) {
 	g, _ := buildTestGraph()
 
-	result := g.FindPath("A", "A", nil, 10)
+	result := g.FindPath(context.Background(), "A", "A", nil, 10)
 
 	if !result.Found {
 		t.Fatal("path A->A should be found (trivial)")
@@ -286,7 +305,7 @@ func TestFindPath_DepthLimit(t *testing.T) {
 	g, _ := buildTestGraph()
 
 	// A -> D needs 3 hops, limit to 2
-	result := g.FindPath("A", "D", nil, 2)
+	result := g.FindPath(context.Background(), "A", "D", nil, 2)
 
 	if result.Found {
 		t.Error("path A->D should not be found with maxDepth=2")
@@ -297,7 +316,7 @@ func TestFindPath_RelationKindFilter(t *testing.T) {
 	g, _ := buildTestGraph()
 
 	// Only imports: A --imports-> E, but no path from E to D via imports
-	result := g.FindPath("A", "D", []string{RelImports}, 10)
+	result := g.FindPath(context.Background(), "A", "D", []string{RelImports}, 10)
 
 	if result.Found {
 		t.Error("path A->D via imports only should not exist")
@@ -307,7 +326,7 @@ func TestFindPath_RelationKindFilter(t *testing.T) {
 func TestFindPath_WithCycle(t *testing.T) {
 	g, _ := buildCyclicGraph()
 
-	result := g.FindPath("A", "C", nil, 10)
+	result := g.FindPath(context.Background(), "A", "C", nil, 10)
 
 	if !result.Found {
 		t.Fatal("path A->C should be found")
@@ -321,7 +340,7 @@ func TestFindPath_WithCycle(t *testing.T) {
 func TestImpactSet_Basic(t *testing.T) {
 	g, _ := buildTestGraph()
 
-	result := g.ImpactSet("C", 10, 100, false)
+	result := g.ImpactSet(context.Background(), "C", 10, 100, false)
 
 	if result.Target != "C" {
 		t.Errorf("Target = %q, want C", result.Target)
@@ -360,7 +379,7 @@ func TestImpactSet_Basic(t *testing.T) {
 func TestImpactSet_WithForward(t *testing.T) {
 	g, _ := buildTestGraph()
 
-	result := g.ImpactSet("C", 10, 100, true)
+	result := g.ImpactSet(context.Background(), "C", 10, 100, true)
 
 	if result.Forward == nil {
 		t.Fatal("forward dependencies should be included")
@@ -377,7 +396,7 @@ func TestImpactSet_LeafNode(t *testing.T) {
 	g, _ := buildTestGraph()
 
 	// D has no dependents
-	result := g.ImpactSet("D", 10, 100, false)
+	result := g.ImpactSet(context.Background(), "D", 10, 100, false)
 
 	totalDependents := 0
 	for _, nodes := range result.ByDepth {
@@ -393,7 +412,7 @@ func TestImpactSet_LeafNode(t *testing.T) {
 func TestImpactSet_CycleHandling(t *testing.T) {
 	g, _ := buildCyclicGraph()
 
-	result := g.ImpactSet("A", 20, 100, false)
+	result := g.ImpactSet(context.Background(), "A", 20, 100, false)
 
 	// In a cycle A->B->C->A, impact of A is: B (depth 1 reverse from A via C->A),
 	// Actually reverse: who points TO A? C points to A. Who points to C? B. Who points to B? A (already visited)
@@ -435,6 +454,48 @@ func TestBuildGraph_ViaStore(t *testing.T) {
 	}
 }
 
+func TestBuildGraph_RelationKinds(t *testing.T) {
+	s := NewStore()
+	s.SetGraphRelationKinds([]string{RelImports})
+	s.Add(
+		Fact{Kind: KindModule, Name: "a", File: "a/a.go", Relations: []Relation{
+			{Kind: RelDeclares, Target: "a.Run"},
+			{Kind: RelImports, Target: "b"},
+		}},
+		Fact{Kind: KindSymbol, Name: "a.Run", File: "a/a.go", Relations: []Relation{
+			{Kind: RelCalls, Target: "b.Do"},
+		}},
+		Fact{Kind: KindModule, Name: "b", File: "b/b.go"},
+		Fact{Kind: KindSymbol, Name: "b.Do", File: "b/b.go"},
+	)
+	s.BuildGraph()
+
+	g := s.Graph()
+	if g.NodeCount() != 4 {
+		t.Errorf("NodeCount = %d, want 4 (excluded relations keep their nodes)", g.NodeCount())
+	}
+	if g.EdgeCount() != 1 {
+		t.Errorf("EdgeCount = %d, want 1", g.EdgeCount())
+	}
+	if edges := g.Forward()["a"]; len(edges) != 1 || edges[0].RelKind != RelImports {
+		t.Errorf("a forward edges = %+v, want only the imports edge", edges)
+	}
+	if res := g.FindPath(context.Background(), "a.Run", "b.Do", nil, 5); res.Found {
+		t.Error("found a calls path although calls are not ingested")
+	}
+
+	// Appended facts are filtered the same way.
+	start := s.Count()
+	s.Add(Fact{Kind: KindSymbol, Name: "b.Other", File: "b/other.go", Relations: []Relation{
+		{Kind: RelCalls, Target: "b.Do"},
+		{Kind: RelImports, Target: "a"},
+	}})
+	s.ExtendGraph(start)
+	if g := s.Graph(); g.EdgeCount() != 2 {
+		t.Errorf("EdgeCount after ExtendGraph = %d, want 2", g.EdgeCount())
+	}
+}
+
 func TestBuildGraph_ClearedByStoreClear(t *testing.T) {
 	s := NewStore()
 	s.Add(Fact{Kind: KindSymbol, Name: "X", File: "x.go"})
@@ -453,7 +514,7 @@ func TestTraverse_DefaultParameters(t *testing.T) {
 	g, _ := buildTestGraph()
 
 	// Test with zero values (should use defaults)
-	result := g.Traverse("A", "forward", nil, nil, 0, 0)
+	result := g.Traverse(context.Background(), "A", "forward", nil, nil, 0, 0)
 
 	// Default maxDepth=5, maxNodes=100
 	// Should still find all reachable nodes
@@ -467,7 +528,7 @@ func TestFindPath_DefaultMaxDepth(t *testing.T) {
 	g, _ := buildTestGraph()
 
 	// maxDepth=0 should use default (10)
-	result := g.FindPath("A", "D", nil, 0)
+	result := g.FindPath(context.Background(), "A", "D", nil, 0)
 
 	if !result.Found {
 		t.Error("should find path A->D with default maxDepth")
@@ -477,7 +538,7 @@ func TestFindPath_DefaultMaxDepth(t *testing.T) {
 func TestTraverse_EdgesAreRecorded(t *testing.T) {
 	g, _ := buildTestGraph()
 
-	result := g.Traverse("A", "forward", []string{RelCalls}, nil, 1, 100)
+	result := g.Traverse(context.Background(), "A", "forward", []string{RelCalls}, nil, 1, 100)
 
 	// A --calls-> B only (depth 1, calls only)
 	if len(result.Edges) != 1 {
@@ -494,7 +555,7 @@ func TestTraverse_EdgesAreRecorded(t *testing.T) {
 func TestFindPath_EdgesHaveCorrectKinds(t *testing.T) {
 	g, _ := buildTestGraph()
 
-	result := g.FindPath("A", "C", nil, 10)
+	result := g.FindPath(context.Background(), "A", "C", nil, 10)
 
 	if !result.Found {
 		t.Fatal("path should be found")
@@ -560,6 +621,43 @@ func TestNewGraph_DeduplicatesEdges(t *testing.T) {
 	}
 }
 
+func TestTraverse_CancelledContext(t *testing.T) {
+	g, _ := buildTestGraph()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := g.Traverse(ctx, "A", "forward", nil, nil, 10, 100)
+	if !result.Stats.TruncatedByCancel {
+		t.Error("expected TruncatedByCancel for a cancelled context")
+	}
+	// The start node is always present, even in a partial result.
+	if len(result.Nodes) != 1 || result.Nodes[0].Name != "A" {
+		t.Errorf("expected only the start node, got %v", nodeNames(result.Nodes))
+	}
+}
+
+func TestFindPath_CancelledContext(t *testing.T) {
+	g, _ := buildTestGraph()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := g.FindPath(ctx, "A", "D", nil, 10)
+	if result.Found || !result.TruncatedByCancel {
+		t.Errorf("expected cancelled search, got found=%v truncated_by_cancel=%v", result.Found, result.TruncatedByCancel)
+	}
+}
+
+func TestImpactSet_CancelledContext(t *testing.T) {
+	g, _ := buildTestGraph()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := g.ImpactSet(ctx, "C", 10, 100, false)
+	if !result.Stats.TruncatedByCancel {
+		t.Error("expected TruncatedByCancel for a cancelled context")
+	}
+}
+
 // --- helpers ---
 
 func nodeNames(nodes []TraversalNode) []string {
@@ -582,3 +680,218 @@ func contains(ss []string, s string) bool {
 	}
 	return false
 }
+
+func joinedNames(nodes []TraversalNode) string {
+	return strings.Join(nodeNames(nodes), ",")
+}
+
+func TestTraversalResult_Paginate(t *testing.T) {
+	g, _ := buildTestGraph()
+
+	// Forward from A: depth 0 A; depth 1 B, E; depth 2 C; depth 3 D.
+	result := g.Traverse(context.Background(), "A", "forward", nil, nil, 10, 100)
+	result.Paginate("", 1, 2)
+	if got := joinedNames(result.Nodes); got != "B,E" {
+		t.Errorf("depth order page = %s, want B,E", got)
+	}
+	if p := result.Page; p == nil || p.Total != 5 || !p.HasMore || p.SortBy != SortByDepth {
+		t.Errorf("page = %+v", result.Page)
+	}
+	for _, e := range result.Edges {
+		if e.Source != "B" && e.Source != "E" && e.Target != "B" && e.Target != "E" {
+			t.Errorf("edge %s->%s does not touch the page", e.Source, e.Target)
+		}
+	}
+
+	result = g.Traverse(context.Background(), "A", "forward", nil, nil, 10, 100)
+	result.Paginate(SortByName, 3, 10)
+	if got := joinedNames(result.Nodes); got != "D,E" || result.Page.HasMore {
+		t.Errorf("name order last page = %s, has_more %v", got, result.Page.HasMore)
+	}
+
+	result.Paginate(SortByName, 10, 5)
+	if len(result.Nodes) != 0 {
+		t.Errorf("offset past the end = %s, want none", joinedNames(result.Nodes))
+	}
+}
+
+func TestImpactResult_Paginate(t *testing.T) {
+	g, _ := buildTestGraph()
+
+	// Impact of D: depth 1 C; depth 2 B, E; depth 3 A.
+	result := g.ImpactSet(context.Background(), "D", 10, 100, false)
+	if got := joinedNames(result.ByDepth[2]); got != "B,E" {
+		t.Errorf("depth 2 = %s, want B,E sorted by name", got)
+	}
+	summary := result.Summary
+
+	result.Paginate(SortByDepth, 1, 2)
+	if got := joinedNames(result.ByDepth[2]); got != "B,E" || len(result.ByDepth[1]) != 0 || len(result.ByDepth[3]) != 0 {
+		t.Errorf("page by_depth = %v", result.ByDepth)
+	}
+	if result.Page.Total != 4 || !result.Page.HasMore {
+		t.Errorf("page = %+v", result.Page)
+	}
+	if result.Summary != summary {
+		t.Errorf("summary should describe the full impact set, got %q", result.Summary)
+	}
+}
+
+// incrementalFacts returns two batches of facts. The second adds a module
+// that changes how imports of the first resolve, a dependency fact importing
+// the first batch, and a relation duplicating one of the first batch.
+func incrementalFacts() (first, second []Fact) {
+	first = []Fact{
+		{Kind: KindModule, Name: "api", File: "api"},
+		{Kind: KindModule, Name: "store", File: "store"},
+		{Kind: KindDependency, Name: "api -> store/sql", File: "api/h.go", Relations: []Relation{
+			{Kind: RelImports, Target: "store/sql"},
+		}},
+		{Kind: KindSymbol, Name: "api.Get", File: "api/h.go", Relations: []Relation{
+			{Kind: RelDeclares, Target: "api"},
+			{Kind: RelCalls, Target: "store.Load"},
+		}},
+		{Kind: KindSymbol, Name: "store.Load", File: "store/load.go", Relations: []Relation{
+			{Kind: RelDeclares, Target: "store"},
+		}},
+	}
+	second = []Fact{
+		{Kind: KindModule, Name: "store/sql", File: "store/sql"},
+		{Kind: KindModule, Name: "cmd", File: "cmd"},
+		{Kind: KindDependency, Name: "cmd -> api", File: "cmd/main.go", Relations: []Relation{
+			{Kind: RelImports, Target: "api"},
+		}},
+		{Kind: KindSymbol, Name: "cmd.main", File: "cmd/main.go", Relations: []Relation{
+			{Kind: RelDeclares, Target: "cmd"},
+			{Kind: RelCalls, Target: "api.Get"},
+		}},
+		{Kind: KindSymbol, Name: "api.Put", File: "api/put.go", Relations: []Relation{
+			{Kind: RelDeclares, Target: "api"},
+			{Kind: RelCalls, Target: "store.Load"},
+		}},
+	}
+	return first, second
+}
+
+// edgeSet flattens an adjacency map into "source kind target weight" lines,
+// independent of edge order.
+func edgeSet(adj map[string][]Edge) map[string]int {
+	out := make(map[string]int)
+	for source, edges := range adj {
+		for _, e := range edges {
+			out[source+" "+e.RelKind+" "+e.Target] += e.Weight
+		}
+	}
+	return out
+}
+
+func assertSameGraph(t *testing.T, got, want *Graph) {
+	t.Helper()
+	for _, dir := range []struct {
+		name      string
+		got, want map[string][]Edge
+	}{
+		{"forward", got.Forward(), want.Forward()},
+		{"reverse", got.Reverse(), want.Reverse()},
+	} {
+		g, w := edgeSet(dir.got), edgeSet(dir.want)
+		for k, wv := range w {
+			if g[k] != wv {
+				t.Errorf("%s edge %q: weight %d, want %d", dir.name, k, g[k], wv)
+			}
+		}
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				t.Errorf("%s edge %q: unexpected", dir.name, k)
+			}
+		}
+	}
+	if got.NodeCount() != want.NodeCount() {
+		t.Errorf("NodeCount = %d, want %d", got.NodeCount(), want.NodeCount())
+	}
+	for name := range want.factIdx {
+		if got.nodeFor(name, 0) != want.nodeFor(name, 0) {
+			t.Errorf("node %q = %+v, want %+v", name, got.nodeFor(name, 0), want.nodeFor(name, 0))
+		}
+	}
+}
+
+func TestGraph_AddFactsMatchesRebuild(t *testing.T) {
+	first, second := incrementalFacts()
+
+	g := NewGraph(first)
+	// Before store/sql exists, the api import resolves to its parent module.
+	if g.EdgeWeight("api", "store") != 1 {
+		t.Fatalf("expected api -> store before the second batch")
+	}
+	g.AddFacts(second)
+
+	assertSameGraph(t, g, NewGraph(append(append([]Fact{}, first...), second...)))
+	if g.EdgeWeight("api", "store") != 0 || g.EdgeWeight("api", "store/sql") != 1 {
+		t.Error("expected the api import to re-resolve to store/sql")
+	}
+	if g.EdgeWeight("api.Put", "store.Load") != 1 {
+		t.Error("expected api.Put -> store.Load")
+	}
+}
+
+func TestGraph_RemoveByFileMatchesRebuild(t *testing.T) {
+	first, second := incrementalFacts()
+	all := append(append([]Fact{}, first...), second...)
+
+	for _, file := range []string{"cmd/main.go", "store/sql", "api/h.go", "store/load.go"} {
+		t.Run(file, func(t *testing.T) {
+			g := NewGraph(all)
+			var rest []Fact
+			for _, f := range all {
+				if f.File != file {
+					rest = append(rest, f)
+				}
+			}
+			if got := g.RemoveByFile(file); got != len(all)-len(rest) {
+				t.Errorf("RemoveByFile = %d, want %d", got, len(all)-len(rest))
+			}
+			assertSameGraph(t, g, NewGraph(rest))
+		})
+	}
+
+	if NewGraph(all).RemoveByFile("missing.go") != 0 {
+		t.Error("removing an unknown file should remove nothing")
+	}
+}
+
+func TestGraph_AdjacencyCopiesSafeWhileExtending(t *testing.T) {
+	s := NewStore()
+	s.Add(Fact{Kind: KindModule, Name: "a"}, Fact{Kind: KindModule, Name: "b", Relations: []Relation{{Kind: RelImports, Target: "a"}}})
+	s.BuildGraph()
+	g := s.Graph()
+
+	fwd := g.Forward()
+	fwd["b"][0].Weight = 99
+	delete(fwd, "b")
+	if edges := g.OutEdges("b"); len(edges) != 1 || edges[0].Weight != 1 {
+		t.Errorf("OutEdges(b) = %+v after mutating the Forward copy, want the original edge", edges)
+	}
+	if edges := g.InEdges("a"); len(edges) != 1 || edges[0].Target != "b" {
+		t.Errorf("InEdges(a) = %+v, want one edge from b", edges)
+	}
+
+	// Readers run alongside ExtendGraph; go test -race flags shared maps.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 200 {
+			for name, out := range g.Forward() {
+				_ = len(out) + len(g.InEdges(name))
+			}
+			_ = g.Reverse()
+		}
+	}()
+	for i := range 200 {
+		start := s.Count()
+		s.Add(Fact{Kind: KindSymbol, Name: fmt.Sprintf("b.F%d", i), Relations: []Relation{{Kind: RelCalls, Target: "a"}, {Kind: RelImports, Target: "a"}}})
+		s.ExtendGraph(start)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,224 @@
+package facts
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// hierarchyRelKinds are the relations a type hierarchy is built from: a
+// type implements (or extends, or mixes in) its supertypes, and a Go struct
+// or interface embeds the types it is composed of.
+var hierarchyRelKinds = map[string]bool{RelImplements: true, RelEmbeds: true}
+
+// HierarchyNode is a type in a hierarchy tree. Via is the relation linking
+// it to its parent in the tree, and Children holds the next level away from
+// the root: supertypes in Ancestors, subtypes in Descendants. A type reached
+// again through another branch (a diamond) is listed with Repeated set and
+// without children, which its first occurrence already holds.
+type HierarchyNode struct {
+	Name     string          `json:"name"`
+	Kind     string          `json:"kind,omitempty"`
+	File     string          `json:"file,omitempty"`
+	Line     int             `json:"line,omitempty"`
+	Via      string          `json:"via"`
+	Repeated bool            `json:"repeated,omitempty"`
+	Children []HierarchyNode `json:"children,omitempty"`
+}
+
+// HierarchyResult is the type hierarchy around one type, returned by
+// Hierarchy.
+type HierarchyResult struct {
+	Type        TraversalNode   `json:"type"`
+	Ancestors   []HierarchyNode `json:"ancestors"`   // supertypes, each with its own supertypes as children
+	Descendants []HierarchyNode `json:"descendants"` // subtypes, each with its own subtypes as children
+	// AncestorCount and DescendantCount count the distinct types in each
+	// tree.
+	AncestorCount   int  `json:"ancestor_count"`
+	DescendantCount int  `json:"descendant_count"`
+	Truncated       bool `json:"truncated"` // maxDepth cut a branch short
+	// TruncatedByCancel is set when the context was cancelled before the
+	// trees were complete.
+	TruncatedByCancel bool `json:"truncated_by_cancel,omitempty"`
+}
+
+// Hierarchy returns the supertype chain of name, following implements and
+// embeds relations forward, and all of its known subtypes, following them in
+// reverse, as trees rooted at name. Supertypes outside the repository (e.g.
+// a framework base class) appear as nodes without a kind or file. maxDepth
+// bounds each tree (0 = use default 10, capped at 20). Siblings are sorted
+// by name.
+//
+// Most extractors record supertypes by the name written in the source
+// (implements → "BaseService"), not by fact name ("src/base.BaseService").
+// Such targets are matched to a type fact by simple name, preferring a type
+// in the subtype's own directory and otherwise a unique one, in both
+// directions.
+func (g *Graph) Hierarchy(ctx context.Context, name string, maxDepth int) HierarchyResult {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if maxDepth <= 0 {
+		maxDepth = 10
+	}
+	if maxDepth > 20 {
+		maxDepth = 20
+	}
+
+	result := HierarchyResult{Type: g.nodeFor(name, 0)}
+	types := g.newTypeResolver()
+
+	var expand func(next func(string) []Edge, node string, depth int, seen map[string]bool) []HierarchyNode
+	expand = func(next func(string) []Edge, node string, depth int, seen map[string]bool) []HierarchyNode {
+		if ctx.Err() != nil {
+			result.TruncatedByCancel = true
+			return nil
+		}
+		var children []HierarchyNode
+		for _, e := range next(node) {
+			if depth >= maxDepth {
+				result.Truncated = true
+				break
+			}
+			f := g.factFor(e.Target)
+			child := HierarchyNode{Name: e.Target, Kind: f.Kind, File: f.File, Line: f.Line, Via: e.RelKind}
+			if seen[e.Target] {
+				child.Repeated = true
+			} else {
+				seen[e.Target] = true
+				child.Children = expand(next, e.Target, depth+1, seen)
+			}
+			children = append(children, child)
+		}
+		sort.SliceStable(children, func(i, j int) bool { return children[i].Name < children[j].Name })
+		return children
+	}
+
+	up := map[string]bool{name: true}
+	result.Ancestors = expand(types.supertypes, name, 0, up)
+	if result.Ancestors == nil {
+		result.Ancestors = []HierarchyNode{}
+	}
+	result.AncestorCount = len(up) - 1
+
+	down := map[string]bool{name: true}
+	result.Descendants = expand(types.subtypes, name, 0, down)
+	if result.Descendants == nil {
+		result.Descendants = []HierarchyNode{}
+	}
+	result.DescendantCount = len(down) - 1
+
+	return result
+}
+
+// typeResolver resolves the supertype names of hierarchy relations to type
+// facts. Callers hold the graph's read lock.
+type typeResolver struct {
+	g       *Graph
+	byShort map[string][]string // simple name → type facts
+	bare    map[string][]string // simple name → relation targets that are not facts
+}
+
+func (g *Graph) newTypeResolver() *typeResolver {
+	r := &typeResolver{g: g, byShort: make(map[string][]string), bare: make(map[string][]string)}
+	for name, idx := range g.factIdx {
+		if idx < len(g.facts) && isTypeFact(g.facts[idx]) {
+			short := simpleTypeName(name)
+			r.byShort[short] = append(r.byShort[short], name)
+		}
+	}
+	for target := range g.reverse {
+		if _, ok := g.factIdx[target]; !ok {
+			short := simpleTypeName(target)
+			r.bare[short] = append(r.bare[short], target)
+		}
+	}
+	for _, names := range r.byShort {
+		sort.Strings(names)
+	}
+	return r
+}
+
+// resolve returns the type fact a hierarchy relation of from to target
+// refers to, or target itself when it is a fact name or matches none.
+func (r *typeResolver) resolve(from, target string) string {
+	if _, ok := r.g.factIdx[target]; ok {
+		return target
+	}
+	candidates := r.byShort[simpleTypeName(target)]
+	dir := filepath.Dir(r.g.factFor(from).File)
+	for _, c := range candidates {
+		if filepath.Dir(r.g.factFor(c).File) == dir {
+			return c
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	return target
+}
+
+// supertypes returns the hierarchy edges of node, with resolved targets.
+func (r *typeResolver) supertypes(node string) []Edge {
+	var out []Edge
+	seen := make(map[string]bool)
+	for _, e := range r.g.forward[node] {
+		if !hierarchyRelKinds[e.RelKind] {
+			continue
+		}
+		e.Target = r.resolve(node, e.Target)
+		if key := e.RelKind + "\x00" + e.Target; !seen[key] {
+			seen[key] = true
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// subtypes returns the hierarchy edges into node, reversed: those naming it
+// in full, and those naming it by a simple name that resolves to it.
+func (r *typeResolver) subtypes(node string) []Edge {
+	var out []Edge
+	seen := make(map[string]bool)
+	add := func(target string) {
+		for _, e := range r.g.reverse[target] {
+			if !hierarchyRelKinds[e.RelKind] || r.resolve(e.Target, target) != node {
+				continue
+			}
+			if key := e.RelKind + "\x00" + e.Target; !seen[key] {
+				seen[key] = true
+				out = append(out, e)
+			}
+		}
+	}
+	add(node)
+	if _, ok := r.g.factIdx[node]; ok {
+		for _, target := range r.bare[simpleTypeName(node)] {
+			add(target)
+		}
+	}
+	return out
+}
+
+// isTypeFact reports whether f can be a supertype: a symbol that is not a
+// function, method, variable or constant.
+func isTypeFact(f Fact) bool {
+	if f.Kind != KindSymbol {
+		return false
+	}
+	switch f.Props["symbol_kind"] {
+	case SymbolFunc, SymbolMethod, SymbolVariable, SymbolConstant:
+		return false
+	}
+	return true
+}
+
+// simpleTypeName strips the qualifier and type arguments of a type name:
+// "src/base.BaseService<T>" gives "BaseService".
+func simpleTypeName(name string) string {
+	if i := strings.Index(name, "<"); i >= 0 {
+		name = name[:i]
+	}
+	return name[strings.LastIndex(name, ".")+1:]
+}
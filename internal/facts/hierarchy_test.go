@@ -0,0 +1,109 @@
+package facts
+
+import (
+	"context"
+	"testing"
+)
+
+func hierarchyStore() *Store {
+	s := NewStore()
+	s.Add(
+		Fact{Kind: KindSymbol, Name: "shapes.Shape", File: "shapes/shape.kt", Line: 1},
+		Fact{Kind: KindSymbol, Name: "shapes.Polygon", File: "shapes/polygon.kt", Line: 1, Relations: []Relation{
+			{Kind: RelImplements, Target: "shapes.Shape"},
+			{Kind: RelImplements, Target: "Comparable"},
+		}},
+		Fact{Kind: KindSymbol, Name: "shapes.Square", File: "shapes/square.kt", Line: 3, Relations: []Relation{
+			{Kind: RelImplements, Target: "shapes.Polygon"},
+			{Kind: RelImplements, Target: "shapes.Shape"},
+			{Kind: RelCalls, Target: "shapes.Unrelated"},
+		}},
+		Fact{Kind: KindSymbol, Name: "shapes.Triangle", File: "shapes/triangle.kt", Line: 3, Relations: []Relation{
+			{Kind: RelImplements, Target: "shapes.Polygon"},
+		}},
+		Fact{Kind: KindSymbol, Name: "shapes.Circle", File: "shapes/circle.kt", Line: 3, Relations: []Relation{
+			{Kind: RelEmbeds, Target: "shapes.Shape"},
+		}},
+		Fact{Kind: KindSymbol, Name: "shapes.Unrelated", File: "shapes/unrelated.kt", Relations: []Relation{
+			{Kind: RelCalls, Target: "shapes.Shape"},
+		}},
+	)
+	s.BuildGraph()
+	return s
+}
+
+func TestHierarchy_AncestorsAndDescendants(t *testing.T) {
+	g := hierarchyStore().Graph()
+
+	square := g.Hierarchy(context.Background(), "shapes.Square", 0)
+	if square.Type.Name != "shapes.Square" || square.Type.File != "shapes/square.kt" {
+		t.Errorf("type = %+v", square.Type)
+	}
+	if len(square.Ancestors) != 2 || square.Ancestors[0].Name != "shapes.Polygon" || square.Ancestors[1].Name != "shapes.Shape" {
+		t.Fatalf("ancestors = %+v, want Polygon and Shape", square.Ancestors)
+	}
+	polygon := square.Ancestors[0]
+	if len(polygon.Children) != 2 || polygon.Children[0].Name != "Comparable" || polygon.Children[0].Kind != "" {
+		t.Errorf("Polygon supertypes = %+v, want the external Comparable first", polygon.Children)
+	}
+	// Shape is reached through Polygon first, then again directly.
+	if shape := polygon.Children[1]; shape.Name != "shapes.Shape" || shape.Repeated {
+		t.Errorf("Shape under Polygon = %+v, want its first occurrence", shape)
+	}
+	if !square.Ancestors[1].Repeated || square.AncestorCount != 3 {
+		t.Errorf("direct Shape = %+v, ancestor count %d; want a repeated node of 3 ancestors", square.Ancestors[1], square.AncestorCount)
+	}
+	if len(square.Descendants) != 0 {
+		t.Errorf("Square descendants = %+v, want none", square.Descendants)
+	}
+
+	shape := g.Hierarchy(context.Background(), "shapes.Shape", 0)
+	if shape.DescendantCount != 4 {
+		t.Errorf("Shape descendant count = %d, want Polygon, Square, Triangle and Circle", shape.DescendantCount)
+	}
+	var names []string
+	for _, d := range shape.Descendants {
+		names = append(names, d.Name+"/"+d.Via)
+	}
+	if len(names) != 3 || names[0] != "shapes.Circle/embeds" || names[1] != "shapes.Polygon/implements" || names[2] != "shapes.Square/implements" {
+		t.Errorf("Shape direct subtypes = %v, want Circle, Polygon and Square (calls ignored)", names)
+	}
+}
+
+func TestHierarchy_MaxDepth(t *testing.T) {
+	g := hierarchyStore().Graph()
+
+	result := g.Hierarchy(context.Background(), "shapes.Shape", 1)
+	if !result.Truncated {
+		t.Error("depth 1 should truncate Polygon's subtypes")
+	}
+	for _, d := range result.Descendants {
+		if len(d.Children) != 0 {
+			t.Errorf("%s has children %+v beyond max depth 1", d.Name, d.Children)
+		}
+	}
+}
+
+func TestHierarchy_ResolvesSimpleNames(t *testing.T) {
+	s := NewStore()
+	s.Add(
+		Fact{Kind: KindSymbol, Name: "a.Base", File: "a/base.ts", Props: map[string]any{"symbol_kind": SymbolClass}},
+		Fact{Kind: KindSymbol, Name: "b.Base", File: "b/base.ts", Props: map[string]any{"symbol_kind": SymbolClass}},
+		// Same directory as a.Base: resolves to it.
+		Fact{Kind: KindSymbol, Name: "a.Child", File: "a/child.ts", Relations: []Relation{{Kind: RelImplements, Target: "Base"}}},
+		// Two candidates elsewhere: left unresolved.
+		Fact{Kind: KindSymbol, Name: "c.Other", File: "c/other.ts", Relations: []Relation{{Kind: RelImplements, Target: "Base"}}},
+	)
+	s.BuildGraph()
+	g := s.Graph()
+
+	if r := g.Hierarchy(context.Background(), "a.Base", 0); r.DescendantCount != 1 || r.Descendants[0].Name != "a.Child" {
+		t.Errorf("a.Base descendants = %+v, want only a.Child", r.Descendants)
+	}
+	if r := g.Hierarchy(context.Background(), "b.Base", 0); r.DescendantCount != 0 {
+		t.Errorf("b.Base descendants = %+v, want none", r.Descendants)
+	}
+	if r := g.Hierarchy(context.Background(), "c.Other", 0); len(r.Ancestors) != 1 || r.Ancestors[0].Name != "Base" || r.Ancestors[0].Kind != "" {
+		t.Errorf("c.Other ancestors = %+v, want the unresolved Base", r.Ancestors)
+	}
+}
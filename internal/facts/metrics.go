@@ -0,0 +1,199 @@
+package facts
+
+import (
+	"math"
+	"sort"
+)
+
+// Coupling holds module-level import counts.
+type Coupling struct {
+	FanIn  int `json:"fan_in"`  // imports of this module from other files
+	FanOut int `json:"fan_out"` // imports from this module's files to other modules
+}
+
+// ModuleCoupling computes fan-in and fan-out for every module in ff. A
+// dependency fact contributes to the module containing its file (fan-out) and
+// to the imported module (fan-in); imports of targets that are not known
+// modules are ignored. Modules with no internal imports are omitted.
+func ModuleCoupling(ff []Fact) map[string]Coupling {
+	modules := make(map[string]bool)
+	for _, f := range ff {
+		if f.Kind == KindModule {
+			modules[f.Name] = true
+		}
+	}
+
+	result := make(map[string]Coupling)
+	for _, dep := range ff {
+		if dep.Kind != KindDependency {
+			continue
+		}
+		sourceModule := fileDirectory(dep.File)
+		for _, rel := range dep.Relations {
+			if rel.Kind != RelImports || !modules[rel.Target] {
+				continue
+			}
+			src := result[sourceModule]
+			src.FanOut++
+			result[sourceModule] = src
+
+			tgt := result[rel.Target]
+			tgt.FanIn++
+			result[rel.Target] = tgt
+		}
+	}
+	return result
+}
+
+// ModuleRank pairs a module name with a count, used for top-N listings.
+type ModuleRank struct {
+	Module string `json:"module"`
+	Count  int    `json:"count"`
+}
+
+// Metrics is a whole-repo summary of the fact set.
+type Metrics struct {
+	TotalFacts        int             `json:"total_facts"`
+	FactsByKind       map[string]int  `json:"facts_by_kind"`
+	SymbolsByLanguage map[string]int  `json:"symbols_by_language"`
+	Modules           int             `json:"modules"`
+	Routes            int             `json:"routes"`
+	StorageItems      int             `json:"storage_items"`
+	Cycles            int             `json:"cycles"`
+	AvgFanIn          float64         `json:"avg_fan_in"`
+	AvgFanOut         float64         `json:"avg_fan_out"`
+	MostDependedOn    []ModuleRank    `json:"most_depended_on"`
+	Tests             int             `json:"tests"`                     // test symbols, not counting suites
+	TestsByModule     map[string]int  `json:"tests_by_module,omitempty"` // by the directory of the test's file
+	Frameworks        []FrameworkInfo `json:"frameworks,omitempty"`
+	ExtractorStats    []ExtractorStat `json:"extractor_stats,omitempty"`
+}
+
+// ComputeMetrics summarizes ff. Averages are taken over all modules, including
+// those without internal imports. MostDependedOn lists up to topN modules by
+// fan-in. Cycles, Frameworks and ExtractorStats are left for the caller,
+// since they come from explainer output and the snapshot meta rather than
+// facts.
+func ComputeMetrics(ff []Fact, topN int) Metrics {
+	m := Metrics{
+		TotalFacts:        len(ff),
+		FactsByKind:       make(map[string]int),
+		SymbolsByLanguage: make(map[string]int),
+	}
+	for _, f := range ff {
+		m.FactsByKind[f.Kind]++
+		switch f.Kind {
+		case KindModule:
+			m.Modules++
+		case KindRoute:
+			m.Routes++
+		case KindStorage:
+			m.StorageItems++
+		case KindSymbol:
+			lang, _ := f.Props["language"].(string)
+			if lang == "" {
+				lang = "unknown"
+			}
+			m.SymbolsByLanguage[lang]++
+			if isTest, _ := f.Props["test"].(bool); isTest && f.Props["test_kind"] != "suite" {
+				if m.TestsByModule == nil {
+					m.TestsByModule = make(map[string]int)
+				}
+				m.Tests++
+				m.TestsByModule[fileDirectory(f.File)]++
+			}
+		}
+	}
+
+	coupling := ModuleCoupling(ff)
+	ranks := make([]ModuleRank, 0, len(coupling))
+	var fanIn, fanOut int
+	for mod, c := range coupling {
+		fanIn += c.FanIn
+		fanOut += c.FanOut
+		if c.FanIn > 0 {
+			ranks = append(ranks, ModuleRank{Module: mod, Count: c.FanIn})
+		}
+	}
+	if m.Modules > 0 {
+		m.AvgFanIn = roundTo2(float64(fanIn) / float64(m.Modules))
+		m.AvgFanOut = roundTo2(float64(fanOut) / float64(m.Modules))
+	}
+
+	sort.Slice(ranks, func(i, j int) bool {
+		if ranks[i].Count != ranks[j].Count {
+			return ranks[i].Count > ranks[j].Count
+		}
+		return ranks[i].Module < ranks[j].Module
+	})
+	if len(ranks) > topN {
+		ranks = ranks[:topN]
+	}
+	m.MostDependedOn = ranks
+	return m
+}
+
+// ModuleMetrics summarizes one module for side-by-side comparison.
+type ModuleMetrics struct {
+	Module   string `json:"module"`
+	Files    int    `json:"files"`    // distinct files with facts in the module
+	Symbols  int    `json:"symbols"`  // symbol facts declared in the module's files
+	Exported int    `json:"exported"` // symbols with exported: true
+	FanIn    int    `json:"fan_in"`
+	FanOut   int    `json:"fan_out"`
+	InCycle  bool   `json:"in_cycle"` // part of a module dependency cycle
+}
+
+// ComputeModuleMetrics returns the metrics of each named module, in order;
+// a module named twice gets the same metrics in both slots. A fact belongs
+// to the module of its file's directory, the same rule ModuleCoupling uses
+// for fan-out. InCycle is left for the caller, since cycles come from
+// explainer output rather than facts.
+func ComputeModuleMetrics(ff []Fact, modules ...string) []ModuleMetrics {
+	index := make(map[string]int, len(modules))
+	var result []ModuleMetrics
+	for _, mod := range modules {
+		if _, ok := index[mod]; !ok {
+			index[mod] = len(result)
+			result = append(result, ModuleMetrics{Module: mod})
+		}
+	}
+
+	files := make([]map[string]bool, len(result))
+	for _, f := range ff {
+		if f.Kind == KindModule || f.File == "" {
+			continue
+		}
+		i, ok := index[fileDirectory(f.File)]
+		if !ok {
+			continue
+		}
+		if files[i] == nil {
+			files[i] = make(map[string]bool)
+		}
+		files[i][f.File] = true
+		if f.Kind == KindSymbol {
+			result[i].Symbols++
+			if exported, _ := f.Props["exported"].(bool); exported {
+				result[i].Exported++
+			}
+		}
+	}
+
+	coupling := ModuleCoupling(ff)
+	for i := range result {
+		result[i].Files = len(files[i])
+		result[i].FanIn = coupling[result[i].Module].FanIn
+		result[i].FanOut = coupling[result[i].Module].FanOut
+	}
+
+	ordered := make([]ModuleMetrics, len(modules))
+	for i, mod := range modules {
+		ordered[i] = result[index[mod]]
+	}
+	return ordered
+}
+
+func roundTo2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
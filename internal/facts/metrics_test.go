@@ -0,0 +1,123 @@
+package facts
+
+import "testing"
+
+func TestModuleCoupling(t *testing.T) {
+	ff := []Fact{
+		{Kind: KindModule, Name: "internal/a"},
+		{Kind: KindModule, Name: "internal/b"},
+		{Kind: KindModule, Name: "internal/c"},
+		{Kind: KindDependency, Name: "internal/a -> internal/b", File: "internal/a/a.go",
+			Relations: []Relation{{Kind: RelImports, Target: "internal/b"}}},
+		{Kind: KindDependency, Name: "internal/c -> internal/b", File: "internal/c/c.go",
+			Relations: []Relation{{Kind: RelImports, Target: "internal/b"}}},
+		// External import: not a known module, ignored.
+		{Kind: KindDependency, Name: "internal/a -> fmt", File: "internal/a/a.go",
+			Relations: []Relation{{Kind: RelImports, Target: "fmt"}}},
+	}
+
+	got := ModuleCoupling(ff)
+
+	if c := got["internal/b"]; c.FanIn != 2 || c.FanOut != 0 {
+		t.Errorf("internal/b = %+v, want fan-in 2, fan-out 0", c)
+	}
+	if c := got["internal/a"]; c.FanIn != 0 || c.FanOut != 1 {
+		t.Errorf("internal/a = %+v, want fan-in 0, fan-out 1", c)
+	}
+	if _, ok := got["fmt"]; ok {
+		t.Error("external import target should not appear in coupling map")
+	}
+}
+
+func TestComputeMetrics(t *testing.T) {
+	ff := []Fact{
+		{Kind: KindModule, Name: "internal/a"},
+		{Kind: KindModule, Name: "internal/b"},
+		{Kind: KindSymbol, Name: "internal/a.Run", Props: map[string]any{"language": "go"}},
+		{Kind: KindSymbol, Name: "internal/b.Load", Props: map[string]any{"language": "go"}},
+		{Kind: KindSymbol, Name: "web/app", Props: map[string]any{"language": "typescript"}},
+		{Kind: KindRoute, Name: "GET /health"},
+		{Kind: KindStorage, Name: "users"},
+		{Kind: KindDependency, Name: "internal/a -> internal/b", File: "internal/a/a.go",
+			Relations: []Relation{{Kind: RelImports, Target: "internal/b"}}},
+	}
+
+	m := ComputeMetrics(ff, 10)
+
+	if m.TotalFacts != len(ff) {
+		t.Errorf("TotalFacts = %d, want %d", m.TotalFacts, len(ff))
+	}
+	if m.FactsByKind[KindSymbol] != 3 || m.Modules != 2 || m.Routes != 1 || m.StorageItems != 1 {
+		t.Errorf("unexpected counts: %+v", m)
+	}
+	if m.SymbolsByLanguage["go"] != 2 || m.SymbolsByLanguage["typescript"] != 1 {
+		t.Errorf("SymbolsByLanguage = %v", m.SymbolsByLanguage)
+	}
+	if m.AvgFanIn != 0.5 || m.AvgFanOut != 0.5 {
+		t.Errorf("avg fan-in/out = %v/%v, want 0.5/0.5", m.AvgFanIn, m.AvgFanOut)
+	}
+	if len(m.MostDependedOn) != 1 || m.MostDependedOn[0].Module != "internal/b" || m.MostDependedOn[0].Count != 1 {
+		t.Errorf("MostDependedOn = %+v", m.MostDependedOn)
+	}
+}
+
+func TestComputeModuleMetrics(t *testing.T) {
+	ff := []Fact{
+		{Kind: KindModule, Name: "internal/a", File: "internal/a"},
+		{Kind: KindModule, Name: "internal/b", File: "internal/b"},
+		{Kind: KindSymbol, Name: "internal/a.Run", File: "internal/a/run.go", Props: map[string]any{"exported": true}},
+		{Kind: KindSymbol, Name: "internal/a.helper", File: "internal/a/run.go", Props: map[string]any{"exported": false}},
+		{Kind: KindSymbol, Name: "internal/a.Config", File: "internal/a/config.go", Props: map[string]any{"exported": true}},
+		{Kind: KindSymbol, Name: "internal/b.Load", File: "internal/b/load.go", Props: map[string]any{"exported": true}},
+		// A subdirectory is its own module.
+		{Kind: KindSymbol, Name: "internal/a/sub.X", File: "internal/a/sub/x.go"},
+		{Kind: KindDependency, Name: "internal/a -> internal/b", File: "internal/a/run.go",
+			Relations: []Relation{{Kind: RelImports, Target: "internal/b"}}},
+	}
+
+	got := ComputeModuleMetrics(ff, "internal/a", "internal/b")
+	want := []ModuleMetrics{
+		{Module: "internal/a", Files: 2, Symbols: 3, Exported: 2, FanIn: 0, FanOut: 1},
+		{Module: "internal/b", Files: 1, Symbols: 1, Exported: 1, FanIn: 1, FanOut: 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d results, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("metrics[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// A module named twice fills both slots.
+	got = ComputeModuleMetrics(ff, "internal/a", "internal/a")
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[0] {
+		t.Errorf("same module twice = %+v, want %+v in both slots", got, want[0])
+	}
+}
+
+func TestComputeMetrics_Tests(t *testing.T) {
+	test := func(name, file, kind string) Fact {
+		return Fact{Kind: KindSymbol, Name: name, File: file,
+			Props: map[string]any{"test": true, "test_kind": kind}}
+	}
+	ff := []Fact{
+		{Kind: KindSymbol, Name: "internal/a.Run", File: "internal/a/a.go", Props: map[string]any{"language": "go"}},
+		test("internal/a.TestRun", "internal/a/a_test.go", "test"),
+		test("internal/a.BenchmarkRun", "internal/a/a_test.go", "benchmark"),
+		test("web/user.test.ts > User", "web/user.test.ts", "suite"),
+		test("web/user.test.ts > User > saves", "web/user.test.ts", "test"),
+	}
+
+	m := ComputeMetrics(ff, 10)
+	if m.Tests != 3 {
+		t.Errorf("Tests = %d, want 3 (suites are not counted)", m.Tests)
+	}
+	if m.TestsByModule["internal/a"] != 2 || m.TestsByModule["web"] != 1 || len(m.TestsByModule) != 2 {
+		t.Errorf("TestsByModule = %v", m.TestsByModule)
+	}
+
+	if m := ComputeMetrics(ff[:1], 10); m.Tests != 0 || m.TestsByModule != nil {
+		t.Errorf("without tests: Tests = %d, TestsByModule = %v", m.Tests, m.TestsByModule)
+	}
+}
@@ -1,8 +1,16 @@
 package facts
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
 // Fact represents a language-agnostic architectural fact extracted from source code.
 type Fact struct {
-	Kind      string         `json:"kind"`                // e.g. "module", "symbol", "route", "storage", "dependency"
+	ID        string         `json:"id,omitempty"`        // Stable identity, set by the store from ComputeID
+	Kind      string         `json:"kind"`                // e.g. "module", "symbol", "route", "storage", "dependency", "config"
 	Name      string         `json:"name"`                // Canonical name
 	File      string         `json:"file,omitempty"`      // Source file (relative to repo root, or repo-prefixed in multi-repo mode)
 	Line      int            `json:"line,omitempty"`      // Line number in file
@@ -11,6 +19,18 @@ type Fact struct {
 	Relations []Relation     `json:"relations,omitempty"` // Edges to other facts
 }
 
+// ComputeID returns the stable identity of f: a hash of its kind, name,
+// file and line. Facts differing only in props, relations or repo share an
+// ID.
+func (f Fact) ComputeID() string {
+	h := sha256.New()
+	for _, part := range []string{f.Kind, f.Name, f.File, strconv.Itoa(f.Line)} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
 // Relation represents a directed edge between two facts.
 type Relation struct {
 	Kind   string `json:"kind"`   // e.g. "declares", "imports", "calls", "implements", "depends_on"
@@ -24,6 +44,7 @@ const (
 	KindRoute      = "route"
 	KindStorage    = "storage"
 	KindDependency = "dependency"
+	KindConfig     = "config" // configuration the code reads, such as an environment variable
 )
 
 // Relation kind constants.
@@ -33,8 +54,20 @@ const (
 	RelCalls      = "calls"
 	RelImplements = "implements"
 	RelDependsOn  = "depends_on"
+	RelEmbeds     = "embeds"      // struct or interface embedding (Go)
+	RelOverrides  = "overrides"   // method -> the supertype method it overrides
+	RelTestedBy   = "tested_by"   // production symbol -> test function referencing it
+	RelReadBy     = "read_by"     // config fact -> module of a file that reads it
+	RelPublishes  = "publishes"   // function -> message topic or queue it publishes to
+	RelConsumedBy = "consumed_by" // message topic or queue -> function consuming it
 )
 
+// RelationKinds lists every relation kind, for validating user input.
+var RelationKinds = []string{
+	RelDeclares, RelImports, RelCalls, RelImplements, RelDependsOn, RelEmbeds,
+	RelOverrides, RelTestedBy, RelReadBy, RelPublishes, RelConsumedBy,
+}
+
 // Symbol kind property values.
 const (
 	SymbolFunc      = "function"
@@ -47,6 +80,54 @@ const (
 	SymbolConstant  = "constant"
 )
 
+// Entry point property values, recorded in Props["entry_point"] on symbols
+// through which execution enters the program. Extractors set the
+// framework-specific ones; main and handler are filled in for every language
+// by extractors.MarkEntryPoints.
+const (
+	EntryMain             = "main"              // program main function
+	EntryHandler          = "handler"           // exported handler/serve function
+	EntryApp              = "app"               // SwiftUI @main App
+	EntryControllerAction = "controller_action" // public action of a Rails controller
+	EntryActivity         = "activity"          // Android Activity with an intent filter
+	EntrySpringBootApp    = "spring_boot_app"   // @SpringBootApplication class
+	EntryCobraCommand     = "cobra_command"     // Cobra CLI command
+)
+
+// IsPublic reports whether f is part of the public API surface: a fact with
+// an exported: true prop, or a route or storage fact, which are public by
+// nature.
+func IsPublic(f Fact) bool {
+	if f.Kind == KindRoute || f.Kind == KindStorage {
+		return true
+	}
+	exported, _ := f.Props["exported"].(bool)
+	return exported
+}
+
+// SplitSymbolName splits a symbol name like "internal/util.Helper" or
+// "internal/util.Repo.Find" into its package and the rest of the name. Names
+// without a package part return false.
+func SplitSymbolName(name string) (pkg, rest string, ok bool) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot <= 0 {
+		return "", "", false
+	}
+	return name[:slash+1+dot], name[slash+2+dot:], true
+}
+
+// DeclaringModule returns the target of f's declares relation, or "" when it
+// has none.
+func DeclaringModule(f Fact) string {
+	for _, r := range f.Relations {
+		if r.Kind == RelDeclares {
+			return r.Target
+		}
+	}
+	return ""
+}
+
 // Insight represents an architectural insight produced by an explainer.
 type Insight struct {
 	Title       string     `json:"title"`
@@ -81,15 +162,70 @@ type Snapshot struct {
 
 // SnapshotMeta contains metadata about a snapshot generation run.
 type SnapshotMeta struct {
-	RepoPath    string     `json:"repo_path"`
-	GeneratedAt string     `json:"generated_at"`
-	Duration    string     `json:"duration"`
-	Extractors  []string   `json:"extractors"`
-	Explainers  []string   `json:"explainers"`
-	Renderers   []string   `json:"renderers"`
-	FileHashes  []FileHash `json:"file_hashes,omitempty"`
-	FactCount   int        `json:"fact_count"`
-	InsightCount int       `json:"insight_count"`
+	RepoPath     string          `json:"repo_path"`
+	GeneratedAt  string          `json:"generated_at"`
+	Duration     string          `json:"duration"`
+	Extractors   []string        `json:"extractors"`
+	Explainers   []string        `json:"explainers"`
+	Renderers    []string        `json:"renderers"`
+	FileHashes   []FileHash      `json:"file_hashes,omitempty"`
+	FileCount    int             `json:"file_count"`
+	FactCount    int             `json:"fact_count"`
+	InsightCount int             `json:"insight_count"`
+	ChangedSince string          `json:"changed_since,omitempty"` // git ref the snapshot was scoped to, if any
+	Errors       []ExtractError  `json:"errors,omitempty"`
+	Frameworks   []FrameworkInfo `json:"frameworks,omitempty"`
+	Sampling     *Sampling       `json:"sampling,omitempty"` // set when symbols were dropped to fit generate.max_facts
+	// ExtractorStats records, in run order, the time and output of each
+	// extractor that ran, including those that failed.
+	ExtractorStats []ExtractorStat `json:"extractor_stats,omitempty"`
+}
+
+// ExtractorStat is the cost of one extractor in a generation. Facts counts
+// what the extractor emitted, before route merging and generate.max_facts
+// sampling; Files counts the files it was given.
+type ExtractorStat struct {
+	Name     string `json:"name"`
+	Duration string `json:"duration"`
+	Facts    int    `json:"facts"`
+	Files    int    `json:"files"`
+}
+
+// Sampling records how symbols were dropped to keep a generation within
+// generate.max_facts.
+type Sampling struct {
+	MaxFacts         int `json:"max_facts"`
+	ExtractedFacts   int `json:"extracted_facts"`    // facts before sampling
+	SymbolsPerModule int `json:"symbols_per_module"` // most symbols kept in any module
+	SampledModules   int `json:"sampled_modules"`    // modules that lost symbols
+	DroppedSymbols   int `json:"dropped_symbols"`
+}
+
+// FilesParsed returns how many of the FileCount files were extracted
+// without a per-file error.
+func (m SnapshotMeta) FilesParsed() int {
+	failed := make(map[string]bool)
+	for _, e := range m.Errors {
+		if e.File != "" {
+			failed[e.File] = true
+		}
+	}
+	return m.FileCount - len(failed)
+}
+
+// ExtractError records a file an extractor failed on. File is empty when the
+// whole extractor failed.
+type ExtractError struct {
+	File      string `json:"file,omitempty"`
+	Extractor string `json:"extractor"`
+	Message   string `json:"message"`
+}
+
+// FrameworkInfo is a framework detected in the repository's manifests, with
+// the version declared or locked there.
+type FrameworkInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
 }
 
 // FileHash tracks a file's content hash for incremental updates.
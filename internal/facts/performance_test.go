@@ -9,6 +9,7 @@ package facts
 //  4. ReverseLookup delegates to Graph.reverse when available (O(1) vs O(N×R))
 
 import (
+	"context"
 	"fmt"
 	"testing"
 )
@@ -97,7 +98,7 @@ func TestTraverse_WideGraphIndexPointerQueue(t *testing.T) {
 	s := buildWideGraph(spokes)
 	g := s.Graph()
 
-	result := g.Traverse("hub", "forward", nil, nil, 20, 500)
+	result := g.Traverse(context.Background(), "hub", "forward", nil, nil, 20, 500)
 
 	// hub + spokes + leaves
 	want := 1 + spokes + spokes
@@ -120,7 +121,7 @@ func TestTraverse_BFSDepthOrderIsCorrect(t *testing.T) {
 	)
 	s.BuildGraph()
 
-	result := s.Graph().Traverse("A", "forward", nil, nil, 10, 100)
+	result := s.Graph().Traverse(context.Background(), "A", "forward", nil, nil, 10, 100)
 
 	// Nodes should appear in BFS order: A(0), B(1), C(2), D(3)
 	depthOf := make(map[string]int)
@@ -144,7 +145,7 @@ func TestFindPath_IndexPointerQueueCorrectness(t *testing.T) {
 	s := buildWideGraph(spokes)
 	g := s.Graph()
 
-	result := g.FindPath("hub", "leaf10", nil, 10)
+	result := g.FindPath(context.Background(), "hub", "leaf10", nil, 10)
 	if !result.Found {
 		t.Fatal("path hub → spoke10 → leaf10 should be found")
 	}
@@ -0,0 +1,105 @@
+package facts
+
+import "sort"
+
+// RelatedFile is a file coupled to another file through graph edges between
+// the facts they contain.
+type RelatedFile struct {
+	File     string `json:"file"`
+	Edges    int    `json:"edges"`
+	Outgoing int    `json:"outgoing"` // edges from the queried file's facts into this file
+	Incoming int    `json:"incoming"` // edges from this file's facts into the queried file
+}
+
+// RelatedFilesResult is the ranking returned by RelatedFiles.
+type RelatedFilesResult struct {
+	File    string        `json:"file"`
+	Facts   int           `json:"facts"` // facts in the queried file that were followed
+	Files   []RelatedFile `json:"files"`
+	Total   int           `json:"total"` // related files before the limit was applied
+	HasMore bool          `json:"has_more"`
+}
+
+// RelatedFiles ranks the files most coupled to file: it follows the forward
+// and reverse edges of every fact declared in file, restricted to relKinds
+// (nil = all), and counts the edges landing in each other file. Module facts
+// are skipped on both ends, since their "file" is a directory and every
+// symbol has a declares edge to one. Ties are broken by file name. limit
+// defaults to 20 and is capped at 200.
+func (g *Graph) RelatedFiles(file string, relKinds []string, limit int) RelatedFilesResult {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	var kindSet map[string]bool
+	if len(relKinds) > 0 {
+		kindSet = make(map[string]bool, len(relKinds))
+		for _, k := range relKinds {
+			kindSet[k] = true
+		}
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	result := RelatedFilesResult{File: file}
+	names := make(map[string]bool)
+	for _, f := range g.facts {
+		if f.File == file && f.Kind != KindModule {
+			names[f.Name] = true
+		}
+	}
+	result.Facts = len(names)
+
+	byFile := make(map[string]*RelatedFile)
+	count := func(name string, edges []Edge, outgoing bool) {
+		for _, e := range edges {
+			if kindSet != nil && !kindSet[e.RelKind] {
+				continue
+			}
+			idx, ok := g.factIdx[e.Target]
+			if !ok || idx >= len(g.facts) {
+				continue
+			}
+			other := g.facts[idx]
+			if other.Kind == KindModule || other.File == "" || other.File == file {
+				continue
+			}
+			rf := byFile[other.File]
+			if rf == nil {
+				rf = &RelatedFile{File: other.File}
+				byFile[other.File] = rf
+			}
+			rf.Edges++
+			if outgoing {
+				rf.Outgoing++
+			} else {
+				rf.Incoming++
+			}
+		}
+	}
+	for name := range names {
+		count(name, g.forward[name], true)
+		count(name, g.reverse[name], false)
+	}
+
+	files := make([]RelatedFile, 0, len(byFile))
+	for _, rf := range byFile {
+		files = append(files, *rf)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Edges != files[j].Edges {
+			return files[i].Edges > files[j].Edges
+		}
+		return files[i].File < files[j].File
+	})
+	result.Total = len(files)
+	if len(files) > limit {
+		files = files[:limit]
+		result.HasMore = true
+	}
+	result.Files = files
+	return result
+}
@@ -0,0 +1,62 @@
+package facts
+
+import "testing"
+
+func TestRelatedFiles(t *testing.T) {
+	s := NewStore()
+	s.Add(
+		Fact{Kind: KindModule, Name: "svc", File: "svc"},
+		Fact{Kind: KindSymbol, Name: "svc.Handle", File: "svc/handler.go", Relations: []Relation{
+			{Kind: RelDeclares, Target: "svc"},
+			{Kind: RelCalls, Target: "store.Load"},
+			{Kind: RelCalls, Target: "store.Save"},
+			{Kind: RelCalls, Target: "log.Info"},
+			{Kind: RelCalls, Target: "svc.helper"},
+		}},
+		Fact{Kind: KindSymbol, Name: "svc.helper", File: "svc/handler.go"},
+		Fact{Kind: KindSymbol, Name: "store.Load", File: "store/store.go"},
+		Fact{Kind: KindSymbol, Name: "store.Save", File: "store/store.go"},
+		Fact{Kind: KindSymbol, Name: "log.Info", File: "log/log.go"},
+		Fact{Kind: KindRoute, Name: "GET /items", File: "api/routes.go", Relations: []Relation{
+			{Kind: RelCalls, Target: "svc.Handle"},
+		}},
+		Fact{Kind: KindSymbol, Name: "svc.Other", File: "svc/other.go", Relations: []Relation{
+			{Kind: RelDeclares, Target: "svc"},
+		}},
+	)
+	s.BuildGraph()
+	g := s.Graph()
+
+	result := g.RelatedFiles("svc/handler.go", nil, 0)
+	if result.Facts != 2 || result.Total != 3 || result.HasMore {
+		t.Errorf("result = %+v, want 2 facts and 3 related files", result)
+	}
+	want := []RelatedFile{
+		{File: "store/store.go", Edges: 2, Outgoing: 2},
+		{File: "api/routes.go", Edges: 1, Incoming: 1},
+		{File: "log/log.go", Edges: 1, Outgoing: 1},
+	}
+	if len(result.Files) != len(want) {
+		t.Fatalf("files = %+v, want %+v", result.Files, want)
+	}
+	for i := range want {
+		if result.Files[i] != want[i] {
+			t.Errorf("files[%d] = %+v, want %+v", i, result.Files[i], want[i])
+		}
+	}
+
+	// Sharing a module is not coupling: svc/other.go only declares into svc.
+	for _, rf := range result.Files {
+		if rf.File == "svc/other.go" || rf.File == "svc" {
+			t.Errorf("unexpected related file %s", rf.File)
+		}
+	}
+
+	limited := g.RelatedFiles("svc/handler.go", []string{RelCalls}, 1)
+	if len(limited.Files) != 1 || limited.Files[0].File != "store/store.go" || !limited.HasMore {
+		t.Errorf("limited = %+v", limited)
+	}
+	if none := g.RelatedFiles("svc/handler.go", []string{RelImplements}, 0); none.Total != 0 {
+		t.Errorf("implements-only = %+v, want no files", none)
+	}
+}
@@ -2,10 +2,17 @@ package facts
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -20,6 +27,30 @@ type Store struct {
 	byFile map[string][]int // file -> indices into facts
 	byName map[string][]int // name -> indices into facts
 	byRepo map[string][]int // repo label -> indices into facts
+	byID   map[string][]int // fact ID -> indices into facts
+
+	// sortedNames holds the distinct fact names in order for prefix lookups.
+	// It is rebuilt lazily on the first lookup after the names change.
+	sortedNames []string
+	namesDirty  bool
+
+	// sortedFiles holds the distinct file paths ordered by their lowercase
+	// form, so a file prefix selects a contiguous range in either case mode.
+	// It is rebuilt lazily on the first prefix query after the files change.
+	sortedFiles []fileKey
+	filesDirty  bool
+
+	// foldedFiles maps a lowercase file path to the stored paths with that
+	// form, for case-insensitive file lookups. It is rebuilt together with
+	// sortedFiles and is only current while filesDirty is false.
+	foldedFiles map[string][]string
+
+	// foldPaths makes file filters compare paths case-insensitively. Stored
+	// paths keep their case.
+	foldPaths bool
+
+	// graphRels restricts the relations BuildGraph ingests; nil keeps all.
+	graphRels []string
 
 	// Graph provides adjacency-list traversal over fact relations
 	graph *Graph
@@ -32,21 +63,117 @@ func NewStore() *Store {
 		byFile: make(map[string][]int),
 		byName: make(map[string][]int),
 		byRepo: make(map[string][]int),
+		byID:   make(map[string][]int),
+	}
+}
+
+// SetCaseInsensitivePaths makes ByFile, Query and the file filters of
+// QueryAdvanced match file paths case-insensitively, for repositories on
+// case-insensitive filesystems. The paths of stored facts are not changed.
+func (s *Store) SetCaseInsensitivePaths(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.foldPaths = on
+}
+
+// CaseInsensitivePaths reports whether file paths are matched
+// case-insensitively.
+func (s *Store) CaseInsensitivePaths() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.foldPaths
+}
+
+// SetGraphRelationKinds restricts the relations BuildGraph and ExtendGraph
+// ingest as edges to kinds; nil or empty ingests all of them. It takes
+// effect on the next graph build.
+func (s *Store) SetGraphRelationKinds(kinds []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.graphRels = kinds
+}
+
+// sameFile reports whether a stored file path matches a filter path.
+func (s *Store) sameFile(file, filter string) bool {
+	if s.foldPaths {
+		return strings.EqualFold(file, filter)
+	}
+	return file == filter
+}
+
+// hasFilePrefix reports whether a stored file path starts with prefix.
+func (s *Store) hasFilePrefix(file, prefix string) bool {
+	if s.foldPaths {
+		return len(file) >= len(prefix) && strings.EqualFold(file[:len(prefix)], prefix)
+	}
+	return strings.HasPrefix(file, prefix)
+}
+
+// fileIndices returns the indices of the facts of file. Without case
+// folding it is a plain index lookup; with it, the keys whose case differs
+// are merged in, in fact order, using foldedFiles. A write since the last
+// rebuild leaves that index stale, and the keys are then scanned instead.
+func (s *Store) fileIndices(file string) []int {
+	if !s.foldPaths {
+		return s.byFile[file]
+	}
+	var indices []int
+	if s.filesDirty {
+		for key, idxs := range s.byFile {
+			if strings.EqualFold(key, file) {
+				indices = append(indices, idxs...)
+			}
+		}
+	} else {
+		keys := s.foldedFiles[strings.ToLower(file)]
+		if len(keys) == 1 {
+			return s.byFile[keys[0]]
+		}
+		for _, key := range keys {
+			indices = append(indices, s.byFile[key]...)
+		}
+	}
+	slices.Sort(indices)
+	return indices
+}
+
+// prepareFileIndices rebuilds the index fileIndices uses when paths are
+// matched case-insensitively. Call it before taking the read lock.
+func (s *Store) prepareFileIndices() {
+	if s.CaseInsensitivePaths() {
+		s.fileIndex()
 	}
 }
 
-// Add adds facts to the store.
+// Add adds facts to the store, setting each fact's ID. A fact that exactly
+// duplicates a stored one (same ID, repo, props and relations), as
+// overlapping extraction passes can produce, is skipped.
 func (s *Store) Add(ff ...Fact) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.addLocked(ff)
+}
+
+func (s *Store) addLocked(ff []Fact) {
 	for _, f := range ff {
+		f.ID = f.ComputeID()
+		if s.isDuplicate(f) {
+			continue
+		}
 		idx := len(s.facts)
 		s.facts = append(s.facts, f)
+		s.byID[f.ID] = append(s.byID[f.ID], idx)
 		s.byKind[f.Kind] = append(s.byKind[f.Kind], idx)
 		if f.File != "" {
+			if _, seen := s.byFile[f.File]; !seen {
+				s.filesDirty = true
+			}
 			s.byFile[f.File] = append(s.byFile[f.File], idx)
 		}
 		if f.Name != "" {
+			if _, seen := s.byName[f.Name]; !seen {
+				s.namesDirty = true
+			}
 			s.byName[f.Name] = append(s.byName[f.Name], idx)
 		}
 		if f.Repo != "" {
@@ -55,6 +182,108 @@ func (s *Store) Add(ff ...Fact) {
 	}
 }
 
+// isDuplicate reports whether a stored fact is identical to f.
+func (s *Store) isDuplicate(f Fact) bool {
+	for _, i := range s.byID[f.ID] {
+		stored := s.facts[i]
+		if stored.Repo == f.Repo && reflect.DeepEqual(stored.Props, f.Props) && reflect.DeepEqual(stored.Relations, f.Relations) {
+			return true
+		}
+	}
+	return false
+}
+
+// retag moves the fact at index i to a new file path, updating its ID and
+// the file and ID indexes.
+func (s *Store) retag(i int, file string) {
+	f := &s.facts[i]
+	s.removeFromIndex(s.byFile, f.File, i)
+	s.removeFromIndex(s.byID, f.ID, i)
+	f.File = file
+	f.ID = f.ComputeID()
+	s.byFile[f.File] = append(s.byFile[f.File], i)
+	s.byID[f.ID] = append(s.byID[f.ID], i)
+	s.filesDirty = true
+}
+
+// Filter returns a new store holding the facts for which keep returns true,
+// in their original order. The new store has no graph, matches paths with
+// the same case sensitivity and builds its graph from the same relations.
+func (s *Store) Filter(keep func(Fact) bool) *Store {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := NewStore()
+	out.foldPaths = s.foldPaths
+	out.graphRels = s.graphRels
+	var kept []Fact
+	for _, f := range s.facts {
+		if keep(f) {
+			kept = append(kept, f)
+		}
+	}
+	out.addLocked(kept)
+	return out
+}
+
+// Clone returns a copy of the store, graph included, that can be changed
+// without affecting s.
+func (s *Store) Clone() *Store {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := &Store{
+		facts:       slices.Clone(s.facts),
+		byKind:      cloneIndex(s.byKind),
+		byFile:      cloneIndex(s.byFile),
+		byName:      cloneIndex(s.byName),
+		byRepo:      cloneIndex(s.byRepo),
+		byID:        cloneIndex(s.byID),
+		sortedNames: s.sortedNames,
+		namesDirty:  s.namesDirty,
+		sortedFiles: s.sortedFiles,
+		filesDirty:  s.filesDirty,
+		foldedFiles: s.foldedFiles,
+		foldPaths:   s.foldPaths,
+		graphRels:   s.graphRels,
+	}
+	if s.graph != nil {
+		out.graph = s.graph.clone(out.facts)
+	}
+	return out
+}
+
+// cloneIndex copies an index map together with its index slices, which
+// removeFromIndex changes in place.
+func cloneIndex(idx map[string][]int) map[string][]int {
+	out := make(map[string][]int, len(idx))
+	for k, v := range idx {
+		out[k] = slices.Clone(v)
+	}
+	return out
+}
+
+// Replace makes s hold the facts, indexes and graph of other, which must not
+// be used afterwards. Readers of s see either the old or the new contents.
+func (s *Store) Replace(other *Store) {
+	other.mu.Lock()
+	defer other.mu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.facts = other.facts
+	s.byKind = other.byKind
+	s.byFile = other.byFile
+	s.byName = other.byName
+	s.byRepo = other.byRepo
+	s.byID = other.byID
+	s.sortedNames = other.sortedNames
+	s.namesDirty = other.namesDirty
+	s.sortedFiles = other.sortedFiles
+	s.filesDirty = other.filesDirty
+	s.foldedFiles = other.foldedFiles
+	s.foldPaths = other.foldPaths
+	s.graphRels = other.graphRels
+	s.graph = other.graph
+}
+
 // All returns all facts in the store.
 func (s *Store) All() []Fact {
 	s.mu.RLock()
@@ -80,9 +309,10 @@ func (s *Store) ByKind(kind string) []Fact {
 
 // ByFile returns all facts for the given file.
 func (s *Store) ByFile(file string) []Fact {
+	s.prepareFileIndices()
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.collectByIndex(s.byFile[file])
+	return s.collectByIndex(s.fileIndices(file))
 }
 
 // ByName returns all facts with the given name.
@@ -132,7 +362,7 @@ func (s *Store) Query(kind, file, name, relKind string) []Fact {
 
 	var result []Fact
 	for _, f := range candidates {
-		if file != "" && f.File != file {
+		if file != "" && !s.sameFile(f.File, file) {
 			continue
 		}
 		if name != "" && !strings.Contains(strings.ToLower(f.Name), strings.ToLower(name)) {
@@ -166,7 +396,7 @@ func (s *Store) queryFromIndices(indices []int, kind, file, name, relKind string
 			continue
 		}
 		f := s.facts[idx]
-		if file != "" && f.File != file {
+		if file != "" && !s.sameFile(f.File, file) {
 			continue
 		}
 		if name != "" && !strings.Contains(strings.ToLower(f.Name), nameLower) {
@@ -191,26 +421,123 @@ func (s *Store) queryFromIndices(indices []int, kind, file, name, relKind string
 
 // QueryOpts holds the full set of query filters for QueryAdvanced.
 // Multi-value filters within a dimension are OR-combined; filters across
-// different dimensions are AND-combined.
+// different dimensions are AND-combined. The Not* fields exclude facts after
+// the positive filters have matched.
 type QueryOpts struct {
 	Kind       string   // single kind filter (exact match)
 	Kinds      []string // multi-kind filter (OR with Kind)
 	File       string   // exact file filter
 	Files      []string // multi-file filter (OR with File)
 	FilePrefix string   // file path prefix filter (e.g. "internal/server")
+	LineMin    int      // only facts on or after this line (0 = unbounded); used with File or FilePrefix
+	LineMax    int      // only facts on or before this line (0 = unbounded)
 	Name       string   // substring name filter
 	Names      []string // exact name batch filter (OR)
 	Repo       string   // repo label filter (exact match, for multi-repo mode)
 	RelKind    string   // relation kind filter
 	Prop       string   // property name filter
 	PropValue  string   // property value filter (requires Prop)
-	Offset     int      // number of results to skip
-	Limit      int      // max results to return (0 = default 100, max 500)
+	PropOp     string   // how PropValue is compared: one of the PropOp* constants (default eq)
+
+	ExportedOnly bool // only facts IsPublic accepts
+
+	NotKinds      []string // exclude facts of any of these kinds
+	NotFilePrefix string   // exclude facts whose file starts with this prefix
+	NotProp       string   // exclude facts that have this property
+	NotPropValue  string   // narrows NotProp to facts whose property equals this value
+
+	Offset int // number of results to skip
+	Limit  int // max results to return (0 = default 100, max 500)
+}
+
+// Comparison operators for QueryOpts.PropOp. PropOpEq compares the formatted
+// value as a string; the others parse both sides as numbers.
+const (
+	PropOpEq  = "eq"
+	PropOpLt  = "lt"
+	PropOpGt  = "gt"
+	PropOpLte = "lte"
+	PropOpGte = "gte"
+)
+
+// ValidPropOp reports whether op is empty or one of the PropOp* constants.
+func ValidPropOp(op string) bool {
+	switch op {
+	case "", PropOpEq, PropOpLt, PropOpGt, PropOpLte, PropOpGte:
+		return true
+	}
+	return false
+}
+
+// matchPropValue compares a fact property against a filter value. A list
+// property matches eq when any of its elements does. Numeric operators never
+// match values that are not numbers, such as "n/a" or a missing coverage
+// figure.
+func matchPropValue(v any, op, want string) bool {
+	if op == "" || op == PropOpEq {
+		switch list := v.(type) {
+		case []string:
+			return slices.Contains(list, want)
+		case []any:
+			for _, el := range list {
+				if fmt.Sprintf("%v", el) == want {
+					return true
+				}
+			}
+			return false
+		}
+		return fmt.Sprintf("%v", v) == want
+	}
+	got, ok := propNumber(v)
+	if !ok {
+		return false
+	}
+	limit, err := strconv.ParseFloat(want, 64)
+	if err != nil {
+		return false
+	}
+	switch op {
+	case PropOpLt:
+		return got < limit
+	case PropOpGt:
+		return got > limit
+	case PropOpLte:
+		return got <= limit
+	case PropOpGte:
+		return got >= limit
+	}
+	return false
+}
+
+// propNumber returns a property value as a float64. Facts loaded from a
+// persisted snapshot hold float64s, freshly extracted ones may hold ints, and
+// some extractors record numbers as strings.
+func propNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
 }
 
 // QueryAdvanced returns facts matching the provided filter options along with
 // the total count of matches before offset/limit are applied.
 func (s *Store) QueryAdvanced(opts QueryOpts) ([]Fact, int) {
+	if opts.FilePrefix != "" {
+		s.fileIndex() // rebuild outside the read lock
+	} else if opts.File != "" || len(opts.Files) > 0 {
+		s.prepareFileIndices()
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -233,13 +560,16 @@ func (s *Store) QueryAdvanced(opts QueryOpts) ([]Fact, int) {
 	// Multi-kind and multi-file filters still fall back to the full slice
 	// because building a union of index slices is only worthwhile when the
 	// union is significantly smaller than N, which is hard to determine
-	// cheaply; the remaining filters then trim the result.
+	// cheaply; the remaining filters then trim the result. A file prefix
+	// without exact files selects the union of the byFile entries in its
+	// range of sortedFiles, which replaces the kind index when smaller.
 	type iterMode int
 	const (
-		iterFull      iterMode = iota // scan all s.facts
-		iterKindIndex                 // scan byKind[kind] indices
-		iterFileIndex                 // scan byFile[file] indices
-		iterNameUnion                 // scan union of byName[name] indices
+		iterFull        iterMode = iota // scan all s.facts
+		iterKindIndex                   // scan byKind[kind] indices
+		iterFileIndex                   // scan byFile[file] indices
+		iterNameUnion                   // scan union of byName[name] indices
+		iterPrefixUnion                 // scan union of byFile indices under FilePrefix
 	)
 
 	mode := iterFull
@@ -257,7 +587,7 @@ func (s *Store) QueryAdvanced(opts QueryOpts) ([]Fact, int) {
 		}
 	} else if len(fileSet) == 1 && opts.FilePrefix == "" {
 		for f := range fileSet {
-			if idxs, ok := s.byFile[f]; ok {
+			if idxs := s.fileIndices(f); len(idxs) > 0 {
 				indexSlice = idxs
 				mode = iterFileIndex
 			} else {
@@ -281,6 +611,18 @@ func (s *Store) QueryAdvanced(opts QueryOpts) ([]Fact, int) {
 			mode = iterNameUnion
 		}
 	}
+	// A write between the rebuild and the read lock leaves the index dirty;
+	// the query then falls back to the filters alone.
+	if opts.FilePrefix != "" && len(fileSet) == 0 && !s.filesDirty && (mode == iterFull || mode == iterKindIndex) {
+		union := s.prefixIndices(opts.FilePrefix)
+		if len(union) == 0 {
+			return nil, 0
+		}
+		if mode == iterFull || len(union) < len(indexSlice) {
+			indexSlice = union
+			mode = iterPrefixUnion
+		}
+	}
 
 	// factAt retrieves a fact by absolute index in s.facts, regardless of mode.
 	filterFact := func(f Fact) bool {
@@ -301,15 +643,30 @@ func (s *Store) QueryAdvanced(opts QueryOpts) ([]Fact, int) {
 			fileMatch := false
 			if len(fileSet) > 0 {
 				_, fileMatch = fileSet[f.File]
+				if !fileMatch && s.foldPaths {
+					for file := range fileSet {
+						if strings.EqualFold(f.File, file) {
+							fileMatch = true
+							break
+						}
+					}
+				}
 			}
 			if !fileMatch && opts.FilePrefix != "" {
-				fileMatch = strings.HasPrefix(f.File, opts.FilePrefix)
+				fileMatch = s.hasFilePrefix(f.File, opts.FilePrefix)
 			}
 			if !fileMatch {
 				return false
 			}
 		}
 
+		// Line range filter: facts without a line never match a range
+		if opts.LineMin > 0 || opts.LineMax > 0 {
+			if f.Line <= 0 || (opts.LineMin > 0 && f.Line < opts.LineMin) || (opts.LineMax > 0 && f.Line > opts.LineMax) {
+				return false
+			}
+		}
+
 		// Name filter: substring (Name) OR exact batch (Names)
 		if opts.Name != "" || len(nameSet) > 0 {
 			nameMatch := false
@@ -344,29 +701,53 @@ func (s *Store) QueryAdvanced(opts QueryOpts) ([]Fact, int) {
 			if !ok {
 				return false
 			}
-			if opts.PropValue != "" && fmt.Sprintf("%v", v) != opts.PropValue {
+			if opts.PropValue != "" && !matchPropValue(v, opts.PropOp, opts.PropValue) {
 				return false
 			}
 		}
 
+		if opts.ExportedOnly && !IsPublic(f) {
+			return false
+		}
+
 		return true
 	}
 
+	// Negation filters run after the positive ones so they only ever narrow
+	// the match set; the total reported below already accounts for them.
+	notKindSet := mergeIntoSet("", opts.NotKinds)
+	excludeFact := func(f Fact) bool {
+		if _, ok := notKindSet[f.Kind]; ok {
+			return true
+		}
+		if opts.NotFilePrefix != "" && s.hasFilePrefix(f.File, opts.NotFilePrefix) {
+			return true
+		}
+		if opts.NotProp != "" {
+			if v, ok := f.Props[opts.NotProp]; ok {
+				if opts.NotPropValue == "" || fmt.Sprintf("%v", v) == opts.NotPropValue {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
 	var matched []Fact
 
 	switch mode {
-	case iterKindIndex, iterFileIndex, iterNameUnion:
+	case iterKindIndex, iterFileIndex, iterNameUnion, iterPrefixUnion:
 		for _, idx := range indexSlice {
 			if idx >= len(s.facts) {
 				continue
 			}
-			if filterFact(s.facts[idx]) {
+			if filterFact(s.facts[idx]) && !excludeFact(s.facts[idx]) {
 				matched = append(matched, s.facts[idx])
 			}
 		}
 	default:
 		for _, f := range s.facts {
-			if filterFact(f) {
+			if filterFact(f) && !excludeFact(f) {
 				matched = append(matched, f)
 			}
 		}
@@ -397,6 +778,65 @@ func (s *Store) QueryAdvanced(opts QueryOpts) ([]Fact, int) {
 	return matched, total
 }
 
+// fileKey is an entry of the sorted file index.
+type fileKey struct {
+	lower string // strings.ToLower(file), the sort key
+	file  string
+}
+
+// fileIndex returns the distinct file paths sorted by their lowercase form,
+// rebuilding it, and foldedFiles with it, if files were added, renamed or
+// removed.
+func (s *Store) fileIndex() []fileKey {
+	s.mu.RLock()
+	files, dirty := s.sortedFiles, s.filesDirty
+	s.mu.RUnlock()
+	if !dirty {
+		return files
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.filesDirty {
+		files := make([]fileKey, 0, len(s.byFile))
+		folded := make(map[string][]string, len(s.byFile))
+		for file := range s.byFile {
+			lower := strings.ToLower(file)
+			files = append(files, fileKey{lower: lower, file: file})
+			folded[lower] = append(folded[lower], file)
+		}
+		sort.Slice(files, func(i, j int) bool {
+			if files[i].lower != files[j].lower {
+				return files[i].lower < files[j].lower
+			}
+			return files[i].file < files[j].file
+		})
+		s.sortedFiles = files
+		s.foldedFiles = folded
+		s.filesDirty = false
+	}
+	return s.sortedFiles
+}
+
+// prefixIndices returns, in fact order, the indices of the facts whose file
+// starts with prefix. Every such file sorts into the range of files whose
+// lowercase form starts with the lowercase prefix, found by binary search;
+// the exact comparison then applies the store's case mode. It must be called
+// with the index up to date and s.mu held.
+func (s *Store) prefixIndices(prefix string) []int {
+	files := s.sortedFiles
+	lower := strings.ToLower(prefix)
+	start := sort.Search(len(files), func(i int) bool { return files[i].lower >= lower })
+	var indices []int
+	for i := start; i < len(files) && strings.HasPrefix(files[i].lower, lower); i++ {
+		if s.hasFilePrefix(files[i].file, prefix) {
+			indices = append(indices, s.byFile[files[i].file]...)
+		}
+	}
+	slices.Sort(indices)
+	return indices
+}
+
 // LookupByExactName returns all facts with the given exact name using the index.
 func (s *Store) LookupByExactName(name string) []Fact {
 	s.mu.RLock()
@@ -404,6 +844,62 @@ func (s *Store) LookupByExactName(name string) []Fact {
 	return s.collectByIndex(s.byName[name])
 }
 
+// LookupByPrefix returns facts whose name starts with prefix, in name order,
+// using a sorted name index instead of a scan. At most limit facts are
+// returned; limit <= 0 means no limit.
+func (s *Store) LookupByPrefix(prefix string, limit int) []Fact {
+	names := s.nameIndex()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []Fact
+	for i := sort.SearchStrings(names, prefix); i < len(names) && strings.HasPrefix(names[i], prefix); i++ {
+		for _, idx := range s.byName[names[i]] {
+			if limit > 0 && len(result) == limit {
+				return result
+			}
+			result = append(result, s.facts[idx])
+		}
+	}
+	return result
+}
+
+// NamesByPrefix returns up to limit distinct fact names starting with prefix,
+// in order. limit <= 0 means no limit.
+func (s *Store) NamesByPrefix(prefix string, limit int) []string {
+	names := s.nameIndex()
+	start := sort.SearchStrings(names, prefix)
+	end := start
+	for end < len(names) && strings.HasPrefix(names[end], prefix) && (limit <= 0 || end-start < limit) {
+		end++
+	}
+	return append([]string(nil), names[start:end]...)
+}
+
+// nameIndex returns the sorted distinct fact names, rebuilding the index if
+// facts with new names were added since it was last built.
+func (s *Store) nameIndex() []string {
+	s.mu.RLock()
+	names, dirty := s.sortedNames, s.namesDirty
+	s.mu.RUnlock()
+	if !dirty {
+		return names
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.namesDirty {
+		names := make([]string, 0, len(s.byName))
+		for name := range s.byName {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		s.sortedNames = names
+		s.namesDirty = false
+	}
+	return s.sortedNames
+}
+
 // ReverseLookup returns all facts that have a relation targeting the given name.
 // If relKind is non-empty, only relations of that kind are considered.
 // When the graph index is available it delegates to the O(1) reverse map;
@@ -463,26 +959,78 @@ func (s *Store) SetRepoRange(startIdx int, repo string) {
 			s.byRepo[repo] = append(s.byRepo[repo], i)
 		}
 	}
+	s.syncGraph(startIdx)
 }
 
-// TagRange sets the Repo field and prefixes File paths for facts added at
-// indices [startIdx, current length). Used by the engine in append mode to
-// namespace facts from different repositories.
+// TagRange sets the Repo field and prefixes File paths, and the reads of
+// config facts, for facts added at indices [startIdx, current length). Used
+// by the engine in append mode to namespace facts from different
+// repositories.
 func (s *Store) TagRange(startIdx int, repo, filePrefix string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	for i := startIdx; i < len(s.facts); i++ {
 		f := &s.facts[i]
-		f.Repo = repo
+		if f.Repo != repo {
+			if f.Repo != "" {
+				s.removeFromIndex(s.byRepo, f.Repo, i)
+			}
+			f.Repo = repo
+			s.byRepo[repo] = append(s.byRepo[repo], i)
+		}
 		if f.File != "" {
-			oldFile := f.File
-			f.File = filePrefix + f.File
-			// Update byFile index: remove old key, add new key.
-			s.removeFromIndex(s.byFile, oldFile, i)
-			s.byFile[f.File] = append(s.byFile[f.File], i)
+			s.retag(i, filePrefix+f.File)
+			prefixReads(f, filePrefix)
+		}
+	}
+	s.syncGraph(startIdx)
+}
+
+// prefixReads prefixes the "file:line" entries of a config fact's reads
+// prop, which name files as File does. Props is replaced rather than
+// changed, as clones of the store share it.
+func prefixReads(f *Fact, prefix string) {
+	if f.Kind != KindConfig {
+		return
+	}
+	var reads []string
+	switch v := f.Props["reads"].(type) {
+	case []string:
+		reads = v
+	case []any: // loaded from facts.jsonl
+		for _, r := range v {
+			if read, ok := r.(string); ok {
+				reads = append(reads, read)
+			}
 		}
-		s.byRepo[repo] = append(s.byRepo[repo], i)
+	default:
+		return
+	}
+	prefixed := make([]string, len(reads))
+	for i, r := range reads {
+		prefixed[i] = prefix + r
+	}
+	f.Props = maps.Clone(f.Props)
+	f.Props["reads"] = prefixed
+}
+
+// syncGraph updates the graph after the facts from startIdx on were
+// retagged in place: the graph is pointed at the store's current slice, which
+// it may no longer share after appends, and the module→import edges, which
+// depend on the files of dependency facts, are re-resolved. Facts the graph
+// does not cover yet are left to ExtendGraph. The caller holds s.mu.
+func (s *Store) syncGraph(startIdx int) {
+	if s.graph == nil {
+		return
+	}
+	s.graph.mu.Lock()
+	defer s.graph.mu.Unlock()
+	n := len(s.graph.facts)
+	if startIdx >= n {
+		return
 	}
+	s.graph.facts = s.facts[:n]
+	s.graph.resyncDerived(startIdx, n)
 }
 
 func (s *Store) removeFromIndex(idx map[string][]int, key string, target int) {
@@ -498,8 +1046,9 @@ func (s *Store) removeFromIndex(idx map[string][]int, key string, target int) {
 	}
 }
 
-// TagUntagged sets the Repo field and prefixes File paths for all facts that
-// belong to the given repo (or have an empty Repo). This is used when entering
+// TagUntagged sets the Repo field and prefixes File paths, and the reads of
+// config facts, for all facts that belong to the given repo (or have an
+// empty Repo). This is used when entering
 // append mode to retroactively label and prefix facts from a prior single-repo
 // snapshot so they become filterable alongside newly appended facts.
 func (s *Store) TagUntagged(repo, filePrefix string) int {
@@ -522,16 +1071,43 @@ func (s *Store) TagUntagged(repo, filePrefix string) int {
 
 		// Prefix the file path if it doesn't already have the prefix.
 		if f.File != "" && !strings.HasPrefix(f.File, filePrefix) {
-			oldFile := f.File
-			f.File = filePrefix + f.File
-			s.removeFromIndex(s.byFile, oldFile, i)
-			s.byFile[f.File] = append(s.byFile[f.File], i)
+			s.retag(i, filePrefix+f.File)
+			prefixReads(f, filePrefix)
 			count++
 		}
 	}
+	s.syncGraph(0)
 	return count
 }
 
+// Files returns the distinct non-empty file paths of the stored facts, sorted.
+// When repo is non-empty only files of facts with that repo label are returned.
+func (s *Store) Files(repo string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var files []string
+	for file, indices := range s.byFile {
+		if file == "" {
+			continue
+		}
+		if repo != "" {
+			found := false
+			for _, i := range indices {
+				if s.facts[i].Repo == repo {
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
 // Modules returns all module facts.
 func (s *Store) Modules() []Fact {
 	return s.ByKind(KindModule)
@@ -551,6 +1127,12 @@ func (s *Store) Clear() {
 	s.byFile = make(map[string][]int)
 	s.byName = make(map[string][]int)
 	s.byRepo = make(map[string][]int)
+	s.byID = make(map[string][]int)
+	s.sortedNames = nil
+	s.namesDirty = false
+	s.sortedFiles = nil
+	s.filesDirty = false
+	s.foldedFiles = nil
 	s.graph = nil
 }
 
@@ -559,7 +1141,77 @@ func (s *Store) Clear() {
 func (s *Store) BuildGraph() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.graph = NewGraph(s.facts)
+	s.graph = NewGraphWithRelations(s.facts, s.graphRels)
+}
+
+// ExtendGraph adds the facts at indices [startIdx, current length) to the
+// graph index, so appending facts does not rebuild the whole graph. Facts
+// before startIdx must not have changed since the graph was built, other than
+// through the store's own tagging and removal methods, which keep the graph in
+// step. Without a graph it builds one from all facts, like BuildGraph.
+func (s *Store) ExtendGraph(startIdx int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.graph == nil || startIdx != len(s.graph.facts) {
+		s.graph = NewGraphWithRelations(s.facts, s.graphRels)
+		return
+	}
+	s.graph.mu.Lock()
+	defer s.graph.mu.Unlock()
+	s.graph.addFacts(s.facts, startIdx)
+}
+
+// RemoveByFile removes the facts whose File is file from the store and the
+// graph index, and returns how many were removed.
+func (s *Store) RemoveByFile(file string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.byFile[file]) == 0 {
+		return 0
+	}
+	return s.removeLocked(func(f Fact) bool { return f.File == file })
+}
+
+// RemoveFunc removes the facts for which drop returns true from the store and
+// the graph index, like RemoveByFile, and returns how many were removed.
+func (s *Store) RemoveFunc(drop func(Fact) bool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.removeLocked(drop)
+}
+
+func (s *Store) removeLocked(drop func(Fact) bool) int {
+	// A new slice, since the graph may share the old one's backing array.
+	kept := make([]Fact, 0, len(s.facts))
+	for _, f := range s.facts {
+		if !drop(f) {
+			kept = append(kept, f)
+		}
+	}
+	removed := len(s.facts) - len(kept)
+	if removed == 0 {
+		return 0
+	}
+
+	s.facts = nil
+	s.byKind = make(map[string][]int)
+	s.byFile = make(map[string][]int)
+	s.byName = make(map[string][]int)
+	s.byRepo = make(map[string][]int)
+	s.byID = make(map[string][]int)
+	s.namesDirty = true
+	s.filesDirty = true
+	s.addLocked(kept)
+
+	if s.graph != nil {
+		s.graph.mu.Lock()
+		s.graph.removeFacts(drop)
+		// Share the store's slice again rather than keep the graph's copy.
+		// The graph may not cover facts added since it was built.
+		s.graph.facts = s.facts[:len(s.graph.facts)]
+		s.graph.mu.Unlock()
+	}
+	return removed
 }
 
 // Graph returns the current graph index, or nil if BuildGraph has not been called.
@@ -569,31 +1221,87 @@ func (s *Store) Graph() *Graph {
 	return s.graph
 }
 
-// WriteJSONL writes all facts as JSONL to the given writer.
+// WriteJSONL writes all facts as JSONL to the given writer, in canonical
+// order (see canonicalLess) rather than store order, so the same facts
+// always produce the same bytes and facts.jsonl diffs cleanly. Props keys
+// are written sorted, as encoding/json does for maps.
 func (s *Store) WriteJSONL(w io.Writer) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	enc := json.NewEncoder(w)
-	for _, f := range s.facts {
-		if err := enc.Encode(f); err != nil {
+	lines := make([][]byte, len(s.facts))
+	for i, f := range s.facts {
+		line, err := json.Marshal(f)
+		if err != nil {
 			return fmt.Errorf("encoding fact %q: %w", f.Name, err)
 		}
+		lines[i] = line
+	}
+	order := make([]int, len(s.facts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		i, j := order[a], order[b]
+		if canonicalLess(s.facts[i], s.facts[j]) {
+			return true
+		}
+		if canonicalLess(s.facts[j], s.facts[i]) {
+			return false
+		}
+		return bytes.Compare(lines[i], lines[j]) < 0
+	})
+	for _, i := range order {
+		if _, err := w.Write(append(lines[i], '\n')); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-// WriteJSONLFile writes all facts as JSONL to the given file path.
+// canonicalLess orders facts by kind, file, line, name and repo.
+func canonicalLess(a, b Fact) bool {
+	if a.Kind != b.Kind {
+		return a.Kind < b.Kind
+	}
+	if a.File != b.File {
+		return a.File < b.File
+	}
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.Repo < b.Repo
+}
+
+// WriteJSONLFile writes all facts as JSONL to the given file path. A path
+// ending in .gz is gzip-compressed.
 func (s *Store) WriteJSONLFile(path string) error {
 	f, err := os.Create(path)
 	if err != nil {
 		return fmt.Errorf("creating %s: %w", path, err)
 	}
 	defer f.Close()
-	bw := bufio.NewWriter(f)
+	var w io.Writer = f
+	var zw *gzip.Writer
+	if strings.HasSuffix(path, ".gz") {
+		zw = gzip.NewWriter(f)
+		w = zw
+	}
+	bw := bufio.NewWriter(w)
 	if err := s.WriteJSONL(bw); err != nil {
 		return err
 	}
-	return bw.Flush()
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("compressing %s: %w", path, err)
+		}
+	}
+	return f.Close()
 }
 
 // ReadJSONL reads facts from a JSONL reader and adds them to the store.
@@ -615,14 +1323,23 @@ func (s *Store) ReadJSONL(r io.Reader) error {
 	return scanner.Err()
 }
 
-// ReadJSONLFile reads facts from a JSONL file and adds them to the store.
+// ReadJSONLFile reads facts from a JSONL file and adds them to the store. A
+// path ending in .gz is decompressed.
 func (s *Store) ReadJSONLFile(path string) error {
 	f, err := os.Open(path)
 	if err != nil {
 		return fmt.Errorf("opening %s: %w", path, err)
 	}
 	defer f.Close()
-	return s.ReadJSONL(f)
+	if !strings.HasSuffix(path, ".gz") {
+		return s.ReadJSONL(f)
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("decompressing %s: %w", path, err)
+	}
+	defer zr.Close()
+	return s.ReadJSONL(zr)
 }
 
 func (s *Store) collectByIndex(indices []int) []Fact {
@@ -2,7 +2,12 @@ package facts
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"testing"
@@ -148,6 +153,36 @@ func TestQuery_RelationFilter(t *testing.T) {
 	}
 }
 
+func TestAdd_SkipsExactDuplicates(t *testing.T) {
+	s := NewStore()
+	f := Fact{Kind: KindSymbol, Name: "pkg.Foo", File: "pkg/foo.go", Line: 3,
+		Props: map[string]any{"exported": true}, Relations: []Relation{{Kind: RelDeclares, Target: "pkg"}}}
+	s.Add(f, f)
+	s.Add(f)
+	if got := s.Count(); got != 1 {
+		t.Fatalf("Count() = %d after adding one fact three times, want 1", got)
+	}
+
+	// Same kind, name, file and line, but not identical: kept.
+	withProps := f
+	withProps.Props = map[string]any{"exported": true, "deprecated": true}
+	otherRepo := f
+	otherRepo.Repo = "other"
+	s.Add(withProps, otherRepo)
+	all := s.All()
+	if len(all) != 3 {
+		t.Fatalf("Count() = %d, want facts differing in props or repo kept", len(all))
+	}
+	if all[0].ID == "" || all[0].ID != all[1].ID || all[0].ID != all[2].ID {
+		t.Errorf("IDs = %q, %q, %q, want one ID from kind, name, file and line", all[0].ID, all[1].ID, all[2].ID)
+	}
+	moved := f
+	moved.Line = 4
+	if moved.ComputeID() == f.ComputeID() {
+		t.Error("facts on different lines should get different IDs")
+	}
+}
+
 func TestJSONL_RoundTrip(t *testing.T) {
 	original := NewStore()
 	original.Add(
@@ -199,14 +234,19 @@ func TestJSONL_RoundTrip(t *testing.T) {
 		t.Fatalf("count mismatch: got %d, want %d", restored.Count(), original.Count())
 	}
 
-	origAll := original.All()
-	restAll := restored.All()
-
-	for i := range origAll {
-		o, r := origAll[i], restAll[i]
+	// Facts are written in canonical order, so match them up by name.
+	restByName := make(map[string]Fact)
+	for _, f := range restored.All() {
+		restByName[f.Name] = f
+	}
+	for i, o := range original.All() {
+		r := restByName[o.Name]
 		if o.Kind != r.Kind || o.Name != r.Name || o.File != r.File || o.Line != r.Line {
 			t.Errorf("fact[%d] basic fields mismatch: %+v vs %+v", i, o, r)
 		}
+		if o.ID == "" || o.ID != r.ID {
+			t.Errorf("fact[%d] ID = %q after round-trip, want %q", i, r.ID, o.ID)
+		}
 		if len(o.Relations) != len(r.Relations) {
 			t.Errorf("fact[%d] relations count: %d vs %d", i, len(o.Relations), len(r.Relations))
 		}
@@ -233,6 +273,47 @@ func TestJSONL_RoundTrip(t *testing.T) {
 	}
 }
 
+func TestJSONL_CanonicalOrder(t *testing.T) {
+	ff := []Fact{
+		{Kind: KindSymbol, Name: "pkg.B", File: "pkg/b.go", Line: 3, Props: map[string]any{"z": 1, "a": map[string]any{"y": 1, "b": 2}}},
+		{Kind: KindModule, Name: "pkg", File: "pkg"},
+		{Kind: KindSymbol, Name: "pkg.A2", File: "pkg/a.go", Line: 10},
+		{Kind: KindSymbol, Name: "pkg.A1", File: "pkg/a.go", Line: 10},
+		{Kind: KindSymbol, Name: "pkg.A0", File: "pkg/a.go", Line: 2},
+	}
+	write := func(ff []Fact) string {
+		s := NewStore()
+		s.Add(ff...)
+		var buf bytes.Buffer
+		if err := s.WriteJSONL(&buf); err != nil {
+			t.Fatalf("WriteJSONL: %v", err)
+		}
+		return buf.String()
+	}
+
+	out := write(ff)
+	reversed := slices.Clone(ff)
+	slices.Reverse(reversed)
+	if again := write(reversed); again != out {
+		t.Errorf("output depends on store order:\n%s\nvs\n%s", out, again)
+	}
+
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var f Fact
+		if err := json.Unmarshal([]byte(line), &f); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, f.Name)
+	}
+	if want := []string{"pkg", "pkg.A0", "pkg.A1", "pkg.A2", "pkg.B"}; !slices.Equal(names, want) {
+		t.Errorf("order = %v, want %v (kind, file, line, name)", names, want)
+	}
+	if !strings.Contains(out, `"props":{"a":{"b":2,"y":1},"z":1}`) {
+		t.Errorf("props keys should be sorted, got:\n%s", out)
+	}
+}
+
 func TestJSONL_SkipsEmptyLines(t *testing.T) {
 	s := NewStore()
 	// JSONL with blank lines and trailing newline
@@ -249,6 +330,38 @@ func TestJSONL_SkipsEmptyLines(t *testing.T) {
 	}
 }
 
+func TestJSONL_GzipFile(t *testing.T) {
+	s := NewStore()
+	s.Add(makeFact(KindModule, "internal/a", "internal/a"), makeFact(KindModule, "internal/b", "internal/b"))
+	dir := t.TempDir()
+	path := filepath.Join(dir, "facts.jsonl.gz")
+	if err := s.WriteJSONLFile(path); err != nil {
+		t.Fatalf("WriteJSONLFile: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		t.Fatalf("facts.jsonl.gz does not start with the gzip magic: % x", data[:min(len(data), 2)])
+	}
+	restored := NewStore()
+	if err := restored.ReadJSONLFile(path); err != nil {
+		t.Fatalf("ReadJSONLFile: %v", err)
+	}
+	if restored.Count() != 2 || len(restored.LookupByExactName("internal/b")) != 1 {
+		t.Errorf("restored %d facts, want internal/a and internal/b", restored.Count())
+	}
+
+	// A .gz file that is not gzip is an error, not an empty store.
+	plain := filepath.Join(dir, "plain.jsonl.gz")
+	os.WriteFile(plain, []byte(`{"kind":"module","name":"a"}`+"\n"), 0o644)
+	if err := NewStore().ReadJSONLFile(plain); err == nil {
+		t.Error("expected an error reading an uncompressed .gz file")
+	}
+}
+
 func TestJSONL_EmptyStore(t *testing.T) {
 	s := NewStore()
 	var buf bytes.Buffer
@@ -374,6 +487,137 @@ func TestQueryAdvanced_FilePrefix(t *testing.T) {
 	}
 }
 
+func TestQueryAdvanced_LineRange(t *testing.T) {
+	s := NewStore()
+	at := func(name string, line int) Fact {
+		f := makeSymbol(name, "internal/server/server.go", SymbolFunc, true)
+		f.Line = line
+		return f
+	}
+	s.Add(at("Before", 90), at("Start", 100), at("Inside", 120), at("End", 150), at("After", 151), at("NoLine", 0),
+		makeSymbol("Elsewhere", "internal/facts/store.go", SymbolFunc, true))
+
+	names := func(opts QueryOpts) []string {
+		results, _ := s.QueryAdvanced(opts)
+		var got []string
+		for _, f := range results {
+			got = append(got, f.Name)
+		}
+		return got
+	}
+	if got := names(QueryOpts{File: "internal/server/server.go", LineMin: 100, LineMax: 150}); strings.Join(got, ",") != "Start,Inside,End" {
+		t.Errorf("lines 100-150 = %v, want Start, Inside, End", got)
+	}
+	if got := names(QueryOpts{FilePrefix: "internal/", LineMin: 151}); strings.Join(got, ",") != "After" {
+		t.Errorf("lines from 151 = %v, want After", got)
+	}
+	if got := names(QueryOpts{File: "internal/server/server.go", LineMax: 90}); strings.Join(got, ",") != "Before" {
+		t.Errorf("lines up to 90 = %v, want Before", got)
+	}
+}
+
+func TestStore_CaseInsensitivePaths(t *testing.T) {
+	s := NewStore()
+	s.Add(
+		makeSymbol("Foo", "Internal/Server/server.go", SymbolFunc, true),
+		makeSymbol("Bar", "internal/server/handler.go", SymbolFunc, true),
+		makeSymbol("Baz", "internal/facts/store.go", SymbolFunc, true),
+	)
+
+	if got := s.ByFile("internal/server/server.go"); len(got) != 0 {
+		t.Errorf("case-sensitive ByFile = %d facts, want 0", len(got))
+	}
+
+	s.SetCaseInsensitivePaths(true)
+	if got := s.ByFile("internal/server/server.go"); len(got) != 1 || got[0].File != "Internal/Server/server.go" {
+		t.Errorf("ByFile = %+v, want the fact with its stored path", got)
+	}
+	if got := s.Query(KindSymbol, "INTERNAL/SERVER/SERVER.GO", "", ""); len(got) != 1 {
+		t.Errorf("Query by file = %d facts, want 1", len(got))
+	}
+	if _, total := s.QueryAdvanced(QueryOpts{File: "internal/server/SERVER.go"}); total != 1 {
+		t.Errorf("QueryAdvanced File total = %d, want 1", total)
+	}
+	if _, total := s.QueryAdvanced(QueryOpts{Files: []string{"internal/server/server.go", "INTERNAL/FACTS/store.go"}}); total != 2 {
+		t.Errorf("QueryAdvanced Files total = %d, want 2", total)
+	}
+	if _, total := s.QueryAdvanced(QueryOpts{FilePrefix: "internal/server"}); total != 2 {
+		t.Errorf("QueryAdvanced FilePrefix total = %d, want 2", total)
+	}
+	if _, total := s.QueryAdvanced(QueryOpts{NotFilePrefix: "INTERNAL/SERVER"}); total != 1 {
+		t.Errorf("QueryAdvanced NotFilePrefix total = %d, want 1", total)
+	}
+
+	// The folded-path index follows added files; keys differing only in
+	// case are merged in fact order.
+	s.Add(makeSymbol("Qux", "internal/server/Server.go", SymbolFunc, true))
+	if got := s.ByFile("internal/server/server.go"); len(got) != 2 || got[0].Name != "Foo" || got[1].Name != "Qux" {
+		t.Errorf("ByFile after add = %+v, want Foo then Qux", got)
+	}
+	if _, total := s.QueryAdvanced(QueryOpts{File: "INTERNAL/server/server.go"}); total != 2 {
+		t.Errorf("QueryAdvanced File total after add = %d, want 2", total)
+	}
+}
+
+func TestQueryAdvanced_FilePrefixIndex(t *testing.T) {
+	s := NewStore()
+	s.Add(
+		makeSymbol("A", "internal/server/b.go", SymbolFunc, true),
+		makeModule("internal/server"),
+		makeSymbol("B", "internal/facts/store.go", SymbolFunc, true),
+		makeSymbol("C", "internal/server/a.go", SymbolFunc, true),
+		makeSymbol("D", "internal/serverless/x.go", SymbolFunc, true),
+	)
+
+	names := func(ff []Fact) []string {
+		var out []string
+		for _, f := range ff {
+			out = append(out, f.Name)
+		}
+		return out
+	}
+
+	// Results keep fact order, not file order.
+	got, total := s.QueryAdvanced(QueryOpts{Kind: KindSymbol, FilePrefix: "internal/server/"})
+	if total != 2 || !slices.Equal(names(got), []string{"A", "C"}) {
+		t.Errorf("prefix query = %v (total %d), want [A C]", names(got), total)
+	}
+	if _, total := s.QueryAdvanced(QueryOpts{FilePrefix: "internal/nope"}); total != 0 {
+		t.Errorf("unknown prefix total = %d, want 0", total)
+	}
+
+	// The index follows added, renamed and removed files.
+	s.Add(makeSymbol("E", "internal/server/c.go", SymbolFunc, true))
+	if _, total := s.QueryAdvanced(QueryOpts{FilePrefix: "internal/server/"}); total != 3 {
+		t.Errorf("after Add total = %d, want 3", total)
+	}
+	s.RemoveByFile("internal/server/b.go")
+	got, _ = s.QueryAdvanced(QueryOpts{FilePrefix: "internal/server/"})
+	if !slices.Equal(names(got), []string{"C", "E"}) {
+		t.Errorf("after RemoveByFile = %v, want [C E]", names(got))
+	}
+	s.TagUntagged("svc", "svc/")
+	if _, total := s.QueryAdvanced(QueryOpts{FilePrefix: "internal/server/"}); total != 0 {
+		t.Errorf("old prefix after TagUntagged total = %d, want 0", total)
+	}
+	if _, total := s.QueryAdvanced(QueryOpts{FilePrefix: "svc/internal/server"}); total != 3 {
+		t.Errorf("new prefix after TagUntagged total = %d, want 3", total)
+	}
+}
+
+func BenchmarkQueryAdvanced_FilePrefix(b *testing.B) {
+	s := NewStore()
+	for d := range 500 {
+		for f := range 20 {
+			s.Add(makeSymbol(fmt.Sprintf("pkg%d.F%d", d, f), fmt.Sprintf("pkg%d/file%d.go", d, f), SymbolFunc, true))
+		}
+	}
+	b.ResetTimer()
+	for range b.N {
+		s.QueryAdvanced(QueryOpts{FilePrefix: "pkg42/"})
+	}
+}
+
 func TestQueryAdvanced_FileAndFilePrefixCombine(t *testing.T) {
 	s := NewStore()
 	s.Add(
@@ -452,6 +696,61 @@ func TestQueryAdvanced_PropFilterBeforeLimit(t *testing.T) {
 	}
 }
 
+func TestQueryAdvanced_PropNumericOps(t *testing.T) {
+	s := NewStore()
+	for name, v := range map[string]any{"low": 5, "mid": 20.0, "high": 42.5, "text": "30", "na": "n/a"} {
+		s.Add(Fact{Kind: KindSymbol, Name: name, File: "a.go", Props: map[string]any{"complexity": v}})
+	}
+
+	tests := []struct {
+		op, value string
+		want      []string
+	}{
+		{PropOpGt, "20", []string{"high", "text"}},
+		{PropOpGte, "20", []string{"high", "mid", "text"}},
+		{PropOpLt, "20", []string{"low"}},
+		{PropOpLte, "5", []string{"low"}},
+		{PropOpEq, "20", []string{"mid"}},
+		{PropOpGt, "abc", nil},
+	}
+	for _, tt := range tests {
+		results, _ := s.QueryAdvanced(QueryOpts{Prop: "complexity", PropValue: tt.value, PropOp: tt.op})
+		var got []string
+		for _, f := range results {
+			got = append(got, f.Name)
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("complexity %s %s = %v, want %v", tt.op, tt.value, got, tt.want)
+		}
+	}
+
+	if !ValidPropOp("") || !ValidPropOp(PropOpGte) || ValidPropOp("ne") {
+		t.Error("ValidPropOp accepted or rejected the wrong operators")
+	}
+}
+
+func TestQueryAdvanced_PropListMembership(t *testing.T) {
+	s := NewStore()
+	s.Add(
+		Fact{Kind: KindSymbol, Name: "User", File: "a.go", Props: map[string]any{"json_keys": []string{"id", "user_id"}}},
+		// Facts loaded from a persisted snapshot hold []any.
+		Fact{Kind: KindSymbol, Name: "Order", File: "a.go", Props: map[string]any{"json_keys": []any{"id", "order_id"}}},
+	)
+
+	for value, want := range map[string]string{"user_id": "User", "order_id": "Order", "id": "Order,User", "[id user_id]": ""} {
+		results, _ := s.QueryAdvanced(QueryOpts{Prop: "json_keys", PropValue: value})
+		var got []string
+		for _, f := range results {
+			got = append(got, f.Name)
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != want {
+			t.Errorf("json_keys = %q matched %v, want %q", value, got, want)
+		}
+	}
+}
+
 func TestQueryAdvanced_Pagination(t *testing.T) {
 	s := NewStore()
 	for i := 0; i < 10; i++ {
@@ -542,6 +841,52 @@ func TestQueryAdvanced_CrossDimensionAND(t *testing.T) {
 	}
 }
 
+func TestQueryAdvanced_NotFilters(t *testing.T) {
+	s := NewStore()
+	s.Add(
+		makeSymbol("Foo", "internal/a/foo.go", SymbolFunc, true),
+		makeSymbol("bar", "internal/a/bar.go", SymbolFunc, false),
+		makeSymbol("baz", "internal/a/gen/baz.go", SymbolFunc, false),
+		makeModule("internal/a"),
+	)
+
+	// Unexported symbols outside the generated directory.
+	results, total := s.QueryAdvanced(QueryOpts{
+		FilePrefix:    "internal/a",
+		NotKinds:      []string{KindModule},
+		NotFilePrefix: "internal/a/gen",
+		NotProp:       "exported",
+		NotPropValue:  "true",
+	})
+	if total != 1 {
+		t.Errorf("total = %d, want 1", total)
+	}
+	if len(results) != 1 || results[0].Name != "bar" {
+		t.Errorf("expected only bar, got %v", results)
+	}
+
+	// NotProp without a value excludes any fact carrying the property.
+	_, total = s.QueryAdvanced(QueryOpts{NotProp: "symbol_kind"})
+	if total != 1 {
+		t.Errorf("NotProp without value: total = %d, want 1 (the module)", total)
+	}
+}
+
+func TestQueryAdvanced_NotFiltersBeforePagination(t *testing.T) {
+	s := NewStore()
+	for i := 0; i < 10; i++ {
+		s.Add(makeSymbol(fmt.Sprintf("Sym%d", i), "a.go", SymbolFunc, i%2 == 0))
+	}
+
+	results, total := s.QueryAdvanced(QueryOpts{Kind: KindSymbol, NotProp: "exported", NotPropValue: "true", Limit: 2})
+	if total != 5 {
+		t.Errorf("total = %d, want 5", total)
+	}
+	if len(results) != 2 {
+		t.Errorf("results = %d, want 2", len(results))
+	}
+}
+
 func TestLookupByExactName(t *testing.T) {
 	s := NewStore()
 	s.Add(
@@ -565,6 +910,47 @@ func TestLookupByExactName(t *testing.T) {
 	}
 }
 
+func TestLookupByPrefix(t *testing.T) {
+	s := NewStore()
+	s.Add(
+		makeFact(KindSymbol, "pkg.FooBar", "b.go"),
+		makeFact(KindSymbol, "pkg.Foo", "a.go"),
+		makeFact(KindModule, "pkg.Foo", "a.go"),
+		makeFact(KindSymbol, "pkg.Bar", "c.go"),
+		makeFact(KindSymbol, "other.Foo", "d.go"),
+	)
+
+	got := s.LookupByPrefix("pkg.Foo", 0)
+	var names []string
+	for _, f := range got {
+		names = append(names, f.Name)
+	}
+	want := []string{"pkg.Foo", "pkg.Foo", "pkg.FooBar"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("LookupByPrefix(pkg.Foo) = %v, want %v", names, want)
+	}
+	if got := s.LookupByPrefix("pkg.", 2); len(got) != 2 || got[0].Name != "pkg.Bar" {
+		t.Errorf("LookupByPrefix(pkg., 2) = %v, want 2 facts starting at pkg.Bar", got)
+	}
+	if got := s.LookupByPrefix("nope", 0); len(got) != 0 {
+		t.Errorf("LookupByPrefix(nope) = %v, want none", got)
+	}
+
+	if got := s.NamesByPrefix("pkg.", 0); strings.Join(got, ",") != "pkg.Bar,pkg.Foo,pkg.FooBar" {
+		t.Errorf("NamesByPrefix(pkg.) = %v", got)
+	}
+
+	// The index picks up names added after it was built, and Clear resets it.
+	s.Add(makeFact(KindSymbol, "pkg.Baz", "e.go"))
+	if got := s.NamesByPrefix("pkg.Ba", 0); strings.Join(got, ",") != "pkg.Bar,pkg.Baz" {
+		t.Errorf("NamesByPrefix after Add = %v", got)
+	}
+	s.Clear()
+	if got := s.LookupByPrefix("pkg.", 0); len(got) != 0 {
+		t.Errorf("LookupByPrefix after Clear = %v, want none", got)
+	}
+}
+
 func TestReverseLookup(t *testing.T) {
 	s := NewStore()
 	s.Add(
@@ -703,6 +1089,9 @@ func TestTagRange(t *testing.T) {
 	if len(new1) != 1 {
 		t.Fatalf("New1 not found")
 	}
+	if new1[0].ID != new1[0].ComputeID() {
+		t.Errorf("New1.ID = %q, want it recomputed for the prefixed file", new1[0].ID)
+	}
 	if new1[0].Repo != "repo-b" {
 		t.Errorf("New1.Repo = %q, want repo-b", new1[0].Repo)
 	}
@@ -735,6 +1124,39 @@ func TestTagRange(t *testing.T) {
 	}
 }
 
+func TestTagRange_PrefixesConfigReads(t *testing.T) {
+	env := func(reads any) Fact {
+		return Fact{Kind: KindConfig, Name: "env:DATABASE_URL", File: "db.go", Line: 3,
+			Props: map[string]any{"source": "env", "reads": reads}}
+	}
+	s := NewStore()
+	s.Add(env([]any{"db.go:3", "cmd/main.go:9"})) // as loaded from facts.jsonl
+	s.TagUntagged("a", "a/")
+	start := s.Count()
+	s.Add(env([]string{"db.go:3"}))
+	before := s.Clone()
+	s.TagRange(start, "b", "b/")
+
+	for _, tt := range []struct {
+		repo string
+		want []string
+	}{
+		{"a", []string{"a/db.go:3", "a/cmd/main.go:9"}},
+		{"b", []string{"b/db.go:3"}},
+	} {
+		got := s.ByRepo(tt.repo)
+		if len(got) != 1 || !slices.Equal(got[0].Props["reads"].([]string), tt.want) {
+			t.Errorf("repo %s facts = %+v, want reads %v", tt.repo, got, tt.want)
+		}
+	}
+	if got := before.ByRepo("b"); len(got) != 0 {
+		t.Fatalf("clone should not see the tagging, got %+v", got)
+	}
+	if got := before.All()[start].Props["reads"].([]string); !slices.Equal(got, []string{"db.go:3"}) {
+		t.Errorf("clone reads = %v, want them unprefixed", got)
+	}
+}
+
 func TestTagRange_FilePrefixQuery(t *testing.T) {
 	s := NewStore()
 	// Simulate multi-repo: two repos tagged differently
@@ -892,7 +1314,7 @@ func TestConcurrentAccess(t *testing.T) {
 		wg.Add(1)
 		go func(i int) {
 			defer wg.Done()
-			s.Add(makeFact(KindSymbol, "sym", "file.go"))
+			s.Add(makeFact(KindSymbol, fmt.Sprintf("sym%d", i), "file.go"))
 		}(i)
 	}
 
@@ -914,3 +1336,168 @@ func TestConcurrentAccess(t *testing.T) {
 		t.Errorf("after concurrent adds: Count() = %d, want %d", got, n)
 	}
 }
+
+func TestFiles(t *testing.T) {
+	s := NewStore()
+	s.Add(
+		Fact{Kind: KindSymbol, Name: "b", File: "svc/b.go", Repo: "svc"},
+		Fact{Kind: KindSymbol, Name: "a", File: "web/a.ts", Repo: "web"},
+		Fact{Kind: KindModule, Name: "svc", File: "svc/b.go", Repo: "svc"},
+		Fact{Kind: KindDependency, Name: "no-file"},
+	)
+	if got := s.Files(""); strings.Join(got, ",") != "svc/b.go,web/a.ts" {
+		t.Errorf("Files() = %v", got)
+	}
+	if got := s.Files("web"); strings.Join(got, ",") != "web/a.ts" {
+		t.Errorf("Files(web) = %v", got)
+	}
+}
+
+func TestStore_ExtendGraphAndRemoveByFile(t *testing.T) {
+	first, second := incrementalFacts()
+	s := NewStore()
+	s.Add(first...)
+	s.BuildGraph()
+	s.Add(second...)
+	s.ExtendGraph(len(first))
+
+	rebuilt := NewStore()
+	rebuilt.Add(s.All()...)
+	rebuilt.BuildGraph()
+	assertSameGraph(t, s.Graph(), rebuilt.Graph())
+
+	if n := s.RemoveByFile("cmd/main.go"); n != 2 {
+		t.Fatalf("RemoveByFile = %d, want 2", n)
+	}
+	if len(s.ByFile("cmd/main.go")) != 0 || len(s.ByName("api.Put")) != 1 {
+		t.Error("store indexes not updated after RemoveByFile")
+	}
+	rebuilt = NewStore()
+	rebuilt.Add(s.All()...)
+	rebuilt.BuildGraph()
+	assertSameGraph(t, s.Graph(), rebuilt.Graph())
+	if r := s.Graph().ReverseFacts("api.Get", RelCalls); len(r) != 0 {
+		t.Errorf("expected no callers of api.Get, got %v", r)
+	}
+}
+
+func TestStore_TagAndRemoveFuncKeepGraph(t *testing.T) {
+	first, second := incrementalFacts()
+	s := NewStore()
+	s.Add(append(append([]Fact{}, first...), second...)...)
+	s.BuildGraph()
+	rebuild := func() *Graph {
+		r := NewStore()
+		r.Add(s.All()...)
+		r.BuildGraph()
+		return r.Graph()
+	}
+
+	// Without store/sql the api import resolves to its parent module again.
+	if n := s.RemoveFunc(func(f Fact) bool { return f.Kind == KindModule && f.Name == "store/sql" }); n != 1 {
+		t.Fatalf("RemoveFunc = %d, want 1", n)
+	}
+	assertSameGraph(t, s.Graph(), rebuild())
+	if s.Graph().EdgeWeight("api", "store") != 1 {
+		t.Error("expected api -> store after removing store/sql")
+	}
+	if s.RemoveFunc(func(Fact) bool { return false }) != 0 {
+		t.Error("RemoveFunc removing nothing should report 0")
+	}
+
+	// Prefixed files no longer sit in a module directory, so the module
+	// import edges of the dependency facts go away, as in a rebuild.
+	if n := s.TagUntagged("repo", "repo/"); n == 0 {
+		t.Fatal("TagUntagged tagged nothing")
+	}
+	assertSameGraph(t, s.Graph(), rebuild())
+	if f := s.Graph().factFor("api.Get"); f.Repo != "repo" || f.File != "repo/api/h.go" {
+		t.Errorf("graph fact after TagUntagged = %+v", f)
+	}
+}
+
+func TestStore_CloneAndReplace(t *testing.T) {
+	first, second := incrementalFacts()
+	s := NewStore()
+	s.Add(first...)
+	s.BuildGraph()
+	edges := s.Graph().EdgeCount()
+
+	c := s.Clone()
+	c.TagUntagged("repo", "repo/")
+	c.RemoveByFile("repo/store/load.go")
+	n := c.Count()
+	c.Add(second...)
+	c.ExtendGraph(n)
+
+	if s.Count() != len(first) || len(s.ByFile("api/h.go")) != 2 || len(s.ByRepo("repo")) != 0 {
+		t.Errorf("changing the clone changed the original store: %v", s.All())
+	}
+	if s.Graph().EdgeCount() != edges || s.Graph().EdgeWeight("api", "store") != 1 {
+		t.Error("changing the clone changed the original graph")
+	}
+
+	g := s.Graph()
+	s.Replace(c)
+	if s.Count() != c.Count() || len(s.ByFile("repo/api/h.go")) != 2 || s.Graph() == g {
+		t.Errorf("Replace did not install the clone's contents: %v", s.All())
+	}
+	rebuilt := NewStore()
+	rebuilt.Add(s.All()...)
+	rebuilt.BuildGraph()
+	assertSameGraph(t, s.Graph(), rebuilt.Graph())
+}
+
+func TestQueryAdvanced_ExportedOnly(t *testing.T) {
+	s := NewStore()
+	s.Add(
+		Fact{Kind: KindSymbol, Name: "pkg.New", Props: map[string]any{"exported": true}},
+		Fact{Kind: KindSymbol, Name: "pkg.helper", Props: map[string]any{"exported": false}},
+		Fact{Kind: KindModule, Name: "pkg"},
+		Fact{Kind: KindRoute, Name: "/users"},
+		Fact{Kind: KindStorage, Name: "users"},
+	)
+	results, total := s.QueryAdvanced(QueryOpts{ExportedOnly: true})
+	var names []string
+	for _, f := range results {
+		names = append(names, f.Name)
+	}
+	if total != 3 || strings.Join(names, ",") != "pkg.New,/users,users" {
+		t.Errorf("ExportedOnly = %v (total %d), want pkg.New, /users, users", names, total)
+	}
+
+	public := s.Filter(IsPublic)
+	if public.Count() != 3 || len(public.LookupByExactName("pkg.helper")) != 0 || len(public.ByKind(KindRoute)) != 1 {
+		t.Errorf("Filter(IsPublic) kept %v", public.All())
+	}
+	if s.Count() != 5 {
+		t.Errorf("Filter must not modify the source store; count = %d", s.Count())
+	}
+}
+
+func TestSplitSymbolName(t *testing.T) {
+	tests := []struct {
+		name, pkg, rest string
+		ok              bool
+	}{
+		{"internal/util.Helper", "internal/util", "Helper", true},
+		{"internal/util.Repo.Find", "internal/util", "Repo.Find", true},
+		{"util.Helper", "util", "Helper", true},
+		{"internal/util", "", "", false},
+		{".Helper", "", "", false},
+	}
+	for _, tt := range tests {
+		pkg, rest, ok := SplitSymbolName(tt.name)
+		if pkg != tt.pkg || rest != tt.rest || ok != tt.ok {
+			t.Errorf("SplitSymbolName(%q) = %q, %q, %v; want %q, %q, %v", tt.name, pkg, rest, ok, tt.pkg, tt.rest, tt.ok)
+		}
+	}
+
+	f := Fact{Name: "util.Helper", Relations: []Relation{{Kind: RelCalls, Target: "x"}, {Kind: RelDeclares, Target: "util"}}}
+	if got := DeclaringModule(f); got != "util" {
+		t.Errorf("DeclaringModule = %q, want util", got)
+	}
+	if got := DeclaringModule(Fact{Name: "x"}); got != "" {
+		t.Errorf("DeclaringModule without declares = %q, want empty", got)
+	}
+}
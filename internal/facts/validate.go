@@ -0,0 +1,143 @@
+package facts
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ValidationReport is the result of Validate: the internal consistency
+// problems of a set of facts, with counts and up to a limit of samples per
+// check.
+type ValidationReport struct {
+	OK        bool `json:"ok"` // no dangling relations, missing files or duplicate names
+	Facts     int  `json:"facts"`
+	Relations int  `json:"relations"`
+
+	// Dangling relations target a name no fact has. Relations of external
+	// and stdlib dependency facts point outside the repo by design and are
+	// only counted. Call targets are recorded as written at the call site
+	// (strings.Contains, s.resolve), so most calls relations dangle.
+	DanglingCount     int                `json:"dangling_count"`
+	DanglingByKind    map[string]int     `json:"dangling_by_kind,omitempty"`
+	Dangling          []DanglingRelation `json:"dangling,omitempty"`
+	ExternalRelations int                `json:"external_relations"`
+
+	// Missing files are fact files that do not exist on disk.
+	MissingFileCount int      `json:"missing_file_count"`
+	MissingFiles     []string `json:"missing_files,omitempty"`
+
+	// Duplicate names are symbol names shared by several symbol facts.
+	DuplicateNameCount int             `json:"duplicate_name_count"`
+	DuplicateNames     []DuplicateName `json:"duplicate_names,omitempty"`
+
+	// Truncated is set when a check found more problems than the limit.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// DanglingRelation is a relation whose target resolves to no fact.
+type DanglingRelation struct {
+	From   string `json:"from"`
+	Kind   string `json:"kind"`
+	Target string `json:"target"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+}
+
+// DuplicateName is a symbol name declared by several symbol facts, with the
+// "file:line" of each.
+type DuplicateName struct {
+	Name      string   `json:"name"`
+	Locations []string `json:"locations"`
+}
+
+// Validate checks ff for dangling relations, facts whose file is missing
+// (fileExists reports whether a fact's file exists; nil skips the check) and
+// duplicate symbol names. Samples are sorted and capped at limit per check
+// (0 = 20). Overloaded methods share a name by design and are reported as
+// duplicates too.
+func Validate(ff []Fact, fileExists func(Fact) bool, limit int) ValidationReport {
+	if limit <= 0 {
+		limit = 20
+	}
+	names := make(map[string]bool, len(ff))
+	for _, f := range ff {
+		names[f.Name] = true
+	}
+
+	r := ValidationReport{Facts: len(ff)}
+	checkedFiles := make(map[string]bool)
+	var missing []string
+	symbols := make(map[string][]string) // symbol name -> locations
+	for _, f := range ff {
+		external := f.Kind == KindDependency && (f.Props["source"] == "external" || f.Props["source"] == "stdlib")
+		for _, rel := range f.Relations {
+			r.Relations++
+			if names[rel.Target] {
+				continue
+			}
+			if external {
+				r.ExternalRelations++
+				continue
+			}
+			r.DanglingCount++
+			if r.DanglingByKind == nil {
+				r.DanglingByKind = make(map[string]int)
+			}
+			r.DanglingByKind[rel.Kind]++
+			r.Dangling = append(r.Dangling, DanglingRelation{From: f.Name, Kind: rel.Kind, Target: rel.Target, File: f.File, Line: f.Line})
+		}
+
+		if fileExists != nil && f.File != "" {
+			key := f.Repo + "\x00" + f.File
+			if _, done := checkedFiles[key]; !done {
+				checkedFiles[key] = true
+				if !fileExists(f) {
+					missing = append(missing, f.File)
+				}
+			}
+		}
+
+		if f.Kind == KindSymbol {
+			symbols[f.Name] = append(symbols[f.Name], fmt.Sprintf("%s:%d", f.File, f.Line))
+		}
+	}
+
+	sort.Slice(r.Dangling, func(i, j int) bool {
+		a, b := r.Dangling[i], r.Dangling[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Target < b.Target
+	})
+	if len(r.Dangling) > limit {
+		r.Dangling = r.Dangling[:limit]
+		r.Truncated = true
+	}
+
+	sort.Strings(missing)
+	r.MissingFileCount = len(missing)
+	if len(missing) > limit {
+		missing = missing[:limit]
+		r.Truncated = true
+	}
+	r.MissingFiles = missing
+
+	for name, locations := range symbols {
+		if len(locations) > 1 {
+			sort.Strings(locations)
+			r.DuplicateNames = append(r.DuplicateNames, DuplicateName{Name: name, Locations: locations})
+		}
+	}
+	sort.Slice(r.DuplicateNames, func(i, j int) bool { return r.DuplicateNames[i].Name < r.DuplicateNames[j].Name })
+	r.DuplicateNameCount = len(r.DuplicateNames)
+	if len(r.DuplicateNames) > limit {
+		r.DuplicateNames = r.DuplicateNames[:limit]
+		r.Truncated = true
+	}
+
+	r.OK = r.DanglingCount == 0 && r.MissingFileCount == 0 && r.DuplicateNameCount == 0
+	return r
+}
@@ -0,0 +1,78 @@
+package facts
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	ff := []Fact{
+		{Kind: KindModule, Name: "internal/a", File: "internal/a"},
+		{Kind: KindSymbol, Name: "internal/a.Run", File: "internal/a/a.go", Line: 3,
+			Relations: []Relation{
+				{Kind: RelDeclares, Target: "internal/a"},
+				{Kind: RelCalls, Target: "internal/a.missing"},
+			}},
+		{Kind: KindSymbol, Name: "internal/a.Load", File: "internal/a/a.go", Line: 9},
+		{Kind: KindSymbol, Name: "internal/a.Load", File: "internal/a/gone.go", Line: 2},
+		// Internal import of a module that is not in the snapshot: dangling.
+		{Kind: KindDependency, Name: "internal/a -> internal/b", File: "internal/a/a.go",
+			Props:     map[string]any{"source": "internal"},
+			Relations: []Relation{{Kind: RelImports, Target: "internal/b"}}},
+		// Stdlib import: outside the repo by design.
+		{Kind: KindDependency, Name: "internal/a -> fmt", File: "internal/a/a.go",
+			Props:     map[string]any{"source": "stdlib"},
+			Relations: []Relation{{Kind: RelImports, Target: "fmt"}}},
+	}
+	exists := func(f Fact) bool { return f.File != "internal/a/gone.go" }
+
+	r := Validate(ff, exists, 0)
+
+	if r.OK {
+		t.Error("OK = true, want false")
+	}
+	if r.Facts != 6 || r.Relations != 4 {
+		t.Errorf("facts, relations = %d, %d, want 6, 4", r.Facts, r.Relations)
+	}
+	if r.DanglingCount != 2 || r.DanglingByKind[RelCalls] != 1 || r.DanglingByKind[RelImports] != 1 {
+		t.Errorf("dangling = %d %v, want 1 calls and 1 imports", r.DanglingCount, r.DanglingByKind)
+	}
+	if len(r.Dangling) != 2 || r.Dangling[0].From != "internal/a -> internal/b" || r.Dangling[1].Target != "internal/a.missing" {
+		t.Errorf("dangling samples = %+v, want sorted by from", r.Dangling)
+	}
+	if r.ExternalRelations != 1 {
+		t.Errorf("external relations = %d, want 1", r.ExternalRelations)
+	}
+	if r.MissingFileCount != 1 || !slices.Equal(r.MissingFiles, []string{"internal/a/gone.go"}) {
+		t.Errorf("missing files = %d %v, want internal/a/gone.go", r.MissingFileCount, r.MissingFiles)
+	}
+	want := []DuplicateName{{Name: "internal/a.Load", Locations: []string{"internal/a/a.go:9", "internal/a/gone.go:2"}}}
+	if r.DuplicateNameCount != 1 || len(r.DuplicateNames) != 1 || r.DuplicateNames[0].Name != want[0].Name ||
+		!slices.Equal(r.DuplicateNames[0].Locations, want[0].Locations) {
+		t.Errorf("duplicate names = %+v, want %+v", r.DuplicateNames, want)
+	}
+	if r.Truncated {
+		t.Error("Truncated = true, want false")
+	}
+
+	// The limit caps the samples but not the counts; a nil fileExists skips
+	// the file check.
+	r = Validate(ff, nil, 1)
+	if r.DanglingCount != 2 || len(r.Dangling) != 1 || !r.Truncated {
+		t.Errorf("limited dangling = %d, %d samples, truncated %v, want 2, 1, true", r.DanglingCount, len(r.Dangling), r.Truncated)
+	}
+	if r.MissingFileCount != 0 {
+		t.Errorf("missing files without fileExists = %d, want 0", r.MissingFileCount)
+	}
+}
+
+func TestValidate_Consistent(t *testing.T) {
+	ff := []Fact{
+		{Kind: KindModule, Name: "internal/a", File: "internal/a"},
+		{Kind: KindSymbol, Name: "internal/a.Run", File: "internal/a/a.go", Line: 3,
+			Relations: []Relation{{Kind: RelDeclares, Target: "internal/a"}}},
+	}
+	if r := Validate(ff, func(Fact) bool { return true }, 0); !r.OK {
+		t.Errorf("report = %+v, want OK", r)
+	}
+}
@@ -0,0 +1,150 @@
+package insightsmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// InsightsRenderer emits insights.md, a human-readable report of the
+// snapshot's insights grouped by category, suitable for attaching to a pull
+// request or an architecture review.
+type InsightsRenderer struct{}
+
+// New creates a new InsightsRenderer.
+func New() *InsightsRenderer {
+	return &InsightsRenderer{}
+}
+
+func (r *InsightsRenderer) Name() string {
+	return "insights_md"
+}
+
+// category groups insights by the title prefix their explainer uses.
+type category struct {
+	heading string
+	match   func(title string) bool
+}
+
+// categories lists the report sections in order. Insights matching none of
+// them are reported under Other.
+var categories = []category{
+	{"Architecture Patterns", func(t string) bool { return strings.HasPrefix(t, "Architecture pattern:") }},
+	{"Cycles", func(t string) bool { return strings.HasPrefix(t, "Cyclic dependency") }},
+	{"Layer Violations", func(t string) bool { return strings.HasPrefix(t, "Layer violation:") }},
+	{"Hotspots", func(t string) bool {
+		return strings.HasPrefix(t, "God module:") || strings.HasPrefix(t, "God object:")
+	}},
+}
+
+// Render produces the insights.md artifact.
+func (r *InsightsRenderer) Render(ctx context.Context, snapshot *facts.Snapshot) ([]facts.Artifact, error) {
+	return []facts.Artifact{
+		{
+			Name:    "insights.md",
+			Content: []byte(render(snapshot)),
+			Type:    "text/markdown",
+		},
+	}, nil
+}
+
+func render(snapshot *facts.Snapshot) string {
+	var sb strings.Builder
+	sb.WriteString("# Architectural Insights\n\n")
+	if snapshot.Meta.RepoPath != "" {
+		sb.WriteString(fmt.Sprintf("Repository: `%s`", snapshot.Meta.RepoPath))
+		if snapshot.Meta.GeneratedAt != "" {
+			sb.WriteString(fmt.Sprintf(", generated %s", snapshot.Meta.GeneratedAt))
+		}
+		sb.WriteString("\n\n")
+	}
+
+	if len(snapshot.Insights) == 0 {
+		sb.WriteString("_No insights were produced for this snapshot._\n")
+		return sb.String()
+	}
+
+	groups := make([][]facts.Insight, len(categories)+1)
+	for _, in := range snapshot.Insights {
+		i := len(categories)
+		for ci, c := range categories {
+			if c.match(in.Title) {
+				i = ci
+				break
+			}
+		}
+		groups[i] = append(groups[i], in)
+	}
+	headings := make([]string, 0, len(groups))
+	for _, c := range categories {
+		headings = append(headings, c.heading)
+	}
+	headings = append(headings, "Other")
+
+	sb.WriteString("| Category | Insights |\n")
+	sb.WriteString("|----------|----------|\n")
+	for i, g := range groups {
+		if len(g) > 0 {
+			sb.WriteString(fmt.Sprintf("| %s | %d |\n", headings[i], len(g)))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("| **Total** | **%d** |\n\n", len(snapshot.Insights)))
+
+	for i, g := range groups {
+		if len(g) == 0 {
+			continue
+		}
+		// Most confident first; explainer order breaks ties.
+		sort.SliceStable(g, func(a, b int) bool { return g[a].Confidence > g[b].Confidence })
+		sb.WriteString(fmt.Sprintf("## %s\n\n", headings[i]))
+		for _, in := range g {
+			writeInsight(&sb, in)
+		}
+	}
+	return sb.String()
+}
+
+func writeInsight(sb *strings.Builder, in facts.Insight) {
+	sb.WriteString(fmt.Sprintf("### %s\n\n", in.Title))
+	sb.WriteString(fmt.Sprintf("**Confidence:** %.0f%%\n\n", in.Confidence*100))
+	if in.Description != "" {
+		sb.WriteString(in.Description + "\n\n")
+	}
+	if len(in.Evidence) > 0 {
+		sb.WriteString("**Evidence:**\n\n")
+		for _, ev := range in.Evidence {
+			sb.WriteString("- " + evidenceLine(ev) + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	if len(in.Actions) > 0 {
+		sb.WriteString("**Suggested actions:**\n\n")
+		for _, a := range in.Actions {
+			sb.WriteString("- " + a + "\n")
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// evidenceLine formats one piece of evidence as its location in code
+// spans followed by the detail: "`a/b.go`, `pkg.Foo`: 42 methods".
+func evidenceLine(ev facts.Evidence) string {
+	var refs []string
+	for _, ref := range []string{ev.File, ev.Symbol, ev.Fact} {
+		if ref != "" && !slices.Contains(refs, "`"+ref+"`") {
+			refs = append(refs, "`"+ref+"`")
+		}
+	}
+	loc := strings.Join(refs, ", ")
+	switch {
+	case loc == "":
+		return ev.Detail
+	case ev.Detail == "":
+		return loc
+	}
+	return loc + ": " + ev.Detail
+}
@@ -0,0 +1,73 @@
+package insightsmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+func renderText(t *testing.T, snapshot *facts.Snapshot) string {
+	t.Helper()
+	artifacts, err := New().Render(context.Background(), snapshot)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if len(artifacts) != 1 || artifacts[0].Name != "insights.md" || artifacts[0].Type != "text/markdown" {
+		t.Fatalf("expected a single insights.md artifact, got %v", artifacts)
+	}
+	return string(artifacts[0].Content)
+}
+
+func TestRender_GroupsByCategory(t *testing.T) {
+	out := renderText(t, &facts.Snapshot{
+		Meta: facts.SnapshotMeta{RepoPath: "/repo", GeneratedAt: "2026-01-02T03:04:05Z"},
+		Insights: []facts.Insight{
+			{Title: "God object: pkg.Server", Description: "Too many methods.", Confidence: 0.6,
+				Evidence: []facts.Evidence{{File: "pkg/server.go", Symbol: "pkg.Server", Detail: "42 methods"}}},
+			{Title: "Cyclic dependency detected (2 modules)", Description: "a and b import each other.", Confidence: 0.95,
+				Evidence: []facts.Evidence{{Fact: "a"}, {Fact: "b"}},
+				Actions:  []string{"Extract the shared types into a new package"}},
+			{Title: "Architecture pattern: Layered", Description: "Handlers call services.", Confidence: 0.8,
+				Evidence: []facts.Evidence{{Detail: "handler -> internal/http"}}},
+			{Title: "God module: pkg", Confidence: 0.9},
+			{Title: "Unusual thing", Confidence: 0.5},
+		},
+	})
+
+	for _, want := range []string{
+		"Repository: `/repo`, generated 2026-01-02T03:04:05Z",
+		"| Hotspots | 2 |",
+		"| **Total** | **5** |",
+		"### Cyclic dependency detected (2 modules)\n\n**Confidence:** 95%\n\na and b import each other.\n\n**Evidence:**\n\n- `a`\n- `b`\n\n**Suggested actions:**\n\n- Extract the shared types into a new package\n",
+		"- `pkg/server.go`, `pkg.Server`: 42 methods\n",
+		"- handler -> internal/http\n",
+		"## Other\n\n### Unusual thing",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	// Sections follow the category order; hotspots are sorted by confidence.
+	order := []string{"## Architecture Patterns", "## Cycles", "## Hotspots", "### God module: pkg", "### God object: pkg.Server", "## Other"}
+	last := -1
+	for _, h := range order {
+		i := strings.Index(out, h)
+		if i <= last {
+			t.Errorf("%q out of order in:\n%s", h, out)
+		}
+		last = i
+	}
+	if strings.Contains(out, "## Layer Violations") {
+		t.Error("empty categories should be omitted")
+	}
+}
+
+func TestRender_NoInsights(t *testing.T) {
+	out := renderText(t, &facts.Snapshot{})
+	if !strings.Contains(out, "_No insights were produced for this snapshot._") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
@@ -0,0 +1,73 @@
+package jsongraph
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+)
+
+// DOT returns the document as a Graphviz digraph. Nodes are keyed by their
+// fact name and labeled with their display label; edges are labeled with
+// their relation kind.
+func (d Document) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph archmcp {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, fontname=\"Helvetica\"];\n")
+	for _, n := range d.Nodes {
+		shape := "box"
+		switch n.Kind {
+		case facts.KindModule:
+			shape = "folder"
+		case facts.KindRoute:
+			shape = "cds"
+		case facts.KindStorage:
+			shape = "cylinder"
+		}
+		fmt.Fprintf(&b, "  %s [label=%s, shape=%s];\n", dotQuote(n.ID), dotQuote(n.Label), shape)
+	}
+	for _, e := range d.Edges {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", dotQuote(e.Source), dotQuote(e.Target), dotQuote(e.Kind))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid returns the document as a Mermaid flowchart. Fact names are not
+// valid Mermaid identifiers, so nodes are numbered in document order and
+// carry their display label.
+func (d Document) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	ids := make(map[string]string, len(d.Nodes))
+	for i, n := range d.Nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[n.ID] = id
+		start, end := "[", "]"
+		switch n.Kind {
+		case facts.KindModule:
+			start, end = "[[", "]]"
+		case facts.KindRoute:
+			start, end = "([", "])"
+		case facts.KindStorage:
+			start, end = "[(", ")]"
+		}
+		fmt.Fprintf(&b, "  %s%s\"%s\"%s\n", id, start, mermaidEscape(n.Label), end)
+	}
+	for _, e := range d.Edges {
+		fmt.Fprintf(&b, "  %s -->|%s| %s\n", ids[e.Source], mermaidEscape(e.Kind), ids[e.Target])
+	}
+	return b.String()
+}
+
+// dotQuote returns s as a DOT quoted string.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// mermaidEscape replaces the characters that end a Mermaid label with their
+// entity codes.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "|", "#124;").Replace(s)
+}
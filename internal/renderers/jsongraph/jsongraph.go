@@ -0,0 +1,214 @@
+package jsongraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+	"github.com/dejo1307/archmcp/internal/renderers"
+)
+
+// JSONGraphRenderer emits the fact graph as graph.cyto.json, a flat
+// nodes/edges document that Cytoscape.js and D3 force layouts can load.
+type JSONGraphRenderer struct {
+	maxNodes int
+	trim     renderers.LabelTrim
+}
+
+// New creates a new JSONGraphRenderer that keeps at most maxNodes nodes and
+// shortens node labels with trim.
+func New(maxNodes int, trim renderers.LabelTrim) *JSONGraphRenderer {
+	if maxNodes <= 0 {
+		maxNodes = 2000
+	}
+	return &JSONGraphRenderer{maxNodes: maxNodes, trim: trim}
+}
+
+func (r *JSONGraphRenderer) Name() string {
+	return "json_graph"
+}
+
+// Node is a graph node in the rendered document.
+type Node struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Kind  string `json:"kind"`
+	File  string `json:"file,omitempty"`
+}
+
+// Edge is a directed graph edge in the rendered document.
+type Edge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Kind   string `json:"kind"`
+	Weight int    `json:"weight"`
+}
+
+// Meta summarizes what was rendered and whether the node cap applied.
+type Meta struct {
+	RepoPath   string `json:"repo_path,omitempty"`
+	NodeCount  int    `json:"node_count"`
+	EdgeCount  int    `json:"edge_count"`
+	TotalNodes int    `json:"total_nodes"`
+	Truncated  bool   `json:"truncated"`
+}
+
+// Document is the top-level shape of graph.cyto.json.
+type Document struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+	Meta  Meta   `json:"meta"`
+}
+
+// kindPriority orders node kinds when the node cap forces a cut: the
+// architectural skeleton (modules, routes, storage) is kept before symbols.
+var kindPriority = map[string]int{
+	facts.KindModule:  0,
+	facts.KindRoute:   1,
+	facts.KindStorage: 2,
+	facts.KindSymbol:  3,
+}
+
+// Render produces the graph.cyto.json artifact. Every non-dependency fact
+// becomes a node; dependency facts contribute module-to-module import edges
+// instead. When there are more nodes than the cap, the highest-priority kinds
+// and best-connected nodes are kept, and edges to dropped nodes are omitted.
+func (r *JSONGraphRenderer) Render(ctx context.Context, snapshot *facts.Snapshot) ([]facts.Artifact, error) {
+	doc := r.build(snapshot, nil)
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling graph: %w", err)
+	}
+	return []facts.Artifact{
+		{
+			Name:    "graph.cyto.json",
+			Content: data,
+			Type:    "application/json",
+		},
+	}, nil
+}
+
+// Subgraph builds the document for the nodes of snapshot for which keep
+// returns true, with the edges among them. Edges are derived from the whole
+// snapshot, so import edges of kept modules are resolved as in Render.
+func (r *JSONGraphRenderer) Subgraph(snapshot *facts.Snapshot, keep func(name string) bool) Document {
+	return r.build(snapshot, keep)
+}
+
+// build builds the document for the nodes kept by keep (nil keeps all).
+func (r *JSONGraphRenderer) build(snapshot *facts.Snapshot, keep func(name string) bool) Document {
+	nodes := make(map[string]*Node)
+	for _, f := range snapshot.Facts {
+		if f.Kind == facts.KindDependency || f.Name == "" {
+			continue
+		}
+		if keep != nil && !keep(f.Name) {
+			continue
+		}
+		if _, exists := nodes[f.Name]; exists {
+			continue
+		}
+		nodes[f.Name] = &Node{
+			ID:    f.Name,
+			Label: r.nodeLabel(f),
+			Kind:  f.Kind,
+			File:  f.File,
+		}
+	}
+
+	// NewGraph deduplicates edges, weights repeated relations, and bridges
+	// dependency facts to module-level import edges.
+	g := facts.NewGraph(snapshot.Facts)
+	var edges []Edge
+	degree := make(map[string]int)
+	for source, out := range g.Forward() {
+		if nodes[source] == nil {
+			continue
+		}
+		for _, e := range out {
+			if nodes[e.Target] == nil || e.Target == source {
+				continue
+			}
+			edges = append(edges, Edge{Source: source, Target: e.Target, Kind: e.RelKind, Weight: e.Weight})
+			degree[source]++
+			degree[e.Target]++
+		}
+	}
+
+	ordered := make([]*Node, 0, len(nodes))
+	for _, n := range nodes {
+		ordered = append(ordered, n)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		pi, pj := priority(ordered[i].Kind), priority(ordered[j].Kind)
+		if pi != pj {
+			return pi < pj
+		}
+		if di, dj := degree[ordered[i].ID], degree[ordered[j].ID]; di != dj {
+			return di > dj
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+
+	doc := Document{Meta: Meta{RepoPath: snapshot.Meta.RepoPath, TotalNodes: len(ordered)}}
+	if len(ordered) > r.maxNodes {
+		ordered = ordered[:r.maxNodes]
+		doc.Meta.Truncated = true
+	}
+
+	kept := make(map[string]bool, len(ordered))
+	doc.Nodes = make([]Node, 0, len(ordered))
+	for _, n := range ordered {
+		kept[n.ID] = true
+		doc.Nodes = append(doc.Nodes, *n)
+	}
+	doc.Edges = make([]Edge, 0, len(edges))
+	for _, e := range edges {
+		if kept[e.Source] && kept[e.Target] {
+			doc.Edges = append(doc.Edges, e)
+		}
+	}
+	sort.Slice(doc.Edges, func(i, j int) bool {
+		a, b := doc.Edges[i], doc.Edges[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		return a.Kind < b.Kind
+	})
+
+	doc.Meta.NodeCount = len(doc.Nodes)
+	doc.Meta.EdgeCount = len(doc.Edges)
+	return doc
+}
+
+func priority(kind string) int {
+	if p, ok := kindPriority[kind]; ok {
+		return p
+	}
+	return len(kindPriority)
+}
+
+// nodeLabel returns a short display label: the last path segment of module
+// and symbol names ("internal/server.New" → "server.New"), or the configured
+// label trim when one is set, and "METHOD path" for routes.
+func (r *JSONGraphRenderer) nodeLabel(f facts.Fact) string {
+	if f.Kind == facts.KindRoute {
+		if method, ok := f.Props["method"].(string); ok && method != "" && !strings.HasPrefix(f.Name, method+" ") {
+			return method + " " + f.Name
+		}
+		return f.Name
+	}
+	if !r.trim.IsZero() {
+		return r.trim.Apply(f.Name)
+	}
+	if i := strings.LastIndex(f.Name, "/"); i >= 0 && i < len(f.Name)-1 {
+		return f.Name[i+1:]
+	}
+	return f.Name
+}
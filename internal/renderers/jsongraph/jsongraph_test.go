@@ -0,0 +1,191 @@
+package jsongraph
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dejo1307/archmcp/internal/facts"
+	"github.com/dejo1307/archmcp/internal/renderers"
+)
+
+func makeSnapshot(ff []facts.Fact) *facts.Snapshot {
+	return &facts.Snapshot{
+		Meta:  facts.SnapshotMeta{RepoPath: "/repo"},
+		Facts: ff,
+	}
+}
+
+func render(t *testing.T, r *JSONGraphRenderer, ff []facts.Fact) Document {
+	t.Helper()
+	artifacts, err := r.Render(context.Background(), makeSnapshot(ff))
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if len(artifacts) != 1 || artifacts[0].Name != "graph.cyto.json" {
+		t.Fatalf("expected a single graph.cyto.json artifact, got %v", artifacts)
+	}
+	var doc Document
+	if err := json.Unmarshal(artifacts[0].Content, &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return doc
+}
+
+func sampleFacts() []facts.Fact {
+	return []facts.Fact{
+		{Kind: facts.KindModule, Name: "internal/server", File: "internal/server"},
+		{Kind: facts.KindModule, Name: "internal/facts", File: "internal/facts"},
+		{Kind: facts.KindSymbol, Name: "internal/server.New", File: "internal/server/server.go",
+			Relations: []facts.Relation{{Kind: facts.RelDeclares, Target: "internal/server"}}},
+		{Kind: facts.KindRoute, Name: "/api/users", File: "internal/server/routes.go",
+			Props: map[string]any{"method": "GET"}},
+		{Kind: facts.KindDependency, Name: "internal/server -> internal/facts", File: "internal/server/server.go",
+			Relations: []facts.Relation{{Kind: facts.RelImports, Target: "internal/facts"}}},
+		{Kind: facts.KindDependency, Name: "internal/server -> fmt", File: "internal/server/server.go",
+			Relations: []facts.Relation{{Kind: facts.RelImports, Target: "fmt"}}},
+	}
+}
+
+func TestRender_NodesAndEdges(t *testing.T) {
+	doc := render(t, New(0, renderers.LabelTrim{}), sampleFacts())
+
+	if doc.Meta.NodeCount != 4 || doc.Meta.Truncated {
+		t.Errorf("meta = %+v, want 4 nodes, not truncated", doc.Meta)
+	}
+	byID := make(map[string]Node)
+	for _, n := range doc.Nodes {
+		byID[n.ID] = n
+	}
+	if n := byID["internal/server.New"]; n.Label != "server.New" || n.Kind != facts.KindSymbol || n.File != "internal/server/server.go" {
+		t.Errorf("symbol node = %+v", n)
+	}
+	if n := byID["/api/users"]; n.Label != "GET /api/users" {
+		t.Errorf("route label = %q, want %q", n.Label, "GET /api/users")
+	}
+	if _, ok := byID["internal/server -> internal/facts"]; ok {
+		t.Error("dependency facts should become edges, not nodes")
+	}
+
+	want := map[Edge]bool{
+		{Source: "internal/server", Target: "internal/facts", Kind: facts.RelImports, Weight: 1}:       false,
+		{Source: "internal/server.New", Target: "internal/server", Kind: facts.RelDeclares, Weight: 1}: false,
+	}
+	for _, e := range doc.Edges {
+		if _, ok := want[e]; ok {
+			want[e] = true
+		} else {
+			t.Errorf("unexpected edge %+v", e)
+		}
+	}
+	for e, seen := range want {
+		if !seen {
+			t.Errorf("missing edge %+v", e)
+		}
+	}
+}
+
+func TestRender_NodeCap(t *testing.T) {
+	doc := render(t, New(2, renderers.LabelTrim{}), sampleFacts())
+
+	if !doc.Meta.Truncated || doc.Meta.TotalNodes != 4 || doc.Meta.NodeCount != 2 {
+		t.Errorf("meta = %+v, want 2 of 4 nodes, truncated", doc.Meta)
+	}
+	// Modules outrank routes and symbols under the cap.
+	for _, n := range doc.Nodes {
+		if n.Kind != facts.KindModule {
+			t.Errorf("expected only modules under a cap of 2, got %s (%s)", n.ID, n.Kind)
+		}
+	}
+	if len(doc.Edges) != 1 || doc.Edges[0].Kind != facts.RelImports {
+		t.Errorf("expected only the module import edge, got %+v", doc.Edges)
+	}
+}
+
+func TestRender_LabelTrim(t *testing.T) {
+	ff := append(sampleFacts(),
+		facts.Fact{Kind: facts.KindSymbol, Name: "internal/facts/store.Query", File: "internal/facts/store/store.go"},
+		facts.Fact{Kind: facts.KindModule, Name: "internal", File: "internal"},
+	)
+	tests := []struct {
+		name string
+		trim renderers.LabelTrim
+		want map[string]string // node ID -> label
+	}{
+		{"default last segment", renderers.LabelTrim{}, map[string]string{
+			"internal/facts/store.Query": "store.Query",
+			"internal/server":            "server",
+		}},
+		{"strip prefix", renderers.LabelTrim{StripPrefix: "internal/"}, map[string]string{
+			"internal/facts/store.Query": "facts/store.Query",
+			"internal/server":            "server",
+			"internal":                   "internal",
+			"/api/users":                 "GET /api/users",
+		}},
+		{"last segments", renderers.LabelTrim{Segments: 2}, map[string]string{
+			"internal/facts/store.Query": "facts/store.Query",
+			"internal/server.New":        "internal/server.New",
+		}},
+		{"prefix then segments", renderers.LabelTrim{StripPrefix: "internal", Segments: 1}, map[string]string{
+			"internal/facts/store.Query": "store.Query",
+			"internal/server.New":        "server.New",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := render(t, New(0, tt.trim), ff)
+			labels := make(map[string]string)
+			for _, n := range doc.Nodes {
+				labels[n.ID] = n.Label
+			}
+			for id, want := range tt.want {
+				if got, ok := labels[id]; !ok || got != want {
+					t.Errorf("label of %q = %q, want %q", id, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSubgraph_DOTAndMermaid(t *testing.T) {
+	r := New(0, renderers.LabelTrim{})
+	doc := r.Subgraph(makeSnapshot(sampleFacts()), func(name string) bool {
+		return name != "/api/users"
+	})
+	if doc.Meta.NodeCount != 3 || doc.Meta.EdgeCount != 2 {
+		t.Fatalf("meta = %+v, want 3 nodes and the declares and imports edges among them", doc.Meta)
+	}
+
+	dot := doc.DOT()
+	for _, want := range []string{
+		`"internal/server" [label="server", shape=folder];`,
+		`"internal/server" -> "internal/facts" [label="imports"];`,
+		`"internal/server.New" -> "internal/server" [label="declares"];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output missing %q:\n%s", want, dot)
+		}
+	}
+	if strings.Contains(dot, "/api/users") {
+		t.Errorf("DOT output should only contain kept nodes:\n%s", dot)
+	}
+
+	mermaid := doc.Mermaid()
+	if !strings.HasPrefix(mermaid, "flowchart LR\n") {
+		t.Errorf("Mermaid output should be a flowchart:\n%s", mermaid)
+	}
+	ids := make(map[string]string)
+	for i, n := range doc.Nodes {
+		ids[n.ID] = "n" + strconv.Itoa(i)
+	}
+	for _, want := range []string{
+		ids["internal/server"] + `[["server"]]`,
+		ids["internal/server"] + " -->|imports| " + ids["internal/facts"],
+	} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("Mermaid output missing %q:\n%s", want, mermaid)
+		}
+	}
+}
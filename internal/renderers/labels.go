@@ -0,0 +1,39 @@
+package renderers
+
+import "strings"
+
+// LabelTrim shortens the display labels of graph nodes. Renderers apply it
+// to labels only, so node IDs keep the full names and still map back to
+// facts.
+type LabelTrim struct {
+	// StripPrefix is removed from the start of a name when it ends at a
+	// path segment boundary.
+	StripPrefix string
+	// Segments, when positive, keeps only the last N path segments.
+	Segments int
+}
+
+// IsZero reports whether no trimming is configured.
+func (t LabelTrim) IsZero() bool {
+	return t.StripPrefix == "" && t.Segments <= 0
+}
+
+// Apply returns the trimmed label for name. The prefix is stripped first, so
+// Segments counts what remains: with StripPrefix "internal" and Segments 1,
+// "internal/facts/store.Query" becomes "store.Query". A name that would be
+// trimmed to nothing is returned unchanged.
+func (t LabelTrim) Apply(name string) string {
+	label := name
+	if prefix := strings.TrimSuffix(t.StripPrefix, "/"); prefix != "" {
+		if rest, ok := strings.CutPrefix(label, prefix+"/"); ok && rest != "" {
+			label = rest
+		}
+	}
+	if t.Segments > 0 {
+		parts := strings.Split(label, "/")
+		if len(parts) > t.Segments {
+			label = strings.Join(parts[len(parts)-t.Segments:], "/")
+		}
+	}
+	return label
+}
@@ -3,10 +3,12 @@ package llmcontext
 import (
 	"context"
 	"fmt"
+	"path"
 	"sort"
 	"strings"
 
 	"github.com/dejo1307/archmcp/internal/facts"
+	"github.com/dejo1307/archmcp/internal/renderers"
 )
 
 // LLMContextRenderer produces a compact markdown summary optimized for LLM consumption.
@@ -26,6 +28,12 @@ func (r *LLMContextRenderer) Name() string {
 	return "llm_context"
 }
 
+// WithMaxTokens returns a renderer with the same settings and a different
+// token budget.
+func (r *LLMContextRenderer) WithMaxTokens(maxTokens int) renderers.Renderer {
+	return New(maxTokens)
+}
+
 // section holds a rendered section with its display name.
 type section struct {
 	name    string
@@ -34,7 +42,9 @@ type section struct {
 
 // Render produces the llm_context.md artifact using progressive summarization.
 // Sections are ordered by priority; lower-priority sections are omitted first
-// when the token budget is tight.
+// when the token budget is tight. A section that only partly fits is cut at a
+// subsection or line boundary so tables and lists stay well-formed. The Meta
+// footer is always included and reports the estimated token count.
 func (r *LLMContextRenderer) Render(ctx context.Context, snapshot *facts.Snapshot) ([]facts.Artifact, error) {
 	// Sections ordered by priority (most important first)
 	sections := []section{
@@ -47,12 +57,12 @@ func (r *LLMContextRenderer) Render(ctx context.Context, snapshot *facts.Snapsho
 		{"Critical Modules", r.renderCriticalModules(snapshot)},
 		{"Risk Zones", r.renderRiskZones(snapshot)},
 		{"How to Add a Feature", r.renderFeatureGuide(snapshot)},
-		{"Meta", r.renderMeta(snapshot)},
 	}
 
 	header := "# Architecture Snapshot\n\n"
-	maxChars := r.maxTokens * 4 // rough estimate: 1 token ~= 4 chars
-	remaining := maxChars - len(header)
+	maxChars := r.maxTokens * charsPerToken
+	// Reserve room for the footer up front so it is never truncated.
+	remaining := maxChars - len(header) - len(r.renderMeta(snapshot, r.maxTokens))
 
 	var sb strings.Builder
 	sb.WriteString(header)
@@ -64,29 +74,31 @@ func (r *LLMContextRenderer) Render(ctx context.Context, snapshot *facts.Snapsho
 		if len(sec.content) <= remaining {
 			sb.WriteString(sec.content)
 			remaining -= len(sec.content)
-		} else if remaining > 200 {
-			// Partially include this section
-			cutpoint := remaining - 100
-			if cutpoint < 0 {
-				cutpoint = 0
+			continue
+		}
+
+		var omitted []string
+		for _, s := range sections[i+1:] {
+			if s.content != "" {
+				omitted = append(omitted, s.name)
 			}
-			sb.WriteString(sec.content[:cutpoint])
-			sb.WriteString(fmt.Sprintf("\n\n---\n*[Truncated in: %s]*\n", sec.name))
-			remaining = 0
-			break
+		}
+
+		// Partially include this section if a meaningful prefix fits
+		// alongside the truncation marker.
+		marker := truncationMarker(sec.name, omitted)
+		if partial := trimToBoundary(sec.content, remaining-len(marker)); partial != "" {
+			sb.WriteString(partial)
+			sb.WriteString(marker)
 		} else {
-			// List omitted sections
-			var omitted []string
-			for _, s := range sections[i:] {
-				if s.content != "" {
-					omitted = append(omitted, s.name)
-				}
-			}
-			sb.WriteString(fmt.Sprintf("\n\n---\n*[Omitted: %s]*\n", strings.Join(omitted, ", ")))
-			break
+			sb.WriteString(truncationMarker("", append([]string{sec.name}, omitted...)))
 		}
+		break
 	}
 
+	tokens := (sb.Len() + len(r.renderMeta(snapshot, r.maxTokens))) / charsPerToken
+	sb.WriteString(r.renderMeta(snapshot, tokens))
+
 	return []facts.Artifact{
 		{
 			Name:    "llm_context.md",
@@ -96,6 +108,73 @@ func (r *LLMContextRenderer) Render(ctx context.Context, snapshot *facts.Snapsho
 	}, nil
 }
 
+// charsPerToken is the rough characters-per-token ratio used for budgeting.
+const charsPerToken = 4
+
+// truncationMarker describes a section cut short (truncated) and the
+// sections left out entirely (omitted). Either may be empty.
+func truncationMarker(truncated string, omitted []string) string {
+	var sb strings.Builder
+	sb.WriteString("\n")
+	if truncated != "" {
+		sb.WriteString(fmt.Sprintf("*[Truncated in: %s]*\n", truncated))
+	}
+	if len(omitted) > 0 {
+		sb.WriteString(fmt.Sprintf("*[Omitted: %s]*\n", strings.Join(omitted, ", ")))
+	}
+	return sb.String()
+}
+
+// trimToBoundary returns the longest prefix of content within limit bytes
+// that ends on a clean boundary: before a "### " subsection if one starts in
+// the second half of the budget, otherwise after the last complete line.
+// Trailing headings and table headers left without any rows are dropped. It
+// returns "" when nothing beyond the section heading would remain.
+func trimToBoundary(content string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	if len(content) <= limit {
+		return content
+	}
+
+	cut := content[:limit]
+	if i := strings.LastIndex(cut, "\n### "); i > limit/2 {
+		cut = content[:i+1]
+	} else if i := strings.LastIndex(cut, "\n"); i >= 0 {
+		cut = content[:i+1]
+	} else {
+		return ""
+	}
+
+	lines := strings.Split(strings.TrimRight(cut, "\n"), "\n")
+	next := strings.SplitN(content[len(cut):], "\n", 2)[0]
+	for len(lines) > 0 {
+		last := strings.TrimSpace(lines[len(lines)-1])
+		switch {
+		case last == "" || strings.HasPrefix(last, "#"):
+		case isTableSeparator(last):
+			// Drop the separator; the header row above it goes next.
+		case strings.HasPrefix(last, "|") && isTableSeparator(strings.TrimSpace(next)):
+			// A table header whose separator and rows were cut.
+		default:
+			return strings.Join(lines, "\n") + "\n"
+		}
+		next = lines[len(lines)-1]
+		lines = lines[:len(lines)-1]
+	}
+	return ""
+}
+
+// isTableSeparator reports whether line is a markdown table header separator
+// such as "|------|:---:|".
+func isTableSeparator(line string) bool {
+	if !strings.HasPrefix(line, "|") {
+		return false
+	}
+	return strings.Trim(line, "|-: ") == ""
+}
+
 func (r *LLMContextRenderer) renderRepoMap(snapshot *facts.Snapshot) string {
 	var sb strings.Builder
 	sb.WriteString("## Repository Map\n\n")
@@ -173,32 +252,21 @@ func (r *LLMContextRenderer) renderEntryPoints(snapshot *facts.Snapshot) string
 
 	var entryPoints []string
 
+	// Extractors classify entry points in Props["entry_point"]: main,
+	// handler, app, controller_action, activity, ...
 	for _, f := range snapshot.Facts {
 		if f.Kind != facts.KindSymbol {
 			continue
 		}
-
-		symbolKind, _ := f.Props["symbol_kind"].(string)
-		exported, _ := f.Props["exported"].(bool)
-
-		// Main functions
-		if strings.HasSuffix(f.Name, ".main") && symbolKind == facts.SymbolFunc {
-			entryPoints = append(entryPoints, fmt.Sprintf("- **main**: `%s` (%s)", f.Name, f.File))
-		}
-
-		// HTTP handlers (common patterns)
-		if exported && symbolKind == facts.SymbolFunc {
-			nameLower := strings.ToLower(f.Name)
-			if strings.Contains(nameLower, "handler") || strings.Contains(nameLower, "handle") ||
-				strings.Contains(nameLower, "serve") {
-				entryPoints = append(entryPoints, fmt.Sprintf("- **handler**: `%s` (%s)", f.Name, f.File))
-			}
+		kind, _ := f.Props["entry_point"].(string)
+		if kind == "" {
+			continue
 		}
-
-		// iOS/macOS app entry point (@main struct conforming to App)
-		if iosComp, _ := f.Props["ios_component"].(string); iosComp == "swiftui_app" {
-			entryPoints = append(entryPoints, fmt.Sprintf("- **app**: `%s` (%s)", f.Name, f.File))
+		label := strings.ReplaceAll(kind, "_", " ")
+		if launcher, _ := f.Props["launcher"].(bool); launcher {
+			label += " (launcher)"
 		}
+		entryPoints = append(entryPoints, fmt.Sprintf("- **%s**: `%s` (%s)", label, f.Name, f.File))
 	}
 
 	// Routes as entry points
@@ -322,23 +390,15 @@ func (r *LLMContextRenderer) renderCriticalModules(snapshot *facts.Snapshot) str
 	sb.WriteString("## Critical Modules\n\n")
 
 	// Compute fan-in (imported by others) and fan-out (imports others)
-	fanIn := make(map[string]int)
-	fanOut := make(map[string]int)
+	coupling := facts.ModuleCoupling(snapshot.Facts)
 
 	modules := make(map[string]bool)
+	docs := make(map[string]string) // module → package doc synopsis
 	for _, f := range snapshot.Facts {
 		if f.Kind == facts.KindModule {
 			modules[f.Name] = true
-		}
-	}
-
-	deps := filterByKind(snapshot.Facts, facts.KindDependency)
-	for _, dep := range deps {
-		sourceModule := fileDir(dep.File)
-		for _, rel := range dep.Relations {
-			if rel.Kind == facts.RelImports && modules[rel.Target] {
-				fanOut[sourceModule]++
-				fanIn[rel.Target]++
+			if doc, ok := f.Props["doc"].(string); ok && doc != "" {
+				docs[f.Name] = doc
 			}
 		}
 	}
@@ -352,11 +412,12 @@ func (r *LLMContextRenderer) renderCriticalModules(snapshot *facts.Snapshot) str
 
 	var scored []modScore
 	for mod := range modules {
+		c := coupling[mod]
 		s := modScore{
 			Name:   mod,
-			FanIn:  fanIn[mod],
-			FanOut: fanOut[mod],
-			Score:  fanIn[mod] + fanOut[mod],
+			FanIn:  c.FanIn,
+			FanOut: c.FanOut,
+			Score:  c.FanIn + c.FanOut,
 		}
 		if s.Score > 0 {
 			scored = append(scored, s)
@@ -378,8 +439,22 @@ func (r *LLMContextRenderer) renderCriticalModules(snapshot *facts.Snapshot) str
 		return sb.String()
 	}
 
-	sb.WriteString("| Module | Fan-In | Fan-Out | Criticality |\n")
-	sb.WriteString("|--------|--------|---------|-------------|\n")
+	// Only add the description column when at least one listed module has a doc comment.
+	withDocs := false
+	for _, s := range scored[:limit] {
+		if docs[s.Name] != "" {
+			withDocs = true
+			break
+		}
+	}
+
+	if withDocs {
+		sb.WriteString("| Module | Fan-In | Fan-Out | Criticality | Description |\n")
+		sb.WriteString("|--------|--------|---------|-------------|-------------|\n")
+	} else {
+		sb.WriteString("| Module | Fan-In | Fan-Out | Criticality |\n")
+		sb.WriteString("|--------|--------|---------|-------------|\n")
+	}
 	for _, s := range scored[:limit] {
 		criticality := "low"
 		if s.Score >= 10 {
@@ -387,24 +462,38 @@ func (r *LLMContextRenderer) renderCriticalModules(snapshot *facts.Snapshot) str
 		} else if s.Score >= 5 {
 			criticality = "medium"
 		}
-		sb.WriteString(fmt.Sprintf("| `%s` | %d | %d | %s |\n", s.Name, s.FanIn, s.FanOut, criticality))
+		if withDocs {
+			sb.WriteString(fmt.Sprintf("| `%s` | %d | %d | %s | %s |\n", s.Name, s.FanIn, s.FanOut, criticality, tableCell(docs[s.Name])))
+		} else {
+			sb.WriteString(fmt.Sprintf("| `%s` | %d | %d | %s |\n", s.Name, s.FanIn, s.FanOut, criticality))
+		}
 	}
 	sb.WriteString("\n")
 	return sb.String()
 }
 
+// tableCell makes text safe for a markdown table cell: pipes are escaped
+// and line breaks, as in a full doc comment, are collapsed to spaces.
+func tableCell(text string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(text), " "), "|", "\\|")
+}
+
 func (r *LLMContextRenderer) renderRiskZones(snapshot *facts.Snapshot) string {
 	var risks []string
 
 	for _, insight := range snapshot.Insights {
 		if strings.Contains(insight.Title, "Cyclic dependency") ||
-			strings.Contains(insight.Title, "Layer violation") {
+			strings.Contains(insight.Title, "Layer violation") ||
+			strings.HasPrefix(insight.Title, "God module:") ||
+			strings.HasPrefix(insight.Title, "God object:") {
 			risks = append(risks, fmt.Sprintf("- **%s** (confidence: %.0f%%): %s",
 				insight.Title, insight.Confidence*100, insight.Description))
 		}
 	}
 
-	if len(risks) == 0 {
+	complexFns := mostComplexFunctions(snapshot.Facts)
+	deprecated := deprecatedInUse(snapshot.Facts)
+	if len(risks) == 0 && len(complexFns) == 0 && len(deprecated) == 0 {
 		return ""
 	}
 
@@ -413,10 +502,145 @@ func (r *LLMContextRenderer) renderRiskZones(snapshot *facts.Snapshot) string {
 	for _, risk := range risks {
 		sb.WriteString(risk + "\n")
 	}
-	sb.WriteString("\n")
+	if len(risks) > 0 {
+		sb.WriteString("\n")
+	}
+	if len(complexFns) > 0 {
+		sb.WriteString("### Most Complex Functions\n\n")
+		sb.WriteString("| Function | Complexity | Location |\n")
+		sb.WriteString("|----------|------------|----------|\n")
+		for _, f := range complexFns {
+			sb.WriteString(fmt.Sprintf("| `%s` | %d | %s:%d |\n", f.Name, complexityOf(f), f.File, f.Line))
+		}
+		sb.WriteString("\n")
+	}
+	if len(deprecated) > 0 {
+		sb.WriteString("### Deprecated Symbols in Use\n\n")
+		sb.WriteString("| Symbol | References | Location | Deprecation |\n")
+		sb.WriteString("|--------|------------|----------|-------------|\n")
+		for _, d := range deprecated {
+			msg, _ := d.fact.Props["deprecation"].(string)
+			sb.WriteString(fmt.Sprintf("| `%s` | %d | %s:%d | %s |\n", d.fact.Name, d.refs, d.fact.File, d.fact.Line, tableCell(msg)))
+		}
+		sb.WriteString("\n")
+	}
 	return sb.String()
 }
 
+// maxDeprecatedInUse caps the Deprecated Symbols in Use table.
+const maxDeprecatedInUse = 10
+
+// deprecatedUse is a deprecated symbol and the number of relations that
+// reference it.
+type deprecatedUse struct {
+	fact facts.Fact
+	refs int
+}
+
+// deprecatedInUse returns the deprecated symbols referenced by other facts,
+// most referenced first. A relation target refers to a symbol when it is the
+// symbol's name, the name qualified with the referencing fact's package
+// ("helper" from pkg), or the name qualified with the last segment of the
+// symbol's package ("util.Old" for internal/util.Old), as Go calls across
+// packages are written.
+func deprecatedInUse(ff []facts.Fact) []deprecatedUse {
+	byName := make(map[string]int) // reference form -> index into uses
+	var uses []deprecatedUse
+	for _, f := range ff {
+		if f.Kind != facts.KindSymbol || f.Props["deprecated"] != true {
+			continue
+		}
+		if _, ok := byName[f.Name]; ok {
+			continue
+		}
+		byName[f.Name] = len(uses)
+		if pkg, name, ok := facts.SplitSymbolName(f.Name); ok {
+			short := path.Base(pkg) + "." + name
+			if _, taken := byName[short]; !taken {
+				byName[short] = len(uses)
+			}
+		}
+		uses = append(uses, deprecatedUse{fact: f})
+	}
+	if len(uses) == 0 {
+		return nil
+	}
+
+	for _, f := range ff {
+		pkg := facts.DeclaringModule(f)
+		for _, rel := range f.Relations {
+			if rel.Kind == facts.RelDeclares {
+				continue
+			}
+			i, ok := byName[rel.Target]
+			if !ok {
+				i, ok = byName[pkg+"."+rel.Target]
+			}
+			if ok && uses[i].fact.Name != f.Name {
+				uses[i].refs++
+			}
+		}
+	}
+
+	var result []deprecatedUse
+	for _, u := range uses {
+		if u.refs > 0 {
+			result = append(result, u)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].refs != result[j].refs {
+			return result[i].refs > result[j].refs
+		}
+		return result[i].fact.Name < result[j].fact.Name
+	})
+	if len(result) > maxDeprecatedInUse {
+		result = result[:maxDeprecatedInUse]
+	}
+	return result
+}
+
+// complexityRiskThreshold is the cyclomatic complexity from which a function
+// is listed as a risk zone.
+const complexityRiskThreshold = 10
+
+// maxComplexFunctions caps the Most Complex Functions table.
+const maxComplexFunctions = 10
+
+// mostComplexFunctions returns the functions and methods at or above
+// complexityRiskThreshold, most complex first.
+func mostComplexFunctions(ff []facts.Fact) []facts.Fact {
+	var result []facts.Fact
+	for _, f := range ff {
+		if f.Kind == facts.KindSymbol && complexityOf(f) >= complexityRiskThreshold {
+			result = append(result, f)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		ci, cj := complexityOf(result[i]), complexityOf(result[j])
+		if ci != cj {
+			return ci > cj
+		}
+		return result[i].Name < result[j].Name
+	})
+	if len(result) > maxComplexFunctions {
+		result = result[:maxComplexFunctions]
+	}
+	return result
+}
+
+// complexityOf reads the complexity prop, which is an int when freshly
+// extracted and a float64 when loaded from a persisted snapshot.
+func complexityOf(f facts.Fact) int {
+	switch v := f.Props["complexity"].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
 func (r *LLMContextRenderer) renderFeatureGuide(snapshot *facts.Snapshot) string {
 	var sb strings.Builder
 	sb.WriteString("## How to Add a Feature\n\n")
@@ -484,12 +708,17 @@ func (r *LLMContextRenderer) renderFeatureGuide(snapshot *facts.Snapshot) string
 	return sb.String()
 }
 
-func (r *LLMContextRenderer) renderMeta(snapshot *facts.Snapshot) string {
+func (r *LLMContextRenderer) renderMeta(snapshot *facts.Snapshot, tokens int) string {
 	var sb strings.Builder
 	sb.WriteString("---\n\n")
-	sb.WriteString(fmt.Sprintf("*Generated at %s in %s. %d facts, %d insights.*\n",
+	sb.WriteString(fmt.Sprintf("*Generated at %s in %s. %d facts, %d insights. ~%d of %d tokens used.*\n",
 		snapshot.Meta.GeneratedAt, snapshot.Meta.Duration,
-		snapshot.Meta.FactCount, snapshot.Meta.InsightCount))
+		snapshot.Meta.FactCount, snapshot.Meta.InsightCount,
+		tokens, r.maxTokens))
+	if sm := snapshot.Meta.Sampling; sm != nil {
+		sb.WriteString(fmt.Sprintf("*Sampled to fit generate.max_facts (%d): %d of %d facts kept, %d symbols dropped from %d modules (at most %d symbols per module).*\n",
+			sm.MaxFacts, sm.ExtractedFacts-sm.DroppedSymbols, sm.ExtractedFacts, sm.DroppedSymbols, sm.SampledModules, sm.SymbolsPerModule))
+	}
 	return sb.String()
 }
 
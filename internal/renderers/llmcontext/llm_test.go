@@ -2,6 +2,7 @@ package llmcontext
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -36,8 +37,7 @@ func TestTokenBudgetEnforcement(t *testing.T) {
 
 	snapshot := makeSnapshot(ff, nil)
 
-	// Small token budget (100 tokens = 400 chars) — enough for truncation logic
-	// to work (maxChars-100 must be positive; see BUG note below)
+	// Small token budget (100 tokens = 400 chars) forces truncation
 	r := New(100)
 	artifacts, err := r.Render(context.Background(), snapshot)
 	if err != nil {
@@ -59,6 +59,89 @@ func TestTokenBudgetEnforcement(t *testing.T) {
 	}
 }
 
+func TestTokenBudget_CutsAtLineBoundaries(t *testing.T) {
+	var ff []facts.Fact
+	for i := 0; i < 40; i++ {
+		ff = append(ff, facts.Fact{
+			Kind: facts.KindRoute,
+			Name: fmt.Sprintf("/api/resource_%02d", i),
+			File: "internal/api/routes.go",
+			Props: map[string]any{
+				"method": "GET",
+			},
+		})
+	}
+	snapshot := makeSnapshot(ff, nil)
+
+	full, err := New(100000).Render(context.Background(), snapshot)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	complete := make(map[string]bool)
+	for _, line := range strings.Split(string(full[0].Content), "\n") {
+		complete[line] = true
+	}
+
+	artifacts, err := New(300).Render(context.Background(), snapshot)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	content := string(artifacts[0].Content)
+
+	if !strings.Contains(content, "[Truncated in:") {
+		t.Fatalf("expected a truncated section, got:\n%s", content)
+	}
+	if len(content) > 300*4 {
+		t.Errorf("content length %d exceeds budget of %d chars", len(content), 300*4)
+	}
+	// Every line that made it in must be a complete line of the full render.
+	body, _, _ := strings.Cut(content, "*[Truncated in:")
+	for _, line := range strings.Split(body, "\n") {
+		if !complete[line] {
+			t.Errorf("partial line: %q", line)
+		}
+	}
+}
+
+func TestTokenBudget_MetaReportsTokens(t *testing.T) {
+	snapshot := makeSnapshot([]facts.Fact{
+		{Kind: facts.KindModule, Name: "internal/server", Props: map[string]any{"language": "go"}},
+	}, nil)
+
+	artifacts, err := New(100).Render(context.Background(), snapshot)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	content := string(artifacts[0].Content)
+
+	want := fmt.Sprintf("~%d of 100 tokens used.", len(content)/4)
+	if !strings.Contains(content, want) {
+		t.Errorf("expected meta to contain %q, got:\n%s", want, content)
+	}
+}
+
+func TestTrimToBoundary(t *testing.T) {
+	content := "## Routes\n\n| Method | Path |\n|--------|------|\n| GET | /a |\n| GET | /b |\n"
+
+	tests := []struct {
+		name  string
+		limit int
+		want  string
+	}{
+		{"fits", len(content), content},
+		{"mid row", len(content) - 3, "## Routes\n\n| Method | Path |\n|--------|------|\n| GET | /a |\n"},
+		{"header only", 40, ""},
+		{"no newline", 5, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trimToBoundary(content, tt.limit); got != tt.want {
+				t.Errorf("trimToBoundary(%d) = %q, want %q", tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestDetectDominantLanguage(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -123,6 +206,35 @@ func TestRender_EmptySnapshot(t *testing.T) {
 	}
 }
 
+func TestRender_EntryPoints(t *testing.T) {
+	ff := []facts.Fact{
+		{Kind: facts.KindSymbol, Name: "cmd/app.main", File: "cmd/app/main.go", Props: map[string]any{"symbol_kind": facts.SymbolFunc, "entry_point": facts.EntryMain}},
+		{Kind: facts.KindSymbol, Name: "UsersController#index", File: "app/controllers/users_controller.rb", Props: map[string]any{"symbol_kind": facts.SymbolMethod, "entry_point": facts.EntryControllerAction}},
+		{Kind: facts.KindSymbol, Name: "app.MainActivity", File: "app/MainActivity.kt", Props: map[string]any{"symbol_kind": facts.SymbolClass, "entry_point": facts.EntryActivity, "launcher": true}},
+		// Names alone no longer make an entry point.
+		{Kind: facts.KindSymbol, Name: "pkg.HandleThing", File: "pkg/h.go", Props: map[string]any{"symbol_kind": facts.SymbolFunc, "exported": true}},
+		{Kind: facts.KindRoute, Name: "/users", File: "routes.rb", Props: map[string]any{"method": "GET"}},
+	}
+	artifacts, err := New(4000).Render(context.Background(), makeSnapshot(ff, nil))
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	content := string(artifacts[0].Content)
+	for _, want := range []string{
+		"- **main**: `cmd/app.main` (cmd/app/main.go)",
+		"- **controller action**: `UsersController#index` (app/controllers/users_controller.rb)",
+		"- **activity (launcher)**: `app.MainActivity` (app/MainActivity.kt)",
+		"- **route** GET `/users` (routes.rb)",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("missing entry point %q in:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "pkg.HandleThing") {
+		t.Error("symbols without an entry_point prop should not be listed")
+	}
+}
+
 func TestRiskZones_IncludesCyclesAndViolations(t *testing.T) {
 	insights := []facts.Insight{
 		{Title: "Architecture pattern: hexagonal", Confidence: 0.8, Description: "Detected hexagonal"},
@@ -162,6 +274,31 @@ func TestRiskZones_IncludesCyclesAndViolations(t *testing.T) {
 	}
 }
 
+func TestRiskZones_MostComplexFunctions(t *testing.T) {
+	symbol := func(name string, complexity any) facts.Fact {
+		return facts.Fact{Kind: facts.KindSymbol, Name: name, File: "pkg/a.go", Line: 7,
+			Props: map[string]any{"symbol_kind": facts.SymbolFunc, "complexity": complexity}}
+	}
+	snapshot := makeSnapshot([]facts.Fact{
+		symbol("pkg.Simple", 3),
+		symbol("pkg.Tangled", 12),
+		symbol("pkg.Worse", float64(25)), // as loaded from JSONL
+	}, nil)
+
+	r := New(4000)
+	risk := r.renderRiskZones(snapshot)
+	if !strings.Contains(risk, "### Most Complex Functions") {
+		t.Fatalf("expected a Most Complex Functions table, got:\n%s", risk)
+	}
+	worse, tangled := strings.Index(risk, "`pkg.Worse` | 25 | pkg/a.go:7"), strings.Index(risk, "`pkg.Tangled` | 12")
+	if worse < 0 || tangled < 0 || worse > tangled {
+		t.Errorf("expected Worse before Tangled, got:\n%s", risk)
+	}
+	if strings.Contains(risk, "pkg.Simple") {
+		t.Error("functions below the threshold should not be listed")
+	}
+}
+
 func TestCriticalModules_FanInFanOut(t *testing.T) {
 	ff := []facts.Fact{
 		{Kind: facts.KindModule, Name: "core"},
@@ -197,6 +334,43 @@ func TestCriticalModules_FanInFanOut(t *testing.T) {
 	}
 }
 
+func TestCriticalModules_Description(t *testing.T) {
+	ff := []facts.Fact{
+		{Kind: facts.KindModule, Name: "core", Props: map[string]any{"doc": "Package core holds the domain model."}},
+		{Kind: facts.KindModule, Name: "a"},
+		{Kind: facts.KindDependency, File: "a/file.go", Relations: []facts.Relation{{Kind: facts.RelImports, Target: "core"}}},
+	}
+
+	artifacts, err := New(4000).Render(context.Background(), makeSnapshot(ff, nil))
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	content := string(artifacts[0].Content)
+	if !strings.Contains(content, "| Description |") {
+		t.Error("expected a Description column when module docs are present")
+	}
+	if !strings.Contains(content, "| Package core holds the domain model. |") {
+		t.Error("expected core's doc in the Critical Modules table")
+	}
+}
+
+func TestCriticalModules_MultiLineDescription(t *testing.T) {
+	ff := []facts.Fact{
+		{Kind: facts.KindModule, Name: "core", Props: map[string]any{"doc": "Package core holds the domain model.\n\nIt has no dependencies | none."}},
+		{Kind: facts.KindModule, Name: "a"},
+		{Kind: facts.KindDependency, File: "a/file.go", Relations: []facts.Relation{{Kind: facts.RelImports, Target: "core"}}},
+	}
+
+	artifacts, err := New(4000).Render(context.Background(), makeSnapshot(ff, nil))
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	content := string(artifacts[0].Content)
+	if !strings.Contains(content, "| Package core holds the domain model. It has no dependencies \\| none. |\n") {
+		t.Errorf("expected the full doc on one table row:\n%s", content)
+	}
+}
+
 func TestFileDir(t *testing.T) {
 	tests := []struct {
 		input string
@@ -213,3 +387,39 @@ func TestFileDir(t *testing.T) {
 		}
 	}
 }
+
+func TestRiskZones_DeprecatedInUse(t *testing.T) {
+	declares := func(pkg string) []facts.Relation { return []facts.Relation{{Kind: facts.RelDeclares, Target: pkg}} }
+	ff := []facts.Fact{
+		{Kind: facts.KindSymbol, Name: "internal/util.OldHelper", File: "internal/util/util.go", Line: 7,
+			Props: map[string]any{"deprecated": true, "deprecation": "Use Helper | Format"}, Relations: declares("internal/util")},
+		{Kind: facts.KindSymbol, Name: "internal/util.Unused", File: "internal/util/util.go", Line: 12,
+			Props: map[string]any{"deprecated": true}, Relations: declares("internal/util")},
+		{Kind: facts.KindSymbol, Name: "internal/util.caller", File: "internal/util/b.go", Line: 3,
+			Relations: append(declares("internal/util"), facts.Relation{Kind: facts.RelCalls, Target: "OldHelper"})},
+		{Kind: facts.KindSymbol, Name: "internal/api.Handle", File: "internal/api/api.go", Line: 9,
+			Relations: append(declares("internal/api"), facts.Relation{Kind: facts.RelCalls, Target: "util.OldHelper"})},
+	}
+
+	got := deprecatedInUse(ff)
+	if len(got) != 1 || got[0].fact.Name != "internal/util.OldHelper" || got[0].refs != 2 {
+		t.Fatalf("deprecatedInUse = %+v, want OldHelper with 2 references", got)
+	}
+
+	artifacts, err := New(4000).Render(context.Background(), makeSnapshot(ff, nil))
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	content := string(artifacts[0].Content)
+	for _, want := range []string{
+		"### Deprecated Symbols in Use",
+		"| `internal/util.OldHelper` | 2 | internal/util/util.go:7 | Use Helper \\| Format |",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("missing %q in:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "internal/util.Unused") {
+		t.Error("an unreferenced deprecated symbol should not be listed")
+	}
+}
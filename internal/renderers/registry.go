@@ -14,6 +14,14 @@ type Renderer interface {
 	Render(ctx context.Context, snapshot *facts.Snapshot) ([]facts.Artifact, error)
 }
 
+// Budgeted is implemented by renderers whose output is sized to a token
+// budget, so a caller can render with a budget other than the configured one.
+type Budgeted interface {
+	Renderer
+	// WithMaxTokens returns a copy of the renderer using the given budget.
+	WithMaxTokens(maxTokens int) Renderer
+}
+
 // Registry holds registered renderers.
 type Registry struct {
 	renderers []Renderer
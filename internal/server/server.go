@@ -1,41 +1,65 @@
 package server
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dejo1307/archmcp/internal/config"
 	"github.com/dejo1307/archmcp/internal/engine"
 	"github.com/dejo1307/archmcp/internal/facts"
+	"github.com/dejo1307/archmcp/internal/renderers"
+	"github.com/dejo1307/archmcp/internal/renderers/jsongraph"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+// Version is the archmcp server version reported to MCP clients.
+const Version = "0.1.0"
+
+// mcpSDKModule is the module path of the MCP SDK, whose version server_info
+// reports from the build info.
+const mcpSDKModule = "github.com/modelcontextprotocol/go-sdk"
+
 // Server wraps the MCP server and connects it to the snapshot engine.
 type Server struct {
-	mcp *mcp.Server
-	eng *engine.Engine
-	cfg *config.Config
+	mcp     *mcp.Server
+	eng     *engine.Engine
+	cfg     *config.Config
+	src     *sourceCache
+	started time.Time
 }
 
 // New creates a new MCP server wired to the given engine.
 func New(eng *engine.Engine, cfg *config.Config) (*Server, error) {
 	s := &Server{
-		eng: eng,
-		cfg: cfg,
+		eng:     eng,
+		cfg:     cfg,
+		src:     newSourceCache(cfg.SourceCache.MaxFiles, cfg.SourceCache.MaxBytes),
+		started: time.Now(),
 	}
 
 	mcpServer := mcp.NewServer(&mcp.Implementation{
 		Name:    "archmcp",
-		Version: "0.1.0",
+		Version: Version,
 	}, nil)
 
 	s.mcp = mcpServer
 	s.registerTools()
+	s.registerResources()
 
 	return s, nil
 }
@@ -46,20 +70,83 @@ func (s *Server) Run(ctx context.Context) error {
 	return s.mcp.Run(ctx, &mcp.StdioTransport{})
 }
 
+// snapshotResource describes a snapshot artifact exposed as an MCP resource.
+// Artifacts produced by a renderer are only exposed when it is enabled.
+type snapshotResource struct {
+	uri         string
+	name        string
+	mimeType    string
+	description string
+	renderer    string
+}
+
+var snapshotResources = []snapshotResource{
+	{"arch://snapshot/context", "llm_context.md", "text/markdown", "Compact LLM-ready architecture summary", "llm_context"},
+	{"arch://snapshot/facts", "facts.jsonl", "application/x-ndjson", "All extracted facts, one JSON object per line", ""},
+	{"arch://snapshot/insights", "insights.json", "application/json", "Architectural insights with confidence scores", ""},
+	{"arch://snapshot/meta", "snapshot.meta.json", "application/json", "Snapshot metadata including file hashes", ""},
+	{"arch://snapshot/graph", "graph.cyto.json", "application/json", "Node/edge graph for Cytoscape.js or D3", "json_graph"},
+	{"arch://snapshot/insights-report", "insights.md", "text/markdown", "Human-readable insights report grouped by category", "insights_md"},
+}
+
+// registerResources exposes the snapshot artifacts as MCP resources so a
+// client can read them directly without a tool call.
+func (s *Server) registerResources() {
+	var resources []snapshotResource
+	for _, r := range snapshotResources {
+		if r.renderer == "" || s.cfg.IsRendererEnabled(r.renderer) {
+			resources = append(resources, r)
+		}
+	}
+
+	for _, r := range resources {
+		s.mcp.AddResource(&mcp.Resource{
+			URI:         r.uri,
+			Name:        r.name,
+			Description: r.description,
+			MIMEType:    r.mimeType,
+		}, s.readArtifactResource(r))
+	}
+}
+
+// readArtifactResource returns a handler serving the current snapshot's copy
+// of the given artifact.
+func (s *Server) readArtifactResource(r snapshotResource) mcp.ResourceHandler {
+	return func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		uri := req.Params.URI
+		if s.eng.Snapshot() == nil {
+			return nil, fmt.Errorf("no snapshot generated; call generate_snapshot first")
+		}
+		data, err := s.eng.GetArtifact(r.name)
+		if err != nil {
+			return nil, mcp.ResourceNotFoundError(uri)
+		}
+		return &mcp.ReadResourceResult{
+			Contents: []*mcp.ResourceContents{{
+				URI:      uri,
+				MIMEType: r.mimeType,
+				Text:     string(data),
+			}},
+		}, nil
+	}
+}
+
 // generateSnapshotArgs are the arguments for the generate_snapshot tool.
 type generateSnapshotArgs struct {
-	RepoPath string `json:"repo_path" jsonschema:"Path to the repository to analyze. Defaults to the configured repo path."`
-	Append   bool   `json:"append,omitempty" jsonschema:"If true, keep existing facts and add new ones with repo-prefixed file paths (for multi-repo analysis). Default false."`
+	RepoPath     string `json:"repo_path" jsonschema:"Path to the repository to analyze. Defaults to the configured repo path. A relative path resolves against the configured repo_root (the server's working directory when unset), and a leading ~ expands to the home directory."`
+	Append       bool   `json:"append,omitempty" jsonschema:"If true, keep existing facts and add new ones with repo-prefixed file paths (for multi-repo analysis). Default false."`
+	ChangedSince string `json:"changed_since,omitempty" jsonschema:"Git ref (branch, tag, or commit). Only files changed since this ref are re-extracted; facts for unchanged files are reused from the previous snapshot. Not supported with append."`
 }
 
 // queryFactsArgs are the arguments for the query_facts tool.
 type queryFactsArgs struct {
-	Kind      string `json:"kind,omitempty" jsonschema:"Filter by fact kind: module, symbol, route, storage, or dependency"`
+	Kind      string `json:"kind,omitempty" jsonschema:"Filter by fact kind: module, symbol, route, storage, dependency, or config (environment variables and feature flags the code reads)"`
 	File      string `json:"file,omitempty" jsonschema:"Filter by file path"`
 	Name      string `json:"name,omitempty" jsonschema:"Filter by name using substring match"`
 	Relation  string `json:"relation,omitempty" jsonschema:"Filter by relation kind: declares, imports, calls, implements, or depends_on"`
-	Prop      string `json:"prop,omitempty" jsonschema:"Filter by property name (e.g. source, symbol_kind, exported, framework, storage_kind)"`
-	PropValue string `json:"prop_value,omitempty" jsonschema:"Filter by property value (requires prop to be set)"`
+	Prop      string `json:"prop,omitempty" jsonschema:"Filter by property name (e.g. source, symbol_kind, exported, framework, storage_kind, manifest, annotations)"`
+	PropValue string `json:"prop_value,omitempty" jsonschema:"Filter by property value (requires prop to be set). A list property (e.g. json_keys, db_columns, annotations) matches when any element equals the value."`
+	PropOp    string `json:"prop_op,omitempty" jsonschema:"How prop_value is compared: eq (default, string equality), or lt, gt, lte, gte to compare numerically (e.g. prop=coverage_pct, prop_op=lt, prop_value=50)"`
 
 	// Batch filters — OR within dimension, AND across dimensions
 	Names      []string `json:"names,omitempty" jsonschema:"Filter by multiple exact names (OR). Use instead of name for batch lookups."`
@@ -67,24 +154,41 @@ type queryFactsArgs struct {
 	Kinds      []string `json:"kinds,omitempty" jsonschema:"Filter by multiple kinds (OR). Use instead of kind for batch lookups."`
 	FilePrefix string   `json:"file_prefix,omitempty" jsonschema:"Filter by file path prefix (e.g. internal/server to match all files in that directory)"`
 	Repo       string   `json:"repo,omitempty" jsonschema:"Filter by repository label (set in multi-repo/append mode, e.g. 'go-service')"`
+	LineMin    int      `json:"line_min,omitempty" jsonschema:"Only facts on or after this line (requires file, files or file_prefix). Use with line_max to find the facts declared in a diff hunk."`
+	LineMax    int      `json:"line_max,omitempty" jsonschema:"Only facts on or before this line (requires file, files or file_prefix)"`
+
+	// Negation filters — applied after the positive filters
+	NotKinds      []string `json:"not_kinds,omitempty" jsonschema:"Exclude facts of any of these kinds"`
+	NotFilePrefix string   `json:"not_file_prefix,omitempty" jsonschema:"Exclude facts whose file path starts with this prefix"`
+	NotProp       string   `json:"not_prop,omitempty" jsonschema:"Exclude facts that have this property (e.g. exported)"`
+	NotPropValue  string   `json:"not_prop_value,omitempty" jsonschema:"Only exclude facts whose not_prop equals this value (e.g. not_prop=exported, not_prop_value=true)"`
+
+	ExportedOnly bool `json:"exported_only,omitempty" jsonschema:"If true, only return the public API: facts with exported=true, plus all routes and storage"`
 
 	// Pagination
 	Offset int `json:"offset,omitempty" jsonschema:"Number of results to skip for pagination. Default 0."`
 	Limit  int `json:"limit,omitempty" jsonschema:"Maximum number of results to return (1-500). Default 100."`
 
 	// Relation expansion
-	IncludeRelated bool `json:"include_related,omitempty" jsonschema:"If true, inline the full fact data for each relation target instead of just the target name"`
+	IncludeRelated bool     `json:"include_related,omitempty" jsonschema:"If true, inline the full fact data for each relation target instead of just the target name"`
+	RelatedDepth   int      `json:"related_depth,omitempty" jsonschema:"How many relation hops to inline with include_related: 1 (default) or 2 (also inline the related facts of related facts)"`
+	RelatedKinds   []string `json:"related_kinds,omitempty" jsonschema:"Only expand relations of these kinds with include_related (e.g. ['implements'])"`
 
 	// Output format
-	OutputMode string `json:"output_mode,omitempty" jsonschema:"Output format: 'full' (default JSON), 'compact' (markdown table), or 'names' (just names and files)"`
+	OutputMode string   `json:"output_mode,omitempty" jsonschema:"Output format: 'full' (default JSON), 'compact' (markdown table), or 'names' (just names and files)"`
+	Fields     []string `json:"fields,omitempty" jsonschema:"Only return these fields of each fact, in every output mode: kind, name, file, line, repo, props, relations, or props.<key> for one property (e.g. ['name', 'file'] or ['name', 'props.symbol_kind'])"`
 }
 
 // enrichedFact wraps a Fact with resolved relation targets.
 type enrichedFact struct {
 	facts.Fact
-	RelatedFacts []facts.Fact `json:"related_facts,omitempty"`
+	RelatedFacts []enrichedFact `json:"related_facts,omitempty"`
 }
 
+// maxRelatedFacts caps how many facts include_related inlines per response,
+// so a depth-2 expansion of a hub node cannot blow up the payload.
+const maxRelatedFacts = 500
+
 // queryResponse is the structured response for query_facts when advanced features are used.
 type queryResponse struct {
 	Facts   any  `json:"facts"`
@@ -92,45 +196,362 @@ type queryResponse struct {
 	Offset  int  `json:"offset"`
 	Limit   int  `json:"limit"`
 	HasMore bool `json:"has_more"`
+
+	// RelatedTruncated is set when include_related stopped at maxRelatedFacts.
+	RelatedTruncated bool `json:"related_truncated,omitempty"`
+}
+
+// expandRelated inlines relation targets for include_related. Targets are
+// deduplicated across the whole response, and the expansion is breadth-first:
+// every result gets its first hop before any second hop is added, so when
+// maxRelatedFacts is reached the closest facts are the ones kept. The second
+// hop skips the query results themselves. It reports whether the cap cut the
+// expansion short.
+func expandRelated(store *facts.Store, results []facts.Fact, depth int, kinds []string) ([]enrichedFact, bool) {
+	if depth <= 0 {
+		depth = 1
+	}
+	var kindSet map[string]bool
+	if len(kinds) > 0 {
+		kindSet = make(map[string]bool, len(kinds))
+		for _, k := range kinds {
+			kindSet[k] = true
+		}
+	}
+
+	seen := make(map[string]struct{}) // deduplicate related facts
+	inlined := 0
+	truncated := false
+	expand := func(f facts.Fact) []enrichedFact {
+		var out []enrichedFact
+		for _, rel := range f.Relations {
+			if kindSet != nil && !kindSet[rel.Kind] {
+				continue
+			}
+			if _, dup := seen[rel.Target]; dup {
+				continue
+			}
+			related := store.LookupByExactName(rel.Target)
+			if inlined+len(related) > maxRelatedFacts {
+				truncated = true
+				continue
+			}
+			seen[rel.Target] = struct{}{}
+			inlined += len(related)
+			for _, rf := range related {
+				out = append(out, enrichedFact{Fact: rf})
+			}
+		}
+		return out
+	}
+
+	enriched := make([]enrichedFact, len(results))
+	for i, f := range results {
+		enriched[i] = enrichedFact{Fact: f, RelatedFacts: expand(f)}
+	}
+	if depth == 2 {
+		for _, f := range results {
+			seen[f.Name] = struct{}{}
+		}
+		for i := range enriched {
+			related := enriched[i].RelatedFacts
+			for j := range related {
+				related[j].RelatedFacts = expand(related[j].Fact)
+			}
+		}
+	}
+	return enriched, truncated
+}
+
+// factFields are the fields query_facts can select with fields, in the
+// order of the Fact JSON. "props.<key>" selects a single property.
+var factFields = []string{"kind", "name", "file", "line", "repo", "props", "relations"}
+
+// defaultCompactFields are the compact table columns when no fields are set.
+var defaultCompactFields = []string{"kind", "name", "file", "line"}
+
+// validateFields checks a fields selection and returns it without duplicates.
+func validateFields(fields []string) ([]string, error) {
+	var out []string
+	seen := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		key, isProp := strings.CutPrefix(f, "props.")
+		if !(isProp && key != "") && !slices.Contains(factFields, f) {
+			return nil, fmt.Errorf("unknown field %q: fields must be %s, or props.<key>", f, strings.Join(factFields, ", "))
+		}
+		if !seen[f] {
+			seen[f] = true
+			out = append(out, f)
+		}
+	}
+	return out, nil
+}
+
+// projectFact returns the selected fields of f as a JSON object. Empty
+// fields are omitted as in the full Fact JSON; props.<key> selections are
+// gathered under props.
+func projectFact(f facts.Fact, fields []string) map[string]any {
+	out := make(map[string]any, len(fields))
+	for _, field := range fields {
+		switch field {
+		case "kind":
+			out["kind"] = f.Kind
+		case "name":
+			out["name"] = f.Name
+		case "file":
+			if f.File != "" {
+				out["file"] = f.File
+			}
+		case "line":
+			if f.Line > 0 {
+				out["line"] = f.Line
+			}
+		case "repo":
+			if f.Repo != "" {
+				out["repo"] = f.Repo
+			}
+		case "props":
+			if len(f.Props) > 0 {
+				out["props"] = f.Props
+			}
+		case "relations":
+			if len(f.Relations) > 0 {
+				out["relations"] = f.Relations
+			}
+		default:
+			key := strings.TrimPrefix(field, "props.")
+			v, ok := f.Props[key]
+			if !ok {
+				continue
+			}
+			props, _ := out["props"].(map[string]any)
+			if props == nil {
+				props = make(map[string]any)
+				out["props"] = props
+			}
+			props[key] = v
+		}
+	}
+	return out
+}
+
+// projectEnriched applies projectFact to expanded facts and their inlined
+// related facts.
+func projectEnriched(enriched []enrichedFact, fields []string) []map[string]any {
+	out := make([]map[string]any, len(enriched))
+	for i, ef := range enriched {
+		out[i] = projectFact(ef.Fact, fields)
+		if len(ef.RelatedFacts) > 0 {
+			out[i]["related_facts"] = projectEnriched(ef.RelatedFacts, fields)
+		}
+	}
+	return out
+}
+
+// fieldText renders one field of f as plain text for the compact and names
+// output modes.
+func fieldText(f facts.Fact, field string) string {
+	switch field {
+	case "kind":
+		return f.Kind
+	case "name":
+		return f.Name
+	case "file":
+		return f.File
+	case "line":
+		if f.Line > 0 {
+			return strconv.Itoa(f.Line)
+		}
+		return ""
+	case "repo":
+		return f.Repo
+	case "props":
+		keys := make([]string, 0, len(f.Props))
+		for k := range f.Props {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = fmt.Sprintf("%s=%v", k, f.Props[k])
+		}
+		return strings.Join(parts, ", ")
+	case "relations":
+		parts := make([]string, len(f.Relations))
+		for i, r := range f.Relations {
+			parts[i] = r.Kind + " " + r.Target
+		}
+		return strings.Join(parts, ", ")
+	}
+	if v, ok := f.Props[strings.TrimPrefix(field, "props.")]; ok {
+		return fmt.Sprint(v)
+	}
+	return ""
 }
 
 // renderCompact formats facts as a markdown table for minimal token usage.
-func renderCompact(results []facts.Fact, total int) string {
+// The columns are the selected fields, or kind, name, file and line.
+func renderCompact(results []facts.Fact, total int, fields []string) string {
+	if len(fields) == 0 {
+		fields = defaultCompactFields
+	}
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("Found %d results (showing %d):\n\n", total, len(results)))
-	sb.WriteString("| Kind | Name | File | Line |\n")
-	sb.WriteString("|------|------|------|------|\n")
+	header := make([]string, len(fields))
+	rule := make([]string, len(fields))
+	for i, field := range fields {
+		name := strings.TrimPrefix(field, "props.")
+		header[i] = strings.ToUpper(name[:1]) + name[1:]
+		rule[i] = strings.Repeat("-", len(name)+2)
+	}
+	sb.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	sb.WriteString("|" + strings.Join(rule, "|") + "|\n")
+	row := make([]string, len(fields))
 	for _, f := range results {
-		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %d |\n", f.Kind, f.Name, f.File, f.Line))
+		for i, field := range fields {
+			row[i] = fieldText(f, field)
+		}
+		sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
 	}
 	return sb.String()
 }
 
-// renderNamesOnly returns just names and files, one per line.
-func renderNamesOnly(results []facts.Fact, total int) string {
+// renderNamesOnly returns just names and files, one per line. With fields
+// set, each line holds the selected fields instead, separated by two spaces.
+func renderNamesOnly(results []facts.Fact, total int, fields []string) string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("Found %d results (showing %d):\n\n", total, len(results)))
 	for _, f := range results {
-		sb.WriteString(fmt.Sprintf("%s  %s:%d\n", f.Name, f.File, f.Line))
+		if len(fields) == 0 {
+			sb.WriteString(fmt.Sprintf("%s  %s:%d\n", f.Name, f.File, f.Line))
+			continue
+		}
+		vals := make([]string, len(fields))
+		for i, field := range fields {
+			vals[i] = fieldText(f, field)
+		}
+		sb.WriteString(strings.Join(vals, "  ") + "\n")
+	}
+	return sb.String()
+}
+
+// maxImpactSummaryModules caps the modules listed by renderImpactSummary.
+const maxImpactSummaryModules = 10
+
+// renderImpactSummary describes the shape of an impact set as markdown: the
+// total, the count per depth and per node kind, and the modules holding the
+// most impacted nodes. Nodes other than modules count toward the directory
+// of their file.
+func renderImpactSummary(result facts.ImpactResult) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## Impact of `%s`\n\n", result.Target))
+
+	total := 0
+	byKind := make(map[string]int)
+	byModule := make(map[string]int)
+	for _, nodes := range result.ByDepth {
+		total += len(nodes)
+		for _, n := range nodes {
+			kind := n.Kind
+			if kind == "" {
+				kind = "unknown"
+			}
+			byKind[kind]++
+			switch {
+			case n.Kind == facts.KindModule:
+				byModule[n.Name]++
+			case n.File != "":
+				byModule[filepath.Dir(n.File)]++
+			}
+		}
+	}
+	if total == 0 {
+		sb.WriteString("No dependents found.\n")
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("**%d impacted nodes** up to depth %d", total, result.Stats.MaxDepthReached))
+	if result.Stats.Truncated {
+		sb.WriteString(" (truncated at max_nodes)")
+	}
+	sb.WriteString(".\n\n")
+
+	depths := make([]int, 0, len(result.ByDepth))
+	for d := range result.ByDepth {
+		depths = append(depths, d)
+	}
+	sort.Ints(depths)
+	sb.WriteString("| Depth | Nodes |\n|-------|-------|\n")
+	for _, d := range depths {
+		sb.WriteString(fmt.Sprintf("| %d | %d |\n", d, len(result.ByDepth[d])))
+	}
+
+	sb.WriteString("\n| Kind | Nodes |\n|------|-------|\n")
+	for _, kv := range sortedCounts(byKind, 0) {
+		sb.WriteString(fmt.Sprintf("| %s | %d |\n", kv.name, kv.count))
 	}
+
+	if len(byModule) > 0 {
+		sb.WriteString(fmt.Sprintf("\n### Top Affected Modules (%d of %d)\n\n", min(len(byModule), maxImpactSummaryModules), len(byModule)))
+		sb.WriteString("| Module | Nodes |\n|--------|-------|\n")
+		for _, kv := range sortedCounts(byModule, maxImpactSummaryModules) {
+			sb.WriteString(fmt.Sprintf("| `%s` | %d |\n", kv.name, kv.count))
+		}
+	}
+
+	sb.WriteString("\n_Use output_mode 'full' for the impacted nodes and edges._\n")
 	return sb.String()
 }
 
+// nameCount is a name with its count, as returned by sortedCounts.
+type nameCount struct {
+	name  string
+	count int
+}
+
+// sortedCounts orders counts by count descending, then name, keeping the
+// first limit entries (limit <= 0 keeps all).
+func sortedCounts(counts map[string]int, limit int) []nameCount {
+	out := make([]nameCount, 0, len(counts))
+	for name, count := range counts {
+		out = append(out, nameCount{name, count})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].count != out[j].count {
+			return out[i].count > out[j].count
+		}
+		return out[i].name < out[j].name
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
 // registerTools adds MCP tools for snapshot generation and fact querying.
 func (s *Server) registerTools() {
 	// Tool: generate_snapshot
 	mcp.AddTool(s.mcp, &mcp.Tool{
 		Name:        "generate_snapshot",
-		Description: "Generate an architectural snapshot of a repository. Parses source code, extracts facts, detects patterns, and produces an LLM-ready context summary. Use append=true to add a second repository without clearing existing facts (for cross-repo analysis).",
+		Description: "Generate an architectural snapshot of a repository. Parses source code, extracts facts, detects patterns, and produces an LLM-ready context summary. Use append=true to add a second repository without clearing existing facts (for cross-repo analysis). Use changed_since=<git ref> to re-extract only files changed since that ref (e.g. for PR review).",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args generateSnapshotArgs) (*mcp.CallToolResult, any, error) {
-		repoPath := args.RepoPath
-		if repoPath == "" {
-			repoPath = s.cfg.Repo
+		var absRepo string
+		var err error
+		if args.RepoPath != "" {
+			absRepo, err = s.cfg.ResolvePath(args.RepoPath)
+		} else {
+			absRepo, err = s.cfg.RepoPath()
 		}
-
-		absRepo, err := filepath.Abs(repoPath)
 		if err != nil {
-			return errorResult(fmt.Sprintf("invalid repo path: %v", err)), nil, nil
+			return errorResult(codeInvalidArg, fmt.Sprintf("invalid repo path: %v", err)), nil, nil
+		}
+		if info, err := os.Stat(absRepo); err != nil || !info.IsDir() {
+			base := "the server's working directory"
+			if s.cfg.RepoRoot != "" {
+				base = "repo_root " + s.cfg.RepoRoot
+			}
+			return errorResult(codeInvalidArg, fmt.Sprintf("repo path %s is not a directory (relative paths resolve against %s)", absRepo, base)), nil, nil
 		}
 
 		// Auto-enable append mode when switching to a different repo
@@ -146,9 +567,17 @@ func (s *Server) registerTools() {
 			}
 		}
 
-		snapshot, err := s.eng.GenerateSnapshot(ctx, absRepo, appendMode)
+		var snapshot *facts.Snapshot
+		if args.ChangedSince != "" {
+			if appendMode {
+				return errorResult(codeInvalidArg, "changed_since cannot be combined with append mode (multi-repo snapshots are always regenerated in full)"), nil, nil
+			}
+			snapshot, err = s.eng.GenerateSnapshotSince(ctx, absRepo, args.ChangedSince)
+		} else {
+			snapshot, err = s.eng.GenerateSnapshot(ctx, absRepo, appendMode)
+		}
 		if err != nil {
-			return errorResult(fmt.Sprintf("snapshot generation failed: %v", err)), nil, nil
+			return errorResult(codeInternal, fmt.Sprintf("snapshot generation failed: %v", err)), nil, nil
 		}
 
 		// Write artifacts to disk
@@ -160,6 +589,7 @@ func (s *Server) registerTools() {
 		summary := fmt.Sprintf(
 			"Snapshot generated successfully.\n\n"+
 				"- Repository: %s\n"+
+				"- Parsed: %d/%d files, %d errors\n"+
 				"- Facts: %d\n"+
 				"- Insights: %d\n"+
 				"- Artifacts: %d\n"+
@@ -168,6 +598,9 @@ func (s *Server) registerTools() {
 				"- Explainers: %v\n\n"+
 				"Use query_facts or explore to inspect the extracted architecture.",
 			snapshot.Meta.RepoPath,
+			snapshot.Meta.FilesParsed(),
+			snapshot.Meta.FileCount,
+			len(snapshot.Meta.Errors),
 			snapshot.Meta.FactCount,
 			snapshot.Meta.InsightCount,
 			len(snapshot.Artifacts),
@@ -176,6 +609,18 @@ func (s *Server) registerTools() {
 			snapshot.Meta.Explainers,
 		)
 
+		if len(snapshot.Meta.ExtractorStats) > 0 {
+			summary += "\n\n" + formatExtractorStats(snapshot.Meta.ExtractorStats)
+		}
+
+		if len(snapshot.Meta.Errors) > 0 {
+			summary += "\n\n" + formatExtractErrors(snapshot.Meta.Errors)
+		}
+
+		if snapshot.Meta.ChangedSince != "" {
+			summary += fmt.Sprintf("\n\nScoped to files changed since %q; facts for unchanged files were reused from the previous snapshot.", snapshot.Meta.ChangedSince)
+		}
+
 		if appendMode {
 			repoLabel := filepath.Base(absRepo)
 			autoNote := ""
@@ -201,11 +646,37 @@ func (s *Server) registerTools() {
 	// Tool: query_facts
 	mcp.AddTool(s.mcp, &mcp.Tool{
 		Name:        "query_facts",
-		Description: "Query the extracted architectural facts by kind, file, name, or relation type. Returns matching facts as JSON. Supports batch filters (names, files, kinds), file prefix matching, pagination (offset/limit), and relation expansion (include_related). For dependencies, filter with prop='source' and prop_value='internal'|'external'|'stdlib' to control noise.",
+		Description: "Query the extracted architectural facts by kind, file, name, or relation type. Returns matching facts as JSON. Supports batch filters (names, files, kinds), file prefix matching, numeric property comparisons (prop_op lt/gt/lte/gte), negation filters (not_kinds, not_file_prefix, not_prop/not_prop_value), pagination (offset/limit), and relation expansion (include_related, with related_depth and related_kinds). For dependencies, filter with prop='source' and prop_value='internal'|'external'|'stdlib' to control noise.",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args queryFactsArgs) (*mcp.CallToolResult, any, error) {
 		store := s.eng.Store()
 		if store.Count() == 0 {
-			return errorResult("No facts available. Run generate_snapshot first."), nil, nil
+			return errorResult(codeNoSnapshot, "No facts available. Run generate_snapshot first."), nil, nil
+		}
+
+		if !facts.ValidPropOp(args.PropOp) {
+			return errorResult(codeInvalidArg, "prop_op must be one of eq, lt, gt, lte, gte"), nil, nil
+		}
+		if args.PropOp != "" && args.PropOp != facts.PropOpEq {
+			if args.Prop == "" || args.PropValue == "" {
+				return errorResult(codeInvalidArg, "prop_op "+args.PropOp+" requires prop and prop_value"), nil, nil
+			}
+			if _, err := strconv.ParseFloat(args.PropValue, 64); err != nil {
+				return errorResult(codeInvalidArg, fmt.Sprintf("prop_value %q must be a number for prop_op %s", args.PropValue, args.PropOp)), nil, nil
+			}
+		}
+
+		if args.LineMin != 0 || args.LineMax != 0 {
+			if args.File == "" && len(args.Files) == 0 && args.FilePrefix == "" {
+				return errorResult(codeInvalidArg, "line_min and line_max require file, files or file_prefix"), nil, nil
+			}
+			if args.LineMin < 0 || args.LineMax < 0 || (args.LineMax > 0 && args.LineMin > args.LineMax) {
+				return errorResult(codeInvalidArg, "line_min and line_max must be positive line numbers with line_min <= line_max"), nil, nil
+			}
+		}
+
+		fields, err := validateFields(args.Fields)
+		if err != nil {
+			return errorResult(codeInvalidArg, err.Error()), nil, nil
 		}
 
 		// Normalize absolute filesystem paths to store-relative paths.
@@ -227,14 +698,24 @@ func (s *Server) registerTools() {
 			File:       normFile,
 			Files:      normFiles,
 			FilePrefix: prefixes[0],
+			LineMin:    args.LineMin,
+			LineMax:    args.LineMax,
 			Name:       args.Name,
 			Names:      args.Names,
 			Repo:       args.Repo,
 			RelKind:    args.Relation,
 			Prop:       args.Prop,
 			PropValue:  args.PropValue,
+			PropOp:     args.PropOp,
 			Offset:     args.Offset,
 			Limit:      args.Limit,
+
+			NotKinds:      args.NotKinds,
+			NotFilePrefix: s.normalizeToRelative(args.NotFilePrefix),
+			NotProp:       args.NotProp,
+			NotPropValue:  args.NotPropValue,
+
+			ExportedOnly: args.ExportedOnly,
 		}
 
 		results, total := store.QueryAdvanced(opts)
@@ -252,13 +733,13 @@ func (s *Server) registerTools() {
 		case "compact":
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
-					&mcp.TextContent{Text: renderCompact(results, total)},
+					&mcp.TextContent{Text: renderCompact(results, total, fields)},
 				},
 			}, nil, nil
 		case "names":
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
-					&mcp.TextContent{Text: renderNamesOnly(results, total)},
+					&mcp.TextContent{Text: renderNamesOnly(results, total, fields)},
 				},
 			}, nil, nil
 		}
@@ -270,21 +751,23 @@ func (s *Server) registerTools() {
 
 		// Enrich with related facts if requested
 		var output any
+		relatedTruncated := false
 		if args.IncludeRelated {
-			enriched := make([]enrichedFact, len(results))
-			seen := make(map[string]struct{}) // deduplicate related facts
-			for i, f := range results {
-				enriched[i] = enrichedFact{Fact: f}
-				for _, rel := range f.Relations {
-					if _, dup := seen[rel.Target]; dup {
-						continue
-					}
-					seen[rel.Target] = struct{}{}
-					related := store.LookupByExactName(rel.Target)
-					enriched[i].RelatedFacts = append(enriched[i].RelatedFacts, related...)
-				}
+			if args.RelatedDepth < 0 || args.RelatedDepth > 2 {
+				return errorResult(codeInvalidArg, "related_depth must be 1 or 2"), nil, nil
 			}
+			var enriched []enrichedFact
+			enriched, relatedTruncated = expandRelated(store, results, args.RelatedDepth, args.RelatedKinds)
 			output = enriched
+			if len(fields) > 0 {
+				output = projectEnriched(enriched, fields)
+			}
+		} else if len(fields) > 0 {
+			projected := make([]map[string]any, len(results))
+			for i, f := range results {
+				projected[i] = projectFact(f, fields)
+			}
+			output = projected
 		} else {
 			output = results
 		}
@@ -303,10 +786,12 @@ func (s *Server) registerTools() {
 				Offset:  args.Offset,
 				Limit:   limit,
 				HasMore: total > args.Offset+len(results),
+
+				RelatedTruncated: relatedTruncated,
 			}
 			data, err := json.MarshalIndent(resp, "", "  ")
 			if err != nil {
-				return errorResult(fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
+				return errorResult(codeInternal, fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
 			}
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
@@ -318,7 +803,7 @@ func (s *Server) registerTools() {
 		// Legacy format: raw JSON array (backwards compatible)
 		data, err := json.MarshalIndent(output, "", "  ")
 		if err != nil {
-			return errorResult(fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
+			return errorResult(codeInternal, fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
 		}
 
 		text := string(data)
@@ -336,20 +821,20 @@ func (s *Server) registerTools() {
 	// Tool: show_symbol
 	mcp.AddTool(s.mcp, &mcp.Tool{
 		Name:        "show_symbol",
-		Description: "Show source code for a symbol found in the architectural snapshot. Returns the actual implementation with surrounding context lines.",
+		Description: "Show source code for a symbol found in the architectural snapshot. Returns the actual implementation with surrounding context lines. Set locations_only to list every match as 'name  file:line' without source, which helps disambiguate before picking one.",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args showSymbolArgs) (*mcp.CallToolResult, any, error) {
 		snapshot := s.eng.Snapshot()
 		if snapshot == nil {
-			return errorResult("No snapshot available. Run generate_snapshot first."), nil, nil
+			return errorResult(codeNoSnapshot, "No snapshot available. Run generate_snapshot first."), nil, nil
 		}
 
 		store := s.eng.Store()
 		if store.Count() == 0 {
-			return errorResult("No facts available. Run generate_snapshot first."), nil, nil
+			return errorResult(codeNoSnapshot, "No facts available. Run generate_snapshot first."), nil, nil
 		}
 
 		if args.Name == "" {
-			return errorResult("name is required"), nil, nil
+			return errorResult(codeInvalidArg, "name is required"), nil, nil
 		}
 
 		// Prefer exact match to avoid substring noise (e.g. "Transaction" matching "AutoTransactionsTogglePatch").
@@ -366,7 +851,19 @@ func (s *Server) registerTools() {
 			results = store.Query("symbol", "", args.Name, "")
 		}
 		if len(results) == 0 {
-			return errorResult(fmt.Sprintf("No symbols matching %q", args.Name)), nil, nil
+			return errorResult(codeNotFound, fmt.Sprintf("No symbols matching %q", args.Name)), nil, nil
+		}
+
+		if args.LocationsOnly {
+			maxResults := args.MaxResults
+			if maxResults <= 0 || maxResults > 500 {
+				maxResults = 500
+			}
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: formatSymbolLocations(results, maxResults)},
+				},
+			}, nil, nil
 		}
 
 		contextLines := args.ContextLines
@@ -374,12 +871,22 @@ func (s *Server) registerTools() {
 			contextLines = 60
 		}
 
-		// Limit to 5 results
-		if len(results) > 5 {
-			results = results[:5]
+		maxResults := args.MaxResults
+		if maxResults <= 0 {
+			maxResults = 5
+		}
+		if maxResults > 50 {
+			maxResults = 50
+		}
+		total := len(results)
+		if total > maxResults {
+			results = results[:maxResults]
 		}
 
 		var sb strings.Builder
+		if total > len(results) {
+			sb.WriteString(fmt.Sprintf("_Showing %d of %d matching symbols. Use max_results or locations_only to see the rest._\n\n", len(results), total))
+		}
 
 		for i, fact := range results {
 			if i > 0 {
@@ -402,7 +909,7 @@ func (s *Server) registerTools() {
 
 			// Read source file (handles both single-repo and multi-repo paths)
 			absFile := s.eng.ResolveFactFile(&fact)
-			source, err := readSourceWindow(absFile, fact.Line, contextLines)
+			source, err := readSourceWindow(s.src, absFile, fact.Line, contextLines)
 			if err != nil {
 				sb.WriteString(fmt.Sprintf("_Could not read source: %v_\n", err))
 				continue
@@ -425,15 +932,15 @@ func (s *Server) registerTools() {
 	// Tool: explore
 	mcp.AddTool(s.mcp, &mcp.Tool{
 		Name:        "explore",
-		Description: "Explore a module, file, symbol, or directory in a single call. Returns a rich markdown summary with symbols, dependencies, dependents, and relations — replacing many query_facts calls with one.",
+		Description: "Explore a module, file, route, symbol, namespace, or directory in a single call. Returns a rich markdown summary with symbols, dependencies, dependents, and relations — replacing many query_facts calls with one. Route paths like '/api/users/:id' (optionally prefixed with an HTTP method) show the handler, its call chain, and the storage it reaches. A glob focus like 'internal/*/handler' explores every matching directory at once. A '::' focus like 'Admin::Billing' (or 'Admin::' for a top-level namespace) aggregates the symbols qualified under that namespace, whatever directory declares them.",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args exploreArgs) (*mcp.CallToolResult, any, error) {
 		store := s.eng.Store()
 		if store.Count() == 0 {
-			return errorResult("No facts available. Run generate_snapshot first."), nil, nil
+			return errorResult(codeNoSnapshot, "No facts available. Run generate_snapshot first."), nil, nil
 		}
 
 		if args.Focus == "" {
-			return errorResult("focus is required"), nil, nil
+			return errorResult(codeInvalidArg, "focus is required"), nil, nil
 		}
 
 		depth := args.Depth
@@ -444,25 +951,89 @@ func (s *Server) registerTools() {
 			depth = 2
 		}
 
+		if args.ExportedOnly {
+			store = store.Filter(func(f facts.Fact) bool {
+				return f.Kind == facts.KindModule || facts.IsPublic(f)
+			})
+		}
+
 		var sb strings.Builder
 
 		// Normalize absolute filesystem paths to store-relative paths.
 		focus := s.normalizeToRelative(args.Focus)
 
 		// Try to determine focus type by matching against store indexes.
-		// Priority: exact module name > exact file > symbol name substring > file prefix (directory)
+		// Priority: directory glob > route > exact module name > exact file > namespace > symbol name substring > file prefix (directory)
+		// Routes come first so "/api/users" is not captured by a module such
+		// as app/controllers/api/users; matchRoutes only accepts route-shaped
+		// focuses ("/path" or "METHOD /path").
 		// Special case: "." means the repo root (from normalizing an absolute path that
 		// equals the snapshot RepoPath). Route directly to directory exploration to avoid
 		// "." accidentally substring-matching dotted symbol names.
 		switch {
+		case hasGlobMeta(focus) && s.exploreDirectoryGlob(store, focus, &sb):
 		case focus == "." && s.exploreDirectory(store, focus, &sb):
+		case focus != "." && s.exploreRoute(store, focus, &sb):
 		case focus != "." && s.exploreModule(store, focus, depth, &sb):
 		case focus != "." && s.exploreModuleSubstring(store, focus, depth, &sb):
 		case focus != "." && s.exploreFile(store, focus, depth, &sb):
+		case strings.Contains(focus, "::") && s.exploreNamespace(store, focus, depth, &sb):
 		case focus != "." && s.exploreSymbol(store, focus, depth, &sb):
 		case s.exploreDirectory(store, focus, &sb):
 		default:
-			return errorResult(fmt.Sprintf("No facts matching focus %q. Try a module name, file path, symbol name, or directory prefix.", focus)), nil, nil
+			return errorResult(codeNotFound, fmt.Sprintf("No facts matching focus %q. Try a module name, file path, route path, symbol name, or directory prefix.", focus)), nil, nil
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: sb.String()},
+			},
+		}, nil, nil
+	})
+
+	// Tool: trace_request
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "trace_request",
+		Description: "Trace an API request end to end in one call. Given a route path like '/api/users/:id' (optionally prefixed with an HTTP method), returns a tree of the route, its handler, the forward call chain, the storage each symbol touches and the external dependencies it calls, followed by summaries of the storage and external dependencies. Use this instead of combining explore, traverse and query_facts calls when debugging an endpoint.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args traceRequestArgs) (*mcp.CallToolResult, any, error) {
+		store := s.eng.Store()
+		if store.Count() == 0 {
+			return errorResult(codeNoSnapshot, "No facts available. Run generate_snapshot first."), nil, nil
+		}
+		if args.Route == "" {
+			return errorResult(codeInvalidArg, "route is required"), nil, nil
+		}
+
+		maxDepth := args.MaxDepth
+		if maxDepth <= 0 {
+			maxDepth = 3
+		}
+		maxDepth = min(maxDepth, 6)
+		maxNodes := args.MaxNodes
+		if maxNodes <= 0 {
+			maxNodes = 30
+		}
+		maxNodes = min(maxNodes, 200)
+
+		routes := matchRoutes(store, args.Route)
+		if len(routes) == 0 {
+			if !strings.HasPrefix(strings.TrimSpace(args.Route), "/") && !strings.Contains(args.Route, " /") {
+				return errorResult(codeInvalidArg, fmt.Sprintf("route %q is not a path; use a route path like '/api/users/:id' or 'GET /api/users/:id'", args.Route)), nil, nil
+			}
+			return errorResult(codeNotFound, fmt.Sprintf("No route matching %q. Use query_facts with kind=route to list the routes.", args.Route)), nil, nil
+		}
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("# Trace: %s\n\n", strings.TrimSpace(args.Route)))
+		if len(routes) > 5 {
+			sb.WriteString(fmt.Sprintf("Showing 5 of %d matching routes. Add the HTTP method or a longer path to narrow the match.\n\n", len(routes)))
+			routes = routes[:5]
+		}
+		for i, r := range routes {
+			if i > 0 {
+				sb.WriteString("---\n\n")
+			}
+			traceRoute(store, r, maxDepth, maxNodes, &sb)
 		}
 
 		return &mcp.CallToolResult{
@@ -472,28 +1043,57 @@ func (s *Server) registerTools() {
 		}, nil, nil
 	})
 
+	// Tool: outline
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "outline",
+		Description: "Return the symbol tree of one file, like an IDE outline view: its symbols in source order with kind, line and signature, and members (methods, nested types) nested under the type that declares them. Much lighter than explore on a file, which also computes dependencies. Use it to decide where to insert code in a specific file, or to pick a symbol for show_symbol.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args outlineArgs) (*mcp.CallToolResult, any, error) {
+		store := s.eng.Store()
+		if store.Count() == 0 {
+			return errorResult(codeNoSnapshot, "No facts available. Run generate_snapshot first."), nil, nil
+		}
+		if args.File == "" {
+			return errorResult(codeInvalidArg, "file is required"), nil, nil
+		}
+
+		file, fileFacts := s.lookupFile(store, s.normalizeToRelative(args.File))
+		if len(fileFacts) == 0 {
+			return errorResult(codeNotFound, fmt.Sprintf("No facts for file %q. Check the path relative to the repo root.", args.File)), nil, nil
+		}
+
+		data, err := json.MarshalIndent(outlineResult{File: file, Symbols: buildOutline(fileFacts)}, "", "  ")
+		if err != nil {
+			return errorResult(codeInternal, fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
 	// Tool: traverse
 	mcp.AddTool(s.mcp, &mcp.Tool{
 		Name:        "traverse",
-		Description: "Walk the dependency/call graph from a starting point. Use direction='forward' to answer 'what does X depend on?' and direction='reverse' to answer 'what depends on X?'. Returns a list of nodes and edges up to the specified depth. Use this instead of multiple explore calls when you need to understand transitive relationships.",
+		Description: "Walk the dependency/call graph from a starting point. Use direction='forward' to answer 'what does X depend on?' and direction='reverse' to answer 'what depends on X?'. Returns a list of nodes and edges up to the specified depth; each node carries its depth (hops from the start) and nodes are sorted nearest first by default. Use this instead of multiple explore calls when you need to understand transitive relationships.",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args traverseArgs) (*mcp.CallToolResult, any, error) {
 		store := s.eng.Store()
 		if store.Count() == 0 {
-			return errorResult("No facts available. Run generate_snapshot first."), nil, nil
+			return errorResult(codeNoSnapshot, "No facts available. Run generate_snapshot first."), nil, nil
 		}
 		graph := store.Graph()
 		if graph == nil {
-			return errorResult("No graph available. Run generate_snapshot first."), nil, nil
+			return errorResult(codeNoSnapshot, "No graph available. Run generate_snapshot first."), nil, nil
 		}
 
 		if args.Start == "" {
-			return errorResult("start is required"), nil, nil
+			return errorResult(codeInvalidArg, "start is required"), nil, nil
 		}
 
 		// Resolve start name: try exact match first, then substring
 		startName, err := s.resolveNodeName(store, args.Start)
 		if err != nil {
-			return errorResult(err.Error()), nil, nil
+			return errorResultFrom(err, codeNotFound, ""), nil, nil
 		}
 
 		direction := args.Direction
@@ -501,14 +1101,27 @@ func (s *Server) registerTools() {
 			direction = "forward"
 		}
 		if direction != "forward" && direction != "reverse" {
-			return errorResult("direction must be 'forward' or 'reverse'"), nil, nil
+			return errorResult(codeInvalidArg, "direction must be 'forward' or 'reverse'"), nil, nil
+		}
+		if !validSortBy(args.SortBy) {
+			return errorResult(codeInvalidArg, "sort_by must be 'depth' or 'name'"), nil, nil
 		}
 
-		result := graph.Traverse(startName, direction, args.RelationKinds, args.NodeKinds, args.MaxDepth, args.MaxNodes)
+		var include func(facts.Fact) bool
+		if kinds := args.NodeKinds; len(kinds) > 0 || args.ExportedOnly {
+			include = func(f facts.Fact) bool {
+				if len(kinds) > 0 && !slices.Contains(kinds, f.Kind) {
+					return false
+				}
+				return !args.ExportedOnly || facts.IsPublic(f)
+			}
+		}
+		result := graph.TraverseFunc(ctx, startName, direction, args.RelationKinds, include, args.MaxDepth, args.MaxNodes)
+		result.Paginate(args.SortBy, args.Offset, args.Limit)
 
 		data, err := json.MarshalIndent(result, "", "  ")
 		if err != nil {
-			return errorResult(fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
+			return errorResult(codeInternal, fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
 		}
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -524,31 +1137,31 @@ func (s *Server) registerTools() {
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args findPathArgs) (*mcp.CallToolResult, any, error) {
 		store := s.eng.Store()
 		if store.Count() == 0 {
-			return errorResult("No facts available. Run generate_snapshot first."), nil, nil
+			return errorResult(codeNoSnapshot, "No facts available. Run generate_snapshot first."), nil, nil
 		}
 		graph := store.Graph()
 		if graph == nil {
-			return errorResult("No graph available. Run generate_snapshot first."), nil, nil
+			return errorResult(codeNoSnapshot, "No graph available. Run generate_snapshot first."), nil, nil
 		}
 
 		if args.From == "" || args.To == "" {
-			return errorResult("both 'from' and 'to' are required"), nil, nil
+			return errorResult(codeInvalidArg, "both 'from' and 'to' are required"), nil, nil
 		}
 
 		fromName, err := s.resolveNodeName(store, args.From)
 		if err != nil {
-			return errorResult(fmt.Sprintf("from: %v", err)), nil, nil
+			return errorResultFrom(err, codeNotFound, "from: "), nil, nil
 		}
 		toName, err := s.resolveNodeName(store, args.To)
 		if err != nil {
-			return errorResult(fmt.Sprintf("to: %v", err)), nil, nil
+			return errorResultFrom(err, codeNotFound, "to: "), nil, nil
 		}
 
-		result := graph.FindPath(fromName, toName, args.RelationKinds, args.MaxDepth)
+		result := graph.FindPath(ctx, fromName, toName, args.RelationKinds, args.MaxDepth)
 
 		data, err := json.MarshalIndent(result, "", "  ")
 		if err != nil {
-			return errorResult(fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
+			return errorResult(codeInternal, fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
 		}
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -557,34 +1170,33 @@ func (s *Server) registerTools() {
 		}, nil, nil
 	})
 
-	// Tool: impact_analysis
+	// Tool: hierarchy
 	mcp.AddTool(s.mcp, &mcp.Tool{
-		Name:        "impact_analysis",
-		Description: "Analyze the impact of changing a module, symbol, or file. Returns all nodes that transitively depend on the target (i.e., what would be affected if the target changes), grouped by depth. Use this for refactoring planning, understanding blast radius, and change risk assessment.",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, args impactAnalysisArgs) (*mcp.CallToolResult, any, error) {
+		Name:        "hierarchy",
+		Description: "Show the inheritance hierarchy of a class, interface, struct or protocol: its supertype chain upward and every known subtype downward, as two trees built from implements and embeds relations. Use this to answer 'what are all the implementations of this base class?' or 'what does this type ultimately extend?' in one call. Supertypes outside the repository appear without a kind or file.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args hierarchyArgs) (*mcp.CallToolResult, any, error) {
 		store := s.eng.Store()
 		if store.Count() == 0 {
-			return errorResult("No facts available. Run generate_snapshot first."), nil, nil
+			return errorResult(codeNoSnapshot, "No facts available. Run generate_snapshot first."), nil, nil
 		}
 		graph := store.Graph()
 		if graph == nil {
-			return errorResult("No graph available. Run generate_snapshot first."), nil, nil
+			return errorResult(codeNoSnapshot, "No graph available. Run generate_snapshot first."), nil, nil
 		}
 
-		if args.Target == "" {
-			return errorResult("target is required"), nil, nil
+		if args.Type == "" {
+			return errorResult(codeInvalidArg, "type is required"), nil, nil
 		}
-
-		targetName, err := s.resolveNodeName(store, args.Target)
+		name, err := s.resolveNodeName(store, args.Type)
 		if err != nil {
-			return errorResult(err.Error()), nil, nil
+			return errorResultFrom(err, codeNotFound, ""), nil, nil
 		}
 
-		result := graph.ImpactSet(targetName, args.MaxDepth, args.MaxNodes, args.IncludeForward)
+		result := graph.Hierarchy(ctx, name, args.MaxDepth)
 
 		data, err := json.MarshalIndent(result, "", "  ")
 		if err != nil {
-			return errorResult(fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
+			return errorResult(codeInternal, fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
 		}
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -592,41 +1204,956 @@ func (s *Server) registerTools() {
 			},
 		}, nil, nil
 	})
-}
 
-// resolveNodeName resolves a user-provided name to an exact fact name.
-// It tries exact match first, then substring match with smart disambiguation
-// that prefers struct/class/interface definitions over their methods.
-func (s *Server) resolveNodeName(store *facts.Store, input string) (string, error) {
-	input = s.normalizeToRelative(input)
+	// Tool: subgraph
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "subgraph",
+		Description: "Render the graph neighborhood of one node as a diagram, for documenting a feature area instead of the whole repo graph. Walks up to 'depth' hops both ways from the focus (what it depends on and what depends on it) and returns the nodes reached with the edges among them, as Graphviz DOT, a Mermaid flowchart, or the json_graph nodes/edges document.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args subgraphArgs) (*mcp.CallToolResult, any, error) {
+		store := s.eng.Store()
+		if store.Count() == 0 {
+			return errorResult(codeNoSnapshot, "No facts available. Run generate_snapshot first."), nil, nil
+		}
+		graph := store.Graph()
+		if graph == nil {
+			return errorResult(codeNoSnapshot, "No graph available. Run generate_snapshot first."), nil, nil
+		}
 
-	// Try exact match first
-	exact := store.LookupByExactName(input)
-	if len(exact) > 0 {
-		return exact[0].Name, nil
-	}
+		if args.Focus == "" {
+			return errorResult(codeInvalidArg, "focus is required"), nil, nil
+		}
+		format := args.Format
+		if format == "" {
+			format = "mermaid"
+		}
+		if format != "dot" && format != "mermaid" && format != "json" {
+			return errorResult(codeInvalidArg, "format must be 'dot', 'mermaid' or 'json'"), nil, nil
+		}
+		depth := args.Depth
+		if depth <= 0 {
+			depth = 2
+		}
 
-	// Try substring match
-	results := store.Query("", "", input, "")
-	if len(results) == 0 {
-		return "", fmt.Errorf("no facts matching %q", input)
-	}
-	if len(results) == 1 {
-		return results[0].Name, nil
-	}
+		focus, err := s.resolveNodeName(store, args.Focus)
+		if err != nil {
+			return errorResultFrom(err, codeNotFound, ""), nil, nil
+		}
 
-	// Smart disambiguation: when multiple matches exist, try to find the
-	// most likely intended target rather than immediately erroring.
+		doc := s.subgraph(ctx, store, graph, focus, depth, args.MaxNodes)
 
-	// 1. Prefer exact name suffix match (e.g., "AuthHandler" matching
-	//    "adapters.AuthHandler" over "adapters.AuthHandler.Login")
-	var suffixMatches []facts.Fact
-	for _, r := range results {
-		parts := strings.Split(r.Name, ".")
-		if len(parts) > 0 && parts[len(parts)-1] == input {
-			suffixMatches = append(suffixMatches, r)
-		}
-	}
+		var text string
+		switch format {
+		case "dot":
+			text = doc.DOT()
+		case "mermaid":
+			text = doc.Mermaid()
+		default:
+			data, err := json.MarshalIndent(doc, "", "  ")
+			if err != nil {
+				return errorResult(codeInternal, fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
+			}
+			text = string(data)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: text},
+			},
+		}, nil, nil
+	})
+
+	// Tool: impact_analysis
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "impact_analysis",
+		Description: "Analyze the impact of changing a module, symbol, or file. Returns all nodes that transitively depend on the target (i.e., what would be affected if the target changes), grouped by depth. Use this for refactoring planning, understanding blast radius, and change risk assessment.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args impactAnalysisArgs) (*mcp.CallToolResult, any, error) {
+		store := s.eng.Store()
+		if store.Count() == 0 {
+			return errorResult(codeNoSnapshot, "No facts available. Run generate_snapshot first."), nil, nil
+		}
+		graph := store.Graph()
+		if graph == nil {
+			return errorResult(codeNoSnapshot, "No graph available. Run generate_snapshot first."), nil, nil
+		}
+
+		if args.Target == "" {
+			return errorResult(codeInvalidArg, "target is required"), nil, nil
+		}
+
+		targetName, err := s.resolveNodeName(store, args.Target)
+		if err != nil {
+			return errorResultFrom(err, codeNotFound, ""), nil, nil
+		}
+
+		if !validSortBy(args.SortBy) {
+			return errorResult(codeInvalidArg, "sort_by must be 'depth' or 'name'"), nil, nil
+		}
+		if args.OutputMode != "" && args.OutputMode != "full" && args.OutputMode != "summary" {
+			return errorResult(codeInvalidArg, "output_mode must be 'full' or 'summary'"), nil, nil
+		}
+
+		result := graph.ImpactSet(ctx, targetName, args.MaxDepth, args.MaxNodes, args.IncludeForward)
+		if args.OutputMode == "summary" {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: renderImpactSummary(result)},
+				},
+			}, nil, nil
+		}
+		result.Paginate(args.SortBy, args.Offset, args.Limit)
+
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return errorResult(codeInternal, fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	// Tool: rename_preview
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "rename_preview",
+		Description: "List every reference to a symbol before renaming it: the facts with a relation (calls, implements, embeds, depends_on, ...) targeting it, with file:line, grouped by file. Answered from the graph's reverse index, so it is much cheaper than grepping the repository. References written unqualified inside the symbol's package, or qualified by its package name from other packages (util.Helper), are included and marked match=short. The declaration itself is reported separately.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args renamePreviewArgs) (*mcp.CallToolResult, any, error) {
+		store := s.eng.Store()
+		if store.Count() == 0 {
+			return errorResult(codeNoSnapshot, "No facts available. Run generate_snapshot first."), nil, nil
+		}
+		if args.Name == "" {
+			return errorResult(codeInvalidArg, "name is required"), nil, nil
+		}
+
+		name, err := s.resolveNodeName(store, args.Name)
+		if err != nil {
+			return errorResultFrom(err, codeNotFound, ""), nil, nil
+		}
+
+		data, err := json.MarshalIndent(renamePreview(store, name), "", "  ")
+		if err != nil {
+			return errorResult(codeInternal, fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	// Tool: metrics
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "metrics",
+		Description: "Return whole-repo statistics as JSON: fact counts by kind, symbols by language, module/route/storage totals, detected cycle count, average fan-in/fan-out, the 10 most-depended-on modules, test counts per module, and the time and fact count of each extractor in the last generation. Use this for an at-a-glance health overview. In multi-repo mode, pass repo to scope the numbers to one repository.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args metricsArgs) (*mcp.CallToolResult, any, error) {
+		store := s.eng.Store()
+		if store.Count() == 0 {
+			return errorResult(codeNoSnapshot, "No facts available. Run generate_snapshot first."), nil, nil
+		}
+
+		var scoped []facts.Fact
+		if args.Repo != "" {
+			scoped = store.ByRepo(args.Repo)
+			if len(scoped) == 0 {
+				return errorResult(codeNotFound, fmt.Sprintf("no facts found for repo %q", args.Repo)), nil, nil
+			}
+		} else {
+			scoped = store.All()
+		}
+
+		result := facts.ComputeMetrics(scoped, 10)
+		if snap := s.eng.Snapshot(); snap != nil {
+			result.Cycles = countCycles(snap.Insights, scoped, args.Repo != "")
+			if args.Repo == "" || args.Repo == filepath.Base(snap.Meta.RepoPath) {
+				result.Frameworks = snap.Meta.Frameworks
+				result.ExtractorStats = snap.Meta.ExtractorStats
+			}
+		}
+
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return errorResult(codeInternal, fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	// Tool: compare_modules
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "compare_modules",
+		Description: "Compare two modules side by side: file count, symbol count, exported symbol count, fan-in, fan-out (the same coupling numbers as the Critical Modules table), and whether each module is part of a dependency cycle. Use this when deciding which of two modules to refactor, instead of two explore calls. Module names are exact or a unique substring (e.g. internal/server or server). Pass format 'table' for a markdown comparison table.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args compareModulesArgs) (*mcp.CallToolResult, any, error) {
+		store := s.eng.Store()
+		if store.Count() == 0 {
+			return errorResult(codeNoSnapshot, "No facts available. Run generate_snapshot first."), nil, nil
+		}
+		if args.A == "" || args.B == "" {
+			return errorResult(codeInvalidArg, "both 'a' and 'b' are required"), nil, nil
+		}
+
+		a, err := s.resolveModuleName(store, args.A)
+		if err != nil {
+			return errorResultFrom(err, codeNotFound, "a: "), nil, nil
+		}
+		b, err := s.resolveModuleName(store, args.B)
+		if err != nil {
+			return errorResultFrom(err, codeNotFound, "b: "), nil, nil
+		}
+		if a == b {
+			return errorResult(codeInvalidArg, fmt.Sprintf("'a' and 'b' both resolve to %s; pass two different modules", a)), nil, nil
+		}
+		format := args.Format
+		if format == "" {
+			format = "json"
+		}
+		if format != "json" && format != "table" {
+			return errorResult(codeInvalidArg, "format must be 'json' or 'table'"), nil, nil
+		}
+
+		result := compareModulesResult{Modules: facts.ComputeModuleMetrics(store.All(), a, b)}
+		if snap := s.eng.Snapshot(); snap != nil {
+			for i := range result.Modules {
+				result.Modules[i].InCycle = inModuleCycle(snap.Insights, result.Modules[i].Module)
+			}
+		}
+
+		if format == "table" {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: formatModuleComparison(result.Modules)},
+				},
+			}, nil, nil
+		}
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return errorResult(codeInternal, fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	// Tool: central_nodes
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "central_nodes",
+		Description: "Rank graph nodes by betweenness centrality: how many shortest dependency paths pass through each node. Unlike raw fan-in, this surfaces architectural chokepoints and down-ranks leaf utilities that are widely imported but depend on nothing. Returns the top-N nodes with fan-in/fan-out and weighted edge totals (weight = number of relations behind an edge). Defaults to module nodes over all relation kinds.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args centralNodesArgs) (*mcp.CallToolResult, any, error) {
+		store := s.eng.Store()
+		if store.Count() == 0 {
+			return errorResult(codeNoSnapshot, "No facts available. Run generate_snapshot first."), nil, nil
+		}
+		graph := store.Graph()
+		if graph == nil {
+			return errorResult(codeNoSnapshot, "No graph available. Run generate_snapshot first."), nil, nil
+		}
+
+		nodeKinds := args.NodeKinds
+		if len(nodeKinds) == 0 {
+			nodeKinds = []string{facts.KindModule}
+		}
+
+		result := graph.CentralNodes(ctx, args.RelationKinds, nodeKinds, args.Limit)
+
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return errorResult(codeInternal, fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	// Tool: related_files
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "related_files",
+		Description: "Rank the files most architecturally coupled to a file, as a proxy for 'files you will likely edit together'. Collects the facts declared in the file, follows their outgoing and incoming graph edges (calls, implements, embeds, ...), and returns the distinct files of the related facts ranked by edge count, with outgoing/incoming splits. Uses only the dependency graph, not git history.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args relatedFilesArgs) (*mcp.CallToolResult, any, error) {
+		store := s.eng.Store()
+		if store.Count() == 0 {
+			return errorResult(codeNoSnapshot, "No facts available. Run generate_snapshot first."), nil, nil
+		}
+		graph := store.Graph()
+		if graph == nil {
+			return errorResult(codeNoSnapshot, "No graph available. Run generate_snapshot first."), nil, nil
+		}
+		if args.File == "" {
+			return errorResult(codeInvalidArg, "file is required"), nil, nil
+		}
+
+		file := s.normalizeToRelative(args.File)
+		if len(store.ByFile(file)) == 0 {
+			return errorResult(codeNotFound, fmt.Sprintf("no facts found for file %q", file)), nil, nil
+		}
+
+		result := graph.RelatedFiles(file, args.RelationKinds, args.Limit)
+
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return errorResult(codeInternal, fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	// Tool: node_info
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "node_info",
+		Description: "Return a single fact by exact name as JSON: kind, file, line, all props, relations, and counts of incoming and outgoing graph edges (total and per relation kind). A lightweight alternative to explore when you already know the node's name. If several facts share the name, pass kind or repo to pick one; the error lists the candidates. If nothing matches exactly, the error suggests similar names.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args nodeInfoArgs) (*mcp.CallToolResult, any, error) {
+		store := s.eng.Store()
+		if store.Count() == 0 {
+			return errorResult(codeNoSnapshot, "No facts available. Run generate_snapshot first."), nil, nil
+		}
+		if args.Name == "" {
+			return errorResult(codeInvalidArg, "name is required"), nil, nil
+		}
+
+		result, err := nodeInfo(store, args.Name, args.Kind, args.Repo)
+		if err != nil {
+			return errorResultFrom(err, codeNotFound, ""), nil, nil
+		}
+
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return errorResult(codeInternal, fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	// Tool: suggest_names
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "suggest_names",
+		Description: "Complete a partial fact name: returns the fact names that start with prefix, in sorted order, with the kinds of facts behind each name. Backed by a sorted name index, so it stays fast on large stores. Use it for tab-completion, or to find the exact name to pass to node_info, traverse, or find_path.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args suggestNamesArgs) (*mcp.CallToolResult, any, error) {
+		store := s.eng.Store()
+		if store.Count() == 0 {
+			return errorResult(codeNoSnapshot, "No facts available. Run generate_snapshot first."), nil, nil
+		}
+		if args.Prefix == "" {
+			return errorResult(codeInvalidArg, "prefix is required"), nil, nil
+		}
+		limit := args.Limit
+		if limit <= 0 {
+			limit = 20
+		}
+		if limit > 200 {
+			limit = 200
+		}
+
+		data, err := json.MarshalIndent(suggestNames(store, s.normalizeToRelative(args.Prefix), limit), "", "  ")
+		if err != nil {
+			return errorResult(codeInternal, fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	// Tool: architecture_summary
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "architecture_summary",
+		Description: "Return the LLM-ready architecture summary (the llm_context.md markdown) for the current snapshot: repository map, architecture pattern, entry points, routes, storage, dependency rules, critical modules and risk zones. Call this first in a session for a high-level orientation. Set max_tokens to get a shorter or longer summary than the configured budget.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args architectureSummaryArgs) (*mcp.CallToolResult, any, error) {
+		if s.eng.Snapshot() == nil {
+			return errorResult(codeNoSnapshot, "No snapshot available. Run generate_snapshot first."), nil, nil
+		}
+		if args.MaxTokens != 0 && (args.MaxTokens < 1000 || args.MaxTokens > 200000) {
+			return errorResult(codeInvalidArg, "max_tokens must be between 1000 and 200000"), nil, nil
+		}
+
+		data, err := s.eng.RenderArtifact(ctx, "llm_context", "llm_context.md", args.MaxTokens)
+		if err != nil {
+			return errorResult(codeInternal, fmt.Sprintf("rendering summary: %v", err)), nil, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	// Tool: save_snapshot
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "save_snapshot",
+		Description: "Save the current snapshot (facts, insights, meta and artifacts) under a name, e.g. per branch, so it can be restored later with load_snapshot without re-parsing the repository. Saving under an existing name replaces it.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args saveSnapshotArgs) (*mcp.CallToolResult, any, error) {
+		if s.eng.Snapshot() == nil {
+			return errorResult(codeNoSnapshot, "No snapshot available. Run generate_snapshot first."), nil, nil
+		}
+		if !engine.ValidSnapshotName(args.Name) {
+			return errorResult(codeInvalidArg, fmt.Sprintf("invalid snapshot name %q: use letters, digits, '.', '-' and '_' (e.g. 'feature-login' for branch feature/login)", args.Name)), nil, nil
+		}
+
+		dir, err := s.eng.SaveSnapshot(args.Name)
+		if err != nil {
+			return errorResult(codeInternal, fmt.Sprintf("saving snapshot: %v", err)), nil, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Saved snapshot %q (%d facts) to %s.\n\nRestore it with load_snapshot(name=%q).", args.Name, s.eng.Store().Count(), dir, args.Name)},
+			},
+		}, nil, nil
+	})
+
+	// Tool: load_snapshot
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "load_snapshot",
+		Description: "Replace the current snapshot with one saved by save_snapshot, rebuilding the graph index. All query tools then answer from the loaded snapshot. Use this to switch between e.g. a 'main' and a 'feature' snapshot in one session.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args loadSnapshotArgs) (*mcp.CallToolResult, any, error) {
+		if !engine.ValidSnapshotName(args.Name) {
+			return errorResult(codeInvalidArg, fmt.Sprintf("invalid snapshot name %q", args.Name)), nil, nil
+		}
+
+		snapshot, err := s.eng.LoadSnapshot(args.Name)
+		if errors.Is(err, engine.ErrSnapshotNotFound) {
+			msg := fmt.Sprintf("no saved snapshot named %q", args.Name)
+			if saved := s.eng.SavedSnapshots(); len(saved) > 0 {
+				msg += ". Saved snapshots: " + strings.Join(saved, ", ")
+			}
+			return errorResult(codeNotFound, msg), nil, nil
+		}
+		if err != nil {
+			return errorResult(codeInternal, fmt.Sprintf("loading snapshot: %v", err)), nil, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Loaded snapshot %q.\n\n- Repository: %s\n- Generated: %s\n- Facts: %d\n- Insights: %d\n- Artifacts: %d",
+					args.Name, snapshot.Meta.RepoPath, snapshot.Meta.GeneratedAt, len(snapshot.Facts), len(snapshot.Insights), len(snapshot.Artifacts))},
+			},
+		}, nil, nil
+	})
+
+	// Tool: grep_source
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "grep_source",
+		Description: "Search the source of the files in the snapshot for a regular expression and return matching lines as 'file:line: text'. Only files that produced facts are searched, so ignore patterns and excluded directories are respected. Narrow with file_prefix or repo; max_results caps the number of lines returned.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args grepSourceArgs) (*mcp.CallToolResult, any, error) {
+		store := s.eng.Store()
+		if store.Count() == 0 {
+			return errorResult(codeNoSnapshot, "No facts available. Run generate_snapshot first."), nil, nil
+		}
+		if args.Pattern == "" {
+			return errorResult(codeInvalidArg, "pattern is required"), nil, nil
+		}
+		re, err := regexp.Compile(args.Pattern)
+		if err != nil {
+			return errorResult(codeInvalidArg, fmt.Sprintf("invalid pattern: %v", err)), nil, nil
+		}
+		maxResults := args.MaxResults
+		if maxResults <= 0 {
+			maxResults = 100
+		}
+		if maxResults > maxGrepResults {
+			maxResults = maxGrepResults
+		}
+
+		prefixes := s.expandFilePrefix(s.normalizeToRelative(args.FilePrefix))
+		matches, truncated := s.grepSource(ctx, store, re, prefixes, args.Repo, maxResults)
+
+		var sb strings.Builder
+		if len(matches) == 0 {
+			sb.WriteString(fmt.Sprintf("No matches for %q", args.Pattern))
+		}
+		for _, m := range matches {
+			sb.WriteString(m)
+			sb.WriteString("\n")
+		}
+		if truncated {
+			sb.WriteString(fmt.Sprintf("\n_Stopped after %d matches. Narrow the pattern or file_prefix, or raise max_results._\n", maxResults))
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: sb.String()},
+			},
+		}, nil, nil
+	})
+
+	// Tool: server_info
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "server_info",
+		Description: "Report server health as JSON: archmcp and MCP SDK versions, uptime, whether a snapshot is loaded, and the fact count. Cheap to call; use it at session start to check whether generate_snapshot needs to run first.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args serverInfoArgs) (*mcp.CallToolResult, any, error) {
+		data, err := json.MarshalIndent(s.serverInfo(), "", "  ")
+		if err != nil {
+			return errorResult(codeInternal, fmt.Sprintf("failed to marshal server info: %v", err)), nil, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	// Tool: validate
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "validate",
+		Description: "Check the loaded snapshot for internal consistency, as JSON: relations whose target resolves to no fact (dangling edges, counted per relation kind), facts whose file no longer exists on disk, and symbol names declared more than once. Relations of external and stdlib dependencies are counted apart, since they point outside the repo; calls relations keep the callee as written at the call site, so most of them dangle. Use it to judge how far graph answers can be trusted, or to spot extractor gaps.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args validateArgs) (*mcp.CallToolResult, any, error) {
+		store := s.eng.Store()
+		if store.Count() == 0 {
+			return errorResult(codeNoSnapshot, "No facts available. Run generate_snapshot first."), nil, nil
+		}
+		limit := args.Limit
+		if limit <= 0 {
+			limit = 20
+		}
+		if limit > 200 {
+			limit = 200
+		}
+		report := facts.Validate(store.All(), func(f facts.Fact) bool {
+			_, err := os.Stat(s.eng.ResolveFactFile(&f))
+			return err == nil
+		}, limit)
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return errorResult(codeInternal, fmt.Sprintf("failed to marshal validation report: %v", err)), nil, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	// Tool: show_config
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "show_config",
+		Description: "Show the configuration currently in effect as JSON, with defaults applied: enabled extractors/explainers/renderers, ignore patterns, output settings (including max context tokens), and the file it was loaded from. Use this to confirm whether a config file was loaded or built-in defaults are being used.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args showConfigArgs) (*mcp.CallToolResult, any, error) {
+		// A copy, since set_extractors may replace the list concurrently.
+		cfg := s.eng.ConfigCopy()
+		resp := configResponse{
+			Config:        cfg,
+			UsingDefaults: cfg.Source == "",
+		}
+		if snap := s.eng.Snapshot(); snap != nil {
+			resp.Frameworks = snap.Meta.Frameworks
+		}
+		data, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			return errorResult(codeInternal, fmt.Sprintf("failed to marshal config: %v", err)), nil, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	// Tool: set_extractors
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "set_extractors",
+		Description: "Choose which extractors run, without restarting the server: for example, drop typescript from a mixed repo whose frontend is noise for the question at hand. The list replaces the configured extractors and is honored by the next generate_snapshot; the current snapshot is unchanged. Names must be registered extractors. Returns the enabled set now in effect and the registered extractors.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args setExtractorsArgs) (*mcp.CallToolResult, any, error) {
+		if len(args.Enabled) == 0 {
+			return errorResult(codeInvalidArg, "enabled must name at least one extractor"), nil, nil
+		}
+		enabled, err := s.eng.SetExtractors(args.Enabled)
+		if err != nil {
+			return errorResult(codeInvalidArg, err.Error()), nil, nil
+		}
+
+		data, err := json.MarshalIndent(setExtractorsResult{Enabled: enabled, Registered: s.eng.ExtractorNames()}, "", "  ")
+		if err != nil {
+			return errorResult(codeInternal, fmt.Sprintf("failed to marshal results: %v", err)), nil, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	// Tool: explain_ignore
+	mcp.AddTool(s.mcp, &mcp.Tool{
+		Name:        "explain_ignore",
+		Description: "Report whether snapshot generation would skip a path and which rule matched: a config ignore pattern, a .archmcpignore line, or a per-directory .archmcp.yaml ignore or include list. Paths inside an ignored directory report the directory's rule. Use this when a file or module you expect is missing from the snapshot. Does not require a snapshot.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args explainIgnoreArgs) (*mcp.CallToolResult, any, error) {
+		if args.Path == "" {
+			return errorResult(codeInvalidArg, "path is required"), nil, nil
+		}
+		repoPath := s.eng.Config().Repo
+		if snap := s.eng.Snapshot(); snap != nil && snap.Meta.RepoPath != "" {
+			repoPath = snap.Meta.RepoPath
+		}
+		if args.Repo != "" {
+			p, ok := s.eng.RepoPaths()[args.Repo]
+			if !ok {
+				return errorResult(codeNotFound, fmt.Sprintf("unknown repo %q; known repos: %s", args.Repo, strings.Join(s.repoLabels(), ", "))), nil, nil
+			}
+			repoPath = p
+		}
+		exp, err := s.eng.ExplainIgnore(repoPath, args.Path)
+		if err != nil {
+			return errorResult(codeInvalidArg, err.Error()), nil, nil
+		}
+		data, err := json.MarshalIndent(exp, "", "  ")
+		if err != nil {
+			return errorResult(codeInternal, fmt.Sprintf("failed to marshal result: %v", err)), nil, nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+}
+
+// maxGrepResults caps the number of lines grep_source returns.
+const maxGrepResults = 1000
+
+// maxGrepLineLen truncates long matching lines (e.g. minified code).
+const maxGrepLineLen = 300
+
+// grepSource searches the files known to the store, in sorted order, for re
+// and returns up to maxResults matches formatted as "file:line: text". Only
+// files under one of prefixes (an empty prefix matches all) and, when repo is
+// set, files of that repository are read. The second result reports whether
+// the search stopped at maxResults. Unreadable files are skipped.
+func (s *Server) grepSource(ctx context.Context, store *facts.Store, re *regexp.Regexp, prefixes []string, repo string, maxResults int) ([]string, bool) {
+	repoPaths := s.eng.RepoPaths()
+	var matches []string
+	for _, file := range store.Files(repo) {
+		if ctx.Err() != nil {
+			break
+		}
+		inScope := false
+		for _, p := range prefixes {
+			if strings.HasPrefix(file, p) {
+				inScope = true
+				break
+			}
+		}
+		if !inScope {
+			continue
+		}
+
+		// ResolveFactFile needs the repo label to map multi-repo paths.
+		ref := facts.Fact{File: file, Repo: repo}
+		if ref.Repo == "" {
+			for label := range repoPaths {
+				if strings.HasPrefix(file, label+"/") {
+					ref.Repo = label
+					break
+				}
+			}
+		}
+		data, err := s.src.read(s.eng.ResolveFactFile(&ref))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		lineNum := 0
+		for scanner.Scan() {
+			lineNum++
+			text := scanner.Text()
+			if !re.MatchString(text) {
+				continue
+			}
+			if len(matches) == maxResults {
+				return matches, true
+			}
+			text = strings.TrimSpace(text)
+			if len(text) > maxGrepLineLen {
+				text = text[:maxGrepLineLen] + "..."
+			}
+			matches = append(matches, fmt.Sprintf("%s:%d: %s", file, lineNum, text))
+		}
+	}
+	return matches, false
+}
+
+// validSortBy reports whether sortBy is a node order accepted by traverse and
+// impact_analysis. Empty selects the default.
+func validSortBy(sortBy string) bool {
+	return sortBy == "" || sortBy == facts.SortByDepth || sortBy == facts.SortByName
+}
+
+// countCycles counts cycle insights produced by the cycles explainer. When
+// scoped is true, only cycles involving at least one module, symbol, or file
+// in ff are counted.
+func countCycles(insights []facts.Insight, ff []facts.Fact, scoped bool) int {
+	modules := make(map[string]bool)
+	files := make(map[string]bool)
+	if scoped {
+		for _, f := range ff {
+			if f.Kind == facts.KindModule || f.Kind == facts.KindSymbol {
+				modules[f.Name] = true
+			}
+			if f.File != "" {
+				files[f.File] = true
+			}
+		}
+	}
+	count := 0
+	for _, ins := range insights {
+		if !strings.HasPrefix(ins.Title, "Cyclic dependency detected") {
+			continue
+		}
+		if !scoped {
+			count++
+			continue
+		}
+		for _, ev := range ins.Evidence {
+			if modules[ev.Fact] || files[ev.File] {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+// renamePreview collects the references to name, found with ReverseLookup
+// under the name itself and under the short forms source code uses for it:
+// the name without its package from facts declared in that package, and the
+// name qualified by the package's last path segment ("util.Helper" for
+// internal/util.Helper) from other packages. Declares relations are
+// containment rather than references and are skipped.
+func renamePreview(store *facts.Store, name string) renamePreviewResult {
+	result := renamePreviewResult{Name: name, Files: []fileReferences{}}
+	if decl := store.LookupByExactName(name); len(decl) > 0 {
+		result.Declaration = &referenceSite{Name: decl[0].Name, Kind: decl[0].Kind, File: decl[0].File, Line: decl[0].Line}
+	}
+
+	type form struct {
+		target string
+		match  string
+		keep   func(facts.Fact) bool
+	}
+	forms := []form{{target: name, match: "exact"}}
+	if pkg, rest, ok := facts.SplitSymbolName(name); ok {
+		inPkg := func(f facts.Fact) bool { return facts.DeclaringModule(f) == pkg }
+		forms = append(forms,
+			form{target: rest, match: "short", keep: inPkg},
+			form{target: path.Base(pkg) + "." + rest, match: "short", keep: func(f facts.Fact) bool { return !inPkg(f) }},
+		)
+	}
+
+	seen := make(map[string]bool)
+	byFile := make(map[string][]referenceSite)
+	for _, fm := range forms {
+		for _, f := range store.ReverseLookup(fm.target, "") {
+			if f.Name == name || seen[f.Name] || (fm.keep != nil && !fm.keep(f)) {
+				continue
+			}
+			var kinds []string
+			for _, rel := range f.Relations {
+				if rel.Target == fm.target && rel.Kind != facts.RelDeclares && !slices.Contains(kinds, rel.Kind) {
+					kinds = append(kinds, rel.Kind)
+				}
+			}
+			if len(kinds) == 0 {
+				continue
+			}
+			seen[f.Name] = true
+			byFile[f.File] = append(byFile[f.File], referenceSite{
+				Name: f.Name, Kind: f.Kind, Line: f.Line, Relations: kinds, Match: fm.match,
+			})
+			result.Total++
+		}
+	}
+
+	files := make([]string, 0, len(byFile))
+	for file := range byFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		refs := byFile[file]
+		sort.Slice(refs, func(i, j int) bool {
+			if refs[i].Line != refs[j].Line {
+				return refs[i].Line < refs[j].Line
+			}
+			return refs[i].Name < refs[j].Name
+		})
+		result.Files = append(result.Files, fileReferences{File: file, References: refs})
+	}
+	return result
+}
+
+// buildOutline arranges the symbol facts of one file into a tree sorted by
+// line. A symbol is nested under the symbol of the same file whose name,
+// followed by a dot, is the longest prefix of its own name: methods under
+// their class or receiver type, nested types under their enclosing type. Go
+// methods whose receiver type is declared in another file stay at the top
+// level.
+func buildOutline(ff []facts.Fact) []*outlineNode {
+	var symbols []facts.Fact
+	for _, f := range ff {
+		if f.Kind == facts.KindSymbol {
+			symbols = append(symbols, f)
+		}
+	}
+	sort.SliceStable(symbols, func(i, j int) bool {
+		if symbols[i].Line != symbols[j].Line {
+			return symbols[i].Line < symbols[j].Line
+		}
+		return symbols[i].Name < symbols[j].Name
+	})
+
+	nodes := make(map[string]*outlineNode, len(symbols))
+	for _, f := range symbols {
+		if nodes[f.Name] != nil {
+			continue
+		}
+		kind, _ := f.Props["symbol_kind"].(string)
+		exported, _ := f.Props["exported"].(bool)
+		signature, _ := f.Props["signature"].(string)
+		name := f.Name
+		if pkg := facts.DeclaringModule(f); pkg != "" {
+			name = strings.TrimPrefix(name, pkg+".")
+		}
+		nodes[f.Name] = &outlineNode{Name: name, Qualified: f.Name, Kind: kind, Line: f.Line, Exported: exported, Signature: signature}
+	}
+
+	roots := []*outlineNode{}
+	placed := make(map[string]bool, len(nodes))
+	for _, f := range symbols {
+		if placed[f.Name] {
+			continue // a duplicate name, e.g. an overload
+		}
+		placed[f.Name] = true
+		node := nodes[f.Name]
+		var parent *outlineNode
+		for p := f.Name; ; {
+			dot := strings.LastIndex(p, ".")
+			if dot <= strings.LastIndex(p, "/") {
+				break
+			}
+			p = p[:dot]
+			if parent = nodes[p]; parent != nil {
+				break
+			}
+		}
+		if parent == nil {
+			roots = append(roots, node)
+			continue
+		}
+		node.Name = strings.TrimPrefix(f.Name, parent.Qualified+".")
+		parent.Children = append(parent.Children, node)
+	}
+	return roots
+}
+
+// inModuleCycle reports whether module appears in the evidence of a module
+// cycle insight.
+func inModuleCycle(insights []facts.Insight, module string) bool {
+	for _, ins := range insights {
+		if !strings.HasPrefix(ins.Title, "Cyclic dependency detected") || !strings.HasSuffix(ins.Title, "modules)") {
+			continue
+		}
+		for _, ev := range ins.Evidence {
+			if ev.Fact == module {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolveModuleName resolves a user-provided name to a module fact name: an
+// exact match, or else the only module whose name contains input.
+func (s *Server) resolveModuleName(store *facts.Store, input string) (string, error) {
+	input = strings.TrimSuffix(s.normalizeToRelative(input), "/")
+	for _, f := range store.LookupByExactName(input) {
+		if f.Kind == facts.KindModule {
+			return f.Name, nil
+		}
+	}
+
+	var matched []facts.Fact
+	for _, f := range store.Modules() {
+		if strings.Contains(f.Name, input) {
+			matched = append(matched, f)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return "", newToolError(codeNotFound, "no module matching %q", input)
+	case 1:
+		return matched[0].Name, nil
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].Name < matched[j].Name })
+	return "", newToolError(codeAmbiguous, "%q matches %d modules; use the full module name:\n%s",
+		input, len(matched), formatNodeCandidates(matched))
+}
+
+// subgraph returns the json_graph document of the nodes within depth hops
+// of focus in either direction, with the edges among them. Labels follow
+// the configured label trim, like the json_graph renderer.
+func (s *Server) subgraph(ctx context.Context, store *facts.Store, graph *facts.Graph, focus string, depth, maxNodes int) jsongraph.Document {
+	keep := map[string]bool{focus: true}
+	for _, direction := range []string{"forward", "reverse"} {
+		result := graph.Traverse(ctx, focus, direction, nil, nil, depth, maxNodes)
+		for _, n := range result.Nodes {
+			keep[n.Name] = true
+		}
+	}
+	rnd := jsongraph.New(s.cfg.JSONGraph.MaxNodes, renderers.LabelTrim{
+		StripPrefix: s.cfg.Output.LabelTrim.StripPrefix,
+		Segments:    s.cfg.Output.LabelTrim.Segments,
+	})
+	return rnd.Subgraph(&facts.Snapshot{Facts: store.All()}, func(name string) bool { return keep[name] })
+}
+
+// resolveNodeName resolves a user-provided name to an exact fact name.
+// It tries exact match first, then a unique name prefix from the prefix
+// index, then substring match with smart disambiguation that prefers
+// struct/class/interface definitions over their methods.
+func (s *Server) resolveNodeName(store *facts.Store, input string) (string, error) {
+	input = s.normalizeToRelative(input)
+
+	// Try exact match first
+	exact := store.LookupByExactName(input)
+	if len(exact) > 0 {
+		return exact[0].Name, nil
+	}
+
+	// A prefix shared by a single name (e.g. "internal/server.Serv") resolves
+	// without scanning every fact.
+	if name, ok := uniquePrefixName(store, input); ok {
+		return name, nil
+	}
+
+	// Try substring match
+	results := store.Query("", "", input, "")
+	if len(results) == 0 {
+		return "", newToolError(codeNotFound, "no facts matching %q", input)
+	}
+	if len(results) == 1 {
+		return results[0].Name, nil
+	}
+
+	// Smart disambiguation: when multiple matches exist, try to find the
+	// most likely intended target rather than immediately erroring.
+
+	// 1. Prefer exact name suffix match (e.g., "AuthHandler" matching
+	//    "adapters.AuthHandler" over "adapters.AuthHandler.Login")
+	var suffixMatches []facts.Fact
+	for _, r := range results {
+		parts := strings.Split(r.Name, ".")
+		if len(parts) > 0 && parts[len(parts)-1] == input {
+			suffixMatches = append(suffixMatches, r)
+		}
+	}
 	if len(suffixMatches) == 1 {
 		return suffixMatches[0].Name, nil
 	}
@@ -659,25 +2186,57 @@ func (s *Server) resolveNodeName(store *facts.Store, input string) (string, erro
 			}
 			names = append(names, r.Name)
 		}
-		return "", fmt.Errorf("ambiguous name %q matches %d facts (e.g. %s). Use a fully qualified name or filter by prop=symbol_kind", input, len(results), strings.Join(names, ", "))
+		return "", newToolError(codeAmbiguous, "ambiguous name %q matches %d facts (e.g. %s). Use a fully qualified name or filter by prop=symbol_kind", input, len(results), strings.Join(names, ", "))
 	}
 	return results[0].Name, nil
 }
 
+// uniquePrefixName reports the only fact name that starts with prefix, if
+// exactly one does.
+func uniquePrefixName(store *facts.Store, prefix string) (string, bool) {
+	if prefix == "" {
+		return "", false
+	}
+	var name string
+	for _, f := range store.LookupByPrefix(prefix, maxPrefixProbe) {
+		if name != "" && f.Name != name {
+			return "", false
+		}
+		name = f.Name
+	}
+	return name, name != ""
+}
+
+// maxPrefixProbe bounds how many prefix matches uniquePrefixName inspects;
+// a name shared by more facts than this is treated as ambiguous.
+const maxPrefixProbe = 16
+
 // exploreArgs are the arguments for the explore tool.
 type exploreArgs struct {
-	Focus string `json:"focus" jsonschema:"required,Module name, file path, or symbol name to explore"`
-	Depth int    `json:"depth,omitempty" jsonschema:"How deep to follow relations (1=direct only, 2=include relations of relations). Default 1, max 2."`
+	Focus        string `json:"focus" jsonschema:"required,Module name, file path, route path (e.g. 'GET /api/users/:id'), namespace (e.g. 'Admin::Billing'), or symbol name to explore"`
+	Depth        int    `json:"depth,omitempty" jsonschema:"How deep to follow relations (1=direct only, 2=include relations of relations). Default 1, max 2."`
+	ExportedOnly bool   `json:"exported_only,omitempty" jsonschema:"If true, only show the public API: exported symbols, routes and storage. Modules are kept as containers."`
+}
+
+// traceRequestArgs are the arguments for the trace_request tool.
+type traceRequestArgs struct {
+	Route    string `json:"route" jsonschema:"required,Route path to trace, optionally prefixed with an HTTP method (e.g. 'GET /api/users/:id'). Path parameters match in any syntax (:id, {id})."`
+	MaxDepth int    `json:"max_depth,omitempty" jsonschema:"Maximum call hops to follow from the handler. Default 3, max 6."`
+	MaxNodes int    `json:"max_nodes,omitempty" jsonschema:"Maximum symbols in the call chain. Default 30, max 200."`
 }
 
 // traverseArgs are the arguments for the traverse tool.
 type traverseArgs struct {
 	Start         string   `json:"start" jsonschema:"required,Starting node name (fact name, module name, or symbol name). Substring match."`
 	Direction     string   `json:"direction,omitempty" jsonschema:"'forward' follows outgoing relations (what does X depend on?), 'reverse' follows incoming relations (what depends on X?). Default: forward."`
-	RelationKinds []string `json:"relation_kinds,omitempty" jsonschema:"Filter to specific relation types: imports, calls, declares, implements, depends_on. Default: all."`
+	RelationKinds []string `json:"relation_kinds,omitempty" jsonschema:"Filter to specific relation types: imports, calls, declares, implements, depends_on, embeds, overrides, tested_by, publishes, consumed_by. Default: all."`
 	MaxDepth      int      `json:"max_depth,omitempty" jsonschema:"Maximum traversal depth (1-20). Default: 5."`
 	MaxNodes      int      `json:"max_nodes,omitempty" jsonschema:"Maximum nodes to return (1-500). Traversal stops when this limit is reached. Default: 100."`
-	NodeKinds     []string `json:"node_kinds,omitempty" jsonschema:"Filter results to specific fact kinds: module, symbol, dependency, route, storage. Default: all."`
+	NodeKinds     []string `json:"node_kinds,omitempty" jsonschema:"Filter results to specific fact kinds: module, symbol, dependency, route, storage, config. Default: all."`
+	SortBy        string   `json:"sort_by,omitempty" jsonschema:"Order of the returned nodes: 'depth' (depth, then name) or 'name'. Default: depth."`
+	Offset        int      `json:"offset,omitempty" jsonschema:"Number of sorted nodes to skip, for paging."`
+	Limit         int      `json:"limit,omitempty" jsonschema:"Maximum nodes in this page. Default: all collected nodes (bounded by max_nodes)."`
+	ExportedOnly  bool     `json:"exported_only,omitempty" jsonschema:"If true, only return public nodes: exported symbols, routes and storage. Other nodes are still traversed through."`
 }
 
 // findPathArgs are the arguments for the find_path tool.
@@ -688,12 +2247,435 @@ type findPathArgs struct {
 	MaxDepth      int      `json:"max_depth,omitempty" jsonschema:"Maximum path length to search (1-20). Default: 10."`
 }
 
+// hierarchyArgs are the arguments for the hierarchy tool.
+type hierarchyArgs struct {
+	Type     string `json:"type" jsonschema:"required,Class, interface, struct or protocol name (substring match)."`
+	MaxDepth int    `json:"max_depth,omitempty" jsonschema:"Maximum levels of supertypes and of subtypes (1-20). Default: 10."`
+}
+
+// subgraphArgs are the arguments for the subgraph tool.
+type subgraphArgs struct {
+	Focus    string `json:"focus" jsonschema:"required,Node at the center of the diagram (fact name, module name, or symbol name). Substring match."`
+	Depth    int    `json:"depth,omitempty" jsonschema:"Hops to walk from the focus in each direction (1-20). Default: 2."`
+	Format   string `json:"format,omitempty" jsonschema:"Output format: 'dot' (Graphviz), 'mermaid' (flowchart) or 'json' (json_graph nodes/edges document). Default: mermaid."`
+	MaxNodes int    `json:"max_nodes,omitempty" jsonschema:"Maximum nodes reached in each direction (1-500). Default: 100."`
+}
+
 // impactAnalysisArgs are the arguments for the impact_analysis tool.
 type impactAnalysisArgs struct {
 	Target         string `json:"target" jsonschema:"required,The node being changed (fact name, substring match)."`
 	MaxDepth       int    `json:"max_depth,omitempty" jsonschema:"How many hops of impact to compute (1-10). Default: 3."`
 	MaxNodes       int    `json:"max_nodes,omitempty" jsonschema:"Maximum impacted nodes to return (1-500). Default: 200."`
 	IncludeForward bool   `json:"include_forward,omitempty" jsonschema:"Include what the target depends on (what might break the target). Default: false."`
+	SortBy         string `json:"sort_by,omitempty" jsonschema:"Order of the impacted nodes: 'depth' (depth, then name) or 'name'. Default: depth."`
+	Offset         int    `json:"offset,omitempty" jsonschema:"Number of sorted impacted nodes to skip, for paging."`
+	Limit          int    `json:"limit,omitempty" jsonschema:"Maximum impacted nodes in this page. Default: all collected nodes (bounded by max_nodes)."`
+	OutputMode     string `json:"output_mode,omitempty" jsonschema:"Output format: 'full' (default JSON with every impacted node) or 'summary' (markdown: totals per depth and node kind, and the most affected modules)."`
+}
+
+// renamePreviewArgs are the arguments for the rename_preview tool.
+type renamePreviewArgs struct {
+	Name string `json:"name" jsonschema:"required,Symbol to rename (fact name, substring match)."`
+}
+
+// renamePreviewResult is the output of the rename_preview tool.
+type renamePreviewResult struct {
+	Name        string           `json:"name"`
+	Declaration *referenceSite   `json:"declaration,omitempty"`
+	Total       int              `json:"total"`
+	Files       []fileReferences `json:"files"`
+}
+
+// fileReferences are the references to a symbol from one file, by line.
+type fileReferences struct {
+	File       string          `json:"file"`
+	References []referenceSite `json:"references"`
+}
+
+// referenceSite is a fact that references the renamed symbol, or its
+// declaration.
+type referenceSite struct {
+	Name      string   `json:"name"`
+	Kind      string   `json:"kind"`
+	File      string   `json:"file,omitempty"`
+	Line      int      `json:"line,omitempty"`
+	Relations []string `json:"relations,omitempty"` // kinds of the referencing relations
+	Match     string   `json:"match,omitempty"`     // "exact", or "short" for an unqualified or package-qualified target
+}
+
+// outlineArgs are the arguments for the outline tool.
+type outlineArgs struct {
+	File string `json:"file" jsonschema:"required,File path relative to the repo root (absolute paths are normalized)."`
+}
+
+// outlineResult is the output of the outline tool.
+type outlineResult struct {
+	File    string         `json:"file"`
+	Symbols []*outlineNode `json:"symbols"`
+}
+
+// outlineNode is one symbol of a file outline.
+type outlineNode struct {
+	Name      string         `json:"name"`      // name relative to the parent, or to the package at the top level
+	Qualified string         `json:"qualified"` // fact name, for show_symbol and the graph tools
+	Kind      string         `json:"kind"`      // symbol_kind
+	Line      int            `json:"line"`
+	Exported  bool           `json:"exported"`
+	Signature string         `json:"signature,omitempty"`
+	Children  []*outlineNode `json:"children,omitempty"`
+}
+
+// centralNodesArgs are the arguments for the central_nodes tool.
+type centralNodesArgs struct {
+	NodeKinds     []string `json:"node_kinds,omitempty" jsonschema:"Fact kinds to rank (module, symbol, ...). Default: [module]."`
+	RelationKinds []string `json:"relation_kinds,omitempty" jsonschema:"Relation types to treat as edges. Default: all."`
+	Limit         int      `json:"limit,omitempty" jsonschema:"Number of nodes to return (1-200). Default: 20."`
+}
+
+// relatedFilesArgs are the arguments for the related_files tool.
+type relatedFilesArgs struct {
+	File          string   `json:"file" jsonschema:"File path to find coupled files for (e.g. internal/server/server.go)"`
+	RelationKinds []string `json:"relation_kinds,omitempty" jsonschema:"Relation types to follow (e.g. ['calls']). Default: all."`
+	Limit         int      `json:"limit,omitempty" jsonschema:"Number of files to return (1-200). Default: 20."`
+}
+
+// showConfigArgs are the arguments for the show_config tool.
+type showConfigArgs struct{}
+
+// setExtractorsArgs are the arguments for the set_extractors tool.
+type setExtractorsArgs struct {
+	Enabled []string `json:"enabled" jsonschema:"required,Extractors to run on the next generate_snapshot, by name (go, kotlin, openapi, python, typescript, swift, ruby, sql, scala, elixir, docker, cpp, proto)."`
+}
+
+// setExtractorsResult is the response of the set_extractors tool.
+type setExtractorsResult struct {
+	Enabled    []string `json:"enabled"`
+	Registered []string `json:"registered"`
+}
+
+// explainIgnoreArgs are the arguments for the explain_ignore tool.
+type explainIgnoreArgs struct {
+	Path string `json:"path" jsonschema:"File or directory to check, relative to the repository root (absolute paths inside the repo are accepted)"`
+	Repo string `json:"repo,omitempty" jsonschema:"Repository label to check against (multi-repo mode only); default: the snapshot's repository"`
+}
+
+// validateArgs are the arguments for the validate tool.
+type validateArgs struct {
+	Limit int `json:"limit,omitempty" jsonschema:"Maximum samples listed per check (1-200). Default: 20."`
+}
+
+// configResponse is the response for the show_config tool.
+type configResponse struct {
+	*config.Config
+	UsingDefaults bool                  `json:"using_defaults"`
+	Frameworks    []facts.FrameworkInfo `json:"frameworks,omitempty"` // detected in the last snapshot
+}
+
+// serverInfoArgs are the arguments for the server_info tool.
+type serverInfoArgs struct{}
+
+// serverInfoResponse is the response for the server_info tool.
+type serverInfoResponse struct {
+	Version        string `json:"version"`
+	MCPSDKVersion  string `json:"mcp_sdk_version"`
+	UptimeSeconds  int64  `json:"uptime_seconds"`
+	Uptime         string `json:"uptime"`
+	SnapshotLoaded bool   `json:"snapshot_loaded"`
+	FactCount      int    `json:"fact_count"`
+	RepoPath       string `json:"repo_path,omitempty"`
+	GeneratedAt    string `json:"generated_at,omitempty"`
+}
+
+// serverInfo collects the server_info response.
+func (s *Server) serverInfo() serverInfoResponse {
+	uptime := time.Since(s.started)
+	info := serverInfoResponse{
+		Version:       Version,
+		MCPSDKVersion: moduleVersion(mcpSDKModule),
+		UptimeSeconds: int64(uptime.Seconds()),
+		Uptime:        uptime.Round(time.Second).String(),
+		FactCount:     s.eng.Store().Count(),
+	}
+	if snap := s.eng.Snapshot(); snap != nil {
+		info.SnapshotLoaded = true
+		info.RepoPath = snap.Meta.RepoPath
+		info.GeneratedAt = snap.Meta.GeneratedAt
+	}
+	return info
+}
+
+// moduleVersion returns the version of the dependency module path compiled
+// into the binary, or "unknown" when build info is unavailable.
+func moduleVersion(path string) string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, dep := range bi.Deps {
+		if dep.Path == path {
+			if dep.Replace != nil {
+				dep = dep.Replace
+			}
+			return dep.Version
+		}
+	}
+	return "unknown"
+}
+
+// metricsArgs are the arguments for the metrics tool.
+type metricsArgs struct {
+	Repo string `json:"repo,omitempty" jsonschema:"Scope metrics to a single repository label (multi-repo mode only)"`
+}
+
+// compareModulesArgs are the arguments for the compare_modules tool.
+type compareModulesArgs struct {
+	A      string `json:"a" jsonschema:"required,First module name (e.g. internal/server)"`
+	B      string `json:"b" jsonschema:"required,Second module name (e.g. internal/engine)"`
+	Format string `json:"format,omitempty" jsonschema:"Output format: 'json' or 'table' (a markdown table with a column per module). Default: json."`
+}
+
+// compareModulesResult is the output of the compare_modules tool: one row
+// per module, in argument order.
+type compareModulesResult struct {
+	Modules []facts.ModuleMetrics `json:"modules"`
+}
+
+// formatModuleComparison renders module metrics as a markdown table with a
+// row per metric and a column per module, plus the difference of the last
+// module from the first.
+func formatModuleComparison(modules []facts.ModuleMetrics) string {
+	var sb strings.Builder
+	sb.WriteString("| Metric |")
+	for _, m := range modules {
+		sb.WriteString(" " + m.Module + " |")
+	}
+	sb.WriteString(" Difference |\n|--------|")
+	for range modules {
+		sb.WriteString("------|")
+	}
+	sb.WriteString("------------|\n")
+
+	row := func(label string, value func(facts.ModuleMetrics) int) {
+		sb.WriteString("| " + label + " |")
+		for _, m := range modules {
+			sb.WriteString(fmt.Sprintf(" %d |", value(m)))
+		}
+		sb.WriteString(fmt.Sprintf(" %+d |\n", value(modules[len(modules)-1])-value(modules[0])))
+	}
+	row("Files", func(m facts.ModuleMetrics) int { return m.Files })
+	row("Symbols", func(m facts.ModuleMetrics) int { return m.Symbols })
+	row("Exported", func(m facts.ModuleMetrics) int { return m.Exported })
+	row("Fan-in", func(m facts.ModuleMetrics) int { return m.FanIn })
+	row("Fan-out", func(m facts.ModuleMetrics) int { return m.FanOut })
+
+	sb.WriteString("| In cycle |")
+	for _, m := range modules {
+		if m.InCycle {
+			sb.WriteString(" yes |")
+		} else {
+			sb.WriteString(" no |")
+		}
+	}
+	sb.WriteString(" |\n")
+	return sb.String()
+}
+
+// nodeInfoArgs are the arguments for the node_info tool.
+type nodeInfoArgs struct {
+	Name string `json:"name" jsonschema:"Exact fact name (e.g. internal/server.New)"`
+	Kind string `json:"kind,omitempty" jsonschema:"Fact kind to pick when several facts share the name (module, symbol, route, storage, dependency, config)"`
+	Repo string `json:"repo,omitempty" jsonschema:"Repository label to pick when several facts share the name (multi-repo mode only)"`
+}
+
+// architectureSummaryArgs are the arguments for the architecture_summary tool.
+type architectureSummaryArgs struct {
+	MaxTokens int `json:"max_tokens,omitempty" jsonschema:"Token budget for the summary (1000-200000). Default: output.max_context_tokens from the config."`
+}
+
+// saveSnapshotArgs are the arguments for the save_snapshot tool.
+type saveSnapshotArgs struct {
+	Name string `json:"name" jsonschema:"required,Name to save the snapshot under, e.g. 'main' or 'feature-login'. Letters, digits, '.', '-' and '_'."`
+}
+
+// loadSnapshotArgs are the arguments for the load_snapshot tool.
+type loadSnapshotArgs struct {
+	Name string `json:"name" jsonschema:"required,Name of a snapshot saved with save_snapshot."`
+}
+
+// grepSourceArgs are the arguments for the grep_source tool.
+type grepSourceArgs struct {
+	Pattern    string `json:"pattern" jsonschema:"Regular expression (RE2 syntax) to search for, e.g. 'TODO|FIXME' or '(?i)retry'"`
+	FilePrefix string `json:"file_prefix,omitempty" jsonschema:"Only search files under this path prefix (e.g. internal/server/)"`
+	Repo       string `json:"repo,omitempty" jsonschema:"Only search files of this repository label (multi-repo mode only)"`
+	MaxResults int    `json:"max_results,omitempty" jsonschema:"Maximum number of matching lines to return (1-1000). Default 100."`
+}
+
+// suggestNamesArgs are the arguments for the suggest_names tool.
+type suggestNamesArgs struct {
+	Prefix string `json:"prefix" jsonschema:"Start of the fact name to complete (e.g. internal/server.Ha)"`
+	Limit  int    `json:"limit,omitempty" jsonschema:"Maximum number of names to return (1-200). Default 20."`
+}
+
+// nameSuggestion is one completion returned by suggest_names.
+type nameSuggestion struct {
+	Name  string   `json:"name"`
+	Kinds []string `json:"kinds"`
+}
+
+// suggestNamesResult is the response for the suggest_names tool.
+type suggestNamesResult struct {
+	Prefix      string           `json:"prefix"`
+	Suggestions []nameSuggestion `json:"suggestions"`
+	HasMore     bool             `json:"has_more"`
+}
+
+// suggestNames completes prefix against the store's prefix index, listing
+// the kinds of the facts behind each name.
+func suggestNames(store *facts.Store, prefix string, limit int) suggestNamesResult {
+	names := store.NamesByPrefix(prefix, limit+1)
+	result := suggestNamesResult{Prefix: prefix, Suggestions: []nameSuggestion{}}
+	if len(names) > limit {
+		names = names[:limit]
+		result.HasMore = true
+	}
+	for _, name := range names {
+		sugg := nameSuggestion{Name: name}
+		seen := make(map[string]bool)
+		for _, f := range store.LookupByExactName(name) {
+			if !seen[f.Kind] {
+				seen[f.Kind] = true
+				sugg.Kinds = append(sugg.Kinds, f.Kind)
+			}
+		}
+		result.Suggestions = append(result.Suggestions, sugg)
+	}
+	return result
+}
+
+// nodeInfoResult is the response for the node_info tool.
+type nodeInfoResult struct {
+	facts.Fact
+	EdgesIn        int            `json:"edges_in"`
+	EdgesOut       int            `json:"edges_out"`
+	EdgesInByKind  map[string]int `json:"edges_in_by_kind,omitempty"`
+	EdgesOutByKind map[string]int `json:"edges_out_by_kind,omitempty"`
+}
+
+// maxNodeCandidates caps the candidate names listed in node_info errors.
+const maxNodeCandidates = 10
+
+// nodeInfo resolves name to exactly one fact, optionally narrowed by kind and
+// repo, and attaches its graph edge counts. Ambiguous or missing names return
+// an error listing candidates.
+func nodeInfo(store *facts.Store, name, kind, repo string) (*nodeInfoResult, error) {
+	exact := store.LookupByExactName(name)
+	var matched []facts.Fact
+	for _, f := range exact {
+		if (kind == "" || f.Kind == kind) && (repo == "" || f.Repo == repo) {
+			matched = append(matched, f)
+		}
+	}
+
+	switch {
+	case len(matched) == 0 && len(exact) > 0:
+		return nil, newToolError(codeNotFound, "no %s matching %q; facts with that name:\n%s",
+			describeFilter(kind, repo), name, formatNodeCandidates(exact))
+	case len(matched) == 0:
+		similar := store.Query(kind, "", name, "")
+		if len(similar) == 0 {
+			return nil, newToolError(codeNotFound, "no facts named %q", name)
+		}
+		return nil, newToolError(codeNotFound, "no facts named %q; similar names:\n%s", name, formatNodeCandidates(similar))
+	case len(matched) > 1:
+		return nil, newToolError(codeAmbiguous, "%q is ambiguous (%d facts); pass kind or repo to pick one:\n%s",
+			name, len(matched), formatNodeCandidates(matched))
+	}
+
+	result := &nodeInfoResult{Fact: matched[0]}
+	if g := store.Graph(); g != nil {
+		result.EdgesOut, result.EdgesOutByKind = countEdges(g.OutEdges(name))
+		result.EdgesIn, result.EdgesInByKind = countEdges(g.InEdges(name))
+	}
+	return result, nil
+}
+
+// countEdges totals edges overall and per relation kind.
+func countEdges(edges []facts.Edge) (int, map[string]int) {
+	if len(edges) == 0 {
+		return 0, nil
+	}
+	byKind := make(map[string]int)
+	for _, e := range edges {
+		byKind[e.RelKind]++
+	}
+	return len(edges), byKind
+}
+
+// describeFilter names the kind/repo filter used in node_info errors.
+func describeFilter(kind, repo string) string {
+	desc := "facts"
+	if kind != "" {
+		desc = kind + " facts"
+	}
+	if repo != "" {
+		desc += " in repo " + repo
+	}
+	return desc
+}
+
+// formatNodeCandidates lists up to maxNodeCandidates facts, one per line.
+func formatNodeCandidates(ff []facts.Fact) string {
+	var sb strings.Builder
+	for i, f := range ff {
+		if i == maxNodeCandidates {
+			sb.WriteString(fmt.Sprintf("- ... and %d more\n", len(ff)-maxNodeCandidates))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("- %s (%s", f.Name, f.Kind))
+		if f.File != "" {
+			sb.WriteString(", " + f.File)
+			if f.Line > 0 {
+				sb.WriteString(fmt.Sprintf(":%d", f.Line))
+			}
+		}
+		if f.Repo != "" {
+			sb.WriteString(", repo " + f.Repo)
+		}
+		sb.WriteString(")\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// maxSummaryErrors caps how many extraction errors generate_snapshot lists;
+// the full list is in the snapshot meta.
+const maxSummaryErrors = 10
+
+// formatExtractErrors lists the first extraction errors, one per line.
+func formatExtractErrors(errs []facts.ExtractError) string {
+	var sb strings.Builder
+	sb.WriteString("**Extraction errors** (facts from these files are missing):\n")
+	for i, e := range errs {
+		if i == maxSummaryErrors {
+			sb.WriteString(fmt.Sprintf("- ... and %d more (see arch://snapshot/meta)\n", len(errs)-maxSummaryErrors))
+			break
+		}
+		file := e.File
+		if file == "" {
+			file = "(extractor failed)"
+		}
+		sb.WriteString(fmt.Sprintf("- [%s] %s: %s\n", e.Extractor, file, e.Message))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// formatExtractorStats lists the time each extractor took, in run order.
+func formatExtractorStats(stats []facts.ExtractorStat) string {
+	var sb strings.Builder
+	sb.WriteString("**Extractor timings:**\n")
+	for _, st := range stats {
+		sb.WriteString(fmt.Sprintf("- %s: %s (%d facts from %d files)\n", st.Name, st.Duration, st.Facts, st.Files))
+	}
+	return strings.TrimRight(sb.String(), "\n")
 }
 
 // exploreModule renders a module exploration if the focus matches a module name.
@@ -720,10 +2702,30 @@ func (s *Server) exploreModule(store *facts.Store, focus string, depth int, sb *
 	if pkg, ok := mod.Props["package"].(string); ok {
 		sb.WriteString(fmt.Sprintf("- Package: %s\n", pkg))
 	}
+	if doc, ok := mod.Props["doc"].(string); ok && doc != "" {
+		sb.WriteString(fmt.Sprintf("- Doc: %s\n", doc))
+	}
 	sb.WriteString("\n")
 
 	// Find symbols declared in this module (symbols whose "declares" relation targets this module)
 	declaredSymbols := store.ReverseLookup(mod.Name, facts.RelDeclares)
+
+	// Metrics: same fan-in/fan-out computation as the llm_context Critical Modules section.
+	coupling := facts.ModuleCoupling(append(store.ByKind(facts.KindModule), store.ByKind(facts.KindDependency)...))[mod.Name]
+	exportedCount := 0
+	for _, sym := range declaredSymbols {
+		if exp, ok := sym.Props["exported"].(bool); ok && exp {
+			exportedCount++
+		}
+	}
+	sb.WriteString("## Metrics\n\n")
+	sb.WriteString(fmt.Sprintf("- Fan-in: %d\n", coupling.FanIn))
+	sb.WriteString(fmt.Sprintf("- Fan-out: %d\n", coupling.FanOut))
+	sb.WriteString(fmt.Sprintf("- Symbols: %d\n", len(declaredSymbols)))
+	if len(declaredSymbols) > 0 {
+		sb.WriteString(fmt.Sprintf("- Exported: %d (%.0f%%)\n", exportedCount, float64(exportedCount)*100/float64(len(declaredSymbols))))
+	}
+	sb.WriteString("\n")
 	if len(declaredSymbols) > 0 {
 		sb.WriteString(fmt.Sprintf("## Symbols (%d)\n\n", len(declaredSymbols)))
 		sb.WriteString("| Name | Kind | File | Line | Exported |\n")
@@ -843,13 +2845,218 @@ func (s *Server) exploreModuleSubstring(store *facts.Store, focus string, depth
 	for _, m := range matches {
 		sb.WriteString(fmt.Sprintf("- `%s` (%s)\n", m.Name, m.File))
 	}
-	sb.WriteString("\nUse the full module name for detailed exploration.\n")
-	return true
+	sb.WriteString("\nUse the full module name for detailed exploration.\n")
+	return true
+}
+
+// inNamespace reports whether a symbol name is qualified under namespace
+// ns: ns itself, a constant nested with ::, or a method with # or . (Ruby
+// "Admin::Billing#charge", "Admin::Billing.build").
+func inNamespace(name, ns string) bool {
+	if !strings.HasPrefix(name, ns) {
+		return false
+	}
+	rest := name[len(ns):]
+	return rest == "" || strings.HasPrefix(rest, "::") || strings.HasPrefix(rest, "#") || strings.HasPrefix(rest, ".")
+}
+
+// namespaceOf returns the namespace a symbol name belongs to: its name up
+// to the last ::, after dropping a #method or .method suffix. Names without
+// :: are their own namespace.
+func namespaceOf(name string) string {
+	if i := strings.Index(name, "#"); i >= 0 {
+		name = name[:i]
+	}
+	i := strings.LastIndex(name, "::")
+	if i < 0 {
+		return name
+	}
+	if j := strings.Index(name[i:], "."); j >= 0 {
+		name = name[:i+j]
+	}
+	return name[:strings.LastIndex(name, "::")]
+}
+
+// namespaceRefs tallies the references between a namespace and one other
+// namespace for exploreNamespace.
+type namespaceRefs struct {
+	namespace string
+	count     int
+	kinds     map[string]bool
+	names     []string
+}
+
+// exploreNamespace renders a namespace focus such as "Admin::Billing", or
+// "Admin::" for a top-level namespace: the symbols qualified under it
+// whatever directory declares them, the other namespaces they depend on and
+// the namespaces depending on them. A focus with no constant nested under it
+// (a plain class) is left to exploreSymbol.
+func (s *Server) exploreNamespace(store *facts.Store, focus string, depth int, sb *strings.Builder) bool {
+	ns := strings.TrimSuffix(focus, "::")
+	if ns == "" {
+		return false
+	}
+	var members []facts.Fact
+	nested := false
+	for _, f := range store.LookupByPrefix(ns, 0) {
+		if f.Kind != facts.KindSymbol || !inNamespace(f.Name, ns) {
+			continue
+		}
+		members = append(members, f)
+		nested = nested || strings.HasPrefix(f.Name, ns+"::")
+	}
+	if !nested {
+		return false
+	}
+	inside := make(map[string]bool, len(members))
+	for _, m := range members {
+		inside[m.Name] = true
+	}
+
+	dirs := make(map[string]int)
+	files := make(map[string]struct{})
+	exportedCount := 0
+	for _, m := range members {
+		dirs[path.Dir(m.File)]++
+		files[m.File] = struct{}{}
+		if exp, ok := m.Props["exported"].(bool); ok && exp {
+			exportedCount++
+		}
+	}
+	sortedDirs := make([]string, 0, len(dirs))
+	for d := range dirs {
+		sortedDirs = append(sortedDirs, d)
+	}
+	sort.Strings(sortedDirs)
+
+	sb.WriteString(fmt.Sprintf("# Namespace: %s\n\n", ns))
+	sb.WriteString(fmt.Sprintf("- Symbols: %d\n", len(members)))
+	if exportedCount > 0 {
+		sb.WriteString(fmt.Sprintf("- Exported: %d (%.0f%%)\n", exportedCount, float64(exportedCount)*100/float64(len(members))))
+	}
+	sb.WriteString(fmt.Sprintf("- Files: %d\n", len(files)))
+	sb.WriteString(fmt.Sprintf("- Directories: %d\n\n", len(dirs)))
+
+	sb.WriteString(fmt.Sprintf("## Directories (%d)\n\n", len(dirs)))
+	for _, d := range sortedDirs {
+		sb.WriteString(fmt.Sprintf("- %s (%d symbols)\n", d, dirs[d]))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(fmt.Sprintf("## Symbols (%d)\n\n", len(members)))
+	sb.WriteString("| Name | Kind | File | Line |\n")
+	sb.WriteString("|------|------|------|------|\n")
+	limit := min(len(members), 50)
+	for _, m := range members[:limit] {
+		symKind, _ := m.Props["symbol_kind"].(string)
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %d |\n", m.Name, symKind, m.File, m.Line))
+	}
+	if len(members) > limit {
+		sb.WriteString(fmt.Sprintf("\n... and %d more symbols\n", len(members)-limit))
+	}
+	sb.WriteString("\n")
+
+	// Cross-namespace references, grouped by the namespace on the other end.
+	tally := func(byNS map[string]*namespaceRefs, other, kind, name string) {
+		refs := byNS[other]
+		if refs == nil {
+			refs = &namespaceRefs{namespace: other, kinds: make(map[string]bool)}
+			byNS[other] = refs
+		}
+		refs.count++
+		refs.kinds[kind] = true
+		if !slices.Contains(refs.names, name) {
+			refs.names = append(refs.names, name)
+		}
+	}
+	deps := make(map[string]*namespaceRefs)
+	dependents := make(map[string]*namespaceRefs)
+	for _, m := range members {
+		for _, r := range m.Relations {
+			if r.Kind == facts.RelDeclares || inside[r.Target] || inNamespace(r.Target, ns) {
+				continue
+			}
+			tally(deps, namespaceOf(r.Target), r.Kind, r.Target)
+		}
+		for _, src := range store.ReverseLookup(m.Name, "") {
+			if src.Kind != facts.KindSymbol || inNamespace(src.Name, ns) {
+				continue
+			}
+			for _, r := range src.Relations {
+				if r.Target == m.Name && r.Kind != facts.RelDeclares {
+					tally(dependents, namespaceOf(src.Name), r.Kind, src.Name)
+				}
+			}
+		}
+	}
+	writeNamespaceRefs(sb, "Dependencies", "Targets", deps)
+	writeNamespaceRefs(sb, "Dependents", "From", dependents)
+
+	// If depth=2, show the outgoing relations of the members
+	if depth >= 2 {
+		sb.WriteString("## Symbol Relations\n\n")
+		shown := 0
+		for _, m := range members {
+			if shown == 20 {
+				break
+			}
+			if len(m.Relations) <= 1 {
+				continue // skip symbols with only a "declares" relation
+			}
+			shown++
+			sb.WriteString(fmt.Sprintf("**%s**\n", m.Name))
+			for _, r := range m.Relations {
+				if r.Kind == facts.RelDeclares {
+					continue
+				}
+				sb.WriteString(fmt.Sprintf("  - %s → %s\n", r.Kind, r.Target))
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return true
+}
+
+// writeNamespaceRefs writes a table of the namespaces referenced from or
+// referencing a namespace, most references first.
+func writeNamespaceRefs(sb *strings.Builder, title, namesHeader string, byNS map[string]*namespaceRefs) {
+	if len(byNS) == 0 {
+		return
+	}
+	rows := make([]*namespaceRefs, 0, len(byNS))
+	for _, refs := range byNS {
+		rows = append(rows, refs)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].count != rows[j].count {
+			return rows[i].count > rows[j].count
+		}
+		return rows[i].namespace < rows[j].namespace
+	})
+	sb.WriteString(fmt.Sprintf("## %s (%d namespaces)\n\n", title, len(rows)))
+	sb.WriteString(fmt.Sprintf("| Namespace | References | Relations | %s |\n", namesHeader))
+	sb.WriteString("|-----------|------------|-----------|" + strings.Repeat("-", len(namesHeader)+2) + "|\n")
+	for _, refs := range rows {
+		kinds := make([]string, 0, len(refs.kinds))
+		for k := range refs.kinds {
+			kinds = append(kinds, k)
+		}
+		sort.Strings(kinds)
+		names := refs.names
+		more := ""
+		if len(names) > 5 {
+			more = fmt.Sprintf(", +%d more", len(names)-5)
+			names = names[:5]
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %d | %s | %s%s |\n", refs.namespace, refs.count, strings.Join(kinds, ", "), strings.Join(names, ", "), more))
+	}
+	sb.WriteString("\n")
 }
 
-// exploreFile renders a file exploration if the focus matches an exact file path.
-// In multi-repo mode, it also tries repo-label prefixed paths and common extensions.
-func (s *Server) exploreFile(store *facts.Store, focus string, depth int, sb *strings.Builder) bool {
+// lookupFile returns the facts of file and the path they are stored under.
+// Like explore, it also tries the path under each repo label in multi-repo
+// mode and with common source extensions appended. No facts means no match.
+func (s *Server) lookupFile(store *facts.Store, focus string) (string, []facts.Fact) {
 	fileFacts := store.ByFile(focus)
 
 	// In multi-repo mode, try repo-label prefixed paths.
@@ -882,6 +3089,13 @@ func (s *Server) exploreFile(store *facts.Store, focus string, depth int, sb *st
 		}
 	}
 
+	return focus, fileFacts
+}
+
+// exploreFile renders a file exploration if the focus matches an exact file path.
+// In multi-repo mode, it also tries repo-label prefixed paths and common extensions.
+func (s *Server) exploreFile(store *facts.Store, focus string, depth int, sb *strings.Builder) bool {
+	focus, fileFacts := s.lookupFile(store, focus)
 	if len(fileFacts) == 0 {
 		return false
 	}
@@ -895,7 +3109,7 @@ func (s *Server) exploreFile(store *facts.Store, focus string, depth int, sb *st
 		byKind[f.Kind] = append(byKind[f.Kind], f)
 	}
 
-	for _, kind := range []string{facts.KindModule, facts.KindSymbol, facts.KindDependency, facts.KindRoute, facts.KindStorage} {
+	for _, kind := range []string{facts.KindModule, facts.KindSymbol, facts.KindDependency, facts.KindRoute, facts.KindStorage, facts.KindConfig} {
 		ff := byKind[kind]
 		if len(ff) == 0 {
 			continue
@@ -922,9 +3136,512 @@ func (s *Server) exploreFile(store *facts.Store, focus string, depth int, sb *st
 	return true
 }
 
-// exploreSymbol renders a symbol exploration if the focus matches symbol names via substring.
+// routeParamRe matches path parameters in the styles used by the route
+// extractors: ":id", "{id}", "[id]", "[...slug]", and "<int:id>".
+var routeParamRe = regexp.MustCompile(`:[A-Za-z_][\w]*|\{[^}/]*\}|\[[^\]/]*\]|<[^>/]*>`)
+
+// normalizeRoutePath rewrites every path parameter to "{}" and drops a
+// trailing slash so "/users/:id" and "/users/{id}/" compare equal.
+func normalizeRoutePath(p string) string {
+	p = routeParamRe.ReplaceAllString(p, "{}")
+	if len(p) > 1 {
+		p = strings.TrimSuffix(p, "/")
+	}
+	return p
+}
+
+// routeMethodAndPath returns the HTTP method and path of a route fact,
+// accounting for extractors that store them under different props.
+func routeMethodAndPath(f facts.Fact) (string, string) {
+	method, _ := f.Props["method"].(string)
+	if method == "" {
+		method, _ = f.Props["http_method"].(string)
+	}
+	path, _ := f.Props["path"].(string)
+	if path == "" {
+		path = f.Name
+		if method != "" {
+			path = strings.TrimPrefix(path, method+" ")
+		}
+	}
+	return strings.ToUpper(method), path
+}
+
+// exploreRoute renders an endpoint-centric view if the focus is a route path,
+// optionally prefixed by an HTTP method ("GET /api/users/:id"). Each matching
+// route shows its handler, the call chain reached from it, and any storage
+// touched along the way.
+func (s *Server) exploreRoute(store *facts.Store, focus string, sb *strings.Builder) bool {
+	routes := matchRoutes(store, focus)
+	if len(routes) == 0 {
+		return false
+	}
+
+	sb.WriteString(fmt.Sprintf("# Route: %s\n\n", strings.TrimSpace(focus)))
+	if len(routes) > 10 {
+		sb.WriteString(fmt.Sprintf("Showing 10 of %d matching routes.\n\n", len(routes)))
+		routes = routes[:10]
+	}
+
+	for i, r := range routes {
+		if i > 0 {
+			sb.WriteString("---\n\n")
+		}
+		method, path := routeMethodAndPath(r)
+		sb.WriteString(fmt.Sprintf("## %s\n\n", strings.TrimSpace(method+" "+path)))
+		if r.Line > 0 {
+			sb.WriteString(fmt.Sprintf("- Source: %s:%d\n", r.File, r.Line))
+		} else {
+			sb.WriteString(fmt.Sprintf("- Source: %s\n", r.File))
+		}
+		if fw, ok := r.Props["framework"].(string); ok {
+			sb.WriteString(fmt.Sprintf("- Framework: %s\n", fw))
+		}
+		if role, ok := r.Props["role"].(string); ok {
+			sb.WriteString(fmt.Sprintf("- Role: %s\n", role))
+		}
+
+		handlers := resolveRouteHandlers(store, r)
+		if h, ok := r.Props["handler"].(string); ok && h != "" {
+			sb.WriteString(fmt.Sprintf("- Handler: %s\n", h))
+		}
+		sb.WriteString("\n")
+
+		if len(handlers) == 0 {
+			sb.WriteString("No handler symbol could be resolved for this route.\n\n")
+			continue
+		}
+
+		chain, storage := routeCallChain(store, handlers, 3, 30)
+		sb.WriteString("### Call Chain\n\n")
+		for _, step := range chain {
+			sb.WriteString(fmt.Sprintf("%s- **%s** — %s", strings.Repeat("  ", step.depth), step.fact.Name, step.fact.File))
+			if step.fact.Line > 0 {
+				sb.WriteString(fmt.Sprintf(":%d", step.fact.Line))
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+
+		if len(storage) > 0 {
+			sb.WriteString("### Storage\n\n")
+			for _, st := range storage {
+				sk, _ := st.Props["storage_kind"].(string)
+				sb.WriteString(fmt.Sprintf("- **%s** (%s) — %s", st.Name, sk, st.File))
+				if st.Line > 0 {
+					sb.WriteString(fmt.Sprintf(":%d", st.Line))
+				}
+				sb.WriteString("\n")
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	return true
+}
+
+// matchRoutes returns the routes matching a route path focus such as
+// "/api/users/:id" or "GET /api/users/{id}". Path parameters match in any
+// syntax, and a gateway_path prop counts as an alternative path. Exact
+// matches win; routes containing the path are only returned when there are
+// none. A focus that is not a path matches nothing.
+func matchRoutes(store *facts.Store, focus string) []facts.Fact {
+	focusMethod, focusPath := "", strings.TrimSpace(focus)
+	if m, p, ok := strings.Cut(focusPath, " "); ok && strings.HasPrefix(strings.TrimSpace(p), "/") {
+		focusMethod, focusPath = strings.ToUpper(m), strings.TrimSpace(p)
+	}
+	if !strings.HasPrefix(focusPath, "/") {
+		return nil
+	}
+	want := normalizeRoutePath(focusPath)
+
+	var exact, partial []facts.Fact
+	for _, r := range store.ByKind(facts.KindRoute) {
+		method, path := routeMethodAndPath(r)
+		if focusMethod != "" && method != focusMethod {
+			continue
+		}
+		candidates := []string{normalizeRoutePath(path)}
+		if gw, ok := r.Props["gateway_path"].(string); ok {
+			candidates = append(candidates, normalizeRoutePath(gw))
+		}
+		for _, c := range candidates {
+			if c == want {
+				exact = append(exact, r)
+				break
+			}
+			if strings.Contains(c, want) {
+				partial = append(partial, r)
+				break
+			}
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return partial
+}
+
+// resolveRouteHandlers finds the symbols that handle a route: targets of its
+// calls relations first, then the handler prop resolved against symbol names.
+func resolveRouteHandlers(store *facts.Store, route facts.Fact) []facts.Fact {
+	var handlers []facts.Fact
+	for _, rel := range route.Relations {
+		if rel.Kind == facts.RelCalls {
+			handlers = append(handlers, resolveCallTarget(store, rel.Target, route.File)...)
+		}
+	}
+	if len(handlers) > 0 {
+		return handlers
+	}
+	h, _ := route.Props["handler"].(string)
+	if h == "" {
+		return nil
+	}
+	// Rails-style "users#show" handlers live on UsersController.show.
+	if ctrl, action, ok := strings.Cut(h, "#"); ok {
+		if i := strings.LastIndex(ctrl, "/"); i >= 0 {
+			ctrl = ctrl[i+1:]
+		}
+		for _, sym := range store.Query(facts.KindSymbol, "", "."+action, "") {
+			if strings.HasSuffix(sym.Name, "."+action) && strings.Contains(strings.ToLower(sym.Name), strings.ToLower(strings.ReplaceAll(ctrl, "_", ""))) {
+				handlers = append(handlers, sym)
+			}
+		}
+		return handlers
+	}
+	return resolveCallTarget(store, h, route.File)
+}
+
+// resolveCallTarget maps a call target as written in source ("svc.Get",
+// "handler.List", "GetUser") to symbol facts. An exact name wins; otherwise
+// symbols whose name ends with the called function are candidates, preferring
+// those in fromFile's directory. At most three candidates are returned.
+func resolveCallTarget(store *facts.Store, target, fromFile string) []facts.Fact {
+	if exact := store.LookupByExactName(target); len(exact) > 0 {
+		return exact[:1]
+	}
+	callee := target
+	if i := strings.LastIndex(callee, "."); i >= 0 {
+		callee = callee[i+1:]
+	}
+	if callee == "" {
+		return nil
+	}
+
+	var local, other []facts.Fact
+	dir := filepath.ToSlash(filepath.Dir(fromFile))
+	for _, sym := range store.Query(facts.KindSymbol, "", callee, "") {
+		if sym.Name != callee && !strings.HasSuffix(sym.Name, "."+callee) {
+			continue
+		}
+		if filepath.ToSlash(filepath.Dir(sym.File)) == dir {
+			local = append(local, sym)
+		} else {
+			other = append(other, sym)
+		}
+	}
+	matches := append(local, other...)
+	if len(matches) > 3 {
+		matches = matches[:3]
+	}
+	return matches
+}
+
+// chainStep is a symbol reached while following a route's call chain.
+type chainStep struct {
+	fact  facts.Fact
+	depth int
+}
+
+// routeCallChain walks calls relations breadth-first from the handlers up to
+// maxDepth hops and maxNodes symbols. It returns the visited symbols in
+// depth-first display order along with storage facts reached directly by a
+// relation or declared in a file on the chain.
+func routeCallChain(store *facts.Store, handlers []facts.Fact, maxDepth, maxNodes int) ([]chainStep, []facts.Fact) {
+	visited := make(map[string]bool)
+	children := make(map[string][]facts.Fact)
+	var roots []facts.Fact
+	files := make(map[string]bool)
+	storageSeen := make(map[string]bool)
+	var storage []facts.Fact
+
+	addStorage := func(f facts.Fact) {
+		key := f.Name + "\x00" + f.File
+		if !storageSeen[key] {
+			storageSeen[key] = true
+			storage = append(storage, f)
+		}
+	}
+
+	type item struct {
+		fact  facts.Fact
+		depth int
+	}
+	var queue []item
+	for _, h := range handlers {
+		if !visited[h.Name] {
+			visited[h.Name] = true
+			roots = append(roots, h)
+			queue = append(queue, item{h, 0})
+		}
+	}
+	count := len(roots)
+
+	for qi := 0; qi < len(queue); qi++ {
+		cur := queue[qi]
+		if cur.fact.File != "" {
+			files[cur.fact.File] = true
+		}
+		for _, rel := range cur.fact.Relations {
+			switch rel.Kind {
+			case facts.RelDependsOn, facts.RelCalls:
+			default:
+				continue
+			}
+			for _, t := range store.LookupByExactName(rel.Target) {
+				if t.Kind == facts.KindStorage {
+					addStorage(t)
+				}
+			}
+			if rel.Kind != facts.RelCalls || cur.depth >= maxDepth || count >= maxNodes {
+				continue
+			}
+			for _, next := range resolveCallTarget(store, rel.Target, cur.fact.File) {
+				if visited[next.Name] || count >= maxNodes {
+					continue
+				}
+				visited[next.Name] = true
+				count++
+				children[cur.fact.Name] = append(children[cur.fact.Name], next)
+				queue = append(queue, item{next, cur.depth + 1})
+			}
+		}
+	}
+
+	for _, st := range store.ByKind(facts.KindStorage) {
+		if files[st.File] {
+			addStorage(st)
+		}
+	}
+
+	var chain []chainStep
+	var walk func(f facts.Fact, depth int)
+	walk = func(f facts.Fact, depth int) {
+		chain = append(chain, chainStep{f, depth})
+		for _, c := range children[f.Name] {
+			walk(c, depth+1)
+		}
+	}
+	for _, r := range roots {
+		walk(r, 0)
+	}
+	return chain, storage
+}
+
+// traceNode is a line of the trace_request tree with the lines nested
+// under it.
+type traceNode struct {
+	label    string
+	children []*traceNode
+}
+
+// externalHit is a call from a traced symbol into an external dependency.
+type externalHit struct {
+	dependency string // import path
+	call       string // call target as written in source
+	caller     string // calling symbol
+}
+
+// traceRoute renders the downstream trace of one route as a tree: the route,
+// its handlers, the symbols they call up to maxDepth hops and maxNodes
+// symbols, and under each symbol the storage it references and the external
+// dependencies it calls. Summaries of all storage touched (including storage
+// declared in a file on the chain) and of the external dependencies follow.
+func traceRoute(store *facts.Store, route facts.Fact, maxDepth, maxNodes int, sb *strings.Builder) {
+	method, path := routeMethodAndPath(route)
+	root := &traceNode{label: strings.TrimSpace(method+" "+path) + " — " + factLocation(route)}
+	if fw, ok := route.Props["framework"].(string); ok {
+		root.label += " (" + fw + ")"
+	}
+
+	handlers := resolveRouteHandlers(store, route)
+	chain, storage := routeCallChain(store, handlers, maxDepth, maxNodes)
+	externals := newExternalIndex(store)
+	var hits []externalHit
+
+	// chain is in depth-first order, so the parent of a step is the nearest
+	// earlier step one level up.
+	stack := []*traceNode{root}
+	for _, step := range chain {
+		node := &traceNode{label: step.fact.Name + " — " + factLocation(step.fact)}
+		stack = stack[:step.depth+1]
+		parent := stack[step.depth]
+		parent.children = append(parent.children, node)
+		stack = append(stack, node)
+
+		seen := make(map[string]bool)
+		for _, rel := range step.fact.Relations {
+			if rel.Kind != facts.RelDependsOn && rel.Kind != facts.RelCalls {
+				continue
+			}
+			for _, t := range store.LookupByExactName(rel.Target) {
+				if t.Kind == facts.KindStorage && !seen[t.Name] {
+					seen[t.Name] = true
+					sk, _ := t.Props["storage_kind"].(string)
+					node.children = append(node.children, &traceNode{label: fmt.Sprintf("[storage] %s (%s)", t.Name, sk)})
+				}
+			}
+			if rel.Kind != facts.RelCalls {
+				continue
+			}
+			if dep := externals.lookup(step.fact.File, rel.Target); dep != "" && len(resolveCallTarget(store, rel.Target, step.fact.File)) == 0 {
+				hits = append(hits, externalHit{dependency: dep, call: rel.Target, caller: step.fact.Name})
+				node.children = append(node.children, &traceNode{label: fmt.Sprintf("[external] %s (%s)", rel.Target, dep)})
+			}
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("## %s\n\n", strings.TrimSpace(method+" "+path)))
+	if len(handlers) == 0 {
+		sb.WriteString("No handler symbol could be resolved for this route.\n\n")
+	}
+	sb.WriteString("```\n")
+	writeTraceTree(root, "", true, true, sb)
+	sb.WriteString("```\n\n")
+
+	if len(storage) > 0 {
+		sb.WriteString("### Storage\n\n")
+		for _, st := range storage {
+			sk, _ := st.Props["storage_kind"].(string)
+			sb.WriteString(fmt.Sprintf("- **%s** (%s) — %s\n", st.Name, sk, factLocation(st)))
+		}
+		sb.WriteString("\n")
+	}
+	if len(hits) > 0 {
+		sb.WriteString("### External Dependencies\n\n")
+		byDep := make(map[string][]string)
+		var deps []string
+		for _, h := range hits {
+			if _, ok := byDep[h.dependency]; !ok {
+				deps = append(deps, h.dependency)
+			}
+			byDep[h.dependency] = append(byDep[h.dependency], fmt.Sprintf("%s from %s", h.call, h.caller))
+		}
+		for _, dep := range deps {
+			sb.WriteString(fmt.Sprintf("- **%s** — %s\n", dep, strings.Join(byDep[dep], ", ")))
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// writeTraceTree writes node and its descendants with box-drawing branches.
+func writeTraceTree(node *traceNode, indent string, last, isRoot bool, sb *strings.Builder) {
+	switch {
+	case isRoot:
+		sb.WriteString(node.label + "\n")
+	case last:
+		sb.WriteString(indent + "└─ " + node.label + "\n")
+		indent += "   "
+	default:
+		sb.WriteString(indent + "├─ " + node.label + "\n")
+		indent += "│  "
+	}
+	for i, c := range node.children {
+		writeTraceTree(c, indent, i == len(node.children)-1, false, sb)
+	}
+}
+
+// factLocation formats a fact's source position as file:line, or just the
+// file when the line is unknown.
+func factLocation(f facts.Fact) string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s:%d", f.File, f.Line)
+	}
+	return f.File
+}
+
+// externalIndex maps, per file, the package qualifiers of the file's
+// external imports to their import paths. It is filled lazily.
+type externalIndex struct {
+	store  *facts.Store
+	byFile map[string]map[string]string
+}
+
+func newExternalIndex(store *facts.Store) *externalIndex {
+	return &externalIndex{store: store, byFile: make(map[string]map[string]string)}
+}
+
+// lookup returns the external import path a call target made from file goes
+// through, matching the target's first segment ("redis" in "redis.NewClient")
+// against the last segment of the import paths, or "" when there is none.
+// Major version suffixes (/v9) and a go- prefix are ignored, so
+// github.com/redis/go-redis/v9 is found through "redis".
+func (x *externalIndex) lookup(file, target string) string {
+	qualifier, _, ok := strings.Cut(target, ".")
+	if !ok || qualifier == "" {
+		return ""
+	}
+	imports, ok := x.byFile[file]
+	if !ok {
+		imports = make(map[string]string)
+		for _, dep := range x.store.ByFile(file) {
+			if dep.Kind != facts.KindDependency || dep.Props["source"] != "external" {
+				continue
+			}
+			for _, rel := range dep.Relations {
+				if rel.Kind == facts.RelImports {
+					imports[importQualifier(rel.Target)] = rel.Target
+				}
+			}
+		}
+		x.byFile[file] = imports
+	}
+	return imports[qualifier]
+}
+
+// importQualifier returns the name an import path is usually referred to by
+// in code: its last segment, skipping a major version suffix and dropping a
+// go- prefix.
+func importQualifier(importPath string) string {
+	sep := "/"
+	if !strings.Contains(importPath, "/") {
+		sep = "." // JVM-style imports such as okhttp3.OkHttpClient
+	}
+	segments := strings.Split(importPath, sep)
+	if n := len(segments); n > 1 && isMajorVersion(segments[n-1]) {
+		segments = segments[:n-1]
+	}
+	return strings.TrimPrefix(segments[len(segments)-1], "go-")
+}
+
+// isMajorVersion reports whether s is a Go module major version suffix
+// such as "v2".
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	for _, r := range s[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// exploreSymbol renders a symbol exploration if the focus matches symbol names.
+// Names starting with focus are found through the prefix index; the substring
+// scan only runs when there are none.
 func (s *Server) exploreSymbol(store *facts.Store, focus string, depth int, sb *strings.Builder) bool {
-	results := store.Query(facts.KindSymbol, "", focus, "")
+	var results []facts.Fact
+	for _, f := range store.LookupByPrefix(focus, 0) {
+		if f.Kind == facts.KindSymbol {
+			results = append(results, f)
+		}
+	}
+	if len(results) == 0 {
+		results = store.Query(facts.KindSymbol, "", focus, "")
+	}
 	if len(results) == 0 {
 		return false
 	}
@@ -952,6 +3669,12 @@ func (s *Server) exploreSymbol(store *facts.Store, focus string, depth int, sb *
 		if exp, ok := sym.Props["exported"].(bool); ok {
 			sb.WriteString(fmt.Sprintf("- Exported: %v\n", exp))
 		}
+		if tp, ok := sym.Props["type_params"].(string); ok {
+			sb.WriteString(fmt.Sprintf("- Type params: %s\n", tp))
+		}
+		if doc, ok := sym.Props["doc"].(string); ok && doc != "" {
+			sb.WriteString(fmt.Sprintf("- Doc: %s\n", doc))
+		}
 		sb.WriteString("\n")
 
 		// Relations
@@ -963,6 +3686,24 @@ func (s *Server) exploreSymbol(store *facts.Store, focus string, depth int, sb *
 			sb.WriteString("\n")
 		}
 
+		// Test functions that reference the symbol (Go with go.tests)
+		var tests []string
+		for _, r := range sym.Relations {
+			if r.Kind != facts.RelTestedBy {
+				continue
+			}
+			for _, tf := range store.LookupByExactName(r.Target) {
+				tests = append(tests, fmt.Sprintf("- %s (%s:%d)\n", tf.Name[strings.LastIndex(tf.Name, ".")+1:], tf.File, tf.Line))
+			}
+		}
+		if len(tests) > 0 {
+			sb.WriteString("### Tested By\n\n")
+			for _, t := range tests {
+				sb.WriteString(t)
+			}
+			sb.WriteString("\n")
+		}
+
 		// Resolve relation targets (depth >= 1)
 		if depth >= 1 && len(sym.Relations) > 0 {
 			sb.WriteString("### Related Facts\n\n")
@@ -1034,7 +3775,92 @@ func (s *Server) exploreDirectory(store *facts.Store, focus string, sb *strings.
 
 	sb.WriteString(fmt.Sprintf("# Directory: %s\n\n", focus))
 	sb.WriteString(fmt.Sprintf("Total facts: %d\n\n", total))
+	writeDirectoryContents(sb, dirFacts)
+	return true
+}
+
+// maxGlobDirectories caps the matched directories listed by
+// exploreDirectoryGlob.
+const maxGlobDirectories = 50
+
+// hasGlobMeta reports whether focus contains glob metacharacters.
+func hasGlobMeta(focus string) bool {
+	return strings.ContainsAny(focus, "*?[")
+}
+
+// matchDirectories returns the directories of the store's files that match
+// pattern, in sorted order. Patterns use path.Match syntax, so each '*'
+// matches within a single path segment. In multi-repo mode a pattern without
+// a repo label also matches under every label.
+func (s *Server) matchDirectories(store *facts.Store, pattern string) []string {
+	patterns := []string{pattern}
+	for _, label := range s.repoLabels() {
+		if !strings.HasPrefix(pattern, label+"/") {
+			patterns = append(patterns, label+"/"+pattern)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, file := range store.Files("") {
+		for dir := path.Dir(filepath.ToSlash(file)); dir != "." && dir != "/" && !seen[dir]; dir = path.Dir(dir) {
+			seen[dir] = true
+			for _, p := range patterns {
+				if ok, _ := path.Match(p, dir); ok {
+					dirs = append(dirs, dir)
+					break
+				}
+			}
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// exploreDirectoryGlob renders the directories matching a glob focus such as
+// "internal/*/handler". A single match is explored as a plain directory;
+// several get a table of the matches followed by a combined summary.
+func (s *Server) exploreDirectoryGlob(store *facts.Store, focus string, sb *strings.Builder) bool {
+	dirs := s.matchDirectories(store, strings.TrimSuffix(focus, "/"))
+	if len(dirs) == 0 {
+		return false
+	}
+	if len(dirs) == 1 {
+		return s.exploreDirectory(store, dirs[0], sb)
+	}
+
+	var combined []facts.Fact
+	var rows strings.Builder
+	total := 0
+	for i, dir := range dirs {
+		dirFacts, dirTotal := store.QueryAdvanced(facts.QueryOpts{FilePrefix: dir + "/", Limit: 500})
+		combined = append(combined, dirFacts...)
+		total += dirTotal
+		if i < maxGlobDirectories {
+			files := make(map[string]struct{})
+			for _, f := range dirFacts {
+				files[f.File] = struct{}{}
+			}
+			rows.WriteString(fmt.Sprintf("| %s | %d | %d |\n", dir, len(files), dirTotal))
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("# Directories: %s\n\n", focus))
+	sb.WriteString(fmt.Sprintf("Matched %d directories. Total facts: %d\n\n", len(dirs), total))
+	sb.WriteString("| Directory | Files | Facts |\n")
+	sb.WriteString("|-----------|-------|-------|\n")
+	sb.WriteString(rows.String())
+	if len(dirs) > maxGlobDirectories {
+		sb.WriteString(fmt.Sprintf("\n... and %d more directories\n", len(dirs)-maxGlobDirectories))
+	}
+	sb.WriteString("\n")
+	writeDirectoryContents(sb, combined)
+	return true
+}
 
+// writeDirectoryContents writes the summary, modules and key symbols of the
+// facts under one or more directories.
+func writeDirectoryContents(sb *strings.Builder, dirFacts []facts.Fact) {
 	// Count by kind
 	kindCount := make(map[string]int)
 	files := make(map[string]struct{})
@@ -1047,7 +3873,7 @@ func (s *Server) exploreDirectory(store *facts.Store, focus string, sb *strings.
 
 	sb.WriteString("## Summary\n\n")
 	sb.WriteString(fmt.Sprintf("- Files: %d\n", len(files)))
-	for _, kind := range []string{facts.KindModule, facts.KindSymbol, facts.KindDependency, facts.KindRoute, facts.KindStorage} {
+	for _, kind := range []string{facts.KindModule, facts.KindSymbol, facts.KindDependency, facts.KindRoute, facts.KindStorage, facts.KindConfig} {
 		if c, ok := kindCount[kind]; ok {
 			sb.WriteString(fmt.Sprintf("- %ss: %d\n", capitalize(kind), c))
 		}
@@ -1092,21 +3918,37 @@ func (s *Server) exploreDirectory(store *facts.Store, focus string, sb *strings.
 		}
 		sb.WriteString("\n")
 	}
-
-	return true
 }
 
 // showSymbolArgs are the arguments for the show_symbol tool.
 type showSymbolArgs struct {
-	Name         string `json:"name" jsonschema:"required,Symbol name to look up (substring match)"`
-	ContextLines int    `json:"context_lines,omitempty" jsonschema:"Number of source lines to show around the symbol (default 60)"`
+	Name          string `json:"name" jsonschema:"required,Symbol name to look up (substring match)"`
+	ContextLines  int    `json:"context_lines,omitempty" jsonschema:"Number of source lines to show around the symbol (default 60)"`
+	MaxResults    int    `json:"max_results,omitempty" jsonschema:"Maximum matched symbols to show (default 5, max 50; with locations_only default and max 500)"`
+	LocationsOnly bool   `json:"locations_only,omitempty" jsonschema:"List every match as 'name  file:line' without source code"`
+}
+
+// formatSymbolLocations lists symbol matches one per line as "name  file:line",
+// noting how many were left out when there are more than maxResults.
+func formatSymbolLocations(results []facts.Fact, maxResults int) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d matching symbols\n\n", len(results)))
+	for i, f := range results {
+		if i == maxResults {
+			sb.WriteString(fmt.Sprintf("... and %d more\n", len(results)-maxResults))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("%s  %s:%d\n", f.Name, f.File, f.Line))
+	}
+	return sb.String()
 }
 
 // readSourceWindow reads lines from a file around the given line number.
 // The window is asymmetric: 1/4 of context before the line, 3/4 after,
-// since symbol declarations are at the start of the interesting code.
-func readSourceWindow(absFile string, centerLine, contextLines int) (string, error) {
-	data, err := os.ReadFile(absFile)
+// since symbol declarations are at the start of the interesting code. The
+// file is read through cache, which may be nil.
+func readSourceWindow(cache *sourceCache, absFile string, centerLine, contextLines int) (string, error) {
+	data, err := cache.read(absFile)
 	if err != nil {
 		return "", err
 	}
@@ -1140,16 +3982,17 @@ func capitalize(s string) string {
 
 // normalizeToRelative converts an absolute filesystem path to a store-relative
 // path by stripping known repo root prefixes. If the path is already relative
-// or doesn't match any known repo root, it is returned unchanged.
+// or doesn't match any known repo root, it is returned unchanged. With
+// case_insensitive_paths set, the root prefix is matched ignoring case.
 func (s *Server) normalizeToRelative(p string) string {
 	if !filepath.IsAbs(p) {
 		return p
 	}
+	fold := s.eng.Store().CaseInsensitivePaths()
 
 	// Try multi-repo paths first (populated in append mode).
 	for label, absRoot := range s.eng.RepoPaths() {
-		rel, err := filepath.Rel(absRoot, p)
-		if err == nil && !strings.HasPrefix(rel, "..") {
+		if rel, ok := relToRoot(absRoot, p, fold); ok {
 			// Prefix with repo label so it matches the prefixed fact files.
 			return filepath.ToSlash(filepath.Join(label, rel))
 		}
@@ -1158,8 +4001,7 @@ func (s *Server) normalizeToRelative(p string) string {
 	// Fall back to the single-snapshot repo path.
 	snap := s.eng.Snapshot()
 	if snap != nil {
-		rel, err := filepath.Rel(snap.Meta.RepoPath, p)
-		if err == nil && !strings.HasPrefix(rel, "..") {
+		if rel, ok := relToRoot(snap.Meta.RepoPath, p, fold); ok {
 			return filepath.ToSlash(rel)
 		}
 	}
@@ -1167,6 +4009,23 @@ func (s *Server) normalizeToRelative(p string) string {
 	return p
 }
 
+// relToRoot returns p relative to root when p is inside it. When fold is
+// set, a root prefix differing only in case is accepted.
+func relToRoot(root, p string, fold bool) (string, bool) {
+	if fold {
+		root = filepath.Clean(root)
+		if len(p) >= len(root) && strings.EqualFold(p[:len(root)], root) &&
+			(len(p) == len(root) || p[len(root)] == filepath.Separator) {
+			p = root + p[len(root):]
+		}
+	}
+	rel, err := filepath.Rel(root, p)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return rel, true
+}
+
 // repoLabels returns the known repo labels from multi-repo mode, or nil.
 func (s *Server) repoLabels() []string {
 	if s.eng == nil {
@@ -1226,11 +4085,61 @@ func (s *Server) expandFilePrefix(prefix string) []string {
 	return expanded
 }
 
-func errorResult(msg string) *mcp.CallToolResult {
+// errorCode classifies a tool error so clients can decide what to do next
+// without parsing the message.
+type errorCode string
+
+const (
+	codeNoSnapshot errorCode = "NO_SNAPSHOT" // run generate_snapshot first
+	codeNotFound   errorCode = "NOT_FOUND"   // nothing matched; refine the query
+	codeAmbiguous  errorCode = "AMBIGUOUS"   // several facts matched; qualify the name
+	codeInvalidArg errorCode = "INVALID_ARG" // missing or invalid arguments
+	codeInternal   errorCode = "INTERNAL"    // the server failed; retrying may help
+)
+
+// toolError is an error that carries the code to report it under.
+type toolError struct {
+	code errorCode
+	msg  string
+}
+
+func (e *toolError) Error() string { return e.msg }
+
+// newToolError formats an error message tagged with code.
+func newToolError(code errorCode, format string, args ...any) error {
+	return &toolError{code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+// toolErrorBody is the structured content of an error result.
+type toolErrorBody struct {
+	Error struct {
+		Code    errorCode `json:"code"`
+		Message string    `json:"message"`
+	} `json:"error"`
+}
+
+// errorResult builds an error result. The code appears both as a stable
+// "[CODE] " prefix on the text and as structured content.
+func errorResult(code errorCode, msg string) *mcp.CallToolResult {
+	var body toolErrorBody
+	body.Error.Code = code
+	body.Error.Message = msg
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
-			&mcp.TextContent{Text: msg},
+			&mcp.TextContent{Text: "[" + string(code) + "] " + msg},
 		},
-		IsError: true,
+		StructuredContent: body,
+		IsError:           true,
+	}
+}
+
+// errorResultFrom reports err under its toolError code, or under fallback
+// when err carries none. prefix, if set, is prepended to the message.
+func errorResultFrom(err error, fallback errorCode, prefix string) *mcp.CallToolResult {
+	code := fallback
+	var te *toolError
+	if errors.As(err, &te) {
+		code = te.code
 	}
+	return errorResult(code, prefix+err.Error())
 }
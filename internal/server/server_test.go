@@ -1,14 +1,27 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/dejo1307/archmcp/internal/config"
 	"github.com/dejo1307/archmcp/internal/engine"
+	"github.com/dejo1307/archmcp/internal/extractors/goextractor"
+	"github.com/dejo1307/archmcp/internal/extractors/tsextractor"
 	"github.com/dejo1307/archmcp/internal/facts"
+	"github.com/dejo1307/archmcp/internal/renderers/jsongraph"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 func TestReadSourceWindow(t *testing.T) {
@@ -43,7 +56,7 @@ func TestReadSourceWindow(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := readSourceWindow(path, tt.centerLine, tt.contextLines)
+			got, err := readSourceWindow(nil, path, tt.centerLine, tt.contextLines)
 			if err != nil {
 				t.Fatalf("readSourceWindow: %v", err)
 			}
@@ -73,7 +86,7 @@ func TestReadSourceWindow_SingleLineFile(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	got, err := readSourceWindow(path, 1, 30)
+	got, err := readSourceWindow(nil, path, 1, 30)
 	if err != nil {
 		t.Fatalf("readSourceWindow: %v", err)
 	}
@@ -94,7 +107,7 @@ func TestReadSourceWindow_LineNumberFormat(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	got, err := readSourceWindow(path, 3, 4)
+	got, err := readSourceWindow(nil, path, 3, 4)
 	if err != nil {
 		t.Fatalf("readSourceWindow: %v", err)
 	}
@@ -107,6 +120,52 @@ func TestReadSourceWindow_LineNumberFormat(t *testing.T) {
 	}
 }
 
+func TestSourceCache(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	a, b, c := write("a.go", "aaaa"), write("b.go", "bbbb"), write("c.go", "cccc")
+
+	cache := newSourceCache(2, 1024)
+	for _, path := range []string{a, b, a, c} {
+		if _, err := cache.read(path); err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+	}
+	// b was least recently used when c pushed the cache past 2 files.
+	if _, ok := cache.entries[b]; ok || cache.count() != 2 {
+		t.Errorf("expected b evicted, cached %d files", cache.count())
+	}
+
+	// A changed file is re-read rather than served stale.
+	write("a.go", "changed")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(a, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := cache.read(a); string(got) != "changed" {
+		t.Errorf("read after change = %q, want %q", got, "changed")
+	}
+
+	// Files over the byte budget are read but not cached.
+	small := newSourceCache(10, 5)
+	if got, _ := small.read(a); string(got) != "changed" || small.count() != 0 {
+		t.Errorf("oversized file: got %q, cached %d files", got, small.count())
+	}
+
+	if newSourceCache(0, 1024) != nil {
+		t.Error("max_files 0 should disable the cache")
+	}
+	if got, err := (*sourceCache)(nil).read(c); err != nil || string(got) != "cccc" {
+		t.Errorf("nil cache read = %q, %v", got, err)
+	}
+}
+
 // --- test helpers ---
 
 // newEngineWithSnapshot creates an engine with a fake snapshot pointing at the given repo path.
@@ -200,6 +259,30 @@ func TestExploreModule_NotFound(t *testing.T) {
 	}
 }
 
+func TestExploreModule_Metrics(t *testing.T) {
+	store := populateTestStore()
+	srv := newTestServer(store)
+
+	var sb strings.Builder
+	if !srv.exploreModule(store, "internal/server", 1, &sb) {
+		t.Fatal("exploreModule should find 'internal/server'")
+	}
+	output := sb.String()
+	for _, want := range []string{"## Metrics", "- Fan-in: 0", "- Fan-out: 1", "- Symbols: 3", "- Exported: 2 (67%)"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("missing %q in output:\n%s", want, output)
+		}
+	}
+
+	sb.Reset()
+	if !srv.exploreModule(store, "internal/facts", 1, &sb) {
+		t.Fatal("exploreModule should find 'internal/facts'")
+	}
+	if !strings.Contains(sb.String(), "- Fan-in: 1") {
+		t.Errorf("internal/facts should have fan-in 1, got:\n%s", sb.String())
+	}
+}
+
 func TestExploreModule_Depth2(t *testing.T) {
 	store := populateTestStore()
 	srv := newTestServer(store)
@@ -318,6 +401,107 @@ func TestExploreFile_NotFound(t *testing.T) {
 	}
 }
 
+func TestExploreDirectoryGlob(t *testing.T) {
+	store := facts.NewStore()
+	for _, f := range []string{
+		"services/billing/handler/invoice.go",
+		"services/billing/handler/refund.go",
+		"services/users/handler/users.go",
+		"services/users/store/users.go",
+	} {
+		store.Add(facts.Fact{Kind: facts.KindSymbol, Name: "sym:" + f, File: f, Props: map[string]any{"symbol_kind": facts.SymbolFunc}})
+	}
+	srv := newTestServer(store)
+
+	var sb strings.Builder
+	if !srv.exploreDirectoryGlob(store, "services/*/handler", &sb) {
+		t.Fatal("exploreDirectoryGlob should match the handler directories")
+	}
+	out := sb.String()
+	for _, want := range []string{
+		"# Directories: services/*/handler",
+		"Matched 2 directories. Total facts: 3",
+		"| services/billing/handler | 2 | 2 |",
+		"| services/users/handler | 1 | 1 |",
+		"- Files: 3",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "services/users/store") {
+		t.Error("store directory should not match")
+	}
+
+	sb.Reset()
+	if !srv.exploreDirectoryGlob(store, "services/*/store", &sb) || !strings.Contains(sb.String(), "# Directory: services/users/store") {
+		t.Errorf("a single match should render as a plain directory:\n%s", sb.String())
+	}
+	if srv.exploreDirectoryGlob(store, "lib/*", &sb) {
+		t.Error("no directory matches lib/*")
+	}
+	if hasGlobMeta("internal/server") || !hasGlobMeta("internal/*") {
+		t.Error("hasGlobMeta misclassified a focus")
+	}
+}
+
+func TestExploreNamespace(t *testing.T) {
+	store := facts.NewStore()
+	sym := func(name, file string, rels ...facts.Relation) facts.Fact {
+		return facts.Fact{Kind: facts.KindSymbol, Name: name, File: file, Line: 1,
+			Props: map[string]any{"symbol_kind": facts.SymbolClass}, Relations: rels}
+	}
+	store.Add(
+		sym("Admin::Billing", "app/models/admin/billing.rb"),
+		sym("Admin::Billing::Invoice", "app/models/admin/billing/invoice.rb",
+			facts.Relation{Kind: facts.RelDependsOn, Target: "Admin::Billing"},
+			facts.Relation{Kind: facts.RelDependsOn, Target: "Payments::Gateway"}),
+		sym("Admin::Billing::Invoice#charge", "app/models/admin/billing/invoice.rb",
+			facts.Relation{Kind: facts.RelCalls, Target: "Payments::Gateway#charge"}),
+		sym("Admin::Billing::InvoicesController", "app/controllers/admin/billing/invoices_controller.rb",
+			facts.Relation{Kind: facts.RelDependsOn, Target: "Admin::Billing::Invoice"}),
+		sym("Admin::BillingReport", "app/models/admin/billing_report.rb"),
+		sym("Payments::Gateway", "lib/payments/gateway.rb"),
+		sym("Payments::Gateway#charge", "lib/payments/gateway.rb"),
+		sym("Reports::Monthly", "app/reports/monthly.rb",
+			facts.Relation{Kind: facts.RelDependsOn, Target: "Admin::Billing::Invoice"}),
+	)
+	store.BuildGraph()
+	srv := newTestServer(store)
+
+	var sb strings.Builder
+	if !srv.exploreNamespace(store, "Admin::Billing", 1, &sb) {
+		t.Fatal("exploreNamespace should match Admin::Billing")
+	}
+	out := sb.String()
+	for _, want := range []string{
+		"# Namespace: Admin::Billing",
+		"- Symbols: 4",
+		"- Directories: 3",
+		"- app/controllers/admin/billing (1 symbols)",
+		"| Admin::Billing::Invoice#charge |",
+		"## Dependencies (1 namespaces)",
+		"| Payments | 2 | calls, depends_on | Payments::Gateway, Payments::Gateway#charge |",
+		"## Dependents (1 namespaces)",
+		"| Reports | 1 | depends_on | Reports::Monthly |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Admin::BillingReport") {
+		t.Error("Admin::BillingReport shares a prefix but is not in the namespace")
+	}
+
+	sb.Reset()
+	if !srv.exploreNamespace(store, "Admin::", 1, &sb) || !strings.Contains(sb.String(), "- Symbols: 5") {
+		t.Errorf("a trailing :: should explore a top-level namespace:\n%s", sb.String())
+	}
+	if srv.exploreNamespace(store, "Admin::Billing::Invoice", 1, &sb) {
+		t.Error("a class without nested constants should be left to exploreSymbol")
+	}
+}
+
 func TestExploreSymbol(t *testing.T) {
 	store := populateTestStore()
 	srv := newTestServer(store)
@@ -344,6 +528,26 @@ func TestExploreSymbol(t *testing.T) {
 	}
 }
 
+func TestExploreSymbol_TestedBy(t *testing.T) {
+	store := facts.NewStore()
+	store.Add(
+		facts.Fact{Kind: facts.KindSymbol, Name: "calc.New", File: "calc/calc.go", Line: 3, Relations: []facts.Relation{
+			{Kind: facts.RelTestedBy, Target: "calc.TestNew"},
+		}},
+		facts.Fact{Kind: facts.KindSymbol, Name: "calc.TestNew", File: "calc/calc_test.go", Line: 12, Props: map[string]any{"test": true}},
+	)
+	store.BuildGraph()
+	srv := newTestServer(store)
+
+	var sb strings.Builder
+	if !srv.exploreSymbol(store, "calc.New", 1, &sb) {
+		t.Fatal("exploreSymbol should find calc.New")
+	}
+	if !strings.Contains(sb.String(), "### Tested By\n\n- TestNew (calc/calc_test.go:12)\n") {
+		t.Errorf("missing Tested By section:\n%s", sb.String())
+	}
+}
+
 func TestExploreSymbol_NotFound(t *testing.T) {
 	store := populateTestStore()
 	srv := newTestServer(store)
@@ -355,6 +559,111 @@ func TestExploreSymbol_NotFound(t *testing.T) {
 	}
 }
 
+func populateRouteStore() *facts.Store {
+	store := facts.NewStore()
+	store.Add(
+		facts.Fact{Kind: facts.KindRoute, Name: "/api/users/:id", File: "internal/api/routes.go", Line: 12,
+			Props:     map[string]any{"method": "GET", "framework": "gin", "handler": "h.GetUser"},
+			Relations: []facts.Relation{{Kind: facts.RelDeclares, Target: "internal/api"}}},
+		facts.Fact{Kind: facts.KindRoute, Name: "/api/users", File: "internal/api/routes.go", Line: 13,
+			Props: map[string]any{"method": "POST", "framework": "gin", "handler": "h.CreateUser"}},
+		facts.Fact{Kind: facts.KindSymbol, Name: "internal/api.Handler.GetUser", File: "internal/api/handler.go", Line: 20,
+			Props:     map[string]any{"symbol_kind": "method"},
+			Relations: []facts.Relation{{Kind: facts.RelCalls, Target: "h.repo.Find"}, {Kind: facts.RelCalls, Target: "json.Marshal"}}},
+		facts.Fact{Kind: facts.KindSymbol, Name: "internal/store.Repo.Find", File: "internal/store/repo.go", Line: 8,
+			Props: map[string]any{"symbol_kind": "method"}},
+		facts.Fact{Kind: facts.KindStorage, Name: "users", File: "internal/store/repo.go", Line: 3,
+			Props: map[string]any{"storage_kind": "table"}},
+	)
+	return store
+}
+
+func TestExploreRoute(t *testing.T) {
+	store := populateRouteStore()
+	srv := newTestServer(store)
+
+	var sb strings.Builder
+	if !srv.exploreRoute(store, "/api/users/{id}", &sb) {
+		t.Fatal("exploreRoute should match /api/users/:id via {id}")
+	}
+	output := sb.String()
+	for _, want := range []string{
+		"## GET /api/users/:id",
+		"- Source: internal/api/routes.go:12",
+		"- Handler: h.GetUser",
+		"- **internal/api.Handler.GetUser** — internal/api/handler.go:20",
+		"  - **internal/store.Repo.Find** — internal/store/repo.go:8",
+		"### Storage",
+		"- **users** (table)",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("missing %q in output:\n%s", want, output)
+		}
+	}
+	// An exact match hides routes that only contain the path.
+	if strings.Contains(output, "POST /api/users") {
+		t.Error("exact match should not list the POST /api/users route")
+	}
+}
+
+func TestExploreRoute_MethodFilterAndPartialMatch(t *testing.T) {
+	store := populateRouteStore()
+	srv := newTestServer(store)
+
+	var sb strings.Builder
+	if !srv.exploreRoute(store, "POST /api/users", &sb) {
+		t.Fatal("exploreRoute should match POST /api/users")
+	}
+	if strings.Contains(sb.String(), "GET /api/users/:id") {
+		t.Error("method filter should exclude the GET route")
+	}
+
+	var sb2 strings.Builder
+	if !srv.exploreRoute(store, "/api", &sb2) {
+		t.Fatal("exploreRoute should fall back to partial matches")
+	}
+	if !strings.Contains(sb2.String(), "GET /api/users/:id") || !strings.Contains(sb2.String(), "POST /api/users") {
+		t.Errorf("expected both routes for a partial match, got:\n%s", sb2.String())
+	}
+}
+
+func TestExploreRoute_NotARoute(t *testing.T) {
+	store := populateRouteStore()
+	srv := newTestServer(store)
+
+	var sb strings.Builder
+	if srv.exploreRoute(store, "internal/api", &sb) {
+		t.Error("non-path focus should not be treated as a route")
+	}
+	if srv.exploreRoute(store, "/nowhere", &sb) {
+		t.Error("unknown route should not match")
+	}
+}
+
+func TestExplore_RouteBeforeModuleSubstring(t *testing.T) {
+	cfg := config.Default()
+	eng, _ := engine.New(cfg)
+	s, err := New(eng, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng.Store().Add(populateRouteStore().All()...)
+	eng.Store().Add(facts.Fact{Kind: facts.KindModule, Name: "app/controllers/api/users", File: "app/controllers/api/users"})
+	eng.Store().BuildGraph()
+	cs := connectTestClient(t, s)
+
+	for _, focus := range []string{"/api/users", "POST /api/users"} {
+		res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: "explore", Arguments: map[string]any{"focus": focus}})
+		if err != nil || res.IsError {
+			t.Fatalf("explore %q: %v %v", focus, err, res)
+		}
+		out := res.Content[0].(*mcp.TextContent).Text
+		if !strings.Contains(out, "# Route: "+focus) || strings.Contains(out, "app/controllers/api/users") {
+			t.Errorf("explore %q should show the route view:\n%s", focus, out)
+		}
+	}
+}
+
 func TestExploreDirectory(t *testing.T) {
 	store := populateTestStore()
 	srv := newTestServer(store)
@@ -445,6 +754,30 @@ func TestNormalizeToRelative_MultiRepo(t *testing.T) {
 	}
 }
 
+func TestNormalizeToRelative_CaseInsensitive(t *testing.T) {
+	eng := newEngineWithSnapshot("/Users/me/Development")
+	srv := &Server{eng: eng}
+
+	if got := srv.normalizeToRelative("/users/me/development/go-service"); got != "/users/me/development/go-service" {
+		t.Errorf("case-sensitive normalizeToRelative = %q, want the input unchanged", got)
+	}
+
+	eng.Store().SetCaseInsensitivePaths(true)
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"/users/me/development/go-service/Lib/foo.rb", "go-service/Lib/foo.rb"},
+		{"/USERS/ME/DEVELOPMENT", "."},
+		{"/users/me/development-other/foo", "/users/me/development-other/foo"},
+	}
+	for _, tt := range tests {
+		if got := srv.normalizeToRelative(tt.input); got != tt.want {
+			t.Errorf("normalizeToRelative(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
 // --- Integration tests: original reported use cases ---
 
 // TestScenario_QueryFactsWithFilePrefixCrossRepo simulates the first reported issue:
@@ -1025,6 +1358,26 @@ func TestShowSymbol_PrefersExactMatch(t *testing.T) {
 	}
 }
 
+func TestFormatSymbolLocations(t *testing.T) {
+	results := []facts.Fact{
+		{Name: "internal/a.New", File: "internal/a/a.go", Line: 10},
+		{Name: "internal/b.New", File: "internal/b/b.go", Line: 20},
+		{Name: "internal/c.New", File: "internal/c/c.go", Line: 30},
+	}
+
+	got := formatSymbolLocations(results, 500)
+	for _, want := range []string{"3 matching symbols", "internal/a.New  internal/a/a.go:10", "internal/c.New  internal/c/c.go:30"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+
+	got = formatSymbolLocations(results, 2)
+	if strings.Contains(got, "internal/c.New") || !strings.Contains(got, "... and 1 more") {
+		t.Errorf("expected the third match to be summarized, got:\n%s", got)
+	}
+}
+
 func TestCapitalize(t *testing.T) {
 	tests := []struct {
 		input, want string
@@ -1040,3 +1393,1165 @@ func TestCapitalize(t *testing.T) {
 		}
 	}
 }
+
+func TestCountCycles(t *testing.T) {
+	insights := []facts.Insight{
+		{Title: "Cyclic dependency detected (2 modules)", Evidence: []facts.Evidence{{Fact: "svc-a/pkg/x"}, {Fact: "svc-a/pkg/y"}}},
+		{Title: "Cyclic dependency detected (2 modules)", Evidence: []facts.Evidence{{Fact: "svc-b/pkg/x"}, {Fact: "svc-b/pkg/y"}}},
+		{Title: "Cyclic dependency detected (2 files)", Evidence: []facts.Evidence{{File: "svc-a/pkg/x/a.go"}, {File: "svc-a/pkg/x/b.go"}}},
+		{Title: "Layered architecture detected"},
+	}
+	scoped := []facts.Fact{
+		{Kind: facts.KindModule, Name: "svc-a/pkg/x", File: "svc-a/pkg/x", Repo: "svc-a"},
+		{Kind: facts.KindModule, Name: "svc-a/pkg/y", File: "svc-a/pkg/y", Repo: "svc-a"},
+		{Kind: facts.KindSymbol, Name: "svc-a/pkg/x.A", File: "svc-a/pkg/x/a.go", Repo: "svc-a"},
+	}
+
+	if got := countCycles(insights, nil, false); got != 3 {
+		t.Errorf("unscoped countCycles = %d, want 3", got)
+	}
+	if got := countCycles(insights, scoped, true); got != 2 {
+		t.Errorf("scoped countCycles = %d, want 2", got)
+	}
+}
+
+func TestConfigResponse_JSON(t *testing.T) {
+	data, err := json.Marshal(configResponse{Config: config.Default(), UsingDefaults: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["using_defaults"] != true {
+		t.Errorf("using_defaults = %v, want true", got["using_defaults"])
+	}
+	output, ok := got["output"].(map[string]any)
+	if !ok || output["max_context_tokens"] != float64(16000) {
+		t.Errorf("output = %v, want max_context_tokens 16000", got["output"])
+	}
+	if _, ok := got["extractors"].([]any); !ok {
+		t.Errorf("expected extractors list in %s", data)
+	}
+}
+
+// connectTestClient connects an in-memory MCP client to s.
+func connectTestClient(t *testing.T, s *Server) *mcp.ClientSession {
+	t.Helper()
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := s.mcp.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	cs, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs
+}
+
+func TestResources_ListAndRead(t *testing.T) {
+	cfg := config.Default()
+	eng, _ := engine.New(cfg)
+	eng.SetSnapshot(&facts.Snapshot{
+		Meta:     facts.SnapshotMeta{RepoPath: "/repo", FactCount: 1},
+		Insights: []facts.Insight{{Title: "Layered architecture"}},
+		Artifacts: []facts.Artifact{
+			{Name: "llm_context.md", Content: []byte("# Architecture Snapshot\n"), Type: "text/markdown"},
+		},
+	})
+	s, err := New(eng, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := connectTestClient(t, s)
+	ctx := context.Background()
+
+	list, err := cs.ListResources(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListResources: %v", err)
+	}
+	mimeTypes := make(map[string]string)
+	for _, r := range list.Resources {
+		mimeTypes[r.Name] = r.MIMEType
+	}
+	want := map[string]string{
+		"facts.jsonl":        "application/x-ndjson",
+		"insights.json":      "application/json",
+		"snapshot.meta.json": "application/json",
+		"llm_context.md":     "text/markdown",
+	}
+	for name, mime := range want {
+		if mimeTypes[name] != mime {
+			t.Errorf("resource %s MIME type = %q, want %q", name, mimeTypes[name], mime)
+		}
+	}
+	// json_graph is not enabled by default.
+	if _, ok := mimeTypes["graph.cyto.json"]; ok {
+		t.Error("graph.cyto.json should not be listed when json_graph is disabled")
+	}
+
+	res, err := cs.ReadResource(ctx, &mcp.ReadResourceParams{URI: "arch://snapshot/context"})
+	if err != nil {
+		t.Fatalf("ReadResource: %v", err)
+	}
+	if len(res.Contents) != 1 || res.Contents[0].Text != "# Architecture Snapshot\n" || res.Contents[0].MIMEType != "text/markdown" {
+		t.Errorf("llm_context.md contents = %+v", res.Contents)
+	}
+
+	res, err = cs.ReadResource(ctx, &mcp.ReadResourceParams{URI: "arch://snapshot/insights"})
+	if err != nil {
+		t.Fatalf("ReadResource: %v", err)
+	}
+	if !strings.Contains(res.Contents[0].Text, "Layered architecture") {
+		t.Errorf("insights.json contents = %q", res.Contents[0].Text)
+	}
+}
+
+func TestResources_NoSnapshot(t *testing.T) {
+	cfg := config.Default()
+	eng, _ := engine.New(cfg)
+	s, err := New(eng, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := connectTestClient(t, s)
+
+	_, err = cs.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: "arch://snapshot/facts"})
+	if err == nil || !strings.Contains(err.Error(), "generate_snapshot") {
+		t.Errorf("expected a no-snapshot error, got %v", err)
+	}
+}
+
+func TestNodeInfo(t *testing.T) {
+	store := populateTestStore()
+	store.BuildGraph()
+
+	result, err := nodeInfo(store, "internal/server.Run", "", "")
+	if err != nil {
+		t.Fatalf("nodeInfo: %v", err)
+	}
+	if result.Kind != facts.KindSymbol || result.File != "internal/server/server.go" || result.Line != 45 {
+		t.Errorf("fact = %+v", result.Fact)
+	}
+	if result.Props["symbol_kind"] != "method" {
+		t.Errorf("props = %v", result.Props)
+	}
+	if result.EdgesOut != 2 || result.EdgesOutByKind[facts.RelCalls] != 1 || result.EdgesOutByKind[facts.RelDeclares] != 1 {
+		t.Errorf("outgoing edges = %d %v, want 2 (1 calls, 1 declares)", result.EdgesOut, result.EdgesOutByKind)
+	}
+
+	mod, err := nodeInfo(store, "internal/server", facts.KindModule, "")
+	if err != nil {
+		t.Fatalf("nodeInfo module: %v", err)
+	}
+	if mod.EdgesIn == 0 || mod.EdgesInByKind[facts.RelDeclares] == 0 {
+		t.Errorf("module incoming edges = %d %v, want declares edges", mod.EdgesIn, mod.EdgesInByKind)
+	}
+}
+
+func TestNodeInfo_AmbiguousAndMissing(t *testing.T) {
+	store := facts.NewStore()
+	store.Add(
+		facts.Fact{Kind: facts.KindModule, Name: "users", File: "users", Repo: "api"},
+		facts.Fact{Kind: facts.KindStorage, Name: "users", File: "db/schema.sql", Line: 3, Repo: "api"},
+		facts.Fact{Kind: facts.KindSymbol, Name: "users.Create", File: "users/create.go", Line: 10, Repo: "api"},
+	)
+	store.BuildGraph()
+
+	_, err := nodeInfo(store, "users", "", "")
+	if err == nil || !strings.Contains(err.Error(), "ambiguous") ||
+		!strings.Contains(err.Error(), "users (module, users, repo api)") ||
+		!strings.Contains(err.Error(), "users (storage, db/schema.sql:3, repo api)") {
+		t.Errorf("expected an ambiguity error listing both candidates, got %v", err)
+	}
+
+	if result, err := nodeInfo(store, "users", facts.KindStorage, ""); err != nil || result.Kind != facts.KindStorage {
+		t.Errorf("kind should disambiguate, got %v, %v", result, err)
+	}
+
+	_, err = nodeInfo(store, "users", facts.KindRoute, "")
+	if err == nil || !strings.Contains(err.Error(), "no route facts") {
+		t.Errorf("expected a kind mismatch error, got %v", err)
+	}
+
+	_, err = nodeInfo(store, "Create", "", "")
+	if err == nil || !strings.Contains(err.Error(), "similar names") || !strings.Contains(err.Error(), "users.Create") {
+		t.Errorf("expected similar-name suggestions, got %v", err)
+	}
+}
+
+func TestExpandRelated(t *testing.T) {
+	store := populateTestStore()
+	results := store.LookupByExactName("internal/server.handleQuery")
+
+	names := func(ff []enrichedFact) []string {
+		var out []string
+		for _, f := range ff {
+			out = append(out, f.Name)
+		}
+		return out
+	}
+
+	enriched, truncated := expandRelated(store, results, 1, nil)
+	if truncated || len(enriched) != 1 {
+		t.Fatalf("depth 1: %d results, truncated=%v", len(enriched), truncated)
+	}
+	related := enriched[0].RelatedFacts
+	if got := names(related); len(got) != 2 || got[0] != "internal/server" || got[1] != "internal/facts.Store.Query" {
+		t.Fatalf("depth 1 related = %v", got)
+	}
+	if related[1].RelatedFacts != nil {
+		t.Error("depth 1 should not expand related facts further")
+	}
+
+	enriched, _ = expandRelated(store, results, 2, nil)
+	query := enriched[0].RelatedFacts[1]
+	if got := names(query.RelatedFacts); len(got) != 1 || got[0] != "internal/facts" {
+		t.Errorf("depth 2 related of Store.Query = %v, want [internal/facts]", got)
+	}
+
+	enriched, _ = expandRelated(store, results, 1, []string{facts.RelCalls})
+	if got := names(enriched[0].RelatedFacts); len(got) != 1 || got[0] != "internal/facts.Store.Query" {
+		t.Errorf("related_kinds=[calls] related = %v", got)
+	}
+}
+
+func TestExpandRelated_Cap(t *testing.T) {
+	store := facts.NewStore()
+	hub := facts.Fact{Kind: facts.KindModule, Name: "hub"}
+	for i := 0; i < maxRelatedFacts+50; i++ {
+		name := fmt.Sprintf("hub.Sym%d", i)
+		store.Add(facts.Fact{Kind: facts.KindSymbol, Name: name})
+		hub.Relations = append(hub.Relations, facts.Relation{Kind: facts.RelDeclares, Target: name})
+	}
+	store.Add(hub)
+
+	enriched, truncated := expandRelated(store, store.LookupByExactName("hub"), 2, nil)
+	if !truncated {
+		t.Error("expected the cap to be reported")
+	}
+	if n := len(enriched[0].RelatedFacts); n != maxRelatedFacts {
+		t.Errorf("inlined %d facts, want the cap of %d", n, maxRelatedFacts)
+	}
+}
+
+func TestSuggestNames(t *testing.T) {
+	store := populateTestStore()
+
+	got := suggestNames(store, "internal/server", 3)
+	if !got.HasMore || len(got.Suggestions) != 3 {
+		t.Fatalf("suggestNames = %+v, want 3 suggestions and has_more", got)
+	}
+	want := []string{"internal/server", "internal/server -> internal/facts", "internal/server.New"}
+	for i, s := range got.Suggestions {
+		if s.Name != want[i] {
+			t.Errorf("suggestion %d = %q, want %q", i, s.Name, want[i])
+		}
+	}
+	if kinds := got.Suggestions[0].Kinds; len(kinds) != 1 || kinds[0] != facts.KindModule {
+		t.Errorf("kinds of internal/server = %v", kinds)
+	}
+
+	if got := suggestNames(store, "internal/facts.", 20); got.HasMore || len(got.Suggestions) != 1 {
+		t.Errorf("suggestNames(internal/facts.) = %+v", got)
+	}
+}
+
+func TestResolveNodeName_UniquePrefix(t *testing.T) {
+	store := populateTestStore()
+	srv := newTestServer(store)
+
+	name, err := srv.resolveNodeName(store, "internal/server.handle")
+	if err != nil || name != "internal/server.handleQuery" {
+		t.Errorf("resolveNodeName(internal/server.handle) = %q, %v", name, err)
+	}
+}
+
+func TestToolErrors_CarryCodes(t *testing.T) {
+	cfg := config.Default()
+	eng, _ := engine.New(cfg)
+	s, err := New(eng, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := connectTestClient(t, s)
+	ctx := context.Background()
+
+	call := func(name string, args map[string]any) (errorCode, string) {
+		t.Helper()
+		res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool %s: %v", name, err)
+		}
+		if !res.IsError {
+			t.Fatalf("%s: expected an error result", name)
+		}
+		var body toolErrorBody
+		data, _ := json.Marshal(res.StructuredContent)
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Fatalf("%s: structured content %s: %v", name, data, err)
+		}
+		return body.Error.Code, res.Content[0].(*mcp.TextContent).Text
+	}
+
+	code, text := call("node_info", map[string]any{"name": "x"})
+	if code != codeNoSnapshot || !strings.HasPrefix(text, "[NO_SNAPSHOT] ") {
+		t.Errorf("empty store: code %s, text %q", code, text)
+	}
+
+	eng.Store().Add(populateTestStore().All()...)
+	eng.Store().Add(facts.Fact{Kind: facts.KindStorage, Name: "internal/server"})
+	eng.Store().BuildGraph()
+
+	tests := []struct {
+		tool string
+		args map[string]any
+		want errorCode
+	}{
+		{"node_info", map[string]any{"name": ""}, codeInvalidArg},
+		{"node_info", map[string]any{"name": "internal/nope"}, codeNotFound},
+		{"node_info", map[string]any{"name": "internal/server"}, codeAmbiguous},
+		{"find_path", map[string]any{"from": "nothing-like-this", "to": "internal/facts"}, codeNotFound},
+		{"traverse", map[string]any{"start": "internal/server.New", "direction": "sideways"}, codeInvalidArg},
+		{"query_facts", map[string]any{"line_min": 10}, codeInvalidArg},
+		{"query_facts", map[string]any{"file": "internal/server/server.go", "line_min": 20, "line_max": 10}, codeInvalidArg},
+	}
+	for _, tt := range tests {
+		if code, text := call(tt.tool, tt.args); code != tt.want || !strings.HasPrefix(text, "["+string(tt.want)+"] ") {
+			t.Errorf("%s %v: code %s, text %q; want %s", tt.tool, tt.args, code, text, tt.want)
+		}
+	}
+}
+
+func TestGrepSource(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"internal/a.go": "package a\n\n// TODO: retry\nfunc A() {}\n",
+		"internal/b.go": "package a\n\nfunc B() {} // TODO later\n",
+		"cmd/main.go":   "package main\n// TODO: flags\n",
+		"vendor/x.go":   "// TODO: not indexed\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	eng := newEngineWithSnapshot(dir)
+	store := eng.Store()
+	store.Add(
+		facts.Fact{Kind: facts.KindSymbol, Name: "a.A", File: "internal/a.go", Line: 4},
+		facts.Fact{Kind: facts.KindSymbol, Name: "a.B", File: "internal/b.go", Line: 3},
+		facts.Fact{Kind: facts.KindSymbol, Name: "main", File: "cmd/main.go", Line: 1},
+		facts.Fact{Kind: facts.KindSymbol, Name: "gone", File: "internal/deleted.go", Line: 1},
+	)
+	srv := &Server{eng: eng}
+	re := regexp.MustCompile(`TODO`)
+
+	got, truncated := srv.grepSource(context.Background(), store, re, []string{""}, "", 10)
+	want := []string{
+		"cmd/main.go:2: // TODO: flags",
+		"internal/a.go:3: // TODO: retry",
+		"internal/b.go:3: func B() {} // TODO later",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") || truncated {
+		t.Errorf("grepSource = %q (truncated %v), want %q", got, truncated, want)
+	}
+
+	got, _ = srv.grepSource(context.Background(), store, re, []string{"internal/"}, "", 10)
+	if len(got) != 2 {
+		t.Errorf("file_prefix internal/: got %q", got)
+	}
+
+	got, truncated = srv.grepSource(context.Background(), store, re, []string{""}, "", 2)
+	if len(got) != 2 || !truncated {
+		t.Errorf("max 2: got %q, truncated %v", got, truncated)
+	}
+}
+
+func TestRenderImpactSummary(t *testing.T) {
+	result := facts.ImpactResult{
+		Target: "internal/core",
+		ByDepth: map[int][]facts.TraversalNode{
+			1: {
+				{Name: "internal/api", Kind: facts.KindModule, Depth: 1},
+				{Name: "internal/api.Handler", Kind: facts.KindSymbol, File: "internal/api/handler.go", Depth: 1},
+				{Name: "internal/api.Router", Kind: facts.KindSymbol, File: "internal/api/router.go", Depth: 1},
+			},
+			2: {
+				{Name: "cmd/server.main", Kind: facts.KindSymbol, File: "cmd/server/main.go", Depth: 2},
+			},
+		},
+		Stats: facts.TraversalStats{MaxDepthReached: 2},
+	}
+
+	got := renderImpactSummary(result)
+	for _, want := range []string{
+		"**4 impacted nodes** up to depth 2.",
+		"| 1 | 3 |\n| 2 | 1 |",
+		"| symbol | 3 |\n| module | 1 |",
+		"### Top Affected Modules (2 of 2)",
+		"| `internal/api` | 3 |\n| `cmd/server` | 1 |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary missing %q:\n%s", want, got)
+		}
+	}
+
+	empty := renderImpactSummary(facts.ImpactResult{Target: "x", ByDepth: map[int][]facts.TraversalNode{}})
+	if !strings.Contains(empty, "No dependents found.") {
+		t.Errorf("empty summary = %q", empty)
+	}
+}
+
+func TestServerInfo(t *testing.T) {
+	cfg := config.Default()
+	eng, _ := engine.New(cfg)
+	s, err := New(eng, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := connectTestClient(t, s)
+	ctx := context.Background()
+
+	call := func() serverInfoResponse {
+		t.Helper()
+		res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "server_info"})
+		if err != nil {
+			t.Fatalf("CallTool: %v", err)
+		}
+		if res.IsError {
+			t.Fatalf("unexpected error result: %v", res.Content)
+		}
+		var info serverInfoResponse
+		if err := json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &info); err != nil {
+			t.Fatal(err)
+		}
+		return info
+	}
+
+	info := call()
+	if info.Version != Version || info.SnapshotLoaded || info.FactCount != 0 {
+		t.Errorf("before snapshot: %+v", info)
+	}
+	if !strings.HasPrefix(info.MCPSDKVersion, "v1.") {
+		t.Errorf("mcp_sdk_version = %q, want a v1 module version", info.MCPSDKVersion)
+	}
+
+	eng.Store().Add(facts.Fact{Kind: facts.KindModule, Name: "internal/server"})
+	eng.SetSnapshot(&facts.Snapshot{Meta: facts.SnapshotMeta{RepoPath: "/repo", GeneratedAt: "2026-01-02T03:04:05Z"}})
+	info = call()
+	if !info.SnapshotLoaded || info.FactCount != 1 || info.RepoPath != "/repo" || info.GeneratedAt != "2026-01-02T03:04:05Z" {
+		t.Errorf("after snapshot: %+v", info)
+	}
+}
+
+func TestQueryFacts_Fields(t *testing.T) {
+	cfg := config.Default()
+	eng, _ := engine.New(cfg)
+	s, err := New(eng, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng.Store().Add(
+		facts.Fact{Kind: facts.KindSymbol, Name: "pkg.New", File: "pkg/pkg.go", Line: 7,
+			Props:     map[string]any{"symbol_kind": facts.SymbolFunc, "exported": true},
+			Relations: []facts.Relation{{Kind: facts.RelCalls, Target: "pkg.helper"}}},
+	)
+	cs := connectTestClient(t, s)
+	ctx := context.Background()
+
+	call := func(args map[string]any) (string, bool) {
+		t.Helper()
+		res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "query_facts", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool: %v", err)
+		}
+		return res.Content[0].(*mcp.TextContent).Text, res.IsError
+	}
+
+	text, _ := call(map[string]any{"name": "pkg.New", "fields": []string{"name", "file", "props.symbol_kind"}})
+	var projected []map[string]any
+	if err := json.Unmarshal([]byte(text), &projected); err != nil {
+		t.Fatalf("full mode: %v\n%s", err, text)
+	}
+	want := map[string]any{"name": "pkg.New", "file": "pkg/pkg.go", "props": map[string]any{"symbol_kind": facts.SymbolFunc}}
+	if len(projected) != 1 || !reflect.DeepEqual(projected[0], want) {
+		t.Errorf("full mode = %v, want [%v]", projected, want)
+	}
+
+	text, _ = call(map[string]any{"name": "pkg.New", "output_mode": "compact", "fields": []string{"name", "line", "relations"}})
+	for _, line := range []string{"| Name | Line | Relations |", "|------|------|-----------|", "| pkg.New | 7 | calls pkg.helper |"} {
+		if !strings.Contains(text, line) {
+			t.Errorf("compact mode missing %q:\n%s", line, text)
+		}
+	}
+
+	text, _ = call(map[string]any{"name": "pkg.New", "output_mode": "names", "fields": []string{"name", "props.exported"}})
+	if !strings.Contains(text, "\npkg.New  true\n") {
+		t.Errorf("names mode:\n%s", text)
+	}
+
+	if text, isErr := call(map[string]any{"fields": []string{"signature"}}); !isErr || !strings.Contains(text, `unknown field "signature"`) {
+		t.Errorf("unknown field: isErr=%v %s", isErr, text)
+	}
+}
+
+func TestExploreAndTraverse_ExportedOnly(t *testing.T) {
+	cfg := config.Default()
+	eng, _ := engine.New(cfg)
+	s, err := New(eng, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng.Store().Add(populateTestStore().All()...)
+	eng.Store().BuildGraph()
+	cs := connectTestClient(t, s)
+	ctx := context.Background()
+
+	call := func(name string, args map[string]any) string {
+		t.Helper()
+		res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool: %v", err)
+		}
+		if res.IsError {
+			t.Fatalf("unexpected error result: %v", res.Content)
+		}
+		return res.Content[0].(*mcp.TextContent).Text
+	}
+
+	out := call("explore", map[string]any{"focus": "internal/server", "exported_only": true})
+	if !strings.Contains(out, "internal/server.New") || strings.Contains(out, "handleQuery") {
+		t.Errorf("explore exported_only should drop unexported symbols:\n%s", out)
+	}
+	if out := call("explore", map[string]any{"focus": "internal/server"}); !strings.Contains(out, "handleQuery") {
+		t.Errorf("explore without exported_only should list handleQuery:\n%s", out)
+	}
+
+	var result facts.TraversalResult
+	text := call("traverse", map[string]any{"start": "internal/server", "direction": "reverse", "exported_only": true})
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range result.Nodes {
+		if n.Name == "internal/server.handleQuery" {
+			t.Errorf("traverse exported_only returned an unexported symbol: %v", result.Nodes)
+		}
+	}
+	if len(result.Nodes) < 3 {
+		t.Errorf("traverse should still return the start node and exported symbols: %v", result.Nodes)
+	}
+}
+
+func TestExplainIgnoreTool(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".archmcpignore"), []byte("gen/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default()
+	cfg.Repo = dir
+	eng, _ := engine.New(cfg)
+	s, err := New(eng, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := connectTestClient(t, s)
+	ctx := context.Background()
+
+	res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "explain_ignore", Arguments: map[string]any{"path": "gen/client.go"}})
+	if err != nil || res.IsError {
+		t.Fatalf("explain_ignore: %v %v", err, res)
+	}
+	var exp engine.IgnoreExplanation
+	if err := json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &exp); err != nil {
+		t.Fatal(err)
+	}
+	if !exp.Ignored || exp.Match == nil || exp.Match.Source != ".archmcpignore" || exp.Match.Pattern != "gen/" || exp.Match.Path != "gen" {
+		t.Errorf("explanation = %+v, match %+v", exp, exp.Match)
+	}
+
+	for _, args := range []map[string]any{{"path": ""}, {"path": "x.go", "repo": "nope"}, {"path": "../x.go"}} {
+		res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "explain_ignore", Arguments: args})
+		if err != nil || !res.IsError {
+			t.Errorf("explain_ignore %v: expected an error result, got %v %v", args, err, res)
+		}
+	}
+}
+
+func TestValidateTool(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default()
+	eng, _ := engine.New(cfg)
+	s, err := New(eng, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := connectTestClient(t, s)
+	ctx := context.Background()
+
+	res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "validate"})
+	if err != nil || !res.IsError {
+		t.Fatalf("validate without a snapshot: expected an error result, got %v %v", err, res)
+	}
+
+	eng.Store().Add(
+		facts.Fact{Kind: facts.KindSymbol, Name: "main.main", File: "main.go", Line: 3,
+			Relations: []facts.Relation{{Kind: facts.RelCalls, Target: "main.run"}}},
+		facts.Fact{Kind: facts.KindSymbol, Name: "main.run", File: "deleted.go", Line: 1},
+	)
+	eng.SetSnapshot(&facts.Snapshot{Meta: facts.SnapshotMeta{RepoPath: dir}})
+	res, err = cs.CallTool(ctx, &mcp.CallToolParams{Name: "validate"})
+	if err != nil || res.IsError {
+		t.Fatalf("validate: %v %v", err, res)
+	}
+	var report facts.ValidationReport
+	if err := json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.OK || report.DanglingCount != 0 || report.MissingFileCount != 1 || report.MissingFiles[0] != "deleted.go" {
+		t.Errorf("report = %+v, want only deleted.go missing", report)
+	}
+}
+
+func TestCompareModulesTool(t *testing.T) {
+	cfg := config.Default()
+	eng, _ := engine.New(cfg)
+	s, err := New(eng, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng.Store().Add(populateTestStore().All()...)
+	eng.SetSnapshot(&facts.Snapshot{Insights: []facts.Insight{{
+		Title:    "Cyclic dependency detected (2 modules)",
+		Evidence: []facts.Evidence{{Fact: "internal/facts"}, {Fact: "internal/engine"}},
+	}}})
+	cs := connectTestClient(t, s)
+	ctx := context.Background()
+
+	res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "compare_modules", Arguments: map[string]any{"a": "internal/server", "b": "facts"}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error result: %v", res.Content)
+	}
+	var result compareModulesResult
+	if err := json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &result); err != nil {
+		t.Fatal(err)
+	}
+	want := []facts.ModuleMetrics{
+		{Module: "internal/server", Files: 2, Symbols: 3, Exported: 2, FanOut: 1},
+		{Module: "internal/facts", Files: 1, Symbols: 1, Exported: 1, FanIn: 1, InCycle: true},
+	}
+	if len(result.Modules) != 2 || result.Modules[0] != want[0] || result.Modules[1] != want[1] {
+		t.Errorf("modules = %+v, want %+v", result.Modules, want)
+	}
+
+	res, err = cs.CallTool(ctx, &mcp.CallToolParams{Name: "compare_modules", Arguments: map[string]any{"a": "internal/server", "b": "nope"}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !res.IsError || !strings.HasPrefix(text, "[NOT_FOUND] b: ") {
+		t.Errorf("unknown module: %s", text)
+	}
+	res, err = cs.CallTool(ctx, &mcp.CallToolParams{Name: "compare_modules", Arguments: map[string]any{"a": "internal", "b": "facts"}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !res.IsError || !strings.HasPrefix(text, "[AMBIGUOUS] a: ") {
+		t.Errorf("ambiguous module: %s", text)
+	}
+	res, err = cs.CallTool(ctx, &mcp.CallToolParams{Name: "compare_modules", Arguments: map[string]any{"a": "internal/facts", "b": "facts"}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !res.IsError || !strings.HasPrefix(text, "[INVALID_ARG] ") {
+		t.Errorf("same module twice: %s", text)
+	}
+
+	res, err = cs.CallTool(ctx, &mcp.CallToolParams{Name: "compare_modules", Arguments: map[string]any{"a": "internal/server", "b": "facts", "format": "table"}})
+	if err != nil || res.IsError {
+		t.Fatalf("table format: %v %v", err, res)
+	}
+	table := res.Content[0].(*mcp.TextContent).Text
+	for _, want := range []string{
+		"| Metric | internal/server | internal/facts | Difference |",
+		"| Symbols | 3 | 1 | -2 |",
+		"| Fan-in | 0 | 1 | +1 |",
+		"| In cycle | no | yes | |",
+	} {
+		if !strings.Contains(table, want) {
+			t.Errorf("table missing %q:\n%s", want, table)
+		}
+	}
+}
+
+func TestTraceRequestTool(t *testing.T) {
+	cfg := config.Default()
+	eng, _ := engine.New(cfg)
+	s, err := New(eng, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng.Store().Add(populateRouteStore().All()...)
+	eng.Store().Add(
+		facts.Fact{Kind: facts.KindSymbol, Name: "internal/cache.Get", File: "internal/cache/cache.go", Line: 5,
+			Props:     map[string]any{"symbol_kind": "func"},
+			Relations: []facts.Relation{{Kind: facts.RelCalls, Target: "redis.NewClient"}, {Kind: facts.RelDependsOn, Target: "sessions"}}},
+		facts.Fact{Kind: facts.KindDependency, Name: "internal/cache -> github.com/redis/go-redis/v9", File: "internal/cache/cache.go", Line: 3,
+			Props:     map[string]any{"source": "external"},
+			Relations: []facts.Relation{{Kind: facts.RelImports, Target: "github.com/redis/go-redis/v9"}}},
+		facts.Fact{Kind: facts.KindStorage, Name: "sessions", File: "internal/cache/schema.go", Line: 1,
+			Props: map[string]any{"storage_kind": "table"}},
+		facts.Fact{Kind: facts.KindRoute, Name: "/api/sessions", File: "internal/api/routes.go", Line: 14,
+			Props: map[string]any{"method": "GET", "framework": "gin", "handler": "cache.Get"}},
+	)
+	cs := connectTestClient(t, s)
+	ctx := context.Background()
+
+	res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "trace_request", Arguments: map[string]any{"route": "GET /api/sessions"}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error result: %v", res.Content)
+	}
+	text := res.Content[0].(*mcp.TextContent).Text
+	for _, want := range []string{
+		"# Trace: GET /api/sessions",
+		"GET /api/sessions — internal/api/routes.go:14 (gin)\n└─ internal/cache.Get — internal/cache/cache.go:5\n",
+		"   ├─ [external] redis.NewClient (github.com/redis/go-redis/v9)\n",
+		"   └─ [storage] sessions (table)\n",
+		"### Storage\n\n- **sessions** (table) — internal/cache/schema.go:1",
+		"### External Dependencies\n\n- **github.com/redis/go-redis/v9** — redis.NewClient from internal/cache.Get",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %q in output:\n%s", want, text)
+		}
+	}
+
+	res, err = cs.CallTool(ctx, &mcp.CallToolParams{Name: "trace_request", Arguments: map[string]any{"route": "/api/users/{id}"}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	text = res.Content[0].(*mcp.TextContent).Text
+	if !strings.Contains(text, "└─ internal/api.Handler.GetUser — internal/api/handler.go:20\n   └─ internal/store.Repo.Find — internal/store/repo.go:8\n") {
+		t.Errorf("missing call chain in output:\n%s", text)
+	}
+
+	res, err = cs.CallTool(ctx, &mcp.CallToolParams{Name: "trace_request", Arguments: map[string]any{"route": "/nowhere"}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !res.IsError || !strings.HasPrefix(text, "[NOT_FOUND] ") {
+		t.Errorf("unknown route: %s", text)
+	}
+	res, err = cs.CallTool(ctx, &mcp.CallToolParams{Name: "trace_request", Arguments: map[string]any{"route": "internal/api"}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !res.IsError || !strings.HasPrefix(text, "[INVALID_ARG] ") {
+		t.Errorf("non-path route: %s", text)
+	}
+}
+
+func TestImportQualifier(t *testing.T) {
+	for in, want := range map[string]string{
+		"github.com/redis/go-redis/v9": "redis",
+		"github.com/stripe/stripe-go":  "stripe-go",
+		"axios":                        "axios",
+		"okhttp3.OkHttpClient":         "OkHttpClient",
+	} {
+		if got := importQualifier(in); got != want {
+			t.Errorf("importQualifier(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRenamePreviewTool(t *testing.T) {
+	cfg := config.Default()
+	eng, _ := engine.New(cfg)
+	s, err := New(eng, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	declares := func(pkg string) facts.Relation { return facts.Relation{Kind: facts.RelDeclares, Target: pkg} }
+	eng.Store().Add(
+		facts.Fact{Kind: facts.KindSymbol, Name: "internal/util.Helper", File: "internal/util/util.go", Line: 5,
+			Relations: []facts.Relation{declares("internal/util")}},
+		facts.Fact{Kind: facts.KindSymbol, Name: "internal/util.local", File: "internal/util/util.go", Line: 20,
+			Relations: []facts.Relation{declares("internal/util"), {Kind: facts.RelCalls, Target: "Helper"}}},
+		facts.Fact{Kind: facts.KindSymbol, Name: "internal/api.Serve", File: "internal/api/api.go", Line: 12,
+			Relations: []facts.Relation{declares("internal/api"), {Kind: facts.RelCalls, Target: "util.Helper"}}},
+		facts.Fact{Kind: facts.KindSymbol, Name: "internal/api.Wrapper", File: "internal/api/api.go", Line: 3,
+			Relations: []facts.Relation{declares("internal/api"), {Kind: facts.RelEmbeds, Target: "internal/util.Helper"}, {Kind: facts.RelDependsOn, Target: "internal/util.Helper"}}},
+		// An unqualified Helper outside internal/util is a different symbol.
+		facts.Fact{Kind: facts.KindSymbol, Name: "internal/other.run", File: "internal/other/run.go", Line: 8,
+			Relations: []facts.Relation{declares("internal/other"), {Kind: facts.RelCalls, Target: "Helper"}}},
+	)
+	eng.Store().BuildGraph()
+	cs := connectTestClient(t, s)
+	ctx := context.Background()
+
+	res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "rename_preview", Arguments: map[string]any{"name": "internal/util.Helper"}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error result: %v", res.Content)
+	}
+	var result renamePreviewResult
+	if err := json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Declaration == nil || result.Declaration.File != "internal/util/util.go" || result.Declaration.Line != 5 {
+		t.Errorf("declaration = %+v", result.Declaration)
+	}
+	if result.Total != 3 || len(result.Files) != 2 {
+		t.Fatalf("result = %+v, want 3 references in 2 files", result)
+	}
+	api := result.Files[0]
+	if api.File != "internal/api/api.go" || len(api.References) != 2 ||
+		api.References[0].Name != "internal/api.Wrapper" || !reflect.DeepEqual(api.References[0].Relations, []string{"embeds", "depends_on"}) || api.References[0].Match != "exact" ||
+		api.References[1].Name != "internal/api.Serve" || api.References[1].Match != "short" {
+		t.Errorf("internal/api/api.go references = %+v", api)
+	}
+	if util := result.Files[1]; util.File != "internal/util/util.go" || len(util.References) != 1 || util.References[0].Name != "internal/util.local" {
+		t.Errorf("internal/util/util.go references = %+v", util)
+	}
+
+	res, err = cs.CallTool(ctx, &mcp.CallToolParams{Name: "rename_preview", Arguments: map[string]any{"name": "nope.Nothing"}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !res.IsError || !strings.HasPrefix(text, "[NOT_FOUND] ") {
+		t.Errorf("unknown name: %s", text)
+	}
+}
+
+func TestOutlineTool(t *testing.T) {
+	cfg := config.Default()
+	eng, _ := engine.New(cfg)
+	s, err := New(eng, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sym := func(name, file string, line int, kind string) facts.Fact {
+		return facts.Fact{Kind: facts.KindSymbol, Name: name, File: file, Line: line,
+			Props:     map[string]any{"symbol_kind": kind, "exported": true},
+			Relations: []facts.Relation{{Kind: facts.RelDeclares, Target: path.Dir(file)}}}
+	}
+	eng.Store().Add(
+		sym("web/src.UserService.save", "web/src/user.ts", 14, facts.SymbolMethod),
+		sym("web/src.UserService", "web/src/user.ts", 5, facts.SymbolClass),
+		sym("web/src.UserService.load", "web/src/user.ts", 8, facts.SymbolMethod),
+		sym("web/src.formatUser", "web/src/user.ts", 30, facts.SymbolFunc),
+		sym("web/src.Config", "web/src/user.ts", 1, facts.SymbolInterface),
+		facts.Fact{Kind: facts.KindDependency, Name: "web/src/user.ts -> react", File: "web/src/user.ts", Line: 1},
+		// A Go method whose receiver type lives in another file.
+		sym("internal/store.Store.Get", "internal/store/get.go", 3, facts.SymbolMethod),
+	)
+	cs := connectTestClient(t, s)
+	ctx := context.Background()
+
+	outline := func(file string) outlineResult {
+		t.Helper()
+		res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "outline", Arguments: map[string]any{"file": file}})
+		if err != nil {
+			t.Fatalf("CallTool: %v", err)
+		}
+		if res.IsError {
+			t.Fatalf("unexpected error result: %v", res.Content)
+		}
+		var result outlineResult
+		if err := json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	var got []string
+	var walk func(nodes []*outlineNode, indent string)
+	walk = func(nodes []*outlineNode, indent string) {
+		for _, n := range nodes {
+			got = append(got, fmt.Sprintf("%s%s %s:%d", indent, n.Kind, n.Name, n.Line))
+			walk(n.Children, indent+"  ")
+		}
+	}
+	result := outline("web/src/user")
+	if result.File != "web/src/user.ts" {
+		t.Errorf("file = %q, want web/src/user.ts", result.File)
+	}
+	walk(result.Symbols, "")
+	want := []string{
+		"interface Config:1",
+		"class UserService:5",
+		"  method load:8",
+		"  method save:14",
+		"function formatUser:30",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("outline =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if q := result.Symbols[1].Children[0].Qualified; q != "web/src.UserService.load" {
+		t.Errorf("qualified = %q", q)
+	}
+
+	if result := outline("internal/store/get.go"); len(result.Symbols) != 1 || result.Symbols[0].Name != "Store.Get" {
+		t.Errorf("go outline = %+v", result.Symbols)
+	}
+
+	res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "outline", Arguments: map[string]any{"file": "missing.go"}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !res.IsError || !strings.HasPrefix(text, "[NOT_FOUND] ") {
+		t.Errorf("missing file: %s", text)
+	}
+}
+
+func TestSubgraphTool(t *testing.T) {
+	cfg := config.Default()
+	eng, _ := engine.New(cfg)
+	s, err := New(eng, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(name, target string) facts.Fact {
+		return facts.Fact{Kind: facts.KindSymbol, Name: name, File: "app/app.go",
+			Relations: []facts.Relation{{Kind: facts.RelCalls, Target: target}}}
+	}
+	// handler -> service -> repo -> db, plus an unrelated cli -> flags.
+	eng.Store().Add(
+		call("app.handler", "app.service"),
+		call("app.service", "app.repo"),
+		call("app.repo", "app.db"),
+		facts.Fact{Kind: facts.KindSymbol, Name: "app.db", File: "app/app.go"},
+		call("app.cli", "app.flags"),
+		facts.Fact{Kind: facts.KindSymbol, Name: "app.flags", File: "app/app.go"},
+	)
+	eng.Store().BuildGraph()
+	cs := connectTestClient(t, s)
+	ctx := context.Background()
+
+	subgraph := func(args map[string]any) string {
+		t.Helper()
+		res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "subgraph", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool: %v", err)
+		}
+		if res.IsError {
+			t.Fatalf("unexpected error result: %s", res.Content[0].(*mcp.TextContent).Text)
+		}
+		return res.Content[0].(*mcp.TextContent).Text
+	}
+
+	var doc jsongraph.Document
+	if err := json.Unmarshal([]byte(subgraph(map[string]any{"focus": "app.service", "depth": 1, "format": "json"})), &doc); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, n := range doc.Nodes {
+		ids = append(ids, n.ID)
+	}
+	sort.Strings(ids)
+	if want := []string{"app.handler", "app.repo", "app.service"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("depth 1 nodes = %v, want %v", ids, want)
+	}
+	if len(doc.Edges) != 2 {
+		t.Errorf("edges = %v, want handler -> service and service -> repo", doc.Edges)
+	}
+
+	dot := subgraph(map[string]any{"focus": "app.service", "format": "dot"})
+	if !strings.Contains(dot, `"app.repo" -> "app.db" [label="calls"];`) || strings.Contains(dot, "app.cli") {
+		t.Errorf("depth 2 DOT should reach app.db and leave out app.cli:\n%s", dot)
+	}
+	if mermaid := subgraph(map[string]any{"focus": "app.service"}); !strings.HasPrefix(mermaid, "flowchart LR\n") {
+		t.Errorf("default format should be mermaid:\n%s", mermaid)
+	}
+
+	res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "subgraph", Arguments: map[string]any{"focus": "app.service", "format": "png"}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if !res.IsError {
+		t.Error("an unknown format should be rejected")
+	}
+}
+
+func TestSetExtractorsTool(t *testing.T) {
+	cfg := config.Default()
+	eng, _ := engine.New(cfg)
+	eng.RegisterExtractor(goextractor.New())
+	eng.RegisterExtractor(tsextractor.New())
+	s, err := New(eng, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := connectTestClient(t, s)
+	ctx := context.Background()
+
+	res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "set_extractors", Arguments: map[string]any{"enabled": []string{"go"}}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error result: %s", res.Content[0].(*mcp.TextContent).Text)
+	}
+	var result setExtractorsResult
+	if err := json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &result); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Enabled, []string{"go"}) || !reflect.DeepEqual(result.Registered, []string{"go", "typescript"}) {
+		t.Errorf("result = %+v, want go enabled of go and typescript", result)
+	}
+	if cfg.IsExtractorEnabled("typescript") {
+		t.Error("typescript should be disabled in the config")
+	}
+
+	for _, enabled := range [][]string{{"go", "cobol"}, {}} {
+		res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "set_extractors", Arguments: map[string]any{"enabled": enabled}})
+		if err != nil {
+			t.Fatalf("CallTool: %v", err)
+		}
+		if text := res.Content[0].(*mcp.TextContent).Text; !res.IsError || !strings.Contains(text, string(codeInvalidArg)) {
+			t.Errorf("enabled %v: got %q, want an invalid argument error", enabled, text)
+		}
+	}
+	if !reflect.DeepEqual(cfg.Extractors, []string{"go"}) {
+		t.Errorf("rejected lists should leave the config unchanged, got %v", cfg.Extractors)
+	}
+}
+
+func TestShowConfig_WhileSettingExtractors(t *testing.T) {
+	cfg := config.Default()
+	eng, _ := engine.New(cfg)
+	eng.RegisterExtractor(goextractor.New())
+	eng.RegisterExtractor(tsextractor.New())
+	s, err := New(eng, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := connectTestClient(t, s)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			enabled := []string{"go"}
+			if i%2 == 1 {
+				enabled = []string{"go", "typescript"}
+			}
+			if _, err := eng.SetExtractors(enabled); err != nil {
+				t.Errorf("SetExtractors: %v", err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "show_config", Arguments: map[string]any{}})
+		if err != nil {
+			t.Fatalf("CallTool: %v", err)
+		}
+		var resp struct {
+			Extractors []string `json:"extractors"`
+		}
+		if err := json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Extractors) == 0 || resp.Extractors[0] != "go" {
+			t.Errorf("extractors = %v, want a list set_extractors installed", resp.Extractors)
+		}
+	}
+	wg.Wait()
+}
+
+func TestGenerateSnapshot_RepoRoot(t *testing.T) {
+	root := t.TempDir()
+	svc := filepath.Join(root, "svc")
+	os.MkdirAll(svc, 0o755)
+	if err := os.WriteFile(filepath.Join(svc, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Default()
+	cfg.RepoRoot = root
+	eng, _ := engine.New(cfg)
+	eng.RegisterExtractor(goextractor.New())
+	s, err := New(eng, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := connectTestClient(t, s)
+	ctx := context.Background()
+
+	res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "generate_snapshot", Arguments: map[string]any{"repo_path": "svc"}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; res.IsError || !strings.Contains(text, "Repository: "+svc) {
+		t.Fatalf("generate_snapshot(svc) = %q, want the repo resolved under repo_root", text)
+	}
+
+	res, err = cs.CallTool(ctx, &mcp.CallToolParams{Name: "generate_snapshot", Arguments: map[string]any{"repo_path": "missing"}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !res.IsError || !strings.Contains(text, string(codeInvalidArg)) || !strings.Contains(text, filepath.Join(root, "missing")) {
+		t.Errorf("generate_snapshot(missing) = %q, want an invalid argument error naming the resolved path", text)
+	}
+}
+
+func TestHierarchyTool(t *testing.T) {
+	cfg := config.Default()
+	eng, _ := engine.New(cfg)
+	s, err := New(eng, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng.Store().Add(
+		facts.Fact{Kind: facts.KindSymbol, Name: "app.Repository", File: "app/repo.py"},
+		facts.Fact{Kind: facts.KindSymbol, Name: "app.SQLRepository", File: "app/sql.py",
+			Relations: []facts.Relation{{Kind: facts.RelImplements, Target: "app.Repository"}}},
+		facts.Fact{Kind: facts.KindSymbol, Name: "app.CachedSQLRepository", File: "app/cache.py",
+			Relations: []facts.Relation{{Kind: facts.RelImplements, Target: "app.SQLRepository"}}},
+	)
+	eng.Store().BuildGraph()
+	cs := connectTestClient(t, s)
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: "hierarchy", Arguments: map[string]any{"type": "app.Repository"}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error result: %s", res.Content[0].(*mcp.TextContent).Text)
+	}
+	var result facts.HierarchyResult
+	if err := json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.DescendantCount != 2 || len(result.Descendants) != 1 || len(result.Descendants[0].Children) != 1 ||
+		result.Descendants[0].Children[0].Name != "app.CachedSQLRepository" {
+		t.Errorf("descendants = %+v, want SQLRepository then CachedSQLRepository", result.Descendants)
+	}
+
+	res, err = cs.CallTool(context.Background(), &mcp.CallToolParams{Name: "hierarchy", Arguments: map[string]any{"type": "app.NoSuchType"}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !res.IsError || !strings.Contains(text, string(codeNotFound)) {
+		t.Errorf("unknown type: got %q, want a not found error", text)
+	}
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"container/list"
+	"os"
+	"sync"
+	"time"
+)
+
+// sourceCache is an LRU cache of source file contents keyed by absolute
+// path, so agents reading the same hot files through show_symbol and
+// grep_source don't hit the disk on every call. An entry is re-read when the
+// file's size or modification time changes. A nil cache reads from disk.
+type sourceCache struct {
+	mu       sync.Mutex
+	maxFiles int
+	maxBytes int64
+	bytes    int64
+	order    *list.List // of *cachedSource, most recently used first
+	entries  map[string]*list.Element
+}
+
+type cachedSource struct {
+	path    string
+	size    int64
+	modTime time.Time
+	data    []byte
+}
+
+// newSourceCache returns a cache holding at most maxFiles files and maxBytes
+// bytes of content, or nil when either limit is zero.
+func newSourceCache(maxFiles int, maxBytes int64) *sourceCache {
+	if maxFiles <= 0 || maxBytes <= 0 {
+		return nil
+	}
+	return &sourceCache{
+		maxFiles: maxFiles,
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// read returns the contents of absFile, from the cache when the file is
+// unchanged since it was cached. The returned slice must not be modified.
+func (c *sourceCache) read(absFile string) ([]byte, error) {
+	if c == nil {
+		return os.ReadFile(absFile)
+	}
+	info, err := os.Stat(absFile)
+	if err != nil {
+		c.remove(absFile)
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if el, ok := c.entries[absFile]; ok {
+		entry := el.Value.(*cachedSource)
+		if entry.size == info.Size() && entry.modTime.Equal(info.ModTime()) {
+			c.order.MoveToFront(el)
+			c.mu.Unlock()
+			return entry.data, nil
+		}
+	}
+	c.mu.Unlock()
+
+	data, err := os.ReadFile(absFile)
+	if err != nil {
+		c.remove(absFile)
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeLocked(absFile)
+	// Files larger than the whole budget are served but not cached.
+	if int64(len(data)) > c.maxBytes {
+		return data, nil
+	}
+	c.entries[absFile] = c.order.PushFront(&cachedSource{
+		path:    absFile,
+		size:    info.Size(),
+		modTime: info.ModTime(),
+		data:    data,
+	})
+	c.bytes += int64(len(data))
+	for c.order.Len() > c.maxFiles || c.bytes > c.maxBytes {
+		c.removeLocked(c.order.Back().Value.(*cachedSource).path)
+	}
+	return data, nil
+}
+
+func (c *sourceCache) remove(absFile string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeLocked(absFile)
+}
+
+func (c *sourceCache) removeLocked(absFile string) {
+	el, ok := c.entries[absFile]
+	if !ok {
+		return
+	}
+	c.order.Remove(el)
+	delete(c.entries, absFile)
+	c.bytes -= int64(len(el.Value.(*cachedSource).data))
+}
+
+// count returns the number of cached files.
+func (c *sourceCache) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}